@@ -1,6 +1,8 @@
 package main
 
 import (
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
 	"sort"
@@ -22,6 +24,14 @@ func ExamineFile(filename string, debug bool) error {
 
 	m, err := mapparser.ParseMapFile(filename)
 	if err != nil {
+		var pe *mapparser.ParseError
+		if errors.As(err, &pe) {
+			fmt.Printf("Parsing stopped at offset %d (0x%x) of %d bytes\n", pe.Offset, pe.Offset, info.Size())
+			if debug {
+				dumpAround(filename, pe.Offset)
+			}
+			fmt.Println()
+		}
 		return fmt.Errorf("parsing map: %w", err)
 	}
 
@@ -30,6 +40,23 @@ func ExamineFile(filename string, debug bool) error {
 	return nil
 }
 
+// dumpAround prints a hex dump of the bytes surrounding offset in a file.
+func dumpAround(filename string, offset int) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return
+	}
+	start := max(0, offset-32) &^ 15
+	end := min(len(data), offset+32)
+	if start >= end {
+		return
+	}
+	dump := hex.Dumper(os.Stdout)
+	fmt.Printf("Bytes from offset 0x%x:\n", start)
+	_, _ = dump.Write(data[start:end])
+	_ = dump.Close()
+}
+
 // displayMap outputs the parsed map structure
 func displayMap(m *mapparser.MudletMap, debug bool) {
 	// Version
@@ -69,7 +96,9 @@ func displayMap(m *mapparser.MudletMap, debug bool) {
 
 	// MapSymbolFont
 	fmt.Printf("mapSymbolFont QFont:\n")
-	fmt.Printf("  (parsed)\n")
+	f := m.MapSymbolFont
+	fmt.Printf("  family = %q, pointSize = %g, pixelSize = %d, weight = %d, style = %d\n",
+		f.Family, f.PointSizeF, f.PixelSize, f.Weight, f.Style)
 
 	// MapFontFudgeFactor
 	fmt.Printf("mapFontFudgeFactor:\n")
@@ -150,9 +179,23 @@ func displayMap(m *mapparser.MudletMap, debug bool) {
 		}
 	}
 
+	if debug {
+		fmt.Printf("\nFormat layout for version %d:\n", m.Version)
+		printSchema(mapparser.MapSchema(m.Version), "  ")
+	}
+
 	fmt.Println()
 }
 
+// printSchema lists the fields of a map format layout, indenting the
+// per-element fields of collections.
+func printSchema(fields []mapparser.SchemaField, indent string) {
+	for _, f := range fields {
+		fmt.Printf("%s%s %s\n", indent, f.Name, f.Type)
+		printSchema(f.Fields, indent+"  ")
+	}
+}
+
 // formatRoom returns a compact string representation of a room
 func formatRoom(room *mapparser.MudletRoom) string {
 	exitNames := []string{"n", "ne", "e", "se", "s", "sw", "w", "nw", "up", "down", "in", "out"}
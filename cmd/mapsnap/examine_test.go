@@ -85,6 +85,8 @@ func TestExamineSmallMapDebug(t *testing.T) {
 		"area=-1 pos=(0,-1,0)",
 		"area=-1 pos=(0,0,0)",
 		"name='Przestronny korytarz.'",
+		"Format layout for version 20:",
+		"    customLinesStyle QMap<QString,int>",
 	}
 
 	for _, expected := range expectedStrings {
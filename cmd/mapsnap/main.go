@@ -2,12 +2,21 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"image"
+	_ "image/png"
+	"maps"
 	"os"
+	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/szydell/mudlet-mapsnap/pkg/assets"
 	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
 	"github.com/szydell/mudlet-mapsnap/pkg/maprenderer"
 )
@@ -20,20 +29,77 @@ func main() {
 	// Define command line flags
 	mapFile := flag.String("map", "", "Path to the Mudlet map file (.map)")
 	roomID := flag.Int("room", 0, "Room ID to center the map on")
+	centerLabel := flag.String("center-label", "", "Center the map on the label with this text")
+	centerArea := flag.String("center-area", "", "Center the map on an area (ID or name)")
+	sheetArea := flag.Bool("levels", false, "With -center-area, render all the area's levels side by side with captions")
+	stackArea := flag.Bool("stack", false, "With -center-area, render all the area's levels as one 2.5D stack")
+	fitArea := flag.Bool("fit-area", false, "With -center-area, render the area's whole level scaled to fit")
+	viewport := flag.String("viewport", "", "With -center-area, render the map window MINX,MINY,MAXX,MAXY of -level (default 0)")
+	route := flag.String("route", "", "Render the shortest route FROM:TO (room IDs) as a stitched image")
+	level := flag.Int("level", 0, "With -room, render this z-level of the room's area instead of the room's own; with -viewport, the window's level")
+	routeTo := flag.Int("route-to", 0, "With -room, draw the route from that room to this one with numbered steps; not with -level")
+	terrainFile := flag.String("terrain", "", "YAML terrain cost profile for -route, also outlined on rooms")
 	outputFile := flag.String("output", "", "Output file path")
 	dumpJSON := flag.String("dump-json", "", "Dump map to JSON file")
+	dumpJSONAreas := flag.String("dump-json-areas", "", "Dump each area to its own JSON file in this directory")
+	jsonCompact := flag.Bool("json-compact", false, "Write JSON without indentation")
+	jsonNoPixmaps := flag.Bool("json-no-pixmaps", false, "Omit label images from JSON output")
+	exportLabels := flag.String("export-labels", "", "Export label images and manifest to directory")
 	validate := flag.Bool("validate", false, "Validate map integrity")
 	showStats := flag.Bool("stats", false, "Show map statistics")
 	debug := flag.Bool("debug", false, "Enable debug output")
+	info := flag.Bool("info", false, "Show version, areas and room counts without parsing rooms")
 	examine := flag.Bool("examine", false, "Examine Qt/MudletMap binary structure with offsets")
 	timeout := flag.Int("timeout", 30, "Timeout in seconds for parsing operations")
+	cacheFile := flag.String("cache", "", "Cache the parsed map in this file and reuse it while the map file is unchanged")
+	zRange := flag.String("z-range", "", "Keep only rooms and labels on z-levels MIN:MAX, or on a single level")
+	normalize := flag.Bool("normalize", false, "Move each area's rooms to start at the origin before any output")
+	compactIDs := flag.Bool("compact-ids", false, "With -normalize, also renumber rooms 1..n without gaps")
 
 	// Rendering options
 	imgWidth := flag.Int("width", 800, "Output image width")
 	imgHeight := flag.Int("height", 600, "Output image height")
 	roomSize := flag.Int("room-size", 20, "Room size in pixels")
 	roomSpacing := flag.Int("room-spacing", 25, "Room spacing in pixels")
+	exitWidth := flag.Float64("exit-width", 2, "Exit line width in pixels")
+	zoom := flag.Float64("zoom", 1, "Scale room size, spacing, exits and room text together")
+	pixelRatio := flag.Float64("pixel-ratio", 1, "Image pixels per layout pixel, e.g. 2 for HiDPI displays (scales the image and everything in it)")
+	parallelism := flag.Int("parallelism", 0, "Draw the map in this many stripes at once (default: all cores for images of 1 megapixel or more)")
+	superSample := flag.Int("supersample", 1, "Draw the map this many times larger and scale it down for smooth edges (2 or 4)")
 	roundRooms := flag.Bool("round", false, "Draw rooms as circles")
+	otherLevels := flag.Int("other-levels", 0, "Draw this many levels above and below faded under the rendered one")
+	gridMode := flag.Bool("grid-mode", false, "Draw all areas as packed tiles without exits, like Mudlet's grid mode")
+	labelAreaExits := flag.Bool("label-area-exits", false, "Print the destination area name next to area exit arrows")
+	areaGhosts := flag.Bool("area-ghosts", false, "Draw the rooms past exits to other areas faded, with the area name")
+	plainOneWay := flag.Bool("plain-one-way", false, "Draw one-way exits like two-way ones, without arrowheads")
+	oneWayColor := flag.String("one-way-color", "", "Color of one-way exits, #rrggbb[aa]")
+	oneWayDash := flag.String("one-way-dash", "", "Comma-separated on,off lengths of one-way exit lines in pen widths")
+	oneWayArrow := flag.Float64("one-way-arrow", 0, "One-way exit arrowhead length in pixels (0 = room-sized, negative = none)")
+	bundleExits := flag.Bool("bundle-exits", false, "Draw parallel connections between two rooms side by side")
+	specialExits := flag.Bool("special-exits", false, "Draw special exits as dashed curves between rooms in view")
+	specialLabels := flag.Bool("special-exit-labels", false, "Print the command of each special exit drawn")
+	smoothLines := flag.Bool("smooth-lines", false, "Draw custom exit lines as smooth curves")
+	curveTension := flag.Float64("curve-tension", 0, "Curve tension for -smooth-lines, 0 (round) to 1 (straight)")
+	showGrid := flag.Bool("grid", false, "Draw a faint coordinate grid")
+	showAxes := flag.Bool("axes", false, "Draw coordinate ticks along the image edges")
+	showLegend := flag.Bool("legend", false, "Draw a legend explaining doors and exit markings")
+	showTitle := flag.Bool("title", false, "Draw a title bar with the area name and level")
+	showCompass := flag.Bool("compass", false, "Draw a north arrow in the top-right corner")
+	showScale := flag.Bool("scale", false, "Draw a scale bar with the rooms per 100 pixels")
+	showAttribution := flag.Bool("attribution", false, "Print the map's author and license in the image corner")
+	showFlags := flag.Bool("flags", false, "Draw room flag badges (no-PK, indoors, water, locked)")
+	valuesFile := flag.String("values", "", "JSON file mapping room IDs to numbers printed on the rooms")
+	markersFile := flag.String("markers", "", "JSON file of room ID -> marker (ring, fill, icon or tag) drawn on the rooms")
+	roomNames := flag.String("room-names", "", "Print room names next to rooms: below, above or right")
+	roomNameLen := flag.Int("room-name-len", 0, "With -room-names, shorten names to this many characters")
+	weights := flag.String("weights", "off", "Color exits or rooms by pathfinding weight: off, exits or rooms")
+	weightMax := flag.Int("weight-max", 0, "With -weights, the weight colored red (0 = highest in view)")
+	weightColors := flag.String("weight-colors", "", "With -weights, comma-separated gradient colors from weight 1 up")
+	writeManifest := flag.Bool("manifest", false, "Write a reproducibility manifest next to the output image")
+	writeHTML := flag.Bool("html", false, "Write an HTML viewer page next to the output image")
+	configFile := flag.String("config", "", "JSON or YAML file with rendering settings; flags given override it")
+	theme := flag.String("theme", "", "Color theme name (dark, light, high-contrast, colorblind, print or one from -assets)")
+	assetsDir := flag.String("assets", "", "Directory with assets overriding the built-in font, themes and viewer")
 
 	// Parse flags
 	flag.Parse()
@@ -57,6 +123,14 @@ func main() {
 		os.Exit(1)
 	}
 
+	given := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { given[f.Name] = true })
+	// A route is drawn on the room's own level
+	if given["level"] && *routeTo > 0 {
+		fmt.Println("Error: -level cannot be combined with -route-to")
+		os.Exit(1)
+	}
+
 	// Examine file if requested
 	if *examine {
 		fmt.Printf("Examining map file: %s\n", *mapFile)
@@ -70,6 +144,22 @@ func main() {
 		os.Exit(0)
 	}
 
+	// Print header summary if requested
+	if *info {
+		mi, err := mapparser.InspectMapFile(*mapFile)
+		if err != nil {
+			fmt.Printf("Error inspecting map file: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Map version: %d\n", mi.Version)
+		fmt.Printf("Rooms: %d\n", mi.RoomCount)
+		fmt.Printf("Areas: %d\n", mi.AreaCount)
+		for _, a := range mi.Areas {
+			fmt.Printf("  %6d  %-40s %d rooms\n", a.ID, a.Name, a.RoomCount)
+		}
+		os.Exit(0)
+	}
+
 	// Create a context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(*timeout)*time.Second)
 	defer cancel()
@@ -81,9 +171,16 @@ func main() {
 	})
 
 	// Parse map file in a goroutine
+	var report mapparser.ParseReport
 	go func() {
 		fmt.Printf("Parsing map file: %s (timeout: %d seconds)\n", *mapFile, *timeout)
-		m, err := mapparser.ParseMapFile(*mapFile)
+		var m *mapparser.Map
+		var err error
+		if *cacheFile != "" {
+			m, err = mapparser.ParseMapFileCached(*mapFile, *cacheFile)
+		} else {
+			m, err = mapparser.ParseMapFileWithOptions(*mapFile, &mapparser.ParseOptions{Report: &report})
+		}
 		resultCh <- struct {
 			m   *mapparser.Map
 			err error
@@ -109,10 +206,35 @@ func main() {
 	fmt.Printf("Map parsed successfully. Found %d rooms, %d areas, %d environments.\n",
 		len(m.Rooms), len(m.Areas), len(m.EnvColors)+len(m.CustomEnvColors))
 
+	// Narrow the map to a z-level window for all further output
+	if *zRange != "" {
+		minZ, maxZ, err := parseZRange(*zRange)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if m, err = mapparser.FilterZRange(m, minZ, maxZ); err != nil {
+			fmt.Printf("Error filtering z-levels: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Kept z-levels %d..%d: %d rooms in %d areas.\n", minZ, maxZ, len(m.Rooms), len(m.Areas))
+	}
+
+	if *normalize {
+		res := mapparser.Normalize(m, &mapparser.NormalizeOptions{CompactRoomIDs: *compactIDs})
+		fmt.Printf("Normalized map: moved %d areas, renumbered %d rooms.\n", len(res.Offsets), len(res.RoomIDs))
+	}
+
 	// Print debug information if requested
 	if *debug {
 		fmt.Println("\nDebug Information:")
 		fmt.Printf("Map Version: %d\n", m.Version)
+		if len(report.Sections) > 0 {
+			fmt.Printf("\nParse Report (%d bytes in %s):\n", report.Bytes, report.Duration.Round(time.Millisecond))
+			for _, s := range report.Sections {
+				fmt.Printf("  %s\n", s)
+			}
+		}
 
 		// Print first 5 rooms for debugging
 		fmt.Println("\nSample Rooms:")
@@ -135,7 +257,7 @@ func main() {
 		if len(errors) > 0 {
 			fmt.Printf("Found %d validation errors:\n", len(errors))
 			for i, err := range errors {
-				fmt.Printf("%d. %s: %s\n", i+1, err.Type, err.Message)
+				fmt.Printf("%d. [%s] %s: %s\n", i+1, err.Severity, err.Type, err.Message)
 			}
 		} else {
 			fmt.Println("Map validation passed. No errors found.")
@@ -154,6 +276,14 @@ func main() {
 			stats.BoundingBox.MinX, stats.BoundingBox.MaxX,
 			stats.BoundingBox.MinY, stats.BoundingBox.MaxY,
 			stats.BoundingBox.MinZ, stats.BoundingBox.MaxZ)
+		if a := stats.Attribution; a != nil {
+			fmt.Printf("Author: %s\n", a.Author)
+			fmt.Printf("License: %s\n", a.License)
+			fmt.Printf("Source: %s\n", a.SourceURL)
+		}
+		if fp, err := mapparser.Fingerprint(m); err == nil {
+			fmt.Printf("Fingerprint: %s\n", fp)
+		}
 
 		// Display a list of all areas
 		if stats.TotalAreas > 0 {
@@ -171,36 +301,297 @@ func main() {
 				fmt.Printf("  %3d: %s\n", id, area.Name)
 			}
 		}
+
+		if envs := m.Environments(); len(envs) > 0 {
+			fmt.Println("\nEnvironments in use:")
+			for _, env := range envs {
+				r, g, b, _ := env.Color.ToRGBA()
+				fmt.Printf("  %3d: %6d rooms  #%02x%02x%02x  %s\n", env.ID, env.Rooms, r, g, b, env.Source)
+			}
+		}
+
+		if symbols := mapparser.GetSymbolStats(m); len(symbols) > 0 {
+			fmt.Println("\nRoom symbols:")
+			for _, s := range symbols {
+				fmt.Printf("  %-4s %6d rooms in %d areas\n", s.Symbol, s.Rooms, len(s.Areas))
+			}
+		}
 	}
 
 	// Dump to JSON if requested
 	if *dumpJSON != "" {
 		fmt.Printf("Exporting map to JSON: %s\n", *dumpJSON)
-		if err := mapparser.ExportToJSON(m, *dumpJSON); err != nil {
+		opts := &mapparser.JSONOptions{Compact: *jsonCompact, OmitPixmaps: *jsonNoPixmaps}
+		if err := mapparser.ExportToJSONWithOptions(m, *dumpJSON, opts); err != nil {
 			fmt.Printf("Error exporting to JSON: %v\n", err)
 			os.Exit(1)
 		}
 		fmt.Println("JSON export completed successfully.")
 	}
 
-	// Render map fragment if room ID and output file provided
-	if *roomID > 0 && *outputFile != "" {
-		fmt.Printf("Rendering map fragment centered on room %d...\n", *roomID)
+	// Dump one JSON file per area if requested
+	if *dumpJSONAreas != "" {
+		fmt.Printf("Exporting areas to JSON in: %s\n", *dumpJSONAreas)
+		if err := os.MkdirAll(*dumpJSONAreas, 0o755); err != nil {
+			fmt.Printf("Error creating directory: %v\n", err)
+			os.Exit(1)
+		}
+		opts := &mapparser.JSONOptions{Compact: *jsonCompact, OmitPixmaps: *jsonNoPixmaps}
+		for _, id := range slices.Sorted(maps.Keys(m.Areas)) {
+			path := filepath.Join(*dumpJSONAreas, fmt.Sprintf("area-%d.json", id))
+			if err := mapparser.ExportAreaToJSONWithOptions(m, id, path, opts); err != nil {
+				fmt.Printf("Error exporting area %d: %v\n", id, err)
+				os.Exit(1)
+			}
+		}
+		fmt.Printf("Exported %d areas.\n", len(m.Areas))
+	}
+
+	// Export label assets if requested
+	if *exportLabels != "" {
+		fmt.Printf("Exporting label images to: %s\n", *exportLabels)
+		manifest, err := mapparser.ExportLabelAssets(m, *exportLabels)
+		if err != nil {
+			fmt.Printf("Error exporting labels: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Exported %d labels.\n", len(manifest.Labels))
+	}
+
+	// Render map fragment if a center and output file provided
+	if (*roomID > 0 || *centerLabel != "" || *centerArea != "" || *route != "") && *outputFile != "" {
+		switch {
+		case *route != "":
+			fmt.Printf("Rendering route %s...\n", *route)
+		case *roomID > 0:
+			fmt.Printf("Rendering map fragment centered on room %d...\n", *roomID)
+		case *centerLabel != "":
+			fmt.Printf("Rendering map fragment centered on label %q...\n", *centerLabel)
+		default:
+			fmt.Printf("Rendering map fragment centered on area %s...\n", *centerArea)
+		}
 
-		// Configure renderer
+		// Configure renderer: flags given on the command line override the
+		// -theme, which overrides the -config file, which overrides the
+		// defaults
 		cfg := maprenderer.DefaultConfig()
-		cfg.Width = *imgWidth
-		cfg.Height = *imgHeight
-		cfg.RoomSize = *roomSize
-		cfg.RoomSpacing = *roomSpacing
-		cfg.RoomRound = *roundRooms
+		if *configFile != "" {
+			loaded, err := maprenderer.LoadConfig(*configFile)
+			if err != nil {
+				fmt.Printf("Error loading config: %v\n", err)
+				os.Exit(1)
+			}
+			cfg = loaded
+		}
+		if *assetsDir != "" {
+			cfg.Assets = assets.New(*assetsDir)
+		}
+		if *theme != "" {
+			if err := cfg.ApplyTheme(*theme); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		set := func(name string) bool { return *configFile == "" || given[name] }
+		if set("width") {
+			cfg.Width = *imgWidth
+		}
+		if set("height") {
+			cfg.Height = *imgHeight
+		}
+		if set("room-size") {
+			cfg.RoomSize = *roomSize
+		}
+		if set("room-spacing") {
+			cfg.RoomSpacing = *roomSpacing
+		}
+		// A theme's exit width holds unless -exit-width is given
+		if set("exit-width") && (*theme == "" || given["exit-width"]) {
+			cfg.ExitWidth = *exitWidth
+		}
+		if set("zoom") {
+			cfg.Zoom = *zoom
+		}
+		if set("pixel-ratio") {
+			cfg.PixelRatio = *pixelRatio
+		}
+		if set("parallelism") {
+			cfg.Parallelism = *parallelism
+		}
+		if set("supersample") {
+			cfg.SuperSample = *superSample
+		}
+		if set("round") {
+			cfg.RoomRound = *roundRooms
+		}
+		if set("grid-mode") {
+			cfg.GridMode = *gridMode
+		}
+		if *otherLevels > 0 {
+			cfg.ShowUpperLevel, cfg.ShowLowerLevel = true, true
+			cfg.OtherLevels = *otherLevels
+		}
+		if set("label-area-exits") {
+			cfg.LabelAreaExits = *labelAreaExits
+		}
+		if set("area-ghosts") {
+			cfg.AreaGhosts = *areaGhosts
+		}
+		if set("plain-one-way") {
+			cfg.OneWayExits.Plain = *plainOneWay
+		}
+		if *oneWayColor != "" {
+			c, err := maprenderer.ParseColor(*oneWayColor)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			cfg.OneWayExits.Color = c
+		}
+		if *oneWayDash != "" {
+			cfg.OneWayExits.Dash = nil
+			for s := range strings.SplitSeq(*oneWayDash, ",") {
+				n, err := strconv.Atoi(strings.TrimSpace(s))
+				if err != nil {
+					fmt.Printf("Error: invalid -one-way-dash %q\n", *oneWayDash)
+					os.Exit(1)
+				}
+				cfg.OneWayExits.Dash = append(cfg.OneWayExits.Dash, n)
+			}
+		}
+		if set("one-way-arrow") {
+			cfg.OneWayExits.ArrowSize = *oneWayArrow
+		}
+		if set("bundle-exits") {
+			cfg.BundleExits = *bundleExits
+		}
+		if set("special-exits") {
+			cfg.ShowSpecialExits = *specialExits
+		}
+		if set("special-exit-labels") {
+			cfg.SpecialExitLabels = *specialLabels
+		}
+		if set("smooth-lines") {
+			cfg.SmoothCustomLines = *smoothLines
+		}
+		if set("curve-tension") {
+			cfg.CurveTension = *curveTension
+		}
+		if set("grid") {
+			cfg.ShowGrid = *showGrid
+		}
+		if set("axes") {
+			cfg.ShowAxes = *showAxes
+		}
+		if set("legend") {
+			cfg.ShowLegend = *showLegend
+		}
+		if set("attribution") {
+			cfg.ShowAttribution = *showAttribution
+		}
+		if set("title") {
+			cfg.ShowTitle = *showTitle
+		}
+		if set("compass") {
+			cfg.ShowCompass = *showCompass
+		}
+		if set("scale") {
+			cfg.ShowScale = *showScale
+		}
+		if *showFlags {
+			cfg.FlagRules = mapparser.DefaultFlagRules()
+		}
+		if *roomNames != "" {
+			placement, err := maprenderer.ParseRoomNamePlacement(*roomNames)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			cfg.ShowRoomNames = true
+			cfg.RoomNamePlacement = placement
+			cfg.RoomNameMaxLen = *roomNameLen
+		}
+		if set("weights") {
+			heatmap, err := maprenderer.ParseWeightHeatmap(*weights)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			cfg.WeightHeatmap = heatmap
+		}
+		if set("weight-max") {
+			cfg.WeightMax = int32(*weightMax)
+		}
+		if *weightColors != "" {
+			cfg.WeightGradient = nil
+			for s := range strings.SplitSeq(*weightColors, ",") {
+				c, err := maprenderer.ParseColor(strings.TrimSpace(s))
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					os.Exit(1)
+				}
+				cfg.WeightGradient = append(cfg.WeightGradient, c)
+			}
+		}
+		if *valuesFile != "" {
+			values, err := loadRoomValues(*valuesFile)
+			if err != nil {
+				fmt.Printf("Error loading room values: %v\n", err)
+				os.Exit(1)
+			}
+			cfg.RoomValues = values
+		}
+		if *markersFile != "" {
+			markers, err := loadMarkers(*markersFile)
+			if err != nil {
+				fmt.Printf("Error loading markers: %v\n", err)
+				os.Exit(1)
+			}
+			cfg.Markers = markers
+		}
+		if *terrainFile != "" {
+			profile, err := mapparser.LoadTerrainProfile(*terrainFile)
+			if err != nil {
+				fmt.Printf("Error loading terrain profile: %v\n", err)
+				os.Exit(1)
+			}
+			cfg.Terrain = profile
+		}
 
 		// Create renderer
 		renderer := maprenderer.NewRenderer(cfg)
 		renderer.SetMap(m)
 
+		if *route != "" {
+			if err := renderRoute(renderer, m, *route, cfg.Terrain, *outputFile); err != nil {
+				fmt.Printf("Error rendering route: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
 		// Render the fragment
-		result, err := renderer.RenderFragment(int32(*roomID))
+		var result *maprenderer.RenderResult
+		switch {
+		case *routeTo > 0 && *roomID > 0:
+			result, err = renderRouteTo(renderer, m, int32(*roomID), int32(*routeTo), cfg.Terrain)
+		case *stackArea && *centerArea != "":
+			var areaID int32
+			if areaID, err = resolveArea(m, *centerArea); err == nil {
+				result, err = renderer.RenderStack(areaID, nil)
+			}
+		case *sheetArea && *centerArea != "":
+			var areaID int32
+			if areaID, err = resolveArea(m, *centerArea); err == nil {
+				result, err = renderer.RenderAreaLevels(areaID)
+			}
+		case *viewport != "" && *centerArea != "":
+			result, err = renderViewport(renderer, m, *centerArea, int32(*level), *viewport)
+		case given["level"] && *roomID > 0:
+			result, err = renderer.RenderFragmentAtZ(int32(*roomID), int32(*level))
+		default:
+			result, err = renderCentered(renderer, m, int32(*roomID), *centerLabel, *centerArea, *fitArea)
+		}
 		if err != nil {
 			fmt.Printf("Error rendering map: %v\n", err)
 			os.Exit(1)
@@ -213,14 +604,230 @@ func main() {
 		}
 
 		fmt.Printf("Map fragment saved to: %s\n", *outputFile)
+		if *writeManifest {
+			if _, err := maprenderer.WriteImageManifest(cfg, result, *outputFile, *mapFile); err != nil {
+				fmt.Printf("Error writing manifest: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Manifest saved to: %s\n", *outputFile+maprenderer.ManifestSuffix)
+		}
+		if *writeHTML {
+			htmlFile, err := writeViewer(cfg, m, result, *outputFile)
+			if err != nil {
+				fmt.Printf("Error writing HTML viewer: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("HTML viewer saved to: %s\n", htmlFile)
+		}
 		fmt.Printf("  Center room: %d\n", result.CenterRoom)
 		fmt.Printf("  Area: %s (ID: %d)\n", result.AreaName, result.AreaID)
 		fmt.Printf("  Z-level: %d\n", result.ZLevel)
 		fmt.Printf("  Rooms rendered: %d\n", result.RoomsDrawn)
+		fmt.Printf("  Exits leaving view: %d\n", len(result.EdgeExits))
 		fmt.Printf("  Image size: %dx%d\n", result.Image.Bounds().Dx(), result.Image.Bounds().Dy())
 	}
 }
 
+// loadRoomValues reads a JSON object mapping room IDs to numbers, e.g.
+// {"1234": 5, "1235": 12}.
+func loadRoomValues(path string) (map[int32]int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var values map[int32]int
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// markerSpec is a marker in a -markers file.
+type markerSpec struct {
+	Style string `json:"style"`
+	Color string `json:"color"`
+	Text  string `json:"text"`
+	Icon  string `json:"icon"`
+}
+
+// loadMarkers reads a JSON object mapping room IDs to markers, e.g.
+// {"1234": {"style": "tag", "color": "#ff0000", "text": "Boss"}}. Icon
+// paths are relative to the file.
+func loadMarkers(path string) (map[int32]maprenderer.Marker, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var specs map[int32]markerSpec
+	if err := json.Unmarshal(data, &specs); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	markers := make(map[int32]maprenderer.Marker, len(specs))
+	for id, spec := range specs {
+		var marker maprenderer.Marker
+		if spec.Style != "" {
+			if marker.Style, err = maprenderer.ParseMarkerStyle(spec.Style); err != nil {
+				return nil, fmt.Errorf("room %d: %w", id, err)
+			}
+		}
+		if spec.Color != "" {
+			if marker.Color, err = maprenderer.ParseColor(spec.Color); err != nil {
+				return nil, fmt.Errorf("room %d: %w", id, err)
+			}
+		}
+		marker.Text = spec.Text
+		if spec.Icon != "" {
+			if marker.Icon, err = loadIcon(filepath.Join(filepath.Dir(path), spec.Icon)); err != nil {
+				return nil, fmt.Errorf("room %d: %w", id, err)
+			}
+		}
+		markers[id] = marker
+	}
+	return markers, nil
+}
+
+func loadIcon(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", path, err)
+	}
+	return img, nil
+}
+
+// writeViewer writes an HTML page for the rendered image next to it and
+// returns the page's path.
+func writeViewer(cfg *maprenderer.Config, m *mapparser.MudletMap, result *maprenderer.RenderResult, imageFile string) (string, error) {
+	htmlFile := strings.TrimSuffix(imageFile, filepath.Ext(imageFile)) + ".html"
+	f, err := os.Create(htmlFile)
+	if err != nil {
+		return "", err
+	}
+	page := maprenderer.ViewerPage{
+		Title:       result.AreaName,
+		Subtitle:    fmt.Sprintf("Room %d, z-level %d", result.CenterRoom, result.ZLevel),
+		Attribution: m.Attribution().String(),
+		Image:       filepath.Base(imageFile),
+		Width:       result.Image.Bounds().Dx(),
+		Height:      result.Image.Bounds().Dy(),
+	}
+	if err := maprenderer.WriteViewer(f, cfg.Assets, page); err != nil {
+		f.Close()
+		return "", err
+	}
+	return htmlFile, f.Close()
+}
+
+// parseZRange parses a z-level window given as "MIN:MAX" or a single level.
+func parseZRange(s string) (minZ, maxZ int32, err error) {
+	lo, hi, found := strings.Cut(s, ":")
+	if !found {
+		hi = lo
+	}
+	a, errLo := strconv.ParseInt(strings.TrimSpace(lo), 10, 32)
+	b, errHi := strconv.ParseInt(strings.TrimSpace(hi), 10, 32)
+	if errLo != nil || errHi != nil || a > b {
+		return 0, 0, fmt.Errorf("invalid z-level range %q, expected MIN:MAX", s)
+	}
+	return int32(a), int32(b), nil
+}
+
+// renderViewport renders the map window given as "MINX,MINY,MAXX,MAXY" of
+// an area's level z.
+func renderViewport(r *maprenderer.Renderer, m *mapparser.MudletMap, area string, z int32, window string) (*maprenderer.RenderResult, error) {
+	areaID, err := resolveArea(m, area)
+	if err != nil {
+		return nil, err
+	}
+	var v [4]float64
+	parts := strings.Split(window, ",")
+	if len(parts) != len(v) {
+		return nil, fmt.Errorf("invalid viewport %q, expected MINX,MINY,MAXX,MAXY", window)
+	}
+	for i, part := range parts {
+		if v[i], err = strconv.ParseFloat(strings.TrimSpace(part), 64); err != nil {
+			return nil, fmt.Errorf("invalid viewport %q, expected MINX,MINY,MAXX,MAXY", window)
+		}
+	}
+	return r.RenderViewport(areaID, z, maprenderer.MapRect{MinX: v[0], MinY: v[1], MaxX: v[2], MaxY: v[3]})
+}
+
+// renderRoute finds the cheapest route given as "FROM:TO", costed by the
+// optional terrain profile, and saves it as one image of stitched views.
+func renderRoute(r *maprenderer.Renderer, m *mapparser.MudletMap, route string, terrain *mapparser.TerrainProfile, outputFile string) error {
+	fromStr, toStr, _ := strings.Cut(route, ":")
+	from, errFrom := strconv.ParseInt(strings.TrimSpace(fromStr), 10, 32)
+	to, errTo := strconv.ParseInt(strings.TrimSpace(toStr), 10, 32)
+	if errFrom != nil || errTo != nil {
+		return fmt.Errorf("invalid route %q, expected FROM:TO", route)
+	}
+	path := mapparser.FindPathWithOptions(m, int32(from), int32(to), &mapparser.PathOptions{Terrain: terrain})
+	if path == nil {
+		return fmt.Errorf("no route from room %d to room %d", from, to)
+	}
+	img, segments, err := r.RenderPathPanorama(path, false)
+	if err != nil {
+		return err
+	}
+	if err := maprenderer.SaveImage(img, outputFile, nil); err != nil {
+		return err
+	}
+	fmt.Printf("Route saved to: %s\n", outputFile)
+	fmt.Printf("  Rooms on route: %d\n", len(path))
+	fmt.Printf("  Views: %d\n", len(segments))
+	fmt.Printf("  Image size: %dx%d\n", img.Bounds().Dx(), img.Bounds().Dy())
+	return nil
+}
+
+// renderRouteTo renders the fragment around room from with the route to
+// room to drawn over it.
+func renderRouteTo(r *maprenderer.Renderer, m *mapparser.MudletMap, from, to int32, terrain *mapparser.TerrainProfile) (*maprenderer.RenderResult, error) {
+	path := mapparser.FindPathWithOptions(m, from, to, &mapparser.PathOptions{Terrain: terrain})
+	if path == nil {
+		return nil, fmt.Errorf("no route from room %d to room %d", from, to)
+	}
+	return r.RenderFragmentWithPath(from, path, &maprenderer.PathStyle{NumberSteps: true})
+}
+
+// renderCentered renders around a room if roomID is set, otherwise around a
+// label or an area given by ID or name.
+func renderCentered(r *maprenderer.Renderer, m *mapparser.MudletMap, roomID int32, label, area string, fit bool) (*maprenderer.RenderResult, error) {
+	switch {
+	case roomID > 0:
+		return r.RenderFragment(roomID)
+	case label != "":
+		return r.RenderLabel(label)
+	}
+	areaID, err := resolveArea(m, area)
+	if err != nil {
+		return nil, err
+	}
+	if !fit {
+		return r.RenderAreaCenter(areaID)
+	}
+	// Fit the most populated level, the one RenderAreaCenter shows.
+	center, ok := m.AreaCentroid(areaID)
+	if !ok {
+		return nil, fmt.Errorf("area %d has no rooms", areaID)
+	}
+	return r.RenderArea(areaID, int32(center.Z))
+}
+
+// resolveArea returns the ID of the area given by ID or name.
+func resolveArea(m *mapparser.MudletMap, area string) (int32, error) {
+	if id, err := strconv.ParseInt(area, 10, 32); err == nil {
+		return int32(id), nil
+	}
+	if a := m.FindArea(area); a != nil {
+		return a.ID, nil
+	}
+	return 0, fmt.Errorf("area %q not found", area)
+}
+
 func printUsage() {
 	fmt.Printf("mudlet-mapsnap %s - Mudlet map snapshot tool\n\n", version)
 	fmt.Println("Usage:")
@@ -229,23 +836,83 @@ func printUsage() {
 	fmt.Println("  -map string       Path to Mudlet map file (.map)")
 	fmt.Println("  -validate         Validate map integrity")
 	fmt.Println("  -stats            Show map statistics")
+	fmt.Println("  -info             Show version, areas and room counts (fast, header only)")
 	fmt.Println("  -dump-json string Export map to JSON")
+	fmt.Println("  -dump-json-areas string Export each area to <dir>/area-<id>.json")
+	fmt.Println("  -json-compact     Write JSON without indentation")
+	fmt.Println("  -json-no-pixmaps  Omit label images from JSON output")
+	fmt.Println("  -export-labels string Export label images and manifest to directory")
+	fmt.Println("  -z-range string   Keep only z-levels MIN:MAX (e.g. -5:-1) for all output")
+	fmt.Println("  -normalize        Move each area to start at the origin for all output")
+	fmt.Println("  -compact-ids      With -normalize, renumber rooms 1..n without gaps")
 	fmt.Println("  -examine          Examine binary structure")
 	fmt.Println("  -debug            Enable debug output")
 	fmt.Println("  -timeout int      Timeout in seconds (default 30)")
+	fmt.Println("  -cache string     Reuse a parsed-map cache file while the map is unchanged")
 	fmt.Println("\nRendering Options:")
 	fmt.Println("  -room int         Room ID to center the map on")
+	fmt.Println("  -center-label string Center the map on a label (e.g. \"Rynek\")")
+	fmt.Println("  -center-area string  Center the map on an area centroid (ID or name)")
+	fmt.Println("  -levels           With -center-area, lay out all the area's levels in a grid")
+	fmt.Println("  -stack            With -center-area, stack all the area's levels in one image")
+	fmt.Println("  -fit-area         With -center-area, fit the area's whole level in the image")
+	fmt.Println("  -viewport string  With -center-area, render the map window MINX,MINY,MAXX,MAXY")
+	fmt.Println("  -route string     Render the shortest route FROM:TO as stacked views")
+	fmt.Println("  -level int        With -room, render this z-level of the room's area; with -viewport, the window's")
+	fmt.Println("  -route-to int     With -room, draw the route to this room with numbered steps; not with -level")
+	fmt.Println("  -terrain string   YAML terrain costs (swamp: 3) for -route, outlined on rooms")
 	fmt.Println("  -output string    Output file path (.webp or .png)")
 	fmt.Println("  -width int        Output image width (default 800)")
 	fmt.Println("  -height int       Output image height (default 600)")
 	fmt.Println("  -room-size int    Room size in pixels (default 20)")
 	fmt.Println("  -room-spacing int Room spacing in pixels (default 25)")
+	fmt.Println("  -exit-width float Exit line width in pixels (default 2)")
+	fmt.Println("  -zoom float       Scale room size, spacing, exits and room text (default 1)")
+	fmt.Println("  -pixel-ratio float Image pixels per layout pixel, 2 for HiDPI (default 1)")
+	fmt.Println("  -parallelism int  Draw the map in this many stripes at once (default: all cores for large images)")
+	fmt.Println("  -supersample int  Draw the map 2 or 4 times larger and scale it down for smooth edges")
 	fmt.Println("  -round            Draw rooms as circles")
+	fmt.Println("  -other-levels int Draw this many levels above and below, faded")
+	fmt.Println("  -grid-mode        Draw all areas as packed tiles without exits")
+	fmt.Println("  -label-area-exits Print the destination area name next to area exit arrows")
+	fmt.Println("  -area-ghosts      Draw the rooms past exits to other areas faded, with the area name")
+	fmt.Println("  -plain-one-way    Draw one-way exits like two-way ones")
+	fmt.Println("  -one-way-color string  Color of one-way exits, #rrggbb[aa]")
+	fmt.Println("  -one-way-dash string   On,off lengths of one-way exit lines, e.g. 4,2")
+	fmt.Println("  -one-way-arrow float   One-way arrowhead length (negative = none)")
+	fmt.Println("  -bundle-exits     Draw parallel exits and special exits side by side")
+	fmt.Println("  -special-exits    Draw special exits as dashed curves between rooms in view")
+	fmt.Println("  -special-exit-labels  Print the command of each special exit drawn")
+	fmt.Println("  -smooth-lines     Draw custom exit lines as smooth curves")
+	fmt.Println("  -curve-tension float  Tension for -smooth-lines, 0 (round) to 1 (straight)")
+	fmt.Println("  -grid             Draw a faint coordinate grid")
+	fmt.Println("  -axes             Draw map coordinates along the image edges")
+	fmt.Println("  -legend           Draw a legend of door colors and exit markings")
+	fmt.Println("  -title            Draw a title bar with the area name and level")
+	fmt.Println("  -compass          Draw a north arrow in the top-right corner")
+	fmt.Println("  -scale            Draw a 100-pixel scale bar with the rooms it spans")
+	fmt.Println("  -attribution      Print the map's author, license and source in the corner")
+	fmt.Println("  -flags            Draw room flag badges from user data and locks")
+	fmt.Println("  -values string    JSON file of room ID -> number to print on rooms")
+	fmt.Println("  -markers string   JSON file of room ID -> marker (ring, fill, icon, tag)")
+	fmt.Println("  -room-names string Print room names below, above or right of rooms")
+	fmt.Println("  -room-name-len int With -room-names, shorten names to this many characters")
+	fmt.Println("  -weights string   Color exits or rooms by pathfinding weight: off, exits or rooms")
+	fmt.Println("  -weight-max int   With -weights, the weight at the end of the gradient")
+	fmt.Println("  -weight-colors string  With -weights, comma-separated gradient colors")
+	fmt.Println("  -manifest         Write <output>.manifest.json with map, config and image hashes")
+	fmt.Println("  -html             Write an offline HTML viewer page next to the image")
+	fmt.Println("  -config string    JSON or YAML rendering settings; flags given override them")
+	fmt.Println("  -theme string     Color theme (dark, light, high-contrast, colorblind, print)")
+	fmt.Println("  -assets string    Directory overriding the built-in font, themes and viewer")
 	fmt.Println("\nExamples:")
 	fmt.Println("  mapsnap -map world.map -stats")
 	fmt.Println("  mapsnap -map world.map -validate")
 	fmt.Println("  mapsnap -map world.map -dump-json map.json")
 	fmt.Println("  mapsnap -map world.map -room 1234 -output map.webp")
 	fmt.Println("  mapsnap -map world.map -room 1234 -output map.png -width 1200 -height 900")
+	fmt.Println("  mapsnap -map world.map -center-label Rynek -output rynek.webp")
+	fmt.Println("  mapsnap -map world.map -z-range -5:-1 -center-area Mines -output mines.webp")
+	fmt.Println("  mapsnap -map world.map -route 1234:5678 -output route.webp")
 	fmt.Println("  mapsnap -map world.map -room 1234 -output map.webp -room-size 15 -room-spacing 20")
 }
@@ -4,4 +4,8 @@ go 1.25
 
 require github.com/HugoSmits86/nativewebp v1.2.1
 
-require golang.org/x/image v0.24.0 // indirect
+require (
+	golang.org/x/image v0.24.0
+	golang.org/x/text v0.22.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -0,0 +1,133 @@
+// Package assets provides the files mapsnap needs to produce styled output:
+// the text font, color themes and the HTML viewer.
+//
+// The files are embedded in the binary, so a single statically linked
+// executable works offline on any platform. Each file can be replaced by
+// placing a file with the same path in an override directory:
+//
+//	fsys := assets.New("/etc/mapsnap/assets")
+//	data, err := fs.ReadFile(fsys, "themes/dark.json")
+//
+// Layout:
+//
+//	fonts/text.ttf       TrueType font for all text drawn on maps; not
+//	                     embedded, Go Regular is used unless overridden
+//	themes/<name>.json   color themes
+//	viewer/index.html    HTML viewer page (html/template)
+//	viewer/viewer.css    viewer style sheet, inlined into the page
+//	viewer/viewer.js     viewer pan and zoom script, inlined into the page
+package assets
+
+import (
+	"embed"
+	"errors"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+)
+
+//go:embed files
+var embedded embed.FS
+
+// Default holds the embedded assets.
+var Default fs.FS = mustSub(embedded, "files")
+
+// Asset paths used by the renderer and the CLI.
+const (
+	TextFontPath = "fonts/text.ttf"
+	ThemeDir     = "themes"
+	ViewerPage   = "viewer/index.html"
+	ViewerStyle  = "viewer/viewer.css"
+	ViewerScript = "viewer/viewer.js"
+)
+
+func mustSub(fsys fs.FS, dir string) fs.FS {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+// New returns the embedded assets overlaid with the given directories. A
+// file is read from the first directory that has it, falling back to the
+// embedded copy. Empty directory names are ignored; with none left, New
+// returns [Default].
+func New(dirs ...string) fs.FS {
+	var layers []fs.FS
+	for _, dir := range dirs {
+		if dir != "" {
+			layers = append(layers, os.DirFS(dir))
+		}
+	}
+	if len(layers) == 0 {
+		return Default
+	}
+	return &overlayFS{layers: append(layers, Default)}
+}
+
+// overlayFS reads each file from the first layer that has it.
+type overlayFS struct {
+	layers []fs.FS
+}
+
+func (o *overlayFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	for _, layer := range o.layers {
+		f, err := layer.Open(name)
+		if err == nil {
+			return f, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// ReadDir merges the directory listings of all layers, so themes added in
+// an override directory are listed next to the built-in ones.
+func (o *overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	var entries []fs.DirEntry
+	found := false
+	for _, layer := range o.layers {
+		des, err := fs.ReadDir(layer, name)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		found = true
+		for _, de := range des {
+			if !slices.ContainsFunc(entries, func(e fs.DirEntry) bool { return e.Name() == de.Name() }) {
+				entries = append(entries, de)
+			}
+		}
+	}
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return entries, nil
+}
+
+// ThemeNames lists the themes available in fsys, without the .json suffix.
+func ThemeNames(fsys fs.FS) ([]string, error) {
+	entries, err := fs.ReadDir(fsys, ThemeDir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if name, ok := strings.CutSuffix(e.Name(), ".json"); ok && !e.IsDir() {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
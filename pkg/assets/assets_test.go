@@ -0,0 +1,61 @@
+package assets
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDefaultAssets(t *testing.T) {
+	for _, name := range []string{ViewerPage, ViewerStyle, ViewerScript} {
+		if _, err := fs.Stat(Default, name); err != nil {
+			t.Errorf("Missing embedded asset %s: %v", name, err)
+		}
+	}
+	names, err := ThemeNames(Default)
+	if err != nil {
+		t.Fatalf("ThemeNames failed: %v", err)
+	}
+	if !reflect.DeepEqual(names, []string{"colorblind", "dark", "high-contrast", "light", "print"}) {
+		t.Errorf("Unexpected themes %v", names)
+	}
+}
+
+func TestOverrideDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, ThemeDir), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string]string{
+		"themes/dark.json":  `{"background": "#000000"}`,
+		"themes/sepia.json": `{}`,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fsys := New("", dir)
+	data, err := fs.ReadFile(fsys, "themes/dark.json")
+	if err != nil || string(data) != `{"background": "#000000"}` {
+		t.Errorf("Expected overridden theme, got %q, %v", data, err)
+	}
+	if _, err := fs.ReadFile(fsys, ViewerPage); err != nil {
+		t.Errorf("Expected fallback to embedded viewer page, got %v", err)
+	}
+	names, err := ThemeNames(fsys)
+	if err != nil {
+		t.Fatalf("ThemeNames failed: %v", err)
+	}
+	if !reflect.DeepEqual(names, []string{"colorblind", "dark", "high-contrast", "light", "print", "sepia"}) {
+		t.Errorf("Unexpected themes %v", names)
+	}
+	if _, err := fsys.Open("../secret"); err == nil {
+		t.Error("Expected invalid path rejected")
+	}
+	if New() != Default {
+		t.Error("Expected New without directories to return Default")
+	}
+}
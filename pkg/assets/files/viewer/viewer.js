@@ -0,0 +1,55 @@
+// Minimal pan and zoom for a single map image, without external libraries.
+(function () {
+  "use strict";
+  var box = document.getElementById("map");
+  var img = document.getElementById("map-image");
+  var scale = 1, x = 0, y = 0, drag = null;
+
+  function apply() {
+    img.style.transform = "translate(" + x + "px," + y + "px) scale(" + scale + ")";
+  }
+
+  function reset() {
+    var w = img.naturalWidth || img.width, h = img.naturalHeight || img.height;
+    scale = Math.min(1, box.clientWidth / w, box.clientHeight / h);
+    x = (box.clientWidth - w * scale) / 2;
+    y = (box.clientHeight - h * scale) / 2;
+    apply();
+  }
+
+  box.addEventListener("wheel", function (e) {
+    e.preventDefault();
+    var r = box.getBoundingClientRect();
+    var px = e.clientX - r.left, py = e.clientY - r.top;
+    var f = e.deltaY < 0 ? 1.25 : 0.8;
+    var next = Math.min(16, Math.max(0.1, scale * f));
+    x = px - (px - x) * next / scale;
+    y = py - (py - y) * next / scale;
+    scale = next;
+    apply();
+  }, { passive: false });
+
+  box.addEventListener("pointerdown", function (e) {
+    drag = { x: e.clientX - x, y: e.clientY - y };
+    box.classList.add("dragging");
+    box.setPointerCapture(e.pointerId);
+  });
+  box.addEventListener("pointermove", function (e) {
+    if (!drag) return;
+    x = e.clientX - drag.x;
+    y = e.clientY - drag.y;
+    apply();
+  });
+  box.addEventListener("pointerup", function () {
+    drag = null;
+    box.classList.remove("dragging");
+  });
+  box.addEventListener("dblclick", reset);
+  window.addEventListener("resize", reset);
+
+  if (img.complete) {
+    reset();
+  } else {
+    img.addEventListener("load", reset);
+  }
+})();
@@ -0,0 +1,151 @@
+package mapparser
+
+import "sort"
+
+// AreaExitOther is the [AreaExit.Direction] code Mudlet uses for special
+// exits that cross into another area.
+const AreaExitOther = 13
+
+// ExitIndex returns the [MudletRoom.Exits] index for the exit's Mudlet
+// direction code, or -1 for special exits and unknown codes.
+func (e AreaExit) ExitIndex() int {
+	return DirectionIndex(e.Direction)
+}
+
+// ComputeAreaExits rebuilds [MudletArea.AreaExits] for every area from room
+// exits, the way Mudlet does before saving: one entry per standard exit
+// leading to a room in another area, and one [AreaExitOther] entry per such
+// special exit. Entries are sorted by room ID, then direction, then
+// destination.
+//
+// Maps parsed from a Mudlet file already carry the stored area exits; call
+// this after building or editing a map, or to refresh stale data.
+func ComputeAreaExits(m *MudletMap) {
+	if m == nil {
+		return
+	}
+	for _, area := range m.Areas {
+		area.AreaExits = area.AreaExits[:0]
+	}
+	for _, id := range sortedKeys(m.Rooms) {
+		room := m.Rooms[id]
+		area, ok := m.Areas[room.Area]
+		if !ok {
+			continue
+		}
+		area.AreaExits = appendAreaExits(m, area.AreaExits, room)
+	}
+}
+
+// appendAreaExits appends the area exits of room, sorted by direction and
+// then destination.
+func appendAreaExits(m *MudletMap, exits []AreaExit, room *MudletRoom) []AreaExit {
+	crosses := func(dest int32) bool {
+		target, ok := m.Rooms[dest]
+		return ok && target.Area != room.Area
+	}
+	start := len(exits)
+	for dir, dest := range room.Exits {
+		if dest != NoExit && crosses(dest) {
+			exits = append(exits, AreaExit{RoomID: room.ID, DestRoomID: dest, Direction: DirectionCode(dir)})
+		}
+	}
+	for _, dest := range room.SpecialExits {
+		if crosses(dest) {
+			exits = append(exits, AreaExit{RoomID: room.ID, DestRoomID: dest, Direction: AreaExitOther})
+		}
+	}
+	added := exits[start:]
+	sort.Slice(added, func(i, j int) bool {
+		if added[i].Direction != added[j].Direction {
+			return added[i].Direction < added[j].Direction
+		}
+		return added[i].DestRoomID < added[j].DestRoomID
+	})
+	return exits
+}
+
+// AreaConnection groups the exits leading from one area into another.
+type AreaConnection struct {
+	From  int32      `json:"from"`
+	To    int32      `json:"to"`
+	Exits []AreaExit `json:"exits"`
+}
+
+// AreaGraph maps an area ID to its outgoing connections, sorted by
+// destination area. Areas without outgoing exits have no entry.
+type AreaGraph map[int32][]AreaConnection
+
+// AreaGraph computes area-to-area connections from room exits. It does not
+// rely on, or modify, the stored [MudletArea.AreaExits].
+func (m *MudletMap) AreaGraph() AreaGraph {
+	graph := make(AreaGraph)
+	links := make(map[[2]int32][]AreaExit)
+	add := func(room *MudletRoom, dest int32, code int32) {
+		target, ok := m.Rooms[dest]
+		if !ok || target.Area == room.Area {
+			return
+		}
+		key := [2]int32{room.Area, target.Area}
+		links[key] = append(links[key], AreaExit{RoomID: room.ID, DestRoomID: dest, Direction: code})
+	}
+	for _, id := range sortedKeys(m.Rooms) {
+		room := m.Rooms[id]
+		for dir, dest := range room.Exits {
+			if dest != NoExit {
+				add(room, dest, DirectionCode(dir))
+			}
+		}
+		for _, cmd := range sortedStringKeys(room.SpecialExits) {
+			add(room, room.SpecialExits[cmd], AreaExitOther)
+		}
+	}
+	for key, exits := range links {
+		graph[key[0]] = append(graph[key[0]], AreaConnection{From: key[0], To: key[1], Exits: exits})
+	}
+	for _, conns := range graph {
+		sort.Slice(conns, func(i, j int) bool { return conns[i].To < conns[j].To })
+	}
+	return graph
+}
+
+// Neighbors returns the IDs of areas directly reachable from areaID.
+func (g AreaGraph) Neighbors(areaID int32) []int32 {
+	conns := g[areaID]
+	ids := make([]int32, len(conns))
+	for i, c := range conns {
+		ids[i] = c.To
+	}
+	return ids
+}
+
+// Route returns the shortest sequence of area IDs leading from one area to
+// another, including both ends, or nil if to is unreachable. Ties are broken
+// toward lower area IDs.
+func (g AreaGraph) Route(from, to int32) []int32 {
+	if from == to {
+		return []int32{from}
+	}
+	prev := map[int32]int32{from: from}
+	queue := []int32{from}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, next := range g.Neighbors(cur) {
+			if _, seen := prev[next]; seen {
+				continue
+			}
+			prev[next] = cur
+			if next == to {
+				route := []int32{to}
+				for at := to; at != from; {
+					at = prev[at]
+					route = append([]int32{at}, route...)
+				}
+				return route
+			}
+			queue = append(queue, next)
+		}
+	}
+	return nil
+}
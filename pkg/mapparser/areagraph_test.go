@@ -0,0 +1,106 @@
+package mapparser
+
+import (
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// newAreaGraphTestMap builds three areas: 1 <-> 2 via east/west exits,
+// 2 -> 3 via a special exit.
+func newAreaGraphTestMap() *MudletMap {
+	m := NewMudletMap()
+	for i := int32(1); i <= 3; i++ {
+		m.Areas[i] = NewMudletArea(i, "")
+	}
+	for i := int32(1); i <= 4; i++ {
+		room := NewMudletRoom(i)
+		room.Area = i
+		m.Rooms[i] = room
+	}
+	m.Rooms[4].Area = 2
+	m.Rooms[1].Exits[ExitEast] = 2
+	m.Rooms[2].Exits[ExitWest] = 1
+	m.Rooms[2].Exits[ExitNorth] = 4 // same area
+	m.Rooms[4].SpecialExits["enter portal"] = 3
+	return m
+}
+
+// TestComputeAreaExits tests area exit population using Mudlet direction codes
+func TestComputeAreaExits(t *testing.T) {
+	m := newAreaGraphTestMap()
+	ComputeAreaExits(m)
+
+	if want := []AreaExit{{RoomID: 1, DestRoomID: 2, Direction: 4}}; !reflect.DeepEqual(m.Areas[1].AreaExits, want) {
+		t.Errorf("Area 1 exits = %+v, expected %+v", m.Areas[1].AreaExits, want)
+	}
+	want := []AreaExit{{RoomID: 2, DestRoomID: 1, Direction: 5}, {RoomID: 4, DestRoomID: 3, Direction: AreaExitOther}}
+	if !reflect.DeepEqual(m.Areas[2].AreaExits, want) {
+		t.Errorf("Area 2 exits = %+v, expected %+v", m.Areas[2].AreaExits, want)
+	}
+	if len(m.Areas[3].AreaExits) != 0 {
+		t.Errorf("Area 3 should have no exits, got %+v", m.Areas[3].AreaExits)
+	}
+	if m.Areas[2].AreaExits[0].ExitIndex() != ExitWest || m.Areas[2].AreaExits[1].ExitIndex() != -1 {
+		t.Error("ExitIndex returned unexpected directions")
+	}
+}
+
+// TestComputeAreaExitsMatchesMudlet compares computed area exits with those
+// stored by Mudlet in the large fixture
+func TestComputeAreaExitsMatchesMudlet(t *testing.T) {
+	if _, err := os.Stat(largeMapPath); os.IsNotExist(err) {
+		t.Skipf("Test fixture not found: %s", largeMapPath)
+	}
+	m, err := ParseMapFile(largeMapPath)
+	if err != nil {
+		t.Fatalf("Failed to parse map: %v", err)
+	}
+	stored := make(map[int32][]AreaExit, len(m.Areas))
+	for id, area := range m.Areas {
+		stored[id] = sortedAreaExits(area.AreaExits)
+	}
+	ComputeAreaExits(m)
+	for id, area := range m.Areas {
+		if got := sortedAreaExits(area.AreaExits); !reflect.DeepEqual(got, stored[id]) {
+			t.Errorf("Area %d: computed %d exits, Mudlet stored %d", id, len(got), len(stored[id]))
+		}
+	}
+}
+
+func sortedAreaExits(exits []AreaExit) []AreaExit {
+	out := append([]AreaExit{}, exits...)
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.RoomID != b.RoomID {
+			return a.RoomID < b.RoomID
+		}
+		if a.Direction != b.Direction {
+			return a.Direction < b.Direction
+		}
+		return a.DestRoomID < b.DestRoomID
+	})
+	return out
+}
+
+// TestAreaGraph tests area connections, neighbors and routing
+func TestAreaGraph(t *testing.T) {
+	g := newAreaGraphTestMap().AreaGraph()
+
+	if !reflect.DeepEqual(g.Neighbors(2), []int32{1, 3}) {
+		t.Errorf("Neighbors(2) = %v, expected [1 3]", g.Neighbors(2))
+	}
+	if len(g[3]) != 0 {
+		t.Errorf("Area 3 should have no outgoing connections")
+	}
+	if c := g[2][1]; c.From != 2 || c.To != 3 || len(c.Exits) != 1 || c.Exits[0].Direction != AreaExitOther {
+		t.Errorf("Unexpected connection 2->3: %+v", c)
+	}
+	if route := g.Route(1, 3); !reflect.DeepEqual(route, []int32{1, 2, 3}) {
+		t.Errorf("Route(1, 3) = %v, expected [1 2 3]", route)
+	}
+	if route := g.Route(3, 1); route != nil {
+		t.Errorf("Route(3, 1) = %v, expected nil", route)
+	}
+}
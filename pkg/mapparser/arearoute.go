@@ -0,0 +1,145 @@
+package mapparser
+
+import (
+	"container/heap"
+	"slices"
+)
+
+// AreaRouter finds routes between rooms in two steps: first across the
+// [AreaGraph] of the map, then between rooms within the areas on that
+// route. On a large world this visits a few areas' rooms instead of the
+// whole map.
+//
+// Each area connection is weighted by its cheapest usable exit, so the
+// route found is the cheapest one through the chosen areas. It can cost
+// more than [FindPath] when crossing more areas would be cheaper overall.
+// When the chosen areas hold no route, the search widens to the areas
+// next to them, one step at a time, so a route is found whenever one
+// exists.
+//
+// The router is a snapshot: create a new one after rooms or exits change.
+type AreaRouter struct {
+	m     *MudletMap
+	opts  PathOptions
+	graph AreaGraph
+	// cost holds the cheapest usable crossing of each connection of
+	// graph, by source and destination area. Connections whose exits are
+	// all locked are missing.
+	cost map[[2]int32]float64
+}
+
+// NewAreaRouter builds the weighted area graph of m. A nil opts uses the
+// defaults of [FindPathWithOptions].
+func NewAreaRouter(m *MudletMap, opts *PathOptions) *AreaRouter {
+	if opts == nil {
+		opts = &PathOptions{}
+	}
+	r := &AreaRouter{m: m, opts: *opts, cost: make(map[[2]int32]float64)}
+	if m == nil {
+		return r
+	}
+	r.graph = m.AreaGraph()
+	for _, conns := range r.graph {
+		for _, conn := range conns {
+			key := [2]int32{conn.From, conn.To}
+			for _, exit := range conn.Exits {
+				room := m.Rooms[exit.RoomID]
+				if room.IsLocked {
+					continue
+				}
+				room.forEachMove(m, func(dest int32, cost float64) {
+					if dest != exit.DestRoomID {
+						return
+					}
+					cost *= opts.Terrain.Cost(m.Rooms[dest])
+					if c, ok := r.cost[key]; !ok || cost < c {
+						r.cost[key] = cost
+					}
+				})
+			}
+		}
+	}
+	return r
+}
+
+// FindPath returns a route from one room to another as room IDs, including
+// both ends, or nil if there is none. Costs and locks are handled as in
+// [FindPath].
+func (r *AreaRouter) FindPath(from, to int32) []int32 {
+	m := r.m
+	if m == nil || m.Rooms[from] == nil || m.Rooms[to] == nil {
+		return nil
+	}
+	areas := r.AreaRoute(m.Rooms[from].Area, m.Rooms[to].Area)
+	if areas == nil {
+		return nil
+	}
+	inside := make(map[int32]bool, len(areas))
+	for _, id := range areas {
+		inside[id] = true
+	}
+	for {
+		if path := findPath(m, from, to, &r.opts, func(area int32) bool { return inside[area] }); path != nil {
+			return path
+		}
+		if !r.widen(inside) {
+			return nil
+		}
+	}
+}
+
+// widen adds to inside the areas reachable in one step from it, and
+// reports whether there were any.
+func (r *AreaRouter) widen(inside map[int32]bool) bool {
+	var added []int32
+	for _, id := range sortedKeys(inside) {
+		for _, next := range r.graph.Neighbors(id) {
+			if _, ok := r.cost[[2]int32{id, next}]; ok && !inside[next] && !slices.Contains(added, next) {
+				added = append(added, next)
+			}
+		}
+	}
+	for _, id := range added {
+		inside[id] = true
+	}
+	return len(added) > 0
+}
+
+// AreaRoute returns the cheapest sequence of areas leading from one area to
+// another, including both, or nil if the areas are not connected.
+func (r *AreaRouter) AreaRoute(from, to int32) []int32 {
+	if from == to {
+		return []int32{from}
+	}
+	dist := map[int32]float64{from: 0}
+	prev := make(map[int32]int32)
+	pq := &pathQueue{{room: from}}
+	for pq.Len() > 0 {
+		cur := heap.Pop(pq).(pathItem)
+		if cur.cost > dist[cur.room] {
+			continue
+		}
+		if cur.room == to {
+			route := []int32{to}
+			for at := to; at != from; {
+				at = prev[at]
+				route = append(route, at)
+			}
+			slices.Reverse(route)
+			return route
+		}
+		for _, next := range r.graph.Neighbors(cur.room) {
+			step, ok := r.cost[[2]int32{cur.room, next}]
+			if !ok {
+				continue
+			}
+			cost := cur.cost + step
+			if d, ok := dist[next]; !ok || cost < d {
+				dist[next] = cost
+				prev[next] = cur.room
+				heap.Push(pq, pathItem{room: next, cost: cost})
+			}
+		}
+	}
+	return nil
+}
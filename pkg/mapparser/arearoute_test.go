@@ -0,0 +1,83 @@
+package mapparser
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+// TestAreaRouter tests routing across areas and widening the search to
+// neighboring areas
+func TestAreaRouter(t *testing.T) {
+	m := NewMudletMap()
+	m.Version = 20
+	for id := int32(1); id <= 5; id++ {
+		m.Areas[id] = NewMudletArea(id, "")
+	}
+	// Areas 1-2-3 in a chain; rooms 7 and 8 of area 4 only connect
+	// through room 9 in area 5
+	for _, r := range []struct{ id, area, x int32 }{
+		{1, 1, 0}, {2, 1, 1}, {3, 2, 2}, {4, 2, 3}, {5, 3, 4}, {6, 3, 5},
+		{7, 4, 0}, {8, 4, 2}, {9, 5, 1},
+	} {
+		room := NewMudletRoom(r.id)
+		room.Area, room.X = r.area, r.x
+		if err := m.AddRoom(room); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, c := range [][2]int32{{1, 2}, {2, 3}, {3, 4}, {4, 5}, {5, 6}, {7, 9}, {9, 8}} {
+		if err := m.ConnectRooms(c[0], ExitEast, c[1], true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := NewAreaRouter(m, nil)
+	if got := r.AreaRoute(1, 3); !reflect.DeepEqual(got, []int32{1, 2, 3}) {
+		t.Errorf("AreaRoute(1, 3) = %v", got)
+	}
+	if got := r.AreaRoute(1, 4); got != nil {
+		t.Errorf("Expected no area route to area 4, got %v", got)
+	}
+	if got, want := r.FindPath(1, 6), FindPath(m, 1, 6); !reflect.DeepEqual(got, want) {
+		t.Errorf("FindPath(1, 6) = %v, expected %v", got, want)
+	}
+	if got := r.FindPath(7, 8); !reflect.DeepEqual(got, []int32{7, 9, 8}) {
+		t.Errorf("Expected the search widened to area 5, got %v", got)
+	}
+	if got := r.FindPath(1, 7); got != nil {
+		t.Errorf("Expected no path, got %v", got)
+	}
+
+	// Locked crossings are not part of the area graph
+	m.Rooms[2].ExitLocks = []int32{DirectionCode(ExitEast)}
+	if got := NewAreaRouter(m, nil).AreaRoute(1, 2); got != nil {
+		t.Errorf("Expected locked exit ignored, got %v", got)
+	}
+}
+
+// TestAreaRouterLargeMap tests that routes on a real map are valid walks
+func TestAreaRouterLargeMap(t *testing.T) {
+	if _, err := os.Stat(largeMapPath); err != nil {
+		t.Skipf("fixture not available: %v", err)
+	}
+	m, err := ParseMapFile(largeMapPath)
+	if err != nil {
+		t.Fatalf("ParseMapFile failed: %v", err)
+	}
+	r := NewAreaRouter(m, nil)
+	ids := sortedKeys(m.Rooms)
+	for i := 0; i < 8; i++ {
+		from, to := ids[i*997%len(ids)], ids[(i*7919+13)%len(ids)]
+		want := FindPath(m, from, to)
+		got := r.FindPath(from, to)
+		if (got == nil) != (want == nil) {
+			t.Fatalf("%d -> %d: router found %v, FindPath %v", from, to, got != nil, want != nil)
+		}
+		for j := 1; j < len(got); j++ {
+			if !m.Rooms[got[j-1]].hasExitTo(got[j]) {
+				t.Fatalf("%d -> %d: no exit from %d to %d", from, to, got[j-1], got[j])
+			}
+		}
+	}
+}
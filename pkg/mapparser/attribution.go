@@ -0,0 +1,75 @@
+package mapparser
+
+import (
+	"strings"
+)
+
+// Map-level user data keys holding attribution metadata. Keeping it in the
+// map's own user data means it travels with the file and can be edited from
+// Mudlet with setMapUserData.
+const (
+	AuthorKey    = "author"
+	LicenseKey   = "license"
+	SourceURLKey = "source_url"
+)
+
+// Attribution describes who made a map and under what terms it may be
+// redistributed.
+type Attribution struct {
+	Author    string `json:"author,omitempty"`
+	License   string `json:"license,omitempty"`
+	SourceURL string `json:"sourceUrl,omitempty"`
+}
+
+// IsZero reports whether no attribution field is set.
+func (a Attribution) IsZero() bool {
+	return a == Attribution{}
+}
+
+// String joins the set fields with commas, as in
+// "by Jane Doe, CC-BY-SA-4.0, https://example.org/maps".
+func (a Attribution) String() string {
+	var parts []string
+	if a.Author != "" {
+		parts = append(parts, "by "+a.Author)
+	}
+	if a.License != "" {
+		parts = append(parts, a.License)
+	}
+	if a.SourceURL != "" {
+		parts = append(parts, a.SourceURL)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Attribution returns the attribution metadata stored in the map's user
+// data, with surrounding whitespace trimmed.
+func (m *MudletMap) Attribution() Attribution {
+	if m == nil {
+		return Attribution{}
+	}
+	return Attribution{
+		Author:    strings.TrimSpace(m.UserData[AuthorKey]),
+		License:   strings.TrimSpace(m.UserData[LicenseKey]),
+		SourceURL: strings.TrimSpace(m.UserData[SourceURLKey]),
+	}
+}
+
+// SetAttribution stores a in the map's user data. Empty fields remove the
+// corresponding key.
+func (m *MudletMap) SetAttribution(a Attribution) {
+	if m.UserData == nil {
+		m.UserData = make(map[string]string)
+	}
+	for key, value := range map[string]string{
+		AuthorKey:    a.Author,
+		LicenseKey:   a.License,
+		SourceURLKey: a.SourceURL,
+	} {
+		if value == "" {
+			delete(m.UserData, key)
+		} else {
+			m.UserData[key] = value
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package mapparser
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+// TestAttribution tests reading and writing attribution through user data
+func TestAttribution(t *testing.T) {
+	m := NewMudletMap()
+	if !m.Attribution().IsZero() || GetMapStats(m).Attribution != nil {
+		t.Fatal("Expected no attribution on a new map")
+	}
+
+	m.SetAttribution(Attribution{Author: "Jane", License: "CC-BY-4.0", SourceURL: "https://example.org/map"})
+	if m.UserData[AuthorKey] != "Jane" || m.UserData[SourceURLKey] != "https://example.org/map" {
+		t.Errorf("Unexpected user data %v", m.UserData)
+	}
+	if got := m.Attribution().String(); got != "by Jane, CC-BY-4.0, https://example.org/map" {
+		t.Errorf("Unexpected string %q", got)
+	}
+	if a := GetMapStats(m).Attribution; a == nil || a.License != "CC-BY-4.0" {
+		t.Errorf("Expected attribution in stats, got %v", a)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJSON(m, &buf, nil); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	var doc struct{ Attribution Attribution }
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Attribution != m.Attribution() {
+		t.Errorf("Expected attribution in JSON, got %+v", doc.Attribution)
+	}
+
+	m.SetAttribution(Attribution{License: "MIT"})
+	if _, ok := m.UserData[AuthorKey]; ok {
+		t.Error("Expected empty author to remove the key")
+	}
+	if got := m.Attribution().String(); got != "MIT" {
+		t.Errorf("Unexpected string %q", got)
+	}
+}
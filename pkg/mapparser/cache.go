@@ -0,0 +1,674 @@
+package mapparser
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// The cache format is a private encoding of a parsed map, built for fast
+// loading rather than portability: a header naming the source file's size
+// and modification time, a table holding every distinct string once as
+// UTF-8, and then the map itself as varints, with strings referenced by
+// table index. Loading allocates the string table as a single string and
+// all rooms as a single slice. Caches are only read by the package version
+// that wrote them; anything else is reported as stale, so it is rebuilt.
+
+const (
+	cacheMagic = "mapsnap-cache\n"
+	// cacheFormat changes whenever the encoding, the map types or what
+	// the parser reads into them change, such as the special exit locks
+	// of maps older than version 21 in format 3.
+	cacheFormat = 3
+)
+
+// ErrStaleCache is returned by [LoadCache] for a cache that was written
+// for a different map file state or by a different cache format.
+var ErrStaleCache = errors.New("map cache is stale")
+
+// CacheKey identifies the state of the map file a cache was built from.
+type CacheKey struct {
+	Size    int64
+	ModTime int64 // nanoseconds since the epoch
+}
+
+// CacheKeyForFile returns the cache key for the map file at path.
+func CacheKeyForFile(path string) (CacheKey, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return CacheKey{}, fmt.Errorf("reading map file state: %w", err)
+	}
+	return CacheKey{Size: info.Size(), ModTime: info.ModTime().UnixNano()}, nil
+}
+
+// ParseMapFileCached parses the map file at mapPath, reusing the cache at
+// cachePath while the map file is unchanged. Otherwise the map file is
+// parsed and the cache rewritten; failing to write the cache does not fail
+// the parse.
+func ParseMapFileCached(mapPath, cachePath string) (*MudletMap, error) {
+	key, err := CacheKeyForFile(mapPath)
+	if err != nil {
+		return nil, err
+	}
+	if m, err := LoadCacheFile(cachePath, key); err == nil {
+		return m, nil
+	}
+	m, err := ParseMapFile(mapPath)
+	if err != nil {
+		return nil, err
+	}
+	_ = SaveCacheFile(cachePath, m, key)
+	return m, nil
+}
+
+// SaveCacheFile writes a cache of m to path, replacing it atomically.
+func SaveCacheFile(path string, m *MudletMap, key CacheKey) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("creating map cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := SaveCache(tmp, m, key); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing map cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("writing map cache: %w", err)
+	}
+	return nil
+}
+
+// LoadCacheFile reads a cache written by [SaveCacheFile], returning
+// [ErrStaleCache] unless it was built for key.
+func LoadCacheFile(path string, key CacheKey) (*MudletMap, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading map cache: %w", err)
+	}
+	return decodeCache(data, key)
+}
+
+// SaveCache writes m to w in the cache format, recording key as the state
+// of the map file it was parsed from.
+func SaveCache(w io.Writer, m *MudletMap, key CacheKey) error {
+	if m == nil {
+		return errors.New("map is nil")
+	}
+	e := &cacheEncoder{strs: make(map[string]uint64)}
+	e.encodeMap(m)
+
+	header := append([]byte(cacheMagic), cacheFormat)
+	header = binary.AppendVarint(header, key.Size)
+	header = binary.AppendVarint(header, key.ModTime)
+	header = binary.AppendUvarint(header, uint64(len(e.table)))
+	header = binary.AppendUvarint(header, uint64(e.tableLen))
+	for _, s := range e.table {
+		header = binary.AppendUvarint(header, uint64(len(s)))
+	}
+	for _, part := range [][]byte{header, []byte(e.joined()), e.buf} {
+		if _, err := w.Write(part); err != nil {
+			return fmt.Errorf("writing map cache: %w", err)
+		}
+	}
+	return nil
+}
+
+// LoadCache reads a map written by [SaveCache], returning [ErrStaleCache]
+// unless it was built for key.
+func LoadCache(r io.Reader, key CacheKey) (*MudletMap, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading map cache: %w", err)
+	}
+	return decodeCache(data, key)
+}
+
+// decodeCache decodes a whole cache file.
+func decodeCache(data []byte, key CacheKey) (*MudletMap, error) {
+	if !bytes.HasPrefix(data, []byte(cacheMagic)) {
+		return nil, errors.New("not a map cache")
+	}
+	d := &cacheDecoder{data: data, off: len(cacheMagic)}
+	if d.byte() != cacheFormat || d.int() != key.Size || d.int() != key.ModTime {
+		return nil, ErrStaleCache
+	}
+
+	count, total := d.count(), d.uint()
+	if d.err == nil && total > uint64(len(data)-d.off) {
+		d.fail()
+	}
+	lens := make([]uint64, count)
+	for i := range lens {
+		lens[i] = d.uint()
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	all := string(d.data[d.off : d.off+int(total)])
+	d.off += int(total)
+	d.strs = make([]string, count)
+	var at uint64
+	for i, n := range lens {
+		if at+n > total {
+			return nil, errCorruptCache
+		}
+		d.strs[i] = all[at : at+n]
+		at += n
+	}
+
+	m := d.decodeMap()
+	if d.err != nil {
+		return nil, d.err
+	}
+	return m, nil
+}
+
+var errCorruptCache = errors.New("map cache is corrupt")
+
+// cacheEncoder appends the body of a cache to buf, collecting strings into
+// the table.
+type cacheEncoder struct {
+	buf      []byte
+	strs     map[string]uint64
+	table    []string
+	tableLen int
+}
+
+func (e *cacheEncoder) joined() string {
+	var b bytes.Buffer
+	b.Grow(e.tableLen)
+	for _, s := range e.table {
+		b.WriteString(s)
+	}
+	return b.String()
+}
+
+func (e *cacheEncoder) int(v int64)   { e.buf = binary.AppendVarint(e.buf, v) }
+func (e *cacheEncoder) uint(v uint64) { e.buf = binary.AppendUvarint(e.buf, v) }
+
+func (e *cacheEncoder) bool(v bool) {
+	if v {
+		e.buf = append(e.buf, 1)
+	} else {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+func (e *cacheEncoder) float(v float64) {
+	e.buf = binary.LittleEndian.AppendUint64(e.buf, math.Float64bits(v))
+}
+
+func (e *cacheEncoder) str(s string) {
+	i, ok := e.strs[s]
+	if !ok {
+		i = uint64(len(e.table))
+		e.strs[s] = i
+		e.table = append(e.table, s)
+		e.tableLen += len(s)
+	}
+	e.uint(i)
+}
+
+// length writes the length of a map or slice, distinguishing nil from empty.
+func (e *cacheEncoder) length(n int, isNil bool) {
+	if isNil {
+		e.uint(0)
+		return
+	}
+	e.uint(uint64(n) + 1)
+}
+
+func (e *cacheEncoder) bytes(b []byte) {
+	e.length(len(b), b == nil)
+	e.buf = append(e.buf, b...)
+}
+
+func (e *cacheEncoder) color(c Color) {
+	e.int(int64(c.Spec))
+	for _, v := range [...]uint16{c.Red, c.Green, c.Blue, c.Alpha, c.Pad} {
+		e.uint(uint64(v))
+	}
+}
+
+func (e *cacheEncoder) vector(v Vector3D) {
+	e.float(v.X)
+	e.float(v.Y)
+	e.float(v.Z)
+}
+
+func encodeMapOf[K comparable, V any](e *cacheEncoder, m map[K]V, key func(K), value func(V)) {
+	e.length(len(m), m == nil)
+	for k, v := range m {
+		key(k)
+		value(v)
+	}
+}
+
+func encodeSliceOf[V any](e *cacheEncoder, s []V, elem func(V)) {
+	e.length(len(s), s == nil)
+	for _, v := range s {
+		elem(v)
+	}
+}
+
+func (e *cacheEncoder) int32(v int32)   { e.int(int64(v)) }
+func (e *cacheEncoder) uint32(v uint32) { e.uint(uint64(v)) }
+
+func (e *cacheEncoder) encodeMap(m *MudletMap) {
+	e.int32(m.Version)
+	encodeMapOf(e, m.EnvColors, e.int32, e.int32)
+	encodeMapOf(e, m.CustomEnvColors, e.int32, e.color)
+	encodeMapOf(e, m.RoomDbHashToRoomId, e.str, e.uint32)
+	encodeMapOf(e, m.RoomIdHash, e.str, e.int32)
+	encodeMapOf(e, m.UserData, e.str, e.str)
+	e.encodeFont(m.MapSymbolFont)
+	e.float(m.MapFontFudgeFactor)
+	e.bool(m.UseOnlyMapFont)
+
+	// Labels are written once and referenced by index, so labels listed
+	// both in an area and at the map level stay shared.
+	var labels []*MudletLabel
+	index := make(map[*MudletLabel]uint64)
+	labelRef := func(l *MudletLabel) {
+		i, ok := index[l]
+		if !ok {
+			i = uint64(len(labels))
+			index[l] = i
+			labels = append(labels, l)
+		}
+		e.uint(i)
+	}
+	body := e.buf
+	e.buf = nil
+	encodeMapOf(e, m.Areas, e.int32, func(a *MudletArea) { e.encodeArea(a, labelRef) })
+	encodeMapOf(e, m.Labels, e.int32, func(ls []*MudletLabel) { encodeSliceOf(e, ls, labelRef) })
+	refs := e.buf
+
+	e.buf = body
+	encodeSliceOf(e, labels, e.encodeLabel)
+	e.buf = append(e.buf, refs...)
+	encodeMapOf(e, m.Rooms, e.int32, e.encodeRoom)
+}
+
+func (e *cacheEncoder) encodeFont(f Font) {
+	e.str(f.Family)
+	e.str(f.StyleName)
+	e.float(f.PointSizeF)
+	e.int32(f.PixelSize)
+	e.uint(uint64(f.StyleHint))
+	e.uint(uint64(f.StyleStrategy))
+	e.uint(uint64(f.Weight))
+	e.uint(uint64(f.Style))
+	e.bool(f.Underline)
+	e.bool(f.Overline)
+	e.bool(f.StrikeOut)
+	e.bool(f.FixedPitch)
+	e.bool(f.Kerning)
+	e.uint(uint64(f.Stretch))
+	e.int32(f.LetterSpacing)
+	e.int32(f.WordSpacing)
+	e.uint(uint64(f.HintingPreference))
+	e.uint(uint64(f.Capitalization))
+}
+
+func (e *cacheEncoder) encodeArea(a *MudletArea, labelRef func(*MudletLabel)) {
+	e.int32(a.ID)
+	e.str(a.Name)
+	encodeSliceOf(e, a.Rooms, e.uint32)
+	encodeSliceOf(e, a.ZLevels, e.int32)
+	encodeSliceOf(e, a.AreaExits, func(x AreaExit) {
+		e.int32(x.RoomID)
+		e.int32(x.DestRoomID)
+		e.int32(x.Direction)
+	})
+	e.bool(a.GridMode)
+	for _, v := range [...]int32{a.Bounds.MinX, a.Bounds.MinY, a.Bounds.MinZ, a.Bounds.MaxX, a.Bounds.MaxY, a.Bounds.MaxZ} {
+		e.int32(v)
+	}
+	e.vector(a.Span)
+	encodeMapOf(e, a.XMaxForZ, e.int32, e.int32)
+	encodeMapOf(e, a.YMaxForZ, e.int32, e.int32)
+	encodeMapOf(e, a.XMinForZ, e.int32, e.int32)
+	encodeMapOf(e, a.YMinForZ, e.int32, e.int32)
+	e.vector(a.Pos)
+	e.bool(a.IsZone)
+	e.int32(a.ZoneAreaRef)
+	e.float(a.Last2DMapZoom)
+	encodeMapOf(e, a.UserData, e.str, e.str)
+	encodeSliceOf(e, a.Labels, labelRef)
+}
+
+func (e *cacheEncoder) encodeLabel(l *MudletLabel) {
+	e.int32(l.ID)
+	e.vector(l.Pos)
+	e.float(l.Width)
+	e.float(l.Height)
+	e.str(l.Text)
+	e.color(l.FgColor)
+	e.color(l.BgColor)
+	e.bytes(l.Pixmap)
+	e.bool(l.NoScaling)
+	e.bool(l.ShowOnTop)
+}
+
+func (e *cacheEncoder) encodeRoom(r *MudletRoom) {
+	e.int32(r.ID)
+	e.int32(r.Area)
+	e.int32(r.X)
+	e.int32(r.Y)
+	e.int32(r.Z)
+	for _, exit := range r.Exits {
+		e.int32(exit)
+	}
+	e.int32(r.Environment)
+	e.int32(r.Weight)
+	e.str(r.Name)
+	e.bool(r.IsLocked)
+	encodeMapOf(e, r.SpecialExits, e.str, e.int32)
+	e.str(r.Symbol)
+	e.bool(r.SymbolColor != nil)
+	if r.SymbolColor != nil {
+		e.color(*r.SymbolColor)
+	}
+	encodeMapOf(e, r.UserData, e.str, e.str)
+	encodeMapOf(e, r.CustomLines, e.str, func(pts []Point2D) {
+		encodeSliceOf(e, pts, func(p Point2D) {
+			e.float(p.X)
+			e.float(p.Y)
+		})
+	})
+	encodeMapOf(e, r.CustomLinesArrow, e.str, e.bool)
+	encodeMapOf(e, r.CustomLinesColor, e.str, e.color)
+	encodeMapOf(e, r.CustomLinesStyle, e.str, e.int32)
+	encodeSliceOf(e, r.SpecialExitLocks, e.str)
+	encodeSliceOf(e, r.ExitLocks, e.int32)
+	encodeSliceOf(e, r.ExitStubs, e.int32)
+	encodeMapOf(e, r.ExitWeights, e.str, e.int32)
+	encodeMapOf(e, r.Doors, e.str, e.int32)
+}
+
+// cacheDecoder reads a cache body. The first error is kept in err, after
+// which every read returns a zero value.
+type cacheDecoder struct {
+	data []byte
+	off  int
+	strs []string
+	err  error
+}
+
+func (d *cacheDecoder) fail() {
+	if d.err == nil {
+		d.err = errCorruptCache
+	}
+	d.off = len(d.data)
+}
+
+func (d *cacheDecoder) byte() byte {
+	if d.off >= len(d.data) {
+		d.fail()
+		return 0
+	}
+	b := d.data[d.off]
+	d.off++
+	return b
+}
+
+func (d *cacheDecoder) int() int64 {
+	v, n := binary.Varint(d.data[d.off:])
+	if n <= 0 {
+		d.fail()
+		return 0
+	}
+	d.off += n
+	return v
+}
+
+func (d *cacheDecoder) uint() uint64 {
+	v, n := binary.Uvarint(d.data[d.off:])
+	if n <= 0 {
+		d.fail()
+		return 0
+	}
+	d.off += n
+	return v
+}
+
+func (d *cacheDecoder) int32() int32   { return int32(d.int()) }
+func (d *cacheDecoder) uint32() uint32 { return uint32(d.uint()) }
+func (d *cacheDecoder) bool() bool     { return d.byte() != 0 }
+
+func (d *cacheDecoder) float() float64 {
+	if len(d.data)-d.off < 8 {
+		d.fail()
+		return 0
+	}
+	v := math.Float64frombits(binary.LittleEndian.Uint64(d.data[d.off:]))
+	d.off += 8
+	return v
+}
+
+func (d *cacheDecoder) str() string {
+	i := d.uint()
+	if i >= uint64(len(d.strs)) {
+		d.fail()
+		return ""
+	}
+	return d.strs[i]
+}
+
+// count reads a plain element count. Every element takes at least one
+// byte, so counts beyond the remaining data are rejected before anything
+// is allocated for them.
+func (d *cacheDecoder) count() int {
+	n := d.uint()
+	if n > uint64(len(d.data)-d.off) {
+		d.fail()
+		return 0
+	}
+	return int(n)
+}
+
+// length reads a length written by cacheEncoder.length; ok is false for nil.
+func (d *cacheDecoder) length() (n int, ok bool) {
+	v := d.uint()
+	if v == 0 {
+		return 0, false
+	}
+	if v-1 > uint64(len(d.data)-d.off) {
+		d.fail()
+		return 0, false
+	}
+	return int(v - 1), true
+}
+
+func (d *cacheDecoder) bytes() []byte {
+	n, ok := d.length()
+	if !ok {
+		return nil
+	}
+	b := slices.Clone(d.data[d.off : d.off+n])
+	d.off += n
+	return b
+}
+
+func (d *cacheDecoder) color() Color {
+	return Color{
+		Spec:  int8(d.int()),
+		Red:   uint16(d.uint()),
+		Green: uint16(d.uint()),
+		Blue:  uint16(d.uint()),
+		Alpha: uint16(d.uint()),
+		Pad:   uint16(d.uint()),
+	}
+}
+
+func (d *cacheDecoder) vector() Vector3D {
+	return Vector3D{X: d.float(), Y: d.float(), Z: d.float()}
+}
+
+func decodeMapOf[K comparable, V any](d *cacheDecoder, key func() K, value func() V) map[K]V {
+	n, ok := d.length()
+	if !ok {
+		return nil
+	}
+	m := make(map[K]V, n)
+	for range n {
+		k := key()
+		m[k] = value()
+	}
+	return m
+}
+
+func decodeSliceOf[V any](d *cacheDecoder, elem func() V) []V {
+	n, ok := d.length()
+	if !ok {
+		return nil
+	}
+	s := make([]V, n)
+	for i := range s {
+		s[i] = elem()
+	}
+	return s
+}
+
+func (d *cacheDecoder) decodeMap() *MudletMap {
+	m := &MudletMap{Version: d.int32()}
+	m.EnvColors = decodeMapOf(d, d.int32, d.int32)
+	m.CustomEnvColors = decodeMapOf(d, d.int32, d.color)
+	m.RoomDbHashToRoomId = decodeMapOf(d, d.str, d.uint32)
+	m.RoomIdHash = decodeMapOf(d, d.str, d.int32)
+	m.UserData = decodeMapOf(d, d.str, d.str)
+	m.MapSymbolFont = d.decodeFont()
+	m.MapFontFudgeFactor = d.float()
+	m.UseOnlyMapFont = d.bool()
+
+	labels := decodeSliceOf(d, d.decodeLabel)
+	labelRef := func() *MudletLabel {
+		i := d.uint()
+		if i >= uint64(len(labels)) {
+			d.fail()
+			return nil
+		}
+		return labels[i]
+	}
+	m.Areas = decodeMapOf(d, d.int32, func() *MudletArea { return d.decodeArea(labelRef) })
+	m.Labels = decodeMapOf(d, d.int32, func() []*MudletLabel { return decodeSliceOf(d, labelRef) })
+
+	n, ok := d.length()
+	if ok {
+		rooms := make([]MudletRoom, n)
+		m.Rooms = make(map[int32]*MudletRoom, n)
+		for i := range rooms {
+			id := d.int32()
+			d.decodeRoom(&rooms[i])
+			m.Rooms[id] = &rooms[i]
+		}
+	}
+	return m
+}
+
+func (d *cacheDecoder) decodeFont() Font {
+	return Font{
+		Family:            d.str(),
+		StyleName:         d.str(),
+		PointSizeF:        d.float(),
+		PixelSize:         d.int32(),
+		StyleHint:         uint8(d.uint()),
+		StyleStrategy:     uint16(d.uint()),
+		Weight:            uint8(d.uint()),
+		Style:             uint8(d.uint()),
+		Underline:         d.bool(),
+		Overline:          d.bool(),
+		StrikeOut:         d.bool(),
+		FixedPitch:        d.bool(),
+		Kerning:           d.bool(),
+		Stretch:           uint16(d.uint()),
+		LetterSpacing:     d.int32(),
+		WordSpacing:       d.int32(),
+		HintingPreference: uint8(d.uint()),
+		Capitalization:    uint8(d.uint()),
+	}
+}
+
+func (d *cacheDecoder) decodeArea(labelRef func() *MudletLabel) *MudletArea {
+	a := &MudletArea{ID: d.int32(), Name: d.str()}
+	a.Rooms = decodeSliceOf(d, d.uint32)
+	a.ZLevels = decodeSliceOf(d, d.int32)
+	a.AreaExits = decodeSliceOf(d, func() AreaExit {
+		return AreaExit{RoomID: d.int32(), DestRoomID: d.int32(), Direction: d.int32()}
+	})
+	a.GridMode = d.bool()
+	a.Bounds = BoundingBox3D{
+		MinX: d.int32(), MinY: d.int32(), MinZ: d.int32(),
+		MaxX: d.int32(), MaxY: d.int32(), MaxZ: d.int32(),
+	}
+	a.Span = d.vector()
+	a.XMaxForZ = decodeMapOf(d, d.int32, d.int32)
+	a.YMaxForZ = decodeMapOf(d, d.int32, d.int32)
+	a.XMinForZ = decodeMapOf(d, d.int32, d.int32)
+	a.YMinForZ = decodeMapOf(d, d.int32, d.int32)
+	a.Pos = d.vector()
+	a.IsZone = d.bool()
+	a.ZoneAreaRef = d.int32()
+	a.Last2DMapZoom = d.float()
+	a.UserData = decodeMapOf(d, d.str, d.str)
+	a.Labels = decodeSliceOf(d, labelRef)
+	return a
+}
+
+func (d *cacheDecoder) decodeLabel() *MudletLabel {
+	return &MudletLabel{
+		ID:        d.int32(),
+		Pos:       d.vector(),
+		Width:     d.float(),
+		Height:    d.float(),
+		Text:      d.str(),
+		FgColor:   d.color(),
+		BgColor:   d.color(),
+		Pixmap:    d.bytes(),
+		NoScaling: d.bool(),
+		ShowOnTop: d.bool(),
+	}
+}
+
+func (d *cacheDecoder) decodeRoom(r *MudletRoom) {
+	r.ID = d.int32()
+	r.Area = d.int32()
+	r.X = d.int32()
+	r.Y = d.int32()
+	r.Z = d.int32()
+	for i := range r.Exits {
+		r.Exits[i] = d.int32()
+	}
+	r.Environment = d.int32()
+	r.Weight = d.int32()
+	r.Name = d.str()
+	r.IsLocked = d.bool()
+	r.SpecialExits = decodeMapOf(d, d.str, d.int32)
+	r.Symbol = d.str()
+	if d.bool() {
+		c := d.color()
+		r.SymbolColor = &c
+	}
+	r.UserData = decodeMapOf(d, d.str, d.str)
+	r.CustomLines = decodeMapOf(d, d.str, func() []Point2D {
+		return decodeSliceOf(d, func() Point2D { return Point2D{X: d.float(), Y: d.float()} })
+	})
+	r.CustomLinesArrow = decodeMapOf(d, d.str, d.bool)
+	r.CustomLinesColor = decodeMapOf(d, d.str, d.color)
+	r.CustomLinesStyle = decodeMapOf(d, d.str, d.int32)
+	r.SpecialExitLocks = decodeSliceOf(d, d.str)
+	r.ExitLocks = decodeSliceOf(d, d.int32)
+	r.ExitStubs = decodeSliceOf(d, d.int32)
+	r.ExitWeights = decodeMapOf(d, d.str, d.int32)
+	r.Doors = decodeMapOf(d, d.str, d.int32)
+}
@@ -0,0 +1,143 @@
+package mapparser
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestCacheRoundTrip tests that a cached map loads back identically, with
+// labels shared between areas and the map-level lists kept shared
+func TestCacheRoundTrip(t *testing.T) {
+	m := NewMudletMap()
+	m.Version = 20
+	m.UserData["author"] = "Zażółć"
+	area := NewMudletArea(1, "Town")
+	area.Rooms = []uint32{1, 2}
+	label := &MudletLabel{ID: 3, Text: "Rynek", Pixmap: []byte{1, 2, 3}}
+	area.Labels = []*MudletLabel{label}
+	m.Areas[1] = area
+	m.Labels[1] = []*MudletLabel{label}
+	for id := int32(1); id <= 2; id++ {
+		room := NewMudletRoom(id)
+		room.Area = 1
+		room.Name = "Ulica"
+		m.Rooms[id] = room
+	}
+	m.Rooms[1].Exits[ExitEast] = 2
+	m.Rooms[1].UserData["terrain"] = "road"
+	m.Rooms[1].SymbolColor = &Color{Red: 0xFFFF, Alpha: 0xFFFF}
+	m.Rooms[1].CustomLines["e"] = []Point2D{{X: 1.5, Y: -2}}
+	m.Rooms[2].ExitLocks = []int32{6}
+	m.Rooms[2].Doors = nil
+
+	var buf bytes.Buffer
+	key := CacheKey{Size: 100, ModTime: 42}
+	if err := SaveCache(&buf, m, key); err != nil {
+		t.Fatalf("SaveCache failed: %v", err)
+	}
+	got, err := LoadCache(bytes.NewReader(buf.Bytes()), key)
+	if err != nil {
+		t.Fatalf("LoadCache failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, m) {
+		t.Errorf("Loaded map differs:\n got %+v\nwant %+v", got, m)
+	}
+	if got.Areas[1].Labels[0] != got.Labels[1][0] {
+		t.Error("Expected area and map-level label to stay shared")
+	}
+
+	if _, err := LoadCache(bytes.NewReader(buf.Bytes()), CacheKey{Size: 100, ModTime: 43}); !errors.Is(err, ErrStaleCache) {
+		t.Errorf("Expected ErrStaleCache for a changed file, got %v", err)
+	}
+	// Caches of format 2 lack the special exit locks of old maps
+	old := bytes.Clone(buf.Bytes())
+	old[len(cacheMagic)] = 2
+	if _, err := LoadCache(bytes.NewReader(old), key); !errors.Is(err, ErrStaleCache) {
+		t.Errorf("Expected ErrStaleCache for an older cache format, got %v", err)
+	}
+	for _, n := range []int{0, 20, buf.Len() - 1} {
+		if _, err := LoadCache(bytes.NewReader(buf.Bytes()[:n]), key); err == nil {
+			t.Errorf("Expected error for cache truncated to %d bytes", n)
+		}
+	}
+}
+
+// TestParseMapFileCached tests that the cache is written, reused while the
+// map file is unchanged and rebuilt once it changes
+func TestParseMapFileCached(t *testing.T) {
+	data, err := os.ReadFile(smallMapPath)
+	if err != nil {
+		t.Skipf("Test fixture not found: %s", smallMapPath)
+	}
+	dir := t.TempDir()
+	mapPath := filepath.Join(dir, "map.dat")
+	cachePath := filepath.Join(dir, "map.cache")
+	if err := os.WriteFile(mapPath, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := ParseMapFileCached(mapPath, cachePath)
+	if err != nil {
+		t.Fatalf("ParseMapFileCached failed: %v", err)
+	}
+	key, _ := CacheKeyForFile(mapPath)
+	cached, err := LoadCacheFile(cachePath, key)
+	if err != nil {
+		t.Fatalf("Expected cache to be written: %v", err)
+	}
+	if !reflect.DeepEqual(cached, want) {
+		t.Error("Cached map differs from the parsed map")
+	}
+
+	// A cache that fails to load is rebuilt
+	if err := os.WriteFile(cachePath, []byte(cacheMagic+"junk"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if m, err := ParseMapFileCached(mapPath, cachePath); err != nil || m.RoomCount() != want.RoomCount() {
+		t.Fatalf("Expected reparse after a bad cache, got %v", err)
+	}
+	if _, err := LoadCacheFile(cachePath, key); err != nil {
+		t.Errorf("Expected cache to be rewritten: %v", err)
+	}
+
+	// So is one for an older state of the map file
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(mapPath, later, later); err != nil {
+		t.Fatal(err)
+	}
+	newKey, _ := CacheKeyForFile(mapPath)
+	if _, err := LoadCacheFile(cachePath, newKey); !errors.Is(err, ErrStaleCache) {
+		t.Fatalf("Expected ErrStaleCache after touching the map, got %v", err)
+	}
+	if _, err := ParseMapFileCached(mapPath, cachePath); err != nil {
+		t.Fatalf("ParseMapFileCached failed: %v", err)
+	}
+	if _, err := LoadCacheFile(cachePath, newKey); err != nil {
+		t.Errorf("Expected cache rebuilt for the new state: %v", err)
+	}
+}
+
+// BenchmarkLoadCacheLargeMap benchmarks loading the large map from a cache,
+// for comparison with BenchmarkParseLargeMap
+func BenchmarkLoadCacheLargeMap(b *testing.B) {
+	m, err := ParseMapFile(largeMapPath)
+	if err != nil {
+		b.Skipf("Test fixture not found: %s", largeMapPath)
+	}
+	var buf bytes.Buffer
+	if err := SaveCache(&buf, m, CacheKey{}); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := LoadCache(bytes.NewReader(buf.Bytes()), CacheKey{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package mapparser
+
+import "sort"
+
+// ConnectivityReport describes how rooms in a map are linked together.
+type ConnectivityReport struct {
+	// Components lists groups of rooms connected by exits in either
+	// direction, largest first. Room IDs within a component are sorted.
+	Components [][]int32 `json:"components"`
+
+	// Start is the room reachability was computed from.
+	Start int32 `json:"start"`
+	// Unreachable lists rooms that cannot be reached from Start by
+	// following exits in their direction of travel. It is nil when Start
+	// is not a room in the map.
+	Unreachable []int32 `json:"unreachable,omitempty"`
+
+	// AreaIslands maps an area ID to its disconnected groups of rooms,
+	// considering only exits between rooms of that area. Only areas that
+	// split into more than one group are included.
+	AreaIslands map[int32][][]int32 `json:"areaIslands,omitempty"`
+}
+
+// Orphans returns rooms that form a component on their own, i.e. rooms with
+// no exits to or from any other room.
+func (r *ConnectivityReport) Orphans() []int32 {
+	var orphans []int32
+	for _, c := range r.Components {
+		if len(c) == 1 {
+			orphans = append(orphans, c[0])
+		}
+	}
+	sort.Slice(orphans, func(i, j int) bool { return orphans[i] < orphans[j] })
+	return orphans
+}
+
+// AnalyzeConnectivity computes connected components, rooms unreachable from
+// start, and per-area islands. Both standard and special exits are followed;
+// exits to missing rooms are ignored.
+//
+// Pass a start ID that is not in the map (e.g. 0) to skip the reachability
+// analysis.
+func AnalyzeConnectivity(m *MudletMap, start int32) *ConnectivityReport {
+	report := &ConnectivityReport{Start: start}
+	if m == nil {
+		return report
+	}
+
+	// Build an undirected adjacency list alongside the directed one.
+	out := make(map[int32][]int32, len(m.Rooms))
+	undirected := make(map[int32][]int32, len(m.Rooms))
+	for _, room := range m.Rooms {
+		for _, n := range room.Neighbors(m) {
+			dest := n.Room.ID
+			if dest == room.ID {
+				continue
+			}
+			out[room.ID] = append(out[room.ID], dest)
+			undirected[room.ID] = append(undirected[room.ID], dest)
+			undirected[dest] = append(undirected[dest], room.ID)
+		}
+	}
+
+	report.Components = components(sortedKeys(m.Rooms), undirected, nil)
+
+	if _, ok := m.Rooms[start]; ok {
+		seen := map[int32]bool{start: true}
+		queue := []int32{start}
+		for len(queue) > 0 {
+			id := queue[0]
+			queue = queue[1:]
+			for _, dest := range out[id] {
+				if !seen[dest] {
+					seen[dest] = true
+					queue = append(queue, dest)
+				}
+			}
+		}
+		report.Unreachable = []int32{}
+		for _, id := range sortedKeys(m.Rooms) {
+			if !seen[id] {
+				report.Unreachable = append(report.Unreachable, id)
+			}
+		}
+	}
+
+	byArea := make(map[int32][]int32)
+	for _, id := range sortedKeys(m.Rooms) {
+		area := m.Rooms[id].Area
+		byArea[area] = append(byArea[area], id)
+	}
+	for areaID, ids := range byArea {
+		sameArea := func(id int32) bool { return m.Rooms[id].Area == areaID }
+		islands := components(ids, undirected, sameArea)
+		if len(islands) > 1 {
+			if report.AreaIslands == nil {
+				report.AreaIslands = make(map[int32][][]int32)
+			}
+			report.AreaIslands[areaID] = islands
+		}
+	}
+
+	return report
+}
+
+// components groups ids into connected components using adjacency, only
+// following edges to rooms accepted by include (nil accepts all). The result
+// is ordered by size descending, then by smallest room ID.
+func components(ids []int32, adjacency map[int32][]int32, include func(int32) bool) [][]int32 {
+	seen := make(map[int32]bool, len(ids))
+	var result [][]int32
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		comp := []int32{id}
+		stack := []int32{id}
+		for len(stack) > 0 {
+			cur := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			for _, next := range adjacency[cur] {
+				if seen[next] || (include != nil && !include(next)) {
+					continue
+				}
+				seen[next] = true
+				comp = append(comp, next)
+				stack = append(stack, next)
+			}
+		}
+		sort.Slice(comp, func(i, j int) bool { return comp[i] < comp[j] })
+		result = append(result, comp)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if len(result[i]) != len(result[j]) {
+			return len(result[i]) > len(result[j])
+		}
+		return result[i][0] < result[j][0]
+	})
+	return result
+}
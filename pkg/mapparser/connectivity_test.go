@@ -0,0 +1,55 @@
+package mapparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestAnalyzeConnectivity tests components, reachability and area islands
+func TestAnalyzeConnectivity(t *testing.T) {
+	m := NewMudletMap()
+	for i := int32(1); i <= 7; i++ {
+		room := NewMudletRoom(i)
+		room.Area = 1
+		m.Rooms[i] = room
+	}
+	m.Rooms[4].Area = 2
+	m.Rooms[5].Area = 2
+
+	// 1 <-> 2 -> 3, 2 -> 4 (area 2), 4 -> 5 via special exit, 6 and 7 isolated
+	m.Rooms[1].Exits[ExitEast] = 2
+	m.Rooms[2].Exits[ExitWest] = 1
+	m.Rooms[2].Exits[ExitNorth] = 3
+	m.Rooms[2].Exits[ExitEast] = 4
+	m.Rooms[4].SpecialExits["swim"] = 5
+	m.Rooms[7].Exits[ExitSouth] = 999 // broken exit is ignored
+
+	report := AnalyzeConnectivity(m, 3)
+
+	wantComponents := [][]int32{{1, 2, 3, 4, 5}, {6}, {7}}
+	if !reflect.DeepEqual(report.Components, wantComponents) {
+		t.Errorf("Components = %v, expected %v", report.Components, wantComponents)
+	}
+	if !reflect.DeepEqual(report.Orphans(), []int32{6, 7}) {
+		t.Errorf("Orphans = %v, expected [6 7]", report.Orphans())
+	}
+
+	// From room 3 (dead end) nothing else is reachable
+	if !reflect.DeepEqual(report.Unreachable, []int32{1, 2, 4, 5, 6, 7}) {
+		t.Errorf("Unreachable from 3 = %v", report.Unreachable)
+	}
+	report = AnalyzeConnectivity(m, 1)
+	if !reflect.DeepEqual(report.Unreachable, []int32{6, 7}) {
+		t.Errorf("Unreachable from 1 = %v, expected [6 7]", report.Unreachable)
+	}
+
+	// Area 1 splits into {1,2,3}, {6}, {7}; area 2 is connected
+	wantIslands := map[int32][][]int32{1: {{1, 2, 3}, {6}, {7}}}
+	if !reflect.DeepEqual(report.AreaIslands, wantIslands) {
+		t.Errorf("AreaIslands = %v, expected %v", report.AreaIslands, wantIslands)
+	}
+
+	if report := AnalyzeConnectivity(m, 0); report.Unreachable != nil {
+		t.Error("Unreachable should be nil for an unknown start room")
+	}
+}
@@ -0,0 +1,98 @@
+package mapparser
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// ConvertVersion rewrites m in place into the layout of map format version
+// target, 20 or 21, so that it matches what the parser returns for a file
+// of that version:
+//
+//   - Version 21 keeps labels inside their areas (MudletArea.Labels);
+//     older versions keep them map-wide (MudletMap.Labels). Labels of
+//     areas missing from the map stay map-wide.
+//   - Version 20 has no room symbol colors or saved 2D zoom; these are
+//     cleared when downgrading.
+//
+// Special exits and their locks are stored the same way for every version
+// (command to destination, locks in SpecialExitLocks), so they need no
+// conversion here. Version 20 files key special exits by destination,
+// though, so downgrading a room with two special exits to the same room
+// would lose one of them: ConvertVersion returns an error for such a map
+// and leaves it unchanged.
+//
+// There is no binary writer yet; the converted map can be exported with
+// [WriteJSON] or compared with [DiffMaps].
+func ConvertVersion(m *MudletMap, target int32) error {
+	if m == nil {
+		return fmt.Errorf("nil map provided")
+	}
+	if target != 20 && target != 21 {
+		return fmt.Errorf("unsupported target version %d, expected 20 or 21", target)
+	}
+	if target < 21 {
+		if err := checkOldSpecialExits(m); err != nil {
+			return err
+		}
+	}
+
+	if target >= 21 {
+		for _, id := range sortedKeys(m.Labels) {
+			area := m.Areas[id]
+			if area == nil {
+				continue
+			}
+			area.Labels = appendNewLabels(area.Labels, m.Labels[id])
+			delete(m.Labels, id)
+		}
+	} else {
+		if m.Labels == nil {
+			m.Labels = make(map[int32][]*MudletLabel)
+		}
+		for _, id := range sortedKeys(m.Areas) {
+			area := m.Areas[id]
+			if len(area.Labels) == 0 {
+				continue
+			}
+			m.Labels[id] = appendNewLabels(m.Labels[id], area.Labels)
+			area.Labels = make([]*MudletLabel, 0)
+		}
+		for _, area := range m.Areas {
+			area.Last2DMapZoom = 0
+		}
+		for _, room := range m.Rooms {
+			room.SymbolColor = nil
+		}
+	}
+	m.Version = target
+	return nil
+}
+
+// appendNewLabels appends the labels of src not already in dst.
+func appendNewLabels(dst, src []*MudletLabel) []*MudletLabel {
+	for _, l := range src {
+		if !slices.Contains(dst, l) {
+			dst = append(dst, l)
+		}
+	}
+	return dst
+}
+
+// checkOldSpecialExits returns an error for the first room, by ID, with two
+// special exits to the same destination, which version 20 cannot store.
+func checkOldSpecialExits(m *MudletMap) error {
+	for _, id := range sortedKeys(m.Rooms) {
+		byDest := make(map[int32]string)
+		for _, cmd := range slices.Sorted(maps.Keys(m.Rooms[id].SpecialExits)) {
+			dest := m.Rooms[id].SpecialExits[cmd]
+			if other, ok := byDest[dest]; ok {
+				return fmt.Errorf("room %d: special exits %q and %q both lead to room %d, which version 20 cannot store",
+					id, other, cmd, dest)
+			}
+			byDest[dest] = cmd
+		}
+	}
+	return nil
+}
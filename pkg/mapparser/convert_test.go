@@ -0,0 +1,69 @@
+package mapparser
+
+import "testing"
+
+// TestConvertVersion tests moving labels and version-only fields between
+// the version 20 and 21 layouts
+func TestConvertVersion(t *testing.T) {
+	m := NewMudletMap()
+	m.Version = 20
+	m.Areas[1] = NewMudletArea(1, "Town")
+	l1 := &MudletLabel{ID: 1, Text: "Gate"}
+	l2 := &MudletLabel{ID: 2, Text: "Lost"}
+	m.Labels[1] = []*MudletLabel{l1}
+	m.Labels[9] = []*MudletLabel{l2} // no such area
+	room := NewMudletRoom(1)
+	room.SpecialExits["climb"] = 1
+	room.SpecialExitLocks = []string{"climb"}
+	m.Rooms[1] = room
+
+	if err := ConvertVersion(m, 21); err != nil {
+		t.Fatalf("ConvertVersion(21) failed: %v", err)
+	}
+	if m.Version != 21 {
+		t.Errorf("Version = %d, expected 21", m.Version)
+	}
+	if got := m.Areas[1].Labels; len(got) != 1 || got[0] != l1 {
+		t.Errorf("area labels = %v, expected the Gate label", got)
+	}
+	if _, ok := m.Labels[1]; ok {
+		t.Error("labels of area 1 should have left the map-wide list")
+	}
+	if got := m.Labels[9]; len(got) != 1 || got[0] != l2 {
+		t.Errorf("labels of a missing area should stay map-wide, got %v", got)
+	}
+	if got := m.GetLabelsForArea(1); len(got) != 1 {
+		t.Errorf("GetLabelsForArea(1) = %v", got)
+	}
+
+	m.Areas[1].Last2DMapZoom = 3
+	room.SymbolColor = &Color{Red: 0xffff, Alpha: 0xffff}
+	if err := ConvertVersion(m, 20); err != nil {
+		t.Fatalf("ConvertVersion(20) failed: %v", err)
+	}
+	if got := m.Labels[1]; len(got) != 1 || got[0] != l1 || len(m.Areas[1].Labels) != 0 {
+		t.Errorf("labels not moved back: map-wide %v, area %v", got, m.Areas[1].Labels)
+	}
+	if m.Areas[1].Last2DMapZoom != 0 || room.SymbolColor != nil {
+		t.Error("version 21 only fields should be cleared when downgrading")
+	}
+	if !room.SpecialExitLocked("climb") || room.SpecialExits["climb"] != 1 {
+		t.Error("special exits should be unchanged")
+	}
+
+	// Version 20 keys special exits by destination
+	if err := ConvertVersion(m, 21); err != nil {
+		t.Fatalf("ConvertVersion(21) failed: %v", err)
+	}
+	room.SpecialExits["jump"] = 1
+	if err := ConvertVersion(m, 20); err == nil {
+		t.Error("expected an error for two special exits to the same room")
+	}
+	if m.Version != 21 || len(m.Areas[1].Labels) != 1 {
+		t.Error("a failed conversion should leave the map unchanged")
+	}
+
+	if err := ConvertVersion(m, 18); err == nil {
+		t.Error("expected an error for an unsupported version")
+	}
+}
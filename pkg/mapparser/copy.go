@@ -0,0 +1,129 @@
+package mapparser
+
+import (
+	"maps"
+	"slices"
+)
+
+// DeepCopy returns a copy of the map that shares no mutable data with m, so
+// either can be modified without affecting the other. Labels referenced
+// from both an area and the map-level label lists remain shared between
+// those lists in the copy.
+func (m *MudletMap) DeepCopy() *MudletMap {
+	if m == nil {
+		return nil
+	}
+	c := *m
+	c.EnvColors = maps.Clone(m.EnvColors)
+	c.CustomEnvColors = maps.Clone(m.CustomEnvColors)
+	c.RoomDbHashToRoomId = maps.Clone(m.RoomDbHashToRoomId)
+	c.RoomIdHash = maps.Clone(m.RoomIdHash)
+	c.UserData = maps.Clone(m.UserData)
+
+	labels := make(map[*MudletLabel]*MudletLabel)
+	copyLabels := func(ls []*MudletLabel) []*MudletLabel {
+		if ls == nil {
+			return nil
+		}
+		out := make([]*MudletLabel, len(ls))
+		for i, l := range ls {
+			lc, ok := labels[l]
+			if !ok {
+				lc = l.DeepCopy()
+				labels[l] = lc
+			}
+			out[i] = lc
+		}
+		return out
+	}
+
+	if m.Areas != nil {
+		c.Areas = make(map[int32]*MudletArea, len(m.Areas))
+		for id, a := range m.Areas {
+			c.Areas[id] = a.deepCopy(copyLabels)
+		}
+	}
+	if m.Rooms != nil {
+		c.Rooms = make(map[int32]*MudletRoom, len(m.Rooms))
+		for id, r := range m.Rooms {
+			c.Rooms[id] = r.DeepCopy()
+		}
+	}
+	if m.Labels != nil {
+		c.Labels = make(map[int32][]*MudletLabel, len(m.Labels))
+		for id, ls := range m.Labels {
+			c.Labels[id] = copyLabels(ls)
+		}
+	}
+	return &c
+}
+
+// DeepCopy returns a copy of the area that shares no mutable data with a.
+func (a *MudletArea) DeepCopy() *MudletArea {
+	return a.deepCopy(func(ls []*MudletLabel) []*MudletLabel {
+		if ls == nil {
+			return nil
+		}
+		out := make([]*MudletLabel, len(ls))
+		for i, l := range ls {
+			out[i] = l.DeepCopy()
+		}
+		return out
+	})
+}
+
+func (a *MudletArea) deepCopy(copyLabels func([]*MudletLabel) []*MudletLabel) *MudletArea {
+	if a == nil {
+		return nil
+	}
+	c := *a
+	c.Rooms = slices.Clone(a.Rooms)
+	c.ZLevels = slices.Clone(a.ZLevels)
+	c.AreaExits = slices.Clone(a.AreaExits)
+	c.XMaxForZ = maps.Clone(a.XMaxForZ)
+	c.YMaxForZ = maps.Clone(a.YMaxForZ)
+	c.XMinForZ = maps.Clone(a.XMinForZ)
+	c.YMinForZ = maps.Clone(a.YMinForZ)
+	c.UserData = maps.Clone(a.UserData)
+	c.Labels = copyLabels(a.Labels)
+	return &c
+}
+
+// DeepCopy returns a copy of the room that shares no mutable data with r.
+func (r *MudletRoom) DeepCopy() *MudletRoom {
+	if r == nil {
+		return nil
+	}
+	c := *r
+	c.SpecialExits = maps.Clone(r.SpecialExits)
+	if r.SymbolColor != nil {
+		sc := *r.SymbolColor
+		c.SymbolColor = &sc
+	}
+	c.UserData = maps.Clone(r.UserData)
+	if r.CustomLines != nil {
+		c.CustomLines = make(map[string][]Point2D, len(r.CustomLines))
+		for k, pts := range r.CustomLines {
+			c.CustomLines[k] = slices.Clone(pts)
+		}
+	}
+	c.CustomLinesArrow = maps.Clone(r.CustomLinesArrow)
+	c.CustomLinesColor = maps.Clone(r.CustomLinesColor)
+	c.CustomLinesStyle = maps.Clone(r.CustomLinesStyle)
+	c.SpecialExitLocks = slices.Clone(r.SpecialExitLocks)
+	c.ExitLocks = slices.Clone(r.ExitLocks)
+	c.ExitStubs = slices.Clone(r.ExitStubs)
+	c.ExitWeights = maps.Clone(r.ExitWeights)
+	c.Doors = maps.Clone(r.Doors)
+	return &c
+}
+
+// DeepCopy returns a copy of the label that shares no mutable data with l.
+func (l *MudletLabel) DeepCopy() *MudletLabel {
+	if l == nil {
+		return nil
+	}
+	c := *l
+	c.Pixmap = slices.Clone(l.Pixmap)
+	return &c
+}
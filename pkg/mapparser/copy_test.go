@@ -0,0 +1,82 @@
+package mapparser
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+// TestDeepCopy tests that a copy is equal to the original and independent of it
+func TestDeepCopy(t *testing.T) {
+	if _, err := os.Stat(largeMapPath); os.IsNotExist(err) {
+		t.Skip("Large map fixture not found")
+	}
+	m, err := ParseMapFile(largeMapPath)
+	if err != nil {
+		t.Fatalf("Failed to parse map: %v", err)
+	}
+
+	c := m.DeepCopy()
+	if !reflect.DeepEqual(m, c) {
+		t.Fatal("Expected copy to equal the original")
+	}
+	if events := DiffMaps(m, c); len(events) != 0 {
+		t.Errorf("Expected no differences, got %d events", len(events))
+	}
+
+	// Room 21 of the fixture has user data and a custom line
+	room := c.Rooms[21]
+	if room == nil || len(room.UserData) == 0 || len(room.CustomLines) == 0 {
+		t.Fatal("Expected room 21 with user data and custom lines")
+	}
+	room.Name = "changed"
+	room.Exits[0] = 42
+	room.UserData["k"] = "v"
+	for k, points := range room.CustomLines {
+		if len(points) > 0 {
+			room.CustomLines[k][0].X = 1e6
+		}
+	}
+	for _, a := range c.Areas {
+		a.Rooms = append(a.Rooms[:0], 1)
+		a.XMaxForZ[0] = 99
+		break
+	}
+	c.EnvColors[1] = 99
+	for _, ls := range c.Labels {
+		if len(ls) > 0 && len(ls[0].Pixmap) > 0 {
+			ls[0].Pixmap[0] = 0
+			break
+		}
+	}
+
+	orig, err := ParseMapFile(largeMapPath)
+	if err != nil {
+		t.Fatalf("Failed to parse map: %v", err)
+	}
+	if !reflect.DeepEqual(m, orig) {
+		t.Error("Modifying the copy changed the original")
+	}
+}
+
+// TestDeepCopySharedLabels tests that a label listed in both an area and the
+// map-level labels stays shared in the copy
+func TestDeepCopySharedLabels(t *testing.T) {
+	m := NewMudletMap()
+	a := NewMudletArea(1, "A")
+	l := &MudletLabel{ID: 1, Text: "x", Pixmap: []byte{1, 2}}
+	a.Labels = []*MudletLabel{l}
+	m.Areas[1] = a
+	m.Labels[1] = []*MudletLabel{l}
+
+	c := m.DeepCopy()
+	if c.Labels[1][0] != c.Areas[1].Labels[0] {
+		t.Error("Expected shared label to stay shared")
+	}
+	if c.Labels[1][0] == l {
+		t.Error("Expected label to be copied")
+	}
+	if (*MudletMap)(nil).DeepCopy() != nil || (*MudletRoom)(nil).DeepCopy() != nil {
+		t.Error("Expected nil copies of nil values")
+	}
+}
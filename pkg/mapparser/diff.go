@@ -0,0 +1,283 @@
+package mapparser
+
+import (
+	"reflect"
+	"sort"
+)
+
+// EventKind identifies the type of a [MapEvent].
+type EventKind string
+
+// Map event kinds reported by [DiffMaps].
+const (
+	MapChanged   EventKind = "map_changed"
+	AreaAdded    EventKind = "area_added"
+	AreaRemoved  EventKind = "area_removed"
+	AreaChanged  EventKind = "area_changed"
+	RoomAdded    EventKind = "room_added"
+	RoomRemoved  EventKind = "room_removed"
+	RoomChanged  EventKind = "room_changed"
+	ExitChanged  EventKind = "exit_changed"
+	LabelAdded   EventKind = "label_added"
+	LabelRemoved EventKind = "label_removed"
+	LabelChanged EventKind = "label_changed"
+)
+
+// MapEvent describes one difference between two versions of a map.
+type MapEvent struct {
+	Kind EventKind `json:"kind"`
+	// Field is the JSON name of the map-level field, such as "envColors"
+	// or "roomIdHash", for [MapChanged] events.
+	Field   string `json:"field,omitempty"`
+	AreaID  int32  `json:"areaId,omitempty"`
+	RoomID  int32  `json:"roomId,omitempty"`
+	LabelID int32  `json:"labelId,omitempty"`
+
+	// Exit is the short direction name ("n", "up", ...) or special exit
+	// command for [ExitChanged] events.
+	Exit string `json:"exit,omitempty"`
+	// OldDest and NewDest are the exit destinations before and after the
+	// change; [NoExit] means the exit did not exist.
+	OldDest int32 `json:"oldDest,omitempty"`
+	NewDest int32 `json:"newDest,omitempty"`
+}
+
+// DiffMaps compares two maps and returns the events that turn old into new.
+// Either map may be nil, which is treated as an empty map.
+//
+// Events are ordered by kind group (map-level fields, areas, rooms,
+// labels) and then by field name or ID.
+// Added and removed rooms do not produce [ExitChanged] events; a room whose
+// exits and other properties both changed yields [RoomChanged] followed by
+// its [ExitChanged] events. Area events ignore the derived room list,
+// z-levels and area exits. Labels stored both in an area and in the
+// map-level list are compared by ID across the two.
+func DiffMaps(old, new *MudletMap) []MapEvent {
+	if old == nil {
+		old = NewMudletMap()
+	}
+	if new == nil {
+		new = NewMudletMap()
+	}
+	events := mapEvents(old, new)
+
+	for _, id := range unionKeys(old.Areas, new.Areas) {
+		a, inOld := old.Areas[id]
+		b, inNew := new.Areas[id]
+		switch {
+		case !inOld:
+			events = append(events, MapEvent{Kind: AreaAdded, AreaID: id})
+		case !inNew:
+			events = append(events, MapEvent{Kind: AreaRemoved, AreaID: id})
+		case !areaPropsEqual(a, b):
+			events = append(events, MapEvent{Kind: AreaChanged, AreaID: id})
+		}
+	}
+
+	for _, id := range unionKeys(old.Rooms, new.Rooms) {
+		a, inOld := old.Rooms[id]
+		b, inNew := new.Rooms[id]
+		switch {
+		case !inOld:
+			events = append(events, MapEvent{Kind: RoomAdded, AreaID: b.Area, RoomID: id})
+		case !inNew:
+			events = append(events, MapEvent{Kind: RoomRemoved, AreaID: a.Area, RoomID: id})
+		default:
+			if !roomPropsEqual(a, b) {
+				events = append(events, MapEvent{Kind: RoomChanged, AreaID: b.Area, RoomID: id})
+			}
+			events = append(events, exitEvents(a, b)...)
+		}
+	}
+
+	for _, areaID := range unionInt32(labelAreaIDs(old), labelAreaIDs(new)) {
+		oldLabels := labelsByID(old.Labels[areaID], areaLabels(old, areaID))
+		newLabels := labelsByID(new.Labels[areaID], areaLabels(new, areaID))
+		for _, id := range unionKeys(oldLabels, newLabels) {
+			a, inOld := oldLabels[id]
+			b, inNew := newLabels[id]
+			switch {
+			case !inOld:
+				events = append(events, MapEvent{Kind: LabelAdded, AreaID: areaID, LabelID: id})
+			case !inNew:
+				events = append(events, MapEvent{Kind: LabelRemoved, AreaID: areaID, LabelID: id})
+			case !sameLabel(a, b):
+				events = append(events, MapEvent{Kind: LabelChanged, AreaID: areaID, LabelID: id})
+			}
+		}
+	}
+
+	return events
+}
+
+// mapEvents reports the map-level fields that differ between two versions
+// of a map, in field name order.
+func mapEvents(a, b *MudletMap) []MapEvent {
+	fields := []struct {
+		name string
+		old  any
+		new  any
+	}{
+		{"customEnvColors", a.CustomEnvColors, b.CustomEnvColors},
+		{"envColors", a.EnvColors, b.EnvColors},
+		{"mapFontFudgeFactor", a.MapFontFudgeFactor, b.MapFontFudgeFactor},
+		{"mapSymbolFont", a.MapSymbolFont, b.MapSymbolFont},
+		{"roomDbHashToRoomId", a.RoomDbHashToRoomId, b.RoomDbHashToRoomId},
+		{"roomIdHash", a.RoomIdHash, b.RoomIdHash},
+		{"useOnlyMapFont", a.UseOnlyMapFont, b.UseOnlyMapFont},
+		{"userData", a.UserData, b.UserData},
+		{"version", a.Version, b.Version},
+	}
+	var events []MapEvent
+	for _, f := range fields {
+		if !equalOrEmpty(f.old, f.new) {
+			events = append(events, MapEvent{Kind: MapChanged, Field: f.name})
+		}
+	}
+	return events
+}
+
+// equalOrEmpty compares two values, treating nil and empty maps as equal.
+func equalOrEmpty(a, b any) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.Kind() == reflect.Map && va.Len() == 0 && vb.Len() == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// areaLabels returns the labels stored in an area (version 21+).
+func areaLabels(m *MudletMap, areaID int32) []*MudletLabel {
+	if area, ok := m.Areas[areaID]; ok {
+		return area.Labels
+	}
+	return nil
+}
+
+// exitEvents reports standard and special exits that differ between two
+// versions of a room.
+func exitEvents(a, b *MudletRoom) []MapEvent {
+	var events []MapEvent
+	for dir := range a.Exits {
+		if a.Exits[dir] != b.Exits[dir] {
+			events = append(events, MapEvent{
+				Kind: ExitChanged, AreaID: b.Area, RoomID: b.ID,
+				Exit: ExitDirectionShortNames[dir], OldDest: a.Exits[dir], NewDest: b.Exits[dir],
+			})
+		}
+	}
+	cmds := make(map[string]struct{}, len(a.SpecialExits)+len(b.SpecialExits))
+	for cmd := range a.SpecialExits {
+		cmds[cmd] = struct{}{}
+	}
+	for cmd := range b.SpecialExits {
+		cmds[cmd] = struct{}{}
+	}
+	for _, cmd := range sortedStringKeys(cmds) {
+		oldDest, ok := a.SpecialExits[cmd]
+		if !ok {
+			oldDest = NoExit
+		}
+		newDest, ok := b.SpecialExits[cmd]
+		if !ok {
+			newDest = NoExit
+		}
+		if oldDest != newDest {
+			events = append(events, MapEvent{
+				Kind: ExitChanged, AreaID: b.Area, RoomID: b.ID,
+				Exit: cmd, OldDest: oldDest, NewDest: newDest,
+			})
+		}
+	}
+	return events
+}
+
+// roomPropsEqual compares two rooms ignoring their exits.
+func roomPropsEqual(a, b *MudletRoom) bool {
+	ca, cb := *a, *b
+	ca.Exits, cb.Exits = [12]int32{}, [12]int32{}
+	ca.SpecialExits, cb.SpecialExits = nil, nil
+	return reflect.DeepEqual(normalizeEmpty(ca), normalizeEmpty(cb))
+}
+
+// areaPropsEqual compares two areas ignoring fields derived from rooms.
+func areaPropsEqual(a, b *MudletArea) bool {
+	ca, cb := *a, *b
+	ca.Rooms, cb.Rooms = nil, nil
+	ca.ZLevels, cb.ZLevels = nil, nil
+	ca.AreaExits, cb.AreaExits = nil, nil
+	ca.Labels, cb.Labels = nil, nil
+	if len(ca.UserData) == 0 && len(cb.UserData) == 0 {
+		ca.UserData, cb.UserData = nil, nil
+	}
+	return reflect.DeepEqual(ca, cb)
+}
+
+// normalizeEmpty replaces empty maps and slices of a room copy with nil so
+// that rooms built with [NewMudletRoom] compare equal to parsed rooms.
+func normalizeEmpty(r MudletRoom) MudletRoom {
+	if len(r.UserData) == 0 {
+		r.UserData = nil
+	}
+	if len(r.CustomLines) == 0 {
+		r.CustomLines = nil
+	}
+	if len(r.CustomLinesArrow) == 0 {
+		r.CustomLinesArrow = nil
+	}
+	if len(r.CustomLinesColor) == 0 {
+		r.CustomLinesColor = nil
+	}
+	if len(r.CustomLinesStyle) == 0 {
+		r.CustomLinesStyle = nil
+	}
+	if len(r.SpecialExitLocks) == 0 {
+		r.SpecialExitLocks = nil
+	}
+	if len(r.ExitLocks) == 0 {
+		r.ExitLocks = nil
+	}
+	if len(r.ExitStubs) == 0 {
+		r.ExitStubs = nil
+	}
+	if len(r.ExitWeights) == 0 {
+		r.ExitWeights = nil
+	}
+	if len(r.Doors) == 0 {
+		r.Doors = nil
+	}
+	return r
+}
+
+// labelsByID indexes labels by ID; later lists win.
+func labelsByID(lists ...[]*MudletLabel) map[int32]*MudletLabel {
+	byID := make(map[int32]*MudletLabel)
+	for _, labels := range lists {
+		for _, l := range labels {
+			byID[l.ID] = l
+		}
+	}
+	return byID
+}
+
+// unionKeys returns the sorted union of the keys of two int32-keyed maps.
+func unionKeys[V any](a, b map[int32]V) []int32 {
+	return unionInt32(sortedKeys(a), sortedKeys(b))
+}
+
+// unionInt32 returns the sorted union of two slices without duplicates.
+func unionInt32(a, b []int32) []int32 {
+	set := make(map[int32]struct{}, len(a)+len(b))
+	for _, v := range a {
+		set[v] = struct{}{}
+	}
+	for _, v := range b {
+		set[v] = struct{}{}
+	}
+	out := make([]int32, 0, len(set))
+	for v := range set {
+		out = append(out, v)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
@@ -0,0 +1,165 @@
+package mapparser
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// newDiffTestMap builds a small two-room map with one label
+func newDiffTestMap() *MudletMap {
+	m := NewMudletMap()
+	m.Areas[1] = NewMudletArea(1, "Town")
+	for i := int32(1); i <= 2; i++ {
+		room := NewMudletRoom(i)
+		room.Area = 1
+		room.X = i
+		m.Rooms[i] = room
+	}
+	m.Rooms[1].Exits[ExitEast] = 2
+	m.Rooms[2].Exits[ExitWest] = 1
+	m.Labels[1] = []*MudletLabel{{ID: 1, Text: "Rynek"}}
+	return m
+}
+
+// TestDiffMaps tests delta events between map versions
+func TestDiffMaps(t *testing.T) {
+	old := newDiffTestMap()
+	if events := DiffMaps(old, newDiffTestMap()); len(events) != 0 {
+		t.Errorf("Expected no events for identical maps, got %+v", events)
+	}
+
+	m := newDiffTestMap()
+	m.Areas[2] = NewMudletArea(2, "Forest")
+	m.Rooms[1].Name = "Square"
+	m.Rooms[1].Exits[ExitEast] = NoExit
+	m.Rooms[1].SpecialExits["climb"] = 3
+	delete(m.Rooms, 2)
+	m.Rooms[3] = NewMudletRoom(3)
+	m.Rooms[3].Area = 2
+	m.Labels[1] = []*MudletLabel{{ID: 2, Text: "Rynek"}}
+
+	want := []MapEvent{
+		{Kind: AreaAdded, AreaID: 2},
+		{Kind: RoomChanged, AreaID: 1, RoomID: 1},
+		{Kind: ExitChanged, AreaID: 1, RoomID: 1, Exit: "e", OldDest: 2, NewDest: NoExit},
+		{Kind: ExitChanged, AreaID: 1, RoomID: 1, Exit: "climb", OldDest: NoExit, NewDest: 3},
+		{Kind: RoomRemoved, AreaID: 1, RoomID: 2},
+		{Kind: RoomAdded, AreaID: 2, RoomID: 3},
+		{Kind: LabelRemoved, AreaID: 1, LabelID: 1},
+		{Kind: LabelAdded, AreaID: 1, LabelID: 2},
+	}
+	if got := DiffMaps(old, m); !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffMaps =\n%+v\nexpected\n%+v", got, want)
+	}
+
+	if got := DiffMaps(nil, old); len(got) != 4 || got[0].Kind != AreaAdded || got[3].Kind != LabelAdded {
+		t.Errorf("Unexpected events from nil map: %+v", got)
+	}
+
+	// Map-level fields and labels kept in both places are compared too
+	m = newDiffTestMap()
+	m.EnvColors[5] = 3
+	m.RoomIdHash["Player"] = 2
+	m.Areas[1].Labels = []*MudletLabel{{ID: 1, Text: "Rynek"}}
+	m.Labels[1] = append(m.Labels[1], &MudletLabel{ID: 3, Text: "Brama"})
+	want = []MapEvent{
+		{Kind: MapChanged, Field: "envColors"},
+		{Kind: MapChanged, Field: "roomIdHash"},
+		{Kind: LabelAdded, AreaID: 1, LabelID: 3},
+	}
+	if got := DiffMaps(old, m); !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffMaps =\n%+v\nexpected\n%+v", got, want)
+	}
+}
+
+// TestDiffMapsParsed tests that re-parsing a file yields no events
+func TestDiffMapsParsed(t *testing.T) {
+	if _, err := os.Stat(smallMapPath); os.IsNotExist(err) {
+		t.Skipf("Test fixture not found: %s", smallMapPath)
+	}
+	a, err := ParseMapFile(smallMapPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := ParseMapFile(smallMapPath)
+	if events := DiffMaps(a, b); len(events) != 0 {
+		t.Errorf("Expected no events, got %+v", events)
+	}
+}
+
+// TestMapStore tests versioning and subscriber notification
+func TestMapStore(t *testing.T) {
+	next := newDiffTestMap()
+	var loadErr error
+	store := NewMapStore(func() (*MudletMap, error) { return next, loadErr })
+
+	var updates []MapUpdate
+	cancel := store.Subscribe(func(u MapUpdate) { updates = append(updates, u) })
+
+	if m, v := store.Map(); m != nil || v != 0 {
+		t.Error("Expected empty store before first load")
+	}
+
+	u, err := store.Reload()
+	if err != nil || u.Version != 1 || len(u.Events) != 4 {
+		t.Fatalf("First reload: version %d, %d events, err %v", u.Version, len(u.Events), err)
+	}
+
+	// Reloading identical content keeps the version and does not notify,
+	// but installs the new map
+	next = newDiffTestMap()
+	if u, _ := store.Reload(); u.Version != 1 || len(u.Events) != 0 {
+		t.Errorf("Unchanged reload: version %d, %d events", u.Version, len(u.Events))
+	}
+	if m, _ := store.Map(); m != next {
+		t.Error("Expected the reloaded map installed")
+	}
+
+	changed := newDiffTestMap()
+	changed.Rooms[2].Environment = 5
+	store.Update(changed)
+	if m, v := store.Map(); m != changed || v != 2 {
+		t.Errorf("Expected updated map at version 2, got version %d", v)
+	}
+
+	if len(updates) != 2 || updates[1].Events[0] != (MapEvent{Kind: RoomChanged, AreaID: 1, RoomID: 2}) {
+		t.Errorf("Unexpected updates: %+v", updates)
+	}
+
+	cancel()
+	loadErr = errors.New("boom")
+	if _, err := store.Reload(); err == nil {
+		t.Error("Expected reload error to be returned")
+	}
+	store.Update(newDiffTestMap())
+	if len(updates) != 2 {
+		t.Error("Cancelled subscriber should not be notified")
+	}
+}
+
+// TestMapStoreOrder tests that concurrent updates notify in version order
+func TestMapStoreOrder(t *testing.T) {
+	store := NewMapStore(nil)
+	var versions []uint64
+	store.Subscribe(func(u MapUpdate) { versions = append(versions, u.Version) })
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m := newDiffTestMap()
+			m.Rooms[1].Environment = int32(i + 1)
+			store.Update(m)
+		}()
+	}
+	wg.Wait()
+	for i, v := range versions {
+		if v != uint64(i+1) {
+			t.Fatalf("Expected versions in order, got %v", versions)
+		}
+	}
+}
@@ -10,6 +10,10 @@
 // big-endian byte order and Qt's QDataStream serialization conventions,
 // including QString (UTF-16BE), QMap, QColor, and other Qt types.
 //
+// The parser is driven by a per-version description of the format, which
+// [MapSchema] exposes: each field's name, Qt type and the versions that
+// carry it, in file order.
+//
 // # Basic Usage
 //
 // Parse a map file:
@@ -20,6 +24,28 @@
 //	}
 //	fmt.Printf("Loaded %d rooms in %d areas\n", m.RoomCount(), m.AreaCount())
 //
+// For very large maps, [ParseMapFileWithOptions] can memory-map the file
+// and skip labels, whose images are passed over by offset without being
+// read; [ParseMapAt] parses from any [io.ReaderAt] and [ParseMapBytes]
+// decodes a map already in memory in place:
+//
+//	m, err := mapparser.ParseMapFileWithOptions("world.map",
+//	    &mapparser.ParseOptions{Mmap: true, SkipLabels: true})
+//
+// Maps older than format version 6 are rejected unless
+// [ParseOptions.LegacyMode] is set, which reads versions 3 to 5 on a
+// best-effort basis and reports the data they lack through
+// [ParseOptions.Warn].
+//
+// Parse failures are [*ParseError] values carrying the offset reached. Test
+// for the cause with [errors.Is] against [ErrNotAMudletMap],
+// [ErrUnsupportedVersion] and [ErrTruncated]:
+//
+//	if errors.Is(err, mapparser.ErrUnsupportedVersion) {
+//	    m, err = mapparser.ParseMapFileWithOptions("old.map",
+//	        &mapparser.ParseOptions{LegacyMode: true})
+//	}
+//
 // Access rooms and areas:
 //
 //	room := m.GetRoom(1234)
@@ -40,6 +66,9 @@
 //   - [MudletRoom]: A single room with exits, position, and metadata
 //   - [MudletLabel]: A text or image label on the map
 //
+// A [Workspace] groups several map files of one world under names, with
+// cross-map queries and a merged map for rendering.
+//
 // # Validation and Export
 //
 // Validate map integrity:
@@ -49,10 +78,22 @@
 //	    fmt.Printf("Error: %s\n", err.Message)
 //	}
 //
+// Select built-in rules or add custom ones with a [Validator]:
+//
+//	v := mapparser.NewValidator().
+//	    Disable(mapparser.RuleAsymmetricExit).
+//	    Register(mapparser.RequireUserData("vnum", mapparser.SeverityWarning))
+//	errors = v.Validate(m)
+//
 // Export to JSON:
 //
 //	err := mapparser.ExportToJSON(m, "output.json")
 //
+// Convert to and from the MMP (MUD Map Protocol) XML format:
+//
+//	err := mapparser.ExportMMP(m, w)
+//	m, err := mapparser.ImportMMP(r)
+//
 // # Room Exits
 //
 // Rooms have 12 standard exit directions, accessed via the Exits array:
@@ -63,4 +104,12 @@
 //	}
 //
 // Special exits (non-standard movement commands) are stored in the SpecialExits map.
+//
+// # Concurrency
+//
+// A parsed map is plain data with no internal locking. Any number of
+// goroutines may read a map at once, as long as nobody modifies it. To
+// change a map that others may be reading, modify a [MudletMap.DeepCopy]
+// and publish the copy, for example through [MapStore.Update]; readers
+// holding the old map are unaffected.
 package mapparser
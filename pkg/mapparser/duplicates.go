@@ -0,0 +1,72 @@
+package mapparser
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DuplicateKind names what the rooms of a [DuplicateGroup] have in common.
+type DuplicateKind string
+
+const (
+	// DuplicateDescription groups rooms with the same [DescriptionHash]:
+	// the same name and description.
+	DuplicateDescription DuplicateKind = "description"
+	// DuplicatePosition groups rooms with the same name at the same
+	// coordinates of the same area.
+	DuplicatePosition DuplicateKind = "position"
+)
+
+// DuplicateGroup is a set of rooms that are likely copies of one room.
+type DuplicateGroup struct {
+	Kind DuplicateKind
+	// Key is the shared description hash, or the name and position.
+	Key string
+	// Rooms holds the room IDs, sorted.
+	Rooms []int32
+}
+
+// FindDuplicateRooms groups rooms that look like copies of each other:
+// rooms sharing a description hash, and rooms sharing a name and a
+// position. Merged maps often contain such near-duplicates. Unnamed rooms
+// and rooms without a description are not compared on those grounds. A
+// room may be in one group of each kind. Groups are sorted by their first
+// room, description groups first.
+func FindDuplicateRooms(m *MudletMap) []DuplicateGroup {
+	type position struct {
+		name          string
+		area, x, y, z int32
+	}
+	byHash := make(map[string][]int32)
+	byPos := make(map[position][]int32)
+	for _, id := range sortedKeys(m.Rooms) {
+		room := m.Rooms[id]
+		if h := DescriptionHash(m, room); h != "" {
+			byHash[h] = append(byHash[h], id)
+		}
+		if room.Name != "" {
+			p := position{room.Name, room.Area, room.X, room.Y, room.Z}
+			byPos[p] = append(byPos[p], id)
+		}
+	}
+
+	var groups []DuplicateGroup
+	for h, ids := range byHash {
+		if len(ids) > 1 {
+			groups = append(groups, DuplicateGroup{Kind: DuplicateDescription, Key: h, Rooms: ids})
+		}
+	}
+	for p, ids := range byPos {
+		if len(ids) > 1 {
+			key := fmt.Sprintf("%s @ area %d (%d,%d,%d)", p.name, p.area, p.x, p.y, p.z)
+			groups = append(groups, DuplicateGroup{Kind: DuplicatePosition, Key: key, Rooms: ids})
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if a, b := groups[i].Rooms[0], groups[j].Rooms[0]; a != b {
+			return a < b
+		}
+		return groups[i].Kind < groups[j].Kind
+	})
+	return groups
+}
@@ -0,0 +1,38 @@
+package mapparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestFindDuplicateRooms tests grouping by description hash and position
+func TestFindDuplicateRooms(t *testing.T) {
+	m := NewMudletMap()
+	add := func(id int32, name, desc string, x int32) {
+		room := NewMudletRoom(id)
+		room.Name, room.Area, room.X = name, 1, x
+		if desc != "" {
+			room.UserData[RoomDescriptionKey] = desc
+		}
+		m.Rooms[id] = room
+	}
+	add(1, "Market", "A busy  market.", 0)
+	add(2, "Market", "A busy market.", 5) // same text, other position
+	add(3, "Market", "", 0)               // same position as 1
+	add(4, "Alley", "Dark.", 1)
+	add(5, "", "", 1)
+	add(6, "", "", 1) // unnamed, not compared
+
+	groups := FindDuplicateRooms(m)
+	if len(groups) != 2 {
+		t.Fatalf("Expected 2 groups, got %+v", groups)
+	}
+	if g := groups[0]; g.Kind != DuplicateDescription || !reflect.DeepEqual(g.Rooms, []int32{1, 2}) ||
+		g.Key != ComputeRoomHash("Market", "A busy market.") {
+		t.Errorf("Unexpected description group %+v", g)
+	}
+	if g := groups[1]; g.Kind != DuplicatePosition || !reflect.DeepEqual(g.Rooms, []int32{1, 3}) ||
+		g.Key != "Market @ area 1 (0,0,0)" {
+		t.Errorf("Unexpected position group %+v", g)
+	}
+}
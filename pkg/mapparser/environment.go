@@ -0,0 +1,213 @@
+package mapparser
+
+import (
+	"fmt"
+	"sort"
+)
+
+// EnvChange describes a single room environment reassignment.
+type EnvChange struct {
+	RoomID int32 `json:"roomId"`
+	From   int32 `json:"from"`
+	To     int32 `json:"to"`
+}
+
+// PlanEnvironmentReassignment computes the changes needed to remap room
+// environments according to mapping (old environment ID → new environment ID)
+// without modifying the map.
+//
+// If areaIDs is non-empty, only rooms in those areas are considered.
+// Changes are returned sorted by room ID.
+func PlanEnvironmentReassignment(m *MudletMap, mapping map[int32]int32, areaIDs ...int32) []EnvChange {
+	if m == nil || len(mapping) == 0 {
+		return nil
+	}
+	var areas map[int32]struct{}
+	if len(areaIDs) > 0 {
+		areas = make(map[int32]struct{}, len(areaIDs))
+		for _, id := range areaIDs {
+			areas[id] = struct{}{}
+		}
+	}
+
+	var changes []EnvChange
+	for _, room := range m.Rooms {
+		if areas != nil {
+			if _, ok := areas[room.Area]; !ok {
+				continue
+			}
+		}
+		to, ok := mapping[room.Environment]
+		if !ok || to == room.Environment {
+			continue
+		}
+		changes = append(changes, EnvChange{RoomID: room.ID, From: room.Environment, To: to})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].RoomID < changes[j].RoomID })
+	return changes
+}
+
+// ApplyEnvironmentChanges applies previously planned changes to the map.
+// Changes referring to missing rooms are ignored.
+func ApplyEnvironmentChanges(m *MudletMap, changes []EnvChange) {
+	if m == nil {
+		return
+	}
+	for _, c := range changes {
+		if room := m.Rooms[c.RoomID]; room != nil {
+			room.Environment = c.To
+		}
+	}
+}
+
+// ReassignEnvironments remaps room environments in place according to
+// mapping (old environment ID → new environment ID), optionally restricted
+// to the given areas, and returns the changes that were made.
+//
+// Example, merging environment 27 into 3 across area 12:
+//
+//	changes := mapparser.ReassignEnvironments(m, map[int32]int32{27: 3}, 12)
+func ReassignEnvironments(m *MudletMap, mapping map[int32]int32, areaIDs ...int32) []EnvChange {
+	changes := PlanEnvironmentReassignment(m, mapping, areaIDs...)
+	ApplyEnvironmentChanges(m, changes)
+	return changes
+}
+
+// EnvColorSource tells where the color of an environment comes from.
+type EnvColorSource int
+
+const (
+	// EnvColorUndefined means the environment has no color of its own;
+	// Mudlet draws its rooms with the color of environment 1.
+	EnvColorUndefined EnvColorSource = iota
+	// EnvColorDefault is one of Mudlet's 16 default environment colors.
+	EnvColorDefault
+	// EnvColorCustom is a color defined in the map's CustomEnvColors.
+	EnvColorCustom
+	// EnvColorANSI is a color of the xterm 256-color palette (17-255),
+	// reached through the map's EnvColors mapping.
+	EnvColorANSI
+)
+
+// String returns the lowercase name of the source.
+func (s EnvColorSource) String() string {
+	switch s {
+	case EnvColorUndefined:
+		return "undefined"
+	case EnvColorDefault:
+		return "default"
+	case EnvColorCustom:
+		return "custom"
+	case EnvColorANSI:
+		return "ansi"
+	}
+	return fmt.Sprintf("EnvColorSource(%d)", int(s))
+}
+
+// MarshalText encodes the source as its name.
+func (s EnvColorSource) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// EnvironmentInfo describes an environment used by rooms of a map.
+type EnvironmentInfo struct {
+	ID int32 `json:"id"`
+	// Rooms is the number of rooms with this environment.
+	Rooms int `json:"rooms"`
+	// ColorEnv is the environment whose color is drawn, after the map's
+	// EnvColors mapping and the fallback for undefined environments.
+	ColorEnv int32          `json:"colorEnv"`
+	Source   EnvColorSource `json:"source"`
+	// Color is the resolved color. Renderer themes may replace the default
+	// colors.
+	Color Color `json:"color"`
+}
+
+// Environments returns the environments used by the map's rooms, sorted by
+// ID, with room counts and resolved colors. Environments whose Source is
+// [EnvColorUndefined] have no color defined anywhere in the map.
+func (m *MudletMap) Environments() []EnvironmentInfo {
+	counts := make(map[int32]int)
+	for _, room := range m.Rooms {
+		counts[room.Environment]++
+	}
+	result := make([]EnvironmentInfo, 0, len(counts))
+	for _, env := range sortedKeys(counts) {
+		info := EnvironmentInfo{ID: env, Rooms: counts[env]}
+		info.ColorEnv, info.Source, info.Color = m.ResolveEnvColor(env)
+		result = append(result, info)
+	}
+	return result
+}
+
+// ResolveEnvColor follows Mudlet's color lookup for a room environment:
+// the EnvColors mapping first, then the default colors, custom colors and,
+// for mapped environments, the ANSI palette. Anything else falls back to
+// environment 1. It returns the environment whose color is drawn, where
+// the color comes from and the color.
+func (m *MudletMap) ResolveEnvColor(env int32) (int32, EnvColorSource, Color) {
+	_, isMapped := m.EnvColors[env]
+	env = m.colorEnv(env)
+	if c, ok := DefaultEnvColor(env); ok {
+		return env, EnvColorDefault, c
+	}
+	if c, ok := m.CustomEnvColors[env]; ok {
+		return env, EnvColorCustom, c
+	}
+	if c, ok := ANSIColor(env); ok && isMapped {
+		return env, EnvColorANSI, c
+	}
+	c, _ := DefaultEnvColor(1)
+	return 1, EnvColorUndefined, c
+}
+
+// colorEnv returns the environment whose color rooms of env are drawn
+// with: its EnvColors mapping if any, which also keys CustomEnvColors.
+func (m *MudletMap) colorEnv(env int32) int32 {
+	if mapped, ok := m.EnvColors[env]; ok {
+		return mapped
+	}
+	return env
+}
+
+// defaultEnvColors are Mudlet's default colors for environments 1-16.
+var defaultEnvColors = [16][3]uint8{
+	{128, 0, 0}, {0, 128, 0}, {128, 128, 0}, {0, 0, 128},
+	{128, 0, 128}, {0, 128, 128}, {192, 192, 192}, {64, 64, 64},
+	{255, 0, 0}, {0, 255, 0}, {255, 255, 0}, {0, 0, 255},
+	{255, 0, 255}, {0, 255, 255}, {255, 255, 255}, {128, 128, 128},
+}
+
+// DefaultEnvColor returns Mudlet's default color of environments 1-16.
+func DefaultEnvColor(env int32) (Color, bool) {
+	if env < 1 || env > 16 {
+		return Color{}, false
+	}
+	c := defaultEnvColors[env-1]
+	return rgbColor(c[0], c[1], c[2]), true
+}
+
+// ANSIColor returns color n of the xterm 256-color palette for n in
+// 16-255: the 6x6x6 color cube followed by 24 shades of gray.
+func ANSIColor(n int32) (Color, bool) {
+	if n < 16 || n > 255 {
+		return Color{}, false
+	}
+	if n >= 232 {
+		k := uint8((n-232)*10 + 8)
+		return rgbColor(k, k, k), true
+	}
+	level := func(v int32) uint8 {
+		if v == 0 {
+			return 0
+		}
+		return uint8((v-1)*40 + 95)
+	}
+	base := n - 16
+	return rgbColor(level(base/36), level(base/6%6), level(base%6)), true
+}
+
+// rgbColor builds an opaque Qt RGB color from 8-bit components.
+func rgbColor(r, g, b uint8) Color {
+	return Color{Spec: 1, Red: uint16(r) * 0x101, Green: uint16(g) * 0x101, Blue: uint16(b) * 0x101, Alpha: 0xffff}
+}
@@ -0,0 +1,48 @@
+package mapparser
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Errors reported by the parser and by operations on maps. They are
+// wrapped with context, so test for them with [errors.Is]; the offset of a
+// parse failure is available by unwrapping to [ParseError] with
+// [errors.As].
+var (
+	// ErrNotAMudletMap reports data that does not start like a Mudlet map.
+	ErrNotAMudletMap = errors.New("not a Mudlet map")
+	// ErrUnsupportedVersion reports a map format version the parser
+	// cannot read, or only reads with [ParseOptions.LegacyMode].
+	ErrUnsupportedVersion = errors.New("unsupported map version")
+	// ErrTruncated reports map data ending in the middle of a structure.
+	ErrTruncated = errors.New("map data truncated")
+	// ErrRoomNotFound reports a room ID missing from the map.
+	ErrRoomNotFound = errors.New("room not found")
+	// ErrAreaNotFound reports an area ID missing from the map.
+	ErrAreaNotFound = errors.New("area not found")
+)
+
+// maxVersion bounds the format versions taken for a map. Mudlet is far
+// below it; a larger first word means the data is something else.
+const maxVersion = 255
+
+// newParseError wraps err with the offset it occurred at, marking running
+// out of data as [ErrTruncated].
+func newParseError(offset int, err error) *ParseError {
+	if !errors.Is(err, ErrTruncated) && (errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)) {
+		err = fmt.Errorf("%w: %w", ErrTruncated, err)
+	}
+	return &ParseError{Offset: offset, Err: err}
+}
+
+// roomNotFound returns an [ErrRoomNotFound] error for room id.
+func roomNotFound(id int32) error {
+	return fmt.Errorf("%w: %d", ErrRoomNotFound, id)
+}
+
+// areaNotFound returns an [ErrAreaNotFound] error for area id.
+func areaNotFound(id int32) error {
+	return fmt.Errorf("%w: %d", ErrAreaNotFound, id)
+}
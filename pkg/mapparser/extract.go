@@ -0,0 +1,218 @@
+package mapparser
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// ExtractArea returns a standalone map holding a copy of one area: its rooms,
+// labels, the environment colors they use and the map-level settings. The
+// result shares no data with m.
+//
+// Standard exits leading to other areas become exit stubs, keeping any door
+// on them; their weights, locks and custom lines are dropped. Special exits
+// leading out of the area are removed. Room hashes and player positions are
+// kept for the extracted rooms only, and the area's z-levels, bounds and
+// area exits are recomputed.
+func ExtractArea(m *MudletMap, areaID int32) (*MudletMap, error) {
+	if m == nil {
+		return nil, fmt.Errorf("nil map provided")
+	}
+	if _, ok := m.Areas[areaID]; !ok {
+		return nil, areaNotFound(areaID)
+	}
+	return extract(m, []int32{areaID},
+		func(*MudletRoom) bool { return true },
+		func(*MudletLabel) bool { return true }), nil
+}
+
+// ExtractAreaZRange is like [ExtractArea] but keeps only the rooms and labels
+// on z-levels minZ through maxZ, e.g. -5 and -1 for the underground part of
+// an area. Exits to rooms outside the window become exit stubs just like
+// exits to other areas.
+func ExtractAreaZRange(m *MudletMap, areaID, minZ, maxZ int32) (*MudletMap, error) {
+	if m == nil {
+		return nil, fmt.Errorf("nil map provided")
+	}
+	if _, ok := m.Areas[areaID]; !ok {
+		return nil, areaNotFound(areaID)
+	}
+	if minZ > maxZ {
+		return nil, fmt.Errorf("invalid z-level range %d..%d", minZ, maxZ)
+	}
+	return extractZRange(m, []int32{areaID}, minZ, maxZ), nil
+}
+
+// FilterZRange returns a standalone copy of the whole map keeping only the
+// rooms and labels on z-levels minZ through maxZ, both the map's labels and
+// the labels stored in version 21 areas. Areas with nothing left in the
+// window are dropped. Exits are handled as in [ExtractAreaZRange].
+func FilterZRange(m *MudletMap, minZ, maxZ int32) (*MudletMap, error) {
+	if m == nil {
+		return nil, fmt.Errorf("nil map provided")
+	}
+	if minZ > maxZ {
+		return nil, fmt.Errorf("invalid z-level range %d..%d", minZ, maxZ)
+	}
+	inRange := make(map[int32]bool)
+	for _, room := range m.Rooms {
+		if room.Z >= minZ && room.Z <= maxZ {
+			inRange[room.Area] = true
+		}
+	}
+	labelsInRange := func(areaID int32, labels []*MudletLabel) {
+		for _, l := range labels {
+			if z := int32(l.Pos.Z); z >= minZ && z <= maxZ {
+				inRange[areaID] = true
+			}
+		}
+	}
+	for areaID, labels := range m.Labels {
+		labelsInRange(areaID, labels)
+	}
+	for areaID, a := range m.Areas {
+		labelsInRange(areaID, a.Labels)
+	}
+	var areaIDs []int32
+	for id := range m.Areas {
+		if inRange[id] {
+			areaIDs = append(areaIDs, id)
+		}
+	}
+	return extractZRange(m, areaIDs, minZ, maxZ), nil
+}
+
+// Subgraph returns a standalone copy of m keeping only the given rooms, for
+// example the rooms of a route or a dungeon wing. Exits to rooms outside
+// the subset are handled as in [ExtractArea]: standard exits become stubs
+// and special exits are removed. The areas of the kept rooms are kept
+// without their labels, which cannot be tied to rooms. It fails with
+// [ErrRoomNotFound] if a room is missing from m.
+func Subgraph(m *MudletMap, roomIDs []int32) (*MudletMap, error) {
+	if m == nil {
+		return nil, fmt.Errorf("nil map provided")
+	}
+	keep := make(map[int32]bool, len(roomIDs))
+	areas := make(map[int32]bool)
+	for _, id := range roomIDs {
+		room := m.Rooms[id]
+		if room == nil {
+			return nil, roomNotFound(id)
+		}
+		keep[id] = true
+		if _, ok := m.Areas[room.Area]; ok {
+			areas[room.Area] = true
+		}
+	}
+	return extract(m, sortedKeys(areas),
+		func(r *MudletRoom) bool { return keep[r.ID] },
+		func(*MudletLabel) bool { return false }), nil
+}
+
+func extractZRange(m *MudletMap, areaIDs []int32, minZ, maxZ int32) *MudletMap {
+	return extract(m, areaIDs,
+		func(r *MudletRoom) bool { return r.Z >= minZ && r.Z <= maxZ },
+		func(l *MudletLabel) bool {
+			z := int32(l.Pos.Z)
+			return z >= minZ && z <= maxZ
+		})
+}
+
+// extract copies the given areas of m, with the rooms and labels accepted by
+// keepRoom and keepLabel, into a new map. Exits to rooms left behind become
+// stubs or are removed as described for [ExtractArea].
+func extract(m *MudletMap, areaIDs []int32, keepRoom func(*MudletRoom) bool, keepLabel func(*MudletLabel) bool) *MudletMap {
+	out := NewMudletMap()
+	out.Version = m.Version
+	out.MapSymbolFont = m.MapSymbolFont
+	out.MapFontFudgeFactor = m.MapFontFudgeFactor
+	out.UseOnlyMapFont = m.UseOnlyMapFont
+	out.UserData = maps.Clone(m.UserData)
+
+	envs := make(map[int32]bool)
+	for id, room := range m.Rooms {
+		if slices.Contains(areaIDs, room.Area) && keepRoom(room) {
+			out.Rooms[id] = room.DeepCopy()
+			envs[room.Environment] = true
+		}
+	}
+	for id, room := range out.Rooms {
+		for dir, dest := range room.Exits {
+			if dest == NoExit || out.Rooms[dest] != nil {
+				continue
+			}
+			door, hasDoor := room.Doors[ExitDirectionShortNames[dir]]
+			room.removeExit(dir)
+			if hasDoor {
+				room.Doors[ExitDirectionShortNames[dir]] = door
+			}
+			if !room.HasExitStub(dir) {
+				room.ExitStubs = append(room.ExitStubs, DirectionCode(dir))
+			}
+		}
+		for cmd, dest := range room.SpecialExits {
+			if out.Rooms[dest] == nil {
+				room.removeSpecialExit(cmd)
+			}
+		}
+		for hash, roomID := range m.RoomDbHashToRoomId {
+			if int32(roomID) == id {
+				out.RoomDbHashToRoomId[hash] = roomID
+			}
+		}
+		for profile, roomID := range m.RoomIdHash {
+			if roomID == id {
+				out.RoomIdHash[profile] = roomID
+			}
+		}
+	}
+
+	for env := range envs {
+		if c, ok := m.EnvColors[env]; ok {
+			out.EnvColors[env] = c
+		}
+		if c, ok := m.CustomEnvColors[m.colorEnv(env)]; ok {
+			out.CustomEnvColors[m.colorEnv(env)] = c
+		}
+	}
+
+	// Keep labels shared between an area and the map-level list shared.
+	labels := make(map[*MudletLabel]*MudletLabel)
+	copyLabels := func(ls []*MudletLabel) []*MudletLabel {
+		if ls == nil {
+			return nil
+		}
+		c := make([]*MudletLabel, 0, len(ls))
+		for _, l := range ls {
+			if !keepLabel(l) {
+				continue
+			}
+			if labels[l] == nil {
+				labels[l] = l.DeepCopy()
+			}
+			c = append(c, labels[l])
+		}
+		return c
+	}
+
+	areaRooms := make(map[int32][]uint32)
+	for _, id := range sortedKeys(out.Rooms) {
+		area := out.Rooms[id].Area
+		areaRooms[area] = append(areaRooms[area], uint32(id))
+	}
+	for _, areaID := range areaIDs {
+		a := m.Areas[areaID].deepCopy(copyLabels)
+		a.Rooms = areaRooms[areaID]
+		if a.Rooms == nil {
+			a.Rooms = []uint32{}
+		}
+		out.Areas[areaID] = a
+		if ls, ok := m.Labels[areaID]; ok {
+			out.Labels[areaID] = copyLabels(ls)
+		}
+		out.updateArea(a)
+	}
+
+	return out
+}
@@ -0,0 +1,194 @@
+package mapparser
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// TestExtractArea tests stubs, colors and hashes of an extracted area
+func TestExtractArea(t *testing.T) {
+	m := newMutationTestMap(t)
+	m.EnvColors[5] = 300
+	m.EnvColors[6] = 4
+	m.CustomEnvColors[300] = Color{Red: 0xFFFF, Alpha: 0xFFFF}
+	m.CustomEnvColors[301] = Color{Green: 0xFFFF, Alpha: 0xFFFF}
+	m.Rooms[1].Environment = 5
+	m.Rooms[3].Environment = 6
+	if err := m.ConnectRooms(2, ExitUp, 3, true); err != nil {
+		t.Fatal(err)
+	}
+	m.Rooms[2].Doors["up"] = DoorClosed
+	m.Rooms[2].ExitWeights["up"] = 5
+	m.Rooms[1].SpecialExits["portal"] = 3
+	m.RoomDbHashToRoomId["h1"] = 1
+	m.RoomDbHashToRoomId["h3"] = 3
+	m.Labels[1] = []*MudletLabel{{ID: 1, Text: "Here"}}
+
+	out, err := ExtractArea(m, 1)
+	if err != nil {
+		t.Fatalf("ExtractArea failed: %v", err)
+	}
+	if len(out.Rooms) != 2 || len(out.Areas) != 1 || out.Version != m.Version {
+		t.Fatalf("Unexpected extracted map: %d rooms, %d areas", len(out.Rooms), len(out.Areas))
+	}
+
+	r2 := out.Rooms[2]
+	if r2.HasExit(ExitUp) || !reflect.DeepEqual(r2.ExitStubs, []int32{DirectionCode(ExitUp)}) {
+		t.Errorf("Expected up exit converted to stub, got exits %v stubs %v", r2.Exits, r2.ExitStubs)
+	}
+	if r2.Doors["up"] != DoorClosed || len(r2.ExitWeights) != 0 {
+		t.Errorf("Expected door kept and weight dropped, got %v %v", r2.Doors, r2.ExitWeights)
+	}
+	if r2.Exits[ExitWest] != 1 {
+		t.Error("Expected exits within the area kept")
+	}
+	if len(out.Rooms[1].SpecialExits) != 0 {
+		t.Error("Expected special exit out of the area removed")
+	}
+	if len(out.Areas[1].AreaExits) != 0 {
+		t.Errorf("Expected no area exits, got %v", out.Areas[1].AreaExits)
+	}
+	if !reflect.DeepEqual(out.EnvColors, map[int32]int32{5: 300}) || !reflect.DeepEqual(out.CustomEnvColors, map[int32]Color{300: m.CustomEnvColors[300]}) {
+		t.Errorf("Unexpected env colors %v %v", out.EnvColors, out.CustomEnvColors)
+	}
+	if !reflect.DeepEqual(out.RoomDbHashToRoomId, map[string]uint32{"h1": 1}) {
+		t.Errorf("Unexpected hashes %v", out.RoomDbHashToRoomId)
+	}
+	if len(out.Labels[1]) != 1 || out.Labels[1][0] == m.Labels[1][0] {
+		t.Error("Expected labels copied")
+	}
+
+	// The source map is untouched
+	if m.Rooms[2].Exits[ExitUp] != 3 || len(m.Rooms[1].SpecialExits) != 1 {
+		t.Error("Expected source map unchanged")
+	}
+	if errs := ValidateMap(out); len(errs) != 0 {
+		t.Errorf("Expected valid extracted map, got %v", errs)
+	}
+
+	if _, err := ExtractArea(m, 42); err == nil {
+		t.Error("Expected error for missing area")
+	}
+}
+
+// TestExtractAreaLargeMap tests extraction from a real map
+func TestExtractAreaLargeMap(t *testing.T) {
+	if _, err := os.Stat(largeMapPath); os.IsNotExist(err) {
+		t.Skipf("Test fixture not found: %s", largeMapPath)
+	}
+	m, err := ParseMapFile(largeMapPath)
+	if err != nil {
+		t.Fatalf("ParseMapFile failed: %v", err)
+	}
+	out, err := ExtractArea(m, 51)
+	if err != nil {
+		t.Fatalf("ExtractArea failed: %v", err)
+	}
+	if len(out.Rooms) != len(m.Areas[51].Rooms) {
+		t.Errorf("Expected %d rooms, got %d", len(m.Areas[51].Rooms), len(out.Rooms))
+	}
+	for _, room := range out.Rooms {
+		for _, dest := range room.Exits {
+			if dest != NoExit && out.Rooms[dest] == nil {
+				t.Fatalf("Room %d has exit to missing room %d", room.ID, dest)
+			}
+		}
+	}
+}
+
+// TestExtractZRange tests z-level filtering of an area and of the whole map
+func TestExtractZRange(t *testing.T) {
+	m := newMutationTestMap(t)
+	if err := m.ConnectRooms(2, ExitDown, 3, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.MoveRoom(3, 1, 0, 0, -1); err != nil {
+		t.Fatal(err)
+	}
+	m.Labels[1] = []*MudletLabel{{ID: 1, Text: "Surface"}, {ID: 2, Text: "Cellar", Pos: Vector3D{Z: -1}}}
+
+	out, err := ExtractAreaZRange(m, 1, -5, -1)
+	if err != nil {
+		t.Fatalf("ExtractAreaZRange failed: %v", err)
+	}
+	if len(out.Rooms) != 1 || out.Rooms[3] == nil {
+		t.Fatalf("Expected only room 3, got %d rooms", len(out.Rooms))
+	}
+	r3 := out.Rooms[3]
+	if r3.HasExit(ExitUp) || !reflect.DeepEqual(r3.ExitStubs, []int32{DirectionCode(ExitUp)}) {
+		t.Errorf("Expected up exit converted to stub, got exits %v stubs %v", r3.Exits, r3.ExitStubs)
+	}
+	if len(out.Labels[1]) != 1 || out.Labels[1][0].Text != "Cellar" {
+		t.Errorf("Expected only the cellar label, got %v", out.Labels[1])
+	}
+	if !reflect.DeepEqual(out.Areas[1].ZLevels, []int32{-1}) {
+		t.Errorf("Expected z-levels [-1], got %v", out.Areas[1].ZLevels)
+	}
+	if errs := ValidateMap(out); len(errs) != 0 {
+		t.Errorf("Expected valid extracted map, got %v", errs)
+	}
+
+	out, err = FilterZRange(m, 0, 0)
+	if err != nil {
+		t.Fatalf("FilterZRange failed: %v", err)
+	}
+	if len(out.Rooms) != 2 || len(out.Areas) != 1 || out.Areas[2] != nil {
+		t.Errorf("Expected rooms 1 and 2 in area 1, got %d rooms, %d areas", len(out.Rooms), len(out.Areas))
+	}
+
+	// Version 21 keeps labels in the areas
+	m.Areas[2].Labels = []*MudletLabel{{ID: 3, Text: "Tower", Pos: Vector3D{Z: 4}}, {ID: 4, Text: "Roof", Pos: Vector3D{Z: 5}}}
+	out, err = FilterZRange(m, 4, 4)
+	if err != nil {
+		t.Fatalf("FilterZRange failed: %v", err)
+	}
+	if len(out.Rooms) != 0 || len(out.Areas) != 1 || out.Areas[2] == nil {
+		t.Fatalf("Expected only area 2 kept for its label, got %d rooms, %d areas", len(out.Rooms), len(out.Areas))
+	}
+	if ls := out.Areas[2].Labels; len(ls) != 1 || ls[0].Text != "Tower" {
+		t.Errorf("Expected only the tower label, got %v", ls)
+	}
+
+	if _, err := ExtractAreaZRange(m, 1, 0, -1); err == nil {
+		t.Error("Expected error for inverted range")
+	}
+}
+
+// TestSubgraph tests that only the listed rooms are kept, across areas
+func TestSubgraph(t *testing.T) {
+	m := newMutationTestMap(t)
+	if err := m.ConnectRooms(2, ExitUp, 3, true); err != nil {
+		t.Fatal(err)
+	}
+	m.Labels[1] = []*MudletLabel{{ID: 1, Text: "Here"}}
+
+	out, err := Subgraph(m, []int32{3, 2})
+	if err != nil {
+		t.Fatalf("Subgraph failed: %v", err)
+	}
+	if len(out.Rooms) != 2 || out.Rooms[1] != nil || len(out.Areas) != 2 {
+		t.Fatalf("Unexpected subgraph: rooms %v, %d areas", sortedKeys(out.Rooms), len(out.Areas))
+	}
+	r2 := out.Rooms[2]
+	if r2.HasExit(ExitWest) || !reflect.DeepEqual(r2.ExitStubs, []int32{DirectionCode(ExitWest)}) {
+		t.Errorf("Expected west exit converted to stub, got exits %v stubs %v", r2.Exits, r2.ExitStubs)
+	}
+	if r2.Exits[ExitUp] != 3 || out.Rooms[3].Exits[ExitDown] != 2 {
+		t.Error("Expected exits within the subset kept")
+	}
+	if !reflect.DeepEqual(out.Areas[1].Rooms, []uint32{2}) || len(out.Areas[1].AreaExits) != 1 {
+		t.Errorf("Unexpected area 1: rooms %v, area exits %v", out.Areas[1].Rooms, out.Areas[1].AreaExits)
+	}
+	if len(out.Labels[1]) != 0 {
+		t.Errorf("Expected labels dropped, got %v", out.Labels[1])
+	}
+	if m.Rooms[2].Exits[ExitWest] != 1 {
+		t.Error("Expected the source map unchanged")
+	}
+
+	if _, err := Subgraph(m, []int32{1, 99}); !errors.Is(err, ErrRoomNotFound) {
+		t.Errorf("Subgraph with a missing room = %v, expected ErrRoomNotFound", err)
+	}
+}
@@ -0,0 +1,103 @@
+package mapparser
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Fingerprint returns a SHA-256 hash, as hex, of the map's semantic content:
+// environment colors, room hashes, map user data and fonts, and the areas,
+// rooms and labels with all their properties. Two maps with the same
+// fingerprint render and route identically, so services can skip work when
+// an uploaded map has not really changed.
+//
+// The hash does not depend on the order of anything in the file, nor on
+// data that does not describe the world: the format version, player
+// positions (RoomIdHash), area room lists, z-levels, extents and area exits
+// (all derived from the rooms), and the saved 2D zoom of areas. Labels are
+// hashed in ID order whether they are stored per area or map-wide.
+func Fingerprint(m *MudletMap) (string, error) {
+	if m == nil {
+		return "", fmt.Errorf("nil map provided")
+	}
+	h := sha256.New()
+	enc := json.NewEncoder(h)
+	encode := func(v any) error {
+		if err := enc.Encode(v); err != nil {
+			return fmt.Errorf("fingerprint: %w", err)
+		}
+		return nil
+	}
+
+	err := encode(struct {
+		EnvColors          sortedIntMap[int32]
+		CustomEnvColors    sortedIntMap[Color]
+		RoomDbHashToRoomId map[string]uint32
+		UserData           map[string]string
+		MapSymbolFont      Font
+		MapFontFudgeFactor float64
+		UseOnlyMapFont     bool
+	}{
+		m.EnvColors, m.CustomEnvColors, m.RoomDbHashToRoomId, m.UserData,
+		m.MapSymbolFont, m.MapFontFudgeFactor, m.UseOnlyMapFont,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	for _, id := range sortedKeys(m.Areas) {
+		a := m.Areas[id]
+		labels := slices.Clone(a.Labels)
+		for _, l := range m.Labels[id] {
+			if !slices.Contains(labels, l) {
+				labels = append(labels, l)
+			}
+		}
+		slices.SortStableFunc(labels, func(x, y *MudletLabel) int { return cmp.Compare(x.ID, y.ID) })
+		err := encode(struct {
+			ID          int32
+			Name        string
+			GridMode    bool
+			IsZone      bool
+			ZoneAreaRef int32
+			UserData    map[string]string
+			Labels      []*MudletLabel
+		}{a.ID, a.Name, a.GridMode, a.IsZone, a.ZoneAreaRef, a.UserData, labels})
+		if err != nil {
+			return "", err
+		}
+	}
+	// Labels of areas missing from the area table still count
+	for _, id := range sortedKeys(m.Labels) {
+		if _, ok := m.Areas[id]; !ok {
+			if err := encode(m.Labels[id]); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	for _, id := range sortedKeys(m.Rooms) {
+		r := *m.Rooms[id]
+		r.ExitLocks = sortedCopy(r.ExitLocks)
+		r.ExitStubs = sortedCopy(r.ExitStubs)
+		r.SpecialExitLocks = slices.Clone(r.SpecialExitLocks)
+		slices.SortFunc(r.SpecialExitLocks, strings.Compare)
+		if err := encode(&r); err != nil {
+			return "", err
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// sortedCopy returns a sorted copy of s.
+func sortedCopy(s []int32) []int32 {
+	c := slices.Clone(s)
+	slices.Sort(c)
+	return c
+}
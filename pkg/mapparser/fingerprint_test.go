@@ -0,0 +1,80 @@
+package mapparser
+
+import (
+	"os"
+	"slices"
+	"testing"
+)
+
+// TestFingerprint tests which changes affect the fingerprint
+func TestFingerprint(t *testing.T) {
+	m := newMutationTestMap(t)
+	m.Rooms[1].ExitStubs = []int32{ExitNorth, ExitSouth}
+	m.Labels[1] = []*MudletLabel{{ID: 1, Text: "A"}, {ID: 2, Text: "B"}}
+	fp := func(m *MudletMap) string {
+		t.Helper()
+		s, err := Fingerprint(m)
+		if err != nil {
+			t.Fatalf("Fingerprint failed: %v", err)
+		}
+		return s
+	}
+	base := fp(m)
+	if len(base) != 64 {
+		t.Fatalf("Expected hex SHA-256, got %q", base)
+	}
+
+	same := m.DeepCopy()
+	same.Version = 21
+	same.RoomIdHash["profile"] = 2
+	slices.Reverse(same.Areas[1].Rooms)
+	slices.Reverse(same.Rooms[1].ExitStubs)
+	slices.Reverse(same.Labels[1])
+	same.Areas[1].Last2DMapZoom = 3
+	if got := fp(same); got != base {
+		t.Error("Expected fingerprint to ignore ordering, version and view state")
+	}
+
+	for name, change := range map[string]func(m *MudletMap){
+		"room name":   func(m *MudletMap) { m.Rooms[2].Name = "Renamed" },
+		"exit":        func(m *MudletMap) { m.Rooms[1].Exits[ExitUp] = 3 },
+		"label":       func(m *MudletMap) { m.Labels[1][0].Text = "C" },
+		"area name":   func(m *MudletMap) { m.Areas[2].Name = "Renamed" },
+		"env color":   func(m *MudletMap) { m.EnvColors[1] = 2 },
+		"user data":   func(m *MudletMap) { m.UserData["author"] = "me" },
+		"room hashes": func(m *MudletMap) { m.RoomDbHashToRoomId["h"] = 1 },
+	} {
+		c := m.DeepCopy()
+		change(c)
+		if fp(c) == base {
+			t.Errorf("Expected %s change to alter the fingerprint", name)
+		}
+	}
+
+	if _, err := Fingerprint(nil); err == nil {
+		t.Error("Expected error for nil map")
+	}
+}
+
+// TestFingerprintLargeMap tests that parsing the same file twice gives the
+// same fingerprint
+func TestFingerprintLargeMap(t *testing.T) {
+	if _, err := os.Stat(largeMapPath); os.IsNotExist(err) {
+		t.Skipf("Test fixture not found: %s", largeMapPath)
+	}
+	var prints []string
+	for range 2 {
+		m, err := ParseMapFile(largeMapPath)
+		if err != nil {
+			t.Fatalf("ParseMapFile failed: %v", err)
+		}
+		s, err := Fingerprint(m)
+		if err != nil {
+			t.Fatalf("Fingerprint failed: %v", err)
+		}
+		prints = append(prints, s)
+	}
+	if prints[0] != prints[1] {
+		t.Error("Expected stable fingerprint")
+	}
+}
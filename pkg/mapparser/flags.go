@@ -0,0 +1,146 @@
+package mapparser
+
+import "strings"
+
+// Built-in room flag names used by [DefaultFlagRules].
+const (
+	FlagNoPK    = "nopk"
+	FlagIndoors = "indoors"
+	FlagWater   = "water"
+	FlagLocked  = "locked"
+)
+
+// FlagRule derives a named flag for rooms matching all of its conditions.
+// A rule with no conditions matches no rooms.
+type FlagRule struct {
+	// Flag is the name reported for matching rooms.
+	Flag string `json:"flag"`
+
+	// UserDataKey requires the room to have this user data key. If
+	// UserDataValues is empty the value must be truthy, i.e. not empty,
+	// "0", "false", "no" or "off".
+	UserDataKey string `json:"userDataKey,omitempty"`
+	// UserDataValues lists accepted values for UserDataKey (case-insensitive).
+	UserDataValues []string `json:"userDataValues,omitempty"`
+
+	// Environments requires the room environment to be one of these IDs.
+	Environments []int32 `json:"environments,omitempty"`
+
+	// LockedRoom requires the room to be locked for pathfinding.
+	LockedRoom bool `json:"lockedRoom,omitempty"`
+	// LockedExits requires at least one locked standard or special exit.
+	LockedExits bool `json:"lockedExits,omitempty"`
+}
+
+// DefaultFlagRules returns rules for common gameplay flags based on user
+// data conventions: "no_pk" and "indoors" (truthy values), "terrain" set to
+// water/ocean/river/lake, and rooms locked for pathfinding.
+func DefaultFlagRules() []FlagRule {
+	return []FlagRule{
+		{Flag: FlagNoPK, UserDataKey: "no_pk"},
+		{Flag: FlagIndoors, UserDataKey: "indoors"},
+		{Flag: FlagWater, UserDataKey: TerrainKey, UserDataValues: []string{"water", "ocean", "river", "lake"}},
+		{Flag: FlagLocked, LockedRoom: true},
+	}
+}
+
+// Matches reports whether room satisfies every condition of the rule.
+func (fr FlagRule) Matches(room *MudletRoom) bool {
+	if room == nil {
+		return false
+	}
+	conditions := 0
+
+	if fr.UserDataKey != "" {
+		conditions++
+		value, ok := room.UserData[fr.UserDataKey]
+		if !ok {
+			return false
+		}
+		if len(fr.UserDataValues) == 0 {
+			switch strings.ToLower(strings.TrimSpace(value)) {
+			case "", "0", "false", "no", "off":
+				return false
+			}
+		} else if !containsFold(fr.UserDataValues, value) {
+			return false
+		}
+	}
+
+	if len(fr.Environments) > 0 {
+		conditions++
+		found := false
+		for _, env := range fr.Environments {
+			if env == room.Environment {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if fr.LockedRoom {
+		conditions++
+		if !room.IsLocked {
+			return false
+		}
+	}
+
+	if fr.LockedExits {
+		conditions++
+		if len(room.ExitLocks) == 0 && len(room.SpecialExitLocks) == 0 {
+			return false
+		}
+	}
+
+	return conditions > 0
+}
+
+// RoomFlags returns the flags of all rules matching room, in rule order and
+// without duplicates.
+func RoomFlags(room *MudletRoom, rules []FlagRule) []string {
+	var flags []string
+	for _, rule := range rules {
+		if rule.Flag == "" || !rule.Matches(room) {
+			continue
+		}
+		dup := false
+		for _, f := range flags {
+			if f == rule.Flag {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			flags = append(flags, rule.Flag)
+		}
+	}
+	return flags
+}
+
+// ComputeRoomFlags evaluates rules for every room in the map and returns the
+// flags of rooms that have at least one.
+func ComputeRoomFlags(m *MudletMap, rules []FlagRule) map[int32][]string {
+	result := make(map[int32][]string)
+	if m == nil {
+		return result
+	}
+	for id, room := range m.Rooms {
+		if flags := RoomFlags(room, rules); len(flags) > 0 {
+			result[id] = flags
+		}
+	}
+	return result
+}
+
+// containsFold reports whether list contains s, ignoring case.
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
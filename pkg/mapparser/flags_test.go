@@ -0,0 +1,41 @@
+package mapparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestRoomFlags tests flag derivation from user data and locks
+func TestRoomFlags(t *testing.T) {
+	m := NewMudletMap()
+	for i := int32(1); i <= 5; i++ {
+		m.Rooms[i] = NewMudletRoom(i)
+	}
+	m.Rooms[1].UserData["no_pk"] = "true"
+	m.Rooms[1].UserData["indoors"] = "yes"
+	m.Rooms[2].UserData["indoors"] = "0"
+	m.Rooms[2].UserData["terrain"] = "Ocean"
+	m.Rooms[3].IsLocked = true
+	m.Rooms[4].ExitLocks = []int32{ExitNorth}
+	m.Rooms[4].Environment = 7
+
+	rules := append(DefaultFlagRules(),
+		FlagRule{Flag: "gated", LockedExits: true, Environments: []int32{7}},
+		FlagRule{Flag: "nothing"},
+	)
+	got := ComputeRoomFlags(m, rules)
+	want := map[int32][]string{
+		1: {FlagNoPK, FlagIndoors},
+		2: {FlagWater},
+		3: {FlagLocked},
+		4: {"gated"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ComputeRoomFlags = %v, expected %v", got, want)
+	}
+
+	m.Rooms[4].Environment = 8
+	if flags := RoomFlags(m.Rooms[4], rules); flags != nil {
+		t.Errorf("Expected no flags when environment does not match, got %v", flags)
+	}
+}
@@ -0,0 +1,77 @@
+package mapparser
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// MapInfo is a summary of a map file produced by [InspectMap].
+type MapInfo struct {
+	// Version is the Mudlet map format version.
+	Version int32 `json:"version"`
+	// AreaCount is the number of areas in the map.
+	AreaCount int `json:"areaCount"`
+	// RoomCount is the number of rooms, summed from the areas' room lists.
+	RoomCount int `json:"roomCount"`
+	// Areas lists each area in ascending ID order.
+	Areas []AreaInfo `json:"areas"`
+}
+
+// AreaInfo summarizes one area in a [MapInfo].
+type AreaInfo struct {
+	ID        int32  `json:"id"`
+	Name      string `json:"name"`
+	RoomCount int    `json:"roomCount"`
+}
+
+// InspectMap reads only the header and area section of a map: the version,
+// area names and room counts. Rooms and labels, which make up most of a map
+// file, are not read, so this is much faster than [ParseMap] for listing
+// available maps.
+//
+// Room counts come from each area's stored room list, which Mudlet keeps in
+// sync with the rooms section.
+func InspectMap(reader io.Reader) (*MapInfo, error) {
+	p := &parser{
+		r:      NewBinaryReader(reader),
+		m:      NewMudletMap(),
+		legacy: true,
+	}
+	if err := p.parseHeader(); err != nil {
+		return nil, newParseError(p.r.Position(), err)
+	}
+
+	info := &MapInfo{
+		Version:   p.m.Version,
+		AreaCount: len(p.m.Areas),
+		Areas:     make([]AreaInfo, 0, len(p.m.Areas)),
+	}
+	for _, id := range sortedKeys(p.m.Areas) {
+		area := p.m.Areas[id]
+		info.Areas = append(info.Areas, AreaInfo{ID: id, Name: area.Name, RoomCount: len(area.Rooms)})
+		info.RoomCount += len(area.Rooms)
+	}
+	return info, nil
+}
+
+// InspectMapFile opens a map file and calls [InspectMap] on it.
+func InspectMapFile(filename string) (info *MapInfo, err error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("opening map file: %w", err)
+	}
+
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil {
+			if err != nil {
+				err = errors.Join(err, closeErr)
+			} else {
+				err = fmt.Errorf("closing map file: %w", closeErr)
+			}
+		}
+	}()
+
+	return InspectMap(file)
+}
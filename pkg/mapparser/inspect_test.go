@@ -0,0 +1,51 @@
+package mapparser
+
+import (
+	"os"
+	"testing"
+)
+
+// TestInspectMap tests that header inspection agrees with a full parse
+func TestInspectMap(t *testing.T) {
+	for _, path := range []string{smallMapPath, largeMapPath} {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			t.Skipf("Test fixture not found: %s", path)
+		}
+		info, err := InspectMapFile(path)
+		if err != nil {
+			t.Fatalf("InspectMapFile(%s) failed: %v", path, err)
+		}
+		m, err := ParseMapFile(path)
+		if err != nil {
+			t.Fatalf("ParseMapFile(%s) failed: %v", path, err)
+		}
+
+		if info.Version != m.Version || info.AreaCount != len(m.Areas) || info.RoomCount != len(m.Rooms) {
+			t.Errorf("%s: info %d/%d/%d, parsed %d/%d/%d", path,
+				info.Version, info.AreaCount, info.RoomCount, m.Version, len(m.Areas), len(m.Rooms))
+		}
+		for i, a := range info.Areas {
+			if i > 0 && info.Areas[i-1].ID >= a.ID {
+				t.Errorf("%s: areas not sorted by ID", path)
+			}
+			if m.Areas[a.ID].Name != a.Name {
+				t.Errorf("%s: area %d name %q, expected %q", path, a.ID, a.Name, m.Areas[a.ID].Name)
+			}
+		}
+	}
+
+	if _, err := InspectMapFile("nonexistent.map"); err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
+
+func BenchmarkInspectLargeMap(b *testing.B) {
+	if _, err := os.Stat(largeMapPath); os.IsNotExist(err) {
+		b.Skipf("Test fixture not found: %s", largeMapPath)
+	}
+	for i := 0; i < b.N; i++ {
+		if _, err := InspectMapFile(largeMapPath); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,375 @@
+package mapparser
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// JSONOptions configures JSON export via [WriteJSON] and
+// [ExportToJSONWithOptions].
+type JSONOptions struct {
+	// OmitPixmaps drops label image data, which otherwise dominates the
+	// output size as base64 text.
+	OmitPixmaps bool
+	// Compact disables indentation.
+	Compact bool
+}
+
+// ExportToJSONWithOptions writes the map structure to a JSON file using
+// the streaming encoder. Pass nil for opts to get the same output as
+// [ExportToJSON].
+func ExportToJSONWithOptions(m *Map, filename string, opts *JSONOptions) error {
+	if m == nil {
+		return fmt.Errorf("nil map provided")
+	}
+	return createJSONFile(m, filename, opts)
+}
+
+// ExportAreaToJSON writes one area to a JSON file, so huge maps can be
+// dumped zone by zone. See [ExportAreaToJSONWithOptions].
+func ExportAreaToJSON(m *Map, areaID int32, filename string) error {
+	return ExportAreaToJSONWithOptions(m, areaID, filename, nil)
+}
+
+// ExportAreaToJSONWithOptions writes one area to a JSON file with the same
+// structure as a full export, holding the area, its rooms and labels, the
+// environment colors its rooms use, their room hashes and the map-level
+// settings. Unlike [ExtractArea], rooms are written unchanged, so exits into
+// other areas keep their destination room IDs and the per-area files can be
+// joined again.
+func ExportAreaToJSONWithOptions(m *Map, areaID int32, filename string, opts *JSONOptions) error {
+	if m == nil {
+		return fmt.Errorf("nil map provided")
+	}
+	if _, ok := m.Areas[areaID]; !ok {
+		return areaNotFound(areaID)
+	}
+	return createJSONFile(m.areaView(areaID), filename, opts)
+}
+
+// areaView returns a map sharing m's data but holding only one area.
+func (m *MudletMap) areaView(areaID int32) *MudletMap {
+	v := *m
+	v.Areas = map[int32]*MudletArea{areaID: m.Areas[areaID]}
+	v.Rooms = make(map[int32]*MudletRoom)
+	v.Labels = make(map[int32][]*MudletLabel)
+	v.EnvColors = make(map[int32]int32)
+	v.CustomEnvColors = make(map[int32]Color)
+	v.RoomDbHashToRoomId = make(map[string]uint32)
+	v.RoomIdHash = make(map[string]int32)
+	for id, room := range m.Rooms {
+		if room.Area != areaID {
+			continue
+		}
+		v.Rooms[id] = room
+		if c, ok := m.EnvColors[room.Environment]; ok {
+			v.EnvColors[room.Environment] = c
+		}
+		if c, ok := m.CustomEnvColors[m.colorEnv(room.Environment)]; ok {
+			v.CustomEnvColors[m.colorEnv(room.Environment)] = c
+		}
+	}
+	for hash, id := range m.RoomDbHashToRoomId {
+		if v.Rooms[int32(id)] != nil {
+			v.RoomDbHashToRoomId[hash] = id
+		}
+	}
+	for profile, id := range m.RoomIdHash {
+		if v.Rooms[id] != nil {
+			v.RoomIdHash[profile] = id
+		}
+	}
+	if ls, ok := m.Labels[areaID]; ok {
+		v.Labels[areaID] = ls
+	}
+	return &v
+}
+
+// createJSONFile creates filename and streams m into it.
+func createJSONFile(m *Map, filename string, opts *JSONOptions) (err error) {
+	f, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("creating json file: %w", err)
+	}
+	defer func() {
+		if cerr := f.Close(); cerr != nil {
+			err = errors.Join(err, fmt.Errorf("closing json file: %w", cerr))
+		}
+	}()
+	return WriteJSON(m, f, opts)
+}
+
+// WriteJSON streams the map as JSON to w.
+//
+// Unlike encoding the whole [MudletMap] with encoding/json, rooms, areas
+// and labels are encoded one at a time into a buffered writer, so the
+// complete document is never held in memory. The resulting JSON has the
+// same structure as [ExportToJSON].
+//
+// The output is deterministic, so exports of the same map can be diffed:
+// objects keyed by IDs (rooms, areas, labels, environment colors, per-z
+// extents) are in ascending numeric order, other objects in ascending key
+// order, and lists keep the order of the map file.
+func WriteJSON(m *Map, w io.Writer, opts *JSONOptions) error {
+	if m == nil {
+		return fmt.Errorf("nil map provided")
+	}
+	if opts == nil {
+		opts = &JSONOptions{}
+	}
+	sw := &jsonStreamWriter{w: bufio.NewWriterSize(w, 64*1024), opts: opts}
+
+	sw.raw("{")
+	sw.field("version", m.Version)
+	if len(m.EnvColors) > 0 {
+		sw.field("envColors", sortedIntMap[int32](m.EnvColors))
+	}
+	if len(m.CustomEnvColors) > 0 {
+		sw.field("customEnvColors", sortedIntMap[Color](m.CustomEnvColors))
+	}
+	if len(m.RoomDbHashToRoomId) > 0 {
+		sw.field("roomDbHashToRoomId", m.RoomDbHashToRoomId)
+	}
+	if len(m.RoomIdHash) > 0 {
+		sw.field("roomIdHash", m.RoomIdHash)
+	}
+	if len(m.UserData) > 0 {
+		sw.field("userData", m.UserData)
+	}
+	if a := m.Attribution(); !a.IsZero() {
+		sw.field("attribution", a)
+	}
+	sw.field("mapSymbolFont", m.MapSymbolFont)
+	sw.field("mapFontFudgeFactor", m.MapFontFudgeFactor)
+	sw.field("useOnlyMapFont", m.UseOnlyMapFont)
+
+	sw.key("areas")
+	if m.Areas == nil {
+		sw.raw("null")
+	} else {
+		sw.objectOf(sortedKeys(m.Areas), func(id int32) any {
+			return sw.area(m.Areas[id])
+		})
+	}
+
+	sw.key("rooms")
+	if m.Rooms == nil {
+		sw.raw("null")
+	} else {
+		sw.objectOf(sortedKeys(m.Rooms), func(id int32) any {
+			return m.Rooms[id]
+		})
+	}
+
+	if len(m.Labels) > 0 {
+		sw.key("labels")
+		sw.objectOf(sortedKeys(m.Labels), func(id int32) any {
+			return sw.labels(m.Labels[id])
+		})
+	}
+
+	sw.newline(0)
+	sw.raw("}\n")
+
+	if sw.err != nil {
+		return fmt.Errorf("encoding json: %w", sw.err)
+	}
+	if err := sw.w.Flush(); err != nil {
+		return fmt.Errorf("writing json: %w", err)
+	}
+	return nil
+}
+
+// jsonStreamWriter emits a JSON document piece by piece, remembering the
+// first error so callers can check once at the end.
+type jsonStreamWriter struct {
+	w     *bufio.Writer
+	opts  *JSONOptions
+	err   error
+	comma bool // whether the next top-level field needs a leading comma
+}
+
+func (sw *jsonStreamWriter) raw(s string) {
+	if sw.err != nil {
+		return
+	}
+	_, sw.err = sw.w.WriteString(s)
+}
+
+func (sw *jsonStreamWriter) newline(depth int) {
+	if sw.opts.Compact {
+		return
+	}
+	sw.raw("\n")
+	for i := 0; i < depth; i++ {
+		sw.raw("  ")
+	}
+}
+
+// key writes a top-level object key, preceded by a comma when needed.
+func (sw *jsonStreamWriter) key(name string) {
+	if sw.comma {
+		sw.raw(",")
+	}
+	sw.comma = true
+	sw.newline(1)
+	sw.raw(strconv.Quote(name))
+	if sw.opts.Compact {
+		sw.raw(":")
+	} else {
+		sw.raw(": ")
+	}
+}
+
+// field writes a top-level key and its encoded value.
+func (sw *jsonStreamWriter) field(name string, v any) {
+	sw.key(name)
+	sw.value(v, 1)
+}
+
+// value encodes v at the given nesting depth.
+func (sw *jsonStreamWriter) value(v any, depth int) {
+	if sw.err != nil {
+		return
+	}
+	var data []byte
+	if sw.opts.Compact {
+		data, sw.err = json.Marshal(v)
+	} else {
+		prefix := ""
+		for i := 0; i < depth; i++ {
+			prefix += "  "
+		}
+		data, sw.err = json.MarshalIndent(v, prefix, "  ")
+	}
+	if sw.err != nil {
+		return
+	}
+	_, sw.err = sw.w.Write(data)
+}
+
+// objectOf writes a JSON object keyed by int32 IDs, encoding each value
+// produced by fn individually.
+func (sw *jsonStreamWriter) objectOf(ids []int32, fn func(id int32) any) {
+	if len(ids) == 0 {
+		sw.raw("{}")
+		return
+	}
+	sw.raw("{")
+	for i, id := range ids {
+		if i > 0 {
+			sw.raw(",")
+		}
+		sw.newline(2)
+		sw.raw(`"` + strconv.FormatInt(int64(id), 10) + `"`)
+		if sw.opts.Compact {
+			sw.raw(":")
+		} else {
+			sw.raw(": ")
+		}
+		sw.value(fn(id), 2)
+	}
+	sw.newline(1)
+	sw.raw("}")
+}
+
+// area returns the area to encode, with label pixmaps stripped if requested.
+func (sw *jsonStreamWriter) area(a *MudletArea) *MudletArea {
+	if a == nil || !sw.opts.OmitPixmaps || len(a.Labels) == 0 {
+		return a
+	}
+	stripped := *a
+	stripped.Labels = sw.labels(a.Labels)
+	return &stripped
+}
+
+// labels returns the labels to encode, with pixmaps stripped if requested.
+func (sw *jsonStreamWriter) labels(labels []*MudletLabel) []*MudletLabel {
+	if !sw.opts.OmitPixmaps {
+		return labels
+	}
+	stripped := make([]*MudletLabel, len(labels))
+	for i, l := range labels {
+		if l == nil {
+			continue
+		}
+		c := *l
+		c.Pixmap = nil
+		stripped[i] = &c
+	}
+	return stripped
+}
+
+// sortedIntMap is an int32-keyed map that encodes its keys in numeric
+// order. encoding/json sorts integer keys as strings, placing "10" before
+// "2".
+type sortedIntMap[V any] map[int32]V
+
+// MarshalJSON implements [json.Marshaler].
+func (m sortedIntMap[V]) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return []byte("null"), nil
+	}
+	buf := []byte{'{'}
+	for i, k := range sortedKeys(m) {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, '"')
+		buf = strconv.AppendInt(buf, int64(k), 10)
+		buf = append(buf, '"', ':')
+		v, err := json.Marshal(m[k])
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, v...)
+	}
+	return append(buf, '}'), nil
+}
+
+// MarshalJSON encodes the map with objects keyed by IDs in ascending
+// numeric order, as [WriteJSON] does, plus a top-level "attribution" object
+// when the map carries attribution metadata. The document decodes to the
+// same value as WriteJSON's, but its top-level keys are in another order:
+// the remaining map fields first, then "envColors", "customEnvColors",
+// "areas", "rooms", "labels" and "attribution".
+func (m *MudletMap) MarshalJSON() ([]byte, error) {
+	type plain MudletMap
+	out := struct {
+		*plain
+		EnvColors       sortedIntMap[int32]          `json:"envColors,omitempty"`
+		CustomEnvColors sortedIntMap[Color]          `json:"customEnvColors,omitempty"`
+		Areas           sortedIntMap[*MudletArea]    `json:"areas"`
+		Rooms           sortedIntMap[*MudletRoom]    `json:"rooms"`
+		Labels          sortedIntMap[[]*MudletLabel] `json:"labels,omitempty"`
+		Attribution     *Attribution                 `json:"attribution,omitempty"`
+	}{
+		plain:           (*plain)(m),
+		EnvColors:       m.EnvColors,
+		CustomEnvColors: m.CustomEnvColors,
+		Areas:           m.Areas,
+		Rooms:           m.Rooms,
+		Labels:          m.Labels,
+	}
+	if a := m.Attribution(); !a.IsZero() {
+		out.Attribution = &a
+	}
+	return json.Marshal(out)
+}
+
+// MarshalJSON encodes the area with its per-z extents in numeric z order.
+func (a *MudletArea) MarshalJSON() ([]byte, error) {
+	type plain MudletArea
+	return json.Marshal(struct {
+		*plain
+		XMaxForZ sortedIntMap[int32] `json:"xMaxForZ,omitempty"`
+		YMaxForZ sortedIntMap[int32] `json:"yMaxForZ,omitempty"`
+		XMinForZ sortedIntMap[int32] `json:"xMinForZ,omitempty"`
+		YMinForZ sortedIntMap[int32] `json:"yMinForZ,omitempty"`
+	}{(*plain)(a), a.XMaxForZ, a.YMaxForZ, a.XMinForZ, a.YMinForZ})
+}
@@ -0,0 +1,277 @@
+package mapparser
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// newJSONTestMap builds a map with an area, rooms and a label with a pixmap
+func newJSONTestMap() *MudletMap {
+	m := NewMudletMap()
+	m.Version = 20
+	m.UserData["author"] = "tester"
+	m.EnvColors[1] = 4
+	m.Areas[1] = NewMudletArea(1, "Area 1")
+	for i := int32(1); i <= 3; i++ {
+		room := NewMudletRoom(i)
+		room.Area = 1
+		room.X = i
+		room.Name = "Room"
+		m.Rooms[i] = room
+	}
+	m.Rooms[1].Exits[ExitEast] = 2
+	m.Labels[1] = []*MudletLabel{{ID: 7, Text: "Sign", Pixmap: []byte{0x89, 'P', 'N', 'G'}}}
+	return m
+}
+
+// decodeJSON decodes JSON into generic values for structural comparison
+func decodeJSON(t *testing.T, data []byte) map[string]any {
+	t.Helper()
+	var v map[string]any
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, data)
+	}
+	return v
+}
+
+// TestWriteJSONMatchesReflection tests that the streaming encoder produces the
+// same document as encoding the whole map with encoding/json
+func TestWriteJSONMatchesReflection(t *testing.T) {
+	m := newJSONTestMap()
+
+	want, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, compact := range []bool{false, true} {
+		var buf bytes.Buffer
+		if err := WriteJSON(m, &buf, &JSONOptions{Compact: compact}); err != nil {
+			t.Fatalf("WriteJSON failed: %v", err)
+		}
+		if !reflect.DeepEqual(decodeJSON(t, buf.Bytes()), decodeJSON(t, want)) {
+			t.Errorf("Streaming output (compact=%v) differs from reflection output:\n%s", compact, buf.String())
+		}
+	}
+}
+
+// TestWriteJSONOmitPixmaps tests that pixmaps can be dropped without touching the map
+func TestWriteJSONOmitPixmaps(t *testing.T) {
+	m := newJSONTestMap()
+
+	var buf bytes.Buffer
+	if err := WriteJSON(m, &buf, &JSONOptions{OmitPixmaps: true}); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	labels := decodeJSON(t, buf.Bytes())["labels"].(map[string]any)["1"].([]any)
+	if _, ok := labels[0].(map[string]any)["pixmap"]; ok {
+		t.Error("Pixmap should be omitted")
+	}
+	if len(m.Labels[1][0].Pixmap) == 0 {
+		t.Error("Omitting pixmaps must not modify the map")
+	}
+
+	if err := WriteJSON(nil, &buf, nil); err == nil {
+		t.Error("Expected error for nil map")
+	}
+}
+
+// TestWriteJSONDeterministic tests stable output and numeric ID ordering
+func TestWriteJSONDeterministic(t *testing.T) {
+	m := newJSONTestMap()
+	for _, id := range []int32{10, 2, 33} {
+		room := NewMudletRoom(id)
+		room.Area = 1
+		room.UserData = map[string]string{"b": "2", "a": "1", "c": "3"}
+		m.Rooms[id] = room
+		m.EnvColors[id] = id
+	}
+	m.Areas[1].XMaxForZ = map[int32]int32{10: 1, 2: 2, -1: 3}
+
+	var first []byte
+	for i := 0; i < 5; i++ {
+		var buf bytes.Buffer
+		if err := WriteJSON(m, &buf, &JSONOptions{Compact: true}); err != nil {
+			t.Fatalf("WriteJSON failed: %v", err)
+		}
+		if i == 0 {
+			first = buf.Bytes()
+		} else if !bytes.Equal(first, buf.Bytes()) {
+			t.Fatal("Expected identical output across exports")
+		}
+	}
+
+	inOrder := func(doc []byte, keys ...string) {
+		t.Helper()
+		last := -1
+		for _, k := range keys {
+			i := bytes.Index(doc, []byte(k))
+			if i < 0 || i < last {
+				t.Errorf("Expected %s after the previous keys in %s", k, doc)
+			}
+			last = i
+		}
+	}
+	inOrder(first, `"envColors":{"1":4,"2":2,"10":10,"33":33}`)
+	inOrder(first, `"rooms":{"1":`, `"2":{"id":2`, `"3":`, `"10":{"id":10`, `"33":{"id":33`)
+	inOrder(first, `"xMaxForZ":{"-1":3,"2":2,"10":1}`)
+	inOrder(first, `"userData":{"a":"1","b":"2","c":"3"}`)
+
+	// Reflection-based encoding uses the same ordering
+	reflected, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inOrder(reflected, `"envColors":{"1":4,"2":2,"10":10,"33":33}`)
+	inOrder(reflected, `"xMaxForZ":{"-1":3,"2":2,"10":1}`)
+}
+
+// TestExportToJSON tests writing JSON to a file
+func TestExportToJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "map.json")
+	if err := ExportToJSON(newJSONTestMap(), path); err != nil {
+		t.Fatalf("ExportToJSON failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := decodeJSON(t, data); v["version"] != float64(20) {
+		t.Errorf("Expected version 20, got %v", v["version"])
+	}
+}
+
+// TestExportAreaToJSON tests that an area export holds only that area and
+// keeps exits into other areas
+func TestExportAreaToJSON(t *testing.T) {
+	m := newMutationTestMap(t)
+	if err := m.ConnectRooms(2, ExitUp, 3, false); err != nil {
+		t.Fatal(err)
+	}
+	m.EnvColors[5] = 300
+	m.EnvColors[6] = 4
+	m.CustomEnvColors[300] = Color{Spec: 1, Red: 0xFFFF, Alpha: 0xFFFF}
+	m.Rooms[1].Environment = 5
+	m.Rooms[3].Environment = 6
+	m.RoomDbHashToRoomId["h3"] = 3
+
+	path := filepath.Join(t.TempDir(), "area.json")
+	if err := ExportAreaToJSON(m, 1, path); err != nil {
+		t.Fatalf("ExportAreaToJSON failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out MudletMap
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Areas) != 1 || out.Areas[1] == nil || len(out.Rooms) != 2 {
+		t.Fatalf("Expected area 1 with 2 rooms, got %d areas, %d rooms", len(out.Areas), len(out.Rooms))
+	}
+	if out.Rooms[2].Exits[ExitUp] != 3 {
+		t.Error("Expected exit into area 2 kept")
+	}
+	if len(out.EnvColors) != 1 || out.EnvColors[5] != 300 || len(out.RoomDbHashToRoomId) != 0 {
+		t.Errorf("Unexpected env colors %v or hashes %v", out.EnvColors, out.RoomDbHashToRoomId)
+	}
+	if len(out.CustomEnvColors) != 1 || out.CustomEnvColors[300] != m.CustomEnvColors[300] {
+		t.Errorf("Expected the mapped custom color kept, got %v", out.CustomEnvColors)
+	}
+	if len(m.Rooms) != 3 || len(m.EnvColors) != 2 {
+		t.Error("Expected source map unchanged")
+	}
+
+	if err := ExportAreaToJSON(m, 42, path); err == nil {
+		t.Error("Expected error for missing area")
+	}
+}
+
+// benchmarkLargeMap parses the large fixture once for JSON benchmarks
+func benchmarkLargeMap(b *testing.B) *MudletMap {
+	b.Helper()
+	if _, err := os.Stat(largeMapPath); os.IsNotExist(err) {
+		b.Skipf("Test fixture not found: %s", largeMapPath)
+	}
+	m, err := ParseMapFile(largeMapPath)
+	if err != nil {
+		b.Fatalf("Failed to parse map: %v", err)
+	}
+	return m
+}
+
+// BenchmarkJSONReflection benchmarks encoding the whole map with encoding/json
+func BenchmarkJSONReflection(b *testing.B) {
+	m := benchmarkLargeMap(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		enc := json.NewEncoder(io.Discard)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(m); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkWriteJSON benchmarks the streaming encoder with default options
+func BenchmarkWriteJSON(b *testing.B) {
+	m := benchmarkLargeMap(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := WriteJSON(m, io.Discard, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkWriteJSONCompactNoPixmaps benchmarks the fastest streaming configuration
+func BenchmarkWriteJSONCompactNoPixmaps(b *testing.B) {
+	m := benchmarkLargeMap(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := WriteJSON(m, io.Discard, &JSONOptions{Compact: true, OmitPixmaps: true}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// TestExportLabelAssets tests writing label pixmaps and the manifest
+func TestExportLabelAssets(t *testing.T) {
+	m := newJSONTestMap()
+	m.Labels[1] = append(m.Labels[1], &MudletLabel{ID: 3, Text: "No image", Width: 2, Height: 1})
+	m.Areas[1].Labels = nil
+
+	dir := t.TempDir()
+	manifest, err := ExportLabelAssets(m, dir)
+	if err != nil {
+		t.Fatalf("ExportLabelAssets failed: %v", err)
+	}
+	if len(manifest.Labels) != 2 {
+		t.Fatalf("Expected 2 manifest entries, got %d", len(manifest.Labels))
+	}
+	if manifest.Labels[0].ID != 3 || manifest.Labels[0].Path != "" {
+		t.Errorf("Label without pixmap should come first with empty path, got %+v", manifest.Labels[0])
+	}
+	if manifest.Labels[1].Path != "labels/1/7.png" {
+		t.Errorf("Unexpected asset path %q", manifest.Labels[1].Path)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "labels", "1", "7.png"))
+	if err != nil || !bytes.Equal(data, m.Labels[1][0].Pixmap) {
+		t.Errorf("Label image not written correctly: %v", err)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(dir, LabelManifestFile))
+	if err != nil {
+		t.Fatalf("Manifest not written: %v", err)
+	}
+	var decoded LabelManifest
+	if err := json.Unmarshal(raw, &decoded); err != nil || !reflect.DeepEqual(&decoded, manifest) {
+		t.Errorf("Manifest file does not match returned manifest: %v", err)
+	}
+}
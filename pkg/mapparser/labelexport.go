@@ -0,0 +1,116 @@
+package mapparser
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// LabelAsset describes one exported label image in a [LabelManifest].
+type LabelAsset struct {
+	ID     int32    `json:"id"`
+	AreaID int32    `json:"areaId"`
+	Path   string   `json:"path,omitempty"` // relative to the manifest; empty if the label has no pixmap
+	Pos    Vector3D `json:"pos"`
+	Width  float64  `json:"width"`
+	Height float64  `json:"height"`
+	Text   string   `json:"text,omitempty"`
+
+	NoScaling bool `json:"noScaling"`
+	ShowOnTop bool `json:"showOnTop"`
+}
+
+// LabelManifest lists label assets written by [ExportLabelAssets].
+type LabelManifest struct {
+	Labels []LabelAsset `json:"labels"`
+}
+
+// LabelManifestFile is the name of the manifest written by [ExportLabelAssets].
+const LabelManifestFile = "labels.json"
+
+// ExportLabelAssets writes each label pixmap to
+// <dir>/labels/<areaID>/<labelID>.png and a manifest of label positions and
+// sizes to <dir>/labels.json, so viewers can load label imagery on demand.
+//
+// Labels without a pixmap are listed in the manifest with an empty Path.
+// Labels are taken from both map-level (version < 21) and area-level
+// (version >= 21) storage.
+func ExportLabelAssets(m *MudletMap, dir string) (*LabelManifest, error) {
+	if m == nil {
+		return nil, fmt.Errorf("nil map provided")
+	}
+
+	manifest := &LabelManifest{Labels: []LabelAsset{}}
+	for _, areaID := range labelAreaIDs(m) {
+		labels := append([]*MudletLabel(nil), m.GetLabelsForArea(areaID)...)
+		sort.Slice(labels, func(i, j int) bool { return labels[i].ID < labels[j].ID })
+
+		for _, lbl := range labels {
+			asset := LabelAsset{
+				ID:        lbl.ID,
+				AreaID:    areaID,
+				Pos:       lbl.Pos,
+				Width:     lbl.Width,
+				Height:    lbl.Height,
+				Text:      lbl.Text,
+				NoScaling: lbl.NoScaling,
+				ShowOnTop: lbl.ShowOnTop,
+			}
+			if len(lbl.Pixmap) > 0 {
+				rel := filepath.Join("labels", strconv.Itoa(int(areaID)), strconv.Itoa(int(lbl.ID))+".png")
+				path := filepath.Join(dir, rel)
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					return nil, fmt.Errorf("creating label directory: %w", err)
+				}
+				if err := os.WriteFile(path, lbl.Pixmap, 0o644); err != nil {
+					return nil, fmt.Errorf("writing label %d in area %d: %w", lbl.ID, areaID, err)
+				}
+				asset.Path = filepath.ToSlash(rel)
+			}
+			manifest.Labels = append(manifest.Labels, asset)
+		}
+	}
+
+	if err := writeJSONFile(filepath.Join(dir, LabelManifestFile), manifest); err != nil {
+		return nil, fmt.Errorf("writing label manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// labelAreaIDs returns, in ascending order, the IDs of areas that have labels
+// in either map-level or area-level storage.
+func labelAreaIDs(m *MudletMap) []int32 {
+	areaIDs := make(map[int32]struct{}, len(m.Labels)+len(m.Areas))
+	for id := range m.Labels {
+		areaIDs[id] = struct{}{}
+	}
+	for id, area := range m.Areas {
+		if len(area.Labels) > 0 {
+			areaIDs[id] = struct{}{}
+		}
+	}
+	return sortedKeys(areaIDs)
+}
+
+// writeJSONFile writes v as indented JSON to path, creating parent directories.
+func writeJSONFile(path string, v any) (err error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); cerr != nil {
+			err = errors.Join(err, cerr)
+		}
+	}()
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
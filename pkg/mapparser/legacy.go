@@ -0,0 +1,66 @@
+package mapparser
+
+import "fmt"
+
+const (
+	// minVersion is the oldest map format read without
+	// [ParseOptions.LegacyMode].
+	minVersion = 6
+	// minLegacyVersion is the oldest map format LegacyMode attempts.
+	minLegacyVersion = 3
+)
+
+// checkVersion rejects map versions the parser cannot read.
+func (p *parser) checkVersion() error {
+	version := p.m.Version
+	if version >= minVersion {
+		return nil
+	}
+	if version < minLegacyVersion {
+		return fmt.Errorf("%w %d", ErrUnsupportedVersion, version)
+	}
+	if !p.legacy {
+		return fmt.Errorf("%w %d: versions before %d need ParseOptions.LegacyMode", ErrUnsupportedVersion, version, minVersion)
+	}
+	return nil
+}
+
+// warnLegacy reports the data a legacy map lacks through p.warn.
+func (p *parser) warnLegacy() {
+	if p.warn == nil || p.m.Version >= minVersion {
+		return
+	}
+	for _, msg := range legacyWarnings(p.m.Version) {
+		p.warn(msg)
+	}
+}
+
+// legacyWarnings describes the fields of the current format that a map of
+// the given version does not store.
+func legacyWarnings(version int32) []string {
+	var msgs []string
+	msgs = appendMissingFields(msgs, "map", headerSchema, version)
+	msgs = appendMissingFields(msgs, "map", bodySchema, version)
+	msgs = appendMissingFields(msgs, "area", areaSchema, version)
+	msgs = appendMissingFields(msgs, "label", labelSchema, version)
+	return appendMissingFields(msgs, "room", roomSchema, version)
+}
+
+// appendMissingFields appends a message for each field of schema that is
+// still stored today but was added after version. A field stored in
+// several encodings over time counts from its first one.
+func appendMissingFields[T any](msgs []string, record string, schema []field[T], version int32) []string {
+	first := make(map[string]int32)
+	for _, f := range schema {
+		if since, ok := first[f.name]; !ok || f.since < since {
+			first[f.name] = f.since
+		}
+	}
+	for _, f := range schema {
+		if f.until == 0 && first[f.name] > version {
+			msgs = append(msgs, fmt.Sprintf("version %d map: %s %s (%s) is not stored before version %d and is left empty",
+				version, record, f.name, f.qtType, first[f.name]))
+		}
+	}
+	return msgs
+}
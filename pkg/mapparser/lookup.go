@@ -0,0 +1,105 @@
+package mapparser
+
+import (
+	"strings"
+)
+
+// VnumKey is the room user data key [ByVnum] reads when given an empty key.
+const VnumKey = "vnum"
+
+// RoomKey identifies a room from live game data such as GMCP Room.Info or
+// MSDP ROOM messages. Leave fields the game does not send empty.
+type RoomKey struct {
+	// Hash is the room hash registered in RoomDbHashToRoomId.
+	Hash string
+	// Vnum is the game's room number, as stored in room user data.
+	Vnum string
+	// Name and Area identify a room by its name and area name. Both are
+	// needed, and the pair must match exactly one room.
+	Name string
+	Area string
+}
+
+// RoomResolver finds the room matching key, or returns nil if it cannot.
+type RoomResolver func(m *MudletMap, key RoomKey) *MudletRoom
+
+// DefaultResolvers is the resolution chain [LookupRoom] uses when none is
+// given: room hash, vnum under [VnumKey], then name and area.
+var DefaultResolvers = []RoomResolver{ByHash, ByVnum(""), ByNameAndArea}
+
+// LookupRoom resolves a room from live game identifiers by trying each
+// resolver in chain in turn, returning the first room found, or nil. With
+// no resolvers, [DefaultResolvers] is used.
+//
+// Example:
+//
+//	room := mapparser.LookupRoom(m, mapparser.RoomKey{Vnum: "1234"},
+//	    mapparser.ByVnum("roomid"), mapparser.ByNameAndArea)
+func LookupRoom(m *MudletMap, key RoomKey, chain ...RoomResolver) *MudletRoom {
+	if m == nil {
+		return nil
+	}
+	if len(chain) == 0 {
+		chain = DefaultResolvers
+	}
+	for _, resolve := range chain {
+		if room := resolve(m, key); room != nil {
+			return room
+		}
+	}
+	return nil
+}
+
+// ByHash resolves a room through the map's room hash index.
+func ByHash(m *MudletMap, key RoomKey) *MudletRoom {
+	if key.Hash == "" {
+		return nil
+	}
+	return m.RoomByHash(key.Hash)
+}
+
+// ByVnum returns a resolver matching key.Vnum against the room user data
+// stored under userDataKey, or [VnumKey] if userDataKey is empty. If
+// several rooms carry the vnum, the lowest room ID wins.
+func ByVnum(userDataKey string) RoomResolver {
+	if userDataKey == "" {
+		userDataKey = VnumKey
+	}
+	return func(m *MudletMap, key RoomKey) *MudletRoom {
+		if key.Vnum == "" {
+			return nil
+		}
+		var found *MudletRoom
+		for _, room := range m.Rooms {
+			if v, ok := room.UserData[userDataKey]; ok && v == key.Vnum {
+				if found == nil || room.ID < found.ID {
+					found = room
+				}
+			}
+		}
+		return found
+	}
+}
+
+// ByNameAndArea resolves a room by exact room name and case-insensitive
+// area name. It returns nil if the pair is ambiguous.
+func ByNameAndArea(m *MudletMap, key RoomKey) *MudletRoom {
+	if key.Name == "" || key.Area == "" {
+		return nil
+	}
+	var found *MudletRoom
+	for _, room := range m.Rooms {
+		if room.Name != key.Name {
+			continue
+		}
+		area := m.Areas[room.Area]
+		if area == nil || !strings.EqualFold(area.Name, key.Area) {
+			continue
+		}
+		if found != nil {
+			return nil
+		}
+		found = room
+	}
+	return found
+}
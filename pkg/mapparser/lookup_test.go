@@ -0,0 +1,76 @@
+package mapparser
+
+import (
+	"testing"
+)
+
+// newLookupTestMap returns a map with three rooms: two named "Road" in
+// different areas and a shop carrying a vnum.
+func newLookupTestMap() *MudletMap {
+	m := NewMudletMap()
+	m.Areas[1] = NewMudletArea(1, "Town")
+	m.Areas[2] = NewMudletArea(2, "Forest")
+	for _, r := range []struct {
+		id, area int32
+		name     string
+	}{{1, 1, "Road"}, {2, 2, "Road"}, {3, 1, "Shop"}} {
+		room := NewMudletRoom(r.id)
+		room.Area, room.Name = r.area, r.name
+		m.Rooms[r.id] = room
+	}
+	m.Rooms[3].UserData["vnum"] = "3001"
+	m.Rooms[2].UserData["roomid"] = "77"
+	m.RoomDbHashToRoomId["abc"] = 2
+	return m
+}
+
+// TestLookupRoom tests each default resolver and chain order
+func TestLookupRoom(t *testing.T) {
+	m := newLookupTestMap()
+	tests := []struct {
+		name string
+		key  RoomKey
+		want int32
+	}{
+		{"hash", RoomKey{Hash: "abc"}, 2},
+		{"vnum", RoomKey{Vnum: "3001"}, 3},
+		{"name and area", RoomKey{Name: "Road", Area: "forest"}, 2},
+		{"hash first", RoomKey{Hash: "abc", Vnum: "3001"}, 2},
+		{"fallback", RoomKey{Hash: "nope", Name: "Shop", Area: "Town"}, 3},
+		{"name without area", RoomKey{Name: "Shop"}, 0},
+		{"unknown", RoomKey{Vnum: "1"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := LookupRoom(m, tt.key)
+			if tt.want == 0 {
+				if got != nil {
+					t.Errorf("Expected no room, got %d", got.ID)
+				}
+				return
+			}
+			if got == nil || got.ID != tt.want {
+				t.Errorf("Expected room %d, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestLookupRoomCustomChain tests a custom vnum key and ambiguous names
+func TestLookupRoomCustomChain(t *testing.T) {
+	m := newLookupTestMap()
+	if got := LookupRoom(m, RoomKey{Vnum: "77"}, ByVnum("roomid")); got == nil || got.ID != 2 {
+		t.Errorf("Expected room 2 by custom vnum key, got %v", got)
+	}
+	if got := LookupRoom(m, RoomKey{Vnum: "3001"}, ByVnum("roomid")); got != nil {
+		t.Errorf("Expected default vnum key unused, got %d", got.ID)
+	}
+
+	m.Rooms[2].Area = 1
+	if got := LookupRoom(m, RoomKey{Name: "Road", Area: "Town"}); got != nil {
+		t.Errorf("Expected nil for ambiguous name, got %d", got.ID)
+	}
+	if LookupRoom(nil, RoomKey{Hash: "abc"}) != nil {
+		t.Error("Expected nil for nil map")
+	}
+}
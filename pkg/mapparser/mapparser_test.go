@@ -1,8 +1,10 @@
 package mapparser
 
 import (
+	"bytes"
 	"os"
 	"testing"
+	"unsafe"
 )
 
 // Test fixtures paths
@@ -464,23 +466,64 @@ func TestValidateMap(t *testing.T) {
 
 	// Test broken exit
 	m.Version = 20
+	m.Areas[0] = NewMudletArea(0, "Area 0")
 	room := NewMudletRoom(1)
 	room.Exits[ExitNorth] = 999 // points to non-existent room
 	m.Rooms[1] = room
 
 	errs = ValidateMap(m)
-	if len(errs) != 1 || errs[0].Type != "broken_exit" {
+	if len(errs) != 1 || errs[0].Type != "broken_exit" || errs[0].Severity != SeverityError {
 		t.Error("Expected broken_exit error for exit to non-existent room")
 	}
 
-	// Add target room - should now be valid
+	// Add target room with a return exit - should now be valid
 	m.Rooms[999] = NewMudletRoom(999)
+	m.Rooms[999].Y = 1
+	m.Rooms[999].Exits[ExitSouth] = 1
 	errs = ValidateMap(m)
 	if len(errs) != 0 {
 		t.Errorf("Expected no errors after adding target room, got %d", len(errs))
 	}
 }
 
+// TestValidateMapRules tests area, reciprocity and coordinate checks
+func TestValidateMapRules(t *testing.T) {
+	m := NewMudletMap()
+	m.Version = 20
+	m.Areas[1] = NewMudletArea(1, "Area 1")
+	for i := int32(1); i <= 4; i++ {
+		room := NewMudletRoom(i)
+		room.Area = 1
+		room.X = i
+		m.Rooms[i] = room
+	}
+	m.Rooms[1].Exits[ExitEast] = 2 // no west exit back from room 2
+	m.Rooms[3].X = 2               // same position as room 2
+	m.Rooms[4].Area = 7            // area does not exist
+
+	errs := ValidateMap(m)
+	want := []struct {
+		typ, severity string
+		room          int32
+	}{
+		{"asymmetric_exit", SeverityWarning, 1},
+		{"duplicate_coordinates", SeverityWarning, 3},
+		{"missing_area", SeverityError, 4},
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("Expected %d validation errors, got %d: %+v", len(want), len(errs), errs)
+	}
+	for i, w := range want {
+		if errs[i].Type != w.typ || errs[i].Severity != w.severity || errs[i].RoomID != w.room {
+			t.Errorf("errs[%d] = %+v, expected %s/%s for room %d", i, errs[i], w.typ, w.severity, w.room)
+		}
+	}
+
+	if OppositeExit(ExitIn) != ExitOut || OppositeExit(ExitNortheast) != ExitSouthwest || OppositeExit(12) != -1 {
+		t.Error("OppositeExit returned unexpected directions")
+	}
+}
+
 // TestGetMapStats tests statistics computation
 func TestGetMapStats(t *testing.T) {
 	m := NewMudletMap()
@@ -519,6 +562,169 @@ func TestGetMapStats(t *testing.T) {
 	}
 }
 
+// TestGetAreaStats tests per-level room, exit and environment statistics
+// and GetRoomsAtZ
+func TestGetAreaStats(t *testing.T) {
+	m := NewMudletMap()
+	m.Areas[1] = NewMudletArea(1, "Town")
+	coords := [][3]int32{{0, 0, 0}, {2, -1, 0}, {1, 1, 1}, {5, 5, 0}}
+	for i, c := range coords {
+		room := NewMudletRoom(int32(i + 1))
+		room.Area = 1
+		room.X, room.Y, room.Z = c[0], c[1], c[2]
+		room.Environment = int32(10 + i%2)
+		m.Rooms[room.ID] = room
+	}
+	m.Rooms[4].Area = 2
+	m.Rooms[1].Exits[ExitEast] = 2
+	m.Rooms[2].Exits[ExitWest] = 1
+	m.Rooms[2].SpecialExits["climb"] = 3
+
+	stats := GetAreaStats(m, 1)
+	if stats.Name != "Town" || stats.Rooms != 3 || stats.Exits != 3 {
+		t.Errorf("Unexpected totals: %+v", stats)
+	}
+	if stats.Environments[10] != 2 || stats.Environments[11] != 1 {
+		t.Errorf("Unexpected environments: %v", stats.Environments)
+	}
+	if want := (BoundingBox{MinX: 0, MinY: -1, MinZ: 0, MaxX: 2, MaxY: 1, MaxZ: 1}); stats.BoundingBox != want {
+		t.Errorf("BoundingBox = %+v, expected %+v", stats.BoundingBox, want)
+	}
+	if len(stats.Levels) != 2 || stats.Levels[0].Z != 0 || stats.Levels[1].Z != 1 {
+		t.Fatalf("Unexpected levels: %+v", stats.Levels)
+	}
+	ground := stats.Levels[0]
+	if ground.Rooms != 2 || ground.Exits != 3 || ground.BoundingBox != (BoundingBox{MinY: -1, MaxX: 2}) {
+		t.Errorf("Unexpected ground level: %+v", ground)
+	}
+
+	rooms := m.GetRoomsAtZ(1, 0)
+	if len(rooms) != 2 || rooms[0].ID != 1 || rooms[1].ID != 2 {
+		t.Errorf("Unexpected rooms at z=0: %v", rooms)
+	}
+	if empty := GetAreaStats(m, 9); empty.Rooms != 0 || empty.Levels != nil {
+		t.Errorf("Expected empty stats for unknown area, got %+v", empty)
+	}
+}
+
+// TestParseMapBackends tests that the stream, ReaderAt and mmap backends
+// produce the same map, and that SkipLabels drops only labels
+func TestParseMapBackends(t *testing.T) {
+	data, err := os.ReadFile(largeMapPath)
+	if err != nil {
+		t.Skipf("Test fixture not found: %s", largeMapPath)
+	}
+	want, err := ParseMap(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ParseMap failed: %v", err)
+	}
+	wantSum, _ := Fingerprint(want)
+
+	parsers := map[string]func() (*MudletMap, error){
+		"file": func() (*MudletMap, error) { return ParseMapFile(largeMapPath) },
+		"mmap": func() (*MudletMap, error) { return ParseMapFileWithOptions(largeMapPath, &ParseOptions{Mmap: true}) },
+		"at": func() (*MudletMap, error) {
+			return ParseMapAt(bytes.NewReader(data), int64(len(data)), nil)
+		},
+		"bytes": func() (*MudletMap, error) { return ParseMapBytes(data, nil) },
+		"low memory": func() (*MudletMap, error) {
+			return ParseMapFileWithOptions(largeMapPath, &ParseOptions{LowMemory: true})
+		},
+	}
+	for name, parse := range parsers {
+		m, err := parse()
+		if err != nil {
+			t.Fatalf("%s: parse failed: %v", name, err)
+		}
+		if sum, _ := Fingerprint(m); sum != wantSum {
+			t.Errorf("%s: fingerprint %s, expected %s", name, sum, wantSum)
+		}
+	}
+
+	m, err := ParseMapAt(bytes.NewReader(data), int64(len(data)), &ParseOptions{SkipLabels: true})
+	if err != nil {
+		t.Fatalf("ParseMapAt with SkipLabels failed: %v", err)
+	}
+	if len(m.Labels) != 0 || m.RoomCount() != want.RoomCount() {
+		t.Errorf("Expected %d rooms and no labels, got %d rooms and %d label areas", want.RoomCount(), m.RoomCount(), len(m.Labels))
+	}
+	for id, area := range m.Areas {
+		if len(area.Labels) != 0 {
+			t.Errorf("Area %d kept %d labels", id, len(area.Labels))
+		}
+	}
+}
+
+// TestParseMapBytesShared tests that label images parsed from memory point
+// into the input instead of being copied
+func TestParseMapBytesShared(t *testing.T) {
+	data, err := os.ReadFile(largeMapPath)
+	if err != nil {
+		t.Skipf("fixture not available: %v", err)
+	}
+	m, err := ParseMapBytes(data, nil)
+	if err != nil {
+		t.Fatalf("ParseMapBytes failed: %v", err)
+	}
+	images := 0
+	for _, labels := range m.Labels {
+		for _, l := range labels {
+			if len(l.Pixmap) == 0 {
+				continue
+			}
+			images++
+			start := &data[0]
+			end := &data[len(data)-1]
+			p := &l.Pixmap[0]
+			if uintptr(unsafe.Pointer(p)) < uintptr(unsafe.Pointer(start)) || uintptr(unsafe.Pointer(p)) > uintptr(unsafe.Pointer(end)) {
+				t.Fatalf("Label %d image was copied", l.ID)
+			}
+			if cap(l.Pixmap) != len(l.Pixmap) {
+				t.Fatalf("Label %d image can be appended into the input", l.ID)
+			}
+		}
+	}
+	if images == 0 {
+		t.Skip("fixture has no label images")
+	}
+}
+
+// TestParseLowMemory tests that low-memory parsing leaves empty room maps
+// nil and shares repeated user data values
+func TestParseLowMemory(t *testing.T) {
+	if _, err := os.Stat(largeMapPath); err != nil {
+		t.Skipf("fixture not available: %v", err)
+	}
+	m, err := ParseMapFileWithOptions(largeMapPath, &ParseOptions{LowMemory: true})
+	if err != nil {
+		t.Fatalf("ParseMapFileWithOptions failed: %v", err)
+	}
+	values := make(map[string]*byte)
+	nilDoors, shared := 0, 0
+	for _, room := range m.Rooms {
+		if room.Doors == nil {
+			nilDoors++
+		} else if len(room.Doors) == 0 {
+			t.Fatalf("Room %d has an empty, non-nil Doors map", room.ID)
+		}
+		for _, v := range room.UserData {
+			if v == "" || len(v) > 256 {
+				continue
+			}
+			if p, ok := values[v]; !ok {
+				values[v] = unsafe.StringData(v)
+			} else if p == unsafe.StringData(v) {
+				shared++
+			} else {
+				t.Fatalf("User data value %q of room %d is not shared", v, room.ID)
+			}
+		}
+	}
+	if nilDoors == 0 || shared == 0 {
+		t.Errorf("Expected nil door maps and shared values, got %d and %d", nilDoors, shared)
+	}
+}
+
 // BenchmarkParseSmallMap benchmarks parsing small map
 func BenchmarkParseSmallMap(b *testing.B) {
 	if _, err := os.Stat(smallMapPath); os.IsNotExist(err) {
@@ -542,3 +748,199 @@ func BenchmarkParseLargeMap(b *testing.B) {
 		ParseMapFile(largeMapPath)
 	}
 }
+
+// TestReassignEnvironments tests bulk environment remapping
+func TestReassignEnvironments(t *testing.T) {
+	m := NewMudletMap()
+	for i := int32(1); i <= 4; i++ {
+		room := NewMudletRoom(i)
+		room.Area = 1 + i%2
+		room.Environment = 27
+		m.Rooms[i] = room
+	}
+	m.Rooms[4].Environment = 5
+
+	plan := PlanEnvironmentReassignment(m, map[int32]int32{27: 3}, 2)
+	if len(plan) != 2 || plan[0].RoomID != 1 || plan[1].RoomID != 3 || plan[0].From != 27 || plan[0].To != 3 {
+		t.Fatalf("Unexpected plan for area 2: %+v", plan)
+	}
+	if m.Rooms[1].Environment != 27 {
+		t.Error("Planning must not modify the map")
+	}
+
+	changes := ReassignEnvironments(m, map[int32]int32{27: 3})
+	if len(changes) != 3 {
+		t.Errorf("Expected 3 changes across all areas, got %d", len(changes))
+	}
+	for _, id := range []int32{1, 2, 3} {
+		if m.Rooms[id].Environment != 3 {
+			t.Errorf("Room %d environment = %d, expected 3", id, m.Rooms[id].Environment)
+		}
+	}
+	if m.Rooms[4].Environment != 5 {
+		t.Error("Unmapped environment should be left unchanged")
+	}
+}
+
+// TestEnvironments tests the catalog of environments in use and their colors
+func TestEnvironments(t *testing.T) {
+	m := NewMudletMap()
+	custom := Color{Spec: 1, Red: 0x1010, Green: 0x2020, Blue: 0x3030, Alpha: 0xffff}
+	m.CustomEnvColors[300] = custom
+	m.EnvColors[40] = 196 // ANSI red
+	m.EnvColors[41] = 300 // mapped to a custom color
+	for i, env := range []int32{5, 5, 300, 40, 41, 99} {
+		room := NewMudletRoom(int32(i + 1))
+		room.Environment = env
+		m.Rooms[room.ID] = room
+	}
+
+	want := []struct {
+		id       int32
+		rooms    int
+		colorEnv int32
+		source   EnvColorSource
+		rgb      [3]uint8
+	}{
+		{5, 2, 5, EnvColorDefault, [3]uint8{128, 0, 128}},
+		{40, 1, 196, EnvColorANSI, [3]uint8{255, 0, 0}},
+		{41, 1, 300, EnvColorCustom, [3]uint8{0x10, 0x20, 0x30}},
+		{99, 1, 1, EnvColorUndefined, [3]uint8{128, 0, 0}},
+		{300, 1, 300, EnvColorCustom, [3]uint8{0x10, 0x20, 0x30}},
+	}
+	got := m.Environments()
+	if len(got) != len(want) {
+		t.Fatalf("Environments() returned %d entries, expected %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		g := got[i]
+		r, gr, b, a := g.Color.ToRGBA()
+		if g.ID != w.id || g.Rooms != w.rooms || g.ColorEnv != w.colorEnv || g.Source != w.source ||
+			[3]uint8{r, gr, b} != w.rgb || a != 255 {
+			t.Errorf("entry %d = %+v (rgb %d,%d,%d), expected %+v", i, g, r, gr, b, w)
+		}
+	}
+	if s := EnvColorANSI.String(); s != "ansi" {
+		t.Errorf("EnvColorANSI.String() = %q", s)
+	}
+}
+
+// TestExitLockAndDoorHelpers tests the lock and door accessors of rooms
+func TestExitLockAndDoorHelpers(t *testing.T) {
+	room := NewMudletRoom(1)
+	room.ExitLocks = []int32{DirectionCode(ExitNorth), DirectionCode(ExitUp)}
+	room.ExitStubs = []int32{DirectionCode(ExitWest)}
+	room.Doors["e"] = DoorClosed
+	room.Doors["up"] = DoorLocked
+	room.SpecialExitLocks = []string{"swim"}
+
+	if !room.IsExitLocked(ExitNorth) || !room.IsExitLocked(ExitUp) || room.IsExitLocked(ExitSouth) {
+		t.Errorf("IsExitLocked mismatch for locks %v", room.ExitLocks)
+	}
+	if room.IsExitLocked(-1) || room.IsExitLocked(12) {
+		t.Error("IsExitLocked should be false for invalid directions")
+	}
+	if !room.HasExitStub(ExitWest) || room.HasExitStub(ExitNorth) || room.HasExitStub(12) {
+		t.Errorf("HasExitStub mismatch for stubs %v", room.ExitStubs)
+	}
+	if got := room.DoorState(ExitEast); got != DoorClosed {
+		t.Errorf("DoorState(east) = %d, expected %d", got, DoorClosed)
+	}
+	if got := room.DoorState(ExitUp); got != DoorLocked {
+		t.Errorf("DoorState(up) = %d, expected %d", got, DoorLocked)
+	}
+	if got := room.DoorState(ExitWest); got != DoorNone {
+		t.Errorf("DoorState(west) = %d, expected none", got)
+	}
+	if got := room.DoorState(42); got != DoorNone {
+		t.Errorf("DoorState(42) = %d, expected none", got)
+	}
+	if !room.SpecialExitLocked("swim") || room.SpecialExitLocked("climb") {
+		t.Errorf("SpecialExitLocked mismatch for locks %v", room.SpecialExitLocks)
+	}
+}
+
+// TestDirectionCodes tests the mapping between exit directions and Mudlet's
+// direction codes
+func TestDirectionCodes(t *testing.T) {
+	want := map[int]int32{ExitNorth: 1, ExitNortheast: 2, ExitNorthwest: 3, ExitEast: 4,
+		ExitWest: 5, ExitSouth: 6, ExitSoutheast: 7, ExitSouthwest: 8,
+		ExitUp: 9, ExitDown: 10, ExitIn: 11, ExitOut: 12}
+	for dir, code := range want {
+		if got := DirectionCode(dir); got != code {
+			t.Errorf("DirectionCode(%s) = %d, expected %d", ExitDirectionNames[dir], got, code)
+		}
+		if got := DirectionIndex(code); got != dir {
+			t.Errorf("DirectionIndex(%d) = %d, expected %d", code, got, dir)
+		}
+	}
+	if DirectionCode(-1) != 0 || DirectionCode(12) != 0 || DirectionIndex(0) != -1 || DirectionIndex(AreaExitOther) != -1 {
+		t.Error("Expected invalid directions and codes rejected")
+	}
+}
+
+// TestExitLocksFromFile tests that exit locks read from a map file fall on
+// the locked exits
+func TestExitLocksFromFile(t *testing.T) {
+	if _, err := os.Stat(largeMapPath); os.IsNotExist(err) {
+		t.Skipf("Test fixture not found: %s", largeMapPath)
+	}
+	m, err := ParseMapFile(largeMapPath)
+	if err != nil {
+		t.Fatalf("Failed to parse map: %v", err)
+	}
+
+	// Room 1733 has locks on its north, southeast and southwest exits, and
+	// unlocked exits northwest and down
+	room := m.Rooms[1733]
+	for _, dir := range []int{ExitNorth, ExitSoutheast, ExitSouthwest} {
+		if !room.HasExit(dir) || !room.IsExitLocked(dir) {
+			t.Errorf("Expected a locked %s exit, locks %v", ExitDirectionNames[dir], room.ExitLocks)
+		}
+	}
+	for _, dir := range []int{ExitNorthwest, ExitDown} {
+		if !room.HasExit(dir) || room.IsExitLocked(dir) {
+			t.Errorf("Expected an unlocked %s exit, locks %v", ExitDirectionNames[dir], room.ExitLocks)
+		}
+	}
+
+	// Every lock in the file is on an existing exit
+	for _, r := range m.Rooms {
+		for _, code := range r.ExitLocks {
+			if dir := DirectionIndex(code); dir < 0 || !r.HasExit(dir) {
+				t.Fatalf("Room %d has lock code %d without an exit", r.ID, code)
+			}
+		}
+	}
+}
+
+// TestSymbolFont tests the effective symbol font settings and defaults
+func TestSymbolFont(t *testing.T) {
+	m := NewMudletMap()
+	sf := m.SymbolFont()
+	if sf.Font.Family != DefaultSymbolFontFamily || sf.Font.PointSizeF != 12 || sf.FudgeFactor != 1 || sf.OnlyMapFont {
+		t.Errorf("default SymbolFont = %+v", sf)
+	}
+
+	m.MapSymbolFont = Font{Family: "Noto Sans Symbols", PointSizeF: 9, Weight: 50}
+	m.MapFontFudgeFactor = 1.5
+	m.UseOnlyMapFont = true
+	sf = m.SymbolFont()
+	if sf.Font.Family != "Noto Sans Symbols" || !sf.OnlyMapFont || sf.Font.Bold() {
+		t.Errorf("SymbolFont = %+v", sf)
+	}
+	if got := sf.Scale(10); got != 15 {
+		t.Errorf("Scale(10) = %g, expected 15", got)
+	}
+
+	if _, err := os.Stat(smallMapPath); err != nil {
+		t.Skipf("fixture not available: %v", err)
+	}
+	parsed, err := ParseMapFile(smallMapPath)
+	if err != nil {
+		t.Fatalf("ParseMapFile failed: %v", err)
+	}
+	if f := parsed.MapSymbolFont; f.Family != DefaultSymbolFontFamily || f.Weight != 50 || f.PointSizeF != 12 || !f.Kerning {
+		t.Errorf("parsed mapSymbolFont = %+v", f)
+	}
+}
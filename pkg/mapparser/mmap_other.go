@@ -0,0 +1,13 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package mapparser
+
+import (
+	"errors"
+	"os"
+)
+
+// mmapFile reports that memory-mapping is not supported on this platform.
+func mmapFile(*os.File, int64) ([]byte, func(), error) {
+	return nil, nil, errors.New("memory-mapping not supported")
+}
@@ -0,0 +1,22 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package mapparser
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// mmapFile maps the first size bytes of f read-only. The returned function
+// unmaps them; the data must not be used afterwards.
+func mmapFile(f *os.File, size int64) ([]byte, func(), error) {
+	if size <= 0 || int64(int(size)) != size {
+		return nil, nil, errors.New("file size not mappable")
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() { _ = syscall.Munmap(data) }, nil
+}
@@ -0,0 +1,307 @@
+package mapparser
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MMP (MUD Map Protocol) is the XML interchange format understood by Mudlet's
+// XML map importer and by several standalone mapping tools. A document looks
+// like:
+//
+//	<map>
+//	  <areas>
+//	    <area id="1" name="Town" />
+//	  </areas>
+//	  <rooms>
+//	    <room id="1" area="1" title="Market" environment="2">
+//	      <coord x="0" y="0" z="0" />
+//	      <exit direction="north" target="2" door="1" />
+//	    </room>
+//	  </rooms>
+//	  <environments>
+//	    <environment id="2" color="2" htmlcolor="#008000" />
+//	  </environments>
+//	</map>
+//
+// Exits whose direction is not one of the 12 standard names are treated as
+// special exits, with the direction used as the command.
+
+// mmpDocument is the root element of an MMP XML document.
+type mmpDocument struct {
+	XMLName      xml.Name         `xml:"map"`
+	Areas        []mmpArea        `xml:"areas>area"`
+	Rooms        []mmpRoom        `xml:"rooms>room"`
+	Environments []mmpEnvironment `xml:"environments>environment"`
+}
+
+type mmpArea struct {
+	ID   int32  `xml:"id,attr"`
+	Name string `xml:"name,attr"`
+}
+
+type mmpRoom struct {
+	ID          int32     `xml:"id,attr"`
+	Area        int32     `xml:"area,attr"`
+	Title       string    `xml:"title,attr"`
+	Environment int32     `xml:"environment,attr"`
+	Symbol      string    `xml:"symbol,attr,omitempty"`
+	Weight      int32     `xml:"weight,attr,omitempty"`
+	Coord       mmpCoord  `xml:"coord"`
+	Exits       []mmpExit `xml:"exit"`
+}
+
+type mmpCoord struct {
+	X int32 `xml:"x,attr"`
+	Y int32 `xml:"y,attr"`
+	Z int32 `xml:"z,attr"`
+}
+
+type mmpExit struct {
+	Direction string `xml:"direction,attr"`
+	Target    int32  `xml:"target,attr"`
+	Door      int32  `xml:"door,attr,omitempty"`
+	Lock      int32  `xml:"lock,attr,omitempty"`
+	Weight    int32  `xml:"weight,attr,omitempty"`
+}
+
+type mmpEnvironment struct {
+	ID        int32  `xml:"id,attr"`
+	Color     string `xml:"color,attr,omitempty"`
+	HTMLColor string `xml:"htmlcolor,attr,omitempty"`
+}
+
+// mmpImportVersion is the map format version assigned to maps created from
+// MMP documents, which carry no version information of their own.
+const mmpImportVersion = 20
+
+// ImportMMP reads an MMP XML document and converts it into a [MudletMap].
+//
+// Area room lists and z-levels are rebuilt from the imported rooms. Exits
+// with a non-standard direction are stored as special exits.
+func ImportMMP(reader io.Reader) (*MudletMap, error) {
+	var doc mmpDocument
+	if err := xml.NewDecoder(reader).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decoding mmp xml: %w", err)
+	}
+
+	m := NewMudletMap()
+	m.Version = mmpImportVersion
+
+	for _, a := range doc.Areas {
+		m.Areas[a.ID] = NewMudletArea(a.ID, a.Name)
+	}
+
+	for _, env := range doc.Environments {
+		if env.Color != "" {
+			c, err := strconv.ParseInt(env.Color, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("environment %d: invalid color %q: %w", env.ID, env.Color, err)
+			}
+			m.EnvColors[env.ID] = int32(c)
+		}
+		if env.HTMLColor != "" {
+			c, err := parseHTMLColor(env.HTMLColor)
+			if err != nil {
+				return nil, fmt.Errorf("environment %d: %w", env.ID, err)
+			}
+			m.CustomEnvColors[env.ID] = c
+		}
+	}
+
+	for _, mr := range doc.Rooms {
+		room := NewMudletRoom(mr.ID)
+		room.Area = mr.Area
+		room.Name = mr.Title
+		room.Environment = mr.Environment
+		room.Symbol = mr.Symbol
+		room.X, room.Y, room.Z = mr.Coord.X, mr.Coord.Y, mr.Coord.Z
+		if mr.Weight > 0 {
+			room.Weight = mr.Weight
+		}
+
+		for _, e := range mr.Exits {
+			dir := exitDirectionIndex(e.Direction)
+			if dir < 0 {
+				room.SpecialExits[e.Direction] = e.Target
+				continue
+			}
+			room.Exits[dir] = e.Target
+			key := ExitDirectionShortNames[dir]
+			if e.Door > DoorNone {
+				room.Doors[key] = e.Door
+			}
+			if e.Weight > 0 {
+				room.ExitWeights[key] = e.Weight
+			}
+			if e.Lock != 0 {
+				room.ExitLocks = append(room.ExitLocks, DirectionCode(dir))
+			}
+		}
+
+		if _, ok := m.Areas[room.Area]; !ok {
+			m.Areas[room.Area] = NewMudletArea(room.Area, "")
+		}
+		m.Rooms[room.ID] = room
+	}
+
+	rebuildAreaRooms(m)
+	ComputeAreaExits(m)
+	return m, nil
+}
+
+// ExportMMP writes the map as an MMP XML document.
+//
+// Areas, rooms and environments are emitted in ascending ID order so the
+// output is stable across runs. Labels, custom lines and user data have no
+// MMP representation and are not exported.
+func ExportMMP(m *MudletMap, writer io.Writer) error {
+	if m == nil {
+		return fmt.Errorf("nil map provided")
+	}
+
+	doc := mmpDocument{}
+
+	for _, id := range sortedKeys(m.Areas) {
+		doc.Areas = append(doc.Areas, mmpArea{ID: id, Name: m.Areas[id].Name})
+	}
+
+	for _, id := range sortedKeys(m.Rooms) {
+		room := m.Rooms[id]
+		mr := mmpRoom{
+			ID:          room.ID,
+			Area:        room.Area,
+			Title:       room.Name,
+			Environment: room.Environment,
+			Symbol:      room.Symbol,
+			Coord:       mmpCoord{X: room.X, Y: room.Y, Z: room.Z},
+		}
+		if room.Weight != 1 {
+			mr.Weight = room.Weight
+		}
+		for dir, target := range room.Exits {
+			if target == NoExit {
+				continue
+			}
+			key := ExitDirectionShortNames[dir]
+			e := mmpExit{
+				Direction: ExitDirectionNames[dir],
+				Target:    target,
+				Door:      room.Doors[key],
+				Weight:    room.ExitWeights[key],
+			}
+			if room.IsExitLocked(dir) {
+				e.Lock = 1
+			}
+			mr.Exits = append(mr.Exits, e)
+		}
+		cmds := make([]string, 0, len(room.SpecialExits))
+		for cmd := range room.SpecialExits {
+			cmds = append(cmds, cmd)
+		}
+		sort.Strings(cmds)
+		for _, cmd := range cmds {
+			mr.Exits = append(mr.Exits, mmpExit{Direction: cmd, Target: room.SpecialExits[cmd]})
+		}
+		doc.Rooms = append(doc.Rooms, mr)
+	}
+
+	envIDs := make(map[int32]struct{}, len(m.EnvColors)+len(m.CustomEnvColors))
+	for id := range m.EnvColors {
+		envIDs[id] = struct{}{}
+	}
+	for id := range m.CustomEnvColors {
+		envIDs[id] = struct{}{}
+	}
+	for _, id := range sortedKeys(envIDs) {
+		env := mmpEnvironment{ID: id}
+		if c, ok := m.EnvColors[id]; ok {
+			env.Color = strconv.Itoa(int(c))
+		}
+		if c, ok := m.CustomEnvColors[id]; ok {
+			env.HTMLColor = formatHTMLColor(c)
+		}
+		doc.Environments = append(doc.Environments, env)
+	}
+
+	if _, err := io.WriteString(writer, xml.Header); err != nil {
+		return fmt.Errorf("writing mmp header: %w", err)
+	}
+	enc := xml.NewEncoder(writer)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("encoding mmp xml: %w", err)
+	}
+	if _, err := io.WriteString(writer, "\n"); err != nil {
+		return fmt.Errorf("writing mmp trailer: %w", err)
+	}
+	return nil
+}
+
+// exitDirectionIndex returns the standard exit index for a full or short
+// direction name, or -1 if the name is not a standard direction.
+func exitDirectionIndex(name string) int {
+	name = strings.ToLower(name)
+	for i := range ExitDirectionNames {
+		if ExitDirectionNames[i] == name || ExitDirectionShortNames[i] == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// rebuildAreaRooms recomputes each area's room list and z-levels from the
+// rooms currently in the map.
+func rebuildAreaRooms(m *MudletMap) {
+	zsets := make(map[int32]map[int32]struct{}, len(m.Areas))
+	for _, area := range m.Areas {
+		area.Rooms = area.Rooms[:0]
+		zsets[area.ID] = make(map[int32]struct{})
+	}
+	for _, id := range sortedKeys(m.Rooms) {
+		room := m.Rooms[id]
+		area, ok := m.Areas[room.Area]
+		if !ok {
+			continue
+		}
+		area.Rooms = append(area.Rooms, uint32(room.ID))
+		zsets[area.ID][room.Z] = struct{}{}
+	}
+	for _, area := range m.Areas {
+		area.ZLevels = append(area.ZLevels[:0], sortedKeys(zsets[area.ID])...)
+	}
+}
+
+// parseHTMLColor parses a "#rrggbb" or "#rrggbbaa" color string.
+func parseHTMLColor(s string) (Color, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(hex) != 6 && len(hex) != 8 {
+		return Color{}, fmt.Errorf("invalid html color %q", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return Color{}, fmt.Errorf("invalid html color %q: %w", s, err)
+	}
+	alpha := uint64(0xFF)
+	if len(hex) == 8 {
+		alpha = v & 0xFF
+		v >>= 8
+	}
+	return Color{
+		Spec:  1,
+		Red:   uint16((v>>16)&0xFF) * 0x101,
+		Green: uint16((v>>8)&0xFF) * 0x101,
+		Blue:  uint16(v&0xFF) * 0x101,
+		Alpha: uint16(alpha) * 0x101,
+	}, nil
+}
+
+// formatHTMLColor formats a color as "#rrggbb", ignoring alpha.
+func formatHTMLColor(c Color) string {
+	r, g, b, _ := c.ToRGBA()
+	return fmt.Sprintf("#%02x%02x%02x", r, g, b)
+}
@@ -0,0 +1,176 @@
+package mapparser
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+const sampleMMP = `<?xml version="1.0" encoding="UTF-8"?>
+<map>
+  <areas>
+    <area id="1" name="Town" />
+  </areas>
+  <rooms>
+    <room id="1" area="1" title="Market" environment="2">
+      <coord x="0" y="0" z="0" />
+      <exit direction="north" target="2" door="2" lock="1" weight="5" />
+      <exit direction="climb rope" target="3" />
+    </room>
+    <room id="2" area="1" title="Gate" environment="3">
+      <coord x="0" y="1" z="0" />
+      <exit direction="s" target="1" />
+    </room>
+    <room id="3" area="1" title="Tower" environment="3">
+      <coord x="1" y="1" z="1" />
+    </room>
+  </rooms>
+  <environments>
+    <environment id="2" color="2" />
+    <environment id="3" htmlcolor="#ff8000" />
+  </environments>
+</map>`
+
+// TestImportMMP tests conversion of an MMP document into a MudletMap
+func TestImportMMP(t *testing.T) {
+	m, err := ImportMMP(strings.NewReader(sampleMMP))
+	if err != nil {
+		t.Fatalf("ImportMMP failed: %v", err)
+	}
+
+	if m.Version != mmpImportVersion {
+		t.Errorf("Expected version %d, got %d", mmpImportVersion, m.Version)
+	}
+	if len(m.Rooms) != 3 {
+		t.Fatalf("Expected 3 rooms, got %d", len(m.Rooms))
+	}
+
+	market := m.GetRoom(1)
+	if market.Name != "Market" || market.Environment != 2 {
+		t.Errorf("Unexpected room 1: name=%q env=%d", market.Name, market.Environment)
+	}
+	if market.Exits[ExitNorth] != 2 {
+		t.Errorf("Expected north exit to room 2, got %d", market.Exits[ExitNorth])
+	}
+	if market.Doors["n"] != DoorClosed {
+		t.Errorf("Expected closed door north, got %d", market.Doors["n"])
+	}
+	if market.ExitWeights["n"] != 5 {
+		t.Errorf("Expected exit weight 5, got %d", market.ExitWeights["n"])
+	}
+	if len(market.ExitLocks) != 1 || market.ExitLocks[0] != DirectionCode(ExitNorth) {
+		t.Errorf("Expected north exit lock, got %v", market.ExitLocks)
+	}
+	if market.SpecialExits["climb rope"] != 3 {
+		t.Errorf("Expected special exit 'climb rope' to room 3, got %v", market.SpecialExits)
+	}
+	if m.GetRoom(2).Exits[ExitSouth] != 1 {
+		t.Error("Short direction name 's' should map to the south exit")
+	}
+
+	area := m.GetArea(1)
+	if len(area.Rooms) != 3 {
+		t.Errorf("Expected 3 rooms in area, got %d", len(area.Rooms))
+	}
+	if len(area.ZLevels) != 2 {
+		t.Errorf("Expected 2 z-levels in area, got %v", area.ZLevels)
+	}
+
+	if m.EnvColors[2] != 2 {
+		t.Errorf("Expected env color 2 -> 2, got %d", m.EnvColors[2])
+	}
+	r, g, b, a := m.CustomEnvColors[3].ToRGBA()
+	if r != 255 || g != 128 || b != 0 || a != 255 {
+		t.Errorf("Expected custom env color (255,128,0,255), got (%d,%d,%d,%d)", r, g, b, a)
+	}
+}
+
+// TestExportMMPRoundTrip tests that exported MMP imports back to the same map
+func TestExportMMPRoundTrip(t *testing.T) {
+	m, err := ImportMMP(strings.NewReader(sampleMMP))
+	if err != nil {
+		t.Fatalf("ImportMMP failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := ExportMMP(m, &buf); err != nil {
+		t.Fatalf("ExportMMP failed: %v", err)
+	}
+
+	m2, err := ImportMMP(&buf)
+	if err != nil {
+		t.Fatalf("re-import failed: %v", err)
+	}
+	if len(m2.Rooms) != len(m.Rooms) || len(m2.Areas) != len(m.Areas) {
+		t.Fatalf("Round trip changed counts: rooms %d->%d, areas %d->%d",
+			len(m.Rooms), len(m2.Rooms), len(m.Areas), len(m2.Areas))
+	}
+	for id, room := range m.Rooms {
+		got := m2.Rooms[id]
+		if got.Name != room.Name || got.Exits != room.Exits || got.X != room.X || got.Z != room.Z {
+			t.Errorf("Room %d differs after round trip", id)
+		}
+		if len(got.SpecialExits) != len(room.SpecialExits) {
+			t.Errorf("Room %d special exits differ after round trip", id)
+		}
+	}
+	if m2.CustomEnvColors[3] != m.CustomEnvColors[3] {
+		t.Errorf("Custom env color changed: %v -> %v", m.CustomEnvColors[3], m2.CustomEnvColors[3])
+	}
+
+	if err := ExportMMP(nil, &buf); err == nil {
+		t.Error("Expected error exporting nil map")
+	}
+}
+
+// TestExportMMPLocks tests that exit locks of a map file survive a round
+// trip through MMP on the same exits
+func TestExportMMPLocks(t *testing.T) {
+	if _, err := os.Stat(largeMapPath); os.IsNotExist(err) {
+		t.Skipf("Test fixture not found: %s", largeMapPath)
+	}
+	m, err := ParseMapFile(largeMapPath)
+	if err != nil {
+		t.Fatalf("Failed to parse map: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := ExportMMP(m, &buf); err != nil {
+		t.Fatalf("ExportMMP failed: %v", err)
+	}
+	m2, err := ImportMMP(&buf)
+	if err != nil {
+		t.Fatalf("ImportMMP failed: %v", err)
+	}
+
+	// Room 1733 has locks on its north, southeast and southwest exits
+	room := m2.Rooms[1733]
+	if !room.IsExitLocked(ExitNorth) || !room.IsExitLocked(ExitSoutheast) || !room.IsExitLocked(ExitSouthwest) ||
+		room.IsExitLocked(ExitNorthwest) || room.IsExitLocked(ExitDown) {
+		t.Errorf("Expected locks north, southeast and southwest, got %v", room.ExitLocks)
+	}
+	locks := 0
+	for id, room := range m.Rooms {
+		locks += len(room.ExitLocks)
+		for dir := range room.Exits {
+			if got := m2.Rooms[id].IsExitLocked(dir); got != room.IsExitLocked(dir) {
+				t.Fatalf("Room %d %s exit locked = %v after round trip, expected %v",
+					id, ExitDirectionNames[dir], got, room.IsExitLocked(dir))
+			}
+		}
+	}
+	if locks == 0 {
+		t.Error("Expected exit locks in the fixture")
+	}
+}
+
+// TestImportMMPInvalid tests error handling for malformed documents
+func TestImportMMPInvalid(t *testing.T) {
+	if _, err := ImportMMP(strings.NewReader("<map><rooms>")); err == nil {
+		t.Error("Expected error for truncated document")
+	}
+	bad := `<map><environments><environment id="1" htmlcolor="#zz" /></environments></map>`
+	if _, err := ImportMMP(strings.NewReader(bad)); err == nil {
+		t.Error("Expected error for invalid html color")
+	}
+}
@@ -1,5 +1,14 @@
 package mapparser
 
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"image"
+	"image/png"
+	"slices"
+)
+
 // MudletMap represents the complete structure of a Mudlet map file.
 //
 // This is the primary data structure returned by [ParseMapFile] and [ParseMap].
@@ -17,7 +26,8 @@ type MudletMap struct {
 	// Custom environment colors: maps environment ID to RGBA color
 	CustomEnvColors map[int32]Color `json:"customEnvColors,omitempty"`
 
-	// Room hash to ID mapping (for quick lookup by hash)
+	// Room hash to ID mapping (for quick lookup by hash, see [MudletMap.RoomByHash]
+	// and [MudletMap.RebuildHashIndex])
 	RoomDbHashToRoomId map[string]uint32 `json:"roomDbHashToRoomId,omitempty"`
 
 	// Room ID hash (reverse lookup)
@@ -56,8 +66,8 @@ type MudletArea struct {
 	// Z-levels used in this area
 	ZLevels []int32 `json:"zLevels,omitempty"`
 
-	// Area exits: rooms that connect to other areas
-	// Key is the room ID in this area, value contains destination room and direction
+	// Area exits: rooms that connect to other areas, as stored by Mudlet.
+	// Use [ComputeAreaExits] to rebuild them from room exits.
 	AreaExits []AreaExit `json:"areaExits,omitempty"`
 
 	// Grid display mode
@@ -96,7 +106,7 @@ type MudletArea struct {
 type AreaExit struct {
 	RoomID     int32 `json:"roomId"`     // Room ID in this area
 	DestRoomID int32 `json:"destRoomId"` // Room ID in other area
-	Direction  int32 `json:"direction"`  // Exit direction
+	Direction  int32 `json:"direction"`  // Mudlet direction code (1-12, or AreaExitOther); see ExitIndex
 }
 
 // MudletRoom represents a single room in the map.
@@ -153,10 +163,12 @@ type MudletRoom struct {
 	// Special exit locks (version >= 21)
 	SpecialExitLocks []string `json:"specialExitLocks,omitempty"`
 
-	// Exit locks: list of locked standard exit directions (version >= 11)
+	// Exit locks: Mudlet direction codes of locked standard exits, see
+	// [DirectionCode] (version >= 11)
 	ExitLocks []int32 `json:"exitLocks,omitempty"`
 
-	// Exit stubs: directions with stub exits (version >= 13)
+	// Exit stubs: Mudlet direction codes of stub exits, see
+	// [DirectionCode] (version >= 13)
 	ExitStubs []int32 `json:"exitStubs,omitempty"`
 
 	// Exit weights: custom weights per direction (version >= 16)
@@ -196,6 +208,19 @@ type MudletLabel struct {
 	ShowOnTop bool `json:"showOnTop"`
 }
 
+// Image decodes the label's pixmap. It returns nil and no error for labels
+// without image data.
+func (l *MudletLabel) Image() (image.Image, error) {
+	if len(l.Pixmap) == 0 {
+		return nil, nil
+	}
+	img, err := png.Decode(bytes.NewReader(l.Pixmap))
+	if err != nil {
+		return nil, fmt.Errorf("decoding label %d pixmap: %w", l.ID, err)
+	}
+	return img, nil
+}
+
 // Color represents an RGBA color stored in Qt's QColor format.
 //
 // Qt stores color components as 16-bit values, where the high byte contains
@@ -214,25 +239,42 @@ func (c Color) ToRGBA() (r, g, b, a uint8) {
 	return uint8(c.Red >> 8), uint8(c.Green >> 8), uint8(c.Blue >> 8), uint8(c.Alpha >> 8)
 }
 
-// Font represents a Qt QFont structure as serialized in QDataStream.
+// Font represents a Qt QFont structure as serialized in QDataStream
+// (stream version Qt 5.12, as written by Mudlet).
 type Font struct {
-	Family            string  `json:"family"`
-	StyleHint         string  `json:"styleHint,omitempty"`
-	PointSizeF        float64 `json:"pointSizeF"`
-	PixelSize         int32   `json:"pixelSize"`
-	StyleStrategy     int8    `json:"styleStrategy"`
-	Weight            uint16  `json:"weight"`
-	Style             uint8   `json:"style"`
-	Underline         bool    `json:"underline"`
-	StrikeOut         bool    `json:"strikeOut"`
-	FixedPitch        bool    `json:"fixedPitch"`
-	Capitalization    int8    `json:"capitalization"`
-	LetterSpacing     int32   `json:"letterSpacing"`
-	WordSpacing       int32   `json:"wordSpacing"`
-	Stretch           int8    `json:"stretch"`
-	HintingPreference int8    `json:"hintingPreference"`
+	Family    string `json:"family"`
+	StyleName string `json:"styleName,omitempty"`
+	// PointSizeF is the point size, or -1 if the size is set in pixels.
+	PointSizeF float64 `json:"pointSizeF"`
+	// PixelSize is the pixel size, or -1 if the size is set in points.
+	PixelSize     int32  `json:"pixelSize"`
+	StyleHint     uint8  `json:"styleHint"`
+	StyleStrategy uint16 `json:"styleStrategy"`
+	// Weight is the Qt 5 font weight, 0-99 (50 normal, 75 bold).
+	Weight uint8 `json:"weight"`
+	// Style is 0 for normal, 1 for italic and 2 for oblique.
+	Style      uint8 `json:"style"`
+	Underline  bool  `json:"underline"`
+	Overline   bool  `json:"overline,omitempty"`
+	StrikeOut  bool  `json:"strikeOut"`
+	FixedPitch bool  `json:"fixedPitch"`
+	Kerning    bool  `json:"kerning"`
+	// Stretch is the width in percent of normal, 0 for any.
+	Stretch uint16 `json:"stretch"`
+	// LetterSpacing and WordSpacing are in 1/64 pixel units.
+	LetterSpacing     int32 `json:"letterSpacing"`
+	WordSpacing       int32 `json:"wordSpacing"`
+	HintingPreference uint8 `json:"hintingPreference"`
+	Capitalization    uint8 `json:"capitalization"`
 }
 
+// Font style values for [Font.Style].
+const (
+	FontStyleNormal  = 0
+	FontStyleItalic  = 1
+	FontStyleOblique = 2
+)
+
 // Vector3D represents a 3D vector, stored as three float64 values.
 // In Qt, this corresponds to QVector3D serialized as 3 doubles.
 type Vector3D struct {
@@ -287,6 +329,49 @@ var ExitDirectionShortNames = [12]string{
 	"n", "ne", "e", "se", "s", "sw", "w", "nw", "up", "down", "in", "out",
 }
 
+// oppositeExits maps each exit direction to its reverse direction.
+var oppositeExits = [12]int{
+	ExitSouth, ExitSouthwest, ExitWest, ExitNorthwest,
+	ExitNorth, ExitNortheast, ExitEast, ExitSoutheast,
+	ExitDown, ExitUp, ExitOut, ExitIn,
+}
+
+// OppositeExit returns the direction leading back for the given exit
+// direction (e.g. [ExitSouth] for [ExitNorth], [ExitOut] for [ExitIn]).
+// Returns -1 for an invalid direction.
+func OppositeExit(direction int) int {
+	if direction < 0 || direction >= len(oppositeExits) {
+		return -1
+	}
+	return oppositeExits[direction]
+}
+
+// directionCodes maps exit indices ([ExitNorth]...[ExitOut]) to the 1-based
+// direction codes Mudlet stores (DIR_NORTH=1, DIR_NORTHEAST=2,
+// DIR_NORTHWEST=3, DIR_EAST=4, ...).
+var directionCodes = [12]int32{1, 2, 4, 7, 6, 8, 5, 3, 9, 10, 11, 12}
+
+// DirectionCode returns the Mudlet direction code of the given exit
+// direction, as stored in [MudletRoom.ExitLocks], [MudletRoom.ExitStubs]
+// and [AreaExit.Direction]. Returns 0 for an invalid direction.
+func DirectionCode(direction int) int32 {
+	if direction < 0 || direction >= len(directionCodes) {
+		return 0
+	}
+	return directionCodes[direction]
+}
+
+// DirectionIndex returns the exit direction ([ExitNorth]...[ExitOut]) of a
+// Mudlet direction code. Returns -1 for special exits and unknown codes.
+func DirectionIndex(code int32) int {
+	for i, c := range directionCodes {
+		if c == code {
+			return i
+		}
+	}
+	return -1
+}
+
 // NoExit indicates that no exit exists in a given direction.
 const NoExit int32 = -1
 
@@ -331,23 +416,27 @@ func NewMudletArea(id int32, name string) *MudletArea {
 // NewMudletRoom creates a new MudletRoom with the given ID and default values.
 // All exits are initialized to [NoExit], and weight is set to 1.
 func NewMudletRoom(id int32) *MudletRoom {
-	r := &MudletRoom{
-		ID:               id,
-		Weight:           1,
-		SpecialExits:     make(map[string]int32),
-		UserData:         make(map[string]string),
-		CustomLines:      make(map[string][]Point2D),
-		CustomLinesArrow: make(map[string]bool),
-		CustomLinesColor: make(map[string]Color),
-		CustomLinesStyle: make(map[string]int32),
-		ExitWeights:      make(map[string]int32),
-		Doors:            make(map[string]int32),
-	}
+	r := &MudletRoom{}
+	r.init(id)
+	r.SpecialExits = make(map[string]int32)
+	r.UserData = make(map[string]string)
+	r.CustomLines = make(map[string][]Point2D)
+	r.CustomLinesArrow = make(map[string]bool)
+	r.CustomLinesColor = make(map[string]Color)
+	r.CustomLinesStyle = make(map[string]int32)
+	r.ExitWeights = make(map[string]int32)
+	r.Doors = make(map[string]int32)
+	return r
+}
+
+// init resets r to a room with the given ID, weight 1 and no exits, leaving
+// its maps nil.
+func (r *MudletRoom) init(id int32) {
+	*r = MudletRoom{ID: id, Weight: 1}
 	// Initialize all exits to NoExit
 	for i := range r.Exits {
 		r.Exits[i] = NoExit
 	}
-	return r
 }
 
 // GetExit returns the destination room ID for the given direction.
@@ -375,6 +464,41 @@ func (r *MudletRoom) ActiveExits() []int {
 	return result
 }
 
+// IsExitLocked reports whether the standard exit in the given direction is
+// locked for pathfinding. Returns false for an invalid direction.
+func (r *MudletRoom) IsExitLocked(direction int) bool {
+	if direction < 0 || direction >= 12 {
+		return false
+	}
+	return slices.Contains(r.ExitLocks, DirectionCode(direction))
+}
+
+// HasExitStub reports whether the room has a stub exit in the given
+// direction. Returns false for an invalid direction.
+func (r *MudletRoom) HasExitStub(direction int) bool {
+	if direction < 0 || direction >= 12 {
+		return false
+	}
+	return slices.Contains(r.ExitStubs, DirectionCode(direction))
+}
+
+// DoorState returns the door on the standard exit in the given direction,
+// one of [DoorNone], [DoorOpen], [DoorClosed] or [DoorLocked].
+// Returns [DoorNone] for an invalid direction.
+func (r *MudletRoom) DoorState(direction int) int32 {
+	if direction < 0 || direction >= 12 {
+		return DoorNone
+	}
+	return r.Doors[ExitDirectionShortNames[direction]]
+}
+
+// SpecialExitLocked reports whether the special exit with the given command
+// is locked for pathfinding. Locks stored in the command prefix of maps
+// older than version 21 are moved here by the parser.
+func (r *MudletRoom) SpecialExitLocked(cmd string) bool {
+	return slices.Contains(r.SpecialExitLocks, cmd)
+}
+
 // GetRoom returns the room with the given ID, or nil if not found.
 func (m *MudletMap) GetRoom(id int32) *MudletRoom {
 	return m.Rooms[id]
@@ -406,6 +530,19 @@ func (m *MudletMap) GetRoomsInArea(areaID int32) []*MudletRoom {
 	return rooms
 }
 
+// GetRoomsAtZ returns the rooms of the specified area on z-level z, in
+// ascending ID order.
+func (m *MudletMap) GetRoomsAtZ(areaID, z int32) []*MudletRoom {
+	var rooms []*MudletRoom
+	for _, room := range m.Rooms {
+		if room.Area == areaID && room.Z == z {
+			rooms = append(rooms, room)
+		}
+	}
+	slices.SortFunc(rooms, func(a, b *MudletRoom) int { return cmp.Compare(a.ID, b.ID) })
+	return rooms
+}
+
 // GetLabelsForArea returns labels for the specified area.
 // In format version 21+, labels are stored within the area; in earlier versions,
 // they are stored at the map level. This method handles both cases.
@@ -0,0 +1,284 @@
+package mapparser
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Map mutation helpers. Unlike direct edits of the exported fields, these
+// keep the derived data Mudlet stores alongside rooms consistent: area room
+// lists, z-levels and bounds, area exits, and the per-exit doors, weights,
+// locks, stubs and custom lines of affected rooms.
+
+// AddRoom adds room to the map and to the room list of its area, which must
+// exist. The room ID must be positive and unused, and every exit of the room
+// must lead to a room already in the map.
+func (m *MudletMap) AddRoom(room *MudletRoom) error {
+	if room == nil {
+		return fmt.Errorf("nil room provided")
+	}
+	if room.ID <= 0 {
+		return fmt.Errorf("invalid room ID %d", room.ID)
+	}
+	if _, ok := m.Rooms[room.ID]; ok {
+		return fmt.Errorf("room %d already exists", room.ID)
+	}
+	area, ok := m.Areas[room.Area]
+	if !ok {
+		return areaNotFound(room.Area)
+	}
+	for dir, dest := range room.Exits {
+		if dest != NoExit && dest != room.ID && m.Rooms[dest] == nil {
+			return fmt.Errorf("room %d: %s exit: %w", room.ID, ExitDirectionNames[dir], roomNotFound(dest))
+		}
+	}
+	for cmd, dest := range room.SpecialExits {
+		if dest != room.ID && m.Rooms[dest] == nil {
+			return fmt.Errorf("room %d: special exit %q: %w", room.ID, cmd, roomNotFound(dest))
+		}
+	}
+
+	m.Rooms[room.ID] = room
+	area.Rooms = append(area.Rooms, uint32(room.ID))
+	m.updateArea(area)
+	return nil
+}
+
+// DeleteRoom removes a room from the map and its area. Exits of other rooms
+// leading to it are removed together with their doors, weights, locks and
+// custom lines.
+func (m *MudletMap) DeleteRoom(id int32) error {
+	room, ok := m.Rooms[id]
+	if !ok {
+		return roomNotFound(id)
+	}
+
+	changed := map[int32]bool{room.Area: true}
+	for _, other := range m.Rooms {
+		if other.ID == id {
+			continue
+		}
+		removed := false
+		for dir, dest := range other.Exits {
+			if dest == id {
+				other.removeExit(dir)
+				removed = true
+			}
+		}
+		for cmd, dest := range other.SpecialExits {
+			if dest == id {
+				other.removeSpecialExit(cmd)
+				removed = true
+			}
+		}
+		if removed && other.Area != room.Area {
+			changed[other.Area] = true
+		}
+	}
+
+	delete(m.Rooms, id)
+	for hash, roomID := range m.RoomIdHash {
+		if roomID == id {
+			delete(m.RoomIdHash, hash)
+		}
+	}
+	for hash, roomID := range m.RoomDbHashToRoomId {
+		if int32(roomID) == id {
+			delete(m.RoomDbHashToRoomId, hash)
+		}
+	}
+	if area, ok := m.Areas[room.Area]; ok {
+		area.Rooms = slices.DeleteFunc(area.Rooms, func(r uint32) bool { return int32(r) == id })
+	}
+	m.updateAreas(changed)
+	return nil
+}
+
+// MoveRoom places a room at new coordinates, moving it into areaID if that
+// differs from its current area.
+func (m *MudletMap) MoveRoom(id, areaID, x, y, z int32) error {
+	room, ok := m.Rooms[id]
+	if !ok {
+		return roomNotFound(id)
+	}
+	dest, ok := m.Areas[areaID]
+	if !ok {
+		return areaNotFound(areaID)
+	}
+
+	changed := map[int32]bool{areaID: true}
+	if room.Area != areaID {
+		changed[room.Area] = true
+		if src, ok := m.Areas[room.Area]; ok {
+			src.Rooms = slices.DeleteFunc(src.Rooms, func(r uint32) bool { return int32(r) == id })
+		}
+		dest.Rooms = append(dest.Rooms, uint32(id))
+		// Area exits of neighbors in other areas may appear or disappear
+		for _, other := range m.Rooms {
+			if other.hasExitTo(id) {
+				changed[other.Area] = true
+			}
+		}
+	}
+	room.Area = areaID
+	room.X, room.Y, room.Z = x, y, z
+	m.updateAreas(changed)
+	return nil
+}
+
+// ConnectRooms creates an exit from one room to another in a standard
+// direction ([ExitNorth]...[ExitOut]), replacing any existing exit or stub
+// in that direction. With twoWay, the matching exit back is created too.
+func (m *MudletMap) ConnectRooms(from int32, dir int, to int32, twoWay bool) error {
+	if dir < 0 || dir >= len(oppositeExits) {
+		return fmt.Errorf("invalid exit direction %d", dir)
+	}
+	src, ok := m.Rooms[from]
+	if !ok {
+		return roomNotFound(from)
+	}
+	dest, ok := m.Rooms[to]
+	if !ok {
+		return roomNotFound(to)
+	}
+
+	src.setExit(dir, to)
+	if twoWay {
+		dest.setExit(OppositeExit(dir), from)
+	}
+	m.updateAreas(map[int32]bool{src.Area: true, dest.Area: true})
+	return nil
+}
+
+// DisconnectRooms removes the exit of room from in a standard direction,
+// along with its door, weight, lock and custom line. With twoWay, the exit
+// back from the destination is removed too if it leads to from.
+func (m *MudletMap) DisconnectRooms(from int32, dir int, twoWay bool) error {
+	if dir < 0 || dir >= len(oppositeExits) {
+		return fmt.Errorf("invalid exit direction %d", dir)
+	}
+	src, ok := m.Rooms[from]
+	if !ok {
+		return roomNotFound(from)
+	}
+	to := src.Exits[dir]
+	if to == NoExit {
+		return fmt.Errorf("room %d has no %s exit", from, ExitDirectionNames[dir])
+	}
+
+	src.removeExit(dir)
+	changed := map[int32]bool{src.Area: true}
+	if dest, ok := m.Rooms[to]; ok && twoWay {
+		if back := OppositeExit(dir); dest.Exits[back] == from {
+			dest.removeExit(back)
+			changed[dest.Area] = true
+		}
+	}
+	m.updateAreas(changed)
+	return nil
+}
+
+// setExit points the exit in dir at dest and drops a stub in that direction.
+func (r *MudletRoom) setExit(dir int, dest int32) {
+	r.Exits[dir] = dest
+	r.ExitStubs = slices.DeleteFunc(r.ExitStubs, func(d int32) bool { return d == DirectionCode(dir) })
+}
+
+// removeExit clears the exit in dir and the data attached to it.
+func (r *MudletRoom) removeExit(dir int) {
+	r.Exits[dir] = NoExit
+	name := ExitDirectionShortNames[dir]
+	delete(r.Doors, name)
+	delete(r.ExitWeights, name)
+	r.ExitLocks = slices.DeleteFunc(r.ExitLocks, func(d int32) bool { return d == DirectionCode(dir) })
+	r.removeCustomLine(name)
+}
+
+// removeSpecialExit deletes a special exit and the data attached to it.
+func (r *MudletRoom) removeSpecialExit(cmd string) {
+	delete(r.SpecialExits, cmd)
+	delete(r.Doors, cmd)
+	delete(r.ExitWeights, cmd)
+	r.SpecialExitLocks = slices.DeleteFunc(r.SpecialExitLocks, func(s string) bool { return s == cmd })
+	r.removeCustomLine(cmd)
+}
+
+func (r *MudletRoom) removeCustomLine(key string) {
+	delete(r.CustomLines, key)
+	delete(r.CustomLinesArrow, key)
+	delete(r.CustomLinesColor, key)
+	delete(r.CustomLinesStyle, key)
+}
+
+// hasExitTo reports whether any standard or special exit leads to id.
+func (r *MudletRoom) hasExitTo(id int32) bool {
+	if slices.Contains(r.Exits[:], id) {
+		return true
+	}
+	for _, dest := range r.SpecialExits {
+		if dest == id {
+			return true
+		}
+	}
+	return false
+}
+
+// updateAreas refreshes the derived data of the given areas.
+func (m *MudletMap) updateAreas(ids map[int32]bool) {
+	for id := range ids {
+		if area, ok := m.Areas[id]; ok {
+			m.updateArea(area)
+		}
+	}
+}
+
+// updateArea recomputes an area's z-levels, bounds and area exits from its
+// room list.
+func (m *MudletMap) updateArea(area *MudletArea) {
+	ids := make([]int32, 0, len(area.Rooms))
+	for _, id := range area.Rooms {
+		if _, ok := m.Rooms[int32(id)]; ok {
+			ids = append(ids, int32(id))
+		}
+	}
+	slices.Sort(ids)
+
+	area.ZLevels = area.ZLevels[:0]
+	for _, limits := range []*map[int32]int32{&area.XMaxForZ, &area.YMaxForZ, &area.XMinForZ, &area.YMinForZ} {
+		if *limits == nil {
+			*limits = make(map[int32]int32)
+		}
+		clear(*limits)
+	}
+	area.Bounds = BoundingBox3D{}
+	area.AreaExits = area.AreaExits[:0]
+
+	for i, id := range ids {
+		room := m.Rooms[id]
+		// Mudlet keeps area extents in screen space, with Y pointing down
+		x, y, z := room.X, -room.Y, room.Z
+		if i == 0 {
+			area.Bounds = BoundingBox3D{MinX: x, MinY: y, MinZ: z, MaxX: x, MaxY: y, MaxZ: z}
+		} else {
+			area.Bounds.MinX = min(area.Bounds.MinX, x)
+			area.Bounds.MinY = min(area.Bounds.MinY, y)
+			area.Bounds.MinZ = min(area.Bounds.MinZ, z)
+			area.Bounds.MaxX = max(area.Bounds.MaxX, x)
+			area.Bounds.MaxY = max(area.Bounds.MaxY, y)
+			area.Bounds.MaxZ = max(area.Bounds.MaxZ, z)
+		}
+		if _, seen := area.XMaxForZ[z]; !seen {
+			area.ZLevels = append(area.ZLevels, z)
+			area.XMaxForZ[z], area.XMinForZ[z] = x, x
+			area.YMaxForZ[z], area.YMinForZ[z] = y, y
+		} else {
+			area.XMaxForZ[z] = max(area.XMaxForZ[z], x)
+			area.XMinForZ[z] = min(area.XMinForZ[z], x)
+			area.YMaxForZ[z] = max(area.YMaxForZ[z], y)
+			area.YMinForZ[z] = min(area.YMinForZ[z], y)
+		}
+
+		area.AreaExits = appendAreaExits(m, area.AreaExits, room)
+	}
+	slices.Sort(area.ZLevels)
+}
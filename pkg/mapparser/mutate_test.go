@@ -0,0 +1,152 @@
+package mapparser
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// newMutationTestMap returns two areas: rooms 1 and 2 in area 1, linked
+// east/west, and room 3 in area 2.
+func newMutationTestMap(t *testing.T) *MudletMap {
+	t.Helper()
+	m := NewMudletMap()
+	m.Version = 20
+	m.Areas[1] = NewMudletArea(1, "One")
+	m.Areas[2] = NewMudletArea(2, "Two")
+	for _, r := range []struct{ id, area, x, y, z int32 }{
+		{1, 1, 0, 0, 0}, {2, 1, 1, 0, 0}, {3, 2, 5, 5, 1},
+	} {
+		room := NewMudletRoom(r.id)
+		room.Area, room.X, room.Y, room.Z = r.area, r.x, r.y, r.z
+		if err := m.AddRoom(room); err != nil {
+			t.Fatalf("AddRoom(%d) failed: %v", r.id, err)
+		}
+	}
+	if err := m.ConnectRooms(1, ExitEast, 2, true); err != nil {
+		t.Fatalf("ConnectRooms failed: %v", err)
+	}
+	return m
+}
+
+// TestAddRoom tests area bookkeeping and validation when adding rooms
+func TestAddRoom(t *testing.T) {
+	m := newMutationTestMap(t)
+
+	a := m.Areas[1]
+	if !reflect.DeepEqual(a.Rooms, []uint32{1, 2}) || !reflect.DeepEqual(a.ZLevels, []int32{0}) {
+		t.Errorf("Unexpected area 1 rooms %v, z-levels %v", a.Rooms, a.ZLevels)
+	}
+	if a.Bounds.MaxX != 1 || a.XMaxForZ[0] != 1 {
+		t.Errorf("Unexpected area 1 bounds %+v, xmax %v", a.Bounds, a.XMaxForZ)
+	}
+
+	dup := NewMudletRoom(1)
+	dup.Area = 1
+	if err := m.AddRoom(dup); err == nil {
+		t.Error("Expected error for duplicate room ID")
+	}
+	broken := NewMudletRoom(9)
+	broken.Area = 1
+	broken.Exits[ExitNorth] = 99
+	if err := m.AddRoom(broken); !errors.Is(err, ErrRoomNotFound) {
+		t.Errorf("AddRoom with an exit to a missing room = %v, expected ErrRoomNotFound", err)
+	}
+	orphan := NewMudletRoom(10)
+	orphan.Area = 42
+	if err := m.AddRoom(orphan); !errors.Is(err, ErrAreaNotFound) {
+		t.Errorf("AddRoom in a missing area = %v, expected ErrAreaNotFound", err)
+	}
+}
+
+// TestConnectRooms tests exits, stubs and area exits when connecting rooms
+func TestConnectRooms(t *testing.T) {
+	m := newMutationTestMap(t)
+	if m.Rooms[1].Exits[ExitEast] != 2 || m.Rooms[2].Exits[ExitWest] != 1 {
+		t.Fatal("Expected two-way east/west exits")
+	}
+
+	m.Rooms[2].ExitStubs = []int32{DirectionCode(ExitUp), DirectionCode(ExitSouthwest)}
+	if err := m.ConnectRooms(2, ExitUp, 3, false); err != nil {
+		t.Fatalf("ConnectRooms failed: %v", err)
+	}
+	if !reflect.DeepEqual(m.Rooms[2].ExitStubs, []int32{DirectionCode(ExitSouthwest)}) {
+		t.Errorf("Expected only the up stub replaced by the exit, got %v", m.Rooms[2].ExitStubs)
+	}
+	if m.Rooms[3].Exits[ExitDown] != NoExit {
+		t.Error("Expected one-way exit")
+	}
+	want := []AreaExit{{RoomID: 2, DestRoomID: 3, Direction: DirectionCode(ExitUp)}}
+	if !reflect.DeepEqual(m.Areas[1].AreaExits, want) {
+		t.Errorf("Area exits = %v, expected %v", m.Areas[1].AreaExits, want)
+	}
+
+	m.Rooms[1].Doors["e"] = DoorLocked
+	if err := m.DisconnectRooms(1, ExitEast, true); err != nil {
+		t.Fatalf("DisconnectRooms failed: %v", err)
+	}
+	if m.Rooms[1].HasExit(ExitEast) || m.Rooms[2].HasExit(ExitWest) || len(m.Rooms[1].Doors) != 0 {
+		t.Error("Expected exits and door removed")
+	}
+	if err := m.DisconnectRooms(1, ExitEast, true); err == nil {
+		t.Error("Expected error for missing exit")
+	}
+	if err := m.ConnectRooms(1, 12, 2, false); err == nil {
+		t.Error("Expected error for invalid direction")
+	}
+}
+
+// TestMoveRoom tests coordinate and area changes
+func TestMoveRoom(t *testing.T) {
+	m := newMutationTestMap(t)
+	if err := m.MoveRoom(2, 2, 7, -3, 1); err != nil {
+		t.Fatalf("MoveRoom failed: %v", err)
+	}
+	r := m.Rooms[2]
+	if r.Area != 2 || r.X != 7 || r.Y != -3 || r.Z != 1 {
+		t.Errorf("Unexpected room after move: area %d at (%d, %d, %d)", r.Area, r.X, r.Y, r.Z)
+	}
+	if !reflect.DeepEqual(m.Areas[1].Rooms, []uint32{1}) || !reflect.DeepEqual(m.Areas[2].Rooms, []uint32{3, 2}) {
+		t.Errorf("Unexpected area rooms %v, %v", m.Areas[1].Rooms, m.Areas[2].Rooms)
+	}
+	// Both sides of the east/west link now cross areas
+	if len(m.Areas[1].AreaExits) != 1 || len(m.Areas[2].AreaExits) != 1 {
+		t.Errorf("Unexpected area exits %v, %v", m.Areas[1].AreaExits, m.Areas[2].AreaExits)
+	}
+	if m.Areas[2].Bounds.MaxY != 3 || m.Areas[2].Bounds.MinY != -5 {
+		t.Errorf("Unexpected area 2 bounds %+v", m.Areas[2].Bounds)
+	}
+	if err := m.MoveRoom(2, 42, 0, 0, 0); !errors.Is(err, ErrAreaNotFound) {
+		t.Errorf("MoveRoom to a missing area = %v, expected ErrAreaNotFound", err)
+	}
+	if err := m.MoveRoom(99, 1, 0, 0, 0); !errors.Is(err, ErrRoomNotFound) {
+		t.Errorf("MoveRoom of a missing room = %v, expected ErrRoomNotFound", err)
+	}
+}
+
+// TestDeleteRoom tests that exits into a deleted room are cleaned up
+func TestDeleteRoom(t *testing.T) {
+	m := newMutationTestMap(t)
+	m.Rooms[1].SpecialExits["jump"] = 2
+	m.Rooms[1].CustomLines["jump"] = []Point2D{{X: 1, Y: 1}}
+	m.Rooms[1].ExitLocks = []int32{DirectionCode(ExitEast), DirectionCode(ExitNortheast)}
+	m.RoomIdHash["abc"] = 2
+
+	if err := m.DeleteRoom(2); err != nil {
+		t.Fatalf("DeleteRoom failed: %v", err)
+	}
+	r := m.Rooms[1]
+	if r.HasExit(ExitEast) || len(r.SpecialExits) != 0 || len(r.CustomLines) != 0 ||
+		!reflect.DeepEqual(r.ExitLocks, []int32{DirectionCode(ExitNortheast)}) {
+		t.Errorf("Expected exits to the deleted room removed: %+v", r)
+	}
+	if m.GetRoom(2) != nil || len(m.RoomIdHash) != 0 || !reflect.DeepEqual(m.Areas[1].Rooms, []uint32{1}) {
+		t.Error("Expected room removed from map, hashes and area")
+	}
+	if errs := ValidateMap(m); len(errs) != 0 {
+		t.Errorf("Expected valid map after delete, got %v", errs)
+	}
+	if err := m.DeleteRoom(2); err == nil {
+		t.Error("Expected error for missing room")
+	}
+}
@@ -0,0 +1,77 @@
+package mapparser
+
+// Neighbor is a room reachable through one exit of another room, as
+// returned by [MudletRoom.Neighbors].
+type Neighbor struct {
+	// Room is the destination room.
+	Room *MudletRoom
+	// Direction is the standard exit direction (see [ExitNorth] and
+	// following), or -1 for a special exit.
+	Direction int
+	// Command is the special exit command, empty for standard exits.
+	Command string
+	// Door is the door on the exit, one of [DoorNone], [DoorOpen],
+	// [DoorClosed] or [DoorLocked].
+	Door int32
+	// Locked reports whether the exit is locked for pathfinding. The lock
+	// state of the destination room itself is Room.IsLocked.
+	Locked bool
+	// Weight is the exit weight, or 0 if the exit has none and the
+	// destination room's weight applies.
+	Weight int32
+}
+
+// IsSpecial reports whether the neighbor is reached through a special exit.
+func (n Neighbor) IsSpecial() bool {
+	return n.Direction < 0
+}
+
+// Key returns the name the exit's door, weight and custom line are stored
+// under: the short direction name or the special exit command.
+func (n Neighbor) Key() string {
+	if n.IsSpecial() {
+		return n.Command
+	}
+	return ExitDirectionShortNames[n.Direction]
+}
+
+// Neighbors returns the rooms reachable from r through its standard exits,
+// in direction order, followed by its special exits sorted by command.
+// Exits leading to rooms missing from m are left out; a room reached by
+// several exits appears once per exit. Locked exits are included with
+// Locked set, so callers decide whether they can be taken.
+func (r *MudletRoom) Neighbors(m *MudletMap) []Neighbor {
+	var result []Neighbor
+	for dir, dest := range r.Exits {
+		if dest == NoExit {
+			continue
+		}
+		target := m.Rooms[dest]
+		if target == nil {
+			continue
+		}
+		key := ExitDirectionShortNames[dir]
+		result = append(result, Neighbor{
+			Room:      target,
+			Direction: dir,
+			Door:      r.Doors[key],
+			Locked:    r.IsExitLocked(dir),
+			Weight:    r.ExitWeights[key],
+		})
+	}
+	for _, cmd := range sortedStringKeys(r.SpecialExits) {
+		target := m.Rooms[r.SpecialExits[cmd]]
+		if target == nil {
+			continue
+		}
+		result = append(result, Neighbor{
+			Room:      target,
+			Direction: -1,
+			Command:   cmd,
+			Door:      r.Doors[cmd],
+			Locked:    r.SpecialExitLocked(cmd),
+			Weight:    r.ExitWeights[cmd],
+		})
+	}
+	return result
+}
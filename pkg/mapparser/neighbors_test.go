@@ -0,0 +1,101 @@
+package mapparser
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+// TestNeighbors tests exit enumeration with door, lock and weight metadata
+func TestNeighbors(t *testing.T) {
+	m := NewMudletMap()
+	for id := int32(1); id <= 4; id++ {
+		m.Rooms[id] = NewMudletRoom(id)
+	}
+	room := m.Rooms[1]
+	room.Exits[ExitNorth] = 2
+	room.Exits[ExitEast] = 99 // missing room
+	room.Exits[ExitUp] = 3
+	room.Doors["n"] = DoorClosed
+	room.ExitLocks = []int32{DirectionCode(ExitUp)}
+	room.ExitWeights["up"] = 7
+	room.SpecialExits["swim"] = 4
+	room.SpecialExits["climb"] = 2
+	room.SpecialExits["jump"] = 98 // missing room
+	room.SpecialExitLocks = []string{"swim"}
+	room.Doors["climb"] = DoorOpen
+
+	got := room.Neighbors(m)
+	want := []struct {
+		id     int32
+		dir    int
+		cmd    string
+		key    string
+		door   int32
+		locked bool
+		weight int32
+	}{
+		{2, ExitNorth, "", "n", DoorClosed, false, 0},
+		{3, ExitUp, "", "up", DoorNone, true, 7},
+		{2, -1, "climb", "climb", DoorOpen, false, 0},
+		{4, -1, "swim", "swim", DoorNone, true, 0},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Neighbors returned %d entries, expected %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		n := got[i]
+		if n.Room.ID != w.id || n.Direction != w.dir || n.Command != w.cmd || n.Key() != w.key ||
+			n.Door != w.door || n.Locked != w.locked || n.Weight != w.weight || n.IsSpecial() != (w.cmd != "") {
+			t.Errorf("neighbor %d = %+v (key %q), expected %+v", i, n, n.Key(), w)
+		}
+	}
+
+	if n := m.Rooms[4].Neighbors(m); len(n) != 0 {
+		t.Errorf("room without exits has neighbors %+v", n)
+	}
+}
+
+// TestNeighborLocksFromFile tests that locks read from a map file mark the
+// locked exits and keep routes off them
+func TestNeighborLocksFromFile(t *testing.T) {
+	if _, err := os.Stat(largeMapPath); os.IsNotExist(err) {
+		t.Skipf("Test fixture not found: %s", largeMapPath)
+	}
+	m, err := ParseMapFile(largeMapPath)
+	if err != nil {
+		t.Fatalf("Failed to parse map: %v", err)
+	}
+
+	// Room 1733 has locks on its north, southeast and southwest exits
+	locked := make(map[int]bool)
+	for _, n := range m.Rooms[1733].Neighbors(m) {
+		if !n.IsSpecial() {
+			locked[n.Direction] = n.Locked
+		}
+	}
+	want := map[int]bool{ExitNorth: true, ExitSoutheast: true, ExitSouthwest: true, ExitNorthwest: false, ExitDown: false}
+	if !reflect.DeepEqual(locked, want) {
+		t.Errorf("Neighbor locks = %v, expected %v", locked, want)
+	}
+
+	if got := FindPath(m, 1733, 1734); !reflect.DeepEqual(got, []int32{1733, 1734}) {
+		t.Errorf("Expected the unlocked northwest exit taken, got %v", got)
+	}
+	north := m.Rooms[1733].Exits[ExitNorth]
+	path := FindPath(m, 1733, north)
+	if len(path) < 2 || path[len(path)-1] != north {
+		t.Fatalf("Expected a route to room %d, got %v", north, path)
+	}
+	for i := 1; i < len(path); i++ {
+		open := false
+		for _, n := range m.Rooms[path[i-1]].Neighbors(m) {
+			if n.Room.ID == path[i] && !n.Locked {
+				open = true
+			}
+		}
+		if !open {
+			t.Errorf("Route %v takes a locked exit from room %d to %d", path, path[i-1], path[i])
+		}
+	}
+}
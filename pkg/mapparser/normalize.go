@@ -0,0 +1,146 @@
+package mapparser
+
+// NormalizeOptions configures [Normalize].
+type NormalizeOptions struct {
+	// CompactRoomIDs renumbers rooms 1..n in the order of their current
+	// IDs, closing the gaps left by deleted rooms. Scripts and other maps
+	// that refer to room IDs will no longer match.
+	CompactRoomIDs bool
+}
+
+// NormalizeResult reports the changes made by [Normalize].
+type NormalizeResult struct {
+	// Offsets holds the (dx, dy) added to the coordinates of each area
+	// that moved.
+	Offsets map[int32][2]int32
+	// RoomIDs maps old room IDs to new ones for renumbered rooms.
+	RoomIDs map[int32]int32
+}
+
+// Normalize modifies m in place so that each area's rooms start at the
+// origin: the lowest X and Y of an area's rooms become 0, and its labels
+// and custom exit lines move along. Z-levels are kept. With
+// opts.CompactRoomIDs, rooms are also renumbered without gaps and every
+// reference to them is updated. A nil opts only recenters.
+//
+// Normalized maps diff and compress better and export smaller coordinates.
+//
+// Example:
+//
+//	res := mapparser.Normalize(m, &mapparser.NormalizeOptions{CompactRoomIDs: true})
+//	fmt.Println("room 1234 is now", res.RoomIDs[1234])
+func Normalize(m *MudletMap, opts *NormalizeOptions) NormalizeResult {
+	if opts == nil {
+		opts = &NormalizeOptions{}
+	}
+	res := NormalizeResult{Offsets: make(map[int32][2]int32)}
+	if m == nil {
+		return res
+	}
+
+	// Lowest coordinates of each area's rooms
+	lows := make(map[int32][2]int32)
+	for _, room := range m.Rooms {
+		low, ok := lows[room.Area]
+		if !ok {
+			low = [2]int32{room.X, room.Y}
+		}
+		lows[room.Area] = [2]int32{min(low[0], room.X), min(low[1], room.Y)}
+	}
+	for area, low := range lows {
+		if low != [2]int32{} {
+			res.Offsets[area] = [2]int32{-low[0], -low[1]}
+		}
+	}
+
+	for _, room := range m.Rooms {
+		off, ok := res.Offsets[room.Area]
+		if !ok {
+			continue
+		}
+		room.X += off[0]
+		room.Y += off[1]
+		for _, points := range room.CustomLines {
+			for i := range points {
+				points[i].X += float64(off[0])
+				points[i].Y += float64(off[1])
+			}
+		}
+	}
+
+	// Labels may be listed both in their area and map-wide; move each once
+	moved := make(map[*MudletLabel]bool)
+	moveLabels := func(areaID int32, labels []*MudletLabel) {
+		off, ok := res.Offsets[areaID]
+		if !ok {
+			return
+		}
+		for _, l := range labels {
+			if !moved[l] {
+				moved[l] = true
+				l.Pos.X += float64(off[0])
+				l.Pos.Y += float64(off[1])
+			}
+		}
+	}
+	for id, area := range m.Areas {
+		moveLabels(id, area.Labels)
+	}
+	for id, labels := range m.Labels {
+		moveLabels(id, labels)
+	}
+
+	if opts.CompactRoomIDs {
+		res.RoomIDs = m.compactRoomIDs()
+	}
+	for _, area := range m.Areas {
+		m.updateArea(area)
+	}
+	return res
+}
+
+// compactRoomIDs renumbers rooms 1..n in ID order and rewrites every room
+// reference. It returns the mapping of the IDs that changed.
+func (m *MudletMap) compactRoomIDs() map[int32]int32 {
+	ids := make(map[int32]int32)
+	for i, id := range sortedKeys(m.Rooms) {
+		if next := int32(i + 1); next != id {
+			ids[id] = next
+		}
+	}
+	if len(ids) == 0 {
+		return ids
+	}
+	renumber := func(id int32) int32 {
+		if next, ok := ids[id]; ok {
+			return next
+		}
+		return id
+	}
+
+	rooms := make(map[int32]*MudletRoom, len(m.Rooms))
+	for _, room := range m.Rooms {
+		room.ID = renumber(room.ID)
+		for dir, dest := range room.Exits {
+			room.Exits[dir] = renumber(dest)
+		}
+		for cmd, dest := range room.SpecialExits {
+			room.SpecialExits[cmd] = renumber(dest)
+		}
+		rooms[room.ID] = room
+	}
+	m.Rooms = rooms
+
+	for _, area := range m.Areas {
+		for i, id := range area.Rooms {
+			area.Rooms[i] = uint32(renumber(int32(id)))
+		}
+	}
+	for hash, id := range m.RoomDbHashToRoomId {
+		m.RoomDbHashToRoomId[hash] = uint32(renumber(int32(id)))
+	}
+	for profile, id := range m.RoomIdHash {
+		m.RoomIdHash[profile] = renumber(id)
+	}
+	return ids
+}
@@ -0,0 +1,73 @@
+package mapparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestNormalize tests recentering areas and compacting room IDs
+func TestNormalize(t *testing.T) {
+	m := NewMudletMap()
+	m.Areas[1] = NewMudletArea(1, "East")
+	m.Areas[2] = NewMudletArea(2, "Origin")
+	add := func(id, area, x, y, z int32) *MudletRoom {
+		room := NewMudletRoom(id)
+		room.Area, room.X, room.Y, room.Z = area, x, y, z
+		m.Rooms[id] = room
+		m.Areas[area].Rooms = append(m.Areas[area].Rooms, uint32(id))
+		return room
+	}
+	a := add(10, 1, 100, -5, 0)
+	b := add(20, 1, 103, 2, 1)
+	c := add(30, 2, 0, 0, 0)
+	a.Exits[ExitEast] = 20
+	a.SpecialExits["portal"] = 30
+	b.CustomLines["w"] = []Point2D{{X: 101, Y: 0}}
+	label := &MudletLabel{ID: 1, Pos: Vector3D{X: 99, Y: -4}}
+	m.Areas[1].Labels = []*MudletLabel{label}
+	m.Labels[1] = []*MudletLabel{label}
+	m.RoomDbHashToRoomId["abc"] = 20
+	m.RoomIdHash["me"] = 30
+
+	res := Normalize(m, &NormalizeOptions{CompactRoomIDs: true})
+
+	wantOffsets := map[int32][2]int32{1: {-100, 5}}
+	if !reflect.DeepEqual(res.Offsets, wantOffsets) {
+		t.Errorf("Offsets = %v, expected %v", res.Offsets, wantOffsets)
+	}
+	wantIDs := map[int32]int32{10: 1, 20: 2, 30: 3}
+	if !reflect.DeepEqual(res.RoomIDs, wantIDs) {
+		t.Errorf("RoomIDs = %v, expected %v", res.RoomIDs, wantIDs)
+	}
+
+	if m.Rooms[1] != a || m.Rooms[2] != b || m.Rooms[3] != c || len(m.Rooms) != 3 {
+		t.Fatalf("rooms not renumbered: %v", sortedKeys(m.Rooms))
+	}
+	if a.X != 0 || a.Y != 0 || b.X != 3 || b.Y != 7 || b.Z != 1 || c.X != 0 || c.Y != 0 {
+		t.Errorf("positions = (%d,%d) (%d,%d,%d) (%d,%d)", a.X, a.Y, b.X, b.Y, b.Z, c.X, c.Y)
+	}
+	if a.Exits[ExitEast] != 2 || a.SpecialExits["portal"] != 3 {
+		t.Errorf("exits not renumbered: %v %v", a.Exits, a.SpecialExits)
+	}
+	if p := b.CustomLines["w"][0]; p.X != 1 || p.Y != 5 {
+		t.Errorf("custom line point = %+v, expected {1 5}", p)
+	}
+	if label.Pos.X != -1 || label.Pos.Y != 1 {
+		t.Errorf("label moved to %+v, expected (-1, 1) once", label.Pos)
+	}
+	if got := m.Areas[1].Rooms; !reflect.DeepEqual(got, []uint32{1, 2}) {
+		t.Errorf("area rooms = %v", got)
+	}
+	if m.RoomDbHashToRoomId["abc"] != 2 || m.RoomIdHash["me"] != 3 {
+		t.Errorf("hash indexes not renumbered: %v %v", m.RoomDbHashToRoomId, m.RoomIdHash)
+	}
+	if bb := m.Areas[1].Bounds; bb.MinX != 0 || bb.MaxX != 3 {
+		t.Errorf("area bounds not refreshed: %+v", bb)
+	}
+
+	// A normalized map is left alone
+	again := Normalize(m, nil)
+	if len(again.Offsets) != 0 || again.RoomIDs != nil {
+		t.Errorf("second Normalize changed the map: %+v", again)
+	}
+}
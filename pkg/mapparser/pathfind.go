@@ -0,0 +1,118 @@
+package mapparser
+
+import (
+	"container/heap"
+	"slices"
+)
+
+// FindPath returns the cheapest route from one room to another as room
+// IDs, including both ends, or nil if there is none. Costs follow Mudlet's
+// speedwalk: taking an exit costs its exit weight if one is set, otherwise
+// the weight of the room it leads to (at least 1). Locked rooms, locked
+// exits and locked special exits are never used, so a locked destination
+// is unreachable. Ties are broken toward lower room IDs.
+func FindPath(m *MudletMap, from, to int32) []int32 {
+	return FindPathWithOptions(m, from, to, nil)
+}
+
+// PathOptions configures [FindPathWithOptions].
+type PathOptions struct {
+	// Terrain, if set, multiplies the cost of entering each room by its
+	// terrain cost.
+	Terrain *TerrainProfile
+}
+
+// FindPathWithOptions is [FindPath] with options.
+func FindPathWithOptions(m *MudletMap, from, to int32, opts *PathOptions) []int32 {
+	if opts == nil {
+		opts = &PathOptions{}
+	}
+	return findPath(m, from, to, opts, nil)
+}
+
+// findPath is [FindPathWithOptions], keeping to rooms in the areas accepted
+// by inside if it is set.
+func findPath(m *MudletMap, from, to int32, opts *PathOptions, inside func(areaID int32) bool) []int32 {
+	if m == nil || m.Rooms[from] == nil || m.Rooms[to] == nil || m.Rooms[to].IsLocked {
+		return nil
+	}
+	if from == to {
+		return []int32{from}
+	}
+
+	dist := map[int32]float64{from: 0}
+	prev := make(map[int32]int32)
+	pq := &pathQueue{{room: from}}
+	for pq.Len() > 0 {
+		cur := heap.Pop(pq).(pathItem)
+		if cur.cost > dist[cur.room] {
+			continue
+		}
+		if cur.room == to {
+			path := []int32{to}
+			for at := to; at != from; {
+				at = prev[at]
+				path = append(path, at)
+			}
+			slices.Reverse(path)
+			return path
+		}
+		m.Rooms[cur.room].forEachMove(m, func(dest int32, cost float64) {
+			if inside != nil && !inside(m.Rooms[dest].Area) {
+				return
+			}
+			next := cur.cost + cost*opts.Terrain.Cost(m.Rooms[dest])
+			if d, ok := dist[dest]; !ok || next < d || next == d && cur.room < prev[dest] {
+				dist[dest] = next
+				prev[dest] = cur.room
+				heap.Push(pq, pathItem{room: dest, cost: next})
+			}
+		})
+	}
+	return nil
+}
+
+// forEachMove calls fn for every usable exit of r with the destination and
+// the cost of taking it.
+func (r *MudletRoom) forEachMove(m *MudletMap, fn func(dest int32, cost float64)) {
+	for _, n := range r.Neighbors(m) {
+		if n.Locked || n.Room.IsLocked || n.Room.ID == r.ID {
+			continue
+		}
+		fn(n.Room.ID, float64(r.MoveWeight(n.Key(), n.Room)))
+	}
+}
+
+// MoveWeight returns the pathfinding cost of taking r's exit stored under
+// key, the short direction name or special exit command, to dest: the
+// exit's weight if it has one, otherwise dest's weight, and at least 1.
+func (r *MudletRoom) MoveWeight(key string, dest *MudletRoom) int32 {
+	if w := r.ExitWeights[key]; w > 0 {
+		return w
+	}
+	return max(dest.Weight, 1)
+}
+
+type pathItem struct {
+	room int32
+	cost float64
+}
+
+// pathQueue is a min-heap of rooms by cost, then room ID.
+type pathQueue []pathItem
+
+func (q pathQueue) Len() int { return len(q) }
+func (q pathQueue) Less(i, j int) bool {
+	if q[i].cost != q[j].cost {
+		return q[i].cost < q[j].cost
+	}
+	return q[i].room < q[j].room
+}
+func (q pathQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *pathQueue) Push(x any)   { *q = append(*q, x.(pathItem)) }
+func (q *pathQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
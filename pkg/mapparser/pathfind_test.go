@@ -0,0 +1,76 @@
+package mapparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+// newPathTestMap builds a square of rooms 1-2-3-4 with two-way exits:
+// 1 east to 2, 2 north to 3, 1 north to 4 and 4 east to 3
+func newPathTestMap(t *testing.T) *MudletMap {
+	t.Helper()
+	m := NewMudletMap()
+	m.Version = 20
+	m.Areas[1] = NewMudletArea(1, "Square")
+	for _, r := range []struct{ id, x, y int32 }{{1, 0, 0}, {2, 1, 0}, {3, 1, 1}, {4, 0, 1}} {
+		room := NewMudletRoom(r.id)
+		room.Area, room.X, room.Y = 1, r.x, r.y
+		if err := m.AddRoom(room); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, c := range []struct {
+		from int32
+		dir  int
+		to   int32
+	}{{1, ExitEast, 2}, {2, ExitNorth, 3}, {1, ExitNorth, 4}, {4, ExitEast, 3}} {
+		if err := m.ConnectRooms(c.from, c.dir, c.to, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return m
+}
+
+// TestFindPath tests weights, locks and tie-breaking of the pathfinder
+func TestFindPath(t *testing.T) {
+	m := newPathTestMap(t)
+	if got := FindPath(m, 1, 3); !reflect.DeepEqual(got, []int32{1, 2, 3}) {
+		t.Errorf("Expected tie broken toward room 2, got %v", got)
+	}
+
+	m.Rooms[2].Weight = 5
+	if got := FindPath(m, 1, 3); !reflect.DeepEqual(got, []int32{1, 4, 3}) {
+		t.Errorf("Expected heavy room avoided, got %v", got)
+	}
+
+	m.Rooms[1].ExitWeights["n"] = 10
+	if got := FindPath(m, 1, 3); !reflect.DeepEqual(got, []int32{1, 2, 3}) {
+		t.Errorf("Expected exit weight to override room weight, got %v", got)
+	}
+
+	m.Rooms[1].ExitLocks = []int32{DirectionCode(ExitEast)}
+	if got := FindPath(m, 1, 3); !reflect.DeepEqual(got, []int32{1, 4, 3}) {
+		t.Errorf("Expected locked exit avoided, got %v", got)
+	}
+
+	m.Rooms[4].IsLocked = true
+	if got := FindPath(m, 1, 3); got != nil {
+		t.Errorf("Expected no path, got %v", got)
+	}
+
+	m.Rooms[1].SpecialExits["climb"] = 3
+	if got := FindPath(m, 1, 3); !reflect.DeepEqual(got, []int32{1, 3}) {
+		t.Errorf("Expected special exit used, got %v", got)
+	}
+	m.Rooms[1].SpecialExitLocks = []string{"climb"}
+	if got := FindPath(m, 1, 3); got != nil {
+		t.Errorf("Expected locked special exit avoided, got %v", got)
+	}
+
+	if got := FindPath(m, 2, 2); !reflect.DeepEqual(got, []int32{2}) {
+		t.Errorf("Expected single-room path, got %v", got)
+	}
+	if FindPath(m, 1, 99) != nil || FindPath(nil, 1, 2) != nil {
+		t.Error("Expected nil for missing rooms")
+	}
+}
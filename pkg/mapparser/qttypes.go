@@ -0,0 +1,428 @@
+package mapparser
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// This file holds readers for Qt value types that appear in map files, so
+// that section parsers can read them as single fields.
+
+// ReadQColor reads a QColor: a color spec byte followed by alpha, red,
+// green and blue as 16-bit values and a padding value.
+func (br *BinaryReader) ReadQColor() (Color, error) {
+	var c Color
+	spec, err := br.ReadInt8()
+	if err != nil {
+		return c, err
+	}
+	c.Spec = spec
+
+	c.Alpha, err = br.ReadUInt16()
+	if err != nil {
+		return c, err
+	}
+	c.Red, err = br.ReadUInt16()
+	if err != nil {
+		return c, err
+	}
+	c.Green, err = br.ReadUInt16()
+	if err != nil {
+		return c, err
+	}
+	c.Blue, err = br.ReadUInt16()
+	if err != nil {
+		return c, err
+	}
+	c.Pad, err = br.ReadUInt16()
+	if err != nil {
+		return c, err
+	}
+	return c, nil
+}
+
+// QFont flag bits as written by Qt 5 (get_font_bits in qfont.cpp).
+const (
+	fontBitItalic     = 0x01
+	fontBitUnderline  = 0x02
+	fontBitStrikeOut  = 0x04
+	fontBitFixedPitch = 0x08
+	fontBitKerning    = 0x10
+	fontBitOverline   = 0x40
+	fontBitOblique    = 0x80
+)
+
+// ReadQFont reads a QFont in the layout written by Qt 5 for stream
+// version Qt 5.12, which Mudlet uses for map files.
+func (br *BinaryReader) ReadQFont() (Font, error) {
+	var f Font
+	var err error
+
+	if f.Family, err = br.ReadQString(); err != nil {
+		return f, err
+	}
+	if f.StyleName, err = br.ReadQString(); err != nil {
+		return f, err
+	}
+	if f.PointSizeF, err = br.ReadDouble(); err != nil {
+		return f, err
+	}
+	if f.PixelSize, err = br.ReadInt32(); err != nil {
+		return f, err
+	}
+	if f.StyleHint, err = br.ReadByte(); err != nil {
+		return f, err
+	}
+	if f.StyleStrategy, err = br.ReadUInt16(); err != nil {
+		return f, err
+	}
+	// Former character set, always 0
+	if _, err = br.ReadByte(); err != nil {
+		return f, err
+	}
+	if f.Weight, err = br.ReadByte(); err != nil {
+		return f, err
+	}
+	bits, err := br.ReadByte()
+	if err != nil {
+		return f, err
+	}
+	switch {
+	case bits&fontBitOblique != 0:
+		f.Style = FontStyleOblique
+	case bits&fontBitItalic != 0:
+		f.Style = FontStyleItalic
+	}
+	f.Underline = bits&fontBitUnderline != 0
+	f.Overline = bits&fontBitOverline != 0
+	f.StrikeOut = bits&fontBitStrikeOut != 0
+	f.FixedPitch = bits&fontBitFixedPitch != 0
+	f.Kerning = bits&fontBitKerning != 0
+
+	if f.Stretch, err = br.ReadUInt16(); err != nil {
+		return f, err
+	}
+	// Extended bits (ignore pitch, absolute letter spacing) are not kept
+	if _, err = br.ReadByte(); err != nil {
+		return f, err
+	}
+	if f.LetterSpacing, err = br.ReadInt32(); err != nil {
+		return f, err
+	}
+	if f.WordSpacing, err = br.ReadInt32(); err != nil {
+		return f, err
+	}
+	if f.HintingPreference, err = br.ReadByte(); err != nil {
+		return f, err
+	}
+	if f.Capitalization, err = br.ReadByte(); err != nil {
+		return f, err
+	}
+	return f, nil
+}
+
+// ReadQVector3D reads a QVector3D stored as three doubles.
+//
+// Qt writes QVector3D components as float, but Mudlet sets the stream's
+// floating point precision to double.
+func (br *BinaryReader) ReadQVector3D() (Vector3D, error) {
+	var v Vector3D
+	var err error
+	v.X, err = br.ReadDouble()
+	if err != nil {
+		return v, err
+	}
+	v.Y, err = br.ReadDouble()
+	if err != nil {
+		return v, err
+	}
+	v.Z, err = br.ReadDouble()
+	if err != nil {
+		return v, err
+	}
+	return v, nil
+}
+
+// pngSignature starts every PNG stream.
+var pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+
+// maxBlobSize bounds the size of a single PNG chunk or byte array, so that
+// a corrupted length cannot trigger a huge allocation.
+const maxBlobSize = 64 << 20
+
+// ReadQPixmap reads a QPixmap, which Qt serializes as a QImage: an int32
+// marker (0 for a null image, 1 otherwise) followed by the image written in
+// PNG format. The PNG stream is not length-prefixed, so its end is found by
+// walking the chunk headers up to the IEND chunk. It returns the raw PNG
+// bytes, or nil for a null image.
+func (br *BinaryReader) ReadQPixmap() ([]byte, error) {
+	return br.readQPixmap(true)
+}
+
+// SkipQPixmap passes over a QPixmap without keeping it. Only the PNG chunk
+// headers are read; with [NewBinaryReaderAt], chunk data is skipped by
+// offset and never read at all.
+func (br *BinaryReader) SkipQPixmap() error {
+	_, err := br.readQPixmap(false)
+	return err
+}
+
+// readQPixmap walks a QPixmap, collecting the PNG bytes if keep is set.
+func (br *BinaryReader) readQPixmap(keep bool) ([]byte, error) {
+	marker, err := br.ReadInt32()
+	if err != nil {
+		return nil, err
+	}
+	switch marker {
+	case 0:
+		return nil, nil
+	case 1:
+	default:
+		return nil, fmt.Errorf("pixmap: unexpected image marker %d", marker)
+	}
+
+	// A PNG is stored contiguously, so shared input is sliced, not copied
+	start := br.off
+	shared := keep && br.shared
+	sig, err := br.readN(len(pngSignature))
+	if err != nil {
+		return nil, fmt.Errorf("pixmap: %w", err)
+	}
+	if !bytes.Equal(sig, pngSignature) {
+		return nil, fmt.Errorf("pixmap: missing PNG signature")
+	}
+	var buf []byte
+	if keep && !shared {
+		buf = bytes.Clone(sig)
+	}
+
+	for {
+		// Chunk: length, type, data, CRC
+		header, err := br.readN(8)
+		if err != nil {
+			return nil, fmt.Errorf("pixmap: chunk header: %w", err)
+		}
+		length := binary.BigEndian.Uint32(header[:4])
+		chunkType := string(header[4:])
+		if length > maxBlobSize {
+			return nil, fmt.Errorf("pixmap: chunk %q too large (%d bytes)", chunkType, length)
+		}
+		if shared {
+			if err := br.Skip(int(length) + 4); err != nil {
+				return nil, fmt.Errorf("pixmap: chunk %q: %w", chunkType, err)
+			}
+		} else if keep {
+			buf = append(buf, header...)
+			body, err := br.readN(int(length) + 4)
+			if err != nil {
+				return nil, fmt.Errorf("pixmap: chunk %q: %w", chunkType, err)
+			}
+			buf = append(buf, body...)
+		} else if err := br.Skip(int(length) + 4); err != nil {
+			return nil, fmt.Errorf("pixmap: chunk %q: %w", chunkType, err)
+		}
+		if chunkType == "IEND" {
+			if shared {
+				return br.win[start:br.off:br.off], nil
+			}
+			return buf, nil
+		}
+	}
+}
+
+// ReadQPoint reads a QPoint stored as two int32 coordinates, returned as a
+// [Point2D] so callers handle both point types alike.
+func (br *BinaryReader) ReadQPoint() (Point2D, error) {
+	x, err := br.ReadInt32()
+	if err != nil {
+		return Point2D{}, err
+	}
+	y, err := br.ReadInt32()
+	if err != nil {
+		return Point2D{}, err
+	}
+	return Point2D{X: float64(x), Y: float64(y)}, nil
+}
+
+// ReadQPointF reads a QPointF stored as two doubles.
+func (br *BinaryReader) ReadQPointF() (Point2D, error) {
+	var pt Point2D
+	var err error
+	pt.X, err = br.ReadDouble()
+	if err != nil {
+		return pt, err
+	}
+	pt.Y, err = br.ReadDouble()
+	if err != nil {
+		return pt, err
+	}
+	return pt, nil
+}
+
+// ReadQByteArray reads a QByteArray: a uint32 length (0xFFFFFFFF for a
+// null array) followed by the bytes. A null array is returned as nil.
+func (br *BinaryReader) ReadQByteArray() ([]byte, error) {
+	length, err := br.ReadUInt32()
+	if err != nil {
+		return nil, err
+	}
+	if length == 0xFFFFFFFF {
+		return nil, nil
+	}
+	if length > maxBlobSize {
+		return nil, fmt.Errorf("byte array too large (%d bytes)", length)
+	}
+	return br.ReadBytes(int(length))
+}
+
+// QVariant type IDs supported by [BinaryReader.ReadQVariant], as Qt 5
+// numbers them.
+const (
+	QVariantInvalid    uint32 = 0
+	QVariantBool       uint32 = 1
+	QVariantInt        uint32 = 2
+	QVariantUInt       uint32 = 3
+	QVariantLongLong   uint32 = 4
+	QVariantULongLong  uint32 = 5
+	QVariantDouble     uint32 = 6
+	QVariantChar       uint32 = 7
+	QVariantMap        uint32 = 8
+	QVariantList       uint32 = 9
+	QVariantString     uint32 = 10
+	QVariantStringList uint32 = 11
+	QVariantByteArray  uint32 = 12
+	QVariantPoint      uint32 = 25
+	QVariantPointF     uint32 = 26
+	QVariantFont       uint32 = 64
+	QVariantPixmap     uint32 = 65
+	QVariantColor      uint32 = 67
+	QVariantFloat      uint32 = 38
+)
+
+// ReadQVariant reads a QVariant: a uint32 type ID, a null flag byte and the
+// value. The value is returned as the matching Go type:
+//
+//   - Bool: bool
+//   - Int, UInt, LongLong, ULongLong: int32, uint32, int64, uint64
+//   - Double, Float: float64
+//   - Char: rune
+//   - String: string; StringList: []string; ByteArray: []byte
+//   - Map: map[string]any; List: []any
+//   - Point, PointF: [Point2D]
+//   - Color: [Color]; Font: [Font]; Pixmap: PNG bytes
+//
+// Variants are decoded as Qt 5 writes them. An invalid variant returns
+// nil. Other types, including user types, return an error because their
+// size is unknown and the stream cannot be resynchronized.
+func (br *BinaryReader) ReadQVariant() (any, error) {
+	typeID, err := br.ReadUInt32()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := br.ReadByte(); err != nil { // isNull
+		return nil, err
+	}
+
+	switch typeID {
+	case QVariantInvalid:
+		return nil, nil
+	case QVariantBool:
+		return br.ReadBool()
+	case QVariantInt:
+		return br.ReadInt32()
+	case QVariantUInt:
+		return br.ReadUInt32()
+	case QVariantLongLong:
+		v, err := br.ReadUInt64()
+		return int64(v), err
+	case QVariantULongLong:
+		return br.ReadUInt64()
+	case QVariantDouble:
+		return br.ReadDouble()
+	case QVariantFloat:
+		// Written with the stream's double precision
+		return br.ReadDouble()
+	case QVariantChar:
+		v, err := br.ReadUInt16()
+		return rune(v), err
+	case QVariantString:
+		return br.ReadQString()
+	case QVariantStringList:
+		return br.readQStringList()
+	case QVariantByteArray:
+		return br.ReadQByteArray()
+	case QVariantMap:
+		return br.readQVariantMap()
+	case QVariantList:
+		return br.readQVariantList()
+	case QVariantPoint:
+		x, err := br.ReadInt32()
+		if err != nil {
+			return nil, err
+		}
+		y, err := br.ReadInt32()
+		return Point2D{X: float64(x), Y: float64(y)}, err
+	case QVariantPointF:
+		return br.ReadQPointF()
+	case QVariantColor:
+		return br.ReadQColor()
+	case QVariantFont:
+		return br.ReadQFont()
+	case QVariantPixmap:
+		return br.ReadQPixmap()
+	}
+	return nil, fmt.Errorf("unsupported QVariant type %d", typeID)
+}
+
+func (br *BinaryReader) readQStringList() ([]string, error) {
+	count, err := br.ReadUInt32()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]string, 0, min(count, 1024))
+	for i := uint32(0); i < count; i++ {
+		s, err := br.ReadQString()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+	return list, nil
+}
+
+func (br *BinaryReader) readQVariantList() ([]any, error) {
+	count, err := br.ReadUInt32()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]any, 0, min(count, 1024))
+	for i := uint32(0); i < count; i++ {
+		v, err := br.ReadQVariant()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+	return list, nil
+}
+
+func (br *BinaryReader) readQVariantMap() (map[string]any, error) {
+	count, err := br.ReadUInt32()
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]any, min(count, 1024))
+	for i := uint32(0); i < count; i++ {
+		key, err := br.ReadQString()
+		if err != nil {
+			return nil, err
+		}
+		v, err := br.ReadQVariant()
+		if err != nil {
+			return nil, err
+		}
+		m[key] = v
+	}
+	return m, nil
+}
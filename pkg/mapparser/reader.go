@@ -1,7 +1,7 @@
 package mapparser
 
 import (
-	"bufio"
+	"bytes"
 	"encoding/binary"
 	"fmt"
 	"io"
@@ -10,34 +10,220 @@ import (
 )
 
 // BinaryReader provides methods for reading binary data in Qt's QDataStream format.
-// It wraps a bufio.Reader for efficient buffered reading and tracks the approximate
-// byte position for debugging purposes.
+// It reads ahead from a stream or an [io.ReaderAt] into a window of its own,
+// and tracks the exact byte offset of the next unread byte.
 type BinaryReader struct {
-	reader *bufio.Reader
-	pos    int // approximate byte position (for debugging)
+	// win holds the bytes read ahead, of which win[off:] are unread. base
+	// is the stream offset of win[0].
+	win  []byte
+	off  int
+	base int
+	// src refills the window, using buf as storage. It is nil when win
+	// holds the whole input.
+	src source
+	buf []byte
+
+	precision FloatPrecision
+	// shared lets decoded byte data point into win instead of being
+	// copied. It is only set when win holds the whole input and outlives
+	// the reader.
+	shared bool
+	// text holds narrowed ASCII strings, so that reads do not allocate.
+	text []byte
+	// interned holds short strings already returned by ReadQString. Maps
+	// repeat the same direction names, door keys and user data keys in
+	// every room, so sharing them saves an allocation per occurrence.
+	interned map[string]string
+	// internAll interns strings up to maxInternAllLen bytes without
+	// limiting the table size.
+	internAll bool
+}
+
+// readWindow is the number of bytes a BinaryReader reads ahead at a time.
+const readWindow = 64 << 10
+
+// Interning limits: strings longer than maxInternLen bytes of UTF-8 are
+// rarely repeated, and the table stops growing at maxInterned entries.
+const (
+	maxInternLen = 32
+	maxInterned  = 4096
+	// maxInternAllLen also covers repeated user data values, such as
+	// terrain names or area tags, in low-memory mode.
+	maxInternAllLen = 256
+)
+
+// source supplies the bytes a [BinaryReader] decodes.
+type source interface {
+	// read reads into p the bytes starting at stream offset off, which
+	// always follows the bytes read or skipped before.
+	read(p []byte, off int64) (int, error)
+	// skip passes over up to n bytes starting at off and returns how many
+	// there were.
+	skip(off int64, n int) (int, error)
+}
+
+// streamSource reads an [io.Reader] front to back.
+type streamSource struct {
+	r io.Reader
+}
+
+func (s streamSource) read(p []byte, _ int64) (int, error) {
+	return s.r.Read(p)
+}
+
+func (s streamSource) skip(_ int64, n int) (int, error) {
+	skipped, err := io.CopyN(io.Discard, s.r, int64(n))
+	return int(skipped), err
+}
+
+// atSource reads the first size bytes of an [io.ReaderAt] by offset, so
+// skipped bytes are never read.
+type atSource struct {
+	r    io.ReaderAt
+	size int64
+}
+
+func (s atSource) read(p []byte, off int64) (int, error) {
+	if off >= s.size {
+		return 0, io.EOF
+	}
+	p = p[:min(int64(len(p)), s.size-off)]
+	n, err := s.r.ReadAt(p, off)
+	if n == len(p) {
+		err = nil
+	}
+	return n, err
+}
+
+func (s atSource) skip(off int64, n int) (int, error) {
+	if left := s.size - off; int64(n) > left {
+		return int(max(left, 0)), io.EOF
+	}
+	return n, nil
 }
 
-// Position returns the approximate byte offset from the start of the stream.
-// This value is tracked for debugging and may not be exact after errors.
+// FloatPrecision identifies how a QDataStream stores floating-point values,
+// as set with QDataStream::setFloatingPointPrecision.
+type FloatPrecision int
+
+const (
+	// DoublePrecision stores every float and double in 8 bytes. It is
+	// Qt's default and what Mudlet writes.
+	DoublePrecision FloatPrecision = iota
+	// SinglePrecision stores every float and double in 4 bytes.
+	SinglePrecision
+)
+
+// String returns "double" or "single".
+func (p FloatPrecision) String() string {
+	if p == SinglePrecision {
+		return "single"
+	}
+	return "double"
+}
+
+// SetFloatPrecision selects the size of floating-point values read by
+// [BinaryReader.ReadDouble] and the Qt types built on it. The default is
+// [DoublePrecision].
+func (br *BinaryReader) SetFloatPrecision(p FloatPrecision) {
+	br.precision = p
+}
+
+// FloatPrecision returns the floating-point precision the reader decodes.
+func (br *BinaryReader) FloatPrecision() FloatPrecision {
+	return br.precision
+}
+
+// Position returns the number of bytes consumed from the start of the stream,
+// which is the offset of the next byte to be read. Bytes buffered ahead but
+// not yet consumed, or only peeked at, are not counted. After a failed read
+// the position includes any bytes of the partial value that were read.
 func (br *BinaryReader) Position() int {
-	return br.pos
+	return br.base + br.off
 }
 
 // NewBinaryReader creates a new BinaryReader wrapping the given io.Reader.
-// The reader is automatically wrapped in a bufio.Reader for efficient buffered I/O.
+// Data is read ahead in large blocks, so the reader need not be buffered.
 func NewBinaryReader(reader io.Reader) *BinaryReader {
-	return &BinaryReader{
-		reader: bufio.NewReader(reader),
+	return &BinaryReader{src: streamSource{reader}}
+}
+
+// NewBinaryReaderAt creates a BinaryReader over the first size bytes of r.
+// Data is fetched by offset, so bytes passed over with
+// [BinaryReader.Skip] are never read from r.
+func NewBinaryReaderAt(r io.ReaderAt, size int64) *BinaryReader {
+	return &BinaryReader{src: atSource{r: r, size: size}}
+}
+
+// newBytesReader creates a BinaryReader decoding data in place, without
+// copying it.
+func newBytesReader(data []byte) *BinaryReader {
+	return &BinaryReader{win: data}
+}
+
+// readN consumes the next n bytes and returns them. The slice is only valid
+// until the next read.
+func (br *BinaryReader) readN(n int) ([]byte, error) {
+	if n <= len(br.win)-br.off {
+		b := br.win[br.off : br.off+n]
+		br.off += n
+		return b, nil
+	}
+	avail, err := br.fill(n)
+	if avail < n {
+		br.off = len(br.win)
+		return nil, eofError(avail, err)
+	}
+	b := br.win[br.off : br.off+n]
+	br.off += n
+	return b, nil
+}
+
+// fill reads ahead until at least n bytes are unread or the source is
+// exhausted, and returns the number of unread bytes.
+func (br *BinaryReader) fill(n int) (int, error) {
+	avail := len(br.win) - br.off
+	if br.src == nil {
+		return avail, io.EOF
 	}
+	size := max(n, readWindow)
+	if cap(br.buf) < size {
+		br.buf = make([]byte, size)
+	}
+	buf := br.buf[:size]
+	copy(buf, br.win[br.off:])
+	br.base += br.off
+	br.off = 0
+	br.win = buf[:avail]
+	for len(br.win) < n {
+		read, err := br.src.read(buf[len(br.win):], int64(br.base+len(br.win)))
+		br.win = buf[:len(br.win)+read]
+		if err != nil {
+			return len(br.win), err
+		}
+	}
+	return len(br.win), nil
+}
+
+// eofError reports a read that ended after got bytes of a value, as
+// [io.ReadFull] does.
+func eofError(got int, err error) error {
+	if err != nil && err != io.EOF {
+		return err
+	}
+	if got == 0 {
+		return io.EOF
+	}
+	return io.ErrUnexpectedEOF
 }
 
 // ReadByte reads a single byte
 func (br *BinaryReader) ReadByte() (byte, error) {
-	b, err := br.reader.ReadByte()
-	if err == nil {
-		br.pos++
+	b, err := br.readN(1)
+	if err != nil {
+		return 0, err
 	}
-	return b, err
+	return b[0], nil
 }
 
 // ReadInt8 reads an int8
@@ -51,13 +237,11 @@ func (br *BinaryReader) ReadInt8() (int8, error) {
 
 // ReadInt32 reads an int32 in big endian format
 func (br *BinaryReader) ReadInt32() (int32, error) {
-	var value int32
-	err := binary.Read(br.reader, binary.BigEndian, &value)
+	b, err := br.readN(4)
 	if err != nil {
 		return 0, err
 	}
-	br.pos += 4
-	return value, nil
+	return int32(binary.BigEndian.Uint32(b)), nil
 }
 
 // ReadString reads a length-prefixed string
@@ -74,8 +258,8 @@ func (br *BinaryReader) ReadString() (string, error) {
 	}
 
 	// Read string data
-	data := make([]byte, length)
-	if _, err := io.ReadFull(br.reader, data); err != nil {
+	data, err := br.readN(int(length))
+	if err != nil {
 		return "", fmt.Errorf("reading string data: %w", err)
 	}
 
@@ -89,26 +273,80 @@ func (br *BinaryReader) ReadString() (string, error) {
 //   - []byte data: UTF-16BE encoded characters (byteLength bytes, must be even)
 //
 // Returns the decoded UTF-8 string, or an empty string for null QString values.
+// Qt4 and Qt5 streams share this encoding, as they do for QMap (a quint32
+// count followed by key/value pairs), so maps of any version decode alike.
 func (br *BinaryReader) ReadQString() (string, error) {
 	// In Qt5 QDataStream, QString is serialized as quint32 byte length (or 0xFFFFFFFF for null),
 	// followed by that many bytes of UTF-16BE data.
-	var n uint32
-	if err := binary.Read(br.reader, binary.BigEndian, &n); err != nil {
+	n, err := br.ReadUInt32()
+	if err != nil {
 		return "", fmt.Errorf("reading QString length: %w", err)
 	}
-	br.pos += 4
 	if n == 0xFFFFFFFF {
 		return "", nil
 	}
 	if n%2 != 0 || n > 10000000 {
 		return "", fmt.Errorf("invalid QString byte length: %d", n)
 	}
-	units := make([]uint16, int(n/2))
-	if err := binary.Read(br.reader, binary.BigEndian, &units); err != nil {
+	data, err := br.readN(int(n))
+	if err != nil {
 		return "", fmt.Errorf("reading QString data: %w", err)
 	}
-	br.pos += int(n)
-	return string(utf16.Decode(units)), nil
+	return br.decodeUTF16(data), nil
+}
+
+// decodeUTF16 converts UTF-16BE data to a string, interning short results.
+// ASCII text, by far the most common in maps, is narrowed byte by byte
+// without going through runes.
+func (br *BinaryReader) decodeUTF16(data []byte) string {
+	for i := 0; i < len(data); i += 2 {
+		if data[i] != 0 || data[i+1] >= 0x80 {
+			units := make([]uint16, len(data)/2)
+			for j := range units {
+				units[j] = binary.BigEndian.Uint16(data[2*j:])
+			}
+			return br.intern(string(utf16.Decode(units)))
+		}
+	}
+	if cap(br.text) < len(data)/2 {
+		br.text = make([]byte, len(data)/2)
+	}
+	ascii := br.text[:len(data)/2]
+	for i := range ascii {
+		ascii[i] = data[2*i+1]
+	}
+	if len(ascii) > br.internLen() {
+		return string(ascii)
+	}
+	if s, ok := br.interned[string(ascii)]; ok {
+		return s
+	}
+	return br.intern(string(ascii))
+}
+
+// intern returns the shared copy of s if it is short enough to be interned.
+func (br *BinaryReader) intern(s string) string {
+	if len(s) > br.internLen() {
+		return s
+	}
+	if shared, ok := br.interned[s]; ok {
+		return shared
+	}
+	if br.interned == nil {
+		br.interned = make(map[string]string)
+	}
+	if len(br.interned) < maxInterned || br.internAll {
+		br.interned[s] = s
+	}
+	return s
+}
+
+// internLen returns the length of the longest strings interned.
+func (br *BinaryReader) internLen() int {
+	if br.internAll {
+		return maxInternAllLen
+	}
+	return maxInternLen
 }
 
 // ReadBool reads a boolean value (1 byte, 0 = false, non-zero = true)
@@ -122,48 +360,88 @@ func (br *BinaryReader) ReadBool() (bool, error) {
 
 // ReadUInt16 reads an unsigned 16-bit integer in big endian
 func (br *BinaryReader) ReadUInt16() (uint16, error) {
-	var value uint16
-	err := binary.Read(br.reader, binary.BigEndian, &value)
+	b, err := br.readN(2)
 	if err != nil {
 		return 0, err
 	}
-	br.pos += 2
-	return value, nil
+	return binary.BigEndian.Uint16(b), nil
 }
 
 // ReadUInt32 reads an unsigned 32-bit integer in big endian
 func (br *BinaryReader) ReadUInt32() (uint32, error) {
-	var value uint32
-	err := binary.Read(br.reader, binary.BigEndian, &value)
+	b, err := br.readN(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(b), nil
+}
+
+// ReadUInt64 reads a big-endian uint64
+func (br *BinaryReader) ReadUInt64() (uint64, error) {
+	b, err := br.readN(8)
 	if err != nil {
 		return 0, err
 	}
-	br.pos += 4
-	return value, nil
+	return binary.BigEndian.Uint64(b), nil
 }
 
-// ReadDouble reads an IEEE754 float64 in big endian
+// ReadDouble reads an IEEE754 float64 in big endian, or a float32 widened
+// to float64 if the reader is set to [SinglePrecision].
 func (br *BinaryReader) ReadDouble() (float64, error) {
-	var bits uint64
-	err := binary.Read(br.reader, binary.BigEndian, &bits)
+	if br.precision == SinglePrecision {
+		bits, err := br.ReadUInt32()
+		if err != nil {
+			return 0, err
+		}
+		return float64(math.Float32frombits(bits)), nil
+	}
+	bits, err := br.ReadUInt64()
 	if err != nil {
 		return 0, err
 	}
-	br.pos += 8
 	return math.Float64frombits(bits), nil
 }
 
-// Skip n bytes
 // Peek returns the next n bytes without advancing the reader
+// The slice is only valid until the next read.
 func (br *BinaryReader) Peek(n int) ([]byte, error) {
-	return br.reader.Peek(n)
+	if n > len(br.win)-br.off {
+		if avail, err := br.fill(n); avail < n {
+			return br.win[br.off:], eofError(avail, err)
+		}
+	}
+	return br.win[br.off : br.off+n], nil
+}
+
+// ReadBytes reads exactly n bytes.
+func (br *BinaryReader) ReadBytes(n int) ([]byte, error) {
+	b, err := br.readN(n)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.Clone(b), nil
 }
 
+// Skip discards the next n bytes.
 func (br *BinaryReader) Skip(n int) error {
-	buf := make([]byte, n)
-	_, err := io.ReadFull(br.reader, buf)
-	if err == nil {
-		br.pos += n
+	if n < 0 {
+		return fmt.Errorf("invalid skip length: %d", n)
+	}
+	if n <= len(br.win)-br.off {
+		br.off += n
+		return nil
+	}
+	buffered := len(br.win) - br.off
+	if br.src == nil {
+		br.off = len(br.win)
+		return eofError(buffered, nil)
+	}
+	br.base += len(br.win)
+	br.win, br.off = br.win[:0], 0
+	skipped, err := br.src.skip(int64(br.base), n-buffered)
+	br.base += skipped
+	if buffered+skipped < n {
+		return eofError(buffered+skipped, err)
 	}
-	return err
+	return nil
 }
@@ -0,0 +1,424 @@
+package mapparser
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf16"
+	"unsafe"
+)
+
+// qdsWriter builds QDataStream-encoded test input.
+type qdsWriter struct {
+	bytes.Buffer
+}
+
+func (w *qdsWriter) int32(v int32) *qdsWriter {
+	_ = binary.Write(w, binary.BigEndian, v)
+	return w
+}
+
+func (w *qdsWriter) uint32(v uint32) *qdsWriter {
+	_ = binary.Write(w, binary.BigEndian, v)
+	return w
+}
+
+func (w *qdsWriter) double(v float64) *qdsWriter {
+	_ = binary.Write(w, binary.BigEndian, v)
+	return w
+}
+
+// single writes a float in QDataStream's single-precision mode.
+func (w *qdsWriter) single(v float32) *qdsWriter {
+	_ = binary.Write(w, binary.BigEndian, v)
+	return w
+}
+
+// variant writes a QVariant header: type ID and a false null flag.
+func (w *qdsWriter) variant(typeID uint32) *qdsWriter {
+	w.uint32(typeID)
+	w.WriteByte(0)
+	return w
+}
+
+func (w *qdsWriter) qstring(s string) *qdsWriter {
+	units := utf16.Encode([]rune(s))
+	w.uint32(uint32(2 * len(units)))
+	_ = binary.Write(w, binary.BigEndian, units)
+	return w
+}
+
+// TestReadQString tests null, empty and non-ASCII QString decoding
+func TestReadQString(t *testing.T) {
+	var w qdsWriter
+	w.uint32(0xFFFFFFFF).uint32(0).qstring("Zażółć")
+	r := NewBinaryReader(&w)
+	for _, want := range []string{"", "", "Zażółć"} {
+		got, err := r.ReadQString()
+		if err != nil || got != want {
+			t.Errorf("ReadQString = %q, %v; expected %q", got, err, want)
+		}
+	}
+	if _, err := NewBinaryReader(bytes.NewReader([]byte{0, 0, 0, 3, 0, 'a', 0})).ReadQString(); err == nil {
+		t.Error("Expected error for odd byte length")
+	}
+}
+
+// TestReadQStringBuffering tests strings larger than the read buffer,
+// surrogate pairs and the sharing of repeated short strings
+func TestReadQStringBuffering(t *testing.T) {
+	long := strings.Repeat("road ", 1000)
+	var w qdsWriter
+	w.qstring("north").qstring(long).qstring("mapa 🗺").qstring("north")
+	r := NewBinaryReader(&w)
+	var got []string
+	for range 4 {
+		s, err := r.ReadQString()
+		if err != nil {
+			t.Fatalf("ReadQString failed: %v", err)
+		}
+		got = append(got, s)
+	}
+	if !reflect.DeepEqual(got, []string{"north", long, "mapa 🗺", "north"}) {
+		t.Errorf("Unexpected strings: %q", got)
+	}
+	if unsafe.StringData(got[0]) != unsafe.StringData(got[3]) {
+		t.Error("Expected repeated short string to be shared")
+	}
+	if want := 4 + 10 + 4 + 2*len(long) + 4 + 14 + 4 + 10; r.Position() != want {
+		t.Errorf("Position() = %d, expected %d", r.Position(), want)
+	}
+}
+
+// TestParseOldHeader tests that pre-v17 maps skip map-level fields that
+// did not exist yet
+func TestParseOldHeader(t *testing.T) {
+	var w qdsWriter
+	w.int32(9)                         // version
+	w.int32(1).int32(1).int32(4)       // envColors {1: 4}
+	w.int32(1).int32(5).qstring("Old") // areaNames {5: "Old"}
+	w.int32(0)                         // mCustomEnvColors
+	w.int32(0)                         // mpRoomDbHashToRoomId
+	w.int32(0)                         // areas
+	// no mUserData (v17) or map font (v19)
+
+	info, err := InspectMap(&w)
+	if err != nil {
+		t.Fatalf("InspectMap failed: %v", err)
+	}
+	if info.Version != 9 || info.AreaCount != 1 || info.Areas[0].Name != "Old" {
+		t.Errorf("Unexpected info: %+v", info)
+	}
+}
+
+// TestPositionExact tests that Position counts consumed bytes only, across
+// peeks, skips and failed reads
+func TestPositionExact(t *testing.T) {
+	var w qdsWriter
+	w.int32(1).qstring("abc").uint32(7)
+	w.WriteByte(0xAA)
+	r := NewBinaryReader(&w)
+
+	steps := []struct {
+		read func() error
+		want int
+	}{
+		{func() error { _, err := r.ReadInt32(); return err }, 4},
+		{func() error { _, err := r.Peek(8); return err }, 4},
+		{func() error { _, err := r.ReadQString(); return err }, 14},
+		{func() error { return r.Skip(2) }, 16},
+		{func() error { _, err := r.ReadByte(); return err }, 17},
+		{func() error { _, err := r.ReadByte(); return err }, 18},
+	}
+	for i, s := range steps {
+		if err := s.read(); err != nil {
+			t.Fatalf("step %d: unexpected error: %v", i, err)
+		}
+		if got := r.Position(); got != s.want {
+			t.Errorf("step %d: Position() = %d, expected %d", i, got, s.want)
+		}
+	}
+
+	// A truncated int32 still counts the bytes that were read
+	r = NewBinaryReader(bytes.NewReader([]byte{0, 0, 1}))
+	if _, err := r.ReadInt32(); err == nil {
+		t.Fatal("Expected error for truncated int32")
+	}
+	if got := r.Position(); got != 3 {
+		t.Errorf("Position() after truncated read = %d, expected 3", got)
+	}
+}
+
+// TestParseErrorOffset tests that parse errors report where reading stopped
+func TestParseErrorOffset(t *testing.T) {
+	var w qdsWriter
+	w.int32(20)         // version
+	w.int32(1).int32(1) // envColors with a truncated value
+	_, err := ParseMap(&w)
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("Expected *ParseError, got %v", err)
+	}
+	if pe.Offset != 12 {
+		t.Errorf("Offset = %d, expected 12", pe.Offset)
+	}
+	if !strings.Contains(err.Error(), "at offset 12") {
+		t.Errorf("Error %q does not mention the offset", err)
+	}
+}
+
+// TestParseErrorSentinels tests that parse failures can be told apart with
+// errors.Is
+func TestParseErrorSentinels(t *testing.T) {
+	tests := []struct {
+		name  string
+		input func(w *qdsWriter)
+		want  error
+	}{
+		{"empty", func(w *qdsWriter) {}, ErrTruncated},
+		{"truncated", func(w *qdsWriter) { w.int32(20).int32(1).int32(1) }, ErrTruncated},
+		{"not a map", func(w *qdsWriter) { w.WriteString("PK\x03\x04zip file") }, ErrNotAMudletMap},
+		{"too old", func(w *qdsWriter) { w.int32(2) }, ErrUnsupportedVersion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var w qdsWriter
+			tt.input(&w)
+			_, err := ParseMap(&w)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("ParseMap error = %v, expected %v", err, tt.want)
+			}
+			var pe *ParseError
+			if !errors.As(err, &pe) {
+				t.Errorf("Expected *ParseError, got %T", err)
+			}
+		})
+	}
+}
+
+// TestSinglePrecision tests that a single-precision stream is read with
+// 4-byte floats throughout an area record
+func TestSinglePrecision(t *testing.T) {
+	var w qdsWriter
+	w.int32(0).int32(0).int32(0) // rooms, zLevels, mAreaExits
+	w.WriteByte(0)               // gridMode
+	for range 6 {
+		w.int32(0) // bounds
+	}
+	w.single(10).single(20).single(1)     // span
+	w.int32(0).int32(0).int32(0).int32(0) // x/y min/max for z
+	w.single(-2.5).single(4).single(0)    // pos
+	w.WriteByte(1)                        // isZone
+	w.int32(7)                            // zoneAreaRef
+	w.single(1.5)                         // mLast2DMapZoom
+	w.int32(0).int32(0)                   // mUserData, mMapLabels
+	size := w.Len()
+
+	m := NewMudletMap()
+	m.Version = 21
+	p := &parser{r: NewBinaryReader(&w), m: m}
+	p.r.SetFloatPrecision(SinglePrecision)
+	area := NewMudletArea(1, "Single")
+	if err := decodeRecord(p, areaSchema, area); err != nil {
+		t.Fatalf("decodeRecord failed: %v", err)
+	}
+	if p.r.Position() != size {
+		t.Errorf("Read %d bytes, expected %d", p.r.Position(), size)
+	}
+	if area.Span != (Vector3D{X: 10, Y: 20, Z: 1}) || area.Pos != (Vector3D{X: -2.5, Y: 4}) {
+		t.Errorf("Span %+v, Pos %+v", area.Span, area.Pos)
+	}
+	if area.Last2DMapZoom != 1.5 || !area.IsZone || area.ZoneAreaRef != 7 {
+		t.Errorf("Unexpected area %+v", area)
+	}
+}
+
+// TestReadQPixmap tests that pixmaps are read chunk by chunk, so "IEND"
+// bytes inside chunk data do not end the image early
+func TestReadQPixmap(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	src.Set(1, 1, color.RGBA{R: 255, A: 255})
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, src); err != nil {
+		t.Fatal(err)
+	}
+	// Insert a tEXt chunk containing "IEND" right after IHDR (8 + 25 bytes)
+	data := []byte("Comment\x00IEND")
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	chunk = append(chunk, "tEXt"...)
+	chunk = append(chunk, data...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+	pngData := append(append(append([]byte{}, encoded.Bytes()[:33]...), chunk...), encoded.Bytes()[33:]...)
+
+	var w qdsWriter
+	w.int32(1)
+	w.Write(pngData)
+	w.int32(0)  // null pixmap
+	w.int32(42) // trailing data
+
+	r := NewBinaryReader(&w)
+	got, err := r.ReadQPixmap()
+	if err != nil {
+		t.Fatalf("ReadQPixmap failed: %v", err)
+	}
+	if !bytes.Equal(got, pngData) {
+		t.Fatalf("ReadQPixmap returned %d bytes, expected %d", len(got), len(pngData))
+	}
+	if null, err := r.ReadQPixmap(); err != nil || null != nil {
+		t.Errorf("Expected nil pixmap for null marker, got %d bytes, %v", len(null), err)
+	}
+	if v, _ := r.ReadInt32(); v != 42 {
+		t.Errorf("Expected trailing value 42, got %d", v)
+	}
+
+	label := &MudletLabel{Pixmap: got}
+	img, err := label.Image()
+	if err != nil {
+		t.Fatalf("Image failed: %v", err)
+	}
+	if img.Bounds() != src.Bounds() {
+		t.Errorf("Image bounds = %v, expected %v", img.Bounds(), src.Bounds())
+	}
+	if img, err := (&MudletLabel{}).Image(); img != nil || err != nil {
+		t.Errorf("Expected nil image for label without pixmap, got %v, %v", img, err)
+	}
+}
+
+// countingReaderAt records how many bytes are fetched from it.
+type countingReaderAt struct {
+	r    *bytes.Reader
+	read int
+}
+
+func (c *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := c.r.ReadAt(p, off)
+	c.read += n
+	return n, err
+}
+
+// TestSkipQPixmapAt tests that skipped pixmap data is not fetched from a
+// ReaderAt, and that skips past the end fail with the bytes counted
+func TestSkipQPixmapAt(t *testing.T) {
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	// A large tEXt chunk after IHDR, well beyond one read window
+	data := bytes.Repeat([]byte{'x'}, 4*readWindow)
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	chunk = append(chunk, "tEXt"...)
+	chunk = append(chunk, data...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+	pngData := append(append(append([]byte{}, encoded.Bytes()[:33]...), chunk...), encoded.Bytes()[33:]...)
+
+	var w qdsWriter
+	w.int32(1)
+	w.Write(pngData)
+	w.int32(42)
+	src := &countingReaderAt{r: bytes.NewReader(w.Bytes())}
+	r := NewBinaryReaderAt(src, int64(w.Len()))
+	if err := r.SkipQPixmap(); err != nil {
+		t.Fatalf("SkipQPixmap failed: %v", err)
+	}
+	if v, err := r.ReadInt32(); v != 42 || err != nil {
+		t.Errorf("Expected trailing value 42, got %d, %v", v, err)
+	}
+	if src.read >= len(data) {
+		t.Errorf("Read %d bytes, expected the %d-byte chunk to be skipped", src.read, len(data))
+	}
+
+	r = NewBinaryReaderAt(bytes.NewReader([]byte{1, 2, 3}), 3)
+	if err := r.Skip(5); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Expected io.ErrUnexpectedEOF, got %v", err)
+	}
+	if r.Position() != 3 {
+		t.Errorf("Position() after short skip = %d, expected 3", r.Position())
+	}
+	if _, err := r.ReadByte(); err != io.EOF {
+		t.Errorf("Expected io.EOF at end, got %v", err)
+	}
+}
+
+// TestReadQVariant tests scalar, container and Qt value variants
+func TestReadQVariant(t *testing.T) {
+	var w qdsWriter
+	w.variant(QVariantMap).uint32(2)
+	w.qstring("count").variant(QVariantInt).int32(5)
+	w.qstring("tags").variant(QVariantList).uint32(2)
+	w.variant(QVariantString).qstring("shop")
+	w.variant(QVariantBool).WriteByte(1)
+	w.variant(QVariantPointF).double(1.5).double(-2)
+	w.variant(QVariantColor).WriteByte(1)
+	w.Write([]byte{0xff, 0xff, 0x12, 0x12, 0x34, 0x34, 0x56, 0x56, 0, 0})
+	w.variant(QVariantInvalid)
+	w.variant(1024) // user type
+
+	r := NewBinaryReader(&w)
+	v, err := r.ReadQVariant()
+	if err != nil {
+		t.Fatalf("ReadQVariant failed: %v", err)
+	}
+	want := map[string]any{"count": int32(5), "tags": []any{"shop", true}}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("ReadQVariant = %#v, expected %#v", v, want)
+	}
+	if v, err := r.ReadQVariant(); err != nil || v != (Point2D{X: 1.5, Y: -2}) {
+		t.Errorf("ReadQVariant = %v, %v; expected point", v, err)
+	}
+	v, err = r.ReadQVariant()
+	if c, ok := v.(Color); err != nil || !ok || c.Red != 0x1212 || c.Blue != 0x5656 {
+		t.Errorf("ReadQVariant = %v, %v; expected color", v, err)
+	}
+	if v, err := r.ReadQVariant(); err != nil || v != nil {
+		t.Errorf("ReadQVariant = %v, %v; expected nil for invalid variant", v, err)
+	}
+	if _, err := r.ReadQVariant(); err == nil {
+		t.Error("Expected error for user type")
+	}
+}
+
+// TestReadQFont tests decoding a QFont in the Qt 5.12 stream layout
+func TestReadQFont(t *testing.T) {
+	var w qdsWriter
+	w.qstring("DejaVu Sans").qstring("Bold Italic").double(10.5).int32(-1)
+	w.WriteByte(5)                         // style hint
+	w.Write([]byte{0x00, 0x01})            // style strategy
+	w.WriteByte(0)                         // character set
+	w.WriteByte(75)                        // weight
+	w.WriteByte(0x01 | 0x02 | 0x10 | 0x40) // italic, underline, kerning, overline
+	w.Write([]byte{0x00, 0x64})            // stretch
+	w.WriteByte(0x01)                      // extended bits
+	w.int32(64).int32(-32)
+	w.WriteByte(2) // hinting
+	w.WriteByte(1) // capitalization
+	w.int32(42)    // next field
+
+	r := NewBinaryReader(&w)
+	f, err := r.ReadQFont()
+	if err != nil {
+		t.Fatalf("ReadQFont failed: %v", err)
+	}
+	want := Font{
+		Family: "DejaVu Sans", StyleName: "Bold Italic", PointSizeF: 10.5, PixelSize: -1,
+		StyleHint: 5, StyleStrategy: 1, Weight: 75, Style: FontStyleItalic,
+		Underline: true, Overline: true, Kerning: true, Stretch: 100,
+		LetterSpacing: 64, WordSpacing: -32, HintingPreference: 2, Capitalization: 1,
+	}
+	if f != want {
+		t.Errorf("ReadQFont = %+v\nexpected %+v", f, want)
+	}
+	if !f.Bold() {
+		t.Error("weight 75 should be bold")
+	}
+	if v, err := r.ReadInt32(); err != nil || v != 42 {
+		t.Errorf("next field = %d, %v; QFont not consumed exactly", v, err)
+	}
+}
@@ -0,0 +1,282 @@
+package mapparser
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// RepairOptions selects which fixes [RepairMap] applies.
+type RepairOptions struct {
+	// DropBrokenExits removes standard and special exits leading to rooms
+	// that do not exist, together with their locks, stubs, weights and doors.
+	DropBrokenExits bool
+
+	// QuarantineOrphans moves rooms whose area does not exist into a
+	// quarantine area, created if needed.
+	QuarantineOrphans bool
+	// QuarantineAreaID is the area used for quarantined rooms. When zero, the
+	// first unused area ID above the current maximum is allocated.
+	QuarantineAreaID int32
+	// QuarantineAreaName names a newly created quarantine area
+	// (default "Quarantine").
+	QuarantineAreaName string
+
+	// DedupeLabels removes labels identical to a lower-ID label of the same
+	// area (same position, size, text, colors, flags and pixmap).
+	DedupeLabels bool
+
+	// ClampWeights raises room weights below 1 to 1 and removes negative
+	// exit weights so the default applies.
+	ClampWeights bool
+}
+
+// DefaultRepairOptions enables every repair.
+func DefaultRepairOptions() RepairOptions {
+	return RepairOptions{
+		DropBrokenExits:   true,
+		QuarantineOrphans: true,
+		DedupeLabels:      true,
+		ClampWeights:      true,
+	}
+}
+
+// Repair change types reported in [RepairChange.Type].
+const (
+	RepairDroppedExit   = "dropped_exit"
+	RepairQuarantined   = "quarantined_room"
+	RepairRemovedLabel  = "removed_label"
+	RepairClampedWeight = "clamped_weight"
+	RepairRemovedWeight = "removed_exit_weight"
+	RepairCreatedArea   = "created_area"
+)
+
+// RepairChange describes one modification made by [RepairMap].
+type RepairChange struct {
+	Type    string `json:"type"`
+	RoomID  int32  `json:"roomId,omitempty"`
+	AreaID  int32  `json:"areaId,omitempty"`
+	LabelID int32  `json:"labelId,omitempty"`
+	Message string `json:"message"`
+}
+
+// RepairReport lists the changes made by [RepairMap] in the order applied.
+type RepairReport struct {
+	Changes []RepairChange `json:"changes"`
+	// QuarantineAreaID is the area rooms were moved to, or 0 if none were.
+	QuarantineAreaID int32 `json:"quarantineAreaId,omitempty"`
+}
+
+// RepairMap fixes common defects in m in place and reports what changed.
+// Rooms are processed in ascending ID order so the report is deterministic.
+func RepairMap(m *MudletMap, opts RepairOptions) (*RepairReport, error) {
+	if m == nil {
+		return nil, fmt.Errorf("nil map provided")
+	}
+	report := &RepairReport{Changes: []RepairChange{}}
+	add := func(c RepairChange) { report.Changes = append(report.Changes, c) }
+
+	roomIDs := sortedKeys(m.Rooms)
+
+	if opts.DropBrokenExits {
+		for _, id := range roomIDs {
+			dropBrokenExits(m, m.Rooms[id], add)
+		}
+	}
+
+	if opts.QuarantineOrphans {
+		quarantineID := int32(0)
+		for _, id := range roomIDs {
+			room := m.Rooms[id]
+			if _, ok := m.Areas[room.Area]; ok {
+				continue
+			}
+			if quarantineID == 0 {
+				quarantineID = quarantineArea(m, opts, add)
+				report.QuarantineAreaID = quarantineID
+			}
+			add(RepairChange{
+				Type:    RepairQuarantined,
+				RoomID:  room.ID,
+				AreaID:  quarantineID,
+				Message: fmt.Sprintf("moved room %d from missing area %d to area %d", room.ID, room.Area, quarantineID),
+			})
+			room.Area = quarantineID
+		}
+		if quarantineID != 0 {
+			rebuildAreaRooms(m)
+		}
+	}
+
+	if opts.DedupeLabels {
+		for _, areaID := range sortedKeys(m.Labels) {
+			m.Labels[areaID] = dedupeLabels(areaID, m.Labels[areaID], add)
+		}
+		for _, areaID := range sortedKeys(m.Areas) {
+			area := m.Areas[areaID]
+			area.Labels = dedupeLabels(areaID, area.Labels, add)
+		}
+	}
+
+	if opts.ClampWeights {
+		for _, id := range roomIDs {
+			room := m.Rooms[id]
+			if room.Weight < 1 {
+				add(RepairChange{
+					Type:    RepairClampedWeight,
+					RoomID:  room.ID,
+					Message: fmt.Sprintf("room %d weight %d clamped to 1", room.ID, room.Weight),
+				})
+				room.Weight = 1
+			}
+			for _, dir := range sortedStringKeys(room.ExitWeights) {
+				if w := room.ExitWeights[dir]; w < 0 {
+					add(RepairChange{
+						Type:    RepairRemovedWeight,
+						RoomID:  room.ID,
+						Message: fmt.Sprintf("room %d exit %q weight %d removed", room.ID, dir, w),
+					})
+					delete(room.ExitWeights, dir)
+				}
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// dropBrokenExits removes exits of room pointing to missing rooms.
+func dropBrokenExits(m *MudletMap, room *MudletRoom, add func(RepairChange)) {
+	for dir, dest := range room.Exits {
+		if dest == NoExit {
+			continue
+		}
+		if _, ok := m.Rooms[dest]; ok {
+			continue
+		}
+		add(RepairChange{
+			Type:    RepairDroppedExit,
+			RoomID:  room.ID,
+			Message: fmt.Sprintf("room %d %s exit to missing room %d removed", room.ID, ExitDirectionNames[dir], dest),
+		})
+		room.Exits[dir] = NoExit
+		room.ExitLocks = removeInt32(room.ExitLocks, DirectionCode(dir))
+		room.ExitStubs = removeInt32(room.ExitStubs, DirectionCode(dir))
+		delete(room.ExitWeights, ExitDirectionShortNames[dir])
+		delete(room.Doors, ExitDirectionShortNames[dir])
+	}
+	for _, cmd := range sortedStringKeys(room.SpecialExits) {
+		dest := room.SpecialExits[cmd]
+		if _, ok := m.Rooms[dest]; ok {
+			continue
+		}
+		add(RepairChange{
+			Type:    RepairDroppedExit,
+			RoomID:  room.ID,
+			Message: fmt.Sprintf("room %d special exit %q to missing room %d removed", room.ID, cmd, dest),
+		})
+		delete(room.SpecialExits, cmd)
+		delete(room.ExitWeights, cmd)
+		delete(room.Doors, cmd)
+		for i, locked := range room.SpecialExitLocks {
+			if locked == cmd {
+				room.SpecialExitLocks = append(room.SpecialExitLocks[:i], room.SpecialExitLocks[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// quarantineArea returns the area quarantined rooms are moved to, creating
+// it if it does not exist.
+func quarantineArea(m *MudletMap, opts RepairOptions, add func(RepairChange)) int32 {
+	id := opts.QuarantineAreaID
+	if id == 0 {
+		for areaID := range m.Areas {
+			if areaID > id {
+				id = areaID
+			}
+		}
+		id++
+	}
+	if _, ok := m.Areas[id]; ok {
+		return id
+	}
+	name := opts.QuarantineAreaName
+	if name == "" {
+		name = "Quarantine"
+	}
+	m.Areas[id] = NewMudletArea(id, name)
+	add(RepairChange{
+		Type:    RepairCreatedArea,
+		AreaID:  id,
+		Message: fmt.Sprintf("created area %d %q for rooms with missing areas", id, name),
+	})
+	return id
+}
+
+// dedupeLabels returns labels without entries identical to a lower-ID label.
+func dedupeLabels(areaID int32, labels []*MudletLabel, add func(RepairChange)) []*MudletLabel {
+	if len(labels) < 2 {
+		return labels
+	}
+	sorted := append([]*MudletLabel(nil), labels...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	removed := make(map[*MudletLabel]bool)
+	for i, a := range sorted {
+		if removed[a] {
+			continue
+		}
+		for _, b := range sorted[i+1:] {
+			if !removed[b] && sameLabel(a, b) {
+				removed[b] = true
+				add(RepairChange{
+					Type:    RepairRemovedLabel,
+					AreaID:  areaID,
+					LabelID: b.ID,
+					Message: fmt.Sprintf("label %d in area %d duplicates label %d", b.ID, areaID, a.ID),
+				})
+			}
+		}
+	}
+	if len(removed) == 0 {
+		return labels
+	}
+	kept := labels[:0]
+	for _, l := range labels {
+		if !removed[l] {
+			kept = append(kept, l)
+		}
+	}
+	return kept
+}
+
+// sameLabel reports whether two labels are identical apart from their IDs.
+func sameLabel(a, b *MudletLabel) bool {
+	return a.Pos == b.Pos && a.Width == b.Width && a.Height == b.Height &&
+		a.Text == b.Text && a.FgColor == b.FgColor && a.BgColor == b.BgColor &&
+		a.NoScaling == b.NoScaling && a.ShowOnTop == b.ShowOnTop &&
+		bytes.Equal(a.Pixmap, b.Pixmap)
+}
+
+// removeInt32 returns s without any occurrence of v.
+func removeInt32(s []int32, v int32) []int32 {
+	out := s[:0]
+	for _, x := range s {
+		if x != v {
+			out = append(out, x)
+		}
+	}
+	return out
+}
+
+// sortedStringKeys returns the keys of a string-keyed map in ascending order.
+func sortedStringKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,87 @@
+package mapparser
+
+import (
+	"testing"
+)
+
+// TestRepairMap tests each repair and the resulting report
+func TestRepairMap(t *testing.T) {
+	m := NewMudletMap()
+	m.Version = 20
+	m.Areas[1] = NewMudletArea(1, "Area 1")
+	m.Areas[4] = NewMudletArea(4, "Area 4")
+	for i := int32(1); i <= 3; i++ {
+		room := NewMudletRoom(i)
+		room.Area = 1
+		m.Rooms[i] = room
+	}
+	m.Rooms[1].Exits[ExitNorth] = 99
+	m.Rooms[1].Doors["n"] = 2
+	m.Rooms[1].ExitLocks = []int32{DirectionCode(ExitNorth), DirectionCode(ExitEast)}
+	m.Rooms[1].SpecialExits["climb"] = 98
+	m.Rooms[1].Exits[ExitEast] = 2
+	m.Rooms[2].Area = 7
+	m.Rooms[3].Weight = 0
+	m.Rooms[3].ExitWeights["s"] = -5
+	m.Labels[1] = []*MudletLabel{
+		{ID: 2, Text: "Town", Width: 3, Height: 1},
+		{ID: 1, Text: "Town", Width: 3, Height: 1},
+		{ID: 3, Text: "Town", Width: 4, Height: 1},
+	}
+
+	report, err := RepairMap(m, DefaultRepairOptions())
+	if err != nil {
+		t.Fatalf("RepairMap failed: %v", err)
+	}
+
+	wantTypes := []string{
+		RepairDroppedExit, RepairDroppedExit,
+		RepairCreatedArea, RepairQuarantined,
+		RepairRemovedLabel,
+		RepairClampedWeight, RepairRemovedWeight,
+	}
+	if len(report.Changes) != len(wantTypes) {
+		t.Fatalf("Expected %d changes, got %d: %+v", len(wantTypes), len(report.Changes), report.Changes)
+	}
+	for i, typ := range wantTypes {
+		if report.Changes[i].Type != typ {
+			t.Errorf("Changes[%d].Type = %s, expected %s", i, report.Changes[i].Type, typ)
+		}
+	}
+
+	r1 := m.Rooms[1]
+	if r1.Exits[ExitNorth] != NoExit || len(r1.SpecialExits) != 0 || r1.IsExitLocked(ExitNorth) || len(r1.Doors) != 0 {
+		t.Error("Expected broken exits and their metadata to be removed")
+	}
+	if r1.Exits[ExitEast] != 2 || !r1.IsExitLocked(ExitEast) {
+		t.Error("Valid exit should be kept with its lock")
+	}
+
+	if report.QuarantineAreaID != 5 || m.Rooms[2].Area != 5 {
+		t.Errorf("Expected room 2 quarantined to new area 5, got area %d (report %d)", m.Rooms[2].Area, report.QuarantineAreaID)
+	}
+	if q := m.Areas[5]; q == nil || q.Name != "Quarantine" || len(q.Rooms) != 1 {
+		t.Errorf("Unexpected quarantine area: %+v", q)
+	}
+
+	if labels := m.Labels[1]; len(labels) != 2 || labels[0].ID != 1 || labels[1].ID != 3 {
+		t.Errorf("Expected labels 1 and 3 to remain, got %d labels", len(labels))
+	}
+	if m.Rooms[3].Weight != 1 || len(m.Rooms[3].ExitWeights) != 0 {
+		t.Error("Expected weights to be clamped")
+	}
+
+	if errs := NewValidator().Only(RuleBrokenExit, RuleMissingArea).Validate(m); len(errs) != 0 {
+		t.Errorf("Expected repaired map to validate, got %+v", errs)
+	}
+
+	// A second pass has nothing left to do
+	report, _ = RepairMap(m, DefaultRepairOptions())
+	if len(report.Changes) != 0 {
+		t.Errorf("Expected no changes on repaired map, got %+v", report.Changes)
+	}
+
+	if _, err := RepairMap(nil, RepairOptions{}); err == nil {
+		t.Error("Expected error for nil map")
+	}
+}
@@ -0,0 +1,134 @@
+package mapparser
+
+import (
+	"fmt"
+	"time"
+)
+
+// ParseReport describes where the time and bytes of a parse went. Pass one
+// in [ParseOptions.Report] to have it filled in.
+type ParseReport struct {
+	// Version is the map format version.
+	Version int32
+	// Bytes is the number of bytes read and Duration the time taken by
+	// the whole parse.
+	Bytes    int
+	Duration time.Duration
+	// Sections lists the top-level fields of the map in file order. For
+	// version 21 and later, labels are stored inside their areas but
+	// reported in the labels section rather than as part of the areas.
+	Sections []ParseSection
+	// Warnings holds the messages also passed to [ParseOptions.Warn].
+	Warnings []string
+}
+
+// ParseSection holds the statistics of one section of a map file.
+type ParseSection struct {
+	// Name is the field name, as in [MapSchema].
+	Name string
+	// Items counts the areas, labels or rooms read; it is 0 for other
+	// sections.
+	Items    int
+	Bytes    int
+	Duration time.Duration
+}
+
+// String returns a summary such as "labels: 397 items, 38.0 MB, 1.2s".
+func (s ParseSection) String() string {
+	d := s.Duration.Round(time.Microsecond)
+	if s.Duration >= time.Millisecond {
+		d = s.Duration.Round(time.Millisecond)
+	}
+	if s.Items > 0 {
+		return fmt.Sprintf("%s: %d items, %s, %s", s.Name, s.Items, formatSize(s.Bytes), d)
+	}
+	return fmt.Sprintf("%s: %s, %s", s.Name, formatSize(s.Bytes), d)
+}
+
+// Section returns the statistics of the named section, or nil if the map
+// has no such section.
+func (r *ParseReport) Section(name string) *ParseSection {
+	for i := range r.Sections {
+		if r.Sections[i].Name == name {
+			return &r.Sections[i]
+		}
+	}
+	return nil
+}
+
+// section returns the named section, adding it if it is missing. The
+// result is only valid until the next section is added.
+func (r *ParseReport) section(name string) *ParseSection {
+	if s := r.Section(name); s != nil {
+		return s
+	}
+	r.Sections = append(r.Sections, ParseSection{Name: name})
+	return &r.Sections[len(r.Sections)-1]
+}
+
+// decodeSections is [decodeRecord] for the top-level map fields, recording
+// each field in p.report if there is one.
+func (p *parser) decodeSections(schema []field[MudletMap]) error {
+	if p.report == nil {
+		return decodeRecord(p, schema, p.m)
+	}
+	for i := range schema {
+		f := &schema[i]
+		if !f.present(p.m.Version) {
+			continue
+		}
+		p.report.section(f.name)
+		p.nested = ParseSection{}
+		labels := p.labels
+		start, at := time.Now(), p.r.Position()
+		err := f.read(p, p.m)
+
+		s := p.report.section(f.name)
+		s.Bytes += p.r.Position() - at - p.nested.Bytes
+		s.Duration += time.Since(start) - p.nested.Duration
+		switch f.name {
+		case "areas":
+			s.Items += len(p.m.Areas)
+		case "labels":
+			s.Items += p.labels - labels
+		case "rooms":
+			s.Items += len(p.m.Rooms)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.name, err)
+		}
+	}
+	return nil
+}
+
+// trackLabels starts measuring labels read inside another section. The
+// returned function records n labels in the labels section and leaves them
+// out of the enclosing one.
+func (p *parser) trackLabels() func(n int) {
+	if p.report == nil {
+		return func(int) {}
+	}
+	start, at := time.Now(), p.r.Position()
+	return func(n int) {
+		bytes, d := p.r.Position()-at, time.Since(start)
+		p.nested.Bytes += bytes
+		p.nested.Duration += d
+		s := p.report.section("labels")
+		s.Items += n
+		s.Bytes += bytes
+		s.Duration += d
+	}
+}
+
+// formatSize formats a byte count with a binary unit.
+func formatSize(n int) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
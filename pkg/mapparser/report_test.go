@@ -0,0 +1,63 @@
+package mapparser
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseReport tests that section statistics add up to the whole parse
+func TestParseReport(t *testing.T) {
+	info, err := os.Stat(largeMapPath)
+	if err != nil {
+		t.Skipf("fixture not available: %v", err)
+	}
+	var report ParseReport
+	m, err := ParseMapFileWithOptions(largeMapPath, &ParseOptions{Report: &report})
+	if err != nil {
+		t.Fatalf("ParseMapFileWithOptions failed: %v", err)
+	}
+	if report.Version != m.Version || int64(report.Bytes) != info.Size() || report.Duration <= 0 {
+		t.Errorf("Unexpected totals: version %d, %d bytes, %v", report.Version, report.Bytes, report.Duration)
+	}
+
+	var bytes int
+	for _, s := range report.Sections {
+		bytes += s.Bytes
+	}
+	if bytes != report.Bytes {
+		t.Errorf("Section bytes add up to %d, expected %d", bytes, report.Bytes)
+	}
+	if s := report.Section("rooms"); s == nil || s.Items != len(m.Rooms) {
+		t.Errorf("rooms section = %+v, expected %d items", s, len(m.Rooms))
+	}
+	if s := report.Section("areas"); s == nil || s.Items != len(m.Areas) {
+		t.Errorf("areas section = %+v, expected %d items", s, len(m.Areas))
+	}
+	labels := 0
+	for _, ls := range m.Labels {
+		labels += len(ls)
+	}
+	for _, a := range m.Areas {
+		labels += len(a.Labels)
+	}
+	if s := report.Section("labels"); s == nil || s.Items != labels {
+		t.Errorf("labels section = %+v, expected %d items", s, labels)
+	}
+	if report.Section("mapSymbolFont") == nil || report.Section("missing") != nil {
+		t.Error("Unexpected section lookup results")
+	}
+}
+
+// TestParseSectionString tests the section summary format
+func TestParseSectionString(t *testing.T) {
+	s := ParseSection{Name: "labels", Items: 397, Bytes: 38 << 20, Duration: 1234 * time.Millisecond}
+	if got := s.String(); got != "labels: 397 items, 38.0 MB, 1.234s" {
+		t.Errorf("String() = %q", got)
+	}
+	s = ParseSection{Name: "envColors", Bytes: 28, Duration: 1500 * time.Nanosecond}
+	if got := s.String(); !strings.HasPrefix(got, "envColors: 28 B, ") {
+		t.Errorf("String() = %q", got)
+	}
+}
@@ -0,0 +1,111 @@
+package mapparser
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"sort"
+	"strings"
+)
+
+// RoomDescriptionKey is the room user data key [DescriptionHash] reads the
+// room description from. Mudlet does not store descriptions itself; mapper
+// scripts that keep them conventionally use this key.
+const RoomDescriptionKey = "description"
+
+// RoomHashFunc computes the hash that identifies a room in live game data.
+// An empty result leaves the room out of the hash index.
+type RoomHashFunc func(m *MudletMap, room *MudletRoom) string
+
+// ComputeRoomHash returns the hash of a room's name and description used to
+// recognize the room from GMCP data: the hex-encoded MD5 digest of the name
+// and description joined by a newline. Runs of whitespace are collapsed and
+// both parts trimmed first, so text wrapped differently by the game or the
+// client hashes the same.
+//
+// Mudlet stores whatever hash scripts pass to setRoomIDbyHash without
+// computing one itself; this is the scheme for games whose GMCP carries the
+// room text but no stable ID. Use a custom [RoomHashFunc] with
+// [MudletMap.RebuildHashIndex] for games that send their own identifiers.
+func ComputeRoomHash(name, description string) string {
+	sum := md5.Sum([]byte(normalizeSpace(name) + "\n" + normalizeSpace(description)))
+	return hex.EncodeToString(sum[:])
+}
+
+// DescriptionHash is a [RoomHashFunc] applying [ComputeRoomHash] to the
+// room name and the description stored under [RoomDescriptionKey]. Rooms
+// without a description are skipped.
+func DescriptionHash(_ *MudletMap, room *MudletRoom) string {
+	desc := room.UserData[RoomDescriptionKey]
+	if strings.TrimSpace(desc) == "" {
+		return ""
+	}
+	return ComputeRoomHash(room.Name, desc)
+}
+
+// RebuildHashIndex replaces RoomDbHashToRoomId with the hashes computed by
+// hash for every room, using [DescriptionHash] if hash is nil. When several
+// rooms share a hash, the lowest room ID wins, as the game cannot tell them
+// apart anyway. It returns the number of rooms left out because their hash
+// was empty or taken.
+func (m *MudletMap) RebuildHashIndex(hash RoomHashFunc) int {
+	if hash == nil {
+		hash = DescriptionHash
+	}
+
+	index := make(map[string]uint32, len(m.Rooms))
+	skipped := 0
+	for _, id := range sortedKeys(m.Rooms) {
+		h := hash(m, m.Rooms[id])
+		if h == "" {
+			skipped++
+			continue
+		}
+		if _, taken := index[h]; taken {
+			skipped++
+			continue
+		}
+		index[h] = uint32(id)
+	}
+	m.RoomDbHashToRoomId = index
+	return skipped
+}
+
+// RoomByHash returns the room registered under hash in RoomDbHashToRoomId,
+// or nil if there is none.
+func (m *MudletMap) RoomByHash(hash string) *MudletRoom {
+	id, ok := m.RoomDbHashToRoomId[hash]
+	if !ok {
+		return nil
+	}
+	return m.Rooms[int32(id)]
+}
+
+// RoomHashes returns the hashes registered for the room in
+// RoomDbHashToRoomId, sorted. A room registered under several hashes was
+// usually reached from different game data and may be a duplicate.
+func (m *MudletMap) RoomHashes(id int32) []string {
+	var hashes []string
+	for hash, roomID := range m.RoomDbHashToRoomId {
+		if int32(roomID) == id {
+			hashes = append(hashes, hash)
+		}
+	}
+	sort.Strings(hashes)
+	return hashes
+}
+
+// PlayerRoom returns the room the player of a Mudlet profile was last in,
+// as stored in RoomIdHash, or nil if the profile has none or the room no
+// longer exists.
+func (m *MudletMap) PlayerRoom(profile string) *MudletRoom {
+	id, ok := m.RoomIdHash[profile]
+	if !ok {
+		return nil
+	}
+	return m.Rooms[id]
+}
+
+// normalizeSpace trims s and collapses runs of whitespace to single spaces.
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,100 @@
+package mapparser
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestComputeRoomHash tests that whitespace differences do not change the hash
+func TestComputeRoomHash(t *testing.T) {
+	a := ComputeRoomHash("Market Square", "A busy square.\nStalls line the walls.")
+	b := ComputeRoomHash(" Market  Square", "A busy square. Stalls\n  line the walls.  ")
+	if a != b {
+		t.Errorf("Expected equal hashes, got %s and %s", a, b)
+	}
+	if len(a) != 32 {
+		t.Errorf("Expected 32 hex digits, got %q", a)
+	}
+	if a == ComputeRoomHash("Market Square", "A quiet square.") {
+		t.Error("Expected different descriptions to hash differently")
+	}
+}
+
+// TestRebuildHashIndex tests index building, collisions and lookup
+func TestRebuildHashIndex(t *testing.T) {
+	m := NewMudletMap()
+	m.RoomDbHashToRoomId["stale"] = 99
+	for id := int32(1); id <= 3; id++ {
+		room := NewMudletRoom(id)
+		room.Name = "Road"
+		m.Rooms[id] = room
+	}
+	m.Rooms[1].UserData[RoomDescriptionKey] = "A dusty road."
+	m.Rooms[2].UserData[RoomDescriptionKey] = "A dusty road."
+
+	if skipped := m.RebuildHashIndex(nil); skipped != 2 {
+		t.Errorf("Expected 2 skipped rooms, got %d", skipped)
+	}
+	if len(m.RoomDbHashToRoomId) != 1 {
+		t.Errorf("Expected stale entries replaced, got %v", m.RoomDbHashToRoomId)
+	}
+	if r := m.RoomByHash(ComputeRoomHash("Road", "A dusty road.")); r == nil || r.ID != 1 {
+		t.Errorf("Expected room 1 for the shared hash, got %v", r)
+	}
+
+	coords := func(_ *MudletMap, r *MudletRoom) string {
+		return fmt.Sprintf("%d:%d:%d", r.X, -r.Y, r.Z)
+	}
+	m.Rooms[3].X, m.Rooms[3].Y = 5, -7
+	m.Rooms[1].X = 1
+	m.Rooms[2].X = 2
+	if skipped := m.RebuildHashIndex(coords); skipped != 0 {
+		t.Errorf("Expected no skipped rooms, got %d", skipped)
+	}
+	if r := m.RoomByHash("5:7:0"); r == nil || r.ID != 3 {
+		t.Errorf("Expected room 3 by custom hash, got %v", r)
+	}
+	if m.RoomByHash("missing") != nil {
+		t.Error("Expected nil for unknown hash")
+	}
+}
+
+// TestRoomHashTables tests lookups through the stored hash and player tables
+func TestRoomHashTables(t *testing.T) {
+	m := NewMudletMap()
+	m.Version = 20
+	m.Areas[1] = NewMudletArea(1, "Town")
+	for id := int32(1); id <= 2; id++ {
+		room := NewMudletRoom(id)
+		room.Area = 1
+		room.X = id
+		m.Rooms[id] = room
+	}
+	m.RoomDbHashToRoomId["b"] = 1
+	m.RoomDbHashToRoomId["a"] = 1
+	m.RoomDbHashToRoomId["c"] = 2
+	m.RoomDbHashToRoomId["gone"] = 9
+	m.RoomIdHash["Hero"] = 2
+	m.RoomIdHash["Ghost"] = 7
+
+	if got := fmt.Sprint(m.RoomHashes(1)); got != "[a b]" {
+		t.Errorf("RoomHashes(1) = %s, expected [a b]", got)
+	}
+	if got := m.RoomHashes(3); got != nil {
+		t.Errorf("RoomHashes(3) = %v, expected none", got)
+	}
+	if room := m.PlayerRoom("Hero"); room == nil || room.ID != 2 {
+		t.Errorf("PlayerRoom(Hero) = %v, expected room 2", room)
+	}
+	if m.PlayerRoom("Ghost") != nil || m.PlayerRoom("Nobody") != nil {
+		t.Error("PlayerRoom should be nil for missing rooms and profiles")
+	}
+
+	if errs := ValidateMap(m); len(errs) != 0 {
+		t.Errorf("Expected the room hash rule off by default, got %+v", errs)
+	}
+	errs := NewValidator().Register(RoomHashRule()).Only(RuleBrokenRoomHash).Validate(m)
+	if len(errs) != 2 || errs[0].RoomID != 7 || errs[1].RoomID != 9 {
+		t.Errorf("broken room hash errors = %+v", errs)
+	}
+}
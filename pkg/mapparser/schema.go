@@ -0,0 +1,607 @@
+package mapparser
+
+import (
+	"fmt"
+	"slices"
+)
+
+// The map format is described declaratively: each record (the map header,
+// areas, labels, rooms) is a list of fields in file order, each with the
+// member name and Qt type Mudlet's TMap::serialize uses, the format versions
+// that carry it, and how its decoded value is stored. A generic decoder walks
+// these lists, so the parser, [MapSchema] and anything documenting the format
+// share a single definition.
+
+// SchemaField describes one field of the Mudlet map format.
+type SchemaField struct {
+	// Name is the member name used in Mudlet's sources.
+	Name string `json:"name"`
+	// Type is the Qt type the field is serialized as.
+	Type string `json:"type"`
+	// Since is the first format version carrying the field, 0 for all.
+	Since int32 `json:"since,omitempty"`
+	// Until is the first format version without the field, 0 if current.
+	Until int32 `json:"until,omitempty"`
+	// Fields is the layout of each element of a record collection, such
+	// as the areas or rooms.
+	Fields []SchemaField `json:"fields,omitempty"`
+}
+
+// MapSchema returns the layout of a map file of the given format version, in
+// file order, as read by [ParseMap]. Fields absent from that version are
+// left out. A version of 0 or less returns the fields of every version.
+func MapSchema(version int32) []SchemaField {
+	return append(describe(headerSchema, version), describe(bodySchema, version)...)
+}
+
+// field is one entry of a record schema for records of type T.
+type field[T any] struct {
+	name   string
+	qtType string
+	since  int32
+	until  int32
+	// fields describes nested records, for collections of them.
+	fields func(version int32) []SchemaField
+	read   func(p *parser, dst *T) error
+}
+
+// present reports whether the field is stored in maps of version.
+func (f *field[T]) present(version int32) bool {
+	if version <= 0 {
+		return true
+	}
+	return version >= f.since && (f.until == 0 || version < f.until)
+}
+
+// decodeRecord reads the fields of schema present in the map version into dst.
+func decodeRecord[T any](p *parser, schema []field[T], dst *T) error {
+	for i := range schema {
+		f := &schema[i]
+		if !f.present(p.m.Version) {
+			continue
+		}
+		if err := f.read(p, dst); err != nil {
+			return fmt.Errorf("%s: %w", f.name, err)
+		}
+	}
+	return nil
+}
+
+// describe converts a schema into its exported description.
+func describe[T any](schema []field[T], version int32) []SchemaField {
+	out := make([]SchemaField, 0, len(schema))
+	for i := range schema {
+		f := &schema[i]
+		if !f.present(version) {
+			continue
+		}
+		sf := SchemaField{Name: f.name, Type: f.qtType, Since: f.since, Until: f.until}
+		if f.fields != nil {
+			sf.Fields = f.fields(version)
+		}
+		out = append(out, sf)
+	}
+	return out
+}
+
+// --- Codecs ---
+
+// codec reads one value of a Qt type from the stream.
+type codec[V any] func(r *BinaryReader) (V, error)
+
+var (
+	qint32    codec[int32]    = (*BinaryReader).ReadInt32
+	quint32   codec[uint32]   = (*BinaryReader).ReadUInt32
+	qbool     codec[bool]     = (*BinaryReader).ReadBool
+	qreal     codec[float64]  = (*BinaryReader).ReadDouble
+	qstring   codec[string]   = (*BinaryReader).ReadQString
+	qcolor    codec[Color]    = (*BinaryReader).ReadQColor
+	qfont     codec[Font]     = (*BinaryReader).ReadQFont
+	qvector3d codec[Vector3D] = (*BinaryReader).ReadQVector3D
+	qpoint    codec[Point2D]  = (*BinaryReader).ReadQPoint
+	qpointf   codec[Point2D]  = (*BinaryReader).ReadQPointF
+	qpixmap   codec[[]byte]   = (*BinaryReader).ReadQPixmap
+)
+
+// readCount reads the element count that precedes Qt containers.
+func readCount(r *BinaryReader) (int32, error) {
+	n, err := r.ReadInt32()
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("invalid element count %d", n)
+	}
+	return n, nil
+}
+
+// qlist reads a QList, QVector or QSet of elements.
+func qlist[V any](elem codec[V]) codec[[]V] {
+	return func(r *BinaryReader) ([]V, error) {
+		n, err := readCount(r)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]V, 0, n)
+		for i := int32(0); i < n; i++ {
+			v, err := elem(r)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	}
+}
+
+// qmap reads a QMap or QHash. For a QMultiMap, the last value read for a
+// key wins.
+func qmap[K comparable, V any](key codec[K], value codec[V]) codec[map[K]V] {
+	return func(r *BinaryReader) (map[K]V, error) {
+		n, err := readCount(r)
+		if err != nil {
+			return nil, err
+		}
+		out := make(map[K]V, n)
+		for i := int32(0); i < n; i++ {
+			k, err := key(r)
+			if err != nil {
+				return nil, err
+			}
+			v, err := value(r)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = v
+		}
+		return out, nil
+	}
+}
+
+// areaExitCodec reads one mAreaExits entry: the room key followed by a
+// QPair of destination room and direction.
+func areaExitCodec(r *BinaryReader) (AreaExit, error) {
+	var e AreaExit
+	var err error
+	if e.RoomID, err = r.ReadInt32(); err != nil {
+		return e, err
+	}
+	if e.DestRoomID, err = r.ReadInt32(); err != nil {
+		return e, err
+	}
+	e.Direction, err = r.ReadInt32()
+	return e, err
+}
+
+// rgbListColor converts an RGB triple stored as QList<int>, used for
+// custom line colors before version 20.
+func rgbListColor(rgb []int32) Color {
+	var c [3]int32
+	copy(c[:], rgb)
+	return Color{
+		Red:   uint16(c[0]) << 8,
+		Green: uint16(c[1]) << 8,
+		Blue:  uint16(c[2]) << 8,
+		Alpha: 0xFFFF,
+	}
+}
+
+// into returns a field reader that decodes a value with c and stores it in
+// the location returned by dst.
+func into[T, V any](c codec[V], dst func(*T) *V) func(*parser, *T) error {
+	return func(p *parser, t *T) error {
+		v, err := c(p.r)
+		if err != nil {
+			return err
+		}
+		*dst(t) = v
+		return nil
+	}
+}
+
+// intoMap returns a field reader that decodes a QMap into the map at the
+// location returned by dst. A nil map is allocated only if there are
+// entries, and an empty map is replaced by one sized for the entries when
+// they would not fit in its first group of slots, so that it is not
+// regrown while they are added.
+func intoMap[T any, K comparable, V any](key codec[K], value codec[V], dst func(*T) *map[K]V) func(*parser, *T) error {
+	return func(p *parser, t *T) error {
+		n, err := readCount(p.r)
+		if err != nil {
+			return err
+		}
+		m := dst(t)
+		if n > 0 && (*m == nil || n > 8 && len(*m) == 0) {
+			*m = make(map[K]V, n)
+		}
+		for i := int32(0); i < n; i++ {
+			k, err := key(p.r)
+			if err != nil {
+				return err
+			}
+			v, err := value(p.r)
+			if err != nil {
+				return err
+			}
+			(*m)[k] = v
+		}
+		return nil
+	}
+}
+
+// skip returns a field reader that decodes and discards a value.
+func skip[T, V any](c codec[V]) func(*parser, *T) error {
+	return func(p *parser, _ *T) error {
+		_, err := c(p.r)
+		return err
+	}
+}
+
+// --- Map ---
+
+// headerSchema holds the map-level fields and areas: everything [InspectMap]
+// needs.
+var headerSchema = []field[MudletMap]{
+	{name: "version", qtType: "qint32", read: func(p *parser, m *MudletMap) error {
+		version, err := p.r.ReadInt32()
+		if err != nil {
+			return err
+		}
+		if version < 1 || version > maxVersion {
+			return fmt.Errorf("%w: invalid version %d", ErrNotAMudletMap, version)
+		}
+		m.Version = version
+		return p.checkVersion()
+	}},
+	{name: "envColors", qtType: "QMap<int,int>",
+		read: intoMap(qint32, qint32, func(m *MudletMap) *map[int32]int32 { return &m.EnvColors })},
+	{name: "areaNames", qtType: "QMap<int,QString>", read: func(p *parser, m *MudletMap) error {
+		names, err := qmap(qint32, qstring)(p.r)
+		if err != nil {
+			return err
+		}
+		for id, name := range names {
+			m.Areas[id] = NewMudletArea(id, name)
+		}
+		return nil
+	}},
+	{name: "mCustomEnvColors", qtType: "QMap<int,QColor>",
+		read: intoMap(qint32, qcolor, func(m *MudletMap) *map[int32]Color { return &m.CustomEnvColors })},
+	{name: "mpRoomDbHashToRoomId", qtType: "QMap<QString,uint>", since: 7,
+		read: intoMap(qstring, quint32, func(m *MudletMap) *map[string]uint32 { return &m.RoomDbHashToRoomId })},
+	{name: "mUserData", qtType: "QMap<QString,QString>", since: 17,
+		read: intoMap(qstring, qstring, func(m *MudletMap) *map[string]string { return &m.UserData })},
+	{name: "mapSymbolFont", qtType: "QFont", since: 19,
+		read: into(qfont, func(m *MudletMap) *Font { return &m.MapSymbolFont })},
+	{name: "mapFontFudgeFactor", qtType: "qreal", since: 19,
+		read: into(qreal, func(m *MudletMap) *float64 { return &m.MapFontFudgeFactor })},
+	{name: "useOnlyMapFont", qtType: "bool", since: 19,
+		read: into(qbool, func(m *MudletMap) *bool { return &m.UseOnlyMapFont })},
+	{name: "areas", qtType: "MudletAreas", read: readAreas,
+		fields: func(v int32) []SchemaField {
+			return append([]SchemaField{{Name: "id", Type: "qint32"}}, describe(areaSchema, v)...)
+		}},
+}
+
+// bodySchema holds the fields following the areas.
+var bodySchema = []field[MudletMap]{
+	{name: "mRoomIdHash", qtType: "QMap<QString,int>",
+		read: intoMap(qstring, qint32, func(m *MudletMap) *map[string]int32 { return &m.RoomIdHash })},
+	{name: "labels", qtType: "MudletLabels", read: readLabels,
+		fields: func(v int32) []SchemaField {
+			return append([]SchemaField{{Name: "count", Type: "qint32"}, {Name: "areaId", Type: "qint32"}}, describe(labelSchema, v)...)
+		}},
+	{name: "rooms", qtType: "MudletRooms", read: readRooms,
+		fields: func(v int32) []SchemaField {
+			return append([]SchemaField{{Name: "id", Type: "qint32"}}, describe(roomSchema, v)...)
+		}},
+}
+
+func readAreas(p *parser, m *MudletMap) error {
+	count, err := readCount(p.r)
+	if err != nil {
+		return err
+	}
+	for i := int32(0); i < count; i++ {
+		areaID, err := p.r.ReadInt32()
+		if err != nil {
+			return err
+		}
+		area := m.Areas[areaID]
+		if area == nil {
+			area = NewMudletArea(areaID, "")
+			m.Areas[areaID] = area
+		}
+		if err := decodeRecord(p, areaSchema, area); err != nil {
+			return fmt.Errorf("area %d: %w", areaID, err)
+		}
+	}
+	return nil
+}
+
+func readLabels(p *parser, m *MudletMap) error {
+	count, err := readCount(p.r)
+	if err != nil {
+		return err
+	}
+	for i := int32(0); i < count; i++ {
+		labelCount, err := readCount(p.r)
+		if err != nil {
+			return err
+		}
+		areaID, err := p.r.ReadInt32()
+		if err != nil {
+			return err
+		}
+		p.labels += int(labelCount)
+		labels := make([]*MudletLabel, 0, labelCount)
+		for j := int32(0); j < labelCount; j++ {
+			label := &MudletLabel{}
+			if err := decodeRecord(p, labelSchema, label); err != nil {
+				return fmt.Errorf("label %d in area %d: %w", j, areaID, err)
+			}
+			labels = append(labels, label)
+		}
+		if !p.skipLabels {
+			m.Labels[areaID] = labels
+		}
+	}
+	return nil
+}
+
+// readRooms reads rooms until the end of the stream. The room count is not
+// stored, so the room map is sized from the rooms the areas list.
+func readRooms(p *parser, m *MudletMap) error {
+	if len(m.Rooms) == 0 {
+		var n int
+		for _, area := range m.Areas {
+			n += len(area.Rooms)
+		}
+		m.Rooms = make(map[int32]*MudletRoom, n)
+	}
+	for {
+		if peek, err := p.r.Peek(4); err != nil || len(peek) < 4 {
+			return nil
+		}
+		roomID, err := p.r.ReadInt32()
+		if err != nil {
+			return nil
+		}
+		room := p.newRoom(roomID)
+		if err := decodeRecord(p, roomSchema, room); err != nil {
+			return fmt.Errorf("room %d: %w", roomID, err)
+		}
+		m.Rooms[roomID] = room
+	}
+}
+
+// --- Areas ---
+
+var areaSchema = []field[MudletArea]{
+	{name: "rooms", qtType: "QSet<int>",
+		read: into(qlist(quint32), func(a *MudletArea) *[]uint32 { return &a.Rooms })},
+	{name: "zLevels", qtType: "QList<int>",
+		read: into(qlist(qint32), func(a *MudletArea) *[]int32 { return &a.ZLevels })},
+	{name: "mAreaExits", qtType: "QMultiMap<int,QPair<int,int>>",
+		read: into(qlist(areaExitCodec), func(a *MudletArea) *[]AreaExit { return &a.AreaExits })},
+	{name: "gridMode", qtType: "bool",
+		read: into(qbool, func(a *MudletArea) *bool { return &a.GridMode })},
+	{name: "max_x", qtType: "qint32",
+		read: into(qint32, func(a *MudletArea) *int32 { return &a.Bounds.MaxX })},
+	{name: "max_y", qtType: "qint32",
+		read: into(qint32, func(a *MudletArea) *int32 { return &a.Bounds.MaxY })},
+	{name: "max_z", qtType: "qint32",
+		read: into(qint32, func(a *MudletArea) *int32 { return &a.Bounds.MaxZ })},
+	{name: "min_x", qtType: "qint32",
+		read: into(qint32, func(a *MudletArea) *int32 { return &a.Bounds.MinX })},
+	{name: "min_y", qtType: "qint32",
+		read: into(qint32, func(a *MudletArea) *int32 { return &a.Bounds.MinY })},
+	{name: "min_z", qtType: "qint32",
+		read: into(qint32, func(a *MudletArea) *int32 { return &a.Bounds.MinZ })},
+	{name: "span", qtType: "QVector3D",
+		read: into(qvector3d, func(a *MudletArea) *Vector3D { return &a.Span })},
+	{name: "xmaxForZ", qtType: "QMap<int,int>",
+		read: intoMap(qint32, qint32, func(a *MudletArea) *map[int32]int32 { return &a.XMaxForZ })},
+	{name: "ymaxForZ", qtType: "QMap<int,int>",
+		read: intoMap(qint32, qint32, func(a *MudletArea) *map[int32]int32 { return &a.YMaxForZ })},
+	{name: "xminForZ", qtType: "QMap<int,int>",
+		read: intoMap(qint32, qint32, func(a *MudletArea) *map[int32]int32 { return &a.XMinForZ })},
+	{name: "yminForZ", qtType: "QMap<int,int>",
+		read: intoMap(qint32, qint32, func(a *MudletArea) *map[int32]int32 { return &a.YMinForZ })},
+	{name: "pos", qtType: "QVector3D",
+		read: into(qvector3d, func(a *MudletArea) *Vector3D { return &a.Pos })},
+	{name: "isZone", qtType: "bool",
+		read: into(qbool, func(a *MudletArea) *bool { return &a.IsZone })},
+	{name: "zoneAreaRef", qtType: "qint32",
+		read: into(qint32, func(a *MudletArea) *int32 { return &a.ZoneAreaRef })},
+	{name: "mLast2DMapZoom", qtType: "qreal", since: 21,
+		read: into(qreal, func(a *MudletArea) *float64 { return &a.Last2DMapZoom })},
+	{name: "mUserData", qtType: "QMap<QString,QString>",
+		read: intoMap(qstring, qstring, func(a *MudletArea) *map[string]string { return &a.UserData })},
+	{name: "mMapLabels", qtType: "QMap<int,TMapLabel>", since: 21, read: readAreaLabels,
+		fields: func(v int32) []SchemaField {
+			return append([]SchemaField{{Name: "id", Type: "qint32"}}, describe(labelSchema, v)...)
+		}},
+}
+
+func readAreaLabels(p *parser, area *MudletArea) error {
+	count, err := readCount(p.r)
+	if err != nil {
+		return err
+	}
+	defer p.trackLabels()(int(count))
+	p.labels += int(count)
+	area.Labels = make([]*MudletLabel, 0, count)
+	for i := int32(0); i < count; i++ {
+		labelID, err := p.r.ReadInt32()
+		if err != nil {
+			return err
+		}
+		label := &MudletLabel{ID: labelID}
+		if err := decodeRecord(p, labelSchema, label); err != nil {
+			return err
+		}
+		if !p.skipLabels {
+			area.Labels = append(area.Labels, label)
+		}
+	}
+	return nil
+}
+
+// --- Labels ---
+
+// labelSchema describes a TMapLabel. Before version 21 labels are stored in
+// their own section with the ID inline; from 21 on they live in the areas,
+// keyed by ID.
+var labelSchema = []field[MudletLabel]{
+	{name: "id", qtType: "qint32", until: 21,
+		read: into(qint32, func(l *MudletLabel) *int32 { return &l.ID })},
+	{name: "pos", qtType: "QVector3D",
+		read: into(qvector3d, func(l *MudletLabel) *Vector3D { return &l.Pos })},
+	{name: "dummy1", qtType: "qreal", until: 21, read: skip[MudletLabel](qreal)},
+	{name: "dummy2", qtType: "qreal", until: 21, read: skip[MudletLabel](qreal)},
+	{name: "size.width", qtType: "qreal",
+		read: into(qreal, func(l *MudletLabel) *float64 { return &l.Width })},
+	{name: "size.height", qtType: "qreal",
+		read: into(qreal, func(l *MudletLabel) *float64 { return &l.Height })},
+	{name: "text", qtType: "QString",
+		read: into(qstring, func(l *MudletLabel) *string { return &l.Text })},
+	{name: "fgColor", qtType: "QColor",
+		read: into(qcolor, func(l *MudletLabel) *Color { return &l.FgColor })},
+	{name: "bgColor", qtType: "QColor",
+		read: into(qcolor, func(l *MudletLabel) *Color { return &l.BgColor })},
+	{name: "pix", qtType: "QPixmap", read: func(p *parser, l *MudletLabel) error {
+		if p.skipLabels {
+			return p.r.SkipQPixmap()
+		}
+		return readLabelPixmap(p, l)
+	}},
+	{name: "noScaling", qtType: "bool",
+		read: into(qbool, func(l *MudletLabel) *bool { return &l.NoScaling })},
+	{name: "showOnTop", qtType: "bool",
+		read: into(qbool, func(l *MudletLabel) *bool { return &l.ShowOnTop })},
+}
+
+var readLabelPixmap = into(qpixmap, func(l *MudletLabel) *[]byte { return &l.Pixmap })
+
+// --- Rooms ---
+
+var roomSchema = buildRoomSchema()
+
+func buildRoomSchema() []field[MudletRoom] {
+	schema := []field[MudletRoom]{
+		{name: "area", qtType: "qint32",
+			read: into(qint32, func(r *MudletRoom) *int32 { return &r.Area })},
+		{name: "x", qtType: "qint32",
+			read: into(qint32, func(r *MudletRoom) *int32 { return &r.X })},
+		{name: "y", qtType: "qint32",
+			read: into(qint32, func(r *MudletRoom) *int32 { return &r.Y })},
+		{name: "z", qtType: "qint32",
+			read: into(qint32, func(r *MudletRoom) *int32 { return &r.Z })},
+	}
+	for dir, name := range ExitDirectionNames {
+		f := field[MudletRoom]{name: name, qtType: "qint32",
+			read: into(qint32, func(r *MudletRoom) *int32 { return &r.Exits[dir] })}
+		if dir >= ExitIn {
+			f.since = minVersion
+		}
+		schema = append(schema, f)
+	}
+	return append(schema, []field[MudletRoom]{
+		{name: "environment", qtType: "qint32",
+			read: into(qint32, func(r *MudletRoom) *int32 { return &r.Environment })},
+		{name: "weight", qtType: "qint32",
+			read: into(qint32, func(r *MudletRoom) *int32 { return &r.Weight })},
+		{name: "name", qtType: "QString",
+			read: into(qstring, func(r *MudletRoom) *string { return &r.Name })},
+		{name: "isLocked", qtType: "bool",
+			read: into(qbool, func(r *MudletRoom) *bool { return &r.IsLocked })},
+		{name: "mSpecialExits", qtType: "QMultiMap<int,QString>", since: 6, until: 21, read: readOldSpecialExits},
+		{name: "mSpecialExits", qtType: "QMultiMap<QString,int>", since: 21,
+			read: intoMap(qstring, qint32, func(r *MudletRoom) *map[string]int32 { return &r.SpecialExits })},
+		{name: "roomSymbol", qtType: "qint8", since: 9, until: 19, read: skip[MudletRoom](codec[byte]((*BinaryReader).ReadByte))},
+		{name: "mSymbol", qtType: "QString", since: 19,
+			read: into(qstring, func(r *MudletRoom) *string { return &r.Symbol })},
+		{name: "mSymbolColor", qtType: "QColor", since: 21, read: func(p *parser, r *MudletRoom) error {
+			color, err := p.r.ReadQColor()
+			if err != nil {
+				return err
+			}
+			r.SymbolColor = &color
+			return nil
+		}},
+		{name: "userData", qtType: "QMap<QString,QString>", since: 10,
+			read: intoMap(qstring, qstring, func(r *MudletRoom) *map[string]string { return &r.UserData })},
+		// Custom line points were whole grid positions until Mudlet 3.0
+		// (version 17) made them QPointF.
+		{name: "customLines", qtType: "QMap<QString,QList<QPoint>>", since: 11, until: 17,
+			read: intoMap(qstring, qlist(qpoint), func(r *MudletRoom) *map[string][]Point2D { return &r.CustomLines })},
+		{name: "customLines", qtType: "QMap<QString,QList<QPointF>>", since: 17,
+			read: intoMap(qstring, qlist(qpointf), func(r *MudletRoom) *map[string][]Point2D { return &r.CustomLines })},
+		{name: "customLinesArrow", qtType: "QMap<QString,bool>", since: 11,
+			read: intoMap(qstring, qbool, func(r *MudletRoom) *map[string]bool { return &r.CustomLinesArrow })},
+		{name: "customLinesColor", qtType: "QMap<QString,QList<int>>", since: 11, until: 20, read: readOldCustomLinesColor},
+		{name: "customLinesColor", qtType: "QMap<QString,QColor>", since: 20,
+			read: intoMap(qstring, qcolor, func(r *MudletRoom) *map[string]Color { return &r.CustomLinesColor })},
+		{name: "customLinesStyle", qtType: "QMap<QString,QString>", since: 11, until: 20,
+			read: skip[MudletRoom](qmap(qstring, qstring))},
+		{name: "customLinesStyle", qtType: "QMap<QString,int>", since: 20,
+			read: intoMap(qstring, qint32, func(r *MudletRoom) *map[string]int32 { return &r.CustomLinesStyle })},
+		{name: "mSpecialExitLocks", qtType: "QList<QString>", since: 21,
+			read: into(qlist(qstring), func(r *MudletRoom) *[]string { return &r.SpecialExitLocks })},
+		{name: "exitLocks", qtType: "QList<int>", since: 11,
+			read: into(qlist(qint32), func(r *MudletRoom) *[]int32 { return &r.ExitLocks })},
+		{name: "exitStubs", qtType: "QList<int>", since: 13,
+			read: into(qlist(qint32), func(r *MudletRoom) *[]int32 { return &r.ExitStubs })},
+		{name: "exitWeights", qtType: "QMap<QString,int>", since: 16,
+			read: intoMap(qstring, qint32, func(r *MudletRoom) *map[string]int32 { return &r.ExitWeights })},
+		{name: "doors", qtType: "QMap<QString,int>", since: 16,
+			read: intoMap(qstring, qint32, func(r *MudletRoom) *map[string]int32 { return &r.Doors })},
+	}...)
+}
+
+// readOldSpecialExits reads the pre-21 special exits, keyed by destination
+// with the lock state as a "0" or "1" prefix of the command.
+func readOldSpecialExits(p *parser, r *MudletRoom) error {
+	count, err := readCount(p.r)
+	if err != nil {
+		return err
+	}
+	for i := int32(0); i < count; i++ {
+		destRoom, err := p.r.ReadInt32()
+		if err != nil {
+			return err
+		}
+		cmd, err := p.r.ReadQString()
+		if err != nil {
+			return err
+		}
+		locked := false
+		if len(cmd) > 1 {
+			locked = cmd[0] == '1'
+			cmd = cmd[1:]
+		}
+		if r.SpecialExits == nil {
+			r.SpecialExits = make(map[string]int32, count)
+		}
+		r.SpecialExits[cmd] = destRoom
+		if locked && !slices.Contains(r.SpecialExitLocks, cmd) {
+			r.SpecialExitLocks = append(r.SpecialExitLocks, cmd)
+		}
+	}
+	return nil
+}
+
+func readOldCustomLinesColor(p *parser, r *MudletRoom) error {
+	colors, err := qmap(qstring, qlist(qint32))(p.r)
+	if err != nil {
+		return err
+	}
+	if len(colors) > 0 && r.CustomLinesColor == nil {
+		r.CustomLinesColor = make(map[string]Color, len(colors))
+	}
+	for dir, rgb := range colors {
+		r.CustomLinesColor[dir] = rgbListColor(rgb)
+	}
+	return nil
+}
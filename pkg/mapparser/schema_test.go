@@ -0,0 +1,222 @@
+package mapparser
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// findField returns the field with the given name, or nil.
+func findField(fields []SchemaField, name string) *SchemaField {
+	for i := range fields {
+		if fields[i].Name == name {
+			return &fields[i]
+		}
+	}
+	return nil
+}
+
+// TestMapSchema tests that the described layout follows the format version
+func TestMapSchema(t *testing.T) {
+	v20 := MapSchema(20)
+	if len(v20) == 0 || v20[0].Name != "version" {
+		t.Fatalf("Expected layout to start with version, got %v", v20)
+	}
+	if findField(v20, "mapSymbolFont") == nil {
+		t.Error("Expected mapSymbolFont in version 20")
+	}
+	if findField(MapSchema(18), "mapSymbolFont") != nil {
+		t.Error("Expected no mapSymbolFont before version 19")
+	}
+
+	rooms := findField(v20, "rooms")
+	if rooms == nil || len(rooms.Fields) == 0 {
+		t.Fatal("Expected room fields")
+	}
+	if f := findField(rooms.Fields, "mSpecialExits"); f == nil || f.Type != "QMultiMap<int,QString>" {
+		t.Errorf("Expected version 20 special exits keyed by room, got %+v", f)
+	}
+	if f := findField(rooms.Fields, "mSymbolColor"); f != nil {
+		t.Error("Expected no symbol color in version 20")
+	}
+
+	rooms21 := findField(MapSchema(21), "rooms")
+	if f := findField(rooms21.Fields, "mSpecialExits"); f == nil || f.Type != "QMultiMap<QString,int>" {
+		t.Errorf("Expected version 21 special exits keyed by command, got %+v", f)
+	}
+
+	// Every version lists both special exit encodings
+	all := findField(MapSchema(0), "rooms")
+	n := 0
+	for _, f := range all.Fields {
+		if f.Name == "mSpecialExits" {
+			n++
+		}
+	}
+	if n != 2 {
+		t.Errorf("Expected 2 special exit encodings across versions, got %d", n)
+	}
+}
+
+// TestParseNegativeCount tests that a negative container count is rejected
+func TestParseNegativeCount(t *testing.T) {
+	var w qdsWriter
+	w.int32(20).int32(-1) // version, envColors count
+	if _, err := ParseMap(&w); err == nil {
+		t.Error("Expected error for negative element count")
+	}
+}
+
+// TestReadOldSpecialExitLocks tests that pre-21 lock prefixes become locks
+func TestReadOldSpecialExitLocks(t *testing.T) {
+	var w qdsWriter
+	w.int32(2)
+	w.int32(7).qstring("1climb rope")
+	w.int32(8).qstring("0enter gate")
+	p := &parser{r: NewBinaryReader(&w)}
+	room := NewMudletRoom(1)
+	if err := readOldSpecialExits(p, room); err != nil {
+		t.Fatalf("readOldSpecialExits failed: %v", err)
+	}
+	if room.SpecialExits["climb rope"] != 7 || room.SpecialExits["enter gate"] != 8 {
+		t.Errorf("SpecialExits = %v", room.SpecialExits)
+	}
+	if !room.SpecialExitLocked("climb rope") || room.SpecialExitLocked("enter gate") {
+		t.Errorf("SpecialExitLocks = %v, expected only \"climb rope\"", room.SpecialExitLocks)
+	}
+}
+
+// TestParseLegacyMap tests that maps before version 6 need LegacyMode, read
+// ten exits per room and report the data they lack
+func TestParseLegacyMap(t *testing.T) {
+	write := func() *bytes.Reader {
+		var w qdsWriter
+		w.int32(5)                          // version
+		w.int32(0)                          // envColors
+		w.int32(1).int32(1).qstring("Home") // areaNames
+		w.int32(0)                          // mCustomEnvColors
+		w.int32(0)                          // areas
+		w.int32(0)                          // mRoomIdHash
+		w.int32(0)                          // labels
+		w.int32(1)                          // room id
+		w.int32(1).int32(2).int32(3).int32(0)
+		for dir := 0; dir < ExitIn; dir++ {
+			if dir == ExitNorth {
+				w.int32(1)
+			} else {
+				w.int32(NoExit)
+			}
+		}
+		w.int32(4).int32(2).qstring("Square") // environment, weight, name
+		w.WriteByte(0)                        // isLocked
+		return bytes.NewReader(w.Bytes())
+	}
+	parse := func(opts *ParseOptions) (*MudletMap, error) {
+		r := write()
+		return ParseMapAt(r, r.Size(), opts)
+	}
+
+	if _, err := ParseMap(write()); err == nil {
+		t.Fatal("Expected version 5 map to be rejected without LegacyMode")
+	}
+	if _, err := parse(&ParseOptions{LegacyMode: true}); err != nil {
+		t.Fatalf("Expected nil Warn to be allowed: %v", err)
+	}
+
+	var warnings []string
+	var report ParseReport
+	m, err := parse(&ParseOptions{
+		LegacyMode: true,
+		Warn:       func(msg string) { warnings = append(warnings, msg) },
+		Report:     &report,
+	})
+	if err != nil {
+		t.Fatalf("Legacy parse failed: %v", err)
+	}
+	if !slices.Equal(report.Warnings, warnings) {
+		t.Errorf("Report warnings %v differ from %v", report.Warnings, warnings)
+	}
+	room := m.Rooms[1]
+	if room == nil || room.Name != "Square" || room.Environment != 4 || room.Weight != 2 {
+		t.Fatalf("Unexpected room: %+v", room)
+	}
+	if room.Exits[ExitNorth] != 1 || room.Exits[ExitIn] != NoExit || room.Exits[ExitOut] != NoExit {
+		t.Errorf("Exits = %v", room.Exits)
+	}
+	for _, want := range []string{"mSpecialExits", "mSymbol", "userData", "room in (", "room out ("} {
+		found := false
+		for _, msg := range warnings {
+			if strings.Contains(msg, want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("Expected a warning about %q, got %v", want, warnings)
+		}
+	}
+
+	var w qdsWriter
+	w.int32(2)
+	if _, err := ParseMapAt(bytes.NewReader(w.Bytes()), int64(w.Len()), &ParseOptions{LegacyMode: true}); err == nil {
+		t.Error("Expected version 2 map to be rejected")
+	}
+}
+
+// TestReadCustomLinePoints tests that custom line points are read as QPoint
+// before version 17 and as QPointF from then on, keeping the room in sync
+func TestReadCustomLinePoints(t *testing.T) {
+	for _, version := range []int32{16, 20} {
+		var w qdsWriter
+		w.int32(1).int32(0).int32(0).int32(0) // area, x, y, z
+		for range ExitDirectionNames {
+			w.int32(NoExit)
+		}
+		w.int32(1).int32(1).qstring("Room") // environment, weight, name
+		w.WriteByte(0)                      // isLocked
+		w.int32(0)                          // mSpecialExits
+		if version < 19 {
+			w.WriteByte(0) // roomSymbol
+		} else {
+			w.qstring("") // mSymbol
+		}
+		w.int32(0)                       // userData
+		w.int32(1).qstring("n").int32(2) // customLines {"n": 2 points}
+		if version < 17 {
+			w.int32(3).int32(-4).int32(5).int32(6)
+		} else {
+			w.double(3).double(-4).double(5.5).double(6)
+		}
+		w.int32(0)                       // customLinesArrow
+		w.int32(0)                       // customLinesColor
+		w.int32(0)                       // customLinesStyle
+		w.int32(0)                       // exitLocks
+		w.int32(0)                       // exitStubs
+		w.int32(1).qstring("n").int32(7) // exitWeights
+		w.int32(0)                       // doors
+		size := w.Len()
+
+		m := NewMudletMap()
+		m.Version = version
+		p := &parser{r: NewBinaryReader(&w), m: m}
+		room := NewMudletRoom(1)
+		if err := decodeRecord(p, roomSchema, room); err != nil {
+			t.Fatalf("version %d: decodeRecord failed: %v", version, err)
+		}
+		if p.r.Position() != size {
+			t.Errorf("version %d: read %d bytes, expected %d", version, p.r.Position(), size)
+		}
+		x2 := 5.0
+		if version >= 17 {
+			x2 = 5.5
+		}
+		want := []Point2D{{X: 3, Y: -4}, {X: x2, Y: 6}}
+		if got := room.CustomLines["n"]; len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+			t.Errorf("version %d: CustomLines = %v, expected %v", version, got, want)
+		}
+		if room.ExitWeights["n"] != 7 {
+			t.Errorf("version %d: ExitWeights = %v, expected n: 7", version, room.ExitWeights)
+		}
+	}
+}
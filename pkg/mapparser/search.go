@@ -0,0 +1,237 @@
+package mapparser
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// RoomQuery describes criteria for [MudletMap.FindRooms].
+//
+// All non-zero fields must match for a room to be returned. A zero-value
+// query matches every room.
+type RoomQuery struct {
+	// Name matches rooms whose name contains this substring (case-insensitive).
+	Name string
+	// NameRegex matches rooms whose name matches this regular expression.
+	NameRegex *regexp.Regexp
+	// Symbol matches rooms whose symbol equals this string exactly.
+	Symbol string
+	// Environments restricts results to rooms with one of these environment IDs.
+	Environments []int32
+	// Areas restricts results to rooms in one of these areas.
+	Areas []int32
+	// UserData requires each key to be present in the room's user data.
+	// A non-empty value must match exactly; an empty value matches any value.
+	UserData map[string]string
+}
+
+// FindRooms returns all rooms matching the query, sorted by area ID and then
+// by room ID.
+//
+// Example:
+//
+//	shops := m.FindRooms(mapparser.RoomQuery{Symbol: "$"})
+//	inns := m.FindRooms(mapparser.RoomQuery{NameRegex: regexp.MustCompile(`(?i)\binn\b`)})
+func (m *MudletMap) FindRooms(q RoomQuery) []*MudletRoom {
+	name := strings.ToLower(q.Name)
+	envs := int32Set(q.Environments)
+	areas := int32Set(q.Areas)
+
+	var result []*MudletRoom
+	for _, room := range m.Rooms {
+		if name != "" && !strings.Contains(strings.ToLower(room.Name), name) {
+			continue
+		}
+		if q.NameRegex != nil && !q.NameRegex.MatchString(room.Name) {
+			continue
+		}
+		if q.Symbol != "" && room.Symbol != q.Symbol {
+			continue
+		}
+		if envs != nil {
+			if _, ok := envs[room.Environment]; !ok {
+				continue
+			}
+		}
+		if areas != nil {
+			if _, ok := areas[room.Area]; !ok {
+				continue
+			}
+		}
+		if !matchUserData(room.UserData, q.UserData) {
+			continue
+		}
+		result = append(result, room)
+	}
+
+	sortRoomsByArea(result)
+	return result
+}
+
+// FindRoomsBySymbol returns the rooms whose symbol is exactly symbol, such
+// as "$" for shops, sorted by area ID and then by room ID. An empty symbol
+// matches no rooms.
+func FindRoomsBySymbol(m *MudletMap, symbol string) []*MudletRoom {
+	if m == nil || symbol == "" {
+		return nil
+	}
+	return m.FindRooms(RoomQuery{Symbol: symbol})
+}
+
+// LabelMatch is a label found by [MudletMap.FindLabels] or
+// [MudletMap.LabelsNear], together with the area it belongs to.
+type LabelMatch struct {
+	AreaID int32
+	Label  *MudletLabel
+	// Distance from the query room to the label rectangle in map units.
+	// Zero for [MudletMap.FindLabels] and for rooms inside the label.
+	Distance float64
+}
+
+// Center returns the label's center in map coordinates. Label positions
+// mark the top-left corner, with Y increasing upward as for rooms.
+func (l *MudletLabel) Center() (x, y float64) {
+	return l.Pos.X + l.Width/2, l.Pos.Y - l.Height/2
+}
+
+// FindLabels returns labels whose text contains text (case-insensitive),
+// sorted by area ID and then by label ID. Labels stored only as images
+// have no text and are never matched.
+func (m *MudletMap) FindLabels(text string) []LabelMatch {
+	needle := strings.ToLower(text)
+	var result []LabelMatch
+	for _, areaID := range labelAreaIDs(m) {
+		for _, lbl := range m.GetLabelsForArea(areaID) {
+			if lbl.Text != "" && strings.Contains(strings.ToLower(lbl.Text), needle) {
+				result = append(result, LabelMatch{AreaID: areaID, Label: lbl})
+			}
+		}
+	}
+	sortLabelMatches(result)
+	return result
+}
+
+// LabelsNear returns labels in the same area and on the same z-level as the
+// room whose rectangle lies within radius map units of the room, nearest
+// first. It returns nil if the room does not exist.
+func (m *MudletMap) LabelsNear(roomID int32, radius float64) []LabelMatch {
+	room := m.GetRoom(roomID)
+	if room == nil {
+		return nil
+	}
+	var result []LabelMatch
+	for _, lbl := range m.GetLabelsForArea(room.Area) {
+		if int32(lbl.Pos.Z) != room.Z {
+			continue
+		}
+		// Distance to the nearest point of the label rectangle
+		px, py := float64(room.X), float64(room.Y)
+		dx := math.Max(0, math.Max(lbl.Pos.X-px, px-(lbl.Pos.X+lbl.Width)))
+		dy := math.Max(0, math.Max((lbl.Pos.Y-lbl.Height)-py, py-lbl.Pos.Y))
+		if d := math.Hypot(dx, dy); d <= radius {
+			result = append(result, LabelMatch{AreaID: room.Area, Label: lbl, Distance: d})
+		}
+	}
+	sortLabelMatches(result)
+	return result
+}
+
+// FindArea returns the area whose name equals name (case-insensitive), or
+// nil if there is none. If several areas share the name, the lowest ID wins.
+func (m *MudletMap) FindArea(name string) *MudletArea {
+	for _, id := range sortedKeys(m.Areas) {
+		if strings.EqualFold(m.Areas[id].Name, name) {
+			return m.Areas[id]
+		}
+	}
+	return nil
+}
+
+// AreaCentroid returns the mean room position of an area on its most
+// populated z-level (the lowest such level on ties). ok is false if the area
+// has no rooms.
+func (m *MudletMap) AreaCentroid(areaID int32) (center Vector3D, ok bool) {
+	type sum struct {
+		x, y  float64
+		count int
+	}
+	levels := make(map[int32]*sum)
+	for _, room := range m.Rooms {
+		if room.Area != areaID {
+			continue
+		}
+		s := levels[room.Z]
+		if s == nil {
+			s = &sum{}
+			levels[room.Z] = s
+		}
+		s.x += float64(room.X)
+		s.y += float64(room.Y)
+		s.count++
+	}
+	var best *sum
+	for _, z := range sortedKeys(levels) {
+		if s := levels[z]; best == nil || s.count > best.count {
+			best = s
+			center.Z = float64(z)
+		}
+	}
+	if best == nil {
+		return Vector3D{}, false
+	}
+	center.X = best.x / float64(best.count)
+	center.Y = best.y / float64(best.count)
+	return center, true
+}
+
+// sortLabelMatches sorts by distance, then area ID, then label ID.
+func sortLabelMatches(matches []LabelMatch) {
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.Distance != b.Distance {
+			return a.Distance < b.Distance
+		}
+		if a.AreaID != b.AreaID {
+			return a.AreaID < b.AreaID
+		}
+		return a.Label.ID < b.Label.ID
+	})
+}
+
+// matchUserData reports whether data satisfies every key/value filter.
+func matchUserData(data, filter map[string]string) bool {
+	for key, want := range filter {
+		got, ok := data[key]
+		if !ok {
+			return false
+		}
+		if want != "" && got != want {
+			return false
+		}
+	}
+	return true
+}
+
+// sortRoomsByArea sorts rooms by area ID, then by room ID.
+func sortRoomsByArea(rooms []*MudletRoom) {
+	sort.Slice(rooms, func(i, j int) bool {
+		if rooms[i].Area != rooms[j].Area {
+			return rooms[i].Area < rooms[j].Area
+		}
+		return rooms[i].ID < rooms[j].ID
+	})
+}
+
+// int32Set converts a slice to a set, returning nil for an empty slice.
+func int32Set(values []int32) map[int32]struct{} {
+	if len(values) == 0 {
+		return nil
+	}
+	set := make(map[int32]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	return set
+}
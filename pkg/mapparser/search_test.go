@@ -0,0 +1,167 @@
+package mapparser
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+// newSearchTestMap builds a small map with varied names, symbols and user data
+func newSearchTestMap() *MudletMap {
+	m := NewMudletMap()
+	rooms := []struct {
+		id, area, env int32
+		name, symbol  string
+		userData      map[string]string
+	}{
+		{1, 2, 1, "Town Square", "", nil},
+		{2, 1, 2, "Blacksmith Shop", "$", map[string]string{"vnum": "1002"}},
+		{3, 1, 2, "General Store", "$", map[string]string{"vnum": "1003", "shop": "general"}},
+		{4, 2, 3, "Old Inn", "I", map[string]string{"vnum": "2004"}},
+		{5, 1, 1, "Dark Alley", "", nil},
+	}
+	for _, r := range rooms {
+		room := NewMudletRoom(r.id)
+		room.Area = r.area
+		room.Environment = r.env
+		room.Name = r.name
+		room.Symbol = r.symbol
+		for k, v := range r.userData {
+			room.UserData[k] = v
+		}
+		m.Rooms[r.id] = room
+	}
+	return m
+}
+
+// roomIDs extracts room IDs in order for compact comparisons
+func roomIDs(rooms []*MudletRoom) []int32 {
+	ids := make([]int32, len(rooms))
+	for i, r := range rooms {
+		ids[i] = r.ID
+	}
+	return ids
+}
+
+// TestFindRooms tests the room search criteria and result ordering
+func TestFindRooms(t *testing.T) {
+	m := newSearchTestMap()
+
+	tests := []struct {
+		name  string
+		query RoomQuery
+		want  []int32
+	}{
+		{"all sorted by area", RoomQuery{}, []int32{2, 3, 5, 1, 4}},
+		{"substring case-insensitive", RoomQuery{Name: "shop"}, []int32{2}},
+		{"regex", RoomQuery{NameRegex: regexp.MustCompile(`^(Old|Dark) `)}, []int32{5, 4}},
+		{"symbol", RoomQuery{Symbol: "$"}, []int32{2, 3}},
+		{"environment", RoomQuery{Environments: []int32{1, 3}}, []int32{5, 1, 4}},
+		{"area", RoomQuery{Areas: []int32{2}}, []int32{1, 4}},
+		{"userdata key", RoomQuery{UserData: map[string]string{"vnum": ""}}, []int32{2, 3, 4}},
+		{"userdata value", RoomQuery{UserData: map[string]string{"shop": "general"}}, []int32{3}},
+		{"combined", RoomQuery{Symbol: "$", Name: "store"}, []int32{3}},
+		{"no match", RoomQuery{Name: "castle"}, []int32{}},
+	}
+
+	for _, tt := range tests {
+		got := roomIDs(m.FindRooms(tt.query))
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %v, expected %v", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: got %v, expected %v", tt.name, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+// TestFindLabels tests text search and proximity search over labels
+func TestFindLabels(t *testing.T) {
+	m := NewMudletMap()
+	m.Areas[1] = NewMudletArea(1, "Town")
+	m.Areas[2] = NewMudletArea(2, "Forest")
+	room := NewMudletRoom(1)
+	room.Area = 1
+	m.Rooms[1] = room
+
+	// Map-level labels for area 1, area-level labels for area 2
+	m.Labels[1] = []*MudletLabel{
+		{ID: 2, Text: "Rynek", Pos: Vector3D{X: -1, Y: 1}, Width: 2, Height: 2}, // covers the room
+		{ID: 1, Text: "Old Rynek", Pos: Vector3D{X: 4, Y: 0}, Width: 1, Height: 1},
+		{ID: 3, Text: "Upstairs", Pos: Vector3D{X: 0, Y: 0, Z: 1}, Width: 1, Height: 1},
+		{ID: 4, Pos: Vector3D{X: 10, Y: 0}, Width: 1, Height: 1},
+	}
+	m.Areas[2].Labels = []*MudletLabel{{ID: 1, Text: "rynek leśny"}}
+
+	found := m.FindLabels("RYNEK")
+	if len(found) != 3 {
+		t.Fatalf("Expected 3 labels, got %d", len(found))
+	}
+	if found[0].AreaID != 1 || found[0].Label.ID != 1 || found[1].Label.ID != 2 || found[2].AreaID != 2 {
+		t.Errorf("Unexpected order: %+v", found)
+	}
+
+	near := m.LabelsNear(1, 5)
+	if len(near) != 2 || near[0].Label.ID != 2 || near[0].Distance != 0 || near[1].Label.ID != 1 || near[1].Distance != 4 {
+		t.Errorf("Unexpected LabelsNear result: %+v", near)
+	}
+	if x, y := near[0].Label.Center(); x != 0 || y != 0 {
+		t.Errorf("Center = (%v, %v), expected (0, 0)", x, y)
+	}
+	if near := m.LabelsNear(1, 3); len(near) != 1 {
+		t.Errorf("Expected 1 label within radius 3, got %d", len(near))
+	}
+	if m.LabelsNear(42, 5) != nil {
+		t.Error("Expected nil for unknown room")
+	}
+}
+
+// TestAreaCentroid tests area lookup by name and centroid computation
+func TestAreaCentroid(t *testing.T) {
+	m := NewMudletMap()
+	m.Areas[3] = NewMudletArea(3, "Forest")
+	for i, pos := range [][3]int32{{0, 0, 0}, {4, 2, 0}, {2, 7, 0}, {9, 9, 1}} {
+		room := NewMudletRoom(int32(i + 1))
+		room.Area = 3
+		room.X, room.Y, room.Z = pos[0], pos[1], pos[2]
+		m.Rooms[room.ID] = room
+	}
+
+	if a := m.FindArea("FOREST"); a == nil || a.ID != 3 {
+		t.Errorf("FindArea returned %+v", a)
+	}
+	if m.FindArea("Desert") != nil {
+		t.Error("Expected nil for unknown area name")
+	}
+
+	c, ok := m.AreaCentroid(3)
+	if !ok || c != (Vector3D{X: 2, Y: 3, Z: 0}) {
+		t.Errorf("AreaCentroid = %+v, %v; expected (2, 3, 0)", c, ok)
+	}
+	if _, ok := m.AreaCentroid(4); ok {
+		t.Error("Expected ok=false for area without rooms")
+	}
+}
+
+// TestFindRoomsBySymbol tests symbol lookup and symbol statistics
+func TestFindRoomsBySymbol(t *testing.T) {
+	m := newSearchTestMap()
+	if got := roomIDs(FindRoomsBySymbol(m, "$")); !reflect.DeepEqual(got, []int32{2, 3}) {
+		t.Errorf("FindRoomsBySymbol($) = %v", got)
+	}
+	if got := FindRoomsBySymbol(m, ""); got != nil {
+		t.Errorf("Expected no rooms for an empty symbol, got %v", roomIDs(got))
+	}
+
+	want := []SymbolCount{
+		{Symbol: "$", Rooms: 2, Areas: []int32{1}},
+		{Symbol: "I", Rooms: 1, Areas: []int32{2}},
+	}
+	if got := GetSymbolStats(m); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetSymbolStats = %+v, expected %+v", got, want)
+	}
+}
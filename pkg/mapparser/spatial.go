@@ -0,0 +1,139 @@
+package mapparser
+
+import "math"
+
+// spatialCellSize is the side of a [SpatialIndex] grid cell in map units.
+const spatialCellSize = 16
+
+// spatialLevel is one area's z-level in a [SpatialIndex].
+type spatialLevel struct {
+	area, z int32
+}
+
+// spatialCell is a grid cell within a level.
+type spatialCell struct {
+	level  spatialLevel
+	cx, cy int32
+}
+
+// cellBounds is the range of occupied cells on a level.
+type cellBounds struct {
+	minX, minY, maxX, maxY int32
+}
+
+// SpatialIndex buckets rooms into a grid per area and z-level, so that
+// nearest-room queries only visit the cells around the query point.
+//
+// The index is a snapshot: rooms added, removed or moved afterwards are not
+// seen until the index is rebuilt with [NewSpatialIndex].
+type SpatialIndex struct {
+	cells  map[spatialCell][]*MudletRoom
+	bounds map[spatialLevel]cellBounds
+}
+
+// NewSpatialIndex indexes the rooms of m by position.
+func NewSpatialIndex(m *MudletMap) *SpatialIndex {
+	idx := &SpatialIndex{
+		cells:  make(map[spatialCell][]*MudletRoom),
+		bounds: make(map[spatialLevel]cellBounds),
+	}
+	for _, room := range m.Rooms {
+		level := spatialLevel{room.Area, room.Z}
+		cx, cy := cellOf(float64(room.X)), cellOf(float64(room.Y))
+		cell := spatialCell{level, cx, cy}
+		idx.cells[cell] = append(idx.cells[cell], room)
+
+		b, ok := idx.bounds[level]
+		if !ok {
+			b = cellBounds{cx, cy, cx, cy}
+		}
+		b.minX, b.maxX = min(b.minX, cx), max(b.maxX, cx)
+		b.minY, b.maxY = min(b.minY, cy), max(b.maxY, cy)
+		idx.bounds[level] = b
+	}
+	return idx
+}
+
+// Nearest returns the room of the area on level z closest to (x, y) in map
+// coordinates, or nil if that level has no rooms. Of rooms at the same
+// distance, the lowest ID wins.
+func (idx *SpatialIndex) Nearest(areaID int32, x, y float64, z int32) *MudletRoom {
+	level := spatialLevel{areaID, z}
+	b, ok := idx.bounds[level]
+	if !ok {
+		return nil
+	}
+	qx, qy := cellOf(x), cellOf(y)
+	// Rings beyond this radius around the query cell hold no rooms
+	last := max(abs32(qx-b.minX), abs32(qx-b.maxX), abs32(qy-b.minY), abs32(qy-b.maxY))
+
+	var best *MudletRoom
+	bestDist := math.Inf(1)
+	for ring := int32(0); ring <= last; ring++ {
+		for cx := qx - ring; cx <= qx+ring; cx++ {
+			for cy := qy - ring; cy <= qy+ring; cy++ {
+				// Only the border of the ring; the inside was visited already
+				if cx != qx-ring && cx != qx+ring && cy != qy-ring && cy != qy+ring {
+					continue
+				}
+				for _, room := range idx.cells[spatialCell{level, cx, cy}] {
+					if d := roomDistance(room, x, y); closerRoom(room, d, best, bestDist) {
+						best, bestDist = room, d
+					}
+				}
+			}
+		}
+		// Every room in the next ring is at least this far from the query point
+		if best != nil && bestDist <= float64(ring*spatialCellSize) {
+			break
+		}
+	}
+	return best
+}
+
+// FindNearestRoom returns the room of the area on level z closest to map
+// coordinates (x, y), or nil if that level has no rooms. It resolves a click
+// on a rendered image or an approximate position reported by the game to a
+// room. Of rooms at the same distance, the lowest ID wins.
+//
+// FindNearestRoom scans every room; build a [SpatialIndex] once to answer
+// many queries on the same map.
+func FindNearestRoom(m *MudletMap, areaID int32, x, y float64, z int32) *MudletRoom {
+	if m == nil {
+		return nil
+	}
+	var best *MudletRoom
+	bestDist := math.Inf(1)
+	for _, room := range m.Rooms {
+		if room.Area != areaID || room.Z != z {
+			continue
+		}
+		if d := roomDistance(room, x, y); closerRoom(room, d, best, bestDist) {
+			best, bestDist = room, d
+		}
+	}
+	return best
+}
+
+// cellOf returns the grid cell containing coordinate v.
+func cellOf(v float64) int32 {
+	return int32(math.Floor(v / spatialCellSize))
+}
+
+// roomDistance returns the distance from the room to (x, y) in map units.
+func roomDistance(room *MudletRoom, x, y float64) float64 {
+	return math.Hypot(float64(room.X)-x, float64(room.Y)-y)
+}
+
+// closerRoom reports whether room at distance d beats the current best.
+func closerRoom(room *MudletRoom, d float64, best *MudletRoom, bestDist float64) bool {
+	return best == nil || d < bestDist || (d == bestDist && room.ID < best.ID)
+}
+
+// abs32 returns the absolute value of v.
+func abs32(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,78 @@
+package mapparser
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// TestFindNearestRoom tests nearest-room lookups and their tie-breaking
+func TestFindNearestRoom(t *testing.T) {
+	m := NewMudletMap()
+	add := func(id, area, x, y, z int32) {
+		room := NewMudletRoom(id)
+		room.Area, room.X, room.Y, room.Z = area, x, y, z
+		m.Rooms[id] = room
+	}
+	add(1, 1, 0, 0, 0)
+	add(2, 1, 4, 0, 0)
+	add(3, 1, 2, 0, 0)
+	add(4, 1, 100, 100, 0)
+	add(5, 1, 1, 0, 1)
+	add(6, 2, 1, 0, 0)
+
+	idx := NewSpatialIndex(m)
+	tests := []struct {
+		name string
+		area int32
+		x, y float64
+		z    int32
+		want int32
+	}{
+		{"exact", 1, 4, 0, 0, 2},
+		{"nearest", 1, 2.4, 0.3, 0, 3},
+		{"tie lowest id", 1, 1, 0, 0, 1},
+		{"far cell", 1, 90, 95, 0, 4},
+		{"outside bounds", 1, -500, -500, 0, 1},
+		{"other level", 1, 50, 50, 1, 5},
+		{"other area", 2, 0, 0, 0, 6},
+		{"empty level", 1, 0, 0, 7, 0},
+		{"missing area", 9, 0, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, got := range map[string]*MudletRoom{
+				"FindNearestRoom": FindNearestRoom(m, tt.area, tt.x, tt.y, tt.z),
+				"Nearest":         idx.Nearest(tt.area, tt.x, tt.y, tt.z),
+			} {
+				var id int32
+				if got != nil {
+					id = got.ID
+				}
+				if id != tt.want {
+					t.Errorf("%s = room %d, want %d", name, id, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// TestSpatialIndexMatchesScan checks the grid search against a full scan
+func TestSpatialIndexMatchesScan(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	m := NewMudletMap()
+	for id := int32(1); id <= 500; id++ {
+		room := NewMudletRoom(id)
+		room.Area = 1
+		room.X = rng.Int31n(200) - 100
+		room.Y = rng.Int31n(200) - 100
+		m.Rooms[id] = room
+	}
+	idx := NewSpatialIndex(m)
+	for i := 0; i < 200; i++ {
+		x, y := rng.Float64()*300-150, rng.Float64()*300-150
+		want := FindNearestRoom(m, 1, x, y, 0)
+		if got := idx.Nearest(1, x, y, 0); got != want {
+			t.Fatalf("Nearest(%.2f, %.2f) = room %d, scan found %d", x, y, got.ID, want.ID)
+		}
+	}
+}
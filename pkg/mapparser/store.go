@@ -0,0 +1,130 @@
+package mapparser
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// MapUpdate is delivered to [MapStore] subscribers when the map changes.
+type MapUpdate struct {
+	// Version is the store version after the update, starting at 1 for the
+	// first loaded map.
+	Version uint64
+	// Map is the new map. It must be treated as read-only.
+	Map *MudletMap
+	// Events lists the differences from the previous map (see [DiffMaps]).
+	Events []MapEvent
+}
+
+// MapStore holds the current version of a map, reloads it on demand and
+// notifies subscribers with delta events, so UIs can update incrementally.
+//
+// A MapStore is safe for concurrent use. Maps handed out by the store are
+// shared and must not be modified; install a modified [MudletMap.DeepCopy]
+// with [MapStore.Update] instead.
+type MapStore struct {
+	load func() (*MudletMap, error)
+
+	// updateMu serializes updates, so subscribers see versions in order
+	updateMu sync.Mutex
+
+	mu      sync.RWMutex
+	current *MudletMap
+	version uint64
+
+	subMu  sync.Mutex
+	subs   map[int]func(MapUpdate)
+	nextID int
+}
+
+// NewMapStore creates a store that obtains maps from load. No map is loaded
+// until [MapStore.Reload] is called. load may be nil for stores fed only
+// through [MapStore.Update].
+func NewMapStore(load func() (*MudletMap, error)) *MapStore {
+	return &MapStore{load: load, subs: make(map[int]func(MapUpdate))}
+}
+
+// NewFileMapStore creates a store that loads the map file at path.
+func NewFileMapStore(path string) *MapStore {
+	return NewMapStore(func() (*MudletMap, error) { return ParseMapFile(path) })
+}
+
+// Map returns the current map and its version. The map is nil and the
+// version 0 before anything has been loaded.
+func (s *MapStore) Map() (*MudletMap, uint64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current, s.version
+}
+
+// Reload loads the map again and installs it. If anything changed it bumps
+// the version and notifies subscribers; otherwise the version is kept, no
+// events are returned and subscribers are not called.
+func (s *MapStore) Reload() (MapUpdate, error) {
+	if s.load == nil {
+		return MapUpdate{}, fmt.Errorf("map store has no loader")
+	}
+	m, err := s.load()
+	if err != nil {
+		return MapUpdate{}, fmt.Errorf("reloading map: %w", err)
+	}
+	return s.Update(m), nil
+}
+
+// Update installs m as the current map, following the same rules as
+// [MapStore.Reload].
+func (s *MapStore) Update(m *MudletMap) MapUpdate {
+	s.updateMu.Lock()
+	defer s.updateMu.Unlock()
+
+	s.mu.Lock()
+	events := DiffMaps(s.current, m)
+	changed := len(events) > 0 || s.current == nil
+	s.current = m
+	if changed {
+		s.version++
+	}
+	update := MapUpdate{Version: s.version, Map: m, Events: events}
+	s.mu.Unlock()
+
+	if changed {
+		s.notify(update)
+	}
+	return update
+}
+
+// Subscribe registers fn to be called after each change, in the goroutine
+// that made the change and in version order. fn may read the store but must
+// not update it. It returns a function that cancels the subscription.
+func (s *MapStore) Subscribe(fn func(MapUpdate)) (cancel func()) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	id := s.nextID
+	s.nextID++
+	s.subs[id] = fn
+	return func() {
+		s.subMu.Lock()
+		defer s.subMu.Unlock()
+		delete(s.subs, id)
+	}
+}
+
+// notify calls subscribers in registration order.
+func (s *MapStore) notify(update MapUpdate) {
+	s.subMu.Lock()
+	ids := make([]int, 0, len(s.subs))
+	for id := range s.subs {
+		ids = append(ids, id)
+	}
+	fns := make([]func(MapUpdate), 0, len(ids))
+	sort.Ints(ids)
+	for _, id := range ids {
+		fns = append(fns, s.subs[id])
+	}
+	s.subMu.Unlock()
+
+	for _, fn := range fns {
+		fn(update)
+	}
+}
@@ -0,0 +1,52 @@
+package mapparser
+
+// DefaultSymbolFontFamily is the font Mudlet draws room symbols with when a
+// map does not name one (maps older than version 19).
+const DefaultSymbolFontFamily = "Bitstream Vera Sans Mono"
+
+// SymbolFont holds the effective settings Mudlet draws room symbols with.
+type SymbolFont struct {
+	Font Font
+	// FudgeFactor scales the symbol size that fits the room square;
+	// 1 draws symbols at the fitted size.
+	FudgeFactor float64
+	// OnlyMapFont disables falling back to other fonts for characters
+	// missing from Font.
+	OnlyMapFont bool
+}
+
+// SymbolFont returns the settings room symbols are drawn with: the map's
+// mapSymbolFont, fudge factor and useOnlyMapFont flag, with Mudlet's
+// defaults (12pt [DefaultSymbolFontFamily], factor 1) filled in for fields
+// older maps do not store.
+func (m *MudletMap) SymbolFont() SymbolFont {
+	sf := SymbolFont{
+		Font:        m.MapSymbolFont,
+		FudgeFactor: m.MapFontFudgeFactor,
+		OnlyMapFont: m.UseOnlyMapFont,
+	}
+	if sf.Font.Family == "" {
+		sf.Font = Font{
+			Family:     DefaultSymbolFontFamily,
+			PointSizeF: 12,
+			PixelSize:  -1,
+			Weight:     50,
+			Kerning:    true,
+		}
+	}
+	if sf.FudgeFactor <= 0 {
+		sf.FudgeFactor = 1
+	}
+	return sf
+}
+
+// Scale returns the size to draw a symbol at, given the largest size at
+// which it fits the room square.
+func (sf SymbolFont) Scale(fitted float64) float64 {
+	return fitted * sf.FudgeFactor
+}
+
+// Bold reports whether the font weight is bold or heavier.
+func (f Font) Bold() bool {
+	return f.Weight >= 75
+}
@@ -0,0 +1,100 @@
+package mapparser
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TerrainKey is the room user data key naming a room's terrain, as used by
+// [DefaultFlagRules] and [TerrainProfile].
+const TerrainKey = "terrain"
+
+// TerrainProfile assigns movement costs to terrain, so that routes from
+// [FindPathWithOptions] reflect travel time rather than the number of
+// moves. Costs multiply the usual cost of entering a room: 2 makes a room
+// twice as slow to enter, 0.5 twice as fast.
+type TerrainProfile struct {
+	// Names maps terrain names, matched case-insensitively against the
+	// room's "terrain" user data, to costs.
+	Names map[string]float64
+	// Environments maps environment IDs to costs, for rooms without a
+	// named terrain in the profile.
+	Environments map[int32]float64
+	// Default is the cost of rooms matching neither; 1 if zero.
+	Default float64
+}
+
+// Cost returns the cost multiplier for entering room.
+func (p *TerrainProfile) Cost(room *MudletRoom) float64 {
+	if p != nil && room != nil {
+		if name, ok := room.UserData[TerrainKey]; ok {
+			if cost, ok := p.Names[strings.ToLower(strings.TrimSpace(name))]; ok {
+				return cost
+			}
+		}
+		if cost, ok := p.Environments[room.Environment]; ok {
+			return cost
+		}
+		if p.Default > 0 {
+			return p.Default
+		}
+	}
+	return 1
+}
+
+// LoadTerrainProfile reads a terrain profile file. See
+// [ParseTerrainProfile] for the format.
+func LoadTerrainProfile(path string) (*TerrainProfile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open terrain profile: %w", err)
+	}
+	defer f.Close()
+	return ParseTerrainProfile(f)
+}
+
+// ParseTerrainProfile reads a terrain profile written as a YAML mapping
+// of terrain to cost. Keys are terrain names, environment IDs, or
+// "default" for all other rooms:
+//
+//	# slow going
+//	swamp: 3
+//	road: 0.5
+//	17: 2      # environment 17 (forest)
+//	default: 1
+//
+// Costs must not be negative.
+func ParseTerrainProfile(r io.Reader) (*TerrainProfile, error) {
+	p := &TerrainProfile{
+		Names:        make(map[string]float64),
+		Environments: make(map[int32]float64),
+	}
+	var entries map[string]yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&entries); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("terrain profile: %w", err)
+	}
+	for key, value := range entries {
+		cost, err := strconv.ParseFloat(value.Value, 64)
+		if value.Kind != yaml.ScalarNode || err != nil || cost < 0 {
+			return nil, fmt.Errorf("terrain profile line %d: invalid cost %q", value.Line, value.Value)
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		if key == "" {
+			return nil, fmt.Errorf("terrain profile line %d: empty key", value.Line)
+		}
+
+		if key == "default" {
+			p.Default = cost
+		} else if env, err := strconv.ParseInt(key, 10, 32); err == nil {
+			p.Environments[int32(env)] = cost
+		} else {
+			p.Names[key] = cost
+		}
+	}
+	return p, nil
+}
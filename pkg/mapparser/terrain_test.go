@@ -0,0 +1,64 @@
+package mapparser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestParseTerrainProfile tests the YAML subset and cost lookup
+func TestParseTerrainProfile(t *testing.T) {
+	p, err := ParseTerrainProfile(strings.NewReader(`---
+# slow going
+Swamp: 3
+"road": '0.5'
+17: 2 # forest
+default: 1.5
+`))
+	if err != nil {
+		t.Fatalf("ParseTerrainProfile failed: %v", err)
+	}
+
+	room := NewMudletRoom(1)
+	room.Environment = 17
+	if got := p.Cost(room); got != 2 {
+		t.Errorf("Expected environment cost 2, got %v", got)
+	}
+	room.UserData[TerrainKey] = "SWAMP"
+	if got := p.Cost(room); got != 3 {
+		t.Errorf("Expected named terrain cost 3, got %v", got)
+	}
+	room.UserData[TerrainKey] = "road"
+	if got := p.Cost(room); got != 0.5 {
+		t.Errorf("Expected named terrain cost 0.5, got %v", got)
+	}
+	room.UserData[TerrainKey] = "desert"
+	room.Environment = 1
+	if got := p.Cost(room); got != 1.5 {
+		t.Errorf("Expected default cost 1.5, got %v", got)
+	}
+	if got := (*TerrainProfile)(nil).Cost(room); got != 1 {
+		t.Errorf("Expected nil profile cost 1, got %v", got)
+	}
+
+	for _, bad := range []string{"swamp", "swamp: -1", "swamp: slow", ": 2"} {
+		if _, err := ParseTerrainProfile(strings.NewReader(bad)); err == nil {
+			t.Errorf("Expected error for %q", bad)
+		}
+	}
+}
+
+// TestFindPathTerrain tests that terrain costs steer the pathfinder
+func TestFindPathTerrain(t *testing.T) {
+	m := newPathTestMap(t)
+	m.Rooms[2].UserData[TerrainKey] = "swamp"
+	opts := &PathOptions{Terrain: &TerrainProfile{Names: map[string]float64{"swamp": 3}}}
+	if got := FindPathWithOptions(m, 1, 3, opts); !reflect.DeepEqual(got, []int32{1, 4, 3}) {
+		t.Errorf("Expected swamp avoided, got %v", got)
+	}
+	m.Rooms[2].Weight = 3
+	opts.Terrain.Names["swamp"] = 0.25
+	if got := FindPathWithOptions(m, 1, 3, opts); !reflect.DeepEqual(got, []int32{1, 2, 3}) {
+		t.Errorf("Expected cheap terrain preferred, got %v", got)
+	}
+}
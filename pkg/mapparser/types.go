@@ -32,12 +32,21 @@ type Label = MudletLabel
 type ValidationError struct {
 	// Type categorizes the error (e.g., "broken_exit", "invalid_version").
 	Type string `json:"type"`
+	// Severity is [SeverityError] for structural defects and
+	// [SeverityWarning] for suspicious but loadable data.
+	Severity string `json:"severity"`
 	// Message provides a human-readable description of the error.
 	Message string `json:"message"`
 	// RoomID identifies the room where the error occurred (if applicable).
 	RoomID int32 `json:"roomId,omitempty"`
 }
 
+// Validation severities for [ValidationError.Severity].
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
 // MapStats contains aggregate statistics about a map.
 type MapStats struct {
 	// TotalRooms is the number of rooms in the map.
@@ -50,6 +59,47 @@ type MapStats struct {
 	BoundingBox BoundingBox `json:"boundingBox"`
 	// ZLevels is a sorted list of all Z-coordinates used.
 	ZLevels []int32 `json:"zLevels"`
+	// Attribution is the map's author and license metadata, if any.
+	Attribution *Attribution `json:"attribution,omitempty"`
+}
+
+// AreaStats contains statistics about one area, broken down by z-level, as
+// returned by [GetAreaStats].
+type AreaStats struct {
+	// AreaID and Name identify the area.
+	AreaID int32  `json:"areaId"`
+	Name   string `json:"name"`
+	// Rooms, Exits, Environments and BoundingBox cover the whole area, as
+	// described on [LevelStats].
+	Rooms        int           `json:"rooms"`
+	Exits        int           `json:"exits"`
+	Environments map[int32]int `json:"environments"`
+	BoundingBox  BoundingBox   `json:"boundingBox"`
+	// Levels holds the statistics of each z-level with rooms, in
+	// ascending order.
+	Levels []LevelStats `json:"levels"`
+}
+
+// LevelStats contains statistics about one z-level of an area.
+type LevelStats struct {
+	Z int32 `json:"z"`
+	// Rooms is the number of rooms on the level.
+	Rooms int `json:"rooms"`
+	// Exits counts the standard and special exits of those rooms.
+	Exits int `json:"exits"`
+	// Environments maps each environment ID to the number of rooms using it.
+	Environments map[int32]int `json:"environments"`
+	// BoundingBox is the extent of the rooms on the level.
+	BoundingBox BoundingBox `json:"boundingBox"`
+}
+
+// SymbolCount reports how many rooms use a room symbol, as returned by
+// [GetSymbolStats].
+type SymbolCount struct {
+	Symbol string `json:"symbol"`
+	Rooms  int    `json:"rooms"`
+	// Areas lists the areas with rooms using the symbol, sorted.
+	Areas []int32 `json:"areas"`
 }
 
 // BoundingBox represents the minimum and maximum coordinates of the map.
@@ -0,0 +1,70 @@
+package mapparser
+
+// UserDataIndex maps room user data values to the rooms carrying them, for
+// repeated lookups such as "all rooms with terrain=swamp" without scanning
+// every room. Build it with [MudletMap.IndexUserData].
+//
+// The index is a snapshot: later changes to the map are not seen until it
+// is rebuilt.
+type UserDataIndex struct {
+	index map[string]map[string][]int32 // key -> value -> room IDs
+}
+
+// IndexUserData indexes the room user data stored under the given keys, or
+// under every key if none are given.
+//
+// Example:
+//
+//	idx := m.IndexUserData("terrain")
+//	for _, id := range idx.Rooms("terrain", "swamp") {
+//	    fmt.Println(m.Rooms[id].Name)
+//	}
+func (m *MudletMap) IndexUserData(keys ...string) *UserDataIndex {
+	idx := &UserDataIndex{index: make(map[string]map[string][]int32, len(keys))}
+	for _, key := range keys {
+		idx.index[key] = make(map[string][]int32)
+	}
+	add := func(key, value string, id int32) {
+		values := idx.index[key]
+		if values == nil {
+			values = make(map[string][]int32)
+			idx.index[key] = values
+		}
+		values[value] = append(values[value], id)
+	}
+
+	// Walking rooms in ID order keeps every room list sorted
+	for _, id := range sortedKeys(m.Rooms) {
+		data := m.Rooms[id].UserData
+		if len(keys) == 0 {
+			for key, value := range data {
+				add(key, value, id)
+			}
+			continue
+		}
+		for _, key := range keys {
+			if value, ok := data[key]; ok {
+				add(key, value, id)
+			}
+		}
+	}
+	return idx
+}
+
+// Rooms returns the IDs of rooms whose user data stores value under key,
+// sorted. It returns nil if there are none or key was not indexed. The
+// slice is shared by the index and must not be modified.
+func (idx *UserDataIndex) Rooms(key, value string) []int32 {
+	return idx.index[key][value]
+}
+
+// Values returns the distinct values stored under key, sorted.
+func (idx *UserDataIndex) Values(key string) []string {
+	return sortedStringKeys(idx.index[key])
+}
+
+// Indexed reports whether key is covered by the index.
+func (idx *UserDataIndex) Indexed(key string) bool {
+	_, ok := idx.index[key]
+	return ok
+}
@@ -0,0 +1,42 @@
+package mapparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestIndexUserData tests user data lookups through the inverted index
+func TestIndexUserData(t *testing.T) {
+	m := newSearchTestMap()
+	m.Rooms[1].UserData["terrain"] = "road"
+	m.Rooms[4].UserData["terrain"] = "swamp"
+	m.Rooms[5].UserData["terrain"] = "swamp"
+
+	idx := m.IndexUserData("terrain", "shop")
+	if got := idx.Rooms("terrain", "swamp"); !reflect.DeepEqual(got, []int32{4, 5}) {
+		t.Errorf("Rooms(terrain, swamp) = %v, expected [4 5]", got)
+	}
+	if got := idx.Rooms("shop", "general"); !reflect.DeepEqual(got, []int32{3}) {
+		t.Errorf("Rooms(shop, general) = %v, expected [3]", got)
+	}
+	if got := idx.Rooms("terrain", "desert"); got != nil {
+		t.Errorf("Rooms(terrain, desert) = %v, expected nil", got)
+	}
+	if got := idx.Values("terrain"); !reflect.DeepEqual(got, []string{"road", "swamp"}) {
+		t.Errorf("Values(terrain) = %v", got)
+	}
+	if idx.Indexed("vnum") || idx.Rooms("vnum", "1002") != nil {
+		t.Error("vnum was not requested and should not be indexed")
+	}
+	if !idx.Indexed("shop") {
+		t.Error("shop should be indexed")
+	}
+
+	all := m.IndexUserData()
+	if got := all.Rooms("vnum", "2004"); !reflect.DeepEqual(got, []int32{4}) {
+		t.Errorf("Rooms(vnum, 2004) on a full index = %v, expected [4]", got)
+	}
+	if got := all.Values("vnum"); len(got) != 3 {
+		t.Errorf("Values(vnum) = %v, expected 3 values", got)
+	}
+}
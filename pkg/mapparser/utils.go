@@ -1,47 +1,9 @@
 package mapparser
 
 import (
-	"encoding/json"
-	"fmt"
-	"os"
 	"sort"
 )
 
-// ValidateMap performs validation of the parsed map structure.
-//
-// It checks:
-//   - Map is not nil
-//   - Map version is positive (valid Mudlet format)
-//   - All room exits point to existing rooms
-//
-// Returns a slice of [ValidationError] describing any issues found.
-func ValidateMap(m *Map) []ValidationError {
-	var errs []ValidationError
-	if m == nil {
-		errs = append(errs, ValidationError{Type: "nil_map", Message: "map is nil"})
-		return errs
-	}
-	// Mudlet QDataStream version is typically >= 6; just ensure positive
-	if m.Version <= 0 {
-		errs = append(errs, ValidationError{Type: "invalid_version", Message: fmt.Sprintf("non-positive version: %d", m.Version)})
-	}
-	// Check that exits point to existing rooms when not NoExit
-	for _, room := range m.Rooms {
-		for i, exitTarget := range room.Exits {
-			if exitTarget != NoExit {
-				if _, ok := m.Rooms[exitTarget]; !ok {
-					errs = append(errs, ValidationError{
-						Type:    "broken_exit",
-						Message: fmt.Sprintf("room %d has %s exit to missing room %d", room.ID, ExitDirectionNames[i], exitTarget),
-						RoomID:  room.ID,
-					})
-				}
-			}
-		}
-	}
-	return errs
-}
-
 // GetMapStats computes and returns statistics about the map.
 //
 // Statistics include:
@@ -49,6 +11,7 @@ func ValidateMap(m *Map) []ValidationError {
 //   - Number of unique environments
 //   - Bounding box (min/max coordinates)
 //   - Sorted list of Z-levels used
+//   - Attribution metadata, if the map carries any
 //
 // Returns an empty [MapStats] if the map is nil.
 func GetMapStats(m *Map) MapStats {
@@ -59,6 +22,9 @@ func GetMapStats(m *Map) MapStats {
 	stats.TotalRooms = len(m.Rooms)
 	stats.TotalAreas = len(m.Areas)
 	stats.TotalEnvironments = len(m.EnvColors) + len(m.CustomEnvColors)
+	if a := m.Attribution(); !a.IsZero() {
+		stats.Attribution = &a
+	}
 	if len(m.Rooms) == 0 {
 		return stats
 	}
@@ -101,23 +67,107 @@ func GetMapStats(m *Map) MapStats {
 	return stats
 }
 
+// GetAreaStats computes statistics about one area, overall and per
+// z-level, so that level selectors can be built without scanning rooms.
+// Rooms are those whose Area field is areaID. An area without rooms
+// returns stats with no levels.
+func GetAreaStats(m *Map, areaID int32) AreaStats {
+	stats := AreaStats{AreaID: areaID, Environments: make(map[int32]int)}
+	if m == nil {
+		return stats
+	}
+	if area := m.Areas[areaID]; area != nil {
+		stats.Name = area.Name
+	}
+
+	levels := make(map[int32]*LevelStats)
+	for _, r := range m.Rooms {
+		if r.Area != areaID {
+			continue
+		}
+		level := levels[r.Z]
+		if level == nil {
+			level = &LevelStats{Z: r.Z, Environments: make(map[int32]int)}
+			level.BoundingBox = BoundingBox{MinX: r.X, MinY: r.Y, MinZ: r.Z, MaxX: r.X, MaxY: r.Y, MaxZ: r.Z}
+			levels[r.Z] = level
+		}
+		exits := len(r.ActiveExits()) + len(r.SpecialExits)
+		level.Rooms++
+		level.Exits += exits
+		level.Environments[r.Environment]++
+		level.BoundingBox.include(r.X, r.Y, r.Z)
+
+		if stats.Rooms == 0 {
+			stats.BoundingBox = level.BoundingBox
+		}
+		stats.Rooms++
+		stats.Exits += exits
+		stats.Environments[r.Environment]++
+		stats.BoundingBox.include(r.X, r.Y, r.Z)
+	}
+	for _, z := range sortedKeys(levels) {
+		stats.Levels = append(stats.Levels, *levels[z])
+	}
+	return stats
+}
+
+// include extends the box to cover the point.
+func (b *BoundingBox) include(x, y, z int32) {
+	b.MinX, b.MaxX = min(b.MinX, x), max(b.MaxX, x)
+	b.MinY, b.MaxY = min(b.MinY, y), max(b.MaxY, y)
+	b.MinZ, b.MaxZ = min(b.MinZ, z), max(b.MaxZ, z)
+}
+
 // ExportToJSON writes the map structure to a JSON file.
 // The output is formatted with 2-space indentation for readability.
 //
 // Returns an error if the map is nil or if file operations fail.
+// Use [ExportToJSONWithOptions] to omit label pixmaps or produce compact output.
 func ExportToJSON(m *Map, filename string) error {
+	return ExportToJSONWithOptions(m, filename, nil)
+}
+
+// sortedKeys returns the keys of an int32-keyed map in ascending order.
+func sortedKeys[V any](m map[int32]V) []int32 {
+	keys := make([]int32, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+// GetSymbolStats counts the rooms using each room symbol, most used first
+// and then by symbol. Rooms without a symbol are not counted.
+func GetSymbolStats(m *Map) []SymbolCount {
 	if m == nil {
-		return fmt.Errorf("nil map provided")
+		return nil
 	}
-	f, err := os.Create(filename)
-	if err != nil {
-		return fmt.Errorf("creating json file: %w", err)
+	counts := make(map[string]*SymbolCount)
+	areas := make(map[string]map[int32]bool)
+	for _, r := range m.Rooms {
+		if r.Symbol == "" {
+			continue
+		}
+		c := counts[r.Symbol]
+		if c == nil {
+			c = &SymbolCount{Symbol: r.Symbol}
+			counts[r.Symbol] = c
+			areas[r.Symbol] = make(map[int32]bool)
+		}
+		c.Rooms++
+		areas[r.Symbol][r.Area] = true
 	}
-	defer f.Close()
-	enc := json.NewEncoder(f)
-	enc.SetIndent("", "  ")
-	if err := enc.Encode(m); err != nil {
-		return fmt.Errorf("encoding json: %w", err)
+	stats := make([]SymbolCount, 0, len(counts))
+	for sym, c := range counts {
+		c.Areas = sortedKeys(areas[sym])
+		stats = append(stats, *c)
 	}
-	return nil
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Rooms != stats[j].Rooms {
+			return stats[i].Rooms > stats[j].Rooms
+		}
+		return stats[i].Symbol < stats[j].Symbol
+	})
+	return stats
 }
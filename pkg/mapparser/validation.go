@@ -0,0 +1,298 @@
+package mapparser
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ValidationRule is a single named check run by a [Validator].
+type ValidationRule interface {
+	// Name identifies the rule for [Validator.Enable] and [Validator.Disable].
+	Name() string
+	// Check returns the problems found in m. It is never called with a nil map.
+	Check(m *Map) []ValidationError
+}
+
+// Names of the built-in validation rules. Each rule reports errors whose
+// Type equals its name. [RoomHashRule] is not run unless registered.
+const (
+	RuleInvalidVersion       = "invalid_version"
+	RuleMissingArea          = "missing_area"
+	RuleDuplicateCoordinates = "duplicate_coordinates"
+	RuleBrokenExit           = "broken_exit"
+	RuleAsymmetricExit       = "asymmetric_exit"
+	RuleBrokenRoomHash       = "broken_room_hash"
+)
+
+// NewRule wraps a function as a [ValidationRule].
+func NewRule(name string, check func(m *Map) []ValidationError) ValidationRule {
+	return funcRule{name: name, check: check}
+}
+
+// NewRoomRule builds a [ValidationRule] that calls check once per room, in
+// ascending room ID order.
+func NewRoomRule(name string, check func(m *Map, room *MudletRoom) []ValidationError) ValidationRule {
+	return NewRule(name, func(m *Map) []ValidationError {
+		var errs []ValidationError
+		for _, id := range sortedKeys(m.Rooms) {
+			errs = append(errs, check(m, m.Rooms[id])...)
+		}
+		return errs
+	})
+}
+
+type funcRule struct {
+	name  string
+	check func(m *Map) []ValidationError
+}
+
+func (r funcRule) Name() string                   { return r.name }
+func (r funcRule) Check(m *Map) []ValidationError { return r.check(m) }
+
+// RequireUserData returns a rule that reports, with the given severity, every
+// room lacking a non-empty value for key in its user data.
+func RequireUserData(key, severity string) ValidationRule {
+	name := "require_user_data:" + key
+	return NewRoomRule(name, func(_ *Map, room *MudletRoom) []ValidationError {
+		if room.UserData[key] != "" {
+			return nil
+		}
+		return []ValidationError{{
+			Type:     name,
+			Severity: severity,
+			Message:  fmt.Sprintf("room %d has no %q user data", room.ID, key),
+			RoomID:   room.ID,
+		}}
+	})
+}
+
+// Validator runs an ordered set of validation rules against a map.
+// Use [NewValidator] to start from the built-in rules.
+type Validator struct {
+	rules    []ValidationRule
+	disabled map[string]bool
+}
+
+// NewValidator returns a validator with all built-in rules enabled.
+func NewValidator() *Validator {
+	return &Validator{
+		rules: []ValidationRule{
+			NewRule(RuleInvalidVersion, checkVersion),
+			NewRoomRule(RuleMissingArea, checkRoomArea),
+			NewRule(RuleDuplicateCoordinates, checkDuplicateCoordinates),
+			NewRoomRule(RuleBrokenExit, checkBrokenExits),
+			NewRoomRule(RuleAsymmetricExit, checkAsymmetricExits),
+		},
+		disabled: make(map[string]bool),
+	}
+}
+
+// Register adds rules to the validator. A rule with the same name as an
+// existing one replaces it.
+func (v *Validator) Register(rules ...ValidationRule) *Validator {
+	for _, rule := range rules {
+		replaced := false
+		for i, existing := range v.rules {
+			if existing.Name() == rule.Name() {
+				v.rules[i] = rule
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			v.rules = append(v.rules, rule)
+		}
+	}
+	return v
+}
+
+// Disable turns off the named rules. Unknown names are ignored.
+func (v *Validator) Disable(names ...string) *Validator {
+	for _, name := range names {
+		v.disabled[name] = true
+	}
+	return v
+}
+
+// Enable turns the named rules back on.
+func (v *Validator) Enable(names ...string) *Validator {
+	for _, name := range names {
+		delete(v.disabled, name)
+	}
+	return v
+}
+
+// Only enables exactly the named rules and disables all others.
+func (v *Validator) Only(names ...string) *Validator {
+	keep := make(map[string]bool, len(names))
+	for _, name := range names {
+		keep[name] = true
+	}
+	for _, rule := range v.rules {
+		v.disabled[rule.Name()] = !keep[rule.Name()]
+	}
+	return v
+}
+
+// Rules returns the names of all enabled rules in run order.
+func (v *Validator) Rules() []string {
+	var names []string
+	for _, rule := range v.rules {
+		if !v.disabled[rule.Name()] {
+			names = append(names, rule.Name())
+		}
+	}
+	return names
+}
+
+// Validate runs all enabled rules against m.
+//
+// Map-level problems are listed first, followed by room-level problems in
+// ascending room ID order; problems for the same room keep rule order.
+// A nil map yields a single "nil_map" error.
+func (v *Validator) Validate(m *Map) []ValidationError {
+	if m == nil {
+		return []ValidationError{{Type: "nil_map", Severity: SeverityError, Message: "map is nil"}}
+	}
+	var errs []ValidationError
+	for _, rule := range v.rules {
+		if !v.disabled[rule.Name()] {
+			errs = append(errs, rule.Check(m)...)
+		}
+	}
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].RoomID < errs[j].RoomID })
+	return errs
+}
+
+// ValidateMap performs validation of the parsed map structure using the
+// built-in rules of [NewValidator].
+//
+// It checks:
+//   - Map is not nil
+//   - Map version is positive (valid Mudlet format)
+//   - All room exits point to existing rooms
+//   - All rooms belong to an existing area
+//   - Standard exits have a reciprocal exit back (warning)
+//   - No two rooms of an area share the same coordinates (warning)
+//
+// Returns a slice of [ValidationError] describing any issues found.
+func ValidateMap(m *Map) []ValidationError {
+	return NewValidator().Validate(m)
+}
+
+// checkVersion reports a non-positive map version. Mudlet QDataStream
+// versions are typically >= 6; this only ensures the value is positive.
+func checkVersion(m *Map) []ValidationError {
+	if m.Version > 0 {
+		return nil
+	}
+	return []ValidationError{{Type: RuleInvalidVersion, Severity: SeverityError, Message: fmt.Sprintf("non-positive version: %d", m.Version)}}
+}
+
+func checkRoomArea(m *Map, room *MudletRoom) []ValidationError {
+	if _, ok := m.Areas[room.Area]; ok {
+		return nil
+	}
+	return []ValidationError{{
+		Type:     RuleMissingArea,
+		Severity: SeverityError,
+		Message:  fmt.Sprintf("room %d is assigned to missing area %d", room.ID, room.Area),
+		RoomID:   room.ID,
+	}}
+}
+
+func checkDuplicateCoordinates(m *Map) []ValidationError {
+	type coord struct{ area, x, y, z int32 }
+	var errs []ValidationError
+	occupied := make(map[coord]int32, len(m.Rooms))
+	for _, id := range sortedKeys(m.Rooms) {
+		room := m.Rooms[id]
+		c := coord{room.Area, room.X, room.Y, room.Z}
+		if other, ok := occupied[c]; ok {
+			errs = append(errs, ValidationError{
+				Type:     RuleDuplicateCoordinates,
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("room %d shares position (%d,%d,%d) in area %d with room %d", room.ID, room.X, room.Y, room.Z, room.Area, other),
+				RoomID:   room.ID,
+			})
+			continue
+		}
+		occupied[c] = room.ID
+	}
+	return errs
+}
+
+func checkBrokenExits(m *Map, room *MudletRoom) []ValidationError {
+	var errs []ValidationError
+	for i, exitTarget := range room.Exits {
+		if exitTarget == NoExit {
+			continue
+		}
+		if _, ok := m.Rooms[exitTarget]; !ok {
+			errs = append(errs, ValidationError{
+				Type:     RuleBrokenExit,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("room %d has %s exit to missing room %d", room.ID, ExitDirectionNames[i], exitTarget),
+				RoomID:   room.ID,
+			})
+		}
+	}
+	return errs
+}
+
+func checkAsymmetricExits(m *Map, room *MudletRoom) []ValidationError {
+	var errs []ValidationError
+	for i, exitTarget := range room.Exits {
+		dest, ok := m.Rooms[exitTarget]
+		if exitTarget == NoExit || !ok {
+			continue
+		}
+		if dest.Exits[OppositeExit(i)] != room.ID {
+			errs = append(errs, ValidationError{
+				Type:     RuleAsymmetricExit,
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("room %d has %s exit to room %d without a %s exit back", room.ID, ExitDirectionNames[i], exitTarget, ExitDirectionNames[OppositeExit(i)]),
+				RoomID:   room.ID,
+			})
+		}
+	}
+	return errs
+}
+
+// RoomHashRule returns the [RuleBrokenRoomHash] rule, which warns about room
+// hash and player position entries pointing to missing rooms. Such stale
+// entries are harmless to most tools, so the rule is opt-in:
+//
+//	v := NewValidator().Register(RoomHashRule())
+func RoomHashRule() ValidationRule {
+	return NewRule(RuleBrokenRoomHash, checkRoomHashes)
+}
+
+// checkRoomHashes reports room hash and player position entries that point
+// to missing rooms.
+func checkRoomHashes(m *Map) []ValidationError {
+	var errs []ValidationError
+	for _, hash := range sortedStringKeys(m.RoomDbHashToRoomId) {
+		id := int32(m.RoomDbHashToRoomId[hash])
+		if _, ok := m.Rooms[id]; !ok {
+			errs = append(errs, ValidationError{
+				Type:     RuleBrokenRoomHash,
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("room hash %q points to missing room %d", hash, id),
+				RoomID:   id,
+			})
+		}
+	}
+	for _, profile := range sortedStringKeys(m.RoomIdHash) {
+		id := m.RoomIdHash[profile]
+		if _, ok := m.Rooms[id]; !ok {
+			errs = append(errs, ValidationError{
+				Type:     RuleBrokenRoomHash,
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("player position of profile %q points to missing room %d", profile, id),
+				RoomID:   id,
+			})
+		}
+	}
+	return errs
+}
@@ -0,0 +1,88 @@
+package mapparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestValidatorSelection tests disabling, enabling and restricting built-in rules
+func TestValidatorSelection(t *testing.T) {
+	m := NewMudletMap()
+	m.Version = 20
+	m.Areas[1] = NewMudletArea(1, "Area 1")
+	for i := int32(1); i <= 3; i++ {
+		room := NewMudletRoom(i)
+		room.Area = 1
+		m.Rooms[i] = room
+	}
+	m.Rooms[1].Exits[ExitEast] = 2 // asymmetric
+	m.Rooms[2].Exits[ExitEast] = 9 // broken
+	// rooms 2 and 3 share room 1's position
+
+	types := func(errs []ValidationError) []string {
+		var out []string
+		for _, e := range errs {
+			out = append(out, e.Type)
+		}
+		return out
+	}
+
+	v := NewValidator()
+	want := []string{RuleAsymmetricExit, RuleDuplicateCoordinates, RuleBrokenExit, RuleDuplicateCoordinates}
+	if got := types(v.Validate(m)); !reflect.DeepEqual(got, want) {
+		t.Errorf("Validate = %v, expected %v", got, want)
+	}
+
+	v.Disable(RuleDuplicateCoordinates, RuleAsymmetricExit)
+	if got := types(v.Validate(m)); !reflect.DeepEqual(got, []string{RuleBrokenExit}) {
+		t.Errorf("Validate with disabled rules = %v", got)
+	}
+
+	v.Enable(RuleAsymmetricExit)
+	if got := types(v.Validate(m)); !reflect.DeepEqual(got, []string{RuleAsymmetricExit, RuleBrokenExit}) {
+		t.Errorf("Validate after Enable = %v", got)
+	}
+
+	v.Only(RuleDuplicateCoordinates)
+	if got := v.Rules(); !reflect.DeepEqual(got, []string{RuleDuplicateCoordinates}) {
+		t.Errorf("Rules after Only = %v", got)
+	}
+}
+
+// TestValidatorCustomRule tests registering and replacing custom rules
+func TestValidatorCustomRule(t *testing.T) {
+	m := NewMudletMap()
+	m.Version = 20
+	m.Areas[-1] = NewMudletArea(-1, "Default Area")
+	for i := int32(1); i <= 3; i++ {
+		room := NewMudletRoom(i)
+		room.Area = -1
+		room.X = i
+		m.Rooms[i] = room
+	}
+	m.Rooms[2].UserData["vnum"] = "1002"
+
+	v := NewValidator().Register(RequireUserData("vnum", SeverityWarning))
+	errs := v.Validate(m)
+	if len(errs) != 2 || errs[0].RoomID != 1 || errs[1].RoomID != 3 {
+		t.Fatalf("Expected missing vnum for rooms 1 and 3, got %+v", errs)
+	}
+	if errs[0].Type != "require_user_data:vnum" || errs[0].Severity != SeverityWarning {
+		t.Errorf("Unexpected error %+v", errs[0])
+	}
+
+	// Replace a built-in rule by name
+	v.Register(NewRule(RuleInvalidVersion, func(m *Map) []ValidationError {
+		if m.Version < 21 {
+			return []ValidationError{{Type: RuleInvalidVersion, Severity: SeverityError, Message: "too old"}}
+		}
+		return nil
+	}))
+	errs = v.Validate(m)
+	if len(errs) != 3 || errs[0].Type != RuleInvalidVersion {
+		t.Errorf("Expected replaced version rule to report first, got %+v", errs)
+	}
+	if n := len(v.Rules()); n != 6 {
+		t.Errorf("Expected 6 rules, got %d", n)
+	}
+}
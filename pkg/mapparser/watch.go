@@ -0,0 +1,161 @@
+package mapparser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultWatchInterval is how often [WatchMapFile] checks for changes.
+const DefaultWatchInterval = time.Second
+
+// WatchOptions configures [WatchMapFileWithOptions].
+type WatchOptions struct {
+	// Interval between checks of the file; DefaultWatchInterval if zero.
+	Interval time.Duration
+	// OnError, if set, receives errors parsing a changed file. The watch
+	// goes on and the file is parsed again when it next changes.
+	OnError func(error)
+}
+
+// WatchMapFile calls onUpdate with the parsed map at path, then again each
+// time the file changes, until ctx is done. See [WatchMapFileWithOptions].
+func WatchMapFile(ctx context.Context, path string, onUpdate func(*MudletMap)) error {
+	return WatchMapFileWithOptions(ctx, path, onUpdate, nil)
+}
+
+// WatchMapFileWithOptions watches a map file by polling its size and
+// modification time, so it needs no platform file notification support.
+//
+// If path is a directory, such as a Mudlet profile's map folder, the most
+// recently modified .dat file in it is watched, following Mudlet's
+// autosaves to new files.
+//
+// A changed file is parsed once it has stopped changing for one interval,
+// so a save in progress is not read. onUpdate always receives a completely
+// parsed map and is not called when the new file has the same
+// [Fingerprint] and player positions (RoomIdHash) as the last delivered
+// map. Calls are made from the calling goroutine, one at a time.
+//
+// The first parse must succeed, otherwise its error is returned. After
+// that the function returns ctx.Err() when ctx is done.
+func WatchMapFileWithOptions(ctx context.Context, path string, onUpdate func(*MudletMap), opts *WatchOptions) error {
+	if opts == nil {
+		opts = &WatchOptions{}
+	}
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+
+	file, state, err := watchTarget(path)
+	if err != nil {
+		return err
+	}
+	m, err := ParseMapFile(file)
+	if err != nil {
+		return err
+	}
+	last, err := watchFingerprint(m)
+	if err != nil {
+		return err
+	}
+	onUpdate(m)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	pending := false // a change was seen and awaits a quiet interval
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		f, s, err := watchTarget(path)
+		if err != nil {
+			// The file may be replaced right now; try again later
+			continue
+		}
+		if f != file || s != state {
+			file, state, pending = f, s, true
+			continue
+		}
+		if !pending {
+			continue
+		}
+		pending = false
+
+		m, err := ParseMapFile(file)
+		if err == nil {
+			var fp string
+			if fp, err = watchFingerprint(m); err == nil && fp != last {
+				last = fp
+				onUpdate(m)
+			}
+		}
+		if err != nil && opts.OnError != nil {
+			opts.OnError(err)
+		}
+	}
+}
+
+// watchFingerprint returns the map's [Fingerprint] extended with the
+// player positions, which the fingerprint leaves out but an autosave after
+// the player moved changes.
+func watchFingerprint(m *MudletMap) (string, error) {
+	fp, err := Fingerprint(m)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(m.RoomIdHash) // map keys are sorted
+	if err != nil {
+		return "", fmt.Errorf("fingerprint: %w", err)
+	}
+	return fp + string(data), nil
+}
+
+// fileState is what the watcher compares between polls.
+type fileState struct {
+	size    int64
+	modTime int64 // nanoseconds since the epoch
+}
+
+// watchTarget returns the file to watch for path and its current state.
+func watchTarget(path string) (string, fileState, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fileState{}, fmt.Errorf("watching map: %w", err)
+	}
+	if !info.IsDir() {
+		return path, fileState{info.Size(), info.ModTime().UnixNano()}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return "", fileState{}, fmt.Errorf("watching map: %w", err)
+	}
+	var newest string
+	var state fileState
+	for _, e := range entries {
+		if e.IsDir() || !strings.EqualFold(filepath.Ext(e.Name()), ".dat") {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			continue
+		}
+		if newest == "" || fi.ModTime().UnixNano() > state.modTime {
+			newest = filepath.Join(path, e.Name())
+			state = fileState{fi.Size(), fi.ModTime().UnixNano()}
+		}
+	}
+	if newest == "" {
+		return "", fileState{}, fmt.Errorf("watching map: no .dat files in %s", path)
+	}
+	return newest, state, nil
+}
@@ -0,0 +1,107 @@
+package mapparser
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestWatchMapFile tests that a watched map directory delivers the initial
+// map and the next autosave
+func TestWatchMapFile(t *testing.T) {
+	small, err := os.ReadFile(smallMapPath)
+	if err != nil {
+		t.Skipf("Test fixture not found: %s", smallMapPath)
+	}
+	dir := t.TempDir()
+	first := filepath.Join(dir, "2025-01-01#10-00-00map.dat")
+	if err := os.WriteFile(first, small, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	updates := make(chan *MudletMap, 4)
+	done := make(chan error, 1)
+	go func() {
+		done <- WatchMapFileWithOptions(ctx, dir, func(m *MudletMap) { updates <- m },
+			&WatchOptions{Interval: 10 * time.Millisecond})
+	}()
+
+	m := <-updates
+	if len(m.Rooms) != 2 {
+		t.Fatalf("Expected the 2-room map first, got %d rooms", len(m.Rooms))
+	}
+
+	// An autosave with the same content is not delivered, a changed one is
+	same := filepath.Join(dir, "2025-01-01#10-05-00map.dat")
+	if err := os.WriteFile(same, small, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(same, later, later); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case m := <-updates:
+		t.Fatalf("Expected unchanged content skipped, got map with %d rooms", len(m.Rooms))
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	large, err := os.ReadFile(largeMapPath)
+	if err != nil {
+		cancel()
+		<-done
+		t.Skipf("Test fixture not found: %s", largeMapPath)
+	}
+	changed := filepath.Join(dir, "2025-01-01#10-10-00map.dat")
+	if err := os.WriteFile(changed, large, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	latest := later.Add(time.Minute)
+	if err := os.Chtimes(changed, latest, latest); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case m := <-updates:
+		if len(m.Rooms) <= 2 {
+			t.Errorf("Expected the large map, got %d rooms", len(m.Rooms))
+		}
+	case <-ctx.Done():
+		t.Fatal("Timed out waiting for update")
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+// TestWatchMapFileMissing tests that a missing file is reported at once
+func TestWatchMapFileMissing(t *testing.T) {
+	err := WatchMapFile(context.Background(), filepath.Join(t.TempDir(), "none.dat"), func(*MudletMap) {})
+	if err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
+
+// TestWatchFingerprint tests that a save where only the player moved is
+// told apart from the previous one
+func TestWatchFingerprint(t *testing.T) {
+	m := NewMudletMap()
+	m.RoomIdHash["Player"] = 1
+	before, err := watchFingerprint(m)
+	if err != nil {
+		t.Fatalf("watchFingerprint failed: %v", err)
+	}
+	same, _ := watchFingerprint(m.DeepCopy())
+	if same != before {
+		t.Error("Expected an identical map to have the same fingerprint")
+	}
+	m.RoomIdHash["Player"] = 2
+	if moved, _ := watchFingerprint(m); moved == before {
+		t.Error("Expected a moved player to change the fingerprint")
+	}
+}
@@ -0,0 +1,321 @@
+package mapparser
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Workspace groups several maps, such as per-guild maps of one world, under
+// unique names. Rooms and areas are addressed by a [GlobalID] made of the
+// map name and the ID within that map, so equal IDs in different files do
+// not clash.
+//
+// [Workspace.Combined] merges all maps into one [MudletMap] with renumbered
+// IDs that can be given to the renderer. A Workspace is not safe for
+// concurrent modification, and its maps must not be modified after being
+// added.
+type Workspace struct {
+	maps  []*WorkspaceMap
+	byKey map[string]*WorkspaceMap
+
+	combined *MudletMap
+	rooms    map[int32]GlobalID // combined room ID -> global ID
+	areas    map[int32]GlobalID // combined area ID -> global ID
+	roomIDs  map[GlobalID]int32
+	areaIDs  map[GlobalID]int32
+}
+
+// WorkspaceMap is one named map of a [Workspace].
+type WorkspaceMap struct {
+	Name string
+	Map  *MudletMap
+}
+
+// GlobalID identifies a room or area within a [Workspace].
+type GlobalID struct {
+	Map string
+	ID  int32
+}
+
+// String formats the ID as "map:id".
+func (g GlobalID) String() string {
+	return g.Map + ":" + strconv.Itoa(int(g.ID))
+}
+
+// ParseGlobalID parses an ID formatted by [GlobalID.String].
+func ParseGlobalID(s string) (GlobalID, error) {
+	i := strings.LastIndexByte(s, ':')
+	if i <= 0 {
+		return GlobalID{}, fmt.Errorf("invalid global ID %q, expected map:id", s)
+	}
+	id, err := strconv.ParseInt(s[i+1:], 10, 32)
+	if err != nil {
+		return GlobalID{}, fmt.Errorf("invalid global ID %q, expected map:id", s)
+	}
+	return GlobalID{Map: s[:i], ID: int32(id)}, nil
+}
+
+// WorkspaceRoom is a room found in a [Workspace].
+type WorkspaceRoom struct {
+	Map  string
+	Room *MudletRoom
+}
+
+// ID returns the room's global ID.
+func (r WorkspaceRoom) ID() GlobalID {
+	return GlobalID{Map: r.Map, ID: r.Room.ID}
+}
+
+// NewWorkspace returns an empty workspace.
+func NewWorkspace() *Workspace {
+	return &Workspace{byKey: make(map[string]*WorkspaceMap)}
+}
+
+// LoadWorkspace parses the given map files into a new workspace, naming
+// each map after its file name without extension.
+func LoadWorkspace(paths ...string) (*Workspace, error) {
+	w := NewWorkspace()
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		if err := w.LoadFile(name, path); err != nil {
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// LoadFile parses the map file at path and adds it under name.
+func (w *Workspace) LoadFile(name, path string) error {
+	m, err := ParseMapFile(path)
+	if err != nil {
+		return fmt.Errorf("loading %s: %w", name, err)
+	}
+	return w.Add(name, m)
+}
+
+// Add adds m under name. Names must be unique, non-empty and free of ':'.
+func (w *Workspace) Add(name string, m *MudletMap) error {
+	if m == nil {
+		return fmt.Errorf("nil map provided")
+	}
+	if name == "" || strings.Contains(name, ":") {
+		return fmt.Errorf("invalid map name %q", name)
+	}
+	if w.byKey[name] != nil {
+		return fmt.Errorf("map %q already in workspace", name)
+	}
+	wm := &WorkspaceMap{Name: name, Map: m}
+	w.maps = append(w.maps, wm)
+	w.byKey[name] = wm
+	w.combined = nil
+	return nil
+}
+
+// Maps returns the workspace's maps in the order they were added.
+func (w *Workspace) Maps() []*WorkspaceMap {
+	return w.maps
+}
+
+// Map returns the map added under name, or nil.
+func (w *Workspace) Map(name string) *MudletMap {
+	if wm := w.byKey[name]; wm != nil {
+		return wm.Map
+	}
+	return nil
+}
+
+// Room returns the room with the given global ID, or nil.
+func (w *Workspace) Room(id GlobalID) *MudletRoom {
+	if m := w.Map(id.Map); m != nil {
+		return m.Rooms[id.ID]
+	}
+	return nil
+}
+
+// FindRooms runs [MudletMap.FindRooms] on every map, returning matches in
+// map order. Area IDs in the query refer to each map's own areas.
+func (w *Workspace) FindRooms(q RoomQuery) []WorkspaceRoom {
+	var result []WorkspaceRoom
+	for _, wm := range w.maps {
+		for _, room := range wm.Map.FindRooms(q) {
+			result = append(result, WorkspaceRoom{Map: wm.Name, Room: room})
+		}
+	}
+	return result
+}
+
+// FindArea returns the global ID of the first area, in map order, whose
+// name matches as in [MudletMap.FindArea].
+func (w *Workspace) FindArea(name string) (GlobalID, bool) {
+	for _, wm := range w.maps {
+		if a := wm.Map.FindArea(name); a != nil {
+			return GlobalID{Map: wm.Name, ID: a.ID}, true
+		}
+	}
+	return GlobalID{}, false
+}
+
+// LookupRoom resolves key with [LookupRoom] in each map in turn and returns
+// the first match.
+func (w *Workspace) LookupRoom(key RoomKey, chain ...RoomResolver) (WorkspaceRoom, bool) {
+	for _, wm := range w.maps {
+		if room := LookupRoom(wm.Map, key, chain...); room != nil {
+			return WorkspaceRoom{Map: wm.Name, Room: room}, true
+		}
+	}
+	return WorkspaceRoom{}, false
+}
+
+// Combined returns all maps merged into one, for rendering or export. Room
+// IDs are shifted by the highest room ID of the maps before them, so the
+// first map keeps its room IDs; area IDs are numbered from 1 in map and
+// area order. Use [Workspace.CombinedRoomID], [Workspace.CombinedAreaID]
+// and [Workspace.GlobalRoomID] to translate.
+//
+// Environment colors, room hashes and map settings come from the first map
+// defining them. The result is built once and shared until the next
+// [Workspace.Add]; it must not be modified.
+func (w *Workspace) Combined() *MudletMap {
+	if w.combined == nil {
+		w.combine()
+	}
+	return w.combined
+}
+
+// CombinedRoomID returns the ID of a room in [Workspace.Combined].
+func (w *Workspace) CombinedRoomID(id GlobalID) (int32, bool) {
+	w.Combined()
+	cid, ok := w.roomIDs[id]
+	return cid, ok
+}
+
+// CombinedAreaID returns the ID of an area in [Workspace.Combined].
+func (w *Workspace) CombinedAreaID(id GlobalID) (int32, bool) {
+	w.Combined()
+	cid, ok := w.areaIDs[id]
+	return cid, ok
+}
+
+// GlobalRoomID translates a room ID of [Workspace.Combined] back to the
+// map it came from.
+func (w *Workspace) GlobalRoomID(combinedID int32) (GlobalID, bool) {
+	w.Combined()
+	id, ok := w.rooms[combinedID]
+	return id, ok
+}
+
+// GlobalAreaID translates an area ID of [Workspace.Combined] back to the
+// map it came from.
+func (w *Workspace) GlobalAreaID(combinedID int32) (GlobalID, bool) {
+	w.Combined()
+	id, ok := w.areas[combinedID]
+	return id, ok
+}
+
+func (w *Workspace) combine() {
+	out := NewMudletMap()
+	w.rooms = make(map[int32]GlobalID)
+	w.areas = make(map[int32]GlobalID)
+	w.roomIDs = make(map[GlobalID]int32)
+	w.areaIDs = make(map[GlobalID]int32)
+
+	var roomOffset int32
+	nextArea := int32(1)
+	for i, wm := range w.maps {
+		m := wm.Map
+		if i == 0 {
+			out.Version = m.Version
+			out.MapSymbolFont = m.MapSymbolFont
+			out.MapFontFudgeFactor = m.MapFontFudgeFactor
+			out.UseOnlyMapFont = m.UseOnlyMapFont
+			out.UserData = maps.Clone(m.UserData)
+		}
+		for env, c := range m.EnvColors {
+			if _, ok := out.EnvColors[env]; !ok {
+				out.EnvColors[env] = c
+			}
+		}
+		for env, c := range m.CustomEnvColors {
+			if _, ok := out.CustomEnvColors[env]; !ok {
+				out.CustomEnvColors[env] = c
+			}
+		}
+
+		areaIDs := make(map[int32]int32, len(m.Areas))
+		for _, id := range sortedKeys(m.Areas) {
+			areaIDs[id] = nextArea
+			g := GlobalID{Map: wm.Name, ID: id}
+			w.areas[nextArea] = g
+			w.areaIDs[g] = nextArea
+			nextArea++
+		}
+		roomID := func(id int32) int32 {
+			if id <= 0 {
+				return id
+			}
+			return id + roomOffset
+		}
+
+		var maxRoom int32
+		for id, room := range m.Rooms {
+			maxRoom = max(maxRoom, id)
+			c := room.DeepCopy()
+			c.ID = roomID(id)
+			if a, ok := areaIDs[room.Area]; ok {
+				c.Area = a
+			}
+			for dir, dest := range c.Exits {
+				c.Exits[dir] = roomID(dest)
+			}
+			for cmd, dest := range c.SpecialExits {
+				c.SpecialExits[cmd] = roomID(dest)
+			}
+			out.Rooms[c.ID] = c
+			g := GlobalID{Map: wm.Name, ID: id}
+			w.rooms[c.ID] = g
+			w.roomIDs[g] = c.ID
+		}
+		for hash, id := range m.RoomDbHashToRoomId {
+			if _, ok := out.RoomDbHashToRoomId[hash]; !ok {
+				out.RoomDbHashToRoomId[hash] = uint32(roomID(int32(id)))
+			}
+		}
+
+		// Keep labels shared between an area and the map-level list shared.
+		labels := make(map[*MudletLabel]*MudletLabel)
+		copyLabels := func(ls []*MudletLabel) []*MudletLabel {
+			if ls == nil {
+				return nil
+			}
+			c := make([]*MudletLabel, len(ls))
+			for i, l := range ls {
+				if labels[l] == nil {
+					labels[l] = l.DeepCopy()
+				}
+				c[i] = labels[l]
+			}
+			return c
+		}
+		for id, area := range m.Areas {
+			a := area.deepCopy(copyLabels)
+			a.ID = areaIDs[id]
+			for i, r := range a.Rooms {
+				a.Rooms[i] = uint32(roomID(int32(r)))
+			}
+			out.Areas[a.ID] = a
+		}
+		for id, ls := range m.Labels {
+			if a, ok := areaIDs[id]; ok {
+				out.Labels[a] = copyLabels(ls)
+			}
+		}
+		roomOffset += maxRoom
+	}
+	for _, a := range out.Areas {
+		out.updateArea(a)
+	}
+	w.combined = out
+}
@@ -0,0 +1,75 @@
+package mapparser
+
+import (
+	"testing"
+)
+
+// TestWorkspace tests namespacing, cross-map queries and the combined map
+func TestWorkspace(t *testing.T) {
+	a := newMutationTestMap(t)
+	b := newMutationTestMap(t)
+	b.Rooms[1].Name = "Guild hall"
+	b.Areas[1].Name = "Guild"
+
+	w := NewWorkspace()
+	if err := w.Add("north", a); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Add("guild", b); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Add("guild", b); err == nil {
+		t.Error("Expected error for duplicate name")
+	}
+	if err := w.Add("a:b", b); err == nil {
+		t.Error("Expected error for name with colon")
+	}
+
+	if w.Room(GlobalID{Map: "guild", ID: 1}) != b.Rooms[1] {
+		t.Error("Expected room lookup by global ID")
+	}
+	found := w.FindRooms(RoomQuery{Name: "guild hall"})
+	if len(found) != 1 || found[0].ID() != (GlobalID{Map: "guild", ID: 1}) {
+		t.Errorf("Unexpected rooms %v", found)
+	}
+	if id, ok := w.FindArea("guild"); !ok || id.Map != "guild" {
+		t.Errorf("Unexpected area %v", id)
+	}
+
+	c := w.Combined()
+	if len(c.Rooms) != 6 || len(c.Areas) != 4 {
+		t.Fatalf("Expected 6 rooms and 4 areas, got %d and %d", len(c.Rooms), len(c.Areas))
+	}
+	if c.Rooms[1].Name == "Guild hall" {
+		t.Error("Expected the first map to keep its room IDs")
+	}
+	id, ok := w.CombinedRoomID(GlobalID{Map: "guild", ID: 1})
+	if !ok || c.Rooms[id].Name != "Guild hall" {
+		t.Fatalf("Unexpected combined ID %d", id)
+	}
+	other, _ := w.CombinedRoomID(GlobalID{Map: "guild", ID: 2})
+	if c.Rooms[id].Exits[ExitEast] != other {
+		t.Errorf("Expected exits renumbered, got %d want %d", c.Rooms[id].Exits[ExitEast], other)
+	}
+	if g, ok := w.GlobalRoomID(id); !ok || g.String() != "guild:1" {
+		t.Errorf("Unexpected global ID %v", g)
+	}
+	area, _ := w.CombinedAreaID(GlobalID{Map: "guild", ID: 1})
+	if c.Areas[area].Name != "Guild" || c.Rooms[id].Area != area {
+		t.Errorf("Unexpected combined area %d", area)
+	}
+	if errs := ValidateMap(c); len(errs) != 0 {
+		t.Errorf("Expected valid combined map, got %v", errs)
+	}
+	if b.Rooms[1].ID != 1 {
+		t.Error("Expected source maps unchanged")
+	}
+
+	g, err := ParseGlobalID("my:map:42")
+	if err != nil || g != (GlobalID{Map: "my:map", ID: 42}) {
+		t.Errorf("Unexpected parsed ID %v, %v", g, err)
+	}
+	if _, err := ParseGlobalID("42"); err == nil {
+		t.Error("Expected error for ID without map")
+	}
+}
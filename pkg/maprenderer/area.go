@@ -0,0 +1,157 @@
+package maprenderer
+
+import (
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+// RenderArea renders every room of an area's z-level in one image of the
+// configured Width and Height. The view is centered on the level's bounding
+// box, and RoomSpacing and RoomSize are scaled down, keeping their ratio,
+// until the whole level fits; a level that already fits is drawn at the
+// configured sizes, after Config.Zoom. The sizes used are recorded in the
+// result.
+func (r *Renderer) RenderArea(areaID, z int32) (*RenderResult, error) {
+	if r.mapData == nil {
+		return nil, fmt.Errorf("no map data loaded")
+	}
+	if r.mapData.GetArea(areaID) == nil {
+		return nil, fmt.Errorf("area %d not found", areaID)
+	}
+	var box mapparser.BoundingBox
+	found := false
+	for _, level := range mapparser.GetAreaStats(r.mapData, areaID).Levels {
+		if level.Z == z {
+			box, found = level.BoundingBox, true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("area %d has no rooms on level %d", areaID, z)
+	}
+
+	return r.renderFitted(areaID, z, box)
+}
+
+// renderFitted renders the rooms within box on level z of an area, as
+// [Renderer.RenderArea].
+func (r *Renderer) renderFitted(areaID, z int32, box mapparser.BoundingBox) (*RenderResult, error) {
+	centerX := box.MinX + (box.MaxX-box.MinX)/2
+	centerY := box.MinY + (box.MaxY-box.MinY)/2
+	cfg, textScale := r.config.zoomed()
+	// The center is rounded down, so the far side reaches further.
+	cfg.RoomSpacing, cfg.RoomSize = fitSpacing(cfg, int(box.MaxX-centerX), int(box.MaxY-centerY))
+
+	w := *r
+	w.config, w.textScale = cfg, textScale
+	return w.render(areaID, centerX, centerY, z, false)
+}
+
+// fitSpacing returns the largest room spacing, at most the configured one,
+// at which rooms up to extentX and extentY grid cells from the center of
+// the image are drawn whole, and the room size scaled to match.
+func fitSpacing(cfg *Config, extentX, extentY int) (spacing, size int) {
+	// One extra cell leaves room for half a room beyond the outermost
+	// centers.
+	spacing = min(cfg.RoomSpacing,
+		cfg.Width/2/(extentX+1),
+		cfg.Height/2/(extentY+1))
+	spacing = max(spacing, 1)
+	if spacing >= cfg.RoomSpacing {
+		return cfg.RoomSpacing, cfg.RoomSize
+	}
+	return spacing, max(cfg.RoomSize*spacing/cfg.RoomSpacing, 1)
+}
+
+// AllAreasOptions configures [Renderer.RenderAllAreas].
+type AllAreasOptions struct {
+	// AllLevels renders every z-level with rooms. By default only the most
+	// populated level of each area is rendered, as [Renderer.RenderAreaCenter]
+	// picks it.
+	AllLevels bool
+}
+
+// RenderAllAreas renders every area with rooms as [Renderer.RenderArea],
+// in ascending area ID and then z-level order, yielding each result with a
+// name suitable for a file: "area-12", or "area-12-z0" with
+// opts.AllLevels. The rooms are scanned once for all areas, and images are
+// rendered one at a time as the sequence is consumed, so a caller writing
+// them out holds a single image at a time. A failed render yields a result
+// with only Err set; stopping the iteration stops rendering. A nil opts
+// uses the defaults.
+func (r *Renderer) RenderAllAreas(opts *AllAreasOptions) iter.Seq2[string, *RenderResult] {
+	if opts == nil {
+		opts = &AllAreasOptions{}
+	}
+	return func(yield func(string, *RenderResult) bool) {
+		if r.mapData == nil {
+			return
+		}
+		levels := areaLevels(r.mapData)
+		for _, areaID := range slices.Sorted(maps.Keys(levels)) {
+			if r.mapData.GetArea(areaID) == nil {
+				continue
+			}
+			byZ := levels[areaID]
+			zs := slices.Sorted(maps.Keys(byZ))
+			if !opts.AllLevels {
+				// Most populated, lowest on ties
+				best := zs[0]
+				for _, z := range zs {
+					if byZ[z].rooms > byZ[best].rooms {
+						best = z
+					}
+				}
+				zs = []int32{best}
+			}
+			for _, z := range zs {
+				name := fmt.Sprintf("area-%d", areaID)
+				if opts.AllLevels {
+					name = fmt.Sprintf("area-%d-z%d", areaID, z)
+				}
+				result, err := r.renderFitted(areaID, z, byZ[z].box)
+				if err != nil {
+					result = &RenderResult{Err: err}
+				}
+				if !yield(name, result) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// levelExtent is the bounding box and room count of one level of an area.
+type levelExtent struct {
+	box   mapparser.BoundingBox
+	rooms int
+}
+
+// areaLevels returns the extent of every level of every area with rooms,
+// by area ID and then z.
+func areaLevels(m *mapparser.MudletMap) map[int32]map[int32]*levelExtent {
+	levels := make(map[int32]map[int32]*levelExtent)
+	for _, room := range m.Rooms {
+		byZ := levels[room.Area]
+		if byZ == nil {
+			byZ = make(map[int32]*levelExtent)
+			levels[room.Area] = byZ
+		}
+		l := byZ[room.Z]
+		if l == nil {
+			l = &levelExtent{box: mapparser.BoundingBox{
+				MinX: room.X, MinY: room.Y, MinZ: room.Z,
+				MaxX: room.X, MaxY: room.Y, MaxZ: room.Z,
+			}}
+			byZ[room.Z] = l
+		}
+		l.rooms++
+		l.box.MinX, l.box.MaxX = min32(l.box.MinX, room.X), max32(l.box.MaxX, room.X)
+		l.box.MinY, l.box.MaxY = min32(l.box.MinY, room.Y), max32(l.box.MaxY, room.Y)
+	}
+	return levels
+}
@@ -0,0 +1,101 @@
+package maprenderer
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+func TestRenderArea(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 200, 100
+	cfg.RoomSize, cfg.RoomSpacing = 20, 25
+	r := NewRenderer(cfg)
+	r.SetMap(testGridMap(10))
+
+	result, err := r.RenderArea(1, 0)
+	if err != nil {
+		t.Fatalf("RenderArea failed: %v", err)
+	}
+	if result.RoomsDrawn != 100 {
+		t.Errorf("RoomsDrawn = %d, want all 100", result.RoomsDrawn)
+	}
+	// The 10x10 grid is centered on (4, 4) and must fit 100 pixels of
+	// height: 5 cells up plus half a room, so 8 pixels apart.
+	if result.CenterX != 4 || result.CenterY != 4 {
+		t.Errorf("center = (%d, %d), want (4, 4)", result.CenterX, result.CenterY)
+	}
+	if result.RoomSpacing != 8 || result.RoomSize != 6 {
+		t.Errorf("spacing, size = %d, %d, want 8, 6", result.RoomSpacing, result.RoomSize)
+	}
+	if cfg.RoomSpacing != 25 || cfg.RoomSize != 20 {
+		t.Error("Expected RenderArea to leave the configuration unchanged")
+	}
+	if room := r.RoomAt(result, 100, 50); room == nil || room.ID != 45 {
+		t.Errorf("RoomAt(center) = %v, want room 45", room)
+	}
+
+	// A level that fits is drawn at the configured sizes
+	r.SetMap(testGridMap(3))
+	result, err = r.RenderArea(1, 0)
+	if err != nil {
+		t.Fatalf("RenderArea failed: %v", err)
+	}
+	if result.RoomSpacing != 25 || result.RoomSize != 20 || result.RoomsDrawn != 9 {
+		t.Errorf("Expected a small area at full size, got spacing %d, size %d, %d rooms",
+			result.RoomSpacing, result.RoomSize, result.RoomsDrawn)
+	}
+
+	if _, err := r.RenderArea(1, 5); err == nil {
+		t.Error("Expected error for a level without rooms")
+	}
+	if _, err := r.RenderArea(9, 0); err == nil {
+		t.Error("Expected error for unknown area")
+	}
+}
+
+func TestRenderAllAreas(t *testing.T) {
+	m := testGridMap(3)
+	m.Areas[2] = mapparser.NewMudletArea(2, "Tower")
+	for i, z := range []int32{1, 1, 2} {
+		room := mapparser.NewMudletRoom(int32(100 + i))
+		room.Area, room.X, room.Z = 2, int32(i), z
+		m.Rooms[room.ID] = room
+	}
+	m.Areas[3] = mapparser.NewMudletArea(3, "Empty")
+	r := NewRenderer(nil)
+	r.SetMap(m)
+
+	var names []string
+	for name, result := range r.RenderAllAreas(nil) {
+		if result.Err != nil {
+			t.Fatalf("%s: %v", name, result.Err)
+		}
+		names = append(names, name)
+		if name == "area-2" && (result.ZLevel != 1 || result.RoomsDrawn != 2) {
+			t.Errorf("area-2: level %d with %d rooms, want the populated level 1 with 2",
+				result.ZLevel, result.RoomsDrawn)
+		}
+	}
+	if want := []string{"area-1", "area-2"}; !slices.Equal(names, want) {
+		t.Errorf("names = %v, want %v", names, want)
+	}
+
+	names = nil
+	for name := range r.RenderAllAreas(&AllAreasOptions{AllLevels: true}) {
+		names = append(names, name)
+	}
+	if want := []string{"area-1-z0", "area-2-z1", "area-2-z2"}; !slices.Equal(names, want) {
+		t.Errorf("names with AllLevels = %v, want %v", names, want)
+	}
+
+	count := 0
+	for range r.RenderAllAreas(&AllAreasOptions{AllLevels: true}) {
+		count++
+		break
+	}
+	if count != 1 {
+		t.Errorf("Expected iteration to stop after break, got %d results", count)
+	}
+}
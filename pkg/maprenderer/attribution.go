@@ -0,0 +1,29 @@
+package maprenderer
+
+import (
+	"image"
+	"strings"
+)
+
+// drawAttribution prints the map's author, license and source in small
+// text in the bottom-right corner, on a translucent plate so it stays
+// legible over the map.
+func (r *Renderer) drawAttribution(img *image.RGBA) {
+	text := strings.ToUpper(r.mapData.Attribution().String())
+	if text == "" {
+		return
+	}
+	u := r.config.ui(1)
+	padding := 3 * u
+	size := r.config.ui(uiTextSize)
+	w := r.stringWidth(size, text) + 2*padding
+	h := 7*u + 2*padding
+	x := r.config.Width - w
+	y := r.config.Height - h
+
+	plate := r.config.BackgroundColor
+	plate.A = 160
+	r.drawFilledRect(img, x, y, w, h, plate)
+
+	r.drawString(img, x+w/2, y+h/2, size, text, r.config.TextColor)
+}
@@ -0,0 +1,98 @@
+package maprenderer
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// RenderRequest describes one image for [Renderer.RenderMany]. The view is
+// chosen by the first field set, in this order: At, Name, Label, AreaID,
+// RoomID.
+type RenderRequest struct {
+	// At renders around a position, as [Renderer.RenderAt].
+	At *Position
+	// Name centers on a label or area name, as [Renderer.RenderByName].
+	Name string
+	// Label centers on a label, as [Renderer.RenderLabel].
+	Label string
+	// AreaID centers on an area's centroid, as [Renderer.RenderAreaCenter].
+	AreaID int32
+	// RoomID centers on a room, as [Renderer.RenderFragment].
+	RoomID int32
+
+	// Config, if non-nil, replaces the renderer's configuration for this
+	// request only.
+	Config *Config
+}
+
+// Position is a point on the map used by [RenderRequest.At].
+type Position struct {
+	AreaID  int32
+	X, Y, Z int32
+}
+
+// RenderMany renders all requests using up to parallelism goroutines, or
+// GOMAXPROCS if parallelism is not positive. The parsed map is shared by all
+// workers and never modified. Results are returned in request order; a
+// failed request has a nil Image and its error in Err, without affecting the
+// others.
+func (r *Renderer) RenderMany(requests []RenderRequest, parallelism int) []RenderResult {
+	results := make([]RenderResult, len(requests))
+	if parallelism <= 0 {
+		parallelism = runtime.GOMAXPROCS(0)
+	}
+	parallelism = min(parallelism, len(requests))
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range parallelism {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i] = r.renderRequest(&requests[i])
+			}
+		}()
+	}
+	for i := range requests {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return results
+}
+
+// renderRequest renders one batch request on a private renderer, so
+// per-request configuration and panics stay contained.
+func (r *Renderer) renderRequest(req *RenderRequest) (result RenderResult) {
+	defer func() {
+		if p := recover(); p != nil {
+			result = RenderResult{Err: fmt.Errorf("render panicked: %v", p)}
+		}
+	}()
+
+	w := *r
+	if req.Config != nil {
+		w.config = req.Config
+	}
+
+	var res *RenderResult
+	var err error
+	switch {
+	case req.At != nil:
+		res, err = w.RenderAt(req.At.AreaID, req.At.X, req.At.Y, req.At.Z)
+	case req.Name != "":
+		res, err = w.RenderByName(req.Name)
+	case req.Label != "":
+		res, err = w.RenderLabel(req.Label)
+	case req.AreaID != 0:
+		res, err = w.RenderAreaCenter(req.AreaID)
+	default:
+		res, err = w.RenderFragment(req.RoomID)
+	}
+	if err != nil {
+		return RenderResult{Err: err}
+	}
+	return *res
+}
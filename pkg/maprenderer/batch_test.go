@@ -0,0 +1,65 @@
+package maprenderer
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRenderMany(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 120, 120
+	r := NewRenderer(cfg)
+	r.SetMap(testGridMap(5))
+
+	small := *cfg
+	small.Width, small.Height = 60, 40
+	requests := []RenderRequest{
+		{RoomID: 1},
+		{RoomID: 999},
+		{At: &Position{AreaID: 1, X: 2, Y: 2}},
+		{AreaID: 1, Config: &small},
+		{Name: "Test Area"},
+		{Label: "nothing"},
+	}
+	for range 20 {
+		requests = append(requests, RenderRequest{RoomID: 13})
+	}
+
+	results := r.RenderMany(requests, 4)
+	if len(results) != len(requests) {
+		t.Fatalf("Expected %d results, got %d", len(requests), len(results))
+	}
+	for i, res := range results {
+		failed := i == 1 || i == 5
+		if failed != (res.Err != nil) {
+			t.Errorf("Request %d: unexpected error %v", i, res.Err)
+		}
+		if !failed && res.Image == nil {
+			t.Errorf("Request %d: missing image", i)
+		}
+	}
+	if results[0].CenterRoom != 1 {
+		t.Errorf("Expected results in request order, got center %d", results[0].CenterRoom)
+	}
+	if b := results[3].Image.Bounds(); b.Dx() != 60 || b.Dy() != 40 {
+		t.Errorf("Expected per-request config size 60x40, got %v", b)
+	}
+	if cfg.Width != 120 {
+		t.Error("Expected renderer config untouched")
+	}
+
+	// Concurrent renders match a sequential one
+	want, err := r.RenderFragment(13)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, res := range results[6:] {
+		if !bytes.Equal(res.Image.Pix, want.Image.Pix) {
+			t.Fatal("Expected parallel render to match sequential render")
+		}
+	}
+
+	if got := r.RenderMany(nil, 0); len(got) != 0 {
+		t.Errorf("Expected no results, got %d", len(got))
+	}
+}
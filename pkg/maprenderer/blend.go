@@ -0,0 +1,106 @@
+package maprenderer
+
+import (
+	"image"
+	"image/color"
+)
+
+// Alpha blending works on the Pix slice of the canvas directly, in 8-bit
+// fixed point. Colors are composited as straight (non-premultiplied) RGBA
+// over the canvas, matching the float formula
+// out = src*a + dst*(1-a) truncated to 8 bits.
+
+// div255 returns x/255 rounded down for 0 <= x <= 255*255, without a
+// division.
+func div255(x uint32) uint32 {
+	return (x + 1 + x>>8) >> 8
+}
+
+// blendInto composites c over the four bytes of one pixel.
+func blendInto(p []byte, c color.RGBA) {
+	p = p[:4:4]
+	if c.A == 255 {
+		p[0], p[1], p[2], p[3] = c.R, c.G, c.B, 255
+		return
+	}
+	a := uint32(c.A)
+	ia := 255 - a
+	p[0] = uint8(div255(uint32(c.R)*a + uint32(p[0])*ia))
+	p[1] = uint8(div255(uint32(c.G)*a + uint32(p[1])*ia))
+	p[2] = uint8(div255(uint32(c.B)*a + uint32(p[2])*ia))
+	p[3] = uint8(a + div255(uint32(p[3])*ia))
+}
+
+// blendPixel composites c over the pixel at (x, y), ignoring points outside
+// the image.
+func blendPixel(img *image.RGBA, x, y int, c color.RGBA) {
+	if c.A == 0 || !(image.Point{X: x, Y: y}).In(img.Rect) {
+		return
+	}
+	blendInto(img.Pix[img.PixOffset(x, y):], c)
+}
+
+// blendSpan composites c over the pixels x0..x1-1 of row y, clipped to the
+// image. Opaque colors are copied in bulk.
+func blendSpan(img *image.RGBA, x0, x1, y int, c color.RGBA) {
+	if c.A == 0 || y < img.Rect.Min.Y || y >= img.Rect.Max.Y {
+		return
+	}
+	x0 = max(x0, img.Rect.Min.X)
+	x1 = min(x1, img.Rect.Max.X)
+	if x0 >= x1 {
+		return
+	}
+	row := img.Pix[img.PixOffset(x0, y):img.PixOffset(x1, y)]
+	if c.A == 255 {
+		row[0], row[1], row[2], row[3] = c.R, c.G, c.B, 255
+		for n := 4; n < len(row); n *= 2 {
+			copy(row[n:], row[:n])
+		}
+		return
+	}
+	for i := 0; i < len(row); i += 4 {
+		blendInto(row[i:], c)
+	}
+}
+
+// blendRect composites c over the rectangle at (x, y) of size w x h,
+// clipped to the image.
+func blendRect(img *image.RGBA, x, y, w, h int, c color.RGBA) {
+	y0 := max(y, img.Rect.Min.Y)
+	y1 := min(y+h, img.Rect.Max.Y)
+	for row := y0; row < y1; row++ {
+		blendSpan(img, x, x+w, row, c)
+	}
+}
+
+// pixelReader returns a function reading pixels of src as color.RGBA. The
+// image types produced by PNG decoding are read straight from their Pix
+// slices; other images go through At.
+func pixelReader(src image.Image) func(x, y int) color.RGBA {
+	switch s := src.(type) {
+	case *image.RGBA:
+		return s.RGBAAt
+	case *image.NRGBA:
+		return func(x, y int) color.RGBA {
+			return premultiply(s.NRGBAAt(x, y))
+		}
+	default:
+		return func(x, y int) color.RGBA {
+			return colorToRGBA(src.At(x, y))
+		}
+	}
+}
+
+// premultiply converts a straight-alpha color to color.RGBA exactly as
+// colorToRGBA does through the color.Color interface.
+func premultiply(c color.NRGBA) color.RGBA {
+	if c.A == 255 {
+		return color.RGBA{R: c.R, G: c.G, B: c.B, A: 255}
+	}
+	a := uint32(c.A) * 0x101
+	mul := func(v uint8) uint8 {
+		return uint8((uint32(v) * 0x101 * a / 0xffff) >> 8)
+	}
+	return color.RGBA{R: mul(c.R), G: mul(c.G), B: mul(c.B), A: c.A}
+}
@@ -0,0 +1,130 @@
+package maprenderer
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+func TestDiv255(t *testing.T) {
+	for x := uint32(0); x <= 255*255; x++ {
+		if got := div255(x); got != x/255 {
+			t.Fatalf("div255(%d) = %d, expected %d", x, got, x/255)
+		}
+	}
+}
+
+func TestBlendMatchesFloat(t *testing.T) {
+	for _, a := range []uint8{1, 64, 128, 200, 254} {
+		for _, v := range []uint8{0, 1, 77, 128, 254, 255} {
+			c := color.RGBA{R: v, G: 255 - v, B: v / 2, A: a}
+			dst := color.RGBA{R: 255 - v, G: v, B: 200, A: v}
+			p := []byte{dst.R, dst.G, dst.B, dst.A}
+			blendInto(p, c)
+
+			alpha := float64(a) / 255
+			want := []byte{
+				uint8(float64(c.R)*alpha + float64(dst.R)*(1-alpha)),
+				uint8(float64(c.G)*alpha + float64(dst.G)*(1-alpha)),
+				uint8(float64(c.B)*alpha + float64(dst.B)*(1-alpha)),
+				uint8(float64(c.A) + float64(dst.A)*(1-alpha)),
+			}
+			for i := range p {
+				if d := int(p[i]) - int(want[i]); d < -1 || d > 1 {
+					t.Errorf("blend %v over %v: channel %d = %d, expected %d", c, dst, i, p[i], want[i])
+				}
+			}
+		}
+	}
+}
+
+func TestBlendSpanClipping(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 10, 3))
+	red := color.RGBA{R: 255, A: 255}
+	blendSpan(img, -5, 4, 1, red)
+	blendSpan(img, 8, 20, 1, red)
+	blendSpan(img, 0, 10, 5, red)
+	for x := 0; x < 10; x++ {
+		want := uint8(0)
+		if x < 4 || x >= 8 {
+			want = 255
+		}
+		if got := img.RGBAAt(x, 1).R; got != want {
+			t.Errorf("pixel %d = %d, expected %d", x, got, want)
+		}
+	}
+
+	half := color.RGBA{B: 255, A: 128}
+	blendRect(img, 2, -1, 3, 10, half)
+	if got := img.RGBAAt(3, 2); got.B != 128 || got.A != 128 {
+		t.Errorf("Unexpected blended pixel %v", got)
+	}
+}
+
+func TestPixelReader(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	src.SetNRGBA(0, 0, color.NRGBA{R: 200, G: 100, B: 50, A: 128})
+	src.SetNRGBA(1, 0, color.NRGBA{R: 10, G: 20, B: 30, A: 255})
+	read := pixelReader(src)
+	for x := 0; x < 2; x++ {
+		if got, want := read(x, 0), colorToRGBA(src.At(x, 0)); got != want {
+			t.Errorf("pixel %d = %v, expected %v", x, got, want)
+		}
+	}
+}
+
+// labelHeavyRenderer returns a renderer over a small area covered by
+// large semi-transparent labels.
+func labelHeavyRenderer(b *testing.B) *Renderer {
+	b.Helper()
+	tile := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	for i := range tile.Pix {
+		tile.Pix[i] = uint8(i)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, tile); err != nil {
+		b.Fatal(err)
+	}
+
+	m := mapparser.NewMudletMap()
+	m.Areas[1] = mapparser.NewMudletArea(1, "Labels")
+	room := mapparser.NewMudletRoom(1)
+	room.Area = 1
+	m.Rooms[1] = room
+	for i := 0; i < 40; i++ {
+		m.Labels[1] = append(m.Labels[1], &mapparser.MudletLabel{
+			ID:     int32(i),
+			Pos:    mapparser.Vector3D{X: float64(i%8) - 12, Y: float64(i/8) + 8},
+			Width:  12,
+			Height: 12,
+			Pixmap: buf.Bytes(),
+		})
+	}
+
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 800, 600
+	r := NewRenderer(cfg)
+	r.SetMap(m)
+	return r
+}
+
+func BenchmarkRenderLabels(b *testing.B) {
+	r := labelHeavyRenderer(b)
+	for b.Loop() {
+		if _, err := r.RenderFragment(1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBlendRect(b *testing.B) {
+	img := image.NewRGBA(image.Rect(0, 0, 800, 600))
+	c := color.RGBA{R: 40, G: 90, B: 200, A: 100}
+	for b.Loop() {
+		blendRect(img, 0, 0, 800, 600, c)
+	}
+}
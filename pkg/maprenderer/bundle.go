@@ -0,0 +1,142 @@
+package maprenderer
+
+import (
+	"image"
+	"math"
+	"sort"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+// exitLink is one connection between two visible rooms, collected for exit
+// bundling.
+type exitLink struct {
+	from, to *mapparser.MudletRoom
+	dir      int    // standard exit direction, or -1 for a special exit
+	command  string // special exit command
+	oneWay   bool
+}
+
+// roomPair identifies two rooms regardless of order, lower ID first.
+type roomPair struct{ a, b int32 }
+
+func makeRoomPair(a, b int32) roomPair {
+	if a > b {
+		a, b = b, a
+	}
+	return roomPair{a, b}
+}
+
+// exitBundles groups the connections between each pair of visible rooms.
+type exitBundles map[roomPair][]exitLink
+
+// addStandard records a standard exit. A two-way exit is recorded once,
+// from the room with the lower ID.
+func (b exitBundles) addStandard(r *Renderer, room, dest *mapparser.MudletRoom, dir int) {
+	twoWay := r.config.OneWayExits.Plain || r.hasReturnExit(room.ID, dest, dir)
+	if twoWay && room.ID > dest.ID {
+		return
+	}
+	pair := makeRoomPair(room.ID, dest.ID)
+	b[pair] = append(b[pair], exitLink{from: room, to: dest, dir: dir, oneWay: !twoWay})
+}
+
+// addSpecial records the special exits of room that lead to visible rooms
+// on the same level and have no custom line of their own. Those to rooms
+// with no exit back are one-way, unless Config.OneWayExits is plain.
+func (b exitBundles) addSpecial(r *Renderer, room *mapparser.MudletRoom, roomMap map[int32]*mapparser.MudletRoom, into exitsInto) {
+	for cmd, destID := range room.SpecialExits {
+		dest := roomMap[destID]
+		if dest == nil || dest.ID == room.ID || dest.Z != room.Z || len(room.CustomLines[cmd]) > 0 {
+			continue
+		}
+		pair := makeRoomPair(room.ID, dest.ID)
+		b[pair] = append(b[pair], exitLink{from: room, to: dest, dir: -1, command: cmd, oneWay: !r.config.OneWayExits.Plain && !into.leadsBack(room, dest)})
+	}
+}
+
+// drawExitBundles draws every bundle, spreading parallel connections
+// between the same two rooms side by side.
+func (r *Renderer) drawExitBundles(img *image.RGBA, bundles exitBundles,
+	centerX, centerY int32, halfWidth, halfHeight, spacing int) {
+
+	pairs := make([]roomPair, 0, len(bundles))
+	for pair := range bundles {
+		pairs = append(pairs, pair)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].a != pairs[j].a {
+			return pairs[i].a < pairs[j].a
+		}
+		return pairs[i].b < pairs[j].b
+	})
+
+	gap := float64(max(3, r.config.RoomSize/5))
+	for _, pair := range pairs {
+		links := bundles[pair]
+		sort.Slice(links, func(i, j int) bool {
+			li, lj := links[i], links[j]
+			if li.dir != lj.dir {
+				return li.dir > lj.dir // standard exits first
+			}
+			if li.from.ID != lj.from.ID {
+				return li.from.ID < lj.from.ID
+			}
+			return li.command < lj.command
+		})
+		for i, link := range links {
+			offset := (float64(i) - float64(len(links)-1)/2) * gap
+			r.drawExitLink(img, link, offset, centerX, centerY, halfWidth, halfHeight, spacing)
+		}
+	}
+}
+
+// drawExitLink draws one connection, shifted sideways by offset pixels.
+// Offsets are measured relative to the direction from the lower to the
+// higher room ID, so that links in both directions spread consistently.
+func (r *Renderer) drawExitLink(img *image.RGBA, link exitLink, offset float64,
+	centerX, centerY int32, halfWidth, halfHeight, spacing int) {
+
+	fromX, fromY := r.roomToScreen(link.from, centerX, centerY, halfWidth, halfHeight, spacing)
+	toX, toY := r.roomToScreen(link.to, centerX, centerY, halfWidth, halfHeight, spacing)
+	dx := float64(toX - fromX)
+	dy := float64(toY - fromY)
+	length := math.Sqrt(dx*dx + dy*dy)
+	if length < 1 {
+		return
+	}
+	nx := dx / length
+	ny := dy / length
+
+	// Perpendicular of the canonical direction
+	px, py := -ny, nx
+	if link.from.ID > link.to.ID {
+		px, py = -px, -py
+	}
+
+	halfRoom := float64(r.config.RoomSize) / 2.0
+	startX := int(float64(fromX) + nx*halfRoom + px*offset)
+	startY := int(float64(fromY) + ny*halfRoom + py*offset)
+	endX := int(float64(toX) - nx*halfRoom + px*offset)
+	endY := int(float64(toY) - ny*halfRoom + py*offset)
+
+	switch {
+	case link.dir < 0 && link.oneWay:
+		c := r.config.OneWayExits.color()
+		r.drawDashedLine(img, startX, startY, endX, endY, c, r.config.ExitWidth)
+		r.drawOneWayArrow(img, endX, endY, nx, ny, c, r.config.ExitWidth)
+	case link.dir < 0:
+		r.drawDashedLine(img, startX, startY, endX, endY, r.config.ExitColor, r.config.ExitWidth)
+		r.drawArrowHead(img, endX, endY, nx, ny, r.config.ExitColor, r.config.ExitWidth)
+	case link.oneWay:
+		r.drawOneWayExit(img, startX, startY, endX, endY, nx, ny, r.config.OneWayExits.color(), r.config.ExitWidth)
+	default:
+		r.drawExitLine(img, startX, startY, endX, endY, r.config.ExitColor)
+	}
+	if link.dir < 0 && r.config.SpecialExitLabels {
+		r.drawSmallLabel(img, (startX+endX)/2, (startY+endY)/2, link.command, r.config.ExitColor)
+	}
+	if link.dir >= 0 {
+		r.drawDoor(img, link.from, link.dir, startX, startY, endX, endY)
+	}
+}
@@ -0,0 +1,51 @@
+package maprenderer
+
+import "testing"
+
+func TestBundleExits(t *testing.T) {
+	m := testGridMap(2)
+	// Room 1 (0,0) and room 2 (1,0) are linked east/west; add a special
+	// exit between them as well
+	m.Rooms[1].SpecialExits = map[string]int32{"climb": 2}
+
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 200, 200
+	r := NewRenderer(cfg)
+	r.SetMap(m)
+
+	plain, err := r.RenderAt(1, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("RenderAt failed: %v", err)
+	}
+	cfg.BundleExits = true
+	bundled, err := r.RenderAt(1, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("RenderAt failed: %v", err)
+	}
+
+	// Without bundling there is a single line through the middle; with it,
+	// two lines sit either side of the middle
+	midX, midY := cfg.Width/2+cfg.RoomSpacing/2, cfg.Height/2
+	if plain.Image.RGBAAt(midX, midY) != cfg.ExitColor {
+		t.Error("Expected unbundled exit line between the rooms")
+	}
+	gap := max(3, cfg.RoomSize/5)
+	above := bundled.Image.RGBAAt(midX, midY-gap/2)
+	below := bundled.Image.RGBAAt(midX, midY+gap-gap/2)
+	if above != cfg.ExitColor && below != cfg.ExitColor {
+		t.Errorf("Expected bundled lines offset from the middle, got %v and %v", above, below)
+	}
+	if plain.Image.RGBAAt(midX, midY-gap/2) == cfg.ExitColor {
+		t.Error("Expected no line off the middle without bundling")
+	}
+
+	// A single exit between two rooms is drawn as before
+	cfg.BundleExits = false
+	r.SetMap(testGridMap(3))
+	plain, _ = r.RenderAt(1, 1, 1, 0)
+	cfg.BundleExits = true
+	bundled, _ = r.RenderAt(1, 1, 1, 0)
+	if !imagesEqual(plain, bundled) {
+		t.Error("Expected bundling to leave single exits unchanged")
+	}
+}
@@ -2,6 +2,10 @@ package maprenderer
 
 import (
 	"image/color"
+	"io/fs"
+	"math"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
 )
 
 // Config holds all rendering configuration options for the map renderer.
@@ -18,28 +22,169 @@ type Config struct {
 	RoomBorder   bool // Draw border around rooms
 	ShowRoomID   bool // Show room ID numbers
 	ShowSymbol   bool // Show room symbols
-	GridMode     bool // Use grid mode (smaller, no spacing)
+	GridMode     bool // Draw all areas as tiles, like Mudlet's grid-mode areas
 	Antialiasing bool // Enable antialiasing
 
+	// ShowRoomNames prints each room's name next to it, at
+	// RoomNamePlacement or, where that space is taken by a room or another
+	// name, at the next placement that is free; names with no free space
+	// are left out. Names longer than RoomNameMaxLen characters are cut
+	// short with an ellipsis; 0 means no limit.
+	ShowRoomNames     bool
+	RoomNamePlacement RoomNamePlacement
+	RoomNameMaxLen    int
+
+	// Zoom scales RoomSize, RoomSpacing, ExitWidth and StubLength together
+	// when rendering, so a view can be enlarged or shrunk without retuning
+	// them. Room symbols follow the room size, and room values are
+	// enlarged to the nearest whole multiple; the legend, axes and
+	// attribution keep their size. Zero means 1.
+	Zoom float64
+
+	// PixelRatio is the number of image pixels per layout pixel, as a
+	// browser's device pixel ratio: at 2, an 800x600 configuration gives
+	// a 1600x1200 image of the same view with everything drawn twice as
+	// large, for HiDPI displays and print. Room and exit sizes and text
+	// scale by it exactly; the legend, axes, title, compass, scale bar,
+	// attribution, borders and player highlight by the nearest whole
+	// multiple. Results are in image pixels. Zero means 1.
+	PixelRatio float64
+
+	// SuperSample draws the map at this many times the image size, after
+	// Zoom, and scales it down by averaging each square of pixels, which
+	// smooths the edges of rooms, lines and text at the cost of drawing
+	// SuperSample squared as many pixels; 2 or 4 are typical. The legend,
+	// axes, title, compass, scale bar and attribution are drawn at the
+	// final size. Zero or 1 means off.
+	SuperSample int
+
+	// Parallelism is the number of horizontal stripes the map is drawn in
+	// at once, each by its own goroutine; the image is the same whatever
+	// the number. Zero draws images of a million pixels or more in
+	// GOMAXPROCS stripes and smaller ones in one; 1 draws all in one.
+	Parallelism int
+
 	// Exit appearance
-	ExitWidth  float64 // Width of exit lines
+	ExitWidth  float64 // Pen width of exit, stub and custom lines and arrowheads
 	ExitColor  color.RGBA
 	StubLength float64 // Length of stub exits
 
+	// LabelAreaExits prints the name of the area each area exit stub leads
+	// to just past its arrow.
+	LabelAreaExits bool
+
+	// AreaGhosts draws, past each compass exit to another area, the room it
+	// leads to and the rooms of that area around it, faded and captioned
+	// with the area's name, in place of the area exit stub.
+	AreaGhosts bool
+
+	// OneWayExits styles exits whose destination has no exit back: standard
+	// exits without the opposite exit, and special exits to rooms with no
+	// exit of any kind back.
+	OneWayExits OneWayExitStyle
+
+	// BundleExits draws every connection between two rooms as its own line,
+	// side by side, instead of a single line per room pair. Special exits
+	// without a custom line are then drawn as dashed arrows.
+	BundleExits bool
+
+	// ShowSpecialExits draws special exits without a custom line, which
+	// Mudlet leaves out, as dashed curves with an arrowhead between rooms
+	// in view on the same level; with BundleExits they are drawn anyway.
+	// SpecialExitLabels prints each one's command at its middle.
+	ShowSpecialExits  bool
+	SpecialExitLabels bool
+
+	// Custom line smoothing. When enabled, custom lines with two or more
+	// bends are drawn as a cardinal spline through their points instead of
+	// straight segments. CurveTension ranges from 0 (Catmull-Rom, loosest)
+	// to 1 (straight segments).
+	SmoothCustomLines bool
+	CurveTension      float64
+
 	// Colors
 	BackgroundColor color.RGBA
 	BorderColor     color.RGBA
 	PlayerRoomColor color.RGBA
 	TextColor       color.RGBA
+	PathColor       color.RGBA // Route drawn by RenderPathSegments
+
+	// Background grid and coordinate axes
+	ShowGrid  bool       // Draw faint lines through room positions
+	GridColor color.RGBA // Grid line color (use low alpha)
+	ShowAxes  bool       // Draw coordinate ticks along the top and left edges
+	AxisColor color.RGBA
+
+	// ShowLegend draws a small panel in the bottom-left corner explaining
+	// door colors and exit markings, for viewers unfamiliar with Mudlet.
+	ShowLegend bool
+
+	// ShowAttribution prints the map's author, license and source URL (see
+	// [mapparser.MudletMap.Attribution]) in the bottom-right corner. Maps
+	// without attribution metadata are drawn unchanged.
+	ShowAttribution bool
+
+	// ShowTitle draws a bar across the top with the area name and level,
+	// ShowCompass a north arrow in the top-right corner and ShowScale a
+	// 100-pixel bar at the bottom with the number of rooms it spans.
+	ShowTitle   bool
+	ShowCompass bool
+	ShowScale   bool
+
+	// Assets supplies the text font and themes. Nil means the embedded
+	// assets; use assets.New to add override directories. A Renderer
+	// parses the text font once for each Assets value, so set a new one,
+	// rather than changing its files, to change the font.
+	Assets fs.FS
 
 	// Environment colors (fallback if not in map)
 	DefaultEnvColors map[int32]color.RGBA
 
-	// Z-level display
+	// Room flag badges, drawn in room corners (up to four per room).
+	// Badges are disabled while FlagRules is empty.
+	FlagRules  []mapparser.FlagRule
+	FlagColors map[string]color.RGBA // Badge color per flag; TextColor if missing
+
+	// RoomValues prints a number on each listed room, such as mob counts or
+	// loot value, in place of the room symbol. Numbers too wide for the room
+	// are drawn just below it.
+	RoomValues map[int32]int
+
+	// Markers annotates rooms, such as quest targets or other players'
+	// positions, with a ring, tint, icon or text tag drawn over the map.
+	Markers map[int32]Marker
+
+	// Overlays are icons and drawing callbacks composited over the map,
+	// after the markers, at a room or map position.
+	Overlays []Overlay
+
+	// Terrain, if set, outlines each room inside its border in a color for
+	// its terrain cost: green for cheap rooms, yellow for cost 1 and red
+	// for cost 3 or more.
+	Terrain *mapparser.TerrainProfile
+
+	// WeightHeatmap colors exit lines or rooms by pathfinding weight, to
+	// spot mis-weighted routes that break speedwalking. WeightGradient
+	// lists the colors from weight 1 to WeightMax, evenly spaced; nil runs
+	// from green through yellow to red. WeightMax 0 uses the highest
+	// weight in view.
+	WeightHeatmap  WeightHeatmap
+	WeightGradient []color.RGBA
+	WeightMax      int32
+
+	// Z-level display: ShowUpperLevel and ShowLowerLevel draw the rooms
+	// and exits of the levels above and below faded under the rendered
+	// one, OtherLevels of them on each side (0 means 1), each farther
+	// level fainter.
 	ShowUpperLevel  bool
 	ShowLowerLevel  bool
 	UpperLevelAlpha uint8
 	LowerLevelAlpha uint8
+	OtherLevels     int
+
+	// uiScale is the whole multiple of PixelRatio that zoomed applied, by
+	// which fixed-size marks are enlarged; 0 means 1.
+	uiScale int
 }
 
 // DefaultConfig returns a configuration with sensible default values.
@@ -72,9 +217,15 @@ func DefaultConfig() *Config {
 		BorderColor:     color.RGBA{R: 100, G: 100, B: 100, A: 255},
 		PlayerRoomColor: color.RGBA{R: 255, G: 100, B: 100, A: 200},
 		TextColor:       color.RGBA{R: 255, G: 255, B: 255, A: 255},
+		PathColor:       color.RGBA{R: 255, G: 200, B: 0, A: 255},
+
+		GridColor: color.RGBA{R: 255, G: 255, B: 255, A: 20},
+		AxisColor: color.RGBA{R: 160, G: 160, B: 160, A: 255},
 
 		DefaultEnvColors: defaultEnvironmentColors(),
 
+		FlagColors: defaultFlagColors(),
+
 		ShowUpperLevel:  false,
 		ShowLowerLevel:  false,
 		UpperLevelAlpha: 80,
@@ -82,6 +233,47 @@ func DefaultConfig() *Config {
 	}
 }
 
+// zoomed returns a copy of c with Zoom and PixelRatio applied to the image,
+// room and exit sizes and reset to 1, and the pixel size of room text at
+// that scale.
+func (c *Config) zoomed() (*Config, int) {
+	z := *c
+	if !c.scales() {
+		return &z, 1
+	}
+	zoom, ratio := c.Zoom, c.PixelRatio
+	if zoom <= 0 {
+		zoom = 1
+	}
+	if ratio <= 0 {
+		ratio = 1
+	}
+	scale := zoom * ratio
+	z.Zoom, z.PixelRatio = 1, 1
+	if ratio != 1 {
+		z.Width = max(int(math.Round(float64(c.Width)*ratio)), 1)
+		z.Height = max(int(math.Round(float64(c.Height)*ratio)), 1)
+		z.uiScale = max(c.uiScale, 1) * max(int(math.Round(ratio)), 1)
+		z.OneWayExits.ArrowSize = c.OneWayExits.ArrowSize * ratio
+	}
+	z.RoomSize = max(int(math.Round(float64(c.RoomSize)*scale)), 1)
+	z.RoomSpacing = max(int(math.Round(float64(c.RoomSpacing)*scale)), 1)
+	z.ExitWidth = c.ExitWidth * scale
+	z.StubLength = c.StubLength * scale
+	return &z, max(int(math.Round(scale)), 1)
+}
+
+// scales reports whether zoomed changes c.
+func (c *Config) scales() bool {
+	return (c.Zoom > 0 && c.Zoom != 1) || (c.PixelRatio > 0 && c.PixelRatio != 1)
+}
+
+// ui returns n pixels of a fixed-size mark, such as a decoration or a
+// border, enlarged by the whole multiple of PixelRatio applied.
+func (c *Config) ui(n int) int {
+	return n * max(c.uiScale, 1)
+}
+
 // CalculateVisibleRooms calculates how many rooms fit from center to edge
 // in both horizontal and vertical directions.
 //
@@ -130,23 +322,28 @@ func (c *Config) CalculateVisibleRooms() (int, int) {
 
 // defaultEnvironmentColors returns Mudlet's default 16 environment colors
 func defaultEnvironmentColors() map[int32]color.RGBA {
-	return map[int32]color.RGBA{
-		1:  {R: 128, G: 0, B: 0, A: 255},     // Red
-		2:  {R: 0, G: 128, B: 0, A: 255},     // Green
-		3:  {R: 128, G: 128, B: 0, A: 255},   // Yellow
-		4:  {R: 0, G: 0, B: 128, A: 255},     // Blue
-		5:  {R: 128, G: 0, B: 128, A: 255},   // Magenta
-		6:  {R: 0, G: 128, B: 128, A: 255},   // Cyan
-		7:  {R: 192, G: 192, B: 192, A: 255}, // White (light gray)
-		8:  {R: 64, G: 64, B: 64, A: 255},    // Black (dark gray)
-		9:  {R: 255, G: 0, B: 0, A: 255},     // Light Red
-		10: {R: 0, G: 255, B: 0, A: 255},     // Light Green
-		11: {R: 255, G: 255, B: 0, A: 255},   // Light Yellow
-		12: {R: 0, G: 0, B: 255, A: 255},     // Light Blue
-		13: {R: 255, G: 0, B: 255, A: 255},   // Light Magenta
-		14: {R: 0, G: 255, B: 255, A: 255},   // Light Cyan
-		15: {R: 255, G: 255, B: 255, A: 255}, // Light White
-		16: {R: 128, G: 128, B: 128, A: 255}, // Light Black (gray)
+	colors := make(map[int32]color.RGBA, 16)
+	for env := int32(1); env <= 16; env++ {
+		c, _ := mapparser.DefaultEnvColor(env)
+		colors[env] = rgba(c)
+	}
+	return colors
+}
+
+// rgba converts a Qt color of the map to an image color.
+func rgba(c mapparser.Color) color.RGBA {
+	r, g, b, a := c.ToRGBA()
+	return color.RGBA{R: r, G: g, B: b, A: a}
+}
+
+// defaultFlagColors returns badge colors for the flags of
+// [mapparser.DefaultFlagRules].
+func defaultFlagColors() map[string]color.RGBA {
+	return map[string]color.RGBA{
+		mapparser.FlagNoPK:    {R: 80, G: 220, B: 80, A: 255},
+		mapparser.FlagIndoors: {R: 230, G: 180, B: 60, A: 255},
+		mapparser.FlagWater:   {R: 60, G: 140, B: 255, A: 255},
+		mapparser.FlagLocked:  {R: 230, G: 60, B: 60, A: 255},
 	}
 }
 
@@ -164,34 +361,8 @@ func envToColor(env int32, customColors map[int32]color.RGBA, defaultColors map[
 	}
 
 	// ANSI 256-color palette (16-255)
-	if env >= 16 && env < 232 {
-		// 6x6x6 color cube (16-231)
-		base := env - 16
-		r := base / 36
-		g := (base - (r * 36)) / 6
-		b := base - (r * 36) - (g * 6)
-
-		var rv, gv, bv uint8
-		if r == 0 {
-			rv = 0
-		} else {
-			rv = uint8((r-1)*40 + 95)
-		}
-		if g == 0 {
-			gv = 0
-		} else {
-			gv = uint8((g-1)*40 + 95)
-		}
-		if b == 0 {
-			bv = 0
-		} else {
-			bv = uint8((b-1)*40 + 95)
-		}
-		return color.RGBA{R: rv, G: gv, B: bv, A: 255}
-	} else if env >= 232 && env < 256 {
-		// Grayscale (232-255)
-		k := uint8(((env - 232) * 10) + 8)
-		return color.RGBA{R: k, G: k, B: k, A: 255}
+	if c, ok := mapparser.ANSIColor(env); ok {
+		return rgba(c)
 	}
 
 	// Fallback to gray
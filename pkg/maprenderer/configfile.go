@@ -0,0 +1,222 @@
+package maprenderer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadConfig reads a configuration file, so that the rendering style can
+// be shared between the CLI and a server. Files ending in .yaml or .yml
+// are read as YAML, see [ParseConfigYAML], and others as JSON, in the
+// layout of [Config.MarshalJSON]. Settings missing from the file keep
+// their [DefaultConfig] values.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yaml" || ext == ".yml" {
+		if data, err = ParseConfigYAML(bytes.NewReader(data)); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	cfg := DefaultConfig()
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// configAlias has the fields of Config without its methods, so that
+// encoding it does not recurse into [Config.MarshalJSON].
+type configAlias Config
+
+// configJSON is the file layout of a Config: its fields under their Go
+// names, with colors written as "#rrggbb" or "#rrggbbaa".
+type configJSON struct {
+	*configAlias
+	ExitColor        hexColor
+	BackgroundColor  hexColor
+	BorderColor      hexColor
+	PlayerRoomColor  hexColor
+	TextColor        hexColor
+	PathColor        hexColor
+	GridColor        hexColor
+	AxisColor        hexColor
+	DefaultEnvColors map[int32]hexColor
+	FlagColors       map[string]hexColor
+	WeightGradient   []hexColor
+	// Assets are set in code only
+	Assets *struct{} `json:",omitempty"`
+}
+
+func (c *Config) toJSON() *configJSON {
+	return &configJSON{
+		configAlias:      (*configAlias)(c),
+		ExitColor:        hexColor(c.ExitColor),
+		BackgroundColor:  hexColor(c.BackgroundColor),
+		BorderColor:      hexColor(c.BorderColor),
+		PlayerRoomColor:  hexColor(c.PlayerRoomColor),
+		TextColor:        hexColor(c.TextColor),
+		PathColor:        hexColor(c.PathColor),
+		GridColor:        hexColor(c.GridColor),
+		AxisColor:        hexColor(c.AxisColor),
+		DefaultEnvColors: convertColors[int32, color.RGBA, hexColor](c.DefaultEnvColors),
+		FlagColors:       convertColors[string, color.RGBA, hexColor](c.FlagColors),
+		WeightGradient:   convertColorSlice[color.RGBA, hexColor](c.WeightGradient),
+	}
+}
+
+// MarshalJSON encodes the configuration with its fields under their Go
+// names and colors as hex strings, e.g. "BackgroundColor": "#1e1e1e". The
+// Assets are left out.
+func (c Config) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.toJSON())
+}
+
+// UnmarshalJSON sets the fields present in data, in the layout of
+// [Config.MarshalJSON], and leaves the others unchanged. Field names match
+// case-insensitively; unknown fields are an error. DefaultEnvColors and
+// FlagColors entries are added to the current ones. Markers and Overlays
+// hold runtime data set in code and are ignored.
+func (c *Config) UnmarshalJSON(data []byte) error {
+	j := c.toJSON()
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&struct {
+		*configJSON
+		Markers  json.RawMessage
+		Overlays json.RawMessage
+	}{configJSON: j}); err != nil {
+		return err
+	}
+	c.ExitColor = color.RGBA(j.ExitColor)
+	c.BackgroundColor = color.RGBA(j.BackgroundColor)
+	c.BorderColor = color.RGBA(j.BorderColor)
+	c.PlayerRoomColor = color.RGBA(j.PlayerRoomColor)
+	c.TextColor = color.RGBA(j.TextColor)
+	c.PathColor = color.RGBA(j.PathColor)
+	c.GridColor = color.RGBA(j.GridColor)
+	c.AxisColor = color.RGBA(j.AxisColor)
+	c.DefaultEnvColors = convertColors[int32, hexColor, color.RGBA](j.DefaultEnvColors)
+	c.FlagColors = convertColors[string, hexColor, color.RGBA](j.FlagColors)
+	c.WeightGradient = convertColorSlice[hexColor, color.RGBA](j.WeightGradient)
+	return nil
+}
+
+// oneWayStyleAlias has the fields of OneWayExitStyle without its methods.
+type oneWayStyleAlias OneWayExitStyle
+
+// oneWayStyleJSON is the file layout of a OneWayExitStyle, with its color
+// written as in Config.
+type oneWayStyleJSON struct {
+	*oneWayStyleAlias
+	Color hexColor
+}
+
+// MarshalJSON encodes the style with its color as a hex string.
+func (s OneWayExitStyle) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&oneWayStyleJSON{oneWayStyleAlias: (*oneWayStyleAlias)(&s), Color: hexColor(s.Color)})
+}
+
+// UnmarshalJSON sets the fields present in data, in the layout of
+// [OneWayExitStyle.MarshalJSON]; unknown fields are an error.
+func (s *OneWayExitStyle) UnmarshalJSON(data []byte) error {
+	j := &oneWayStyleJSON{oneWayStyleAlias: (*oneWayStyleAlias)(s), Color: hexColor(s.Color)}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(j); err != nil {
+		return err
+	}
+	s.Color = color.RGBA(j.Color)
+	return nil
+}
+
+// hexColor is a color encoded as "#rrggbb", or "#rrggbbaa" if translucent.
+type hexColor color.RGBA
+
+func (h hexColor) MarshalText() ([]byte, error) {
+	return []byte(formatHexColor(color.RGBA(h))), nil
+}
+
+func (h *hexColor) UnmarshalText(text []byte) error {
+	c, err := parseHexColor(string(text))
+	*h = hexColor(c)
+	return err
+}
+
+func convertColors[K comparable, From, To ~struct{ R, G, B, A uint8 }](m map[K]From) map[K]To {
+	if m == nil {
+		return nil
+	}
+	out := make(map[K]To, len(m))
+	for k, c := range m {
+		out[k] = To(c)
+	}
+	return out
+}
+
+func convertColorSlice[From, To ~struct{ R, G, B, A uint8 }](s []From) []To {
+	if s == nil {
+		return nil
+	}
+	out := make([]To, len(s))
+	for i, c := range s {
+		out[i] = To(c)
+	}
+	return out
+}
+
+// ParseConfigYAML converts a configuration written in YAML to the JSON
+// read by [Config.UnmarshalJSON]: a mapping of the same fields, where
+// nested settings such as FlagColors, OneWayExits or Terrain are nested
+// mappings. Colors must be quoted, as "#" starts a comment:
+//
+//	Width: 1024
+//	RoomRound: true
+//	BackgroundColor: "#f4f1e8"
+//	DefaultEnvColors:
+//	  1: "#d55e00"
+//	WeightGradient: ["#ffffff", "#ff0000"]
+//	OneWayExits:
+//	  Dash: [4, 2]
+func ParseConfigYAML(r io.Reader) ([]byte, error) {
+	var root map[string]any
+	if err := yaml.NewDecoder(r).Decode(&root); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	if root == nil {
+		root = make(map[string]any)
+	}
+	return json.Marshal(jsonValue(root))
+}
+
+// jsonValue converts decoded YAML to values encoding/json accepts, with
+// mapping keys such as environment IDs written as strings.
+func jsonValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, x := range v {
+			v[k] = jsonValue(x)
+		}
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, x := range v {
+			m[fmt.Sprint(k)] = jsonValue(x)
+		}
+		return m
+	case []any:
+		for i, x := range v {
+			v[i] = jsonValue(x)
+		}
+	}
+	return v
+}
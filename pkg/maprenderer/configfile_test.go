@@ -0,0 +1,119 @@
+package maprenderer
+
+import (
+	"encoding/json"
+	"image/color"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+func TestConfigJSON(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.RoomNamePlacement = RoomNameAbove
+	cfg.FlagColors["quest"] = color.RGBA{R: 1, G: 2, B: 3, A: 128}
+	cfg.WeightHeatmap = HeatmapExits
+	cfg.OneWayExits = OneWayExitStyle{Color: color.RGBA{R: 255, A: 255}, Dash: []int{4, 2}}
+	cfg.WeightGradient = []color.RGBA{{A: 255}, {R: 255, A: 255}}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	for _, want := range []string{`"BackgroundColor":"#1e1e1e"`, `"PlayerRoomColor":"#ff6464c8"`,
+		`"quest":"#01020380"`, `"RoomNamePlacement":"above"`, `"WeightHeatmap":"exits"`,
+		`"WeightGradient":["#000000","#ff0000"]`, `"Dash":[4,2],"ArrowSize":0,"Color":"#ff0000"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Expected %s in %s", want, data)
+		}
+	}
+
+	got := &Config{}
+	if err := json.Unmarshal(data, got); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, cfg) {
+		t.Errorf("Expected the configuration to round-trip, got %+v", got)
+	}
+
+	// Missing fields keep their values and color maps are merged
+	got = DefaultConfig()
+	if err := json.Unmarshal([]byte(`{"width": 1024, "DefaultEnvColors": {"1": "#000000"}}`), got); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if got.Width != 1024 || got.Height != 600 || got.DefaultEnvColors[1] != (color.RGBA{A: 255}) ||
+		got.DefaultEnvColors[2] != cfg.DefaultEnvColors[2] {
+		t.Errorf("Unexpected width %d, height %d or environment colors", got.Width, got.Height)
+	}
+
+	for _, bad := range []string{`{"Widht": 1}`, `{"TextColor": "white"}`, `{"RoomNamePlacement": "left"}`,
+		`{"OneWayExits": {"Colour": "#ff0000"}}`} {
+		if err := json.Unmarshal([]byte(bad), DefaultConfig()); err == nil {
+			t.Errorf("Expected an error for %s", bad)
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	yamlFile := filepath.Join(dir, "style.yaml")
+	err := os.WriteFile(yamlFile, []byte(`# shared style
+---
+Width: 1024
+RoomRound: true
+BackgroundColor: "#f4f1e8" # light
+RoomNamePlacement: right
+FlagColors:
+  quest: '#ff0000'
+ExitWidth: 1.5
+WeightGradient: ["#ffffff", '#ff0000']
+OneWayExits:
+  Dash: [4, 2]
+Terrain:
+  Names:
+    swamp: 3
+  Environments:
+    17: 2
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(yamlFile)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Width != 1024 || !cfg.RoomRound || cfg.ExitWidth != 1.5 || cfg.RoomNamePlacement != RoomNameRight ||
+		cfg.BackgroundColor != (color.RGBA{0xf4, 0xf1, 0xe8, 0xff}) || cfg.FlagColors["quest"] != (color.RGBA{R: 255, A: 255}) {
+		t.Errorf("Unexpected configuration %+v", cfg)
+	}
+	if want := []color.RGBA{{255, 255, 255, 255}, {R: 255, A: 255}}; !reflect.DeepEqual(cfg.WeightGradient, want) {
+		t.Errorf("Expected weight gradient %v, got %v", want, cfg.WeightGradient)
+	}
+	if !reflect.DeepEqual(cfg.OneWayExits.Dash, []int{4, 2}) {
+		t.Errorf("Expected the one-way dash [4 2], got %v", cfg.OneWayExits.Dash)
+	}
+	want := &mapparser.TerrainProfile{Names: map[string]float64{"swamp": 3}, Environments: map[int32]float64{17: 2}}
+	if !reflect.DeepEqual(cfg.Terrain, want) {
+		t.Errorf("Expected terrain %+v, got %+v", want, cfg.Terrain)
+	}
+	if cfg.Height != 600 || cfg.FlagColors[mapparser.FlagNoPK] != DefaultConfig().FlagColors[mapparser.FlagNoPK] {
+		t.Error("Expected settings missing from the file to keep their defaults")
+	}
+
+	jsonFile := filepath.Join(dir, "style.json")
+	if err := os.WriteFile(jsonFile, []byte(`{"Height": 300}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err := LoadConfig(jsonFile); err != nil || cfg.Height != 300 {
+		t.Errorf("Expected height 300 from JSON, got %v", err)
+	}
+
+	for _, bad := range []string{"Width 1024", "Width: [1"} {
+		if _, err := ParseConfigYAML(strings.NewReader(bad)); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+}
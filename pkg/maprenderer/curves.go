@@ -0,0 +1,66 @@
+package maprenderer
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// curveSampleSpacing is the approximate distance in pixels between points
+// sampled along a smoothed curve.
+const curveSampleSpacing = 4.0
+
+// smoothPath returns points sampled along a cardinal spline through pts.
+// The spline is evaluated as one cubic Bézier segment per pair of points.
+// tension 0 gives a Catmull-Rom curve; tension 1 gives straight segments.
+func smoothPath(pts []fPoint, tension float64) []fPoint {
+	if len(pts) < 3 {
+		return pts
+	}
+	tension = math.Max(0, math.Min(1, tension))
+	k := (1 - tension) / 6 // tangent scale, including the Bézier 1/3 factor
+
+	out := []fPoint{pts[0]}
+	for i := 0; i < len(pts)-1; i++ {
+		p0 := pts[max(i-1, 0)]
+		p1 := pts[i]
+		p2 := pts[i+1]
+		p3 := pts[min(i+2, len(pts)-1)]
+
+		c1 := fPoint{p1.X + k*(p2.X-p0.X), p1.Y + k*(p2.Y-p0.Y)}
+		c2 := fPoint{p2.X - k*(p3.X-p1.X), p2.Y - k*(p3.Y-p1.Y)}
+
+		steps := max(4, int(math.Ceil(math.Hypot(p2.X-p1.X, p2.Y-p1.Y)/curveSampleSpacing)))
+		for s := 1; s <= steps; s++ {
+			out = append(out, cubicBezier(p1, c1, c2, p2, float64(s)/float64(steps)))
+		}
+	}
+	return out
+}
+
+// cubicBezier evaluates a cubic Bézier curve at t in [0, 1].
+func cubicBezier(p0, p1, p2, p3 fPoint, t float64) fPoint {
+	u := 1 - t
+	a, b, c, d := u*u*u, 3*u*u*t, 3*u*t*t, t*t*t
+	return fPoint{
+		X: a*p0.X + b*p1.X + c*p2.X + d*p3.X,
+		Y: a*p0.Y + b*p1.Y + c*p2.Y + d*p3.Y,
+	}
+}
+
+// drawStyledLine draws a line in the given Qt pen style (see
+// drawCustomLines) with a pen width pixels wide. step is the pattern
+// position at the line start; the position after the line is returned so
+// that a pattern can continue across the segments of a curve.
+func (r *Renderer) drawStyledLine(img *image.RGBA, x1, y1, x2, y2 int, style int32, c color.RGBA, width float64, step int) int {
+	var on func(step int) bool
+	switch style {
+	case 0: // NoPen
+		on = func(int) bool { return false }
+	case 2, 4, 5: // Dash, DashDot, DashDotDot
+		on = dashed
+	case 3: // Dot
+		on = dotted
+	}
+	return stroke(img, x1, y1, x2, y2, c, width, on, step)
+}
@@ -0,0 +1,75 @@
+package maprenderer
+
+import (
+	"math"
+	"testing"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+func TestSmoothPath(t *testing.T) {
+	pts := []fPoint{{0, 0}, {40, 0}, {40, 40}}
+
+	curve := smoothPath(pts, 0)
+	if len(curve) <= len(pts) {
+		t.Fatalf("Expected sampled curve, got %d points", len(curve))
+	}
+	// The curve passes through every input point
+	for _, p := range pts {
+		found := false
+		for _, c := range curve {
+			if math.Abs(c.X-p.X) < 1e-9 && math.Abs(c.Y-p.Y) < 1e-9 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("Curve does not pass through %v", p)
+		}
+	}
+	// Catmull-Rom cuts the corner; full tension keeps straight segments
+	mid := len(curve) / 2
+	if curve[mid-1].X >= 40 || curve[mid+1].Y <= 0 {
+		t.Errorf("Expected rounded corner, got %v", curve[mid-1:mid+2])
+	}
+	for _, c := range smoothPath(pts, 1) {
+		if c.Y != 0 && math.Abs(c.X-40) > 1e-9 {
+			t.Errorf("Point %v off the straight segments at tension 1", c)
+		}
+	}
+
+	if got := smoothPath(pts[:2], 0); len(got) != 2 {
+		t.Errorf("Expected two-point path unchanged, got %v", got)
+	}
+}
+
+func TestSmoothCustomLines(t *testing.T) {
+	m := testGridMap(1)
+	m.Rooms[1].CustomLines = map[string][]mapparser.Point2D{
+		"ferry": {{X: 3, Y: 0}, {X: 3, Y: 3}},
+	}
+
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 300, 300
+	r := NewRenderer(cfg)
+	r.SetMap(m)
+	straight, err := r.RenderFragment(1)
+	if err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+	cfg.SmoothCustomLines = true
+	smooth, err := r.RenderFragment(1)
+	if err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+
+	// Both pass through the bend at (3, 0), but only the straight line
+	// approaches it horizontally
+	x, y := cfg.Width/2+3*cfg.RoomSpacing-10, cfg.Height/2
+	if straight.Image.RGBAAt(x, y) != cfg.ExitColor {
+		t.Errorf("Expected straight line approaching the bend horizontally")
+	}
+	if smooth.Image.RGBAAt(x, y) == cfg.ExitColor {
+		t.Errorf("Expected smoothed line to curve into the bend")
+	}
+}
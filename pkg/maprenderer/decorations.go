@@ -0,0 +1,124 @@
+package maprenderer
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strconv"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	// decorationMargin is the distance in pixels between the title bar,
+	// compass and scale bar and the image edges.
+	decorationMargin = 6
+	// titleSize is the font size of the title bar in pixels.
+	titleSize = 14
+	// scaleBarLength is the length of the scale bar in pixels.
+	scaleBarLength = 100
+)
+
+// drawTitle draws a bar across the top of the image with the area name and
+// level, and returns its height.
+func (r *Renderer) drawTitle(img *image.RGBA, areaName string, areaID, z int32) int {
+	if areaName == "" {
+		areaName = fmt.Sprintf("Area %d", areaID)
+	}
+	title := fmt.Sprintf("%s · level %d", areaName, z)
+
+	margin := r.config.ui(decorationMargin)
+	face := r.face(r.config.ui(titleSize))
+	if face == nil {
+		return 0
+	}
+	metrics := face.Metrics()
+	h := (metrics.Ascent + metrics.Descent).Ceil() + margin
+	bar := r.config.BackgroundColor
+	bar.A = 200
+	r.drawFilledRect(img, 0, 0, r.config.Width, h, bar)
+	for i := range r.config.ui(1) {
+		r.drawLine(img, 0, h-1-i, r.config.Width-1, h-1-i, r.config.BorderColor)
+	}
+
+	w := font.MeasureString(face, title).Ceil()
+	d := font.Drawer{Dst: img, Src: image.NewUniform(r.config.TextColor), Face: face,
+		Dot: fixed.P((r.config.Width-w)/2, margin/2+metrics.Ascent.Ceil())}
+	d.DrawString(title)
+	return h
+}
+
+// drawCompass draws a north arrow with an "N" over it in the top-right
+// corner, top pixels from the top edge. Mudlet maps have north up.
+func (r *Renderer) drawCompass(img *image.RGBA, top int) {
+	u := r.config.ui(1)
+	margin := decorationMargin * u
+	arrowH, arrowW := 20*u, 12*u
+	plateW := arrowW + 2*margin
+	plateH := arrowH + 7*u + 3*margin
+	x := r.config.Width - margin - plateW
+	y := top + margin
+	plate := r.config.BackgroundColor
+	plate.A = 160
+	r.drawFilledRect(img, x, y, plateW, plateH, plate)
+
+	c := r.config.TextColor
+	cx := x + plateW/2
+	r.drawString(img, cx, y+margin+3*u, r.config.ui(uiTextSize), "N", c)
+	tip := y + 2*margin + 7*u
+	for dy := range arrowH {
+		// The arrow widens from its tip to the base, with a notch cut
+		// into the base's middle.
+		half := (dy + 1) * arrowW / 2 / arrowH
+		notch := max(0, dy-arrowH*2/3) * arrowW / 2 / arrowH
+		for dx := -half; dx <= half; dx++ {
+			if abs(dx) >= notch {
+				setPixelSafe(img, cx+dx, tip+dy, c)
+			}
+		}
+	}
+}
+
+// drawScaleBar draws a bar scaleBarLength layout pixels long at the bottom
+// middle of the image, ticked at every room, with the number of rooms it spans.
+func (r *Renderer) drawScaleBar(img *image.RGBA, spacing int) {
+	if spacing < 1 {
+		return
+	}
+	u := r.config.ui(1)
+	length := scaleBarLength * u
+	rooms := float64(length) / float64(spacing)
+	caption := strconv.FormatFloat(math.Round(rooms*10)/10, 'f', -1, 64) + " ROOMS"
+	if rooms == 1 {
+		caption = "1 ROOM"
+	}
+
+	margin := decorationMargin * u
+	plateH := (7+8)*u + 3*margin/2
+	x := (r.config.Width - length) / 2
+	size := r.config.ui(uiTextSize)
+	plateW := max(length, r.stringWidth(size, caption)) + margin
+	plateY := r.config.Height - margin - plateH
+	plate := r.config.BackgroundColor
+	plate.A = 160
+	r.drawFilledRect(img, (r.config.Width-plateW)/2, plateY, plateW, plateH, plate)
+
+	c := r.config.TextColor
+	r.drawString(img, r.config.Width/2, plateY+margin/2+3*u, size, caption, c)
+	y := plateY + plateH - margin/2 - 2*u
+	stroke(img, x, y, x+length, y, c, float64(2*u), nil, 0)
+	tick := func(tx, h int) {
+		for i := range u {
+			r.drawLine(img, tx+i, y-h*u, tx+i, y, c)
+		}
+	}
+	tick(x, 4)
+	tick(x+length, 4)
+	// Tick every room while the ticks stay apart
+	if spacing >= 4*u {
+		for tx := x + spacing; tx < x+length; tx += spacing {
+			tick(tx, 2)
+		}
+	}
+}
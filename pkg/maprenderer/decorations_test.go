@@ -0,0 +1,52 @@
+package maprenderer
+
+import (
+	"image"
+	"testing"
+)
+
+func TestDecorations(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 200, 200
+	r := NewRenderer(cfg)
+	r.SetMap(testGridMap(3))
+	plain, err := r.RenderAt(1, 1, 1, 0)
+	if err != nil {
+		t.Fatalf("RenderAt failed: %v", err)
+	}
+	render := func() *image.RGBA {
+		t.Helper()
+		result, err := r.RenderAt(1, 1, 1, 0)
+		if err != nil {
+			t.Fatalf("RenderAt failed: %v", err)
+		}
+		return result.Image
+	}
+	all := image.Rect(0, 0, 200, 200)
+
+	cfg.ShowTitle = true
+	title := inkBounds(render(), plain.Image, all)
+	if title.Empty() || title.Dx() != 200 || title.Max.Y > 30 {
+		t.Errorf("Expected a bar across the top, ink %v", title)
+	}
+
+	cfg.ShowTitle = false
+	cfg.ShowCompass = true
+	compass := inkBounds(render(), plain.Image, all)
+	if compass.Empty() || compass.Max.X > 200-decorationMargin || compass.Min.Y < decorationMargin || compass.Min.X < 150 {
+		t.Errorf("Expected a compass in the top-right corner, ink %v", compass)
+	}
+	cfg.ShowTitle, cfg.ShowCompass = true, false
+	titleOnly := render()
+	cfg.ShowCompass = true
+	if below := inkBounds(render(), titleOnly, all); below.Min.Y < title.Max.Y+decorationMargin {
+		t.Errorf("Expected the compass below the title bar, ink %v", below)
+	}
+
+	cfg.ShowTitle, cfg.ShowCompass = false, false
+	cfg.ShowScale = true
+	scale := inkBounds(render(), plain.Image, all)
+	if scale.Empty() || scale.Max.Y > 200-decorationMargin || scale.Min.Y < 160 || scale.Dx() < scaleBarLength {
+		t.Errorf("Expected a scale bar at the bottom, ink %v", scale)
+	}
+}
@@ -31,14 +31,52 @@
 //	// Save to file
 //	err = maprenderer.SaveImage(result.Image, "map.webp", nil)
 //
+// Fragments can also be centered on a named label or area, or on arbitrary
+// coordinates, using [Renderer.RenderByName], [Renderer.RenderAreaCenter] and
+// [Renderer.RenderAt]. [Renderer.RenderArea] shows a whole area level scaled
+// to fit the image, and [Renderer.RenderAllAreas] does so for every area:
+//
+//	for name, result := range renderer.RenderAllAreas(nil) {
+//	    if result.Err != nil {
+//	        log.Fatal(result.Err)
+//	    }
+//	    err := maprenderer.SaveImage(result.Image, name+".webp", nil)
+//	    ...
+//	}
+//
+// [Renderer.RenderFragmentAtZ] shows another level around a room, and
+// [Renderer.RenderStack] draws several levels of an area in one image as a
+// 2.5D stack, and [Renderer.RenderAreaLevels] lays them out side by side.
+// [Renderer.RenderViewport] renders any window of map coordinates, and
+// [Renderer.RenderFragmentInto] draws a fragment over an image of the
+// caller's, such as a GUI frame, reusing its pixel buffer.
+//
 // # Configuration
 //
 // The [Config] struct controls rendering behavior:
-//   - Image dimensions (Width, Height)
+//   - Image dimensions (Width, Height, PixelRatio, SuperSample)
 //   - Room appearance (RoomSize, RoomSpacing, RoomRound)
 //   - Exit lines (ExitWidth, ExitColor)
 //   - Colors (BackgroundColor, BorderColor, PlayerRoomColor)
-//   - Z-level display (ShowUpperLevel, ShowLowerLevel)
+//   - Z-level display (ShowUpperLevel, ShowLowerLevel, OtherLevels)
+//   - Overlays (ShowGrid, ShowAxes, ShowLegend, ShowAttribution)
+//   - Decorations (ShowTitle, ShowCompass, ShowScale)
+//   - Annotations on rooms and map positions (Markers, Overlays)
+//   - Drawing large images on several cores (Parallelism)
+//
+// # Assets
+//
+// Color themes and the HTML viewer page come from the assets package,
+// embedded in the binary. All text is drawn with a TrueType font, Go
+// Regular unless the assets provide fonts/text.ttf, with room symbols
+// shrunk as needed to fit the room. Set Config.Assets to read them
+// with override directories instead, apply a theme with
+// [Config.ApplyTheme], or load one with [Config.LoadTheme], adjust it and
+// set it with [Config.SetTheme], and write a viewer page with
+// [WriteViewer].
+//
+// A Config encodes to JSON with colors as hex strings, and [LoadConfig]
+// reads one from a JSON or YAML file.
 //
 // # Output Formats
 //
@@ -51,15 +89,21 @@
 //
 // # Environment Colors
 //
-// Room colors are determined by their environment ID. The renderer uses:
+// Room colors are determined by their environment ID, after the map's
+// environment color mapping, as [mapparser.MudletMap.ResolveEnvColor]
+// resolves them. The renderer uses:
 //  1. Mudlet's default 16 ANSI colors (environments 1-16)
 //  2. Custom environment colors defined in the map file
-//  3. ANSI 256-color palette for environments 17-255
-//  4. Fallback gray for undefined environments
+//  3. ANSI 256-color palette for environments mapped to 17-255
+//  4. The color of environment 1 for undefined environments
 //
 // # Labels
 //
 // Map labels (text and images) are rendered according to their ShowOnTop flag:
 //   - Background labels: rendered under rooms and exits
 //   - Foreground labels: rendered on top of everything
+//
+// Labels without a usable image, such as those of maps exported by other
+// tools, have their text drawn in the label's colors, one centered row per
+// line.
 package maprenderer
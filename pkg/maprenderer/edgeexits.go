@@ -0,0 +1,77 @@
+package maprenderer
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+// EdgeExit is an exit from a rendered room to a room that is not in the
+// view: further along the same level, on another level or in another area.
+// Web UIs can use them to draw "continue" affordances that load the
+// neighboring view, e.g. by rendering DestRoomID.
+type EdgeExit struct {
+	// RoomID is the rendered room the exit leaves from.
+	RoomID int32 `json:"roomId"`
+	// Direction is the exit direction (mapparser.ExitNorth and so on), or
+	// -1 for a special exit.
+	Direction int `json:"direction"`
+	// Command is the special exit command, empty for standard exits.
+	Command string `json:"command,omitempty"`
+
+	DestRoomID int32 `json:"destRoomId"`
+	DestAreaID int32 `json:"destAreaId"`
+	DestX      int32 `json:"destX"`
+	DestY      int32 `json:"destY"`
+	DestZ      int32 `json:"destZ"`
+	// OtherArea is set when the destination lies in another area.
+	OtherArea bool `json:"otherArea,omitempty"`
+
+	// ScreenX and ScreenY locate the center of the source room in the
+	// image.
+	ScreenX int `json:"screenX"`
+	ScreenY int `json:"screenY"`
+}
+
+// collectEdgeExits lists the exits of rooms leading to rooms not in
+// visible, sorted by room, direction and command.
+func (r *Renderer) collectEdgeExits(rooms []*mapparser.MudletRoom, visible map[int32]*mapparser.MudletRoom,
+	centerX, centerY int32, halfWidth, halfHeight, spacing int) []EdgeExit {
+	var edges []EdgeExit
+	add := func(room *mapparser.MudletRoom, dir int, cmd string, destID int32) {
+		dest := r.mapData.GetRoom(destID)
+		if dest == nil || visible[destID] != nil {
+			return
+		}
+		x, y := r.roomToScreen(room, centerX, centerY, halfWidth, halfHeight, spacing)
+		edges = append(edges, EdgeExit{
+			RoomID:     room.ID,
+			Direction:  dir,
+			Command:    cmd,
+			DestRoomID: destID,
+			DestAreaID: dest.Area,
+			DestX:      dest.X,
+			DestY:      dest.Y,
+			DestZ:      dest.Z,
+			OtherArea:  dest.Area != room.Area,
+			ScreenX:    x,
+			ScreenY:    y,
+		})
+	}
+	for _, room := range rooms {
+		for dir, dest := range room.Exits {
+			if dest != mapparser.NoExit {
+				add(room, dir, "", dest)
+			}
+		}
+		for cmd, dest := range room.SpecialExits {
+			add(room, -1, cmd, dest)
+		}
+	}
+	slices.SortFunc(edges, func(a, b EdgeExit) int {
+		return cmp.Or(cmp.Compare(a.RoomID, b.RoomID), cmp.Compare(a.Direction, b.Direction),
+			cmp.Compare(a.Command, b.Command))
+	})
+	return edges
+}
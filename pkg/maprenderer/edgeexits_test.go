@@ -0,0 +1,55 @@
+package maprenderer
+
+import (
+	"testing"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+func TestEdgeExits(t *testing.T) {
+	m := testGridMap(9)
+	m.Areas[2] = mapparser.NewMudletArea(2, "Other")
+	far := mapparser.NewMudletRoom(100)
+	far.Area = 2
+	m.Rooms[far.ID] = far
+	center := m.Rooms[41] // (4, 4)
+	center.SpecialExits["enter portal"] = far.ID
+
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 120, 120
+	r := NewRenderer(cfg)
+	r.SetMap(m)
+	result, err := r.RenderFragment(center.ID)
+	if err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+
+	// A 5x5 view of the grid has five exits leaving through each side
+	sides := make(map[int]int)
+	var portal *EdgeExit
+	for i, e := range result.EdgeExits {
+		if e.Direction == -1 {
+			portal = &result.EdgeExits[i]
+			continue
+		}
+		sides[e.Direction]++
+		if e.OtherArea || e.DestAreaID != 1 {
+			t.Errorf("Expected grid exit within the area, got %+v", e)
+		}
+	}
+	for _, dir := range []int{mapparser.ExitNorth, mapparser.ExitEast, mapparser.ExitSouth, mapparser.ExitWest} {
+		if sides[dir] != 5 {
+			t.Errorf("Expected 5 edge exits to the %s, got %d", mapparser.ExitDirectionNames[dir], sides[dir])
+		}
+	}
+
+	if portal == nil {
+		t.Fatal("Expected the special exit to another area")
+	}
+	if portal.RoomID != center.ID || portal.Command != "enter portal" || portal.DestRoomID != far.ID || !portal.OtherArea {
+		t.Errorf("Unexpected portal edge exit %+v", portal)
+	}
+	if portal.ScreenX != 60 || portal.ScreenY != 60 {
+		t.Errorf("Expected portal at the image center, got (%d, %d)", portal.ScreenX, portal.ScreenY)
+	}
+}
@@ -0,0 +1,124 @@
+package maprenderer
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+// ghostAlpha is the opacity of the rooms of neighboring areas drawn by
+// Config.AreaGhosts.
+const ghostAlpha = 96
+
+// ghostOffsets are the screen offsets in rooms of the eight compass
+// directions, in exit order.
+var ghostOffsets = [8]image.Point{
+	{0, -1}, {1, -1}, {1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1},
+}
+
+// drawAreaGhosts draws, for each compass exit of rooms to another area,
+// the destination room one room past the exit and the rooms of its area
+// around it, faded, joined to the exit's room by a faded area exit line
+// and captioned with the area's name. Ghosts are left out where they would
+// cover a room in view, one of the places occupied; drawExits draws the
+// area exit stub instead.
+func (r *Renderer) drawAreaGhosts(img *image.RGBA, rooms []*mapparser.MudletRoom, occupied map[image.Point]bool,
+	customEnvColors map[int32]color.RGBA, centerX, centerY int32, halfWidth, halfHeight, spacing int) {
+
+	type caption struct {
+		x, y int
+		text string
+	}
+	var captions []caption
+	layer := image.NewRGBA(img.Bounds())
+	drawn := make(map[image.Point]bool)
+	halfRoom := float64(r.config.RoomSize) / 2
+	for _, room := range rooms {
+		fromX, fromY := r.roomToScreen(room, centerX, centerY, halfWidth, halfHeight, spacing)
+		for dir, off := range ghostOffsets {
+			dest := r.mapData.GetRoom(room.Exits[dir])
+			if dest == nil || dest.Area == room.Area {
+				continue
+			}
+			at := ghostPlace(room, dir)
+			if occupied[at] {
+				continue
+			}
+			destX, destY := fromX+off.X*spacing, fromY+off.Y*spacing
+			if !drawn[at] {
+				captions = append(captions, caption{destX, destY, r.areaName(dest.Area)})
+			}
+
+			// The line runs between the room edges, as exit lines do
+			nx, ny := float64(off.X), float64(off.Y)
+			if off.X != 0 && off.Y != 0 {
+				nx, ny = nx*0.707, ny*0.707
+			}
+			r.drawExitLine(layer, fromX+int(nx*halfRoom), fromY+int(ny*halfRoom),
+				destX-int(nx*halfRoom), destY-int(ny*halfRoom), areaExitColor)
+
+			for _, ghost := range r.ghostRing(dest) {
+				dx, dy := int(ghost.X-dest.X), int(ghost.Y-dest.Y)
+				p := at.Add(image.Pt(dx, dy))
+				if occupied[p] || drawn[p] {
+					continue
+				}
+				drawn[p] = true
+				r.drawRoomShape(layer, destX+dx*spacing, destY-dy*spacing, r.getEnvColor(ghost.Environment, customEnvColors))
+			}
+		}
+	}
+
+	mask := image.NewUniform(color.Alpha{A: ghostAlpha})
+	draw.DrawMask(img, img.Bounds(), layer, img.Bounds().Min, mask, image.Point{}, draw.Over)
+	for _, c := range captions {
+		r.drawSmallLabel(img, c.x, c.y, c.text, r.config.TextColor)
+	}
+}
+
+// areaName returns the name of area id, or "Area <id>" if it has none.
+func (r *Renderer) areaName(id int32) string {
+	if area := r.mapData.GetArea(id); area != nil && area.Name != "" {
+		return area.Name
+	}
+	return fmt.Sprintf("Area %d", id)
+}
+
+// roomPlaces returns the map places of rooms on one level.
+func roomPlaces(rooms []*mapparser.MudletRoom) map[image.Point]bool {
+	places := make(map[image.Point]bool, len(rooms))
+	for _, room := range rooms {
+		places[image.Pt(int(room.X), int(room.Y))] = true
+	}
+	return places
+}
+
+// ghostPlace returns the map place next to room in compass direction dir,
+// where the ghost of the room its exit leads to is drawn.
+func ghostPlace(room *mapparser.MudletRoom, dir int) image.Point {
+	// Screen offsets grow southward, map Y northward
+	off := ghostOffsets[dir]
+	return image.Pt(int(room.X)+off.X, int(room.Y)-off.Y)
+}
+
+// ghostRing returns dest and the rooms of its area on its level in the
+// eight places around it.
+func (r *Renderer) ghostRing(dest *mapparser.MudletRoom) []*mapparser.MudletRoom {
+	ring := []*mapparser.MudletRoom{dest}
+	area := r.mapData.GetArea(dest.Area)
+	if area == nil {
+		return ring
+	}
+	for _, id := range area.Rooms {
+		room := r.mapData.GetRoom(int32(id))
+		if room == nil || room.ID == dest.ID || room.Area != dest.Area || room.Z != dest.Z ||
+			abs32(room.X-dest.X) > 1 || abs32(room.Y-dest.Y) > 1 {
+			continue
+		}
+		ring = append(ring, room)
+	}
+	return ring
+}
@@ -0,0 +1,83 @@
+package maprenderer
+
+import (
+	"image"
+	"testing"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+func TestAreaGhosts(t *testing.T) {
+	m := testGridMap(3)
+	m.Areas[2] = mapparser.NewMudletArea(2, "Beyond")
+	for i, x := range []int32{0, 1} {
+		room := mapparser.NewMudletRoom(100 + int32(i))
+		room.Area = 2
+		room.X = x
+		room.Environment = 2
+		m.Rooms[room.ID] = room
+		m.Areas[2].Rooms = append(m.Areas[2].Rooms, uint32(room.ID))
+	}
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 200, 200
+	r := NewRenderer(cfg)
+	r.SetMap(m)
+	render := func(ghosts bool) *image.RGBA {
+		t.Helper()
+		cfg.AreaGhosts = ghosts
+		result, err := r.RenderAt(1, 1, 1, 0)
+		if err != nil {
+			t.Fatalf("RenderAt failed: %v", err)
+		}
+		return result.Image
+	}
+
+	// Room 5 at (1, 1) leads north into area 2, but room 8 takes the
+	// place its ghost would have: the stub stays
+	m.Rooms[5].Exits[mapparser.ExitNorth] = 100
+	if !imagesEqual(&RenderResult{Image: render(true)}, &RenderResult{Image: render(false)}) {
+		t.Error("Expected no ghost where a room in view is")
+	}
+	m.Rooms[5].Exits[mapparser.ExitNorth] = mapparser.NoExit
+
+	// Room 3 is drawn at (125, 125); its ghost east at (150, 125) and the
+	// room east of that at (175, 125)
+	m.Rooms[3].Exits[mapparser.ExitEast] = 100
+	plain, ghosts := render(false), render(true)
+	if !regionDiffers(ghosts, plain, image.Rect(141, 116, 160, 135)) {
+		t.Error("Expected the ghost of the destination room")
+	}
+	if !regionDiffers(ghosts, plain, image.Rect(166, 116, 185, 135)) {
+		t.Error("Expected the ghost of the room next to the destination")
+	}
+	if regionDiffers(ghosts, plain, image.Rect(0, 0, 130, 200)) {
+		t.Error("Expected the rooms in view to be unchanged")
+	}
+}
+
+func TestLabelAreaExits(t *testing.T) {
+	m := testGridMap(3)
+	m.Areas[2] = mapparser.NewMudletArea(2, "Beyond")
+	room := mapparser.NewMudletRoom(100)
+	room.Area = 2
+	m.Rooms[100] = room
+	// Room 3 is drawn at (145, 125), its stub east ending 22 pixels out
+	m.Rooms[3].Exits[mapparser.ExitEast] = 100
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 240, 200
+	r := NewRenderer(cfg)
+	r.SetMap(m)
+	plain, err := r.RenderAt(1, 1, 1, 0)
+	if err != nil {
+		t.Fatalf("RenderAt failed: %v", err)
+	}
+	cfg.LabelAreaExits = true
+	labeled, err := r.RenderAt(1, 1, 1, 0)
+	if err != nil {
+		t.Fatalf("RenderAt failed: %v", err)
+	}
+	ink := inkBounds(labeled.Image, plain.Image, image.Rect(0, 0, 240, 200))
+	if ink.Empty() || ink.Min.X < 167 || ink.Min.Y > 125 || ink.Max.Y < 125 {
+		t.Errorf("Expected the area name right of the stub, ink %v", ink)
+	}
+}
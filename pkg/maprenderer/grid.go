@@ -0,0 +1,67 @@
+package maprenderer
+
+import (
+	"image"
+	"strconv"
+)
+
+// drawGrid draws faint lines through every room position on the visible
+// part of the map.
+func (r *Renderer) drawGrid(img *image.RGBA, halfWidth, halfHeight, spacing int) {
+	if spacing < 1 {
+		return
+	}
+	c := r.config.GridColor
+	top, bottom := max(img.Rect.Min.Y, 0), min(img.Rect.Max.Y, r.config.Height)
+	for x := halfWidth % spacing; x < r.config.Width; x += spacing {
+		for y := top; y < bottom; y++ {
+			blendPixel(img, x, y, c)
+		}
+	}
+	for y := halfHeight % spacing; y < r.config.Height; y += spacing {
+		blendSpan(img, 0, r.config.Width, y, c)
+	}
+}
+
+// drawAxes draws tick marks for every room column and row along the top and
+// left image edges, labelled with map coordinates where space allows.
+func (r *Renderer) drawAxes(img *image.RGBA, centerX, centerY int32, halfWidth, halfHeight, spacing int) {
+	if spacing < 1 {
+		return
+	}
+	c := r.config.AxisColor
+	u := r.config.ui(1)
+	tick := 4 * u
+	size := r.config.ui(uiTextSize)
+
+	// Label every step-th coordinate so neighbouring labels don't overlap.
+	widest := r.stringWidth(size, "-1234") + u
+	step := (widest + spacing - 1) / spacing
+
+	for x := halfWidth % spacing; x < r.config.Width; x += spacing {
+		coord := centerX + int32((x-halfWidth)/spacing)
+		for y := 0; y < tick; y++ {
+			for i := range u {
+				setPixelSafe(img, x+i, y, c)
+			}
+		}
+		// Leave the top-left corner to the row labels
+		if coord%int32(step) == 0 && x >= widest {
+			r.drawString(img, x, tick+5*u, size, strconv.Itoa(int(coord)), c)
+		}
+	}
+
+	for y := halfHeight % spacing; y < r.config.Height; y += spacing {
+		// Screen Y grows downward while map Y grows upward
+		coord := centerY - int32((y-halfHeight)/spacing)
+		for x := 0; x < tick; x++ {
+			for i := range u {
+				setPixelSafe(img, x, y+i, c)
+			}
+		}
+		if coord%int32(max(1, (7*u+spacing)/spacing)) == 0 && y >= 2*tick+8*u {
+			label := strconv.Itoa(int(coord))
+			r.drawString(img, tick+2*u+r.stringWidth(size, label)/2, y, size, label, c)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package maprenderer
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+// RenderFragmentInto renders the fragment centered on roomID, as
+// [Renderer.RenderFragment] does, over dst with its top-left corner at
+// the top-left of dst's bounds, for applications that composite the map
+// into frames of their own. The view keeps the configured size, in image
+// pixels after Config.PixelRatio; what falls outside dst is cut off. The
+// background is drawn over dst's pixels, so a translucent
+// Config.BackgroundColor shows them through.
+//
+// When dst is an [*image.RGBA] at least as large as the view, the map is
+// drawn straight into its pixels, and the result's Image shares them;
+// other images get the view drawn over them from a new image, which the
+// result's Image holds.
+func (r *Renderer) RenderFragmentInto(dst draw.Image, roomID int32) (*RenderResult, error) {
+	if r.mapData == nil {
+		return nil, fmt.Errorf("no map data loaded")
+	}
+	if dst == nil {
+		return nil, fmt.Errorf("no destination image")
+	}
+
+	centerRoom := r.mapData.GetRoom(roomID)
+	if centerRoom == nil {
+		return nil, fmt.Errorf("%w: %d", mapparser.ErrRoomNotFound, roomID)
+	}
+
+	cfg, _ := r.config.zoomed()
+	view := image.Rect(0, 0, cfg.Width, cfg.Height)
+	b := dst.Bounds()
+	rgba, ok := dst.(*image.RGBA)
+	if !ok || b.Dx() < view.Dx() || b.Dy() < view.Dy() {
+		result, err := r.RenderFragment(roomID)
+		if err != nil {
+			return nil, err
+		}
+		draw.Draw(dst, view.Add(b.Min), result.Image, image.Point{}, draw.Over)
+		return result, nil
+	}
+
+	// The view's image shares rgba's pixels from the corner of its bounds
+	w := *r
+	w.target = &image.RGBA{Pix: rgba.Pix[rgba.PixOffset(b.Min.X, b.Min.Y):], Stride: rgba.Stride, Rect: view}
+	result, err := w.render(centerRoom.Area, centerRoom.X, centerRoom.Y, centerRoom.Z, true)
+	if err != nil {
+		return nil, err
+	}
+	result.CenterRoom = roomID
+	return result, nil
+}
@@ -0,0 +1,62 @@
+package maprenderer
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestRenderFragmentInto(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 200, 150
+	r := NewRenderer(cfg)
+	r.SetMap(testGridMap(3))
+	want, err := r.RenderFragment(5)
+	if err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+
+	red := color.RGBA{R: 255, A: 255}
+	frame := image.NewRGBA(image.Rect(-10, 20, 250, 200))
+	draw.Draw(frame, frame.Bounds(), image.NewUniform(red), image.Point{}, draw.Src)
+	result, err := r.RenderFragmentInto(frame, 5)
+	if err != nil {
+		t.Fatalf("RenderFragmentInto failed: %v", err)
+	}
+	if result.CenterRoom != 5 || result.RoomsDrawn != want.RoomsDrawn {
+		t.Errorf("Expected room 5 with %d rooms, got room %d with %d", want.RoomsDrawn, result.CenterRoom, result.RoomsDrawn)
+	}
+	if &result.Image.Pix[0] != &frame.Pix[frame.PixOffset(-10, 20)] {
+		t.Error("Expected the result to share the frame's pixels")
+	}
+	for y := 0; y < 150; y++ {
+		for x := 0; x < 200; x++ {
+			if got := frame.RGBAAt(x-10, y+20); got != want.Image.RGBAAt(x, y) {
+				t.Fatalf("Pixel (%d, %d) differs: got %v, want %v", x, y, got, want.Image.RGBAAt(x, y))
+			}
+		}
+	}
+	if frame.RGBAAt(190, 20) != red || frame.RGBAAt(-10, 170) != red {
+		t.Error("Expected the frame outside the view unchanged")
+	}
+
+	// Other images get the view drawn over them
+	other := image.NewNRGBA(image.Rect(0, 0, 200, 150))
+	if _, err := r.RenderFragmentInto(other, 5); err != nil {
+		t.Fatalf("RenderFragmentInto failed: %v", err)
+	}
+	if got := color.RGBAModel.Convert(other.At(100, 75)); got != want.Image.RGBAAt(100, 75) {
+		t.Errorf("Expected the center room drawn, got %v", got)
+	}
+
+	// A transparent background leaves the frame showing
+	cfg.BackgroundColor = color.RGBA{}
+	draw.Draw(frame, frame.Bounds(), image.NewUniform(red), image.Point{}, draw.Src)
+	if _, err := r.RenderFragmentInto(frame, 5); err != nil {
+		t.Fatalf("RenderFragmentInto failed: %v", err)
+	}
+	if got := frame.RGBAAt(-10, 20); got != red {
+		t.Errorf("Expected the frame under the transparent background, got %v", got)
+	}
+}
@@ -0,0 +1,56 @@
+package maprenderer
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+// drawTextLabel draws the text of a label on its background color, sized
+// to fill rect. Each line of the text is centered.
+func (r *Renderer) drawTextLabel(img *image.RGBA, lbl *mapparser.MudletLabel, rect image.Rectangle) {
+	br, bg, bb, ba := lbl.BgColor.ToRGBA()
+	draw.Draw(img, rect, &image.Uniform{color.RGBA{R: br, G: bg, B: bb, A: ba}}, image.Point{}, draw.Over)
+	fr, fg, fb, fa := lbl.FgColor.ToRGBA()
+	center := rect.Min.Add(rect.Max).Div(2)
+	r.drawTextLines(img, center.X, center.Y, rect.Dx()*9/10, rect.Dy()*9/10,
+		strings.Split(lbl.Text, "\n"), color.RGBA{R: fr, G: fg, B: fb, A: fa})
+}
+
+// drawTextLines draws lines of text one under another, each centered, as
+// large as the block fits in a box of maxW by maxH pixels centered at
+// (cx, cy).
+func (r *Renderer) drawTextLines(img *image.RGBA, cx, cy, maxW, maxH int, lines []string, c color.RGBA) {
+	if len(lines) == 1 {
+		r.drawText(img, cx, cy, maxW, maxH, lines[0], c)
+		return
+	}
+	var face font.Face
+	var lineH int
+	for size := max(maxH/len(lines), minTextSize); size >= minTextSize; size-- {
+		if face = r.face(size); face == nil {
+			return
+		}
+		m := face.Metrics()
+		lineH = (m.Ascent + m.Descent).Ceil()
+		widest := 0
+		for _, line := range lines {
+			widest = max(widest, font.MeasureString(face, line).Ceil())
+		}
+		if widest <= maxW && lineH*len(lines) <= maxH {
+			break
+		}
+	}
+	d := font.Drawer{Dst: img, Src: image.NewUniform(c), Face: face}
+	top := cy - lineH*len(lines)/2 + face.Metrics().Ascent.Ceil()
+	for i, line := range lines {
+		d.Dot = fixed.P(cx-font.MeasureString(face, line).Ceil()/2, top+i*lineH)
+		d.DrawString(line)
+	}
+}
@@ -0,0 +1,80 @@
+package maprenderer
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+func TestTextLabel(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 200, 200
+	r := NewRenderer(cfg)
+	m := testGridMap(1)
+	r.SetMap(m)
+	plain, err := r.RenderAt(1, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("RenderAt failed: %v", err)
+	}
+
+	// A 4x1 label whose top-left corner is at (-2, 3): pixels 50..150, 25..50
+	m.Labels[1] = []*mapparser.MudletLabel{{
+		ID: 1, Text: "Rynek", Pos: mapparser.Vector3D{X: -2, Y: 3}, Width: 4, Height: 1,
+		FgColor: mapparser.Color{Red: 0xFFFF, Green: 0xFFFF, Alpha: 0xFFFF},
+	}}
+	res, err := r.RenderAt(1, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("RenderAt failed: %v", err)
+	}
+	ink := inkBounds(res.Image, plain.Image, image.Rect(0, 0, 200, 200))
+	if ink.Empty() || !ink.In(image.Rect(50, 25, 150, 50)) {
+		t.Errorf("label ink %v, want inside (50,25)-(150,50)", ink)
+	}
+}
+
+func TestTextLabelFallback(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 200, 200
+	r := NewRenderer(cfg)
+	m := testGridMap(1)
+	r.SetMap(m)
+
+	label := &mapparser.MudletLabel{
+		ID: 1, Text: "Rynek", Pos: mapparser.Vector3D{X: -2, Y: 3}, Width: 4, Height: 2,
+		FgColor: mapparser.Color{Red: 0xFFFF, Green: 0xFFFF, Alpha: 0xFFFF},
+		BgColor: mapparser.Color{Blue: 0xFFFF, Alpha: 0xFFFF},
+		Pixmap:  []byte("not a PNG"),
+	}
+	m.Labels[1] = []*mapparser.MudletLabel{label}
+	oneLine, err := r.RenderAt(1, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("RenderAt failed: %v", err)
+	}
+	// The label covers pixels 50..150, 25..75 in its background color
+	if got := oneLine.Image.RGBAAt(52, 27); got != (color.RGBA{B: 255, A: 255}) {
+		t.Errorf("Expected the label background for an undecodable pixmap, got %v", got)
+	}
+
+	label.Text = "Rynek\nGłówny"
+	twoLines, err := r.RenderAt(1, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("RenderAt failed: %v", err)
+	}
+	yellow := func(res *RenderResult) image.Rectangle {
+		var ink image.Rectangle
+		for y := 25; y < 75; y++ {
+			for x := 50; x < 150; x++ {
+				if c := res.Image.RGBAAt(x, y); c.R > 128 && c.G > 128 {
+					ink = ink.Union(image.Rect(x, y, x+1, y+1))
+				}
+			}
+		}
+		return ink
+	}
+	one, two := yellow(oneLine), yellow(twoLines)
+	if one.Empty() || two.Dy() <= one.Dy() || two.Dy() > 50 {
+		t.Errorf("Expected two lines of text taller than one within the label, got %v and %v", two, one)
+	}
+}
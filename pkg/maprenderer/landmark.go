@@ -0,0 +1,78 @@
+package maprenderer
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+// RenderByName renders a fragment centered on a named landmark.
+//
+// The name is first looked up among label texts (case-insensitive; an exact
+// match is preferred over a substring match, then the lowest area and label
+// ID wins), then among area names, in which case the area centroid is used.
+// Returns an error if nothing matches.
+func (r *Renderer) RenderByName(name string) (*RenderResult, error) {
+	if r.mapData == nil {
+		return nil, fmt.Errorf("no map data loaded")
+	}
+	if match, ok := r.findLabel(name); ok {
+		return r.renderLabel(match)
+	}
+	if area := r.mapData.FindArea(name); area != nil {
+		return r.RenderAreaCenter(area.ID)
+	}
+	return nil, fmt.Errorf("no label or area named %q", name)
+}
+
+// RenderLabel renders a fragment centered on the label whose text matches
+// text, as resolved by [Renderer.RenderByName], ignoring area names.
+func (r *Renderer) RenderLabel(text string) (*RenderResult, error) {
+	if r.mapData == nil {
+		return nil, fmt.Errorf("no map data loaded")
+	}
+	match, ok := r.findLabel(text)
+	if !ok {
+		return nil, fmt.Errorf("no label matching %q", text)
+	}
+	return r.renderLabel(match)
+}
+
+// RenderAreaCenter renders a fragment centered on the centroid of an area's
+// most populated z-level (see [mapparser.MudletMap.AreaCentroid]).
+func (r *Renderer) RenderAreaCenter(areaID int32) (*RenderResult, error) {
+	if r.mapData == nil {
+		return nil, fmt.Errorf("no map data loaded")
+	}
+	center, ok := r.mapData.AreaCentroid(areaID)
+	if !ok {
+		return nil, fmt.Errorf("area %d has no rooms", areaID)
+	}
+	return r.RenderAt(areaID, roundCoord(center.X), roundCoord(center.Y), int32(center.Z))
+}
+
+// findLabel resolves text to a label, preferring exact matches.
+func (r *Renderer) findLabel(text string) (mapparser.LabelMatch, bool) {
+	matches := r.mapData.FindLabels(text)
+	if len(matches) == 0 {
+		return mapparser.LabelMatch{}, false
+	}
+	for _, m := range matches {
+		if strings.EqualFold(m.Label.Text, text) {
+			return m, true
+		}
+	}
+	return matches[0], true
+}
+
+func (r *Renderer) renderLabel(match mapparser.LabelMatch) (*RenderResult, error) {
+	x, y := match.Label.Center()
+	return r.RenderAt(match.AreaID, roundCoord(x), roundCoord(y), int32(match.Label.Pos.Z))
+}
+
+// roundCoord rounds a map coordinate to the nearest grid position.
+func roundCoord(v float64) int32 {
+	return int32(math.Round(v))
+}
@@ -0,0 +1,59 @@
+package maprenderer
+
+import (
+	"testing"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+func TestRenderByName(t *testing.T) {
+	m := testGridMap(5)
+	m.Labels[1] = []*mapparser.MudletLabel{
+		{ID: 1, Text: "Rynek Główny", Pos: mapparser.Vector3D{X: 0, Y: 4}, Width: 2, Height: 2},
+		{ID: 2, Text: "Rynek", Pos: mapparser.Vector3D{X: 3, Y: 2}, Width: 2, Height: 2},
+	}
+	r := NewRenderer(nil)
+	r.SetMap(m)
+
+	// Exact match wins: label 2 is centered at (4, 1)
+	want, err := r.RenderAt(1, 4, 1, 0)
+	if err != nil {
+		t.Fatalf("RenderAt failed: %v", err)
+	}
+	got, err := r.RenderByName("rynek")
+	if err != nil {
+		t.Fatalf("RenderByName failed: %v", err)
+	}
+	if got.CenterRoom != 0 || got.AreaID != 1 || !imagesEqual(got, want) {
+		t.Error("Expected RenderByName to center on the exact label match")
+	}
+
+	// Area names fall back to the centroid, (2, 2) for a 5x5 grid
+	want, _ = r.RenderAt(1, 2, 2, 0)
+	got, err = r.RenderByName("test area")
+	if err != nil {
+		t.Fatalf("RenderByName with area name failed: %v", err)
+	}
+	if !imagesEqual(got, want) {
+		t.Error("Expected RenderByName to center on the area centroid")
+	}
+
+	if _, err := r.RenderByName("Nowhere"); err == nil {
+		t.Error("Expected error for unknown name")
+	}
+	if _, err := r.RenderAreaCenter(9); err == nil {
+		t.Error("Expected error for empty area")
+	}
+}
+
+func imagesEqual(a, b *RenderResult) bool {
+	if a.Image.Bounds() != b.Image.Bounds() {
+		return false
+	}
+	for i := range a.Image.Pix {
+		if a.Image.Pix[i] != b.Image.Pix[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,85 @@
+package maprenderer
+
+import (
+	"image"
+	"image/color"
+)
+
+// legendEntry is one row of the map legend: a sample glyph and its caption.
+type legendEntry struct {
+	caption string
+	draw    func(r *Renderer, img *image.RGBA, x1, x2, y int)
+}
+
+// legendEntries lists the map markings explained by the legend, drawn with
+// the same helpers and colors as the map itself.
+var legendEntries = []legendEntry{
+	{"OPEN DOOR", func(r *Renderer, img *image.RGBA, x1, x2, y int) {
+		r.drawLegendDoor(img, x1, x2, y, doorOpenColor)
+	}},
+	{"CLOSED DOOR", func(r *Renderer, img *image.RGBA, x1, x2, y int) {
+		r.drawLegendDoor(img, x1, x2, y, doorClosedColor)
+	}},
+	{"LOCKED DOOR", func(r *Renderer, img *image.RGBA, x1, x2, y int) {
+		r.drawLegendDoor(img, x1, x2, y, doorLockedColor)
+	}},
+	{"ONE-WAY EXIT", func(r *Renderer, img *image.RGBA, x1, x2, y int) {
+		r.drawOneWayExit(img, x1, y, x2, y, 1, 0, r.config.OneWayExits.color(), float64(r.config.ui(1)))
+	}},
+	{"UNEXPLORED EXIT", func(r *Renderer, img *image.RGBA, x1, x2, y int) {
+		r.drawLegendLine(img, x1, x2, y, r.config.ExitColor)
+		r.drawFilledCircle(img, x2, y, r.config.ui(2), r.config.ExitColor)
+	}},
+	{"EXIT TO AREA", func(r *Renderer, img *image.RGBA, x1, x2, y int) {
+		r.drawLegendLine(img, x1, x2, y, areaExitColor)
+		r.drawArrowHead(img, x2, y, 1, 0, areaExitColor, float64(r.config.ui(1)))
+	}},
+}
+
+// drawLegendDoor draws an exit line with a door mark in the middle.
+func (r *Renderer) drawLegendDoor(img *image.RGBA, x1, x2, y int, c color.RGBA) {
+	r.drawLegendLine(img, x1, x2, y, r.config.ExitColor)
+	r.drawDoorMark(img, (x1+x2)/2, y, r.config.ui(3), c)
+}
+
+// drawLegendLine draws a sample exit line, as thick as the legend is
+// enlarged.
+func (r *Renderer) drawLegendLine(img *image.RGBA, x1, x2, y int, c color.RGBA) {
+	u := r.config.ui(1)
+	for i := range u {
+		r.drawLine(img, x1, y-u/2+i, x2, y-u/2+i, c)
+	}
+}
+
+// drawLegend draws a small panel in the bottom-left corner explaining door
+// colors and exit markings.
+func (r *Renderer) drawLegend(img *image.RGBA) {
+	u := r.config.ui(1)
+	padding, rowH, sampleW, gap := 6*u, 12*u, 24*u, 6*u
+	size := r.config.ui(uiTextSize)
+
+	widest := 0
+	for _, e := range legendEntries {
+		widest = max(widest, r.stringWidth(size, e.caption))
+	}
+	w := padding + sampleW + gap + widest + padding
+	h := padding + len(legendEntries)*rowH + padding - (rowH - 7*u)
+	x := padding
+	y := r.config.Height - padding - h
+
+	panel := r.config.BackgroundColor
+	panel.A = 220
+	r.drawFilledRect(img, x, y, w, h, panel)
+	for i := range u {
+		r.drawRectOutline(img, x+i, y+i, w-2*i, h-2*i, r.config.BorderColor)
+	}
+
+	for i, e := range legendEntries {
+		cy := y + padding + 3*u + i*rowH
+		sx := x + padding
+		e.draw(r, img, sx, sx+sampleW, cy)
+
+		textX := sx + sampleW + gap + r.stringWidth(size, e.caption)/2
+		r.drawString(img, textX, cy, size, e.caption, r.config.TextColor)
+	}
+}
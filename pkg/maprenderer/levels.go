@@ -0,0 +1,111 @@
+package maprenderer
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"maps"
+	"math"
+	"slices"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// levelCaptionSize is the font size in pixels of the level captions of
+// [Renderer.RenderAreaLevels].
+const levelCaptionSize = 12
+
+// RenderAreaLevels renders every z-level of an area with rooms side by side
+// in one image of the configured Width and Height: a grid of cells, as
+// close to square as the level count allows, in ascending z order from the
+// top left, each with a caption naming its level. Every level is centered
+// on its own bounding box and drawn at one shared room spacing, scaled
+// down as for [Renderer.RenderArea] until the largest level fits its cell,
+// so that the levels compare at the same scale. The result's ZLevel is the
+// lowest level; since each cell has its own center, [Renderer.RoomAt] does
+// not apply to it.
+func (r *Renderer) RenderAreaLevels(areaID int32) (*RenderResult, error) {
+	if r.mapData == nil {
+		return nil, fmt.Errorf("no map data loaded")
+	}
+	area := r.mapData.GetArea(areaID)
+	if area == nil {
+		return nil, fmt.Errorf("area %d not found", areaID)
+	}
+	byZ := areaLevels(r.mapData)[areaID]
+	if len(byZ) == 0 {
+		return nil, fmt.Errorf("area %d has no rooms", areaID)
+	}
+	zs := slices.Sorted(maps.Keys(byZ))
+
+	cfg, textScale := r.config.zoomed()
+	cols := int(math.Ceil(math.Sqrt(float64(len(zs)))))
+	rows := (len(zs) + cols - 1) / cols
+	cellW, cellH := cfg.Width/cols, cfg.Height/rows
+
+	text, err := cfg.loadTextFont(r.fonts)
+	if err != nil {
+		return nil, err
+	}
+	face, err := text.face(cfg.ui(levelCaptionSize))
+	if err != nil {
+		return nil, err
+	}
+	metrics := face.Metrics()
+	captionH := (metrics.Ascent + metrics.Descent).Ceil() + cfg.ui(4)
+
+	cell := levelConfig(cfg)
+	cell.Width, cell.Height = cellW, cellH-captionH
+	if cell.Width < 1 || cell.Height < 1 {
+		return nil, fmt.Errorf("image too small for %d levels", len(zs))
+	}
+	for _, z := range zs {
+		b := byZ[z].box
+		centerX, centerY := b.MinX+(b.MaxX-b.MinX)/2, b.MinY+(b.MaxY-b.MinY)/2
+		fitted := *cfg
+		fitted.Width, fitted.Height = cell.Width, cell.Height
+		if spacing, size := fitSpacing(&fitted, int(b.MaxX-centerX), int(b.MaxY-centerY)); spacing < cell.RoomSpacing {
+			cell.RoomSpacing, cell.RoomSize = spacing, size
+		}
+	}
+	// Each level is drawn on an image of its own
+	w := *r
+	w.config, w.textScale, w.target = &cell, textScale, nil
+
+	img := image.NewRGBA(image.Rect(0, 0, cfg.Width, cfg.Height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{cfg.BackgroundColor}, image.Point{}, draw.Src)
+
+	band := cfg.BorderColor
+	band.A = 80
+	src := image.NewUniform(cfg.TextColor)
+	roomsDrawn := 0
+	for i, z := range zs {
+		b := byZ[z].box
+		level, err := w.render(areaID, b.MinX+(b.MaxX-b.MinX)/2, b.MinY+(b.MaxY-b.MinY)/2, z, false)
+		if err != nil {
+			return nil, err
+		}
+		roomsDrawn += level.RoomsDrawn
+
+		at := image.Pt(i%cols*cellW, i/cols*cellH)
+		draw.Draw(img, level.Image.Bounds().Add(at.Add(image.Pt(0, captionH))), level.Image, image.Point{}, draw.Src)
+
+		r.drawFilledRect(img, at.X, at.Y, cellW, captionH, band)
+		caption := fmt.Sprintf("Level %d", z)
+		d := font.Drawer{Dst: img, Src: src, Face: face}
+		d.Dot = fixed.P(at.X+(cellW-d.MeasureString(caption).Ceil())/2, at.Y+cfg.ui(2)+metrics.Ascent.Ceil())
+		d.DrawString(caption)
+		r.drawRectOutline(img, at.X, at.Y, cellW, cellH, cfg.BorderColor)
+	}
+
+	return &RenderResult{
+		Image:       img,
+		AreaID:      areaID,
+		AreaName:    area.Name,
+		ZLevel:      zs[0],
+		RoomSpacing: cell.RoomSpacing,
+		RoomSize:    cell.RoomSize,
+		RoomsDrawn:  roomsDrawn,
+	}, nil
+}
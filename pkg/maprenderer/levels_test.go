@@ -0,0 +1,65 @@
+package maprenderer
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+func TestRenderAreaLevels(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 400, 400
+	r := NewRenderer(cfg)
+	m := testGridMap(3)
+	// Levels 1 and 2 hold one room each; the 3x3 level 0 is the largest
+	for z := int32(1); z <= 2; z++ {
+		room := mapparser.NewMudletRoom(100 + z)
+		room.Area, room.X, room.Y, room.Z, room.Environment = 1, 5, 5, z, 1
+		m.Rooms[room.ID] = room
+	}
+	r.SetMap(m)
+
+	result, err := r.RenderAreaLevels(1)
+	if err != nil {
+		t.Fatalf("RenderAreaLevels failed: %v", err)
+	}
+	if b := result.Image.Bounds(); b.Dx() != 400 || b.Dy() != 400 {
+		t.Errorf("Expected a 400x400 image, got %v", b)
+	}
+	if result.ZLevel != 0 || result.RoomsDrawn != 11 {
+		t.Errorf("Expected level 0 first and 11 rooms, got %d and %d", result.ZLevel, result.RoomsDrawn)
+	}
+	if result.RoomSpacing != cfg.RoomSpacing {
+		t.Errorf("Expected the levels to fit at the configured spacing, got %d", result.RoomSpacing)
+	}
+
+	// Three levels make a 2x2 grid of 200x200 cells: level 1's room is in
+	// the middle of the top-right cell's map, below the caption, and the
+	// bottom-right cell is empty.
+	blank := cfg.BackgroundColor
+	cell := func(x, y int) image.Rectangle { return image.Rect(x+1, y+1, x+199, y+199) }
+	room := result.Image.RGBAAt(300, 100)
+	if room == blank {
+		t.Error("Expected level 1's room in the top-right cell")
+	}
+	if !regionDiffers(result.Image, uniformImage(400, 400, blank), image.Rect(210, 1, 390, 10)) {
+		t.Error("Expected a caption at the top of the cell")
+	}
+	if regionDiffers(result.Image, uniformImage(400, 400, blank), cell(200, 200)) {
+		t.Error("Expected the last cell empty")
+	}
+
+	if _, err := r.RenderAreaLevels(9); err == nil {
+		t.Error("Expected an error for a missing area")
+	}
+}
+
+func uniformImage(w, h int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+	return img
+}
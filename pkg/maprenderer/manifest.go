@@ -0,0 +1,210 @@
+package maprenderer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/assets"
+)
+
+// ManifestSuffix is appended to an image path to name its manifest file.
+const ManifestSuffix = ".manifest.json"
+
+// modulePath is the Go module path of this project, used to look up the
+// package version from build information.
+const modulePath = "github.com/szydell/mudlet-mapsnap"
+
+// RenderManifest records everything needed to verify that an image was
+// produced from a given map file with a given configuration.
+//
+// Rendering is deterministic, so re-rendering the same map with the same
+// configuration and package version yields an image with the same SHA-256.
+type RenderManifest struct {
+	// Version is the mudlet-mapsnap package version that rendered the image.
+	Version string `json:"version"`
+
+	// MapFile is the base name of the source map file, if known.
+	MapFile string `json:"mapFile,omitempty"`
+	// MapSHA256 is the SHA-256 of the source map file bytes, if known.
+	MapSHA256 string `json:"mapSha256,omitempty"`
+	// ConfigSHA256 is the hash of the rendering configuration (see [Config.Hash]).
+	ConfigSHA256 string `json:"configSha256"`
+
+	// View parameters taken from the [RenderResult].
+	CenterRoom int32  `json:"centerRoom"`
+	AreaID     int32  `json:"areaId"`
+	ZLevel     int32  `json:"zLevel"`
+	Width      int    `json:"width"`
+	Height     int    `json:"height"`
+	Format     string `json:"format"`
+
+	// Output is the base name of the image file.
+	Output string `json:"output,omitempty"`
+	// OutputSHA256 is the SHA-256 of the encoded image bytes.
+	OutputSHA256 string `json:"outputSha256"`
+}
+
+// PackageVersion returns the version of this module as recorded in the
+// binary's build information, or "devel" if it is unavailable.
+func PackageVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "devel"
+	}
+	if info.Main.Path == modulePath && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			return dep.Version
+		}
+	}
+	return "devel"
+}
+
+// Hash returns a hex SHA-256 over the configuration's fields and, with
+// custom Assets, the font file rendering reads from them. The fields are
+// encoded as JSON without the file layout of [Config.MarshalJSON], so the
+// hash does not change with the file format. Two configurations with equal
+// hashes render identical images unless their [Overlay.Draw] callbacks,
+// which are left out, differ.
+func (c *Config) Hash() (string, error) {
+	h := sha256.New()
+	if err := json.NewEncoder(h).Encode((*configAlias)(c)); err != nil {
+		return "", fmt.Errorf("encoding config: %w", err)
+	}
+	if c.Assets != nil {
+		data, err := fs.ReadFile(c.Assets, assets.TextFontPath)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("hashing config assets: %w", err)
+		}
+		sum := sha256.Sum256(data)
+		fmt.Fprintf(h, "%s %t %x\n", assets.TextFontPath, err == nil, sum)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// NewRenderManifest builds a manifest for a rendered result whose encoded
+// image bytes are given. mapSHA256 may be empty if the source file is not
+// known; see [HashFile].
+func NewRenderManifest(cfg *Config, result *RenderResult, encoded []byte, format OutputFormat, mapSHA256 string) (*RenderManifest, error) {
+	if cfg == nil {
+		cfg = DefaultConfig()
+	}
+	if result == nil || result.Image == nil {
+		return nil, fmt.Errorf("nil render result")
+	}
+	cfgHash, err := cfg.Hash()
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(encoded)
+	return &RenderManifest{
+		Version:      PackageVersion(),
+		MapSHA256:    mapSHA256,
+		ConfigSHA256: cfgHash,
+		CenterRoom:   result.CenterRoom,
+		AreaID:       result.AreaID,
+		ZLevel:       result.ZLevel,
+		Width:        result.Image.Bounds().Dx(),
+		Height:       result.Image.Bounds().Dy(),
+		Format:       format.String(),
+		OutputSHA256: hex.EncodeToString(sum[:]),
+	}, nil
+}
+
+// Verify checks that the image bytes match the manifest's output hash.
+func (m *RenderManifest) Verify(encoded []byte) error {
+	sum := sha256.Sum256(encoded)
+	if got := hex.EncodeToString(sum[:]); got != m.OutputSHA256 {
+		return fmt.Errorf("output hash mismatch: manifest %s, image %s", m.OutputSHA256, got)
+	}
+	return nil
+}
+
+// WriteManifest writes the manifest as indented JSON to path.
+func WriteManifest(m *RenderManifest, path string) (err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating manifest file: %w", err)
+	}
+	defer func() {
+		if cerr := f.Close(); cerr != nil {
+			err = errors.Join(err, fmt.Errorf("closing manifest file: %w", cerr))
+		}
+	}()
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(m); err != nil {
+		return fmt.Errorf("encoding manifest: %w", err)
+	}
+	return nil
+}
+
+// WriteImageManifest hashes an image already saved at imagePath and the map
+// file it was rendered from, and writes the manifest next to the image as
+// imagePath + [ManifestSuffix]. mapPath may be empty if the map was not
+// loaded from a file.
+func WriteImageManifest(cfg *Config, result *RenderResult, imagePath, mapPath string) (*RenderManifest, error) {
+	encoded, err := os.ReadFile(imagePath)
+	if err != nil {
+		return nil, fmt.Errorf("reading image: %w", err)
+	}
+	var mapSum string
+	if mapPath != "" {
+		if mapSum, err = HashFile(mapPath); err != nil {
+			return nil, fmt.Errorf("hashing map file: %w", err)
+		}
+	}
+	m, err := NewRenderManifest(cfg, result, encoded, FormatFromPath(imagePath), mapSum)
+	if err != nil {
+		return nil, err
+	}
+	m.Output = filepath.Base(imagePath)
+	if mapPath != "" {
+		m.MapFile = filepath.Base(mapPath)
+	}
+	if err := WriteManifest(m, imagePath+ManifestSuffix); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ReadManifest reads a manifest previously written by [WriteManifest].
+func ReadManifest(path string) (*RenderManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest: %w", err)
+	}
+	var m RenderManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("decoding manifest: %w", err)
+	}
+	return &m, nil
+}
+
+// HashFile returns the hex SHA-256 of a file's contents.
+func HashFile(path string) (sum string, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("opening file: %w", err)
+	}
+	defer func() {
+		if cerr := f.Close(); cerr != nil {
+			err = errors.Join(err, fmt.Errorf("closing file: %w", cerr))
+		}
+	}()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("hashing file: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
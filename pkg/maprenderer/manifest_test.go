@@ -0,0 +1,104 @@
+package maprenderer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/assets"
+)
+
+func TestWriteImageManifest(t *testing.T) {
+	dir := t.TempDir()
+	mapPath := filepath.Join(dir, "test.map")
+	if err := os.WriteFile(mapPath, []byte("map bytes"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 120, 100
+	r := NewRenderer(cfg)
+	r.SetMap(testGridMap(3))
+
+	render := func(name string) *RenderManifest {
+		result, err := r.RenderFragment(5)
+		if err != nil {
+			t.Fatalf("RenderFragment failed: %v", err)
+		}
+		out := filepath.Join(dir, name)
+		if err := SaveImage(result.Image, out, nil); err != nil {
+			t.Fatalf("SaveImage failed: %v", err)
+		}
+		m, err := WriteImageManifest(cfg, result, out, mapPath)
+		if err != nil {
+			t.Fatalf("WriteImageManifest failed: %v", err)
+		}
+		return m
+	}
+
+	first := render("a.png")
+	second := render("b.png")
+
+	if first.OutputSHA256 != second.OutputSHA256 {
+		t.Error("Expected identical renders to have the same output hash")
+	}
+	if first.ConfigSHA256 == "" || first.MapSHA256 == "" {
+		t.Error("Expected config and map hashes to be set")
+	}
+	if first.MapFile != "test.map" || first.Output != "a.png" || first.Format != "png" {
+		t.Errorf("Unexpected manifest fields: %+v", first)
+	}
+	if first.CenterRoom != 5 || first.Width != 120 || first.Height != 100 {
+		t.Errorf("Unexpected view fields: %+v", first)
+	}
+
+	read, err := ReadManifest(filepath.Join(dir, "a.png"+ManifestSuffix))
+	if err != nil {
+		t.Fatalf("ReadManifest failed: %v", err)
+	}
+	if *read != *first {
+		t.Errorf("Round-tripped manifest differs: %+v vs %+v", read, first)
+	}
+
+	img, err := os.ReadFile(filepath.Join(dir, "a.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := read.Verify(img); err != nil {
+		t.Errorf("Verify failed on original image: %v", err)
+	}
+	img[len(img)-1] ^= 0xff
+	if err := read.Verify(img); err == nil {
+		t.Error("Expected Verify to fail on a modified image")
+	}
+}
+
+func TestConfigHash(t *testing.T) {
+	a, err := DefaultConfig().Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := DefaultConfig().Hash()
+	if a != b {
+		t.Error("Expected equal configs to hash equally")
+	}
+	cfg := DefaultConfig()
+	cfg.RoomRound = true
+	c, _ := cfg.Hash()
+	if a == c {
+		t.Error("Expected different configs to hash differently")
+	}
+
+	// Asset overrides count by the contents of the files rendering reads
+	cfg = DefaultConfig()
+	cfg.Assets = fstest.MapFS{assets.TextFontPath: {Data: []byte("one")}}
+	one, err := cfg.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Assets = fstest.MapFS{assets.TextFontPath: {Data: []byte("two")}}
+	if two, _ := cfg.Hash(); one == a || two == one {
+		t.Error("Expected asset overrides to change the hash")
+	}
+}
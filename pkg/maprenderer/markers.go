@@ -0,0 +1,125 @@
+package maprenderer
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+// MarkerStyle is how [Config.Markers] marks a room.
+type MarkerStyle int
+
+const (
+	// MarkerRing draws a ring around the room (default).
+	MarkerRing MarkerStyle = iota
+	// MarkerFill tints the room with the marker color; use a translucent
+	// color to keep the room's own color visible.
+	MarkerFill
+	// MarkerIcon draws the marker's Icon over the room, scaled to its size.
+	MarkerIcon
+	// MarkerTag prints the marker's Text on a plate in the marker color
+	// above the room.
+	MarkerTag
+)
+
+var markerStyles = []string{"ring", "fill", "icon", "tag"}
+
+// String returns the lowercase name of the style ("ring", "fill", "icon"
+// or "tag").
+func (s MarkerStyle) String() string {
+	if s >= 0 && int(s) < len(markerStyles) {
+		return markerStyles[s]
+	}
+	return fmt.Sprintf("MarkerStyle(%d)", int(s))
+}
+
+// ParseMarkerStyle returns the style named by s, as returned by
+// [MarkerStyle.String].
+func ParseMarkerStyle(s string) (MarkerStyle, error) {
+	for i, name := range markerStyles {
+		if s == name {
+			return MarkerStyle(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown marker style %q (want ring, fill, icon or tag)", s)
+}
+
+// Marker annotates a room of [Config.Markers], such as a quest target or
+// a player's position.
+type Marker struct {
+	Style MarkerStyle
+	// Color is the ring, tint or tag color. The zero value uses
+	// Config.PlayerRoomColor.
+	Color color.RGBA
+	// Icon is the image drawn by MarkerIcon.
+	Icon image.Image
+	// Text is the tag printed by MarkerTag.
+	Text string
+}
+
+// drawMarkers draws the markers of the given rooms, which are on the
+// rendered view.
+func (r *Renderer) drawMarkers(img *image.RGBA, rooms []*mapparser.MudletRoom, centerX, centerY int32, halfWidth, halfHeight, spacing int) {
+	size := r.config.RoomSize
+	half := size / 2
+	for _, room := range rooms {
+		marker, ok := r.config.Markers[room.ID]
+		if !ok {
+			continue
+		}
+		x, y := r.roomToScreen(room, centerX, centerY, halfWidth, halfHeight, spacing)
+		c := marker.Color
+		if c == (color.RGBA{}) {
+			c = r.config.PlayerRoomColor
+		}
+		switch marker.Style {
+		case MarkerFill:
+			if r.config.RoomRound {
+				for dy := -half; dy <= half; dy++ {
+					for dx := -half; dx <= half; dx++ {
+						if dx*dx+dy*dy <= half*half {
+							blendPixel(img, x+dx, y+dy, c)
+						}
+					}
+				}
+			} else {
+				blendRect(img, x-half, y-half, size, size, c)
+			}
+		case MarkerIcon:
+			if marker.Icon != nil {
+				r.drawScaled(img, image.Rect(x-half, y-half, x-half+size, y-half+size), marker.Icon)
+			}
+		case MarkerTag:
+			r.drawMarkerTag(img, x, y-half-roomNameGap, marker.Text, c)
+		default:
+			for i := range 2 {
+				r.drawCircleOutline(img, x, y, half+3+i, c)
+			}
+		}
+	}
+}
+
+// drawMarkerTag prints text on a plate of color c whose bottom edge is
+// centered at (x, bottom).
+func (r *Renderer) drawMarkerTag(img *image.RGBA, x, bottom int, text string, c color.RGBA) {
+	if text == "" {
+		return
+	}
+	face := r.face(max(r.config.RoomSize/2, 9))
+	if face == nil {
+		return
+	}
+	metrics := face.Metrics()
+	w := font.MeasureString(face, text).Ceil() + 4
+	h := (metrics.Ascent + metrics.Descent).Ceil() + 2
+	left, top := x-w/2, bottom-h
+	blendRect(img, left, top, w, h, c)
+	d := font.Drawer{Dst: img, Src: image.NewUniform(contrastColor(c)), Face: face,
+		Dot: fixed.P(left+2, top+1+metrics.Ascent.Ceil())}
+	d.DrawString(text)
+}
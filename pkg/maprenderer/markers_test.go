@@ -0,0 +1,83 @@
+package maprenderer
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestMarkers(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 200, 200
+	r := NewRenderer(cfg)
+	r.SetMap(testGridMap(3)) // room 5 at (100, 100), its neighbours 25 pixels away
+	plain, err := r.RenderAt(1, 1, 1, 0)
+	if err != nil {
+		t.Fatalf("RenderAt failed: %v", err)
+	}
+	room := image.Rect(90, 90, 110, 110)
+	render := func(m Marker) *image.RGBA {
+		t.Helper()
+		cfg.Markers = map[int32]Marker{5: m}
+		result, err := r.RenderAt(1, 1, 1, 0)
+		if err != nil {
+			t.Fatalf("RenderAt failed: %v", err)
+		}
+		return result.Image
+	}
+
+	red := color.RGBA{R: 255, A: 255}
+	ring := render(Marker{Color: red})
+	if ink := inkBounds(ring, plain.Image, image.Rect(0, 0, 200, 200)); ink != image.Rect(86, 86, 115, 115) {
+		t.Errorf("Expected a ring just around the room, ink %v", ink)
+	}
+	if ring.RGBAAt(100, 87) != red {
+		t.Errorf("Expected the ring in the marker color, got %v", ring.RGBAAt(100, 87))
+	}
+
+	fill := render(Marker{Style: MarkerFill, Color: color.RGBA{R: 255, A: 128}})
+	if ink := inkBounds(fill, plain.Image, image.Rect(0, 0, 200, 200)); !ink.In(room) || ink.Empty() {
+		t.Errorf("Expected the tint inside the room, ink %v", ink)
+	}
+
+	icon := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for i := range icon.Pix {
+		icon.Pix[i] = 0xFF
+	}
+	iconImg := render(Marker{Style: MarkerIcon, Icon: icon})
+	if c := iconImg.RGBAAt(95, 105); c != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("Expected the icon scaled over the room, got %v", c)
+	}
+
+	tag := render(Marker{Style: MarkerTag, Color: red, Text: "Boss"})
+	ink := inkBounds(tag, plain.Image, image.Rect(0, 0, 200, 200))
+	if ink.Empty() || ink.Max.Y > 88 || ink.Min.X > 100 || ink.Max.X < 100 {
+		t.Errorf("Expected the tag centered above the room, ink %v", ink)
+	}
+
+	// Markers on rooms of another level are not drawn
+	cfg.Markers = map[int32]Marker{5: {Color: red}}
+	other, err := r.RenderAt(1, 1, 1, 1)
+	if err != nil {
+		t.Fatalf("RenderAt failed: %v", err)
+	}
+	cfg.Markers = nil
+	otherPlain, err := r.RenderAt(1, 1, 1, 1)
+	if err != nil {
+		t.Fatalf("RenderAt failed: %v", err)
+	}
+	if !imagesEqual(other, otherPlain) {
+		t.Error("Expected no marker off the rendered level")
+	}
+}
+
+func TestParseMarkerStyle(t *testing.T) {
+	for _, s := range []MarkerStyle{MarkerRing, MarkerFill, MarkerIcon, MarkerTag} {
+		if got, err := ParseMarkerStyle(s.String()); err != nil || got != s {
+			t.Errorf("ParseMarkerStyle(%q) = %v, %v", s, got, err)
+		}
+	}
+	if _, err := ParseMarkerStyle("star"); err == nil {
+		t.Error("Expected an error for an unknown style")
+	}
+}
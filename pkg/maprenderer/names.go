@@ -0,0 +1,171 @@
+package maprenderer
+
+import (
+	"fmt"
+	"image"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+// RoomNamePlacement is where [Config.ShowRoomNames] prints a room's name.
+type RoomNamePlacement int
+
+const (
+	// RoomNameBelow centers the name under the room (default).
+	RoomNameBelow RoomNamePlacement = iota
+	// RoomNameAbove centers the name over the room.
+	RoomNameAbove
+	// RoomNameRight starts the name right of the room.
+	RoomNameRight
+)
+
+var roomNamePlacements = []string{"below", "above", "right"}
+
+// String returns the lowercase name of the placement ("below", "above" or
+// "right").
+func (p RoomNamePlacement) String() string {
+	if p >= 0 && int(p) < len(roomNamePlacements) {
+		return roomNamePlacements[p]
+	}
+	return fmt.Sprintf("RoomNamePlacement(%d)", int(p))
+}
+
+// MarshalText encodes the placement as its name, for configuration files.
+func (p RoomNamePlacement) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText decodes a placement name, as [ParseRoomNamePlacement].
+func (p *RoomNamePlacement) UnmarshalText(text []byte) error {
+	v, err := ParseRoomNamePlacement(string(text))
+	if err != nil {
+		return err
+	}
+	*p = v
+	return nil
+}
+
+// ParseRoomNamePlacement returns the placement named by s, as returned by
+// [RoomNamePlacement.String].
+func ParseRoomNamePlacement(s string) (RoomNamePlacement, error) {
+	for i, name := range roomNamePlacements {
+		if s == name {
+			return RoomNamePlacement(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown room name placement %q (want below, above or right)", s)
+}
+
+// roomNameGap is the distance in pixels between a room and its name.
+const roomNameGap = 2
+
+// roomName is a room name placed by placeRoomNames.
+type roomName struct {
+	text string
+	box  image.Rectangle
+}
+
+// placeRoomNames places the names of the given rooms next to them. Each
+// name goes to the configured placement, or the next free one in placement
+// order; a name that would overlap a room, another name or the image edge
+// at every placement is left out.
+func (r *Renderer) placeRoomNames(rooms []*mapparser.MudletRoom, centerX, centerY int32, halfWidth, halfHeight, spacing int) []roomName {
+	face := r.face(max(r.config.RoomSize/2, 9))
+	if face == nil {
+		return nil
+	}
+	metrics := face.Metrics()
+	lineH := (metrics.Ascent + metrics.Descent).Ceil()
+	half := r.config.RoomSize / 2
+	// Names are placed in the whole view, also when it is drawn in stripes
+	view := image.Rect(0, 0, r.config.Width, r.config.Height)
+
+	type placed struct {
+		room   *mapparser.MudletRoom
+		sx, sy int
+	}
+	var visible []placed
+	var taken []image.Rectangle
+	for _, room := range rooms {
+		sx, sy := r.roomToScreen(room, centerX, centerY, halfWidth, halfHeight, spacing)
+		rect := image.Rect(sx-half, sy-half, sx-half+r.config.RoomSize, sy-half+r.config.RoomSize)
+		if !rect.Overlaps(view) {
+			continue
+		}
+		visible = append(visible, placed{room, sx, sy})
+		taken = append(taken, rect)
+	}
+
+	var names []roomName
+	for _, v := range visible {
+		name := truncateName(v.room.Name, r.config.RoomNameMaxLen)
+		if name == "" {
+			continue
+		}
+		w := font.MeasureString(face, name).Ceil()
+		for i := range roomNamePlacements {
+			p := (r.config.RoomNamePlacement + RoomNamePlacement(i)) % RoomNamePlacement(len(roomNamePlacements))
+			box := roomNameBox(p, v.sx, v.sy, half, w, lineH)
+			if !box.In(view) || overlapsAny(box, taken) {
+				continue
+			}
+			names = append(names, roomName{name, box})
+			taken = append(taken, box)
+			break
+		}
+	}
+	return names
+}
+
+// drawRoomNames prints the names placed by placeRoomNames that reach img.
+func (r *Renderer) drawRoomNames(img *image.RGBA, names []roomName) {
+	face := r.face(max(r.config.RoomSize/2, 9))
+	if face == nil {
+		return
+	}
+	ascent := face.Metrics().Ascent.Ceil()
+	src := image.NewUniform(r.config.TextColor)
+	for _, n := range names {
+		// Glyphs may stray a little past the box
+		if !n.box.Inset(-2).Overlaps(img.Rect) {
+			continue
+		}
+		d := font.Drawer{Dst: img, Src: src, Face: face, Dot: fixed.P(n.box.Min.X, n.box.Min.Y+ascent)}
+		d.DrawString(n.text)
+	}
+}
+
+// roomNameBox returns the box of a name w by h pixels placed at p next to
+// a room centered at (sx, sy).
+func roomNameBox(p RoomNamePlacement, sx, sy, half, w, h int) image.Rectangle {
+	switch p {
+	case RoomNameAbove:
+		return image.Rect(sx-w/2, sy-half-roomNameGap-h, sx-w/2+w, sy-half-roomNameGap)
+	case RoomNameRight:
+		return image.Rect(sx+half+roomNameGap, sy-h/2, sx+half+roomNameGap+w, sy-h/2+h)
+	default:
+		return image.Rect(sx-w/2, sy+half+roomNameGap, sx-w/2+w, sy+half+roomNameGap+h)
+	}
+}
+
+func overlapsAny(box image.Rectangle, rects []image.Rectangle) bool {
+	for _, rect := range rects {
+		if box.Overlaps(rect) {
+			return true
+		}
+	}
+	return false
+}
+
+// truncateName shortens name to maxLen characters, the last being an
+// ellipsis. A maxLen of 0 or less leaves it whole.
+func truncateName(name string, maxLen int) string {
+	runes := []rune(name)
+	if maxLen <= 0 || len(runes) <= maxLen {
+		return name
+	}
+	return string(runes[:maxLen-1]) + "…"
+}
@@ -0,0 +1,124 @@
+package maprenderer
+
+import (
+	"image"
+	"testing"
+)
+
+func TestRoomNames(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 200, 200
+	r := NewRenderer(cfg)
+	m := testGridMap(2) // rooms 1 and 2 at (0, 0) and (1, 0), 25 pixels apart
+	delete(m.Rooms, 3)
+	delete(m.Rooms, 4)
+	m.Rooms[1].Name = "Rynek Główny"
+	m.Rooms[2].Name = "Sukiennice"
+	r.SetMap(m)
+	plain, err := r.RenderAt(1, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("RenderAt failed: %v", err)
+	}
+
+	cfg.ShowRoomNames = true
+	named, err := r.RenderAt(1, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("RenderAt failed: %v", err)
+	}
+	// Room 1 is drawn at (100, 100) and room 2 at (125, 100), both 20
+	// pixels wide: the first name goes below, the second would overlap it
+	// and goes above instead.
+	below := image.Rect(0, 111, 200, 200)
+	above := image.Rect(0, 0, 200, 90)
+	if ink := inkBounds(named.Image, plain.Image, below); ink.Empty() || ink.Min.X > 100 {
+		t.Errorf("Expected room 1's name below it, ink %v", ink)
+	}
+	if ink := inkBounds(named.Image, plain.Image, above); ink.Empty() || ink.Max.X < 125 {
+		t.Errorf("Expected room 2's name above it, ink %v", ink)
+	}
+	if inkBounds(named.Image, plain.Image, image.Rect(0, 90, 200, 111)) != (image.Rectangle{}) {
+		t.Error("Expected names to leave the rooms uncovered")
+	}
+
+	// Truncated names fit side by side below their rooms
+	cfg.RoomNameMaxLen = 2
+	short, err := r.RenderAt(1, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("RenderAt failed: %v", err)
+	}
+	if !inkBounds(short.Image, plain.Image, above).Empty() {
+		t.Error("Expected short names to fit below the rooms")
+	}
+
+	cfg.RoomNameMaxLen = 0
+	cfg.RoomNamePlacement = RoomNameRight
+	right, err := r.RenderAt(1, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("RenderAt failed: %v", err)
+	}
+	// Room 1's right side is taken by room 2, so it falls back to below
+	if ink := inkBounds(right.Image, plain.Image, image.Rect(136, 90, 200, 111)); ink.Empty() {
+		t.Error("Expected room 2's name right of it")
+	}
+	if ink := inkBounds(right.Image, plain.Image, below); ink.Empty() {
+		t.Error("Expected room 1's name to fall back below it")
+	}
+}
+
+func TestRoomNamesStacked(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 200, 200
+	cfg.ShowRoomNames = true
+	r := NewRenderer(cfg)
+	m := testGridMap(3)
+	// Stack rooms 6 and 8 on room 5, so their names compete for its places
+	for _, id := range []int32{6, 8} {
+		m.Rooms[id].X, m.Rooms[id].Y = m.Rooms[5].X, m.Rooms[5].Y
+	}
+	for id, name := range map[int32]string{5: "Rynek", 6: "Ratusz", 8: "Sukiennice"} {
+		m.Rooms[id].Name = name
+	}
+	r.SetMap(m)
+	first, err := r.RenderFragment(5)
+	if err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+	for range 20 {
+		res, err := r.RenderFragment(5)
+		if err != nil {
+			t.Fatalf("RenderFragment failed: %v", err)
+		}
+		if !imagesEqual(res, first) {
+			t.Fatal("Expected stacked room names placed the same way on every render")
+		}
+	}
+}
+
+func TestTruncateName(t *testing.T) {
+	tests := []struct {
+		name   string
+		maxLen int
+		want   string
+	}{
+		{"Rynek Główny", 0, "Rynek Główny"},
+		{"Rynek Główny", 12, "Rynek Główny"},
+		{"Rynek Główny", 8, "Rynek G…"},
+		{"Żółw", 2, "Ż…"},
+	}
+	for _, tt := range tests {
+		if got := truncateName(tt.name, tt.maxLen); got != tt.want {
+			t.Errorf("truncateName(%q, %d) = %q, want %q", tt.name, tt.maxLen, got, tt.want)
+		}
+	}
+}
+
+func TestParseRoomNamePlacement(t *testing.T) {
+	for _, p := range []RoomNamePlacement{RoomNameBelow, RoomNameAbove, RoomNameRight} {
+		if got, err := ParseRoomNamePlacement(p.String()); err != nil || got != p {
+			t.Errorf("ParseRoomNamePlacement(%q) = %v, %v", p, got, err)
+		}
+	}
+	if _, err := ParseRoomNamePlacement("left"); err == nil {
+		t.Error("Expected error for unknown placement")
+	}
+}
@@ -0,0 +1,111 @@
+package maprenderer
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+// OneWayExitStyle is how exits without an exit back are drawn, see
+// [Config.OneWayExits]. The zero value draws them as Mudlet does: dotted,
+// in translucent gray, with an arrowhead at the destination.
+type OneWayExitStyle struct {
+	// Plain draws one-way exits like the others, solid and without an
+	// arrowhead; special exits keep theirs.
+	Plain bool
+	// Color is the line and arrowhead color. The zero value uses a
+	// translucent gray.
+	Color color.RGBA
+	// Dash lists alternating on and off lengths in pen widths, so that
+	// the pattern grows with ExitWidth. Nil draws dots, 1 on and 3 off;
+	// {1, 0} draws a solid line.
+	Dash []int
+	// ArrowSize is the arrowhead length in pixels. Zero sizes it with the
+	// room as other arrowheads; a negative size leaves it out.
+	ArrowSize float64
+}
+
+// color returns the line color of one-way exits.
+func (s *OneWayExitStyle) color() color.RGBA {
+	if s.Color == (color.RGBA{}) {
+		return oneWayExitColor
+	}
+	return s.Color
+}
+
+// pattern returns the stroke pattern of one-way exit lines.
+func (s *OneWayExitStyle) pattern() func(step int) bool {
+	if s.Dash == nil {
+		return dotted
+	}
+	period := 0
+	for _, n := range s.Dash {
+		period += max(n, 0)
+	}
+	if period == 0 {
+		return nil
+	}
+	return func(step int) bool {
+		pos := step % period
+		for i, n := range s.Dash {
+			if pos < max(n, 0) {
+				return i%2 == 0
+			}
+			pos -= max(n, 0)
+		}
+		return false
+	}
+}
+
+// drawOneWayExit draws a one-way exit line from (x1, y1) to (x2, y2) in
+// color c with the arrowhead of Config.OneWayExits, pointing along
+// (nx, ny).
+func (r *Renderer) drawOneWayExit(img *image.RGBA, x1, y1, x2, y2 int, nx, ny float64, c color.RGBA, width float64) {
+	style := &r.config.OneWayExits
+	stroke(img, x1, y1, x2, y2, c, width, style.pattern(), 0)
+	r.drawOneWayArrow(img, x2, y2, nx, ny, c, width)
+}
+
+// drawOneWayArrow draws the arrowhead of Config.OneWayExits, if any.
+func (r *Renderer) drawOneWayArrow(img *image.RGBA, x, y int, nx, ny float64, c color.RGBA, width float64) {
+	switch size := r.config.OneWayExits.ArrowSize; {
+	case size < 0:
+	case size == 0:
+		r.drawArrowHead(img, x, y, nx, ny, c, width)
+	default:
+		r.drawArrowHeadSized(img, x, y, nx, ny, c, width, size)
+	}
+}
+
+// exitsInto is a reverse exit index: for each room, the set of rooms with
+// a standard or special exit into it.
+type exitsInto map[int32]map[int32]bool
+
+// indexExitsInto indexes the exits of rooms by destination.
+func indexExitsInto(rooms []*mapparser.MudletRoom) exitsInto {
+	index := make(exitsInto)
+	add := func(from, to int32) {
+		if to == mapparser.NoExit {
+			return
+		}
+		if index[to] == nil {
+			index[to] = make(map[int32]bool)
+		}
+		index[to][from] = true
+	}
+	for _, room := range rooms {
+		for _, dest := range room.Exits {
+			add(room.ID, dest)
+		}
+		for _, dest := range room.SpecialExits {
+			add(room.ID, dest)
+		}
+	}
+	return index
+}
+
+// leadsBack reports whether dest has any exit back to room.
+func (x exitsInto) leadsBack(room, dest *mapparser.MudletRoom) bool {
+	return x[room.ID][dest.ID]
+}
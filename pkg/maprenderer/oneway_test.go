@@ -0,0 +1,69 @@
+package maprenderer
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+func TestOneWayExitStyle(t *testing.T) {
+	m := testGridMap(3)
+	// Rooms 2 and 3 are drawn at (100, 125) and (125, 125); without the
+	// way back the exit east from room 2 is one-way
+	m.Rooms[3].Exits[mapparser.ExitWest] = mapparser.NoExit
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 200, 200
+	r := NewRenderer(cfg)
+	r.SetMap(m)
+	render := func() *image.RGBA {
+		t.Helper()
+		result, err := r.RenderAt(1, 1, 1, 0)
+		if err != nil {
+			t.Fatalf("RenderAt failed: %v", err)
+		}
+		return result.Image
+	}
+
+	red := color.RGBA{R: 255, A: 255}
+	cfg.OneWayExits = OneWayExitStyle{Color: red, Dash: []int{1, 0}}
+	solid := render()
+	if got := solid.RGBAAt(111, 125); got != red {
+		t.Errorf("Expected a solid red one-way exit, got %v", got)
+	}
+	cfg.OneWayExits.ArrowSize = -1
+	if !regionDiffers(render(), solid, image.Rect(110, 118, 115, 133)) {
+		t.Error("Expected no arrowhead with a negative arrow size")
+	}
+	cfg.OneWayExits.Plain = true
+	if got := render().RGBAAt(111, 125); got != cfg.ExitColor {
+		t.Errorf("Expected a plain exit in the exit color, got %v", got)
+	}
+
+	// A special exit is one-way unless its destination leads back in any
+	// way
+	cfg.OneWayExits = OneWayExitStyle{Color: red}
+	cfg.ShowSpecialExits = true
+	m.Rooms[1].SpecialExits = map[string]int32{"climb": 3}
+	if !hasColor(render(), red) {
+		t.Error("Expected a one-way special exit in the one-way color")
+	}
+	m.Rooms[3].SpecialExits = map[string]int32{"jump": 1}
+	m.Rooms[3].Exits[mapparser.ExitWest] = 2
+	if hasColor(render(), red) {
+		t.Error("Expected special exits both ways in the exit color")
+	}
+}
+
+func hasColor(img *image.RGBA, c color.RGBA) bool {
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if img.RGBAAt(x, y) == c {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -22,6 +22,18 @@ const (
 	FormatPNG
 )
 
+// String returns the lowercase name of the format ("webp" or "png").
+func (f OutputFormat) String() string {
+	switch f {
+	case FormatPNG:
+		return "png"
+	case FormatWEBP:
+		return "webp"
+	default:
+		return fmt.Sprintf("OutputFormat(%d)", int(f))
+	}
+}
+
 // OutputOptions configures the image encoding behavior.
 type OutputOptions struct {
 	// Format specifies the output image format.
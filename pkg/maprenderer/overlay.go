@@ -0,0 +1,58 @@
+package maprenderer
+
+import (
+	"image"
+	"image/draw"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+// Overlay is an item of [Config.Overlays], such as a point-of-interest pin
+// or a player avatar, composited over the map at a room or map position.
+type Overlay struct {
+	// Room anchors the overlay at the center of this room. 0 anchors it at
+	// Pos instead.
+	Room int32
+	// Area and Pos anchor the overlay at a map position, in room
+	// coordinates like label positions; Pos.Z is the level. An Area of 0
+	// shows it on every area.
+	Area int32
+	Pos  mapparser.Vector3D
+	// Icon is drawn at its own size, centered on the anchor moved by
+	// Offset; a pin whose tip is at the bottom middle uses an Offset of
+	// half its height up.
+	Icon   image.Image
+	Offset image.Point
+	// Draw, if set, is called with the anchor's position in the image
+	// after the icon is drawn. It is not part of [Config.Hash].
+	Draw func(img *image.RGBA, x, y int) `json:"-"`
+}
+
+// drawOverlays composites the overlays anchored on the rendered area and
+// level, in order.
+func (r *Renderer) drawOverlays(img *image.RGBA, areaID, centerZ, centerX, centerY int32, halfWidth, halfHeight, spacing int) {
+	for _, o := range r.config.Overlays {
+		var x, y int
+		if o.Room != 0 {
+			room := r.mapData.GetRoom(o.Room)
+			if room == nil || room.Area != areaID || room.Z != centerZ {
+				continue
+			}
+			x, y = r.roomToScreen(room, centerX, centerY, halfWidth, halfHeight, spacing)
+		} else {
+			if o.Area != 0 && o.Area != areaID || int32(o.Pos.Z) != centerZ {
+				continue
+			}
+			x = halfWidth + int((o.Pos.X-float64(centerX))*float64(spacing))
+			y = halfHeight - int((o.Pos.Y-float64(centerY))*float64(spacing))
+		}
+		if o.Icon != nil {
+			b := o.Icon.Bounds()
+			at := image.Pt(x+o.Offset.X-b.Dx()/2, y+o.Offset.Y-b.Dy()/2)
+			draw.Draw(img, image.Rectangle{Min: at, Max: at.Add(b.Size())}, o.Icon, b.Min, draw.Over)
+		}
+		if o.Draw != nil {
+			o.Draw(img, x, y)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package maprenderer
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+func TestOverlays(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 200, 200
+	r := NewRenderer(cfg)
+	r.SetMap(testGridMap(3)) // room 5 at (1, 1) is drawn at (100, 100)
+
+	pin := image.NewRGBA(image.Rect(0, 0, 6, 10))
+	red := color.RGBA{R: 255, A: 255}
+	for i := 0; i < len(pin.Pix); i += 4 {
+		copy(pin.Pix[i:], []uint8{255, 0, 0, 255})
+	}
+	var calls []image.Point
+	cfg.Overlays = []Overlay{
+		{Room: 5, Icon: pin, Offset: image.Pt(0, -5)},
+		{Area: 1, Pos: mapparser.Vector3D{X: 1.5, Y: 0}, Draw: func(img *image.RGBA, x, y int) {
+			calls = append(calls, image.Pt(x, y))
+			img.SetRGBA(x, y, red)
+		}},
+		{Area: 2, Pos: mapparser.Vector3D{X: 1, Y: 1}, Draw: func(*image.RGBA, int, int) {
+			t.Error("Expected no overlay from another area")
+		}},
+		{Room: 5, Pos: mapparser.Vector3D{Z: 1}, Draw: func(*image.RGBA, int, int) {}},
+	}
+	result, err := r.RenderAt(1, 1, 1, 0)
+	if err != nil {
+		t.Fatalf("RenderAt failed: %v", err)
+	}
+	// The pin's bottom middle sits on the room's center
+	if c := result.Image.RGBAAt(100, 99); c != red {
+		t.Errorf("Expected the pin above the room center, got %v", c)
+	}
+	if c := result.Image.RGBAAt(100, 101); c == red {
+		t.Error("Expected the pin to end at the room center")
+	}
+	if len(calls) != 1 || calls[0] != image.Pt(112, 125) {
+		t.Errorf("Expected one callback at (112, 125), got %v", calls)
+	}
+
+	// Overlays on another level are left out
+	calls = nil
+	if _, err := r.RenderAt(1, 1, 1, 1); err != nil {
+		t.Fatalf("RenderAt failed: %v", err)
+	}
+	if len(calls) != 0 {
+		t.Errorf("Expected no callbacks on level 1, got %v", calls)
+	}
+
+	if _, err := cfg.Hash(); err != nil {
+		t.Errorf("Expected overlays with callbacks to hash, got %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+package maprenderer
+
+import (
+	"cmp"
+	"image"
+	"math"
+	"runtime"
+	"sync"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+// parallelMinPixels is the image size from which Config.Parallelism 0
+// draws in stripes.
+const parallelMinPixels = 1 << 20
+
+// stripes returns the number of stripes to draw img in.
+func (r *Renderer) stripes(img *image.RGBA) int {
+	n := r.config.Parallelism
+	if n == 0 {
+		if img.Rect.Dx()*img.Rect.Dy() < parallelMinPixels {
+			return 1
+		}
+		n = runtime.GOMAXPROCS(0)
+	}
+	return max(min(n, img.Rect.Dy()), 1)
+}
+
+// drawStriped calls draw for horizontal stripes of img at once, each with
+// a sub-image sharing img's pixels and a copy of r with its own text
+// faces. Drawing clips to the stripe, so every pixel is drawn by one
+// goroutine in the order a single call would draw it; draw walks only the
+// rooms of a levelRooms that reach its stripe. A panic in any stripe is
+// raised again here, and a text error is kept on r.
+func (r *Renderer) drawStriped(img *image.RGBA, draw func(r *Renderer, stripe *image.RGBA)) {
+	n := r.stripes(img)
+	if n == 1 {
+		draw(r, img)
+		return
+	}
+
+	b := img.Rect
+	panics := make([]any, n)
+	workers := make([]Renderer, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		stripe := img.SubImage(image.Rect(b.Min.X, b.Min.Y+b.Dy()*i/n, b.Max.X, b.Min.Y+b.Dy()*(i+1)/n)).(*image.RGBA)
+		workers[i] = *r
+		workers[i].text = r.text.clone()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { panics[i] = recover() }()
+			draw(&workers[i], stripe)
+		}()
+	}
+	wg.Wait()
+	for i, p := range panics {
+		if p != nil {
+			panic(p)
+		}
+		r.text.err = cmp.Or(r.text.err, workers[i].text.err)
+	}
+}
+
+// levelRooms holds the rooms in view on one level with the indexes their
+// exits are drawn by, built once for all stripes.
+type levelRooms struct {
+	rooms  []*mapparser.MudletRoom
+	byID   map[int32]*mapparser.MudletRoom
+	into   exitsInto
+	places map[image.Point]bool
+
+	// spans are the screen rows each room's drawing reaches, set by
+	// Renderer.spanRooms when the view is drawn in stripes
+	spans []rowSpan
+}
+
+// rowSpan is a range of screen rows, both ends included.
+type rowSpan struct {
+	top, bottom int
+}
+
+func newLevelRooms(rooms []*mapparser.MudletRoom) *levelRooms {
+	l := &levelRooms{
+		rooms:  rooms,
+		byID:   make(map[int32]*mapparser.MudletRoom, len(rooms)),
+		into:   indexExitsInto(rooms),
+		places: roomPlaces(rooms),
+	}
+	for _, room := range rooms {
+		l.byID[room.ID] = room
+	}
+	return l
+}
+
+// in returns, in order, the rooms whose drawing reaches the rows of
+// stripe, or all rooms when the view is not drawn in stripes.
+func (l *levelRooms) in(stripe image.Rectangle) []*mapparser.MudletRoom {
+	if l.spans == nil {
+		return l.rooms
+	}
+	var rooms []*mapparser.MudletRoom
+	for i, room := range l.rooms {
+		if l.spans[i].bottom >= stripe.Min.Y && l.spans[i].top < stripe.Max.Y {
+			rooms = append(rooms, room)
+		}
+	}
+	return rooms
+}
+
+// spanRooms sets the screen rows each room of l draws on: the room and
+// every room in view its exits, special exits and custom lines lead to,
+// with the bows of special exits and smoothed lines, the offsets of exit
+// bundles, and a margin for strokes, stubs, arrowheads, text and ghosts of
+// neighboring areas. A room's exits are drawn from both ends, so each end
+// reaches the whole line and stripes draw them in the order a single call
+// would.
+func (r *Renderer) spanRooms(l *levelRooms, centerX, centerY int32, halfWidth, halfHeight, spacing int) {
+	margin := 2*r.config.RoomSize + 2*int(math.Ceil(r.config.ExitWidth)) + int(math.Ceil(math.Max(r.config.OneWayExits.ArrowSize, 0)))
+	if face := r.face(max(r.config.RoomSize/2, 9)); face != nil {
+		metrics := face.Metrics()
+		margin += (metrics.Ascent + metrics.Descent).Ceil() + 2
+	}
+	if r.config.AreaGhosts {
+		margin += 2 * spacing
+	}
+	gap := 0
+	if r.config.BundleExits {
+		gap = max(3, r.config.RoomSize/5)
+	}
+
+	l.spans = make([]rowSpan, len(l.rooms))
+	for i, room := range l.rooms {
+		x, y := r.roomToScreen(room, centerX, centerY, halfWidth, halfHeight, spacing)
+		s := rowSpan{y, y}
+		reach := func(y, pad int) {
+			s.top = min(s.top, y-pad)
+			s.bottom = max(s.bottom, y+pad)
+		}
+		// Bundled links between two rooms spread sideways, one gap apart
+		bundled := func(dest *mapparser.MudletRoom) int {
+			return gap * (16 + len(room.SpecialExits) + len(dest.SpecialExits)) / 2
+		}
+		for _, id := range room.Exits {
+			if dest := l.byID[id]; dest != nil {
+				_, destY := r.roomToScreen(dest, centerX, centerY, halfWidth, halfHeight, spacing)
+				reach(destY, bundled(dest))
+				reach(y, bundled(dest))
+			}
+		}
+		bow := 0.2 + 0.15*float64(len(room.SpecialExits))
+		for _, id := range room.SpecialExits {
+			if dest := l.byID[id]; dest != nil {
+				destX, destY := r.roomToScreen(dest, centerX, centerY, halfWidth, halfHeight, spacing)
+				pad := int(math.Ceil(math.Hypot(float64(destX-x), float64(destY-y))*bow)) + bundled(dest)
+				reach(destY, pad)
+				reach(y, pad)
+			}
+		}
+		for _, points := range room.CustomLines {
+			line := rowSpan{y, y}
+			for _, pt := range points {
+				py := halfHeight - int(math.Round(pt.Y)-float64(centerY))*spacing
+				line.top, line.bottom = min(line.top, py), max(line.bottom, py)
+			}
+			// A smoothed line strays at most a sixth of its height
+			pad := 0
+			if r.config.SmoothCustomLines {
+				pad = (line.bottom-line.top)/6 + 1
+			}
+			reach(line.top, pad)
+			reach(line.bottom, pad)
+		}
+		l.spans[i] = rowSpan{s.top - margin, s.bottom + margin}
+	}
+}
@@ -0,0 +1,137 @@
+package maprenderer
+
+import (
+	"fmt"
+	"image"
+	"testing"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+func TestParallelStripes(t *testing.T) {
+	m := testGridMap(12)
+	for _, room := range m.Rooms {
+		room.Name = fmt.Sprintf("Room %d", room.ID)
+		room.Environment = 1 + room.ID%7
+		if room.ID%5 == 0 {
+			room.Exits[mapparser.ExitNortheast] = room.ID + 13
+		}
+		if room.ID%9 == 0 {
+			room.SpecialExits["climb"] = room.ID + 2
+		}
+	}
+	// A second level under the first, and an area past its east edge
+	for y := int32(0); y < 6; y++ {
+		room := mapparser.NewMudletRoom(1000 + y)
+		room.Area, room.X, room.Y, room.Z, room.Environment = 1, y, y, -1, 3
+		m.Rooms[room.ID] = room
+		m.Areas[1].Rooms = append(m.Areas[1].Rooms, uint32(room.ID))
+	}
+	m.Areas[2] = mapparser.NewMudletArea(2, "East")
+	east := mapparser.NewMudletRoom(2000)
+	east.Area, east.Environment = 2, 4
+	m.Rooms[east.ID] = east
+	m.Areas[2].Rooms = []uint32{uint32(east.ID)}
+	m.Rooms[12*5+12].Exits[mapparser.ExitEast] = east.ID
+	// A line bending far north and south of its room
+	m.Rooms[40].CustomLines["wind"] = []mapparser.Point2D{{X: 3, Y: 11}, {X: 6, Y: 0}, {X: 9, Y: 11}}
+
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 320, 300
+	cfg.RoomSize, cfg.RoomSpacing = 16, 26
+	cfg.RoomRound = true
+	cfg.ShowRoomNames = true
+	cfg.ShowSpecialExits = true
+	cfg.ShowLowerLevel = true
+	cfg.AreaGhosts = true
+	cfg.ShowGrid = true
+	cfg.ShowLegend = true
+	cfg.ShowTitle = true
+	cfg.SmoothCustomLines = true
+	cfg.Markers = map[int32]Marker{30: {Style: MarkerTag, Text: "Boss"}, 90: {}}
+	r := NewRenderer(cfg)
+	r.SetMap(m)
+
+	cfg.Parallelism = 1
+	want, err := r.RenderFragment(66)
+	if err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+	for _, n := range []int{2, 7, 1000} {
+		cfg.Parallelism = n
+		got, err := r.RenderFragment(66)
+		if err != nil {
+			t.Fatalf("RenderFragment failed: %v", err)
+		}
+		if !imagesEqual(got, want) || got.RoomsDrawn != want.RoomsDrawn {
+			t.Errorf("Expected %d stripes to draw the image drawn in one", n)
+		}
+	}
+
+	// Bundled exits spread sideways across stripes too
+	cfg.BundleExits = true
+	cfg.Parallelism = 1
+	want, err = r.RenderFragment(66)
+	if err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+	cfg.Parallelism = 7
+	if got, err := r.RenderFragment(66); err != nil || !imagesEqual(got, want) {
+		t.Errorf("Expected 7 stripes to draw the bundled image drawn in one (err %v)", err)
+	}
+	cfg.BundleExits = false
+
+	// Overlay callbacks run once, on the whole image
+	var calls []image.Rectangle
+	cfg.Overlays = []Overlay{{Room: 66, Draw: func(img *image.RGBA, x, y int) {
+		calls = append(calls, img.Bounds())
+	}}}
+	cfg.Parallelism = 4
+	if _, err := r.RenderFragment(66); err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+	if len(calls) != 1 || calls[0] != image.Rect(0, 0, cfg.Width, cfg.Height) {
+		t.Errorf("Expected one overlay call on the whole image, got %v", calls)
+	}
+	cfg.Overlays = nil
+
+	// Small images are drawn in one stripe by default
+	cfg.Parallelism = 0
+	if n := r.stripes(want.Image); n != 1 {
+		t.Errorf("Expected a small image in one stripe, got %d", n)
+	}
+}
+
+func TestStripeRooms(t *testing.T) {
+	m := testGridMap(30)
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 800, 800
+	cfg.RoomSize, cfg.RoomSpacing = 10, 25
+	r := NewRenderer(cfg)
+	r.SetMap(m)
+	if err := r.loadFonts(); err != nil {
+		t.Fatalf("loadFonts failed: %v", err)
+	}
+
+	var rooms []*mapparser.MudletRoom
+	for _, room := range m.Rooms {
+		rooms = append(rooms, room)
+	}
+	l := newLevelRooms(rooms)
+	if got := l.in(image.Rect(0, 0, 800, 100)); len(got) != len(rooms) {
+		t.Errorf("Expected all %d rooms before spanning, got %d", len(rooms), len(got))
+	}
+
+	// Room (15, 15) is at the image center; a stripe at the top reaches
+	// the northern rooms only
+	r.spanRooms(l, 15, 15, 400, 400, 25)
+	top := l.in(image.Rect(0, 0, 800, 100))
+	if len(top) == 0 || len(top) >= len(rooms)/2 {
+		t.Fatalf("Expected a top stripe to walk a few of %d rooms, got %d", len(rooms), len(top))
+	}
+	for _, room := range top {
+		if room.Y < 15 {
+			t.Errorf("Expected room %d south of the center out of the top stripe", room.ID)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package maprenderer
+
+import (
+	"image"
+	"testing"
+)
+
+func TestPixelRatio(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 300, 300
+	r := NewRenderer(cfg)
+	r.SetMap(testGridMap(3))
+
+	legendInk := func() image.Rectangle {
+		t.Helper()
+		cfg.ShowLegend = false
+		bare, err := r.RenderFragment(5)
+		if err != nil {
+			t.Fatalf("RenderFragment failed: %v", err)
+		}
+		cfg.ShowLegend = true
+		legend, err := r.RenderFragment(5)
+		if err != nil {
+			t.Fatalf("RenderFragment failed: %v", err)
+		}
+		return inkBounds(bare.Image, legend.Image, legend.Image.Bounds())
+	}
+	plain := legendInk()
+
+	cfg.PixelRatio = 2
+	hidpi := legendInk()
+	// Antialiased text is within a pixel per glyph of twice as wide
+	if abs(hidpi.Dx()-2*plain.Dx()) > plain.Dx()/20 || abs(hidpi.Dy()-2*plain.Dy()) > plain.Dy()/20 {
+		t.Errorf("Expected the legend twice as large as %v, got %v", plain, hidpi)
+	}
+
+	result, err := r.RenderFragment(5)
+	if err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+	if result.Image.Bounds() != image.Rect(0, 0, 600, 600) || result.RoomSpacing != 50 || result.RoomSize != 40 {
+		t.Fatalf("Expected a 600x600 image with rooms 40 pixels apart by 50, got %v, %d, %d",
+			result.Image.Bounds(), result.RoomSize, result.RoomSpacing)
+	}
+	if room := r.RoomAt(result, 250, 350); room == nil || room.ID != 1 {
+		t.Errorf("Expected room 1 at (250, 350), got %v", room)
+	}
+	// Room 1's left border is two pixels wide
+	for _, x := range []int{230, 231} {
+		if got := result.Image.RGBAAt(x, 350); got != cfg.BorderColor {
+			t.Errorf("Expected the border color at (%d, 350), got %v", x, got)
+		}
+	}
+	if cfg.Width != 300 || cfg.RoomSize != 20 {
+		t.Error("Expected the configuration unchanged")
+	}
+}
@@ -0,0 +1,138 @@
+package maprenderer
+
+import (
+	"container/list"
+	"sync"
+)
+
+// PrefetchCache serves fragments centered on rooms, as
+// [Renderer.RenderFragment], for live-tracking clients that follow a player.
+// After each request it renders, in the background, the views centered on
+// the rooms one move away: the destinations of the room's exits and special
+// exits, whether they are in the view or among its [RenderResult.EdgeExits].
+// When the player moves, the next view is usually ready.
+//
+// Results are cached by room ID; the least recently used views are dropped
+// once the cache holds more than its capacity. A PrefetchCache is safe for
+// concurrent use. It renders with copies of the renderer and its
+// configuration taken when it is created, so later changes to them do not
+// reach it, but the map must not be modified while the cache is in use;
+// create a new cache for a new map.
+type PrefetchCache struct {
+	r        *Renderer
+	capacity int
+
+	mu      sync.Mutex
+	entries map[int32]*list.Element
+	lru     *list.List // of *prefetchEntry, most recently used first
+	wg      sync.WaitGroup
+}
+
+type prefetchEntry struct {
+	roomID int32
+	done   chan struct{}
+	result RenderResult
+}
+
+// NewPrefetchCache returns a cache rendering with a copy of r and its
+// configuration, holding up to capacity views, or 64 if capacity is not
+// positive.
+func NewPrefetchCache(r *Renderer, capacity int) *PrefetchCache {
+	if capacity <= 0 {
+		capacity = 64
+	}
+	w, cfg := *r, *r.config
+	w.config = &cfg
+	return &PrefetchCache{r: &w, capacity: capacity, entries: make(map[int32]*list.Element), lru: list.New()}
+}
+
+// Render returns the view centered on roomID, rendering it unless it is
+// cached or already being prefetched, and starts prefetching its
+// neighbors. The returned result is shared and must not be modified.
+func (c *PrefetchCache) Render(roomID int32) (*RenderResult, error) {
+	e := c.get(roomID, false)
+	<-e.done
+	if e.result.Err != nil {
+		return nil, e.result.Err
+	}
+	c.prefetch(roomID)
+	return &e.result, nil
+}
+
+// Cached reports whether the view centered on roomID is cached or being
+// rendered.
+func (c *PrefetchCache) Cached(roomID int32) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries[roomID] != nil
+}
+
+// Wait blocks until all background prefetches have finished.
+func (c *PrefetchCache) Wait() {
+	c.wg.Wait()
+}
+
+// get returns the entry for roomID, starting a render if there is none. It
+// marks the entry most recently used, or with prefetched, second most
+// recently used, so prefetches never evict the view just requested.
+func (c *PrefetchCache) get(roomID int32, prefetched bool) *prefetchEntry {
+	c.mu.Lock()
+	front := c.lru.Front()
+	if el := c.entries[roomID]; el != nil {
+		if prefetched && el != front {
+			c.lru.MoveAfter(el, front)
+		} else {
+			c.lru.MoveToFront(el)
+		}
+		c.mu.Unlock()
+		return el.Value.(*prefetchEntry)
+	}
+	e := &prefetchEntry{roomID: roomID, done: make(chan struct{})}
+	if prefetched && front != nil {
+		c.entries[roomID] = c.lru.InsertAfter(e, front)
+	} else {
+		c.entries[roomID] = c.lru.PushFront(e)
+	}
+	for c.lru.Len() > c.capacity {
+		c.remove(c.lru.Back())
+	}
+	w := *c.r
+	c.mu.Unlock()
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer close(e.done)
+		e.result = w.renderRequest(&RenderRequest{RoomID: roomID})
+		if e.result.Err != nil {
+			// Do not cache failures
+			c.mu.Lock()
+			if el := c.entries[roomID]; el != nil && el.Value == e {
+				c.remove(el)
+			}
+			c.mu.Unlock()
+		}
+	}()
+	return e
+}
+
+// remove drops a view. The caller holds c.mu.
+func (c *PrefetchCache) remove(el *list.Element) {
+	e := c.lru.Remove(el).(*prefetchEntry)
+	delete(c.entries, e.roomID)
+}
+
+// prefetch starts rendering the views one move away from roomID.
+func (c *PrefetchCache) prefetch(roomID int32) {
+	room := c.r.mapData.GetRoom(roomID)
+	if room == nil {
+		return
+	}
+	seen := map[int32]bool{roomID: true}
+	for _, n := range room.Neighbors(c.r.mapData) {
+		if !seen[n.Room.ID] {
+			seen[n.Room.ID] = true
+			c.get(n.Room.ID, true)
+		}
+	}
+}
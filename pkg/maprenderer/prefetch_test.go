@@ -0,0 +1,89 @@
+package maprenderer
+
+import (
+	"testing"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+func TestPrefetchCache(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 80, 80
+	r := NewRenderer(cfg)
+	r.SetMap(testGridMap(5))
+	c := NewPrefetchCache(r, 0)
+
+	// Room 7 is at (1, 1) with exits to rooms 2, 6, 8 and 12
+	first, err := c.Render(7)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if first.CenterRoom != 7 {
+		t.Errorf("Expected center room 7, got %d", first.CenterRoom)
+	}
+	c.Wait()
+	for _, id := range []int32{2, 6, 8, 12} {
+		if !c.Cached(id) {
+			t.Errorf("Expected neighbor %d prefetched", id)
+		}
+	}
+	if c.Cached(13) {
+		t.Error("Expected room two moves away not prefetched")
+	}
+
+	again, err := c.Render(7)
+	if err != nil || again != first {
+		t.Errorf("Expected cached result, got %p (%v)", again, err)
+	}
+
+	if _, err := c.Render(999); err == nil {
+		t.Error("Expected error for missing room")
+	}
+	if c.Cached(999) {
+		t.Error("Expected failed render not cached")
+	}
+
+	small := NewPrefetchCache(r, 2)
+	if _, err := small.Render(13); err != nil {
+		t.Fatal(err)
+	}
+	small.Wait()
+	small.mu.Lock()
+	n := len(small.entries)
+	small.mu.Unlock()
+	if n != 2 || !small.Cached(13) {
+		t.Errorf("Expected cache limited to 2 views including the requested one, got %d", n)
+	}
+}
+
+func TestPrefetchCacheLRU(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 80, 80
+	r := NewRenderer(cfg)
+	m := testGridMap(3)
+	for _, room := range m.Rooms {
+		for dir := range room.Exits {
+			room.Exits[dir] = mapparser.NoExit
+		}
+	}
+	r.SetMap(m)
+	c := NewPrefetchCache(r, 2)
+
+	// Later changes to the configuration do not reach the cache
+	cfg.Width = 40
+
+	for _, id := range []int32{1, 2, 1, 3} {
+		res, err := c.Render(id)
+		if err != nil {
+			t.Fatalf("Render(%d) failed: %v", id, err)
+		}
+		if res.Image.Bounds().Dx() != 80 {
+			t.Errorf("Expected the cache to keep the renderer's configuration, got width %d", res.Image.Bounds().Dx())
+		}
+	}
+	c.Wait()
+	if !c.Cached(1) || c.Cached(2) || !c.Cached(3) {
+		t.Errorf("Expected the least recently used view 2 evicted, cached 1: %v, 2: %v, 3: %v",
+			c.Cached(1), c.Cached(2), c.Cached(3))
+	}
+}
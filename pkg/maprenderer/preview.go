@@ -0,0 +1,49 @@
+package maprenderer
+
+import (
+	"fmt"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+// PreviewEnvironmentChanges renders the fragment centered on roomID twice:
+// once with the current map, and once as it would look after applying the
+// given environment changes (see [mapparser.PlanEnvironmentReassignment]).
+//
+// Neither the loaded map nor the renderer is modified; changed rooms are
+// copied for the "after" render, which runs on a private copy of the
+// renderer.
+func (r *Renderer) PreviewEnvironmentChanges(roomID int32, changes []mapparser.EnvChange) (before, after *RenderResult, err error) {
+	if r.mapData == nil {
+		return nil, nil, fmt.Errorf("no map data loaded")
+	}
+
+	before, err = r.RenderFragment(roomID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("rendering before: %w", err)
+	}
+
+	original := r.mapData
+	preview := *original
+	preview.Rooms = make(map[int32]*mapparser.MudletRoom, len(original.Rooms))
+	for id, room := range original.Rooms {
+		preview.Rooms[id] = room
+	}
+	for _, c := range changes {
+		room, ok := preview.Rooms[c.RoomID]
+		if !ok {
+			continue
+		}
+		changed := *room
+		changed.Environment = c.To
+		preview.Rooms[c.RoomID] = &changed
+	}
+
+	w := *r
+	w.mapData = &preview
+	after, err = w.RenderFragment(roomID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("rendering after: %w", err)
+	}
+	return before, after, nil
+}
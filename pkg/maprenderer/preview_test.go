@@ -0,0 +1,37 @@
+package maprenderer
+
+import (
+	"testing"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+func TestPreviewEnvironmentChanges(t *testing.T) {
+	m := testGridMap(3)
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 200, 200
+	r := NewRenderer(cfg)
+	r.SetMap(m)
+
+	changes := mapparser.PlanEnvironmentReassignment(m, map[int32]int32{1: 2})
+	if len(changes) != 9 {
+		t.Fatalf("Expected 9 planned changes, got %d", len(changes))
+	}
+
+	before, after, err := r.PreviewEnvironmentChanges(5, changes)
+	if err != nil {
+		t.Fatalf("PreviewEnvironmentChanges failed: %v", err)
+	}
+
+	// Sample the middle of room 1 (bottom-left corner of the grid)
+	x, y := cfg.Width/2-cfg.RoomSpacing+2, cfg.Height/2+cfg.RoomSpacing+2
+	if before.Image.RGBAAt(x, y) == after.Image.RGBAAt(x, y) {
+		t.Error("Expected room color to differ between before and after renders")
+	}
+	if m.Rooms[1].Environment != 1 {
+		t.Error("Preview must not modify the loaded map")
+	}
+	if r.mapData != m {
+		t.Error("Preview must not replace the renderer's map")
+	}
+}
@@ -0,0 +1,253 @@
+package maprenderer
+
+import (
+	"bytes"
+	"container/list"
+	"sync"
+	"time"
+	"weak"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+// RenderCacheOptions limits a [RenderCache].
+type RenderCacheOptions struct {
+	// MaxEntries is the number of images kept; 0 means 256.
+	MaxEntries int
+	// MaxBytes caps the total size of the encoded images kept; 0 means no
+	// limit. An image larger than the cap is returned but not kept.
+	MaxBytes int64
+	// TTL is how long an image is served after it was rendered; 0 means
+	// until it is evicted.
+	TTL time.Duration
+}
+
+// RenderCache keeps the encoded images of recent renders, so that a
+// snapshot service answers identical requests without rendering and
+// encoding them again. Images are keyed by the map's
+// [mapparser.Fingerprint], the hash of the configuration (see
+// [Config.Hash]), the view requested and the output format, so one cache
+// can serve renderers of several map versions; a map with the same
+// content as an earlier one reuses its images. The fingerprint is computed
+// on the first request for each map and remembered; call
+// [RenderCache.Invalidate] after modifying a map in place. The least
+// recently used images are evicted first once a limit is reached.
+//
+// [Overlay.Draw] callbacks are not part of the hash, so requests using
+// them are rendered and encoded every time and never cached.
+//
+// A RenderCache is safe for concurrent use; concurrent requests for the
+// same image wait for a single render.
+type RenderCache struct {
+	opts RenderCacheOptions
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[renderCacheKey]*list.Element
+	lru     *list.List // of *renderCacheEntry, most recently used first
+	size    int64
+	// fingerprints remembers the fingerprint of each map rendered, without
+	// keeping the maps alive.
+	fingerprints map[weak.Pointer[mapparser.MudletMap]]string
+}
+
+// renderCacheKey identifies an encoded image.
+type renderCacheKey struct {
+	fingerprint, config string
+	at                  Position
+	hasAt               bool
+	name, label         string
+	areaID, roomID      int32
+	format              OutputFormat
+}
+
+type renderCacheEntry struct {
+	key     renderCacheKey
+	done    chan struct{}
+	data    []byte
+	err     error
+	expires time.Time
+}
+
+// NewRenderCache returns an empty cache with the given limits; nil opts
+// uses the defaults.
+func NewRenderCache(opts *RenderCacheOptions) *RenderCache {
+	c := &RenderCache{now: time.Now, entries: make(map[renderCacheKey]*list.Element), lru: list.New(),
+		fingerprints: make(map[weak.Pointer[mapparser.MudletMap]]string)}
+	if opts != nil {
+		c.opts = *opts
+	}
+	if c.opts.MaxEntries <= 0 {
+		c.opts.MaxEntries = 256
+	}
+	return c
+}
+
+// Render returns req rendered by r, as [Renderer.RenderMany] renders each
+// request, and encoded in format, from the cache if it holds the image.
+// The returned bytes are shared and must not be modified. Failed renders
+// are not cached.
+func (c *RenderCache) Render(r *Renderer, req *RenderRequest, format OutputFormat) ([]byte, error) {
+	key, ok, err := c.key(r, req, format)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return c.encode(r, req, format)
+	}
+
+	c.mu.Lock()
+	if el := c.entries[key]; el != nil {
+		e := el.Value.(*renderCacheEntry)
+		if e.expires.IsZero() || c.now().Before(e.expires) {
+			c.lru.MoveToFront(el)
+			c.mu.Unlock()
+			<-e.done
+			return e.data, e.err
+		}
+		c.remove(el)
+	}
+	e := &renderCacheEntry{key: key, done: make(chan struct{})}
+	c.entries[key] = c.lru.PushFront(e)
+	c.evict()
+	c.mu.Unlock()
+
+	e.data, e.err = c.encode(r, req, format)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el := c.entries[key]
+	if el == nil || el.Value != e {
+		// Evicted while rendering
+		close(e.done)
+		return e.data, e.err
+	}
+	if e.err != nil || (c.opts.MaxBytes > 0 && int64(len(e.data)) > c.opts.MaxBytes) {
+		c.remove(el)
+		close(e.done)
+		return e.data, e.err
+	}
+	c.size += int64(len(e.data))
+	if c.opts.TTL > 0 {
+		e.expires = c.now().Add(c.opts.TTL)
+	}
+	close(e.done)
+	c.evict()
+	return e.data, e.err
+}
+
+// Len returns the number of images cached or being rendered.
+func (c *RenderCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// Size returns the total size in bytes of the encoded images cached.
+func (c *RenderCache) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+// key returns the cache key of req rendered by r in format, and false if
+// the image cannot be cached.
+func (c *RenderCache) key(r *Renderer, req *RenderRequest, format OutputFormat) (renderCacheKey, bool, error) {
+	cfg := r.config
+	if req.Config != nil {
+		cfg = req.Config
+	}
+	if !cacheable(cfg) {
+		return renderCacheKey{}, false, nil
+	}
+	cfgHash, err := cfg.Hash()
+	if err != nil {
+		return renderCacheKey{}, false, err
+	}
+	fp, err := c.fingerprint(r.mapData)
+	if err != nil {
+		return renderCacheKey{}, false, err
+	}
+	key := renderCacheKey{fingerprint: fp, config: cfgHash, name: req.Name, label: req.Label,
+		areaID: req.AreaID, roomID: req.RoomID, format: format}
+	if req.At != nil {
+		key.at, key.hasAt = *req.At, true
+	}
+	return key, true, nil
+}
+
+// Invalidate makes the cache compute the fingerprint of m again on its
+// next request, after m was modified in place. Images of m's earlier
+// content stay cached for maps with that content.
+func (c *RenderCache) Invalidate(m *mapparser.MudletMap) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.fingerprints, weak.Make(m))
+}
+
+// fingerprint returns the remembered fingerprint of m, computing it on
+// first use.
+func (c *RenderCache) fingerprint(m *mapparser.MudletMap) (string, error) {
+	key := weak.Make(m)
+	c.mu.Lock()
+	fp, ok := c.fingerprints[key]
+	c.mu.Unlock()
+	if ok {
+		return fp, nil
+	}
+	fp, err := mapparser.Fingerprint(m)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k := range c.fingerprints {
+		if k.Value() == nil {
+			delete(c.fingerprints, k)
+		}
+	}
+	c.fingerprints[key] = fp
+	return fp, nil
+}
+
+// cacheable reports whether everything cfg renders with is in its hash.
+func cacheable(cfg *Config) bool {
+	for _, o := range cfg.Overlays {
+		if o.Draw != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// encode renders req with r and encodes the image in format.
+func (c *RenderCache) encode(r *Renderer, req *RenderRequest, format OutputFormat) ([]byte, error) {
+	result := r.renderRequest(req)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	var buf bytes.Buffer
+	if err := WriteImage(result.Image, &buf, &OutputOptions{Format: format}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// evict drops the least recently used images while over a limit. The
+// caller holds c.mu.
+func (c *RenderCache) evict() {
+	for c.lru.Len() > c.opts.MaxEntries || (c.opts.MaxBytes > 0 && c.size > c.opts.MaxBytes) {
+		c.remove(c.lru.Back())
+	}
+}
+
+// remove drops an image. The caller holds c.mu.
+func (c *RenderCache) remove(el *list.Element) {
+	e := c.lru.Remove(el).(*renderCacheEntry)
+	delete(c.entries, e.key)
+	select {
+	case <-e.done:
+		c.size -= int64(len(e.data))
+	default:
+		// Still rendering; its size was never added
+	}
+}
@@ -0,0 +1,111 @@
+package maprenderer
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+	"time"
+)
+
+func TestRenderCache(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 80, 80
+	m := testGridMap(3)
+	r := NewRenderer(cfg)
+	r.SetMap(m)
+	c := NewRenderCache(&RenderCacheOptions{MaxEntries: 2})
+	start := time.Now()
+	c.now = func() time.Time { return start }
+
+	first, err := c.Render(r, &RenderRequest{RoomID: 5}, FormatPNG)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if _, err := png.Decode(bytes.NewReader(first)); err != nil {
+		t.Fatalf("Expected a PNG image: %v", err)
+	}
+	again, err := c.Render(r, &RenderRequest{RoomID: 5}, FormatPNG)
+	if err != nil || &again[0] != &first[0] {
+		t.Errorf("Expected the cached image, got a new one (%v)", err)
+	}
+
+	// A renderer of an identical copy of the map shares the images
+	twin := NewRenderer(cfg)
+	twin.SetMap(m.DeepCopy())
+	if shared, err := c.Render(twin, &RenderRequest{RoomID: 5}, FormatPNG); err != nil || &shared[0] != &first[0] {
+		t.Errorf("Expected the image cached for the same map content (%v)", err)
+	}
+
+	// The fingerprint is remembered until the map is invalidated after
+	// editing it in place
+	env := m.Rooms[5].Environment
+	m.Rooms[5].Environment = 3
+	if stale, err := c.Render(r, &RenderRequest{RoomID: 5}, FormatPNG); err != nil || &stale[0] != &first[0] {
+		t.Errorf("Expected the remembered fingerprint used (%v)", err)
+	}
+	c.Invalidate(m)
+	edited, err := c.Render(r, &RenderRequest{RoomID: 5}, FormatPNG)
+	if err != nil || &edited[0] == &first[0] {
+		t.Errorf("Expected the edited map rendered again (%v)", err)
+	}
+	m.Rooms[5].Environment = env
+	c = NewRenderCache(&RenderCacheOptions{MaxEntries: 2})
+	c.now = func() time.Time { return start }
+	first, _ = c.Render(r, &RenderRequest{RoomID: 5}, FormatPNG)
+
+	// Callbacks are not in the hash, so their renders are not cached
+	calls := 0
+	drawn := *cfg
+	drawn.Overlays = []Overlay{{Room: 5, Draw: func(*image.RGBA, int, int) { calls++ }}}
+	for range 2 {
+		if _, err := c.Render(r, &RenderRequest{RoomID: 5, Config: &drawn}, FormatPNG); err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+	}
+	if calls != 2 || c.Len() != 1 {
+		t.Errorf("Expected 2 uncached renders with a callback, got %d calls and %d images cached", calls, c.Len())
+	}
+
+	if _, err := c.Render(r, &RenderRequest{RoomID: 999}, FormatPNG); err == nil {
+		t.Error("Expected an error for a missing room")
+	}
+	if c.Len() != 1 || c.Size() != int64(len(first)) {
+		t.Errorf("Expected one image of %d bytes cached, got %d of %d", len(first), c.Len(), c.Size())
+	}
+
+	// Another configuration or format is another image; the least
+	// recently used one is evicted
+	if _, err := c.Render(r, &RenderRequest{RoomID: 5}, FormatWEBP); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	round := *cfg
+	round.RoomRound = true
+	if _, err := c.Render(r, &RenderRequest{RoomID: 5, Config: &round}, FormatPNG); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if c.Len() != 2 {
+		t.Errorf("Expected 2 images cached, got %d", c.Len())
+	}
+	if again, _ := c.Render(r, &RenderRequest{RoomID: 5}, FormatPNG); &again[0] == &first[0] {
+		t.Error("Expected the least recently used image evicted")
+	}
+
+	// Images expire after the TTL
+	c = NewRenderCache(&RenderCacheOptions{TTL: time.Minute})
+	c.now = func() time.Time { return start }
+	first, _ = c.Render(r, &RenderRequest{RoomID: 5}, FormatPNG)
+	c.now = func() time.Time { return start.Add(2 * time.Minute) }
+	if again, _ := c.Render(r, &RenderRequest{RoomID: 5}, FormatPNG); &again[0] == &first[0] {
+		t.Error("Expected the expired image rendered again")
+	}
+
+	// Images over the size cap are not kept
+	c = NewRenderCache(&RenderCacheOptions{MaxBytes: 10})
+	if _, err := c.Render(r, &RenderRequest{RoomID: 5}, FormatPNG); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if c.Len() != 0 || c.Size() != 0 {
+		t.Errorf("Expected nothing cached under a 10-byte cap, got %d images of %d bytes", c.Len(), c.Size())
+	}
+}
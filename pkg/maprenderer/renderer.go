@@ -1,24 +1,49 @@
 package maprenderer
 
 import (
-	"bytes"
 	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
-	"image/png"
 	"math"
 	"sort"
 
+	"golang.org/x/image/font"
+
 	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
 )
 
 // Renderer handles map visualization and image generation.
 // Create a new Renderer using [NewRenderer], set the map data with [SetMap],
 // then generate images using [RenderFragment].
+//
+// A Renderer is not safe for concurrent use, but renderers are cheap and
+// only read the map. A service that swaps maps while renders are in flight
+// can create a new Renderer for each render or map version: renders that
+// already started keep using the old map, which must not be modified (see
+// [mapparser.MudletMap.DeepCopy]). [Renderer.RenderMany] renders a batch
+// in parallel over one shared map.
 type Renderer struct {
 	config  *Config
 	mapData *mapparser.MudletMap
+	// text is the font of room symbols and all other text, with faces
+	// for one render; fonts keeps it parsed across renders.
+	text  *textFont
+	fonts *fontCache
+	// textScale is the pixel size of room symbols and values, set from
+	// Config.Zoom; 0 means 1.
+	textScale int
+	// grid is set on the private renderer drawing a grid-mode area.
+	grid bool
+	// weightMax is the weight at the end of the weight heatmap gradient,
+	// set for each render.
+	weightMax int32
+	// shift moves the map center from the middle of the image, in pixels,
+	// on the private renderer of RenderViewport.
+	shift image.Point
+	// target is the caller's image RenderFragmentInto draws over, sized
+	// to the view, or nil to draw on a new image.
+	target *image.RGBA
 }
 
 // NewRenderer creates a new Renderer with the given configuration.
@@ -29,6 +54,7 @@ func NewRenderer(cfg *Config) *Renderer {
 	}
 	return &Renderer{
 		config: cfg,
+		fonts:  new(fontCache),
 	}
 }
 
@@ -50,8 +76,23 @@ type RenderResult struct {
 	AreaName string
 	// ZLevel is the Z-coordinate of the rendered level.
 	ZLevel int32
+	// CenterX and CenterY are the map coordinates drawn at the center of
+	// the image, moved by Offset pixels.
+	CenterX, CenterY int32
+	// Offset is where CenterX and CenterY are drawn relative to the
+	// center of the image; it is zero except for [Renderer.RenderViewport].
+	Offset image.Point
+	// RoomSpacing and RoomSize are the pixel sizes the rooms were drawn
+	// with, which differ from the configuration for [Renderer.RenderArea].
+	RoomSpacing, RoomSize int
 	// RoomsDrawn is the number of rooms actually rendered.
 	RoomsDrawn int
+	// EdgeExits lists the exits from rendered rooms to rooms outside the
+	// view, see [EdgeExit].
+	EdgeExits []EdgeExit
+	// Err is set instead of the other fields when a [Renderer.RenderMany]
+	// request fails.
+	Err error
 }
 
 // RenderFragment renders a map fragment centered on the specified room.
@@ -72,32 +113,122 @@ func (r *Renderer) RenderFragment(roomID int32) (*RenderResult, error) {
 
 	centerRoom := r.mapData.GetRoom(roomID)
 	if centerRoom == nil {
-		return nil, fmt.Errorf("room %d not found", roomID)
+		return nil, fmt.Errorf("%w: %d", mapparser.ErrRoomNotFound, roomID)
+	}
+
+	result, err := r.render(centerRoom.Area, centerRoom.X, centerRoom.Y, centerRoom.Z, true)
+	if err != nil {
+		return nil, err
+	}
+	result.CenterRoom = roomID
+	return result, nil
+}
+
+// RenderFragmentAtZ renders the fragment of the room's area centered on
+// the room's x and y, as [Renderer.RenderFragment] does, but on level z.
+// The player highlight is drawn only if z is the room's own level; other
+// levels show the rooms above or below it, so a tool can browse an area
+// level by level around one room.
+func (r *Renderer) RenderFragmentAtZ(roomID, z int32) (*RenderResult, error) {
+	if r.mapData == nil {
+		return nil, fmt.Errorf("no map data loaded")
+	}
+
+	centerRoom := r.mapData.GetRoom(roomID)
+	if centerRoom == nil {
+		return nil, fmt.Errorf("%w: %d", mapparser.ErrRoomNotFound, roomID)
+	}
+
+	result, err := r.render(centerRoom.Area, centerRoom.X, centerRoom.Y, z, z == centerRoom.Z)
+	if err != nil {
+		return nil, err
+	}
+	result.CenterRoom = roomID
+	return result, nil
+}
+
+// RenderAt renders the given area centered on map coordinates (x, y, z)
+// rather than on a room. No player highlight is drawn and the result's
+// CenterRoom is 0.
+func (r *Renderer) RenderAt(areaID, x, y, z int32) (*RenderResult, error) {
+	if r.mapData == nil {
+		return nil, fmt.Errorf("no map data loaded")
 	}
+	return r.render(areaID, x, y, z, false)
+}
 
-	area := r.mapData.GetArea(centerRoom.Area)
+// RoomAt returns the room drawn under pixel (px, py) of a result this
+// renderer produced, or nil if the pixel is not on a room of the rendered
+// area and level. It maps a click on the image back to a room.
+func (r *Renderer) RoomAt(result *RenderResult, px, py int) *mapparser.MudletRoom {
+	if r.mapData == nil || result == nil || result.Image == nil {
+		return nil
+	}
+	x, y := r.screenToMap(result, px, py)
+	room := mapparser.FindNearestRoom(r.mapData, result.AreaID, x, y, result.ZLevel)
+	if room == nil {
+		return nil
+	}
+	// Accept only pixels inside the drawn room square
+	sx, sy := r.roomToScreen(room, result.CenterX, result.CenterY,
+		result.Image.Bounds().Dx()/2+result.Offset.X, result.Image.Bounds().Dy()/2+result.Offset.Y, result.RoomSpacing)
+	half := result.RoomSize / 2
+	if px < sx-half || px > sx+half || py < sy-half || py > sy+half {
+		return nil
+	}
+	return room
+}
+
+// screenToMap converts a pixel of a rendered result to map coordinates,
+// the inverse of roomToScreen.
+func (r *Renderer) screenToMap(result *RenderResult, px, py int) (x, y float64) {
+	spacing := float64(result.RoomSpacing)
+	dx := float64(px-result.Image.Bounds().Dx()/2-result.Offset.X) / spacing
+	dy := float64(result.Image.Bounds().Dy()/2+result.Offset.Y-py) / spacing
+	return float64(result.CenterX) + dx, float64(result.CenterY) + dy
+}
+
+// render draws the rooms, exits and labels of an area around a center point.
+// When highlight is set, the player highlight is drawn at the center.
+func (r *Renderer) render(areaID, centerX, centerY, centerZ int32, highlight bool) (*RenderResult, error) {
+	if z := r.zoomed(); z != r {
+		return z.render(areaID, centerX, centerY, centerZ, highlight)
+	}
+	area := r.mapData.GetArea(areaID)
 	if area == nil {
-		return nil, fmt.Errorf("area %d not found", centerRoom.Area)
+		return nil, fmt.Errorf("area %d not found", areaID)
+	}
+	if r.config.SuperSample > 1 {
+		return r.superSampled(area, areaID, centerX, centerY, centerZ, highlight)
+	}
+	if (r.config.GridMode || area.GridMode) && !r.grid {
+		return r.gridded().render(areaID, centerX, centerY, centerZ, highlight)
+	}
+	if err := r.loadFonts(); err != nil {
+		return nil, err
 	}
 
-	// Create the output image
-	img := image.NewRGBA(image.Rect(0, 0, r.config.Width, r.config.Height))
+	// Create the output image, or draw over the caller's
+	img, op := image.NewRGBA(image.Rect(0, 0, r.config.Width, r.config.Height)), draw.Src
+	if r.target != nil {
+		img, op = r.target, draw.Over
+	}
 
 	// Fill background
-	draw.Draw(img, img.Bounds(), &image.Uniform{r.config.BackgroundColor}, image.Point{}, draw.Src)
+	draw.Draw(img, img.Bounds(), &image.Uniform{r.config.BackgroundColor}, image.Point{}, op)
 
 	// Calculate rendering parameters
-	centerX := centerRoom.X
-	centerY := centerRoom.Y
-	centerZ := centerRoom.Z
-	areaID := centerRoom.Area
-
-	halfWidth := r.config.Width / 2
-	halfHeight := r.config.Height / 2
+	halfWidth := r.config.Width/2 + r.shift.X
+	halfHeight := r.config.Height/2 + r.shift.Y
 	spacing := r.config.RoomSpacing
 
 	// Calculate how many rooms fit in each direction (rectangular, not circular)
 	rangeX, rangeY := r.config.CalculateVisibleRooms()
+	if r.shift != (image.Point{}) {
+		// The view reaches up to half a room further on one side
+		rangeX++
+		rangeY++
+	}
 
 	// Build custom environment colors map from map data
 	customEnvColors := make(map[int32]color.RGBA)
@@ -109,66 +240,206 @@ func (r *Renderer) RenderFragment(roomID int32) (*RenderResult, error) {
 	// Collect rooms to render - ONLY from the same area
 	roomsToRender := r.collectRoomsInArea(centerX, centerY, centerZ, int32(rangeX), int32(rangeY), areaID)
 
-	// Build room lookup map
-	roomMap := make(map[int32]*mapparser.MudletRoom)
-	for _, room := range roomsToRender {
-		roomMap[room.ID] = room
+	view := newLevelRooms(roomsToRender)
+	if r.config.WeightHeatmap != HeatmapOff {
+		r.weightMax = r.viewWeightMax(roomsToRender)
 	}
 
-	// Optionally draw lower level rooms (same area only)
+	// Collect the levels below and above, farthest first (same area only)
+	type otherLevel struct {
+		rooms *levelRooms
+		depth int
+		lower bool
+	}
+	var otherLevels []otherLevel
+	levels := max(r.config.OtherLevels, 1)
 	if r.config.ShowLowerLevel {
-		lowerRooms := r.collectRoomsInArea(centerX, centerY, centerZ-1, int32(rangeX), int32(rangeY), areaID)
-		r.drawOtherLevelRooms(img, lowerRooms, centerX, centerY, halfWidth, halfHeight, spacing, true)
+		for d := levels; d >= 1; d-- {
+			lowerRooms := r.collectRoomsInArea(centerX, centerY, centerZ-int32(d), int32(rangeX), int32(rangeY), areaID)
+			otherLevels = append(otherLevels, otherLevel{newLevelRooms(lowerRooms), d, true})
+		}
 	}
-
-	// Optionally draw upper level rooms (same area only)
 	if r.config.ShowUpperLevel {
-		upperRooms := r.collectRoomsInArea(centerX, centerY, centerZ+1, int32(rangeX), int32(rangeY), areaID)
-		r.drawOtherLevelRooms(img, upperRooms, centerX, centerY, halfWidth, halfHeight, spacing, false)
+		for d := levels; d >= 1; d-- {
+			upperRooms := r.collectRoomsInArea(centerX, centerY, centerZ+int32(d), int32(rangeX), int32(rangeY), areaID)
+			otherLevels = append(otherLevels, otherLevel{newLevelRooms(upperRooms), d, false})
+		}
 	}
 
-	// Draw background labels (under everything)
-	r.drawLabels(img, areaID, centerZ, false, centerX, centerY, halfWidth, halfHeight, spacing)
+	// Each stripe walks only the rooms reaching it
+	if r.stripes(img) > 1 {
+		r.spanRooms(view, centerX, centerY, halfWidth, halfHeight, spacing)
+		for _, l := range otherLevels {
+			r.spanRooms(l.rooms, centerX, centerY, halfWidth, halfHeight, spacing)
+		}
+	}
 
-	// Draw exits FIRST (under rooms)
-	r.drawExits(img, roomsToRender, roomMap, centerX, centerY, halfWidth, halfHeight, spacing, areaID)
+	// Names are placed in the whole view, before it is split into stripes
+	var names []roomName
+	if r.config.ShowRoomNames {
+		names = r.placeRoomNames(roomsToRender, centerX, centerY, halfWidth, halfHeight, spacing)
+	}
 
-	// Draw rooms on current z-level
 	roomsDrawn := 0
 	for _, room := range roomsToRender {
-		screenX, screenY := r.roomToScreen(room, centerX, centerY, halfWidth, halfHeight, spacing)
+		if r.onImage(r.roomToScreen(room, centerX, centerY, halfWidth, halfHeight, spacing)) {
+			roomsDrawn++
+		}
+	}
 
-		// Check if room is within image bounds
-		margin := r.config.RoomSize
-		if screenX < -margin || screenX > r.config.Width+margin ||
-			screenY < -margin || screenY > r.config.Height+margin {
-			continue
+	// Draw the map, in parallel stripes when it is large
+	r.drawStriped(img, func(r *Renderer, img *image.RGBA) {
+		if r.config.ShowGrid {
+			r.drawGrid(img, halfWidth, halfHeight, spacing)
 		}
 
-		// Get room color based on environment
-		envColor := r.getEnvColor(room.Environment, customEnvColors)
+		// Draw the other levels under this one
+		for _, l := range otherLevels {
+			r.drawOtherLevel(img, l.rooms, customEnvColors, centerX, centerY, halfWidth, halfHeight, spacing, areaID, l.depth, l.lower)
+		}
 
-		// Draw the room
-		r.drawRoom(img, screenX, screenY, envColor, room)
-		roomsDrawn++
-	}
+		// Draw background labels (under everything)
+		r.drawLabels(img, areaID, centerZ, false, centerX, centerY, halfWidth, halfHeight, spacing)
+
+		// Draw exits FIRST (under rooms); grid-mode tiles have none
+		rooms := view.in(img.Rect)
+		if !r.grid {
+			r.drawExits(img, rooms, view, centerX, centerY, halfWidth, halfHeight, spacing, areaID)
+			if r.config.AreaGhosts {
+				r.drawAreaGhosts(img, rooms, view.places, customEnvColors, centerX, centerY, halfWidth, halfHeight, spacing)
+			}
+		}
+
+		// Draw rooms on current z-level
+		for _, room := range rooms {
+			screenX, screenY := r.roomToScreen(room, centerX, centerY, halfWidth, halfHeight, spacing)
+			if !r.onImage(screenX, screenY) {
+				continue
+			}
 
-	// Draw player room highlight (gradient like Mudlet)
-	r.drawPlayerHighlight(img, halfWidth, halfHeight)
+			// Get room color based on environment
+			envColor := r.getEnvColor(room.Environment, customEnvColors)
+			if r.config.WeightHeatmap == HeatmapRooms {
+				envColor = r.weightColor(room.Weight)
+			}
 
-	// Draw foreground labels (on top of everything)
-	r.drawLabels(img, areaID, centerZ, true, centerX, centerY, halfWidth, halfHeight, spacing)
+			// Draw the room
+			r.drawRoom(img, screenX, screenY, envColor, room)
+		}
+
+		if r.config.ShowRoomNames {
+			r.drawRoomNames(img, names)
+		}
+
+		// Draw player room highlight (gradient like Mudlet)
+		if highlight {
+			r.drawPlayerHighlight(img, halfWidth, halfHeight)
+		}
+
+		// Draw foreground labels (on top of everything)
+		r.drawLabels(img, areaID, centerZ, true, centerX, centerY, halfWidth, halfHeight, spacing)
+
+		if len(r.config.Markers) > 0 {
+			r.drawMarkers(img, rooms, centerX, centerY, halfWidth, halfHeight, spacing)
+		}
+	})
+
+	// Overlays run caller code, so they are drawn once over the whole image
+	if len(r.config.Overlays) > 0 {
+		r.drawOverlays(img, areaID, centerZ, centerX, centerY, halfWidth, halfHeight, spacing)
+	}
+
+	r.drawDecorations(img, area, areaID, centerX, centerY, centerZ, halfWidth, halfHeight, spacing)
+	if r.text.err != nil {
+		return nil, r.text.err
+	}
 
 	return &RenderResult{
-		Image:      img,
-		CenterRoom: roomID,
-		AreaID:     centerRoom.Area,
-		AreaName:   area.Name,
-		ZLevel:     centerZ,
-		RoomsDrawn: roomsDrawn,
+		Image:       img,
+		AreaID:      areaID,
+		AreaName:    area.Name,
+		ZLevel:      centerZ,
+		CenterX:     centerX,
+		CenterY:     centerY,
+		Offset:      r.shift,
+		RoomSpacing: spacing,
+		RoomSize:    r.config.RoomSize,
+		RoomsDrawn:  roomsDrawn,
+		EdgeExits:   r.collectEdgeExits(roomsToRender, view.byID, centerX, centerY, halfWidth, halfHeight, spacing),
 	}, nil
 }
 
+// onImage reports whether a room centered at (screenX, screenY) is drawn,
+// that is within a room's size of the image.
+func (r *Renderer) onImage(screenX, screenY int) bool {
+	margin := r.config.RoomSize
+	return screenX >= -margin && screenX <= r.config.Width+margin &&
+		screenY >= -margin && screenY <= r.config.Height+margin
+}
+
+// loadFonts loads the text font for a render.
+func (r *Renderer) loadFonts() error {
+	text, err := r.config.loadTextFont(r.fonts)
+	if err != nil {
+		return err
+	}
+	r.text = text
+	return nil
+}
+
+// drawDecorations draws the axes, legend, title bar, compass, scale bar
+// and attribution over the map, as configured.
+func (r *Renderer) drawDecorations(img *image.RGBA, area *mapparser.MudletArea, areaID, centerX, centerY, centerZ int32,
+	halfWidth, halfHeight, spacing int) {
+
+	if r.config.ShowAxes {
+		r.drawAxes(img, centerX, centerY, halfWidth, halfHeight, spacing)
+	}
+
+	if r.config.ShowLegend {
+		r.drawLegend(img)
+	}
+
+	titleH := 0
+	if r.config.ShowTitle {
+		titleH = r.drawTitle(img, area.Name, areaID, centerZ)
+	}
+	if r.config.ShowCompass {
+		r.drawCompass(img, titleH)
+	}
+	if r.config.ShowScale {
+		r.drawScaleBar(img, spacing)
+	}
+
+	if r.config.ShowAttribution {
+		r.drawAttribution(img)
+	}
+}
+
+// gridded returns a private renderer drawing rooms as Mudlet does for
+// grid-mode areas: borderless square tiles as large as the room spacing,
+// packed edge to edge, with no exit lines.
+func (r *Renderer) gridded() *Renderer {
+	cfg := *r.config
+	cfg.RoomSize = cfg.RoomSpacing
+	cfg.RoomRound = false
+	cfg.RoomBorder = false
+	w := *r
+	w.config, w.grid = &cfg, true
+	return &w
+}
+
+// zoomed returns r if Config.Zoom and PixelRatio are unset, or otherwise a
+// private renderer whose configuration has them applied.
+func (r *Renderer) zoomed() *Renderer {
+	if !r.config.scales() {
+		return r
+	}
+	w := *r
+	w.config, w.textScale = r.config.zoomed()
+	return &w
+}
+
 // roomToScreen converts room coordinates to screen coordinates
 func (r *Renderer) roomToScreen(room *mapparser.MudletRoom, centerX, centerY int32, halfWidth, halfHeight, spacing int) (int, int) {
 	dx := int(room.X - centerX)
@@ -202,12 +473,16 @@ func (r *Renderer) collectRoomsInArea(centerX, centerY, centerZ, rangeX, rangeY,
 		}
 	}
 
-	// Sort by rendering order (Y desc, then X asc for consistent drawing)
+	// Sort by rendering order (Y desc, then X asc for consistent drawing),
+	// and stacked rooms by ID, as map iteration order is random
 	sort.Slice(rooms, func(i, j int) bool {
 		if rooms[i].Y != rooms[j].Y {
 			return rooms[i].Y > rooms[j].Y
 		}
-		return rooms[i].X < rooms[j].X
+		if rooms[i].X != rooms[j].X {
+			return rooms[i].X < rooms[j].X
+		}
+		return rooms[i].ID < rooms[j].ID
 	})
 
 	return rooms
@@ -215,27 +490,53 @@ func (r *Renderer) collectRoomsInArea(centerX, centerY, centerZ, rangeX, rangeY,
 
 // drawRoom draws a single room at the given screen coordinates
 func (r *Renderer) drawRoom(img *image.RGBA, x, y int, roomColor color.RGBA, room *mapparser.MudletRoom) {
-	halfSize := r.config.RoomSize / 2
+	r.drawRoomShape(img, x, y, roomColor)
 
-	if r.config.RoomRound {
-		r.drawFilledCircle(img, x, y, halfSize, roomColor)
-		if r.config.RoomBorder {
-			r.drawCircleOutline(img, x, y, halfSize, r.config.BorderColor)
-		}
-	} else {
-		r.drawFilledRect(img, x-halfSize, y-halfSize, r.config.RoomSize, r.config.RoomSize, roomColor)
-		if r.config.RoomBorder {
-			r.drawRectOutline(img, x-halfSize, y-halfSize, r.config.RoomSize, r.config.RoomSize, r.config.BorderColor)
-		}
+	if r.config.Terrain != nil {
+		r.drawTerrainCost(img, x, y, room)
 	}
 
-	// Draw up/down indicators
-	r.drawUpDownIndicators(img, x, y, room, roomColor)
+	// Draw up/down/in/out indicators
+	r.drawInnerExitIndicators(img, x, y, room, roomColor)
 
-	// Draw room symbol if present
-	if r.config.ShowSymbol && room.Symbol != "" {
+	// Draw the room's value if set, otherwise its symbol
+	if _, ok := r.config.RoomValues[room.ID]; ok {
+		r.drawRoomValue(img, x, y, room, roomColor)
+	} else if r.config.ShowSymbol && room.Symbol != "" {
 		r.drawRoomSymbol(img, x, y, room.Symbol, room, roomColor)
 	}
+
+	if len(r.config.FlagRules) > 0 {
+		r.drawFlagBadges(img, x, y, room)
+	}
+}
+
+// drawFlagBadges draws a small square in a room corner for each flag derived
+// from the configured flag rules, clockwise from the top-left corner.
+func (r *Renderer) drawFlagBadges(img *image.RGBA, cx, cy int, room *mapparser.MudletRoom) {
+	flags := mapparser.RoomFlags(room, r.config.FlagRules)
+	if len(flags) == 0 {
+		return
+	}
+	halfSize := r.config.RoomSize / 2
+	size := max(3, r.config.RoomSize/4)
+	corners := [4][2]int{
+		{cx - halfSize, cy - halfSize},
+		{cx + halfSize - size, cy - halfSize},
+		{cx + halfSize - size, cy + halfSize - size},
+		{cx - halfSize, cy + halfSize - size},
+	}
+	for i, flag := range flags {
+		if i >= len(corners) {
+			break
+		}
+		c, ok := r.config.FlagColors[flag]
+		if !ok {
+			c = r.config.TextColor
+		}
+		r.drawFilledRect(img, corners[i][0], corners[i][1], size, size, c)
+		r.drawRectOutline(img, corners[i][0], corners[i][1], size, size, r.config.BackgroundColor)
+	}
 }
 
 // drawRoomSymbol draws the room symbol text
@@ -258,38 +559,27 @@ func (r *Renderer) drawRoomSymbol(img *image.RGBA, cx, cy int, symbol string, ro
 			symbolColor = color.RGBA{R: 255, G: 255, B: 255, A: 255} // White on dark
 		}
 	}
-	size := max(3, r.config.RoomSize/4)
-
-	// Get first character
-	ch := rune(symbol[0])
-
-	// Try to draw as bitmap letter first
-	if r.drawBitmapChar(img, cx, cy, ch, symbolColor) {
+	// Fit the symbol inside the room, leaving its border clear
+	inner := r.config.RoomSize * 3 / 4
+	if r.text.covers(symbol) {
+		r.drawText(img, cx, cy, inner, inner, symbol, symbolColor)
 		return
 	}
 
-	// Fallback for special symbols
-	switch symbol {
-	case "X", "x":
-		r.drawLine(img, cx-size, cy-size, cx+size, cy+size, symbolColor)
-		r.drawLine(img, cx+size, cy-size, cx-size, cy+size, symbolColor)
-	case "+":
-		r.drawLine(img, cx-size, cy, cx+size, cy, symbolColor)
-		r.drawLine(img, cx, cy-size, cx, cy+size, symbolColor)
-	case "O", "o", "0":
-		r.drawCircleOutline(img, cx, cy, size, symbolColor)
-	default:
-		// Draw a small filled square as generic indicator
-		halfS := size / 2
-		r.drawFilledRect(img, cx-halfS, cy-halfS, size, size, symbolColor)
-	}
+	// Draw a small filled square as generic indicator
+	size := max(3, r.config.RoomSize/4)
+	halfS := size / 2
+	r.drawFilledRect(img, cx-halfS, cy-halfS, size, size, symbolColor)
 }
 
-// drawUpDownIndicators draws Mudlet-like up/down markers.
-// In Mudlet these are small triangles centered horizontally, offset from room center,
-// filled with hatch patterns (Dense4 for real exits, DiagCross for stubs), and optionally
-// highlighted in door color.
-func (r *Renderer) drawUpDownIndicators(img *image.RGBA, cx, cy int, room *mapparser.MudletRoom, roomColor color.RGBA) {
+// drawInnerExitIndicators draws Mudlet-like markers for the up, down, in and
+// out exits, which have no direction on the map.
+// In Mudlet these are small triangles offset from the room center, pointing
+// up or down for up and down exits, centered horizontally, and pointing at
+// the center from the left for in exits and from the right for out exits,
+// centered vertically. They are filled with hatch patterns (Dense4 for real
+// exits, DiagCross for stubs), and optionally highlighted in door color.
+func (r *Renderer) drawInnerExitIndicators(img *image.RGBA, cx, cy int, room *mapparser.MudletRoom, roomColor color.RGBA) {
 	// Mudlet constants:
 	// allInsideTipOffsetFactor = 1/20, upDownXOrYFactor = 1/3.1
 	tipOffset := float64(r.config.RoomSize) * (1.0 / 20.0)
@@ -301,11 +591,6 @@ func (r *Renderer) drawUpDownIndicators(img *image.RGBA, cx, cy int, room *mappa
 		lc = color.RGBA{R: 0, G: 0, B: 0, A: 255}
 	}
 
-	// Door colors from Mudlet
-	openDoor := color.RGBA{R: 10, G: 155, B: 10, A: 255}
-	closedDoor := color.RGBA{R: 155, G: 155, B: 10, A: 255}
-	lockedDoor := color.RGBA{R: 155, G: 10, B: 10, A: 255}
-
 	// Helpers
 	getDoorColor := func(key string) (c color.RGBA, isDoor bool) {
 		status, ok := room.Doors[key]
@@ -314,55 +599,42 @@ func (r *Renderer) drawUpDownIndicators(img *image.RGBA, cx, cy int, room *mappa
 		}
 		switch status {
 		case 1:
-			return openDoor, true
+			return doorOpenColor, true
 		case 2:
-			return closedDoor, true
+			return doorClosedColor, true
 		case 3:
-			return lockedDoor, true
+			return doorLockedColor, true
 		default:
 			return lc, false
 		}
 	}
-	hasStub := func(dir int32) bool {
-		for _, d := range room.ExitStubs {
-			if d == dir {
-				return true
-			}
-		}
-		return false
-	}
-
-	// UP marker (triangle pointing up) shown when there is a real up exit OR an up stub
-	if room.HasExit(mapparser.ExitUp) || hasStub(mapparser.ExitUp) {
-		isReal := room.HasExit(mapparser.ExitUp)
-		fill, isDoor := getDoorColor("up")
-		if !isDoor {
-			fill = lc
-		}
-		p0 := fPoint{X: float64(cx), Y: float64(cy) + tipOffset}
-		p1 := fPoint{X: float64(cx) - baseOffset, Y: float64(cy) + baseOffset}
-		p2 := fPoint{X: float64(cx) + baseOffset, Y: float64(cy) + baseOffset}
-		pattern := hatchDense
-		if !isReal {
-			pattern = hatchDiagCross
-		}
-		r.fillTriangleHatch(img, p0, p1, p2, fill, pattern)
-		r.strokeTriangle(img, p0, p1, p2, lc)
-		if isDoor {
-			r.strokeTriangle(img, p0, p1, p2, fill)
+	// Each marker's tip is at tip times tipOffset from the center, and its
+	// base baseOffset past it on either side of the tip's axis.
+	x, y := float64(cx), float64(cy)
+	markers := []struct {
+		dir    int
+		tip    fPoint
+		across fPoint
+	}{
+		{mapparser.ExitUp, fPoint{X: 0, Y: 1}, fPoint{X: 1, Y: 0}},
+		{mapparser.ExitDown, fPoint{X: 0, Y: -1}, fPoint{X: 1, Y: 0}},
+		{mapparser.ExitIn, fPoint{X: -1, Y: 0}, fPoint{X: 0, Y: 1}},
+		{mapparser.ExitOut, fPoint{X: 1, Y: 0}, fPoint{X: 0, Y: 1}},
+	}
+	for _, m := range markers {
+		// A marker is shown when there is a real exit OR a stub
+		isReal := room.HasExit(m.dir)
+		if !isReal && !room.HasExitStub(m.dir) {
+			continue
 		}
-	}
-
-	// DOWN marker (triangle pointing down) shown when there is a real down exit OR a down stub
-	if room.HasExit(mapparser.ExitDown) || hasStub(mapparser.ExitDown) {
-		isReal := room.HasExit(mapparser.ExitDown)
-		fill, isDoor := getDoorColor("down")
+		fill, isDoor := getDoorColor(mapparser.ExitDirectionNames[m.dir])
 		if !isDoor {
 			fill = lc
 		}
-		p0 := fPoint{X: float64(cx), Y: float64(cy) - tipOffset}
-		p1 := fPoint{X: float64(cx) - baseOffset, Y: float64(cy) - baseOffset}
-		p2 := fPoint{X: float64(cx) + baseOffset, Y: float64(cy) - baseOffset}
+		baseX, baseY := x+m.tip.X*baseOffset, y+m.tip.Y*baseOffset
+		p0 := fPoint{X: x + m.tip.X*tipOffset, Y: y + m.tip.Y*tipOffset}
+		p1 := fPoint{X: baseX - m.across.X*baseOffset, Y: baseY - m.across.Y*baseOffset}
+		p2 := fPoint{X: baseX + m.across.X*baseOffset, Y: baseY + m.across.Y*baseOffset}
 		pattern := hatchDense
 		if !isReal {
 			pattern = hatchDiagCross
@@ -378,8 +650,8 @@ func (r *Renderer) drawUpDownIndicators(img *image.RGBA, cx, cy int, room *mappa
 // drawPlayerHighlight draws the player room highlight with gradient effect
 func (r *Renderer) drawPlayerHighlight(img *image.RGBA, x, y int) {
 	// Draw a radial gradient highlight like Mudlet does
-	outerRadius := r.config.RoomSize/2 + 8
-	innerRadius := r.config.RoomSize/2 + 2
+	outerRadius := r.config.RoomSize/2 + r.config.ui(8)
+	innerRadius := r.config.RoomSize/2 + r.config.ui(2)
 
 	playerColor := r.config.PlayerRoomColor
 
@@ -394,12 +666,14 @@ func (r *Renderer) drawPlayerHighlight(img *image.RGBA, x, y int) {
 	}
 
 	// Draw solid inner ring
-	r.drawCircleOutline(img, x, y, innerRadius, playerColor)
-	r.drawCircleOutline(img, x, y, innerRadius+1, playerColor)
+	for i := range r.config.ui(2) {
+		r.drawCircleOutline(img, x, y, innerRadius+i, playerColor)
+	}
 }
 
-// drawExits draws exit lines between rooms
-func (r *Renderer) drawExits(img *image.RGBA, rooms []*mapparser.MudletRoom, roomMap map[int32]*mapparser.MudletRoom,
+// drawExits draws the exit lines of rooms, which are rooms of view, to the
+// rooms of view.
+func (r *Renderer) drawExits(img *image.RGBA, rooms []*mapparser.MudletRoom, view *levelRooms,
 	centerX, centerY int32, halfWidth, halfHeight, spacing int, currentAreaID int32) {
 
 	// Direction unit vectors (for exit line direction from room center)
@@ -418,6 +692,12 @@ func (r *Renderer) drawExits(img *image.RGBA, rooms []*mapparser.MudletRoom, roo
 	drawnExits := make(map[string]bool)
 	halfRoom := float64(r.config.RoomSize) / 2.0
 
+	var bundles exitBundles
+	if r.config.BundleExits {
+		bundles = make(exitBundles)
+	}
+	roomMap, into, ghostsBlocked := view.byID, view.into, view.places
+
 	for _, room := range rooms {
 		fromX, fromY := r.roomToScreen(room, centerX, centerY, halfWidth, halfHeight, spacing)
 
@@ -436,8 +716,14 @@ func (r *Renderer) drawExits(img *image.RGBA, rooms []*mapparser.MudletRoom, roo
 
 			// Check if destination is in same area
 			if destRoom.Area != currentAreaID {
-				// Area exit - draw stub with arrow pointing outward
-				r.drawAreaExitStub(img, fromX, fromY, dir, dirVectors[dir], halfRoom)
+				// Area exit - draw stub with arrow pointing outward,
+				// unless a ghost of the other area shows where it leads
+				if !r.config.AreaGhosts || ghostsBlocked[ghostPlace(room, dir)] {
+					r.drawAreaExitStub(img, fromX, fromY, dir, dirVectors[dir], halfRoom)
+					if r.config.LabelAreaExits {
+						r.drawAreaExitLabel(img, fromX, fromY, dirVectors[dir], halfRoom, destRoom.Area)
+					}
+				}
 				continue
 			}
 
@@ -457,6 +743,11 @@ func (r *Renderer) drawExits(img *image.RGBA, rooms []*mapparser.MudletRoom, roo
 				continue
 			}
 
+			if bundles != nil {
+				bundles.addStandard(r, room, destRoom, dir)
+				continue
+			}
+
 			// Avoid drawing the same exit twice
 			minID := min32(room.ID, destID)
 			maxID := max32(room.ID, destID)
@@ -490,37 +781,57 @@ func (r *Renderer) drawExits(img *image.RGBA, rooms []*mapparser.MudletRoom, roo
 			endY := float64(toY) - ny*halfRoom
 
 			// Check if it's a one-way exit
-			isOneWay := !r.hasReturnExit(room.ID, destRoom, dir)
+			isOneWay := !r.config.OneWayExits.Plain && !r.hasReturnExit(room.ID, destRoom, dir)
 
-			exitColor := r.config.ExitColor
+			exitColor := r.exitWeightColor(r.config.ExitColor, room, destRoom, mapparser.ExitDirectionShortNames[dir], true)
 			if isOneWay {
-				// Dotted line for one-way (we'll use a different color)
-				exitColor = color.RGBA{R: 180, G: 180, B: 180, A: 180}
-				r.drawDottedLine(img, int(startX), int(startY), int(endX), int(endY), exitColor)
-				// Draw arrow
-				r.drawArrowHead(img, int(endX), int(endY), nx, ny, exitColor)
+				// Patterned line with an arrow for one-way (in a different color)
+				exitColor = r.exitWeightColor(r.config.OneWayExits.color(), room, destRoom, mapparser.ExitDirectionShortNames[dir], false)
+				r.drawOneWayExit(img, int(startX), int(startY), int(endX), int(endY), nx, ny, exitColor, r.config.ExitWidth)
 			} else {
-				r.drawLine(img, int(startX), int(startY), int(endX), int(endY), exitColor)
+				r.drawExitLine(img, int(startX), int(startY), int(endX), int(endY), exitColor)
 			}
 
 			// Draw doors if present
 			r.drawDoor(img, room, dir, int(startX), int(startY), int(endX), int(endY))
 		}
 
-		// Draw stub exits
-		for _, stubDir := range room.ExitStubs {
-			if stubDir < 0 || stubDir >= 8 {
+		// Up, down, in and out exits are marked on the room itself; join
+		// the rooms too when the destination is in view on this level
+		for dir := mapparser.ExitUp; dir <= mapparser.ExitOut; dir++ {
+			destRoom := roomMap[room.Exits[dir]]
+			if destRoom == nil || destRoom.ID == room.ID || linkedInPlane(room, destRoom) {
 				continue
 			}
-			// Check if there's already a real exit in this direction
-			if room.Exits[stubDir] != mapparser.NoExit {
+			key := fmt.Sprintf("inner-%d-%d", min32(room.ID, destRoom.ID), max32(room.ID, destRoom.ID))
+			if drawnExits[key] {
 				continue
 			}
-			r.drawExitStub(img, fromX, fromY, int(stubDir), dirVectors[stubDir], halfRoom)
+			drawnExits[key] = true
+			r.drawInnerExitConnector(img, room, destRoom, centerX, centerY, halfWidth, halfHeight, spacing)
+		}
+
+		// Draw stub exits
+		for dir := range 8 {
+			// Skip stubs where there's already a real exit
+			if !room.HasExitStub(dir) || room.Exits[dir] != mapparser.NoExit {
+				continue
+			}
+			r.drawExitStub(img, fromX, fromY, dir, dirVectors[dir], halfRoom)
 		}
 
 		// Draw custom lines (used for special exits like "drzwi", "dziob" etc.)
 		r.drawCustomLines(img, room, centerX, centerY, halfWidth, halfHeight, spacing)
+
+		if bundles != nil {
+			bundles.addSpecial(r, room, roomMap, into)
+		} else if r.config.ShowSpecialExits {
+			r.drawSpecialExits(img, room, roomMap, into, centerX, centerY, halfWidth, halfHeight, spacing)
+		}
+	}
+
+	if bundles != nil {
+		r.drawExitBundles(img, bundles, centerX, centerY, halfWidth, halfHeight, spacing)
 	}
 }
 
@@ -533,13 +844,55 @@ func (r *Renderer) drawExitStub(img *image.RGBA, fromX, fromY, dir int, dirVec [
 	endY := startY + dirVec[1]*stubLen
 
 	stubColor := r.config.ExitColor
-	r.drawLine(img, int(startX), int(startY), int(endX), int(endY), stubColor)
+	r.drawExitLine(img, int(startX), int(startY), int(endX), int(endY), stubColor)
 
 	// Draw small filled circle at stub end
 	dotRadius := max(2, r.config.RoomSize/10)
 	r.drawFilledCircle(img, int(endX), int(endY), dotRadius, stubColor)
 }
 
+// drawInnerExitConnector draws a dotted line between a room and the
+// destination of one of its up, down, in or out exits, with an arrowhead at the
+// destination unless the destination leads back by any of those exits.
+func (r *Renderer) drawInnerExitConnector(img *image.RGBA, room, destRoom *mapparser.MudletRoom,
+	centerX, centerY int32, halfWidth, halfHeight, spacing int) {
+
+	fromX, fromY := r.roomToScreen(room, centerX, centerY, halfWidth, halfHeight, spacing)
+	toX, toY := r.roomToScreen(destRoom, centerX, centerY, halfWidth, halfHeight, spacing)
+	dx := float64(toX - fromX)
+	dy := float64(toY - fromY)
+	length := math.Sqrt(dx*dx + dy*dy)
+	halfRoom := float64(r.config.RoomSize) / 2.0
+	if length <= 2*halfRoom {
+		return
+	}
+	nx := dx / length
+	ny := dy / length
+	startX := float64(fromX) + nx*halfRoom
+	startY := float64(fromY) + ny*halfRoom
+	endX := float64(toX) - nx*halfRoom
+	endY := float64(toY) - ny*halfRoom
+
+	r.drawDottedLine(img, int(startX), int(startY), int(endX), int(endY), r.config.ExitColor, r.config.ExitWidth)
+	for back := mapparser.ExitUp; back <= mapparser.ExitOut; back++ {
+		if destRoom.Exits[back] == room.ID {
+			return
+		}
+	}
+	r.drawArrowHead(img, int(endX), int(endY), nx, ny, r.config.ExitColor, r.config.ExitWidth)
+}
+
+// linkedInPlane reports whether a or b has one of the eight compass exits
+// to the other, so that the two are already joined by an exit line.
+func linkedInPlane(a, b *mapparser.MudletRoom) bool {
+	for dir := mapparser.ExitNorth; dir <= mapparser.ExitNorthwest; dir++ {
+		if a.Exits[dir] == b.ID || b.Exits[dir] == a.ID {
+			return true
+		}
+	}
+	return false
+}
+
 // drawCustomLines draws custom lines for special exits
 // CustomLines are used in Mudlet for non-standard directions like "drzwi", "dziob", etc.
 // Points in customLines are in absolute map coordinates.
@@ -562,6 +915,7 @@ func (r *Renderer) drawCustomLines(img *image.RGBA, room *mapparser.MudletRoom,
 			rc, gc, bc, ac := c.ToRGBA()
 			lineColor = color.RGBA{R: rc, G: gc, B: bc, A: ac}
 		}
+		lineColor = r.exitWeightColor(lineColor, room, r.exitDestination(room, exitName), exitName, false)
 
 		// Get line style - Qt::PenStyle enum
 		// 0=NoPen, 1=SolidLine, 2=DashLine, 3=DotLine, 4=DashDotLine, 5=DashDotDotLine
@@ -579,59 +933,43 @@ func (r *Renderer) drawCustomLines(img *image.RGBA, room *mapparser.MudletRoom,
 		// Start from room center (in screen coordinates)
 		roomScreenX := halfWidth + int(room.X-centerX)*spacing
 		roomScreenY := halfHeight - int(room.Y-centerY)*spacing
+		path := []fPoint{{X: float64(roomScreenX), Y: float64(roomScreenY)}}
 
-		prevX := roomScreenX
-		prevY := roomScreenY
-
-		// Draw line segments through all points
 		// Points are in absolute map coordinates
 		for _, pt := range points {
-			// Convert absolute map coordinates to screen coordinates
 			ptScreenX := halfWidth + int(math.Round(pt.X)-float64(centerX))*spacing
 			ptScreenY := halfHeight - int(math.Round(pt.Y)-float64(centerY))*spacing
+			path = append(path, fPoint{X: float64(ptScreenX), Y: float64(ptScreenY)})
+		}
 
-			// Draw line segment based on style
-			switch lineStyle {
-			case 0: // NoPen - don't draw
-				// skip
-			case 2: // DashLine
-				r.drawDashedLine(img, prevX, prevY, ptScreenX, ptScreenY, lineColor)
-			case 3: // DotLine
-				r.drawDottedLine(img, prevX, prevY, ptScreenX, ptScreenY, lineColor)
-			case 4, 5: // DashDotLine, DashDotDotLine - use dashed for simplicity
-				r.drawDashedLine(img, prevX, prevY, ptScreenX, ptScreenY, lineColor)
-			default: // 1 = SolidLine (default)
-				r.drawLine(img, prevX, prevY, ptScreenX, ptScreenY, lineColor)
+		// Draw line segments through all points. A smoothed curve keeps its
+		// dash pattern running across the short segments it is made of.
+		smooth := r.config.SmoothCustomLines && len(path) >= 3
+		if smooth {
+			path = smoothPath(path, r.config.CurveTension)
+		}
+		step := 0
+		for i := 1; i < len(path); i++ {
+			if !smooth {
+				step = 0
 			}
-
-			prevX = ptScreenX
-			prevY = ptScreenY
+			step = r.drawStyledLine(img,
+				int(math.Round(path[i-1].X)), int(math.Round(path[i-1].Y)),
+				int(math.Round(path[i].X)), int(math.Round(path[i].Y)),
+				lineStyle, lineColor, r.config.ExitWidth, step)
 		}
 
 		// Draw arrow at last point if requested
-		if hasArrow && len(points) > 0 {
-			lastPt := points[len(points)-1]
-			lastX := halfWidth + int(math.Round(lastPt.X)-float64(centerX))*spacing
-			lastY := halfHeight - int(math.Round(lastPt.Y)-float64(centerY))*spacing
-
-			// Calculate direction for arrow
-			var dx, dy float64
-			if len(points) >= 2 {
-				prevPt := points[len(points)-2]
-				prevPtX := halfWidth + int(math.Round(prevPt.X)-float64(centerX))*spacing
-				prevPtY := halfHeight - int(math.Round(prevPt.Y)-float64(centerY))*spacing
-				dx = float64(lastX - prevPtX)
-				dy = float64(lastY - prevPtY)
-			} else {
-				dx = float64(lastX - roomScreenX)
-				dy = float64(lastY - roomScreenY)
-			}
+		if hasArrow {
+			last, prev := path[len(path)-1], path[len(path)-2]
+			dx := last.X - prev.X
+			dy := last.Y - prev.Y
 
 			length := math.Sqrt(dx*dx + dy*dy)
 			if length > 0 {
 				dx /= length
 				dy /= length
-				r.drawArrowHead(img, lastX, lastY, dx, dy, lineColor)
+				r.drawArrowHead(img, int(math.Round(last.X)), int(math.Round(last.Y)), dx, dy, lineColor, r.config.ExitWidth)
 			}
 		}
 	}
@@ -645,17 +983,53 @@ func (r *Renderer) drawAreaExitStub(img *image.RGBA, fromX, fromY, dir int, dirV
 	endX := startX + dirVec[0]*stubLen
 	endY := startY + dirVec[1]*stubLen
 
-	// Use a distinct color for area exits
-	areaExitColor := color.RGBA{R: 200, G: 100, B: 100, A: 255}
-	r.drawLine(img, int(startX), int(startY), int(endX), int(endY), areaExitColor)
+	r.drawExitLine(img, int(startX), int(startY), int(endX), int(endY), areaExitColor)
 
 	// Draw arrow head
-	r.drawArrowHead(img, int(endX), int(endY), dirVec[0], dirVec[1], areaExitColor)
+	r.drawArrowHead(img, int(endX), int(endY), dirVec[0], dirVec[1], areaExitColor, r.config.ExitWidth)
 }
 
-// drawArrowHead draws an arrow head at the given position
-func (r *Renderer) drawArrowHead(img *image.RGBA, x, y int, dx, dy float64, c color.RGBA) {
-	arrowLen := float64(max(4, r.config.RoomSize/4))
+// drawAreaExitLabel prints the name of the area an exit stub leads to just
+// past its arrow, in the stub's color.
+func (r *Renderer) drawAreaExitLabel(img *image.RGBA, fromX, fromY int, dirVec [2]float64, halfRoom float64, areaID int32) {
+	name := r.areaName(areaID)
+	face := r.face(max(r.config.RoomSize*2/5, 8))
+	if face == nil {
+		return
+	}
+	metrics := face.Metrics()
+	w := font.MeasureString(face, name).Ceil() + 2
+	h := (metrics.Ascent + metrics.Descent).Ceil()
+
+	// The stub ends 2.2 half rooms out; the label's near side clears it
+	tip := halfRoom*2.2 + 2
+	x := fromX + int(dirVec[0]*tip)
+	y := fromY + int(dirVec[1]*tip)
+	switch {
+	case dirVec[0] > 0:
+		x += w / 2
+	case dirVec[0] < 0:
+		x -= w / 2
+	}
+	switch {
+	case dirVec[1] > 0:
+		y += h / 2
+	case dirVec[1] < 0:
+		y -= h / 2
+	}
+	r.drawSmallLabel(img, x, y, name, areaExitColor)
+}
+
+// drawArrowHead draws an arrow head at the given position with a pen
+// width pixels wide
+func (r *Renderer) drawArrowHead(img *image.RGBA, x, y int, dx, dy float64, c color.RGBA, width float64) {
+	r.drawArrowHeadSized(img, x, y, dx, dy, c, width, float64(max(4, r.config.RoomSize/4)))
+}
+
+// drawArrowHeadSized draws an arrow head size pixels long, lengthened to
+// fit the pen.
+func (r *Renderer) drawArrowHeadSized(img *image.RGBA, x, y int, dx, dy float64, c color.RGBA, width, size float64) {
+	arrowLen := size + math.Max(width-1, 0)
 	arrowAngle := math.Pi / 6 // 30 degrees
 
 	sin1 := math.Sin(arrowAngle)
@@ -667,15 +1041,14 @@ func (r *Renderer) drawArrowHead(img *image.RGBA, x, y int, dx, dy float64, c co
 	ax2 := float64(x) - arrowLen*(dx*cos1+dy*sin1)
 	ay2 := float64(y) - arrowLen*(dy*cos1-dx*sin1)
 
-	r.drawLine(img, x, y, int(ax1), int(ay1), c)
-	r.drawLine(img, x, y, int(ax2), int(ay2), c)
+	stroke(img, x, y, int(ax1), int(ay1), c, width, nil, 0)
+	stroke(img, x, y, int(ax2), int(ay2), c, width, nil, 0)
 }
 
 // drawDoor draws door indicators on an exit
 func (r *Renderer) drawDoor(img *image.RGBA, room *mapparser.MudletRoom, dir int, x1, y1, x2, y2 int) {
-	dirName := mapparser.ExitDirectionShortNames[dir]
-	doorStatus, hasDoor := room.Doors[dirName]
-	if !hasDoor || doorStatus == 0 {
+	doorStatus := room.DoorState(dir)
+	if doorStatus == mapparser.DoorNone {
 		return
 	}
 
@@ -683,23 +1056,25 @@ func (r *Renderer) drawDoor(img *image.RGBA, room *mapparser.MudletRoom, dir int
 	midX := (x1 + x2) / 2
 	midY := (y1 + y2) / 2
 
-	// Door colors from Mudlet
 	var doorColor color.RGBA
 	switch doorStatus {
 	case 1: // Open
-		doorColor = color.RGBA{R: 10, G: 155, B: 10, A: 255}
+		doorColor = doorOpenColor
 	case 2: // Closed
-		doorColor = color.RGBA{R: 155, G: 155, B: 10, A: 255}
+		doorColor = doorClosedColor
 	case 3: // Locked
-		doorColor = color.RGBA{R: 155, G: 10, B: 10, A: 255}
+		doorColor = doorLockedColor
 	default:
 		return
 	}
 
-	// Draw X shape for door
-	doorSize := max(3, r.config.RoomSize/6)
-	r.drawLine(img, midX-doorSize, midY-doorSize, midX+doorSize, midY+doorSize, doorColor)
-	r.drawLine(img, midX+doorSize, midY-doorSize, midX-doorSize, midY+doorSize, doorColor)
+	r.drawDoorMark(img, midX, midY, max(3, r.config.RoomSize/6), doorColor)
+}
+
+// drawDoorMark draws the X shape used for doors
+func (r *Renderer) drawDoorMark(img *image.RGBA, x, y, size int, c color.RGBA) {
+	r.drawLine(img, x-size, y-size, x+size, y+size, c)
+	r.drawLine(img, x+size, y-size, x-size, y+size, c)
 }
 
 // hasReturnExit checks if destRoom has an exit back to srcRoomID in the opposite direction
@@ -711,64 +1086,82 @@ func (r *Renderer) hasReturnExit(srcRoomID int32, destRoom *mapparser.MudletRoom
 	return destRoom.Exits[opposite[direction]] == srcRoomID
 }
 
-// drawOtherLevelRooms draws rooms from other z-levels with transparency
-func (r *Renderer) drawOtherLevelRooms(img *image.RGBA, rooms []*mapparser.MudletRoom,
-	centerX, centerY int32, halfWidth, halfHeight, spacing int, isLower bool) {
+// drawRoomShape draws a bare room, filled with c and with its border, but
+// without its markings.
+func (r *Renderer) drawRoomShape(img *image.RGBA, x, y int, c color.RGBA) {
+	halfSize := r.config.RoomSize / 2
+	if r.config.RoomRound {
+		r.drawFilledCircle(img, x, y, halfSize, c)
+	} else {
+		r.drawFilledRect(img, x-halfSize, y-halfSize, r.config.RoomSize, r.config.RoomSize, c)
+	}
+	if !r.config.RoomBorder {
+		return
+	}
+	for i := range min(r.config.ui(1), halfSize) {
+		if r.config.RoomRound {
+			r.drawCircleOutline(img, x, y, halfSize-i, r.config.BorderColor)
+		} else {
+			r.drawRectOutline(img, x-halfSize+i, y-halfSize+i, r.config.RoomSize-2*i, r.config.RoomSize-2*i, r.config.BorderColor)
+		}
+	}
+}
 
-	var levelColor color.RGBA
-	var offsetX, offsetY int
+// drawOtherLevel draws the rooms and exits of the level depth levels below
+// (isLower) or above the rendered one, in their own shapes and colors,
+// shifted down-left or up-right by 2 pixels per level and faded to
+// LowerLevelAlpha or UpperLevelAlpha, divided by depth. The level is drawn
+// opaque on its own layer first, so that overlapping rooms and exits fade
+// evenly.
+func (r *Renderer) drawOtherLevel(img *image.RGBA, level *levelRooms, customEnvColors map[int32]color.RGBA,
+	centerX, centerY int32, halfWidth, halfHeight, spacing int, areaID int32, depth int, isLower bool) {
 
+	alpha, offset := r.config.UpperLevelAlpha, image.Pt(2*depth, -2*depth)
 	if isLower {
-		levelColor = color.RGBA{R: 50, G: 50, B: 70, A: r.config.LowerLevelAlpha}
-		offsetX, offsetY = -2, 2 // Offset down-left
-	} else {
-		levelColor = color.RGBA{R: 70, G: 70, B: 50, A: r.config.UpperLevelAlpha}
-		offsetX, offsetY = 2, -2 // Offset up-right
+		alpha, offset = r.config.LowerLevelAlpha, image.Pt(-2*depth, 2*depth)
+	}
+	if alpha == 0 || len(level.rooms) == 0 {
+		return
 	}
 
-	halfSize := r.config.RoomSize / 2
+	// Neighboring areas are shown on the rendered level only
+	if r.config.AreaGhosts {
+		cfg := *r.config
+		cfg.AreaGhosts = false
+		level := *r
+		level.config = &cfg
+		r = &level
+	}
 
-	for _, room := range rooms {
-		screenX, screenY := r.roomToScreen(room, centerX, centerY, halfWidth, halfHeight, spacing)
-		screenX += offsetX
-		screenY += offsetY
+	// The layer covers the pixels shifted onto img
+	layer := image.NewRGBA(img.Bounds().Sub(offset))
+	rooms := level.in(layer.Rect)
+	r.drawExits(layer, rooms, level, centerX, centerY, halfWidth, halfHeight, spacing, areaID)
 
-		if isLower {
-			r.drawFilledRect(img, screenX-halfSize, screenY-halfSize, r.config.RoomSize, r.config.RoomSize, levelColor)
-		} else {
-			r.drawRectOutline(img, screenX-halfSize, screenY-halfSize, r.config.RoomSize, r.config.RoomSize, levelColor)
-		}
+	for _, room := range rooms {
+		x, y := r.roomToScreen(room, centerX, centerY, halfWidth, halfHeight, spacing)
+		r.drawRoomShape(layer, x, y, r.getEnvColor(room.Environment, customEnvColors))
 	}
+
+	mask := image.NewUniform(color.Alpha{A: alpha / uint8(min(depth, 255))})
+	draw.DrawMask(img, img.Bounds(), layer, layer.Bounds().Min, mask, image.Point{}, draw.Over)
 }
 
 // getEnvColor returns the color for an environment ID
 // Mudlet behavior: if env is not in mEnvColors AND not in mCustomEnvColors,
 // it defaults to env=1 (red). We replicate this behavior.
 func (r *Renderer) getEnvColor(env int32, customColors map[int32]color.RGBA) color.RGBA {
-	// First check mEnvColors mapping
-	if mappedEnv, ok := r.mapData.EnvColors[env]; ok {
-		env = mappedEnv
-	}
-
-	// If env is NOT a default color (1-16) and NOT in customColors,
-	// fall back to env=1 (red) like Mudlet does
-	_, isDefault := r.config.DefaultEnvColors[env]
-	_, isCustom := customColors[env]
-	if !isDefault && !isCustom {
-		env = 1 // Default to red
-	}
-
+	// Resolve the environment as the map's Environments do, so legends
+	// agree with the image; the default colors come from the
+	// configuration, which themes may replace
+	env, _, _ = r.mapData.ResolveEnvColor(env)
 	return envToColor(env, customColors, r.config.DefaultEnvColors)
 }
 
 // Drawing primitives
 
 func (r *Renderer) drawFilledRect(img *image.RGBA, x, y, w, h int, c color.RGBA) {
-	for dy := 0; dy < h; dy++ {
-		for dx := 0; dx < w; dx++ {
-			blendPixel(img, x+dx, y+dy, c)
-		}
-	}
+	blendRect(img, x, y, w, h, c)
 }
 
 func (r *Renderer) drawRectOutline(img *image.RGBA, x, y, w, h int, c color.RGBA) {
@@ -819,109 +1212,22 @@ func (r *Renderer) drawCircleOutline(img *image.RGBA, cx, cy, radius int, c colo
 }
 
 func (r *Renderer) drawLine(img *image.RGBA, x1, y1, x2, y2 int, c color.RGBA) {
-	dx := abs(x2 - x1)
-	dy := abs(y2 - y1)
-	sx := 1
-	if x1 >= x2 {
-		sx = -1
-	}
-	sy := 1
-	if y1 >= y2 {
-		sy = -1
-	}
-	err := dx - dy
-
-	for {
-		setPixelSafe(img, x1, y1, c)
-
-		if x1 == x2 && y1 == y2 {
-			break
-		}
-
-		e2 := 2 * err
-		if e2 > -dy {
-			err -= dy
-			x1 += sx
-		}
-		if e2 < dx {
-			err += dx
-			y1 += sy
-		}
-	}
+	stroke(img, x1, y1, x2, y2, c, 1, nil, 0)
 }
 
-func (r *Renderer) drawDottedLine(img *image.RGBA, x1, y1, x2, y2 int, c color.RGBA) {
-	dx := abs(x2 - x1)
-	dy := abs(y2 - y1)
-	sx := 1
-	if x1 >= x2 {
-		sx = -1
-	}
-	sy := 1
-	if y1 >= y2 {
-		sy = -1
-	}
-	err := dx - dy
-	step := 0
-
-	for {
-		// Draw every 4th pixel for dotted effect (dot on, 3 off)
-		if step%4 == 0 {
-			setPixelSafe(img, x1, y1, c)
-		}
-		step++
-
-		if x1 == x2 && y1 == y2 {
-			break
-		}
-
-		e2 := 2 * err
-		if e2 > -dy {
-			err -= dy
-			x1 += sx
-		}
-		if e2 < dx {
-			err += dx
-			y1 += sy
-		}
-	}
+// drawExitLine draws a solid line with the pen of Config.ExitWidth.
+func (r *Renderer) drawExitLine(img *image.RGBA, x1, y1, x2, y2 int, c color.RGBA) {
+	stroke(img, x1, y1, x2, y2, c, r.config.ExitWidth, nil, 0)
 }
 
-func (r *Renderer) drawDashedLine(img *image.RGBA, x1, y1, x2, y2 int, c color.RGBA) {
-	dx := abs(x2 - x1)
-	dy := abs(y2 - y1)
-	sx := 1
-	if x1 >= x2 {
-		sx = -1
-	}
-	sy := 1
-	if y1 >= y2 {
-		sy = -1
-	}
-	err := dx - dy
-	step := 0
-
-	for {
-		// Draw 6 pixels on, 4 pixels off for dashed effect
-		if step%10 < 6 {
-			setPixelSafe(img, x1, y1, c)
-		}
-		step++
-
-		if x1 == x2 && y1 == y2 {
-			break
-		}
+// drawDottedLine draws a dotted line with a pen width pixels wide.
+func (r *Renderer) drawDottedLine(img *image.RGBA, x1, y1, x2, y2 int, c color.RGBA, width float64) {
+	stroke(img, x1, y1, x2, y2, c, width, dotted, 0)
+}
 
-		e2 := 2 * err
-		if e2 > -dy {
-			err -= dy
-			x1 += sx
-		}
-		if e2 < dx {
-			err += dx
-			y1 += sy
-		}
-	}
+// drawDashedLine draws a dashed line with a pen width pixels wide.
+func (r *Renderer) drawDashedLine(img *image.RGBA, x1, y1, x2, y2 int, c color.RGBA, width float64) {
+	stroke(img, x1, y1, x2, y2, c, width, dashed, 0)
 }
 
 func (r *Renderer) drawTriangleUp(img *image.RGBA, cx, cy, size int, c color.RGBA) {
@@ -1004,18 +1310,9 @@ func (r *Renderer) fillTriangleHatch(img *image.RGBA, a, b, c fPoint, col color.
 	maxY := int(math.Ceil(max3(a.Y, b.Y, c.Y)))
 
 	// Clamp to image bounds
-	if minX < 0 {
-		minX = 0
-	}
-	if minY < 0 {
-		minY = 0
-	}
-	if maxX > img.Bounds().Max.X-1 {
-		maxX = img.Bounds().Max.X - 1
-	}
-	if maxY > img.Bounds().Max.Y-1 {
-		maxY = img.Bounds().Max.Y - 1
-	}
+	bounds := img.Bounds()
+	minX, minY = max(minX, bounds.Min.X), max(minY, bounds.Min.Y)
+	maxX, maxY = min(maxX, bounds.Max.X-1), min(maxY, bounds.Max.Y-1)
 
 	for y := minY; y <= maxY; y++ {
 		for x := minX; x <= maxX; x++ {
@@ -1110,100 +1407,25 @@ func (r *Renderer) drawTriangleDownOutline(img *image.RGBA, cx, cy, size int, c
 	r.drawLine(img, leftX, leftY, rightX, rightY, c)
 }
 
-// Bitmap font for common characters (5x7 pixels)
-var bitmapFont = map[rune][]uint8{
-	'A': {0x0E, 0x11, 0x11, 0x1F, 0x11, 0x11, 0x11},
-	'B': {0x1E, 0x11, 0x11, 0x1E, 0x11, 0x11, 0x1E},
-	'C': {0x0E, 0x11, 0x10, 0x10, 0x10, 0x11, 0x0E},
-	'D': {0x1C, 0x12, 0x11, 0x11, 0x11, 0x12, 0x1C},
-	'E': {0x1F, 0x10, 0x10, 0x1E, 0x10, 0x10, 0x1F},
-	'F': {0x1F, 0x10, 0x10, 0x1E, 0x10, 0x10, 0x10},
-	'G': {0x0E, 0x11, 0x10, 0x17, 0x11, 0x11, 0x0F},
-	'H': {0x11, 0x11, 0x11, 0x1F, 0x11, 0x11, 0x11},
-	'I': {0x0E, 0x04, 0x04, 0x04, 0x04, 0x04, 0x0E},
-	'J': {0x07, 0x02, 0x02, 0x02, 0x02, 0x12, 0x0C},
-	'K': {0x11, 0x12, 0x14, 0x18, 0x14, 0x12, 0x11},
-	'L': {0x10, 0x10, 0x10, 0x10, 0x10, 0x10, 0x1F},
-	'M': {0x11, 0x1B, 0x15, 0x15, 0x11, 0x11, 0x11},
-	'N': {0x11, 0x11, 0x19, 0x15, 0x13, 0x11, 0x11},
-	'O': {0x0E, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0E},
-	'P': {0x1E, 0x11, 0x11, 0x1E, 0x10, 0x10, 0x10},
-	'Q': {0x0E, 0x11, 0x11, 0x11, 0x15, 0x12, 0x0D},
-	'R': {0x1E, 0x11, 0x11, 0x1E, 0x14, 0x12, 0x11},
-	'S': {0x0E, 0x11, 0x10, 0x0E, 0x01, 0x11, 0x0E},
-	'T': {0x1F, 0x04, 0x04, 0x04, 0x04, 0x04, 0x04},
-	'U': {0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0E},
-	'V': {0x11, 0x11, 0x11, 0x11, 0x11, 0x0A, 0x04},
-	'W': {0x11, 0x11, 0x11, 0x15, 0x15, 0x15, 0x0A},
-	'X': {0x11, 0x11, 0x0A, 0x04, 0x0A, 0x11, 0x11},
-	'Y': {0x11, 0x11, 0x0A, 0x04, 0x04, 0x04, 0x04},
-	'Z': {0x1F, 0x01, 0x02, 0x04, 0x08, 0x10, 0x1F},
-	'0': {0x0E, 0x11, 0x13, 0x15, 0x19, 0x11, 0x0E},
-	'1': {0x04, 0x0C, 0x04, 0x04, 0x04, 0x04, 0x0E},
-	'2': {0x0E, 0x11, 0x01, 0x0E, 0x10, 0x10, 0x1F},
-	'3': {0x0E, 0x11, 0x01, 0x06, 0x01, 0x11, 0x0E},
-	'4': {0x02, 0x06, 0x0A, 0x12, 0x1F, 0x02, 0x02},
-	'5': {0x1F, 0x10, 0x1E, 0x01, 0x01, 0x11, 0x0E},
-	'6': {0x06, 0x08, 0x10, 0x1E, 0x11, 0x11, 0x0E},
-	'7': {0x1F, 0x01, 0x02, 0x04, 0x08, 0x08, 0x08},
-	'8': {0x0E, 0x11, 0x11, 0x0E, 0x11, 0x11, 0x0E},
-	'9': {0x0E, 0x11, 0x11, 0x0F, 0x01, 0x02, 0x0C},
-}
-
-// drawBitmapChar draws a character from bitmap font, returns true if character was found
-func (r *Renderer) drawBitmapChar(img *image.RGBA, cx, cy int, ch rune, c color.RGBA) bool {
-	// Convert lowercase to uppercase
-	if ch >= 'a' && ch <= 'z' {
-		ch = ch - 'a' + 'A'
-	}
-
-	bitmap, ok := bitmapFont[ch]
-	if !ok {
-		return false
-	}
-
-	// Font is 5x7, draw centered at cx, cy
-	startX := cx - 2
-	startY := cy - 3
-
-	for row, rowData := range bitmap {
-		for col := 0; col < 5; col++ {
-			if (rowData & (0x10 >> col)) != 0 {
-				setPixelSafe(img, startX+col, startY+row, c)
-			}
-		}
-	}
+// Door colors from Mudlet
+var (
+	doorOpenColor   = color.RGBA{R: 10, G: 155, B: 10, A: 255}
+	doorClosedColor = color.RGBA{R: 155, G: 155, B: 10, A: 255}
+	doorLockedColor = color.RGBA{R: 155, G: 10, B: 10, A: 255}
+)
 
-	return true
-}
+// Colors for one-way exits and exits leading to other areas
+var (
+	oneWayExitColor = color.RGBA{R: 180, G: 180, B: 180, A: 180}
+	areaExitColor   = color.RGBA{R: 200, G: 100, B: 100, A: 255}
+)
 
 // Helper functions
 
 func setPixelSafe(img *image.RGBA, x, y int, c color.RGBA) {
-	if x >= 0 && x < img.Bounds().Max.X && y >= 0 && y < img.Bounds().Max.Y {
-		img.Set(x, y, c)
-	}
-}
-
-func blendPixel(img *image.RGBA, x, y int, c color.RGBA) {
-	if x < 0 || x >= img.Bounds().Max.X || y < 0 || y >= img.Bounds().Max.Y {
-		return
+	if (image.Point{X: x, Y: y}).In(img.Rect) {
+		img.SetRGBA(x, y, c)
 	}
-	if c.A == 255 {
-		img.Set(x, y, c)
-		return
-	}
-
-	existing := img.RGBAAt(x, y)
-	alpha := float64(c.A) / 255.0
-	invAlpha := 1.0 - alpha
-
-	nr := uint8(float64(c.R)*alpha + float64(existing.R)*invAlpha)
-	ng := uint8(float64(c.G)*alpha + float64(existing.G)*invAlpha)
-	nb := uint8(float64(c.B)*alpha + float64(existing.B)*invAlpha)
-	na := uint8(float64(c.A) + float64(existing.A)*invAlpha)
-
-	img.Set(x, y, color.RGBA{R: nr, G: ng, B: nb, A: na})
 }
 
 func abs(x int) int {
@@ -1280,29 +1502,36 @@ func (r *Renderer) drawLabels(img *image.RGBA, areaID, centerZ int32, showOnTop
 			screenY+height < 0 || screenY > r.config.Height {
 			continue
 		}
+		// or off the stripe drawn; unscaled images reach right and down
+		// by their own size
+		if b := img.Bounds(); screenX >= b.Max.X || screenY >= b.Max.Y ||
+			!lbl.NoScaling && (screenX+width <= b.Min.X || screenY+height <= b.Min.Y) {
+			continue
+		}
 
 		// Draw image if available
-		if len(lbl.Pixmap) > 0 {
-			// Decode PNG data
-			lblImg, err := png.Decode(bytes.NewReader(lbl.Pixmap))
-			if err == nil {
-				destRect := image.Rect(screenX, screenY, screenX+width, screenY+height)
-
-				if !lbl.NoScaling {
-					// Scale to fit width/height
-					r.drawScaled(img, destRect, lblImg)
-				} else {
-					// Draw unscaled at position
-					// In Mudlet, NoScaling means it ignores lbl.Width/Height for rendering size,
-					// and uses the original image size.
-					bounds := lblImg.Bounds()
-					targetRect := image.Rect(screenX, screenY, screenX+bounds.Dx(), screenY+bounds.Dy())
-					draw.Draw(img, targetRect, lblImg, bounds.Min, draw.Over)
-				}
+		lblImg, err := lbl.Image()
+		if err == nil && lblImg != nil {
+			destRect := image.Rect(screenX, screenY, screenX+width, screenY+height)
+
+			if !lbl.NoScaling {
+				// Scale to fit width/height
+				r.drawScaled(img, destRect, lblImg)
+			} else {
+				// Draw unscaled at position
+				// In Mudlet, NoScaling means it ignores lbl.Width/Height for rendering size,
+				// and uses the original image size.
+				bounds := lblImg.Bounds()
+				targetRect := image.Rect(screenX, screenY, screenX+bounds.Dx(), screenY+bounds.Dy())
+				draw.Draw(img, targetRect, lblImg, bounds.Min, draw.Over)
 			}
 		}
-		// TODO: Handle text-only labels if Pixmap is missing?
-		// Mudlet usually includes rendered text in Pixmap.
+		if (err != nil || lblImg == nil) && lbl.Text != "" {
+			// Mudlet stores labels with their text rendered into the
+			// pixmap; draw the text for labels that come without a
+			// usable one.
+			r.drawTextLabel(img, lbl, image.Rect(screenX, screenY, screenX+width, screenY+height))
+		}
 	}
 }
 
@@ -1323,13 +1552,8 @@ func (r *Renderer) drawScaled(dst *image.RGBA, rect image.Rectangle, src image.I
 	x0 := rect.Min.X
 	y0 := rect.Min.Y
 
-	// Clip against destination bounds
-	if x0 < 0 {
-		// Optimization needed but for now simple loop check
-		// or advanced clipping logic
-	}
-
 	dstBounds := dst.Bounds()
+	pixel := pixelReader(src)
 
 	for y := 0; y < h; y++ {
 		dy := y0 + y
@@ -1337,20 +1561,12 @@ func (r *Renderer) drawScaled(dst *image.RGBA, rect image.Rectangle, src image.I
 			continue
 		}
 
-		sy := (y * sh) / h
-		for x := 0; x < w; x++ {
-			dx := x0 + x
-			if dx < dstBounds.Min.X || dx >= dstBounds.Max.X {
-				continue
+		sy := srcBounds.Min.Y + (y*sh)/h
+		for x := max(0, dstBounds.Min.X-x0); x < w && x0+x < dstBounds.Max.X; x++ {
+			sx := srcBounds.Min.X + (x*sw)/w
+			if c := pixel(sx, sy); c.A != 0 {
+				blendInto(dst.Pix[dst.PixOffset(x0+x, dy):], c)
 			}
-
-			sx := (x * sw) / w
-
-			// Get source color
-			c := src.At(srcBounds.Min.X+sx, srcBounds.Min.Y+sy)
-
-			// Blend pixel
-			blendPixel(dst, dx, dy, colorToRGBA(c))
 		}
 	}
 }
@@ -2,6 +2,8 @@ package maprenderer
 
 import (
 	"bytes"
+	"errors"
+	"image"
 	"image/color"
 	"testing"
 
@@ -54,6 +56,32 @@ func TestEnvToColor(t *testing.T) {
 	}
 }
 
+// TestExtractedCustomEnvColor tests that rooms of an extracted area keep
+// the custom colors their environments are mapped to
+func TestExtractedCustomEnvColor(t *testing.T) {
+	m := testGridMap(3)
+	m.Rooms[6].Environment = 300
+	m.EnvColors[300] = 400
+	m.CustomEnvColors[400] = mapparser.Color{Spec: 1, Red: 0x2020, Green: 0x4040, Blue: 0x6060, Alpha: 0xffff}
+	sub, err := mapparser.ExtractArea(m, 1)
+	if err != nil {
+		t.Fatalf("ExtractArea failed: %v", err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 120, 120
+	r := NewRenderer(cfg)
+	r.SetMap(sub)
+	result, err := r.RenderFragment(5)
+	if err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+	want := color.RGBA{R: 0x20, G: 0x40, B: 0x60, A: 255}
+	if got := result.Image.RGBAAt(cfg.Width/2+cfg.RoomSpacing, cfg.Height/2); got != want {
+		t.Errorf("Expected the extracted room in its custom color %v, got %v", want, got)
+	}
+}
+
 func TestEnvToColorANSI256(t *testing.T) {
 	defaultColors := defaultEnvironmentColors()
 	customColors := map[int32]color.RGBA{}
@@ -126,6 +154,23 @@ func TestGetEnvColorWithMudletFallback(t *testing.T) {
 	}
 }
 
+func TestGetEnvColorMatchesEnvironments(t *testing.T) {
+	// Environments mapped into the ANSI palette are drawn in its colors,
+	// as the map's environment list reports them
+	m := testGridMap(2)
+	m.EnvColors[1] = 100
+	r := NewRenderer(nil)
+	r.SetMap(m)
+
+	envs := m.Environments()
+	if len(envs) != 1 || envs[0].Source != mapparser.EnvColorANSI {
+		t.Fatalf("Expected one ANSI environment, got %+v", envs)
+	}
+	if got, want := r.getEnvColor(1, nil), rgba(envs[0].Color); got != want {
+		t.Errorf("getEnvColor(1) = %v, expected the listed color %v", got, want)
+	}
+}
+
 func TestNewRenderer(t *testing.T) {
 	// Test with nil config
 	r := NewRenderer(nil)
@@ -387,3 +432,560 @@ func TestCollectRoomsInArea(t *testing.T) {
 		t.Errorf("collectRoomsInArea with wrong area returned %d rooms, expected 0", len(roomsWrongArea))
 	}
 }
+
+// testGridMap builds an n x n grid of rooms in area 1 on z-level 0, with
+// bidirectional east/west and north/south exits between neighbors.
+// Room IDs start at 1 and increase along X first.
+func testGridMap(n int32) *mapparser.MudletMap {
+	m := mapparser.NewMudletMap()
+	m.Version = 20
+	m.Areas[1] = mapparser.NewMudletArea(1, "Test Area")
+	id := func(x, y int32) int32 { return y*n + x + 1 }
+	for y := int32(0); y < n; y++ {
+		for x := int32(0); x < n; x++ {
+			room := mapparser.NewMudletRoom(id(x, y))
+			room.Area = 1
+			room.X = x
+			room.Y = y
+			room.Environment = 1
+			if x > 0 {
+				room.Exits[mapparser.ExitWest] = id(x-1, y)
+			}
+			if x < n-1 {
+				room.Exits[mapparser.ExitEast] = id(x+1, y)
+			}
+			if y > 0 {
+				room.Exits[mapparser.ExitSouth] = id(x, y-1)
+			}
+			if y < n-1 {
+				room.Exits[mapparser.ExitNorth] = id(x, y+1)
+			}
+			m.Rooms[room.ID] = room
+			m.Areas[1].Rooms = append(m.Areas[1].Rooms, uint32(room.ID))
+		}
+	}
+	m.Areas[1].ZLevels = []int32{0}
+	return m
+}
+
+func TestFlagBadges(t *testing.T) {
+	m := testGridMap(3)
+	m.Rooms[1].UserData["no_pk"] = "1"
+	m.Rooms[1].UserData["terrain"] = "Water"
+
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 200, 200
+	r := NewRenderer(cfg)
+	r.SetMap(m)
+
+	// Room 1 is one step west and south of the center room 5
+	x := cfg.Width/2 - cfg.RoomSpacing - cfg.RoomSize/2 + 2
+	y := cfg.Height/2 + cfg.RoomSpacing - cfg.RoomSize/2 + 2
+
+	plain, err := r.RenderFragment(5)
+	if err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+
+	cfg.FlagRules = mapparser.DefaultFlagRules()
+	flagged, err := r.RenderFragment(5)
+	if err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+
+	if got := flagged.Image.RGBAAt(x, y); got != cfg.FlagColors[mapparser.FlagNoPK] {
+		t.Errorf("Top-left badge color = %v, expected no-PK color", got)
+	}
+	if plain.Image.RGBAAt(x, y) == flagged.Image.RGBAAt(x, y) {
+		t.Error("Expected badge to change the rendered image")
+	}
+	topRight := x + cfg.RoomSize - cfg.RoomSize/4 - 1
+	if got := flagged.Image.RGBAAt(topRight, y); got != cfg.FlagColors[mapparser.FlagWater] {
+		t.Errorf("Top-right badge color = %v, expected water color", got)
+	}
+}
+
+func TestGridAndAxes(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 200, 200
+	r := NewRenderer(cfg)
+	r.SetMap(testGridMap(3))
+
+	plain, err := r.RenderFragment(5)
+	if err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+
+	cfg.ShowGrid = true
+	cfg.ShowAxes = true
+	gridded, err := r.RenderFragment(5)
+	if err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+
+	// A grid line runs through room columns, outside the rooms themselves
+	x, y := cfg.Width/2+3*cfg.RoomSpacing, cfg.Height-20
+	if plain.Image.RGBAAt(x, y) == gridded.Image.RGBAAt(x, y) {
+		t.Error("Expected grid line to change the background")
+	}
+	// Between grid lines the background is unchanged
+	if plain.Image.RGBAAt(x+cfg.RoomSpacing/2, y+3) != gridded.Image.RGBAAt(x+cfg.RoomSpacing/2, y+3) {
+		t.Error("Expected background between grid lines to be unchanged")
+	}
+	// Axis tick at the top edge
+	if got := gridded.Image.RGBAAt(x, 0); got != cfg.AxisColor {
+		t.Errorf("Expected axis tick color at top edge, got %v", got)
+	}
+}
+
+func TestLegend(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 200, 200
+	r := NewRenderer(cfg)
+	r.SetMap(testGridMap(1))
+
+	plain, err := r.RenderFragment(1)
+	if err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+	cfg.ShowLegend = true
+	withLegend, err := r.RenderFragment(1)
+	if err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+
+	// Panel border
+	if got := withLegend.Image.RGBAAt(6, cfg.Height-7); got != cfg.BorderColor {
+		t.Errorf("Expected legend border at bottom-left, got %v", got)
+	}
+	// The top-right corner is untouched
+	if plain.Image.RGBAAt(cfg.Width-2, 1) != withLegend.Image.RGBAAt(cfg.Width-2, 1) {
+		t.Error("Expected legend to leave the rest of the image unchanged")
+	}
+}
+
+func TestAttribution(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 200, 200
+	cfg.ShowAttribution = true
+	r := NewRenderer(cfg)
+	m := testGridMap(1)
+	r.SetMap(m)
+
+	plain, err := r.RenderFragment(1)
+	if err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+	m.SetAttribution(mapparser.Attribution{Author: "Jane", License: "CC-BY-4.0"})
+	signed, err := r.RenderFragment(1)
+	if err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+
+	if !regionDiffers(plain.Image, signed.Image, image.Rect(cfg.Width-60, cfg.Height-13, cfg.Width, cfg.Height)) {
+		t.Error("Expected attribution in the bottom-right corner")
+	}
+	if regionDiffers(plain.Image, signed.Image, image.Rect(0, 0, cfg.Width, cfg.Height-14)) {
+		t.Error("Expected attribution to leave the rest of the image unchanged")
+	}
+}
+
+func TestRoomValues(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 200, 200
+	cfg.ShowSymbol = false
+	r := NewRenderer(cfg)
+	r.SetMap(testGridMap(1))
+
+	plain, err := r.RenderFragment(1)
+	if err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+	cfg.RoomValues = map[int32]int{1: 7}
+	short, err := r.RenderFragment(1)
+	if err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+	cfg.RoomValues = map[int32]int{1: 123456}
+	long, err := r.RenderFragment(1)
+	if err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+
+	cx, cy := cfg.Width/2, cfg.Height/2
+	roomBounds := image.Rect(cx-cfg.RoomSize/2, cy-cfg.RoomSize/2, cx+cfg.RoomSize/2, cy+cfg.RoomSize/2)
+	below := image.Rect(cx-20, cy+cfg.RoomSize/2+1, cx+20, cy+cfg.RoomSize/2+10)
+
+	if !regionDiffers(plain.Image, short.Image, roomBounds) {
+		t.Error("Expected short value drawn inside the room")
+	}
+	if regionDiffers(plain.Image, short.Image, below) {
+		t.Error("Expected nothing below the room for a short value")
+	}
+	if !regionDiffers(plain.Image, long.Image, below) {
+		t.Error("Expected long value drawn below the room")
+	}
+}
+
+// regionDiffers reports whether two images differ anywhere inside rect.
+func regionDiffers(a, b *image.RGBA, rect image.Rectangle) bool {
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			if a.RGBAAt(x, y) != b.RGBAAt(x, y) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestContrastColor(t *testing.T) {
+	if got := contrastColor(color.RGBA{R: 255, G: 255, B: 0, A: 255}); got.R != 0 {
+		t.Errorf("Expected black text on yellow, got %v", got)
+	}
+	if got := contrastColor(color.RGBA{R: 0, G: 0, B: 128, A: 255}); got.R != 255 {
+		t.Errorf("Expected white text on dark blue, got %v", got)
+	}
+}
+
+// TestRoomAt tests mapping pixels of a rendered image back to rooms
+func TestRoomAt(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 200, 200
+	cfg.RoomSize, cfg.RoomSpacing = 10, 20
+	r := NewRenderer(cfg)
+
+	m := mapparser.NewMudletMap()
+	m.Areas[1] = mapparser.NewMudletArea(1, "Test Area")
+	for i := int32(0); i < 9; i++ {
+		room := mapparser.NewMudletRoom(i + 1)
+		room.Area = 1
+		room.X = i % 3
+		room.Y = i / 3
+		m.Rooms[i+1] = room
+	}
+	r.SetMap(m)
+
+	result, err := r.RenderFragment(5) // room 5 is at (1, 1)
+	if err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+	if result.CenterX != 1 || result.CenterY != 1 {
+		t.Errorf("center = (%d, %d), want (1, 1)", result.CenterX, result.CenterY)
+	}
+
+	tests := []struct {
+		name   string
+		px, py int
+		want   int32
+	}{
+		{"center", 100, 100, 5},
+		{"inside room", 103, 96, 5},
+		{"east neighbour", 120, 100, 6},
+		{"north is up", 100, 80, 8},
+		{"between rooms", 110, 100, 0},
+		{"no room", 10, 10, 0},
+	}
+	for _, tt := range tests {
+		var id int32
+		if room := r.RoomAt(result, tt.px, tt.py); room != nil {
+			id = room.ID
+		}
+		if id != tt.want {
+			t.Errorf("%s: RoomAt(%d, %d) = room %d, want %d", tt.name, tt.px, tt.py, id, tt.want)
+		}
+	}
+}
+
+// TestZoom tests that Zoom scales room sizes and text together
+func TestZoom(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 200, 200
+	cfg.RoomSize, cfg.RoomSpacing, cfg.ExitWidth = 20, 40, 4
+	r := NewRenderer(cfg)
+	r.SetMap(testGridMap(3))
+	want, err := r.RenderFragment(5)
+	if err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+
+	cfg.RoomSize, cfg.RoomSpacing, cfg.ExitWidth, cfg.Zoom = 10, 20, 2, 2
+	got, err := r.RenderFragment(5)
+	if err != nil {
+		t.Fatalf("RenderFragment with zoom failed: %v", err)
+	}
+	if !imagesEqual(got, want) {
+		t.Error("Expected Zoom 2 to match doubled room size, spacing and exit width")
+	}
+	if got.RoomSpacing != 40 || got.RoomSize != 20 || cfg.RoomSpacing != 20 {
+		t.Errorf("Expected zoomed sizes in the result only, got spacing %d, size %d, config %d",
+			got.RoomSpacing, got.RoomSize, cfg.RoomSpacing)
+	}
+	if room := r.RoomAt(got, 140, 100); room == nil || room.ID != 6 {
+		t.Errorf("RoomAt(east neighbour) = %v, want room 6", room)
+	}
+
+	// Room values are drawn twice as large
+	cfg.ShowSymbol = false
+	valueInk := func() image.Rectangle {
+		t.Helper()
+		cfg.RoomValues = nil
+		bare, err := r.RenderFragment(5)
+		if err != nil {
+			t.Fatalf("RenderFragment failed: %v", err)
+		}
+		cfg.RoomValues = map[int32]int{5: 1}
+		valued, err := r.RenderFragment(5)
+		if err != nil {
+			t.Fatalf("RenderFragment failed: %v", err)
+		}
+		return inkBounds(valued.Image, bare.Image, valued.Image.Bounds())
+	}
+	zoomed := valueInk()
+	cfg.Zoom = 1
+	plain := valueInk()
+	if plain.Empty() || abs(zoomed.Dy()-2*plain.Dy()) > 1 {
+		t.Errorf("Expected the value twice as tall as %v, got %v", plain, zoomed)
+	}
+}
+
+func TestZoomedKeepsState(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Zoom = 2
+	r := NewRenderer(cfg)
+	r.shift = image.Pt(3, -4)
+	r.target = image.NewRGBA(image.Rect(0, 0, 10, 10))
+	z := r.zoomed()
+	if z.config.RoomSize != 2*cfg.RoomSize || z.textScale != 2 {
+		t.Errorf("Expected a zoomed configuration, got room size %d, text scale %d", z.config.RoomSize, z.textScale)
+	}
+	if z.shift != r.shift || z.target != r.target || z.fonts != r.fonts {
+		t.Error("Expected the zoomed renderer to keep the shift, target and font cache")
+	}
+	if g := r.gridded(); g.shift != r.shift || g.target != r.target || !g.grid {
+		t.Error("Expected the grid-mode renderer to keep the shift and target")
+	}
+}
+
+func TestGridMode(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 200, 200
+	cfg.ShowSymbol = false
+	r := NewRenderer(cfg)
+	m := testGridMap(3)
+	r.SetMap(m)
+	plain, err := r.RenderFragment(5)
+	if err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+
+	m.Areas[1].GridMode = true
+	grid, err := r.RenderFragment(5)
+	if err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+	// Rooms 25 pixels apart become 25-pixel tiles that touch, so the gap
+	// between rooms 5 and 6 is room color instead of an exit line.
+	room := grid.Image.RGBAAt(100, 100)
+	for _, x := range []int{88, 112, 113, 137} {
+		if c := grid.Image.RGBAAt(x, 100); c != room {
+			t.Errorf("Expected tile color at x=%d, got %v, want %v", x, c, room)
+		}
+	}
+	if c := grid.Image.RGBAAt(100, 88); c != room {
+		t.Errorf("Expected no border on the tile edge, got %v", c)
+	}
+	if grid.RoomSize != cfg.RoomSpacing {
+		t.Errorf("Expected RoomSize %d in grid mode, got %d", cfg.RoomSpacing, grid.RoomSize)
+	}
+	if cfg.RoomSize == cfg.RoomSpacing {
+		t.Error("Expected the caller's config unchanged")
+	}
+
+	// Config.GridMode applies to every area
+	m.Areas[1].GridMode = false
+	cfg.GridMode = true
+	forced, err := r.RenderFragment(5)
+	if err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+	if !imagesEqual(forced, grid) || imagesEqual(forced, plain) {
+		t.Error("Expected Config.GridMode to match the area's grid mode")
+	}
+}
+
+func TestOtherLevels(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 200, 200
+	cfg.ShowSymbol = false
+	r := NewRenderer(cfg)
+	m := testGridMap(3)
+	// Copy rooms 1 and 2 (joined by an exit) one and two levels down, at
+	// the corner away from room 5.
+	for z := int32(1); z <= 2; z++ {
+		for _, id := range []int32{1, 2} {
+			room := *m.Rooms[id]
+			room.ID = id + 100*z
+			room.Z = -z
+			room.Exits = [12]int32{}
+			for dir := range room.Exits {
+				room.Exits[dir] = mapparser.NoExit
+			}
+			m.Rooms[room.ID] = &room
+		}
+		m.Rooms[1+100*z].Exits[mapparser.ExitEast] = 2 + 100*z
+		m.Rooms[2+100*z].Exits[mapparser.ExitWest] = 1 + 100*z
+	}
+	r.SetMap(m)
+	// The lower level is drawn where room 5 (at (100, 100)) has no
+	// neighbours: rooms 101 and 102 at (75, 125) and (100, 125).
+	delete(m.Rooms, 1)
+	delete(m.Rooms, 2)
+	delete(m.Rooms, 3)
+	plain, err := r.RenderFragment(5)
+	if err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+
+	cfg.ShowLowerLevel = true
+	lower, err := r.RenderFragment(5)
+	if err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+	// Shifted 2 pixels down-left, rooms keep their color faded and the exit
+	// between them is drawn.
+	room := lower.Image.RGBAAt(73, 127)
+	if room == plain.Image.RGBAAt(73, 127) {
+		t.Error("Expected the lower level's room drawn")
+	}
+	if room.R <= room.G || room.R <= room.B {
+		t.Errorf("Expected the room's faded red environment color, got %v", room)
+	}
+	if !regionDiffers(lower.Image, plain.Image, image.Rect(86, 120, 89, 134)) {
+		t.Error("Expected the lower level's exit drawn")
+	}
+	if regionDiffers(lower.Image, plain.Image, image.Rect(40, 140, 200, 200)) {
+		t.Error("Expected only one level drawn by default")
+	}
+
+	cfg.OtherLevels = 2
+	two, err := r.RenderFragment(5)
+	if err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+	// The second level is shifted 4 pixels and fainter than the first
+	far, near := two.Image.RGBAAt(62, 137), two.Image.RGBAAt(81, 118)
+	if far == plain.Image.RGBAAt(62, 137) {
+		t.Error("Expected the second level below drawn")
+	}
+	if far.R-far.G >= near.R-near.G {
+		t.Errorf("Expected the second level fainter, got %v and %v", far, near)
+	}
+}
+
+func TestRenderFragmentAtZ(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 200, 200
+	r := NewRenderer(cfg)
+	m := testGridMap(3)
+	upper := mapparser.NewMudletRoom(100)
+	upper.Area, upper.X, upper.Y, upper.Z = 1, 2, 2, 1
+	m.Rooms[100] = upper
+	r.SetMap(m)
+
+	own, err := r.RenderFragmentAtZ(5, 0)
+	if err != nil {
+		t.Fatalf("RenderFragmentAtZ failed: %v", err)
+	}
+	want, err := r.RenderFragment(5)
+	if err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+	if !imagesEqual(own, want) {
+		t.Error("Expected the room's own level to match RenderFragment")
+	}
+
+	above, err := r.RenderFragmentAtZ(5, 1)
+	if err != nil {
+		t.Fatalf("RenderFragmentAtZ failed: %v", err)
+	}
+	want, err = r.RenderAt(1, 1, 1, 1)
+	if err != nil {
+		t.Fatalf("RenderAt failed: %v", err)
+	}
+	if !imagesEqual(above, want) {
+		t.Error("Expected another level drawn without the player highlight")
+	}
+	if above.CenterRoom != 5 || above.ZLevel != 1 || above.RoomsDrawn != 1 {
+		t.Errorf("Expected center room 5, level 1 and 1 room, got %d, %d and %d",
+			above.CenterRoom, above.ZLevel, above.RoomsDrawn)
+	}
+
+	if _, err := r.RenderFragmentAtZ(999, 0); !errors.Is(err, mapparser.ErrRoomNotFound) {
+		t.Errorf("Expected ErrRoomNotFound, got %v", err)
+	}
+}
+
+func TestInOutExits(t *testing.T) {
+	m := testGridMap(3)
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 200, 200
+	r := NewRenderer(cfg)
+	r.SetMap(m)
+	render := func() *image.RGBA {
+		t.Helper()
+		result, err := r.RenderAt(1, 1, 1, 0)
+		if err != nil {
+			t.Fatalf("RenderAt failed: %v", err)
+		}
+		return result.Image
+	}
+	plain := render()
+
+	// Room 5 is drawn at (100, 100); an out exit to a room not in view is
+	// only marked on the right half of the room
+	m.Rooms[5].Exits[mapparser.ExitOut] = 99
+	out := render()
+	if !regionDiffers(out, plain, image.Rect(101, 94, 108, 107)) {
+		t.Error("Expected an out marker on the right half of the room")
+	}
+	if regionDiffers(out, plain, image.Rect(0, 0, 100, 200)) {
+		t.Error("Expected no out marker left of the room center")
+	}
+
+	// Rooms 1 and 9 are drawn at (75, 125) and (125, 75), diagonally
+	// across room 5, and have no compass exit between them
+	m.Rooms[5].Exits[mapparser.ExitOut] = mapparser.NoExit
+	m.Rooms[1].Exits[mapparser.ExitIn] = 9
+	in := render()
+	if !regionDiffers(in, plain, image.Rect(66, 119, 75, 132)) {
+		t.Error("Expected an in marker on the left half of room 1")
+	}
+	if !regionDiffers(in, plain, image.Rect(82, 108, 92, 119)) {
+		t.Error("Expected a connector from room 1 towards room 9")
+	}
+}
+
+func TestExitStubDirection(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 100, 100
+	cfg.RoomSize, cfg.RoomSpacing = 40, 50
+	r := NewRenderer(cfg)
+	m := testGridMap(1)
+	r.SetMap(m)
+	plain, err := r.RenderFragment(1)
+	if err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+
+	// The room spans (30, 30)-(70, 70); stubs hold Mudlet direction codes
+	m.Rooms[1].ExitStubs = []int32{mapparser.DirectionCode(mapparser.ExitEast)}
+	stubbed, err := r.RenderFragment(1)
+	if err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+	ink := inkBounds(stubbed.Image, plain.Image, image.Rect(0, 0, 100, 100))
+	if ink.Empty() || ink.Min.X < 70 || ink.Min.Y > 50 || ink.Max.Y < 50 {
+		t.Errorf("Expected a stub east of the room, ink %v", ink)
+	}
+}
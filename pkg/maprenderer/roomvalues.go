@@ -0,0 +1,41 @@
+package maprenderer
+
+import (
+	"image"
+	"image/color"
+	"strconv"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+// contrastColor returns black or white, whichever reads better on bg.
+func contrastColor(bg color.RGBA) color.RGBA {
+	if rgbaLightness(bg) > 127 {
+		return color.RGBA{R: 0, G: 0, B: 0, A: 255}
+	}
+	return color.RGBA{R: 255, G: 255, B: 255, A: 255}
+}
+
+// drawRoomValue prints the room's entry from Config.RoomValues. The number
+// is drawn inside the room when it fits, otherwise on a small plate just
+// below it.
+func (r *Renderer) drawRoomValue(img *image.RGBA, cx, cy int, room *mapparser.MudletRoom, roomColor color.RGBA) {
+	value, ok := r.config.RoomValues[room.ID]
+	if !ok {
+		return
+	}
+	text := strconv.Itoa(value)
+	size := uiTextSize * max(r.textScale, 1)
+	textW := r.stringWidth(size, text)
+
+	if textW <= r.config.RoomSize-2 {
+		r.drawString(img, cx, cy, size, text, contrastColor(roomColor))
+		return
+	}
+
+	plateH := size + 2
+	plateW := textW + 2
+	top := cy + r.config.RoomSize/2 + 1
+	r.drawFilledRect(img, cx-plateW/2, top, plateW, plateH, r.config.BackgroundColor)
+	r.drawString(img, cx, top+plateH/2, size, text, contrastColor(r.config.BackgroundColor))
+}
@@ -0,0 +1,106 @@
+package maprenderer
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+// PathStyle configures the route drawn by [Renderer.RenderFragmentWithPath].
+type PathStyle struct {
+	// Color is the route color. The zero value uses Config.PathColor.
+	Color color.RGBA
+	// Width is the width of the route line in pixels; 0 means 3.
+	Width float64
+	// NumberSteps marks each room of the route with its position along
+	// it, 1 for the first room, instead of a dot.
+	NumberSteps bool
+}
+
+// RenderFragmentWithPath renders the fragment centered on roomID, as
+// [Renderer.RenderFragment], with a route such as one from
+// [mapparser.FindPath] drawn over it: a line through the rooms, on top of
+// the exits joining them, and a dot or step number on each room. Only the
+// parts of the route on the rendered area and level are drawn. A nil style
+// uses the defaults.
+func (r *Renderer) RenderFragmentWithPath(roomID int32, path []int32, style *PathStyle) (*RenderResult, error) {
+	if r.mapData == nil {
+		return nil, fmt.Errorf("no map data loaded")
+	}
+	if style == nil {
+		style = &PathStyle{}
+	}
+	rooms := make([]*mapparser.MudletRoom, len(path))
+	for i, id := range path {
+		if rooms[i] = r.mapData.GetRoom(id); rooms[i] == nil {
+			return nil, fmt.Errorf("%w: %d", mapparser.ErrRoomNotFound, id)
+		}
+	}
+	result, err := r.RenderFragment(roomID)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.drawRoute(result, rooms, style); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// drawRoute draws a route over a rendered result, joining consecutive
+// rooms that are both on the result's area and level.
+func (r *Renderer) drawRoute(result *RenderResult, rooms []*mapparser.MudletRoom, style *PathStyle) error {
+	c := style.Color
+	if c == (color.RGBA{}) {
+		c = r.config.PathColor
+	}
+	width := style.Width
+	if width <= 0 {
+		width = 3
+	}
+	// Step numbers are drawn by a copy of r with a text font of its own,
+	// leaving r as the render left it
+	w := *r
+	if style.NumberSteps {
+		text, err := r.config.loadTextFont(r.fonts)
+		if err != nil {
+			return err
+		}
+		w.text = text
+	}
+
+	img := result.Image
+	halfWidth, halfHeight := img.Bounds().Dx()/2+result.Offset.X, img.Bounds().Dy()/2+result.Offset.Y
+	onView := func(room *mapparser.MudletRoom) bool {
+		return room.Area == result.AreaID && room.Z == result.ZLevel
+	}
+	toScreen := func(room *mapparser.MudletRoom) (int, int) {
+		return r.roomToScreen(room, result.CenterX, result.CenterY, halfWidth, halfHeight, result.RoomSpacing)
+	}
+
+	for i := 1; i < len(rooms); i++ {
+		if onView(rooms[i-1]) && onView(rooms[i]) {
+			px, py := toScreen(rooms[i-1])
+			x, y := toScreen(rooms[i])
+			stroke(img, px, py, x, y, c, width, nil, 0)
+		}
+	}
+	for i, room := range rooms {
+		if !onView(room) {
+			continue
+		}
+		x, y := toScreen(room)
+		if !style.NumberSteps {
+			r.drawFilledCircle(img, x, y, max(2, result.RoomSize/5), c)
+			continue
+		}
+		r.drawFilledCircle(img, x, y, result.RoomSize/2, c)
+		inner := result.RoomSize * 3 / 4
+		w.drawText(img, x, y, inner, inner, strconv.Itoa(i+1), contrastColor(c))
+	}
+	if style.NumberSteps {
+		return w.text.err
+	}
+	return nil
+}
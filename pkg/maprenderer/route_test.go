@@ -0,0 +1,64 @@
+package maprenderer
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+func TestRenderFragmentWithPath(t *testing.T) {
+	m := testGridMap(3)
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 100, 100
+	r := NewRenderer(cfg)
+	r.SetMap(m)
+
+	// Rooms 1, 2, 3 run along the bottom row and 6 is above 3; room 5
+	// at (1, 1) is drawn at the image center, 25 pixels apart.
+	path := []int32{1, 2, 3, 6}
+	result, err := r.RenderFragmentWithPath(5, path, nil)
+	if err != nil {
+		t.Fatalf("RenderFragmentWithPath failed: %v", err)
+	}
+	for _, p := range []image.Point{{38, 75}, {63, 75}, {75, 63}, {25, 75}, {75, 50}} {
+		if got := result.Image.RGBAAt(p.X, p.Y); got != cfg.PathColor {
+			t.Errorf("Expected the route at %v, got %v", p, got)
+		}
+	}
+	if got := result.Image.RGBAAt(50, 50); got == cfg.PathColor {
+		t.Error("Expected room 5 off the route")
+	}
+
+	green := color.RGBA{G: 255, A: 255}
+	numbered, err := r.RenderFragmentWithPath(5, path, &PathStyle{Color: green, NumberSteps: true})
+	if err != nil {
+		t.Fatalf("RenderFragmentWithPath failed: %v", err)
+	}
+	// The numbers are drawn without touching the renderer's text font
+	text := r.text
+	if err := r.drawRoute(result, []*mapparser.MudletRoom{m.Rooms[1]}, &PathStyle{NumberSteps: true}); err != nil {
+		t.Fatalf("drawRoute failed: %v", err)
+	}
+	if r.text != text {
+		t.Error("Expected drawRoute to leave the renderer's text font")
+	}
+	// The step number is drawn in black over a room-sized green disc
+	dark := 0
+	for y := 68; y < 83; y++ {
+		for x := 18; x < 33; x++ {
+			if c := numbered.Image.RGBAAt(x, y); c.G < 128 {
+				dark++
+			}
+		}
+	}
+	if got := numbered.Image.RGBAAt(32, 75); got != green || dark == 0 {
+		t.Errorf("Expected a numbered green marker on room 1, edge %v with %d dark pixels", got, dark)
+	}
+
+	if _, err := r.RenderFragmentWithPath(5, []int32{1, 999}, nil); !errors.Is(err, mapparser.ErrRoomNotFound) {
+		t.Errorf("Expected ErrRoomNotFound, got %v", err)
+	}
+}
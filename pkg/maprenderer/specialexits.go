@@ -0,0 +1,110 @@
+package maprenderer
+
+import (
+	"image"
+	"image/color"
+	"maps"
+	"math"
+	"slices"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+// specialExitSteps is the number of segments a special exit curve is drawn
+// with.
+const specialExitSteps = 24
+
+// drawSpecialExits draws the special exits of room that lead to rooms in
+// view on the same level and have no custom line of their own, as dashed
+// curves with an arrowhead at the destination; those to rooms with no exit
+// back are drawn in the one-way color and arrowhead. A curve bows to the
+// left of its way, so exits both ways between two rooms do not overlap,
+// and each further exit between the same rooms bows wider.
+func (r *Renderer) drawSpecialExits(img *image.RGBA, room *mapparser.MudletRoom, roomMap map[int32]*mapparser.MudletRoom,
+	into exitsInto, centerX, centerY int32, halfWidth, halfHeight, spacing int) {
+
+	bows := make(map[int32]int)
+	for _, cmd := range slices.Sorted(maps.Keys(room.SpecialExits)) {
+		dest := roomMap[room.SpecialExits[cmd]]
+		if dest == nil || dest.ID == room.ID || dest.Z != room.Z || len(room.CustomLines[cmd]) > 0 {
+			continue
+		}
+		fromX, fromY := r.roomToScreen(room, centerX, centerY, halfWidth, halfHeight, spacing)
+		toX, toY := r.roomToScreen(dest, centerX, centerY, halfWidth, halfHeight, spacing)
+		from, to := fPoint{X: float64(fromX), Y: float64(fromY)}, fPoint{X: float64(toX), Y: float64(toY)}
+		dx, dy := to.X-from.X, to.Y-from.Y
+		length := math.Hypot(dx, dy)
+		if length < 1 {
+			continue
+		}
+
+		// Left of the way on screen, whose Y grows downward
+		bow := length * (0.2 + 0.15*float64(bows[dest.ID]))
+		bows[dest.ID]++
+		px, py := dy/length*bow, -dx/length*bow
+		c1 := fPoint{X: from.X + dx/3 + px, Y: from.Y + dy/3 + py}
+		c2 := fPoint{X: from.X + 2*dx/3 + px, Y: from.Y + 2*dy/3 + py}
+
+		// Draw the part of the curve outside both rooms
+		half := float64(r.config.RoomSize) / 2
+		inside := func(p, center fPoint) bool {
+			return math.Abs(p.X-center.X) <= half && math.Abs(p.Y-center.Y) <= half
+		}
+		var path []fPoint
+		for i := 0; i <= specialExitSteps; i++ {
+			p := cubicBezier(from, c1, c2, to, float64(i)/specialExitSteps)
+			if !inside(p, from) && !inside(p, to) {
+				path = append(path, p)
+			}
+		}
+		if len(path) < 2 {
+			continue
+		}
+		oneWay := !r.config.OneWayExits.Plain && !into.leadsBack(room, dest)
+		c := r.config.ExitColor
+		if oneWay {
+			c = r.config.OneWayExits.color()
+		}
+		c = r.exitWeightColor(c, room, dest, cmd, false)
+		step := 0
+		for i := 1; i < len(path); i++ {
+			step = r.drawStyledLine(img, int(path[i-1].X), int(path[i-1].Y), int(path[i].X), int(path[i].Y),
+				2, c, r.config.ExitWidth, step)
+		}
+		end, prev := path[len(path)-1], path[len(path)-2]
+		seg := math.Hypot(end.X-prev.X, end.Y-prev.Y)
+		switch {
+		case seg == 0:
+		case oneWay:
+			r.drawOneWayArrow(img, int(end.X), int(end.Y), (end.X-prev.X)/seg, (end.Y-prev.Y)/seg, c, r.config.ExitWidth)
+		default:
+			r.drawArrowHead(img, int(end.X), int(end.Y), (end.X-prev.X)/seg, (end.Y-prev.Y)/seg, c, r.config.ExitWidth)
+		}
+
+		if r.config.SpecialExitLabels {
+			mid := cubicBezier(from, c1, c2, to, 0.5)
+			r.drawSmallLabel(img, int(mid.X), int(mid.Y), cmd, r.config.ExitColor)
+		}
+	}
+}
+
+// drawSmallLabel prints text, such as a special exit's command, centered
+// at (x, y) in color c, in small text on a plate of the background color.
+func (r *Renderer) drawSmallLabel(img *image.RGBA, x, y int, text string, c color.RGBA) {
+	face := r.face(max(r.config.RoomSize*2/5, 8))
+	if face == nil {
+		return
+	}
+	metrics := face.Metrics()
+	w := font.MeasureString(face, text).Ceil() + 2
+	h := (metrics.Ascent + metrics.Descent).Ceil()
+	plate := r.config.BackgroundColor
+	plate.A = 200
+	r.drawFilledRect(img, x-w/2, y-h/2, w, h, plate)
+	d := font.Drawer{Dst: img, Src: image.NewUniform(c), Face: face,
+		Dot: fixed.P(x-w/2+1, y-h/2+metrics.Ascent.Ceil())}
+	d.DrawString(text)
+}
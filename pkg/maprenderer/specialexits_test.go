@@ -0,0 +1,46 @@
+package maprenderer
+
+import (
+	"image"
+	"testing"
+)
+
+func TestSpecialExits(t *testing.T) {
+	m := testGridMap(3)
+	// Room 1 is at (0, 0) and room 3 at (2, 0), two rooms to the east
+	m.Rooms[1].SpecialExits = map[string]int32{"climb": 3}
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 200, 200
+	r := NewRenderer(cfg)
+	r.SetMap(m)
+	render := func() *image.RGBA {
+		t.Helper()
+		result, err := r.RenderAt(1, 1, 1, 0)
+		if err != nil {
+			t.Fatalf("RenderAt failed: %v", err)
+		}
+		return result.Image
+	}
+	all := image.Rect(0, 0, 200, 200)
+
+	plain := render()
+	cfg.SpecialExitLabels = true
+	if inked := inkBounds(render(), plain, all); !inked.Empty() {
+		t.Errorf("Expected no special exits unless shown, ink %v", inked)
+	}
+
+	cfg.SpecialExitLabels = false
+	cfg.ShowSpecialExits = true
+	curve := render()
+	ink := inkBounds(curve, plain, all)
+	// The curve bows north of the row, between the two rooms
+	if ink.Empty() || ink.Min.X < 75 || ink.Max.X > 126 || ink.Max.Y > 125 || ink.Min.Y < 100 {
+		t.Errorf("Expected a curve between rooms 1 and 3, ink %v", ink)
+	}
+
+	cfg.SpecialExitLabels = true
+	if !regionDiffers(render(), curve, image.Rect(85, 105, 116, 125)) {
+		t.Error("Expected the command label at the middle of the curve")
+	}
+
+}
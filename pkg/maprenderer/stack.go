@@ -0,0 +1,191 @@
+package maprenderer
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+// StackOptions configures [Renderer.RenderStack].
+type StackOptions struct {
+	// Levels lists the z-levels to stack. Nil stacks every level of the
+	// area with rooms.
+	Levels []int32
+	// Offset is the shift in pixels from each level to the one above it,
+	// before Config.PixelRatio.
+	// The zero value spreads the levels over a third of the image width
+	// and height, each one up and to the right of the one below.
+	Offset image.Point
+}
+
+// RenderStack renders several z-levels of an area in one image as a 2.5D
+// stack: each level is drawn on a slab shifted by opts.Offset from the one
+// below it, the lowest at the bottom, with dashed lines joining the rooms
+// connected by up and down exits. All levels share one room spacing,
+// scaled down as for [Renderer.RenderArea] until the widest level fits its
+// slab. The result's ZLevel is the lowest level and CenterX and CenterY
+// the center of the levels' combined bounding box; since each level is
+// shifted, [Renderer.RoomAt] does not apply to it. A nil opts uses the
+// defaults.
+func (r *Renderer) RenderStack(areaID int32, opts *StackOptions) (*RenderResult, error) {
+	if r.mapData == nil {
+		return nil, fmt.Errorf("no map data loaded")
+	}
+	if opts == nil {
+		opts = &StackOptions{}
+	}
+	area := r.mapData.GetArea(areaID)
+	if area == nil {
+		return nil, fmt.Errorf("area %d not found", areaID)
+	}
+	byZ := areaLevels(r.mapData)[areaID]
+	if len(byZ) == 0 {
+		return nil, fmt.Errorf("area %d has no rooms", areaID)
+	}
+	zs := slices.Sorted(maps.Keys(byZ))
+	if opts.Levels != nil {
+		zs = slices.Sorted(slices.Values(opts.Levels))
+		for _, z := range zs {
+			if byZ[z] == nil {
+				return nil, fmt.Errorf("area %d has no rooms on level %d", areaID, z)
+			}
+		}
+	}
+
+	box := byZ[zs[0]].box
+	for _, z := range zs[1:] {
+		b := byZ[z].box
+		box.MinX, box.MaxX = min32(box.MinX, b.MinX), max32(box.MaxX, b.MaxX)
+		box.MinY, box.MaxY = min32(box.MinY, b.MinY), max32(box.MaxY, b.MaxY)
+	}
+
+	cfg, textScale := r.config.zoomed()
+	offset := opts.Offset
+	if ratio := r.config.PixelRatio; ratio > 0 {
+		offset = image.Pt(int(math.Round(float64(offset.X)*ratio)), int(math.Round(float64(offset.Y)*ratio)))
+	}
+	if offset == (image.Point{}) && len(zs) > 1 {
+		offset = image.Pt(cfg.Width/3/(len(zs)-1), -cfg.Height/3/(len(zs)-1))
+	}
+	shift := offset.Mul(len(zs) - 1)
+
+	// Each level is rendered on its own transparent slab
+	slab := levelConfig(cfg)
+	slab.Width -= abs(shift.X)
+	slab.Height -= abs(shift.Y)
+	if slab.Width < 1 || slab.Height < 1 {
+		return nil, fmt.Errorf("stack offset %v leaves no room for %d levels", offset, len(zs))
+	}
+	slab.BackgroundColor = color.RGBA{}
+	centerX := box.MinX + (box.MaxX-box.MinX)/2
+	centerY := box.MinY + (box.MaxY-box.MinY)/2
+	slab.RoomSpacing, slab.RoomSize = fitSpacing(&slab, int(box.MaxX-centerX), int(box.MaxY-centerY))
+	w := *r
+	w.config, w.textScale, w.target = &slab, textScale, nil
+
+	img := image.NewRGBA(image.Rect(0, 0, cfg.Width, cfg.Height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{cfg.BackgroundColor}, image.Point{}, draw.Src)
+
+	// The lowest level's slab is in the corner the offset moves away from.
+	base := image.Pt(max(0, -shift.X), max(0, -shift.Y))
+	origins := make(map[int32]image.Point, len(zs))
+	plate := cfg.BorderColor
+	plate.A = 40
+	roomsDrawn := 0
+	for i, z := range zs {
+		level, err := w.render(areaID, centerX, centerY, z, false)
+		if err != nil {
+			return nil, err
+		}
+		origin := base.Add(offset.Mul(i))
+		origins[z] = origin
+		roomsDrawn += level.RoomsDrawn
+
+		// The slab covers the level's rooms with half a spacing around them
+		b := byZ[z].box
+		minX, minY := w.stackedRoom(b.MinX, b.MaxY, centerX, centerY, origin)
+		maxX, maxY := w.stackedRoom(b.MaxX, b.MinY, centerX, centerY, origin)
+		pad := slab.RoomSpacing / 2
+		rect := image.Rect(minX-pad, minY-pad, maxX+pad+1, maxY+pad+1)
+		r.drawFilledRect(img, rect.Min.X, rect.Min.Y, rect.Dx(), rect.Dy(), plate)
+		r.drawRectOutline(img, rect.Min.X, rect.Min.Y, rect.Dx(), rect.Dy(), cfg.BorderColor)
+
+		draw.Draw(img, level.Image.Bounds().Add(origin), level.Image, image.Point{}, draw.Over)
+
+		caption := "Z " + strconv.Itoa(int(z))
+		u := cfg.ui(1)
+		size := cfg.ui(uiTextSize)
+		w.drawString(img, rect.Min.X+2*u+w.stringWidth(size, caption)/2, rect.Min.Y+6*u, size, caption, cfg.TextColor)
+		if w.text.err != nil {
+			return nil, w.text.err
+		}
+	}
+
+	w.drawStackLinks(img, areaID, centerX, centerY, origins)
+
+	return &RenderResult{
+		Image:       img,
+		AreaID:      areaID,
+		AreaName:    area.Name,
+		ZLevel:      zs[0],
+		CenterX:     centerX,
+		CenterY:     centerY,
+		RoomSpacing: slab.RoomSpacing,
+		RoomSize:    slab.RoomSize,
+		RoomsDrawn:  roomsDrawn,
+	}, nil
+}
+
+// levelConfig returns a copy of cfg for drawing one of several levels in
+// an image, without the other levels and the overlays, which would repeat
+// on every level.
+func levelConfig(cfg *Config) Config {
+	c := *cfg
+	c.ShowUpperLevel, c.ShowLowerLevel = false, false
+	c.ShowAxes, c.ShowLegend, c.ShowAttribution = false, false, false
+	c.ShowTitle, c.ShowCompass, c.ShowScale = false, false, false
+	return c
+}
+
+// stackedRoom returns the position in a stack image of map coordinates
+// (x, y) on the level whose slab starts at origin.
+func (r *Renderer) stackedRoom(x, y, centerX, centerY int32, origin image.Point) (int, int) {
+	room := mapparser.MudletRoom{X: x, Y: y}
+	sx, sy := r.roomToScreen(&room, centerX, centerY, r.config.Width/2, r.config.Height/2, r.config.RoomSpacing)
+	return sx + origin.X, sy + origin.Y
+}
+
+// drawStackLinks draws a dashed line for each up or down exit between two
+// rooms of the area on stacked levels.
+func (r *Renderer) drawStackLinks(img *image.RGBA, areaID, centerX, centerY int32, origins map[int32]image.Point) {
+	type link struct{ from, to int32 }
+	drawn := make(map[link]bool)
+	for _, room := range r.mapData.Rooms {
+		from, ok := origins[room.Z]
+		if room.Area != areaID || !ok {
+			continue
+		}
+		for _, dir := range []int{mapparser.ExitUp, mapparser.ExitDown} {
+			dest := r.mapData.GetRoom(room.Exits[dir])
+			if dest == nil || dest.Area != areaID {
+				continue
+			}
+			to, ok := origins[dest.Z]
+			key := link{min32(room.ID, dest.ID), max32(room.ID, dest.ID)}
+			if !ok || drawn[key] {
+				continue
+			}
+			drawn[key] = true
+			x1, y1 := r.stackedRoom(room.X, room.Y, centerX, centerY, from)
+			x2, y2 := r.stackedRoom(dest.X, dest.Y, centerX, centerY, to)
+			r.drawDashedLine(img, x1, y1, x2, y2, r.config.ExitColor, 1)
+		}
+	}
+}
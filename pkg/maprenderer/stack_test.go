@@ -0,0 +1,70 @@
+package maprenderer
+
+import (
+	"image"
+	"testing"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+func TestRenderStack(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 300, 300
+	r := NewRenderer(cfg)
+	m := testGridMap(2)
+	// A single room above room 1, joined by up and down exits
+	upper := mapparser.NewMudletRoom(10)
+	upper.Area, upper.Z, upper.Environment = 1, 1, 1
+	upper.Exits[mapparser.ExitDown] = 1
+	m.Rooms[10] = upper
+	m.Rooms[1].Exits[mapparser.ExitUp] = 10
+	r.SetMap(m)
+
+	result, err := r.RenderStack(1, &StackOptions{Offset: image.Pt(60, -60)})
+	if err != nil {
+		t.Fatalf("RenderStack failed: %v", err)
+	}
+	if b := result.Image.Bounds(); b.Dx() != 300 || b.Dy() != 300 {
+		t.Errorf("Expected a 300x300 image, got %v", b)
+	}
+	if result.ZLevel != 0 || result.RoomsDrawn != 5 {
+		t.Errorf("Expected level 0 and 5 rooms, got %d and %d", result.ZLevel, result.RoomsDrawn)
+	}
+	// Slabs are 240x240: the levels' center (0, 0) is at (120, 120), in
+	// the bottom-left slab from (0, 60) and the top-right one from (60, 0).
+	// Room 1 at (0, 0) is at (120, 180) and room 10 above it at (180, 120).
+	lower := result.Image.RGBAAt(120, 180)
+	top := result.Image.RGBAAt(180, 120)
+	if lower == cfg.BackgroundColor || top != lower {
+		t.Errorf("Expected rooms 1 and 10 drawn alike, got %v and %v", lower, top)
+	}
+	// The dashed up exit joins them along the diagonal, between the rooms
+	linked := false
+	for d := 15; d < 45; d++ {
+		if result.Image.RGBAAt(120+d, 180-d) == cfg.ExitColor {
+			linked = true
+		}
+	}
+	if !linked {
+		t.Error("Expected a line joining the rooms of the up exit")
+	}
+
+	// Only the requested levels are stacked
+	only, err := r.RenderStack(1, &StackOptions{Levels: []int32{1}})
+	if err != nil {
+		t.Fatalf("RenderStack failed: %v", err)
+	}
+	if only.RoomsDrawn != 1 || only.ZLevel != 1 {
+		t.Errorf("Expected only level 1, got %d rooms from level %d", only.RoomsDrawn, only.ZLevel)
+	}
+	if _, err := r.RenderStack(1, &StackOptions{Levels: []int32{0, 7}}); err == nil {
+		t.Error("Expected an error for a level without rooms")
+	}
+	if _, err := r.RenderStack(1, &StackOptions{Offset: image.Pt(300, 0)}); err == nil {
+		t.Error("Expected an error for an offset wider than the image")
+	}
+
+	if _, err := r.RenderStack(1, nil); err != nil {
+		t.Errorf("RenderStack with defaults failed: %v", err)
+	}
+}
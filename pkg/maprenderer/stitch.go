@@ -0,0 +1,134 @@
+package maprenderer
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+// panoramaGap is the width of the separator between stitched views.
+const panoramaGap = 4
+
+// PathSegment is one view of a route rendered by
+// [Renderer.RenderPathSegments].
+type PathSegment struct {
+	// Result is the rendered view, with the route drawn on top.
+	Result *RenderResult
+	// Start and End are the indexes of the first and last path rooms shown,
+	// inclusive.
+	Start, End int
+}
+
+// RenderPathSegments renders a route, such as one from
+// [mapparser.FindPath], as a series of views that each fit the configured
+// image size. A new view starts where the route leaves the current one or
+// changes area or z-level. Consecutive views on the same level overlap by
+// one room, so the route can be followed from one image to the next. The
+// route is drawn over each view in Config.PathColor.
+func (r *Renderer) RenderPathSegments(path []int32) ([]PathSegment, error) {
+	if r.mapData == nil {
+		return nil, fmt.Errorf("no map data loaded")
+	}
+	if len(path) == 0 {
+		return nil, fmt.Errorf("empty path")
+	}
+	rooms := make([]*mapparser.MudletRoom, len(path))
+	for i, id := range path {
+		if rooms[i] = r.mapData.GetRoom(id); rooms[i] == nil {
+			return nil, fmt.Errorf("%w: %d", mapparser.ErrRoomNotFound, id)
+		}
+	}
+
+	if z := r.zoomed(); z != r {
+		return z.RenderPathSegments(path)
+	}
+	rangeX, rangeY := r.config.CalculateVisibleRooms()
+	var segments []PathSegment
+	for start := 0; start < len(rooms); {
+		first := rooms[start]
+		minX, maxX, minY, maxY := first.X, first.X, first.Y, first.Y
+		end := start
+		for end+1 < len(rooms) {
+			next := rooms[end+1]
+			if next.Area != first.Area || next.Z != first.Z {
+				break
+			}
+			nMinX, nMaxX := min32(minX, next.X), max32(maxX, next.X)
+			nMinY, nMaxY := min32(minY, next.Y), max32(maxY, next.Y)
+			if nMaxX-nMinX > int32(2*rangeX) || nMaxY-nMinY > int32(2*rangeY) {
+				break
+			}
+			minX, maxX, minY, maxY = nMinX, nMaxX, nMinY, nMaxY
+			end++
+		}
+
+		centerX, centerY := minX+(maxX-minX)/2, minY+(maxY-minY)/2
+		result, err := r.RenderAt(first.Area, centerX, centerY, first.Z)
+		if err != nil {
+			return nil, err
+		}
+		r.drawPath(result.Image, rooms[start:end+1], centerX, centerY)
+		segments = append(segments, PathSegment{Result: result, Start: start, End: end})
+
+		switch {
+		case end+1 >= len(rooms):
+			start = end + 1
+		case end > start && rooms[end+1].Area == first.Area && rooms[end+1].Z == first.Z:
+			start = end // overlap by one room
+		default:
+			start = end + 1
+		}
+	}
+	return segments, nil
+}
+
+// RenderPathPanorama renders a route with [Renderer.RenderPathSegments]
+// and stacks the views into one image, top to bottom, or left to right if
+// horizontal is set, separated by thin bars in the border color.
+func (r *Renderer) RenderPathPanorama(path []int32, horizontal bool) (*image.RGBA, []PathSegment, error) {
+	segments, err := r.RenderPathSegments(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The segments are rendered at the pixel ratio's scale
+	tile := segments[0].Result.Image.Bounds()
+	w, h := tile.Dx(), tile.Dy()
+	n := len(segments)
+	size := image.Rect(0, 0, w, n*h+(n-1)*panoramaGap)
+	if horizontal {
+		size = image.Rect(0, 0, n*w+(n-1)*panoramaGap, h)
+	}
+	img := image.NewRGBA(size)
+	draw.Draw(img, img.Bounds(), &image.Uniform{r.config.BorderColor}, image.Point{}, draw.Src)
+	for i, seg := range segments {
+		at := image.Pt(0, i*(h+panoramaGap))
+		if horizontal {
+			at = image.Pt(i*(w+panoramaGap), 0)
+		}
+		src := seg.Result.Image
+		draw.Draw(img, image.Rectangle{Min: at, Max: at.Add(src.Bounds().Size())}, src, src.Bounds().Min, draw.Src)
+	}
+	return img, segments, nil
+}
+
+// drawPath draws a route through rooms over a view centered on
+// (centerX, centerY), with a dot on each room.
+func (r *Renderer) drawPath(img *image.RGBA, rooms []*mapparser.MudletRoom, centerX, centerY int32) {
+	halfWidth, halfHeight := r.config.Width/2, r.config.Height/2
+	spacing := r.config.RoomSpacing
+	c := r.config.PathColor
+	dot := max(2, r.config.RoomSize/5)
+	for i, room := range rooms {
+		x, y := r.roomToScreen(room, centerX, centerY, halfWidth, halfHeight, spacing)
+		if i > 0 {
+			px, py := r.roomToScreen(rooms[i-1], centerX, centerY, halfWidth, halfHeight, spacing)
+			for d := -1; d <= 1; d++ {
+				r.drawLine(img, px+d, py, x+d, y, c)
+				r.drawLine(img, px, py+d, x, y+d, c)
+			}
+		}
+		r.drawFilledCircle(img, x, y, dot, c)
+	}
+}
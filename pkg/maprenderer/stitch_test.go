@@ -0,0 +1,70 @@
+package maprenderer
+
+import (
+	"testing"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+func TestRenderPathSegments(t *testing.T) {
+	m := testGridMap(9)
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 80, 80 // three rooms across
+	r := NewRenderer(cfg)
+	r.SetMap(m)
+
+	path := mapparser.FindPath(m, 1, 9) // along the bottom row
+	if len(path) != 9 {
+		t.Fatalf("Expected a 9-room path, got %v", path)
+	}
+	segments, err := r.RenderPathSegments(path)
+	if err != nil {
+		t.Fatalf("RenderPathSegments failed: %v", err)
+	}
+	want := [][2]int{{0, 2}, {2, 4}, {4, 6}, {6, 8}}
+	if len(segments) != len(want) {
+		t.Fatalf("Expected %d segments, got %d", len(want), len(segments))
+	}
+	for i, seg := range segments {
+		if seg.Start != want[i][0] || seg.End != want[i][1] {
+			t.Errorf("Segment %d covers %d..%d, want %d..%d", i, seg.Start, seg.End, want[i][0], want[i][1])
+		}
+	}
+	// The route passes through the center of the middle room of each view
+	if got := segments[0].Result.Image.RGBAAt(40, 40); got != cfg.PathColor {
+		t.Errorf("Expected path color at the view center, got %v", got)
+	}
+
+	img, _, err := r.RenderPathPanorama(path, false)
+	if err != nil {
+		t.Fatalf("RenderPathPanorama failed: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 80 || b.Dy() != 4*80+3*panoramaGap {
+		t.Errorf("Unexpected panorama size %v", b)
+	}
+	img, _, _ = r.RenderPathPanorama(path, true)
+	if b := img.Bounds(); b.Dx() != 4*80+3*panoramaGap || b.Dy() != 80 {
+		t.Errorf("Unexpected horizontal panorama size %v", b)
+	}
+
+	// Tiles are as large as the segments at a pixel ratio
+	cfg.PixelRatio = 2
+	img, segments, err = r.RenderPathPanorama(path, false)
+	if err != nil {
+		t.Fatalf("RenderPathPanorama failed: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 160 || b.Dy() != len(segments)*160+(len(segments)-1)*panoramaGap {
+		t.Errorf("Unexpected panorama size %v at pixel ratio 2", b)
+	}
+	if got := img.RGBAAt(80, 80); got != cfg.PathColor {
+		t.Errorf("Expected the first view's center uncropped, got %v", got)
+	}
+	cfg.PixelRatio = 0
+
+	if _, err := r.RenderPathSegments([]int32{1, 999}); err == nil {
+		t.Error("Expected error for missing room")
+	}
+	if _, err := r.RenderPathSegments(nil); err == nil {
+		t.Error("Expected error for empty path")
+	}
+}
@@ -0,0 +1,81 @@
+package maprenderer
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// stroke draws a line from (x1, y1) to (x2, y2) with a round pen width
+// pixels wide, which gives round caps and, where lines meet, round joins.
+// Widths up to 1 draw single pixels. on reports whether the pattern is on
+// at a step along the line; its steps are counted in pen widths, so dots
+// and dashes grow with the pen. A nil on draws a solid line. step is the
+// pattern position at the line start, and the position after the line is
+// returned.
+func stroke(img *image.RGBA, x1, y1, x2, y2 int, c color.RGBA, width float64, on func(step int) bool, step int) int {
+	scale := max(int(math.Round(width)), 1)
+	dx := abs(x2 - x1)
+	dy := abs(y2 - y1)
+	sx := 1
+	if x1 >= x2 {
+		sx = -1
+	}
+	sy := 1
+	if y1 >= y2 {
+		sy = -1
+	}
+	err := dx - dy
+
+	for {
+		if on == nil || on(step/scale) {
+			penDot(img, x1, y1, width, c)
+		}
+
+		if x1 == x2 && y1 == y2 {
+			return step
+		}
+		step++
+
+		e2 := 2 * err
+		if e2 > -dy {
+			err -= dy
+			x1 += sx
+		}
+		if e2 < dx {
+			err += dx
+			y1 += sy
+		}
+	}
+}
+
+// penDot draws a disc width pixels across (rounded) at (x, y), or the
+// single pixel for widths up to 1. The disc is tested at pixel centers;
+// an odd width is centered on the pixel, and an even one on its top left
+// corner, so that both cover exactly width pixels across.
+func penDot(img *image.RGBA, x, y int, width float64, c color.RGBA) {
+	if width <= 1 {
+		setPixelSafe(img, x, y, c)
+		return
+	}
+	n := int(math.Round(width))
+	lo := -n / 2
+	center := float64(lo) + float64(n-1)/2
+	radius := float64(n) / 2
+	for oy := lo; oy < lo+n; oy++ {
+		for ox := lo; ox < lo+n; ox++ {
+			dx, dy := float64(ox)-center, float64(oy)-center
+			if dx*dx+dy*dy <= radius*radius {
+				setPixelSafe(img, x+ox, y+oy, c)
+			}
+		}
+	}
+}
+
+// Line patterns for stroke.
+var (
+	// dotted is 1 on, 3 off.
+	dotted = func(step int) bool { return step%4 == 0 }
+	// dashed is 6 on, 4 off.
+	dashed = func(step int) bool { return step%10 < 6 }
+)
@@ -0,0 +1,101 @@
+package maprenderer
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// inked returns the bounds of the non-transparent pixels of img.
+func inked(img *image.RGBA) image.Rectangle {
+	var ink image.Rectangle
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if img.RGBAAt(x, y).A != 0 {
+				ink = ink.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return ink
+}
+
+func TestStroke(t *testing.T) {
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	tests := []struct {
+		width float64
+		want  image.Rectangle
+	}{
+		{0, image.Rect(10, 20, 41, 21)},
+		{1, image.Rect(10, 20, 41, 21)},
+		// Round caps reach half the width beyond the ends
+		{5, image.Rect(8, 18, 43, 23)},
+		// Even widths cover exactly width pixels across
+		{2, image.Rect(9, 19, 41, 21)},
+		{4, image.Rect(8, 18, 42, 22)},
+	}
+	for _, tt := range tests {
+		img := image.NewRGBA(image.Rect(0, 0, 60, 40))
+		stroke(img, 10, 20, 40, 20, white, tt.width, nil, 0)
+		if got := inked(img); got != tt.want {
+			t.Errorf("width %v: ink %v, want %v", tt.width, got, tt.want)
+		}
+	}
+
+	// Dots of a 3 pixel pen are 3 steps long, 12 steps apart
+	img := image.NewRGBA(image.Rect(0, 0, 60, 10))
+	stroke(img, 0, 5, 59, 5, white, 3, dotted, 0)
+	var on []int
+	for x := range 60 {
+		if img.RGBAAt(x, 5).A != 0 && (x == 0 || img.RGBAAt(x-1, 5).A == 0) {
+			on = append(on, x)
+		}
+	}
+	if len(on) != 5 || on[2]-on[1] != 12 {
+		t.Errorf("dot starts = %v, want 5 dots 12 pixels apart", on)
+	}
+}
+
+func TestExitWidth(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 100, 100
+	cfg.ExitWidth = 1
+	r := NewRenderer(cfg)
+	r.SetMap(testGridMap(2))
+	thin, err := r.RenderAt(1, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("RenderAt failed: %v", err)
+	}
+	cfg.ExitWidth = 3
+	thick, err := r.RenderAt(1, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("RenderAt failed: %v", err)
+	}
+
+	// The east exit of room 1 runs between the rooms at x 60 to 65
+	column := func(res *RenderResult) int {
+		n := 0
+		for y := 40; y < 60; y++ {
+			if res.Image.RGBAAt(62, y) == cfg.ExitColor {
+				n++
+			}
+		}
+		return n
+	}
+	if got := column(thin); got != 1 {
+		t.Errorf("Expected a 1 pixel exit line, got %d", got)
+	}
+	if got := column(thick); got != 3 {
+		t.Errorf("Expected a 3 pixel exit line, got %d", got)
+	}
+
+	// The default width draws 2 pixel lines
+	cfg.ExitWidth = DefaultConfig().ExitWidth
+	even, err := r.RenderAt(1, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("RenderAt failed: %v", err)
+	}
+	if got := column(even); got != 2 {
+		t.Errorf("Expected a 2 pixel exit line, got %d", got)
+	}
+}
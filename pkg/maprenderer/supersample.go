@@ -0,0 +1,85 @@
+package maprenderer
+
+import (
+	"image"
+	"image/draw"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+// superSampled renders the view as render does, drawing the map at
+// Config.SuperSample times the size with a private renderer and scaling
+// it down, over the target if any, then drawing the overlays and
+// decorations at the final size.
+func (r *Renderer) superSampled(area *mapparser.MudletArea, areaID, centerX, centerY, centerZ int32,
+	highlight bool) (*RenderResult, error) {
+
+	k := r.config.SuperSample
+	cfg := *r.config
+	cfg.SuperSample = 0
+	cfg.Width, cfg.Height = cfg.Width*k, cfg.Height*k
+	cfg.RoomSize, cfg.RoomSpacing = cfg.RoomSize*k, cfg.RoomSpacing*k
+	cfg.ExitWidth, cfg.StubLength = cfg.ExitWidth*float64(k), cfg.StubLength*float64(k)
+	cfg.OneWayExits.ArrowSize *= float64(k)
+	cfg.uiScale = cfg.ui(k)
+	cfg.ShowAxes, cfg.ShowLegend, cfg.ShowAttribution = false, false, false
+	cfg.ShowTitle, cfg.ShowCompass, cfg.ShowScale = false, false, false
+	cfg.Overlays = nil
+	// The big image is scaled down onto the target afterwards
+	big := *r
+	big.config, big.textScale, big.shift, big.target = &cfg, max(r.textScale, 1)*k, r.shift.Mul(k), nil
+	result, err := big.render(areaID, centerX, centerY, centerZ, highlight)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.loadFonts(); err != nil {
+		return nil, err
+	}
+	result.Image = downsample(result.Image, k)
+	if r.target != nil {
+		draw.Draw(r.target, r.target.Bounds(), result.Image, image.Point{}, draw.Over)
+		result.Image = r.target
+	}
+	result.Offset = r.shift
+	result.RoomSpacing /= k
+	result.RoomSize /= k
+	for i := range result.EdgeExits {
+		result.EdgeExits[i].ScreenX /= k
+		result.EdgeExits[i].ScreenY /= k
+	}
+	halfWidth, halfHeight := r.config.Width/2+r.shift.X, r.config.Height/2+r.shift.Y
+	if len(r.config.Overlays) > 0 {
+		r.drawOverlays(result.Image, areaID, centerZ, centerX, centerY, halfWidth, halfHeight, result.RoomSpacing)
+	}
+	r.drawDecorations(result.Image, area, areaID, centerX, centerY, centerZ,
+		halfWidth, halfHeight, result.RoomSpacing)
+	if r.text.err != nil {
+		return nil, r.text.err
+	}
+	return result, nil
+}
+
+// downsample shrinks img k times by averaging each k by k square of
+// pixels (a box filter), which is exact for premultiplied colors.
+func downsample(img *image.RGBA, k int) *image.RGBA {
+	b := img.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, b.Dx()/k, b.Dy()/k))
+	n := uint32(k * k)
+	for y := 0; y < out.Rect.Dy(); y++ {
+		for x := 0; x < out.Rect.Dx(); x++ {
+			var sum [4]uint32
+			for sy := 0; sy < k; sy++ {
+				row := img.PixOffset(b.Min.X+x*k, b.Min.Y+y*k+sy)
+				for i := 0; i < 4*k; i++ {
+					sum[i%4] += uint32(img.Pix[row+i])
+				}
+			}
+			o := out.PixOffset(x, y)
+			for c := range sum {
+				out.Pix[o+c] = uint8((sum[c] + n/2) / n)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,107 @@
+package maprenderer
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestSuperSample(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 200, 200
+	cfg.RoomRound = true
+	cfg.Antialiasing = false
+	cfg.ShowTitle = true
+	r := NewRenderer(cfg)
+	r.SetMap(testGridMap(3))
+	plain, err := r.RenderFragment(5)
+	if err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+
+	cfg.SuperSample = 2
+	smooth, err := r.RenderFragment(5)
+	if err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+	if smooth.Image.Bounds() != plain.Image.Bounds() || smooth.RoomSpacing != plain.RoomSpacing ||
+		smooth.RoomSize != plain.RoomSize || smooth.RoomsDrawn != plain.RoomsDrawn {
+		t.Fatalf("Expected the plain render's geometry, got bounds %v, spacing %d, size %d, rooms %d",
+			smooth.Image.Bounds(), smooth.RoomSpacing, smooth.RoomSize, smooth.RoomsDrawn)
+	}
+	if room := r.RoomAt(smooth, 75, 125); room == nil || room.ID != 1 {
+		t.Errorf("Expected room 1 at (75, 125), got %v", room)
+	}
+
+	// The edge of the round room in the corner is smoothed
+	region := image.Rect(63, 113, 88, 138)
+	colors := func(img *image.RGBA) int {
+		seen := make(map[color.RGBA]bool)
+		for y := region.Min.Y; y < region.Max.Y; y++ {
+			for x := region.Min.X; x < region.Max.X; x++ {
+				seen[img.RGBAAt(x, y)] = true
+			}
+		}
+		return len(seen)
+	}
+	if p, s := colors(plain.Image), colors(smooth.Image); s <= p {
+		t.Errorf("Expected more shades at the room edge, got %d supersampled and %d plain", s, p)
+	}
+
+	// The title is drawn at the final size
+	titleBar := image.Rect(0, 0, 200, 12)
+	if regionDiffers(plain.Image, smooth.Image, titleBar) {
+		t.Error("Expected the title bar unchanged by supersampling")
+	}
+
+	// Overlays are drawn at their own size, at final image coordinates
+	icon := image.NewRGBA(image.Rect(0, 0, 40, 40))
+	red := color.RGBA{R: 255, A: 255}
+	for i := 0; i < len(icon.Pix); i += 4 {
+		copy(icon.Pix[i:], []uint8{255, 0, 0, 255})
+	}
+	var at image.Point
+	cfg.Overlays = []Overlay{{Room: 5, Icon: icon, Draw: func(img *image.RGBA, x, y int) { at = image.Pt(x, y) }}}
+	cfg.SuperSample = 4
+	pinned, err := r.RenderFragment(5)
+	if err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+	if ink := inkedColor(pinned.Image, red); ink != image.Rect(80, 80, 120, 120) {
+		t.Errorf("Expected a 40 pixel icon on the room center, got %v", ink)
+	}
+	if at != image.Pt(100, 100) {
+		t.Errorf("Expected the callback at (100, 100), got %v", at)
+	}
+}
+
+func TestDownsample(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	img.SetRGBA(0, 0, color.RGBA{R: 255, A: 255})
+	img.SetRGBA(1, 1, color.RGBA{R: 255, A: 255})
+	img.SetRGBA(2, 0, color.RGBA{B: 100, A: 100})
+	got := downsample(img, 2)
+	if got.Bounds() != image.Rect(0, 0, 2, 1) {
+		t.Fatalf("Expected 2x1 pixels, got %v", got.Bounds())
+	}
+	if c := got.RGBAAt(0, 0); c != (color.RGBA{R: 128, A: 128}) {
+		t.Errorf("Expected half red, got %v", c)
+	}
+	if c := got.RGBAAt(1, 0); c != (color.RGBA{B: 25, A: 25}) {
+		t.Errorf("Expected a quarter of the blue, got %v", c)
+	}
+}
+
+// inkedColor returns the bounds of the pixels of img in color c.
+func inkedColor(img *image.RGBA, c color.RGBA) image.Rectangle {
+	var ink image.Rectangle
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if img.RGBAAt(x, y) == c {
+				ink = ink.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return ink
+}
@@ -0,0 +1,35 @@
+package maprenderer
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+// drawTerrainCost outlines a room just inside its border in the color of
+// its cost under Config.Terrain.
+func (r *Renderer) drawTerrainCost(img *image.RGBA, cx, cy int, room *mapparser.MudletRoom) {
+	c := terrainCostColor(r.config.Terrain.Cost(room))
+	inset := r.config.RoomSize/2 - 1
+	if inset < 2 {
+		return
+	}
+	if r.config.RoomRound {
+		r.drawCircleOutline(img, cx, cy, inset, c)
+		return
+	}
+	r.drawRectOutline(img, cx-inset, cy-inset, 2*inset, 2*inset, c)
+}
+
+// terrainCostColor maps a terrain cost to green (0), yellow (1) or red (3
+// and more), blending in between.
+func terrainCostColor(cost float64) color.RGBA {
+	if cost <= 1 {
+		t := math.Max(cost, 0)
+		return color.RGBA{R: uint8(255 * t), G: 220, B: 0, A: 255}
+	}
+	t := min((cost-1)/2, 1)
+	return color.RGBA{R: 255, G: uint8(220 * (1 - t)), B: 0, A: 255}
+}
@@ -0,0 +1,30 @@
+package maprenderer
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+func TestTerrainCostOutline(t *testing.T) {
+	m := testGridMap(3)
+	m.Rooms[5].UserData[mapparser.TerrainKey] = "swamp"
+	cfg := DefaultConfig()
+	cfg.Terrain = &mapparser.TerrainProfile{Names: map[string]float64{"swamp": 3}, Default: 0.5}
+	r := NewRenderer(cfg)
+	r.SetMap(m)
+
+	result, err := r.RenderAt(1, 1, 1, 0)
+	if err != nil {
+		t.Fatalf("RenderAt failed: %v", err)
+	}
+	cx, cy := cfg.Width/2, cfg.Height/2
+	inset := cfg.RoomSize/2 - 1
+	if got := result.Image.RGBAAt(cx-inset, cy); got != (color.RGBA{R: 255, A: 255}) {
+		t.Errorf("Expected red outline on swamp room, got %v", got)
+	}
+	if got := result.Image.RGBAAt(cx+cfg.RoomSpacing-inset, cy); got != (color.RGBA{R: 127, G: 220, A: 255}) {
+		t.Errorf("Expected yellow-green outline on cheap room, got %v", got)
+	}
+}
@@ -0,0 +1,220 @@
+package maprenderer
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io/fs"
+	"reflect"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/assets"
+)
+
+// minTextSize is the smallest font size, in pixels, text is shrunk to when
+// fitting it into a room or label.
+const minTextSize = 5
+
+// uiTextSize is the font size, in layout pixels, of the legend, axes,
+// compass, scale bar and attribution.
+const uiTextSize = 10
+
+// textFont is a TrueType font with its faces cached by pixel size. Faces
+// are not safe for concurrent use, so each render makes its own.
+type textFont struct {
+	font  *opentype.Font
+	faces map[int]font.Face
+	buf   sfnt.Buffer
+	// err is the first error making a face, returned by the render.
+	err error
+}
+
+// fontCache keeps the text font parsed from Config.Assets, so a Renderer
+// parses a custom font once rather than on every render. It is shared by
+// the Renderer's private renderers and batch workers.
+type fontCache struct {
+	mu     sync.Mutex
+	assets fs.FS
+	font   *opentype.Font
+}
+
+// defaultTextFont is Go Regular, which covers Latin scripts including
+// Polish, parsed once.
+var defaultTextFont = sync.OnceValues(func() (*opentype.Font, error) {
+	return opentype.Parse(goregular.TTF)
+})
+
+// loadTextFont returns the TrueType font from the configured assets, or Go
+// Regular if they have none, parsing it only if cache holds another one.
+// A nil cache parses the font every time.
+func (c *Config) loadTextFont(cache *fontCache) (*textFont, error) {
+	if c.Assets == nil {
+		f, err := defaultTextFont()
+		if err != nil {
+			return nil, err
+		}
+		return newTextFont(f), nil
+	}
+	if cache == nil {
+		cache = new(fontCache)
+	}
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if cache.font == nil || !sameAssets(cache.assets, c.Assets) {
+		f, err := readTextFont(c.Assets)
+		if err != nil {
+			return nil, err
+		}
+		cache.assets, cache.font = c.Assets, f
+	}
+	return newTextFont(cache.font), nil
+}
+
+func readTextFont(fsys fs.FS) (*opentype.Font, error) {
+	data, err := fs.ReadFile(fsys, assets.TextFontPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return defaultTextFont()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading text font: %w", err)
+	}
+	f, err := opentype.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", assets.TextFontPath, err)
+	}
+	return f, nil
+}
+
+// sameAssets reports whether a and b are the same file system. File
+// systems that are maps, such as fstest.MapFS, are compared by identity.
+func sameAssets(a, b fs.FS) bool {
+	t := reflect.TypeOf(a)
+	if t != reflect.TypeOf(b) {
+		return false
+	}
+	switch {
+	case t.Comparable():
+		return a == b
+	case t.Kind() == reflect.Map:
+		return reflect.ValueOf(a).UnsafePointer() == reflect.ValueOf(b).UnsafePointer()
+	}
+	return false
+}
+
+func newTextFont(f *opentype.Font) *textFont {
+	return &textFont{font: f, faces: make(map[int]font.Face)}
+}
+
+// face returns the face of the given pixel size.
+func (t *textFont) face(size int) (font.Face, error) {
+	if f, ok := t.faces[size]; ok {
+		return f, nil
+	}
+	f, err := opentype.NewFace(t.font, &opentype.FaceOptions{
+		Size:    float64(size),
+		DPI:     72,
+		Hinting: font.HintingNone,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("text font at %d pixels: %w", size, err)
+	}
+	t.faces[size] = f
+	return f, nil
+}
+
+// clone returns a textFont sharing t's font, which is safe for concurrent
+// use, with faces of its own.
+func (t *textFont) clone() *textFont {
+	return newTextFont(t.font)
+}
+
+// covers reports whether the font has a glyph for every character of s.
+func (t *textFont) covers(s string) bool {
+	for _, ch := range s {
+		if i, err := t.font.GlyphIndex(&t.buf, ch); err != nil || i == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// face returns the text face of the given pixel size. If it cannot be
+// made, the error is kept for the render to return and face returns nil,
+// so the caller leaves the text out.
+func (r *Renderer) face(size int) font.Face {
+	f, err := r.text.face(size)
+	if err != nil {
+		r.text.err = cmp.Or(r.text.err, err)
+		return nil
+	}
+	return f
+}
+
+// fit returns the face of the largest size up to maxH pixels at which s is
+// at most maxW by maxH pixels, or of minTextSize if none is, with the
+// bounds of s at that size, or a nil face as [Renderer.face].
+func (r *Renderer) fit(s string, maxW, maxH int) (font.Face, fixed.Rectangle26_6) {
+	for size := max(maxH, minTextSize); ; size-- {
+		face := r.face(size)
+		if face == nil {
+			return nil, fixed.Rectangle26_6{}
+		}
+		bounds, _ := font.BoundString(face, s)
+		w, h := (bounds.Max.X - bounds.Min.X).Ceil(), (bounds.Max.Y - bounds.Min.Y).Ceil()
+		if w <= maxW && h <= maxH || size == minTextSize {
+			return face, bounds
+		}
+	}
+}
+
+// drawText draws s as large as fits in a box of maxW by maxH pixels
+// centered at (cx, cy), antialiased over the image.
+func (r *Renderer) drawText(img *image.RGBA, cx, cy, maxW, maxH int, s string, c color.RGBA) {
+	face, bounds := r.fit(s, maxW, maxH)
+	if face == nil {
+		return
+	}
+	// Center the ink, not the advance, so that symbols such as "." sit in
+	// the middle of the room.
+	mid := fixed.Point26_6{
+		X: (bounds.Min.X + bounds.Max.X) / 2,
+		Y: (bounds.Min.Y + bounds.Max.Y) / 2,
+	}
+	d := font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(c),
+		Face: face,
+		Dot:  fixed.P(cx, cy).Sub(mid),
+	}
+	d.DrawString(s)
+}
+
+// drawString draws s at size pixels, centered horizontally on cx and with
+// its capital letters centered vertically on cy, so that a row of numbers
+// or captions lines up with the marks next to it.
+func (r *Renderer) drawString(img *image.RGBA, cx, cy, size int, s string, c color.RGBA) {
+	face := r.face(size)
+	if face == nil {
+		return
+	}
+	d := font.Drawer{Dst: img, Src: image.NewUniform(c), Face: face}
+	d.Dot = fixed.P(cx, cy+face.Metrics().CapHeight.Ceil()/2).Sub(fixed.Point26_6{X: d.MeasureString(s) / 2})
+	d.DrawString(s)
+}
+
+// stringWidth returns the width in pixels of s drawn at size pixels.
+func (r *Renderer) stringWidth(size int, s string) int {
+	face := r.face(size)
+	if face == nil {
+		return 0
+	}
+	return font.MeasureString(face, s).Ceil()
+}
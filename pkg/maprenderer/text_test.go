@@ -0,0 +1,86 @@
+package maprenderer
+
+import (
+	"image"
+	"testing"
+	"testing/fstest"
+
+	"golang.org/x/image/font/gofont/goregular"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/assets"
+)
+
+// inkBounds returns the bounds of the pixels of a inside rect that differ
+// from b.
+func inkBounds(a, b *image.RGBA, rect image.Rectangle) image.Rectangle {
+	var ink image.Rectangle
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			if a.RGBAAt(x, y) != b.RGBAAt(x, y) {
+				ink = ink.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return ink
+}
+
+func TestRoomSymbolText(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 100, 100
+	cfg.RoomSize, cfg.RoomSpacing = 40, 50
+	r := NewRenderer(cfg)
+	m := testGridMap(1)
+	r.SetMap(m)
+	plain, err := r.RenderFragment(1)
+	if err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+
+	room := image.Rect(30, 30, 70, 70)
+	widths := make(map[string]int)
+	for _, symbol := range []string{"I", "Żół", "\U0001F600"} {
+		m.Rooms[1].Symbol = symbol
+		res, err := r.RenderFragment(1)
+		if err != nil {
+			t.Fatalf("RenderFragment failed: %v", err)
+		}
+		ink := inkBounds(res.Image, plain.Image, image.Rect(0, 0, 100, 100))
+		if ink.Empty() || !ink.In(room.Inset(2)) {
+			t.Errorf("symbol %q: ink %v, want inside the room %v", symbol, ink, room)
+		}
+		widths[symbol] = ink.Dx()
+	}
+	if widths["Żół"] <= 2*widths["I"] {
+		t.Errorf("Expected all characters of a multi-character symbol drawn, widths %v", widths)
+	}
+	// Go Regular has no emoji: a generic square is drawn instead
+	if w := widths["\U0001F600"]; w != cfg.RoomSize/4 {
+		t.Errorf("Expected a %d pixel square for a missing glyph, got %d", cfg.RoomSize/4, w)
+	}
+}
+
+func TestTextFontOverride(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 60, 60
+	cfg.Assets = fstest.MapFS{assets.TextFontPath: {Data: goregular.TTF}}
+	r := NewRenderer(cfg)
+	r.SetMap(testGridMap(1))
+	if _, err := r.RenderFragment(1); err != nil {
+		t.Fatalf("Render with text font override failed: %v", err)
+	}
+	parsed := r.fonts.font
+	if def, _ := defaultTextFont(); parsed == nil || parsed == def {
+		t.Fatal("Expected the text font parsed from the assets")
+	}
+	if _, err := r.RenderFragment(1); err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+	if r.fonts.font != parsed {
+		t.Error("Expected the parsed text font reused for the same assets")
+	}
+
+	cfg.Assets = fstest.MapFS{assets.TextFontPath: {Data: []byte("not a font")}}
+	if _, err := r.RenderFragment(1); err == nil {
+		t.Error("Expected error for malformed text font")
+	}
+}
@@ -0,0 +1,174 @@
+package maprenderer
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io/fs"
+	"maps"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/assets"
+)
+
+// Theme bundles the colors and styles of a look for [Config.SetTheme].
+// Zero fields leave the configuration unchanged, so a theme loaded with
+// [Config.LoadTheme] can be adjusted field by field before it is applied.
+type Theme struct {
+	Background color.RGBA
+	Border     color.RGBA
+	PlayerRoom color.RGBA
+	Text       color.RGBA
+	Exit       color.RGBA
+	Path       color.RGBA
+	Grid       color.RGBA
+	Axis       color.RGBA
+	// EnvColors replaces the default colors of these environments, e.g.
+	// with a palette colorblind viewers can tell apart.
+	EnvColors map[int32]color.RGBA
+	// ExitWidth is the exit line width in pixels.
+	ExitWidth float64
+}
+
+// themeFile is the JSON layout of a theme asset. Colors are "#rrggbb" or
+// "#rrggbbaa"; missing keys leave the configuration unchanged.
+type themeFile struct {
+	Background   string           `json:"background"`
+	Border       string           `json:"border"`
+	PlayerRoom   string           `json:"playerRoom"`
+	Text         string           `json:"text"`
+	Exit         string           `json:"exit"`
+	Path         string           `json:"path"`
+	Grid         string           `json:"grid"`
+	Axis         string           `json:"axis"`
+	Environments map[int32]string `json:"environments"`
+	ExitWidth    float64          `json:"exitWidth"`
+}
+
+// ApplyTheme sets the colors and styles of the named theme, as
+// [Config.LoadTheme] and [Config.SetTheme] do.
+func (c *Config) ApplyTheme(name string) error {
+	t, err := c.LoadTheme(name)
+	if err != nil {
+		return err
+	}
+	c.SetTheme(t)
+	return nil
+}
+
+// LoadTheme reads the named theme from themes/<name>.json in the
+// configured assets. The embedded assets provide "dark" (the default
+// colors, as in Mudlet), "light", "high-contrast", "colorblind" (an
+// Okabe-Ito environment palette) and "print" (black on white).
+func (c *Config) LoadTheme(name string) (*Theme, error) {
+	fsys := c.Assets
+	if fsys == nil {
+		fsys = assets.Default
+	}
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return nil, fmt.Errorf("invalid theme name %q", name)
+	}
+	data, err := fs.ReadFile(fsys, path.Join(assets.ThemeDir, name+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("theme %q: %w", name, err)
+	}
+	var f themeFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("theme %q: %w", name, err)
+	}
+	t := &Theme{ExitWidth: f.ExitWidth}
+	for _, field := range []struct {
+		value string
+		dst   *color.RGBA
+	}{
+		{f.Background, &t.Background},
+		{f.Border, &t.Border},
+		{f.PlayerRoom, &t.PlayerRoom},
+		{f.Text, &t.Text},
+		{f.Exit, &t.Exit},
+		{f.Path, &t.Path},
+		{f.Grid, &t.Grid},
+		{f.Axis, &t.Axis},
+	} {
+		if field.value == "" {
+			continue
+		}
+		if *field.dst, err = parseHexColor(field.value); err != nil {
+			return nil, fmt.Errorf("theme %q: %w", name, err)
+		}
+	}
+	if len(f.Environments) > 0 {
+		t.EnvColors = make(map[int32]color.RGBA, len(f.Environments))
+		for env, value := range f.Environments {
+			if t.EnvColors[env], err = parseHexColor(value); err != nil {
+				return nil, fmt.Errorf("theme %q: environment %d: %w", name, env, err)
+			}
+		}
+	}
+	return t, nil
+}
+
+// SetTheme sets the non-zero colors and styles of t.
+func (c *Config) SetTheme(t *Theme) {
+	for _, field := range []struct {
+		value color.RGBA
+		dst   *color.RGBA
+	}{
+		{t.Background, &c.BackgroundColor},
+		{t.Border, &c.BorderColor},
+		{t.PlayerRoom, &c.PlayerRoomColor},
+		{t.Text, &c.TextColor},
+		{t.Exit, &c.ExitColor},
+		{t.Path, &c.PathColor},
+		{t.Grid, &c.GridColor},
+		{t.Axis, &c.AxisColor},
+	} {
+		if field.value != (color.RGBA{}) {
+			*field.dst = field.value
+		}
+	}
+	if len(t.EnvColors) > 0 {
+		// The map may be shared with other configurations
+		envColors := maps.Clone(c.DefaultEnvColors)
+		if envColors == nil {
+			envColors = make(map[int32]color.RGBA, len(t.EnvColors))
+		}
+		maps.Copy(envColors, t.EnvColors)
+		c.DefaultEnvColors = envColors
+	}
+	if t.ExitWidth > 0 {
+		c.ExitWidth = t.ExitWidth
+	}
+}
+
+// ParseColor parses a color written as in themes, "#rrggbb" or
+// "#rrggbbaa".
+func ParseColor(s string) (color.RGBA, error) {
+	return parseHexColor(s)
+}
+
+// parseHexColor parses "#rrggbb" or "#rrggbbaa".
+func parseHexColor(s string) (color.RGBA, error) {
+	hex, ok := strings.CutPrefix(s, "#")
+	if !ok || len(hex) != 6 && len(hex) != 8 {
+		return color.RGBA{}, fmt.Errorf("invalid color %q", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid color %q", s)
+	}
+	if len(hex) == 6 {
+		v = v<<8 | 0xFF
+	}
+	return color.RGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
+
+// formatHexColor writes c as "#rrggbb", or "#rrggbbaa" if translucent.
+func formatHexColor(c color.RGBA) string {
+	if c.A == 255 {
+		return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+	}
+	return fmt.Sprintf("#%02x%02x%02x%02x", c.R, c.G, c.B, c.A)
+}
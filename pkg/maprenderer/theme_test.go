@@ -0,0 +1,104 @@
+package maprenderer
+
+import (
+	"bytes"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/assets"
+)
+
+func TestApplyTheme(t *testing.T) {
+	cfg := DefaultConfig()
+	if err := cfg.ApplyTheme("dark"); err != nil {
+		t.Fatalf("ApplyTheme failed: %v", err)
+	}
+	def := DefaultConfig()
+	if cfg.BackgroundColor != def.BackgroundColor || cfg.PlayerRoomColor != def.PlayerRoomColor ||
+		cfg.GridColor != def.GridColor || cfg.ExitColor != def.ExitColor {
+		t.Error("Expected the dark theme to match the default colors")
+	}
+
+	if err := cfg.ApplyTheme("light"); err != nil {
+		t.Fatalf("ApplyTheme failed: %v", err)
+	}
+	if cfg.BackgroundColor == def.BackgroundColor {
+		t.Error("Expected light background")
+	}
+
+	for _, name := range []string{"missing", "../dark", ""} {
+		if err := cfg.ApplyTheme(name); err == nil {
+			t.Errorf("Expected error for theme %q", name)
+		}
+	}
+
+	c, err := parseHexColor("#10203040")
+	if err != nil || c != (color.RGBA{R: 0x10, G: 0x20, B: 0x30, A: 0x40}) {
+		t.Errorf("Unexpected color %v, %v", c, err)
+	}
+	if _, err := parseHexColor("102030"); err == nil {
+		t.Error("Expected error for color without #")
+	}
+}
+
+func TestThemes(t *testing.T) {
+	names, err := assets.ThemeNames(assets.Default)
+	if err != nil {
+		t.Fatalf("ThemeNames failed: %v", err)
+	}
+	def := DefaultConfig()
+	for _, name := range names {
+		theme, err := def.LoadTheme(name)
+		if err != nil {
+			t.Errorf("LoadTheme(%q) failed: %v", name, err)
+			continue
+		}
+		if name == "dark" && (theme.Path != def.PathColor || theme.Background != def.BackgroundColor) {
+			t.Error("Expected the dark theme to match the default colors")
+		}
+	}
+
+	// Fields are overridden one by one before the theme is applied
+	theme, err := def.LoadTheme("print")
+	if err != nil {
+		t.Fatalf("LoadTheme failed: %v", err)
+	}
+	theme.Path = color.RGBA{R: 255, A: 255}
+	cfg := DefaultConfig()
+	cfg.SetTheme(theme)
+	if cfg.BackgroundColor != (color.RGBA{255, 255, 255, 255}) || cfg.PathColor != theme.Path || cfg.ExitWidth != 1.5 {
+		t.Errorf("Unexpected print colors %v, %v and exit width %v", cfg.BackgroundColor, cfg.PathColor, cfg.ExitWidth)
+	}
+
+	// Environment colors replace the defaults without changing a map
+	// shared with another configuration
+	cfg = DefaultConfig()
+	shared := cfg.DefaultEnvColors
+	if err := cfg.ApplyTheme("colorblind"); err != nil {
+		t.Fatalf("ApplyTheme failed: %v", err)
+	}
+	if cfg.DefaultEnvColors[1] != (color.RGBA{0xd5, 0x5e, 0x00, 0xff}) || cfg.DefaultEnvColors[7] != shared[7] {
+		t.Errorf("Unexpected colorblind environment colors %v and %v", cfg.DefaultEnvColors[1], cfg.DefaultEnvColors[7])
+	}
+	if shared[1] == cfg.DefaultEnvColors[1] {
+		t.Error("Expected the shared environment colors unchanged")
+	}
+	if cfg.BackgroundColor != def.BackgroundColor {
+		t.Error("Expected colors missing from the theme unchanged")
+	}
+}
+
+func TestWriteViewer(t *testing.T) {
+	var buf bytes.Buffer
+	page := ViewerPage{Title: "<Town>", Image: "map.webp", Width: 800, Height: 600}
+	if err := WriteViewer(&buf, nil, page); err != nil {
+		t.Fatalf("WriteViewer failed: %v", err)
+	}
+	html := buf.String()
+	for _, want := range []string{"&lt;Town&gt;", `src="map.webp"`, "#map-image", "pointerdown"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("Expected page to contain %q", want)
+		}
+	}
+}
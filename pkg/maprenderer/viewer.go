@@ -0,0 +1,59 @@
+package maprenderer
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/assets"
+)
+
+// ViewerPage describes the HTML page written by [WriteViewer].
+type ViewerPage struct {
+	Title       string
+	Subtitle    string
+	Attribution string
+	// Image is the URL of the rendered image, usually its file name so the
+	// page and the image can be moved together.
+	Image         string
+	Width, Height int
+}
+
+// WriteViewer writes a self-contained HTML page showing the image with pan
+// and zoom, using the viewer template, style sheet and script from fsys (nil
+// for the embedded assets). The style sheet and script are inlined, so the
+// page works offline.
+func WriteViewer(w io.Writer, fsys fs.FS, page ViewerPage) error {
+	if fsys == nil {
+		fsys = assets.Default
+	}
+	read := func(name string) (string, error) {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return "", fmt.Errorf("reading viewer asset: %w", err)
+		}
+		return string(data), nil
+	}
+	src, err := read(assets.ViewerPage)
+	if err != nil {
+		return err
+	}
+	style, err := read(assets.ViewerStyle)
+	if err != nil {
+		return err
+	}
+	script, err := read(assets.ViewerScript)
+	if err != nil {
+		return err
+	}
+	tmpl, err := template.New("viewer").Parse(src)
+	if err != nil {
+		return fmt.Errorf("parsing %s: %w", assets.ViewerPage, err)
+	}
+	return tmpl.Execute(w, struct {
+		ViewerPage
+		Style  template.CSS
+		Script template.JS
+	}{page, template.CSS(style), template.JS(script)})
+}
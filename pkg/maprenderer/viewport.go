@@ -0,0 +1,49 @@
+package maprenderer
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// MapRect is a window of map coordinates, with Y growing northward as in
+// Mudlet. Its edges may fall between rooms.
+type MapRect struct {
+	MinX, MinY, MaxX, MaxY float64
+}
+
+// RenderViewport renders the window rect of an area's level z into an
+// image of the configured Width and Height, independent of any center
+// room, for panning UIs and precise crops. The window's center is drawn at
+// the center of the image, at the largest whole room spacing at which the
+// whole window fits; RoomSize keeps its ratio to RoomSpacing, after
+// Config.Zoom. A window of another aspect ratio than the image shows more
+// of the map on the longer side. The result's CenterX and CenterY are the
+// room coordinates nearest the window's center, and its Offset where they
+// are drawn, so that [Renderer.RoomAt] applies.
+func (r *Renderer) RenderViewport(areaID, z int32, rect MapRect) (*RenderResult, error) {
+	if r.mapData == nil {
+		return nil, fmt.Errorf("no map data loaded")
+	}
+	if r.mapData.GetArea(areaID) == nil {
+		return nil, fmt.Errorf("area %d not found", areaID)
+	}
+	if !(rect.MaxX > rect.MinX && rect.MaxY > rect.MinY) {
+		return nil, fmt.Errorf("empty viewport %+v", rect)
+	}
+
+	cfg, textScale := r.config.zoomed()
+	scale := math.Min(float64(cfg.Width)/(rect.MaxX-rect.MinX), float64(cfg.Height)/(rect.MaxY-rect.MinY))
+	spacing := max(int(scale), 1)
+	cfg.RoomSize = max(cfg.RoomSize*spacing/max(cfg.RoomSpacing, 1), 1)
+	cfg.RoomSpacing = spacing
+
+	// Draw the nearest room coordinates off the image center by the rest
+	midX, midY := (rect.MinX+rect.MaxX)/2, (rect.MinY+rect.MaxY)/2
+	centerX, centerY := math.Round(midX), math.Round(midY)
+	shift := image.Pt(int(math.Round((centerX-midX)*float64(spacing))), int(math.Round((midY-centerY)*float64(spacing))))
+
+	w := *r
+	w.config, w.textScale, w.shift = cfg, textScale, shift
+	return w.render(areaID, int32(centerX), int32(centerY), z, false)
+}
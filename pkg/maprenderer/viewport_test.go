@@ -0,0 +1,51 @@
+package maprenderer
+
+import (
+	"image"
+	"testing"
+)
+
+func TestRenderViewport(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 300, 300
+	r := NewRenderer(cfg)
+	r.SetMap(testGridMap(3))
+
+	// The whole 3x3 grid with half a room around it, 100 pixels a room
+	result, err := r.RenderViewport(1, 0, MapRect{MinX: -0.5, MinY: -0.5, MaxX: 2.5, MaxY: 2.5})
+	if err != nil {
+		t.Fatalf("RenderViewport failed: %v", err)
+	}
+	if result.RoomSpacing != 100 || result.RoomSize != 80 || result.RoomsDrawn != 9 || result.Offset != (image.Point{}) {
+		t.Errorf("Unexpected spacing %d, size %d, rooms %d or offset %v",
+			result.RoomSpacing, result.RoomSize, result.RoomsDrawn, result.Offset)
+	}
+	if room := r.RoomAt(result, 50, 250); room == nil || room.ID != 1 {
+		t.Errorf("Expected room 1 at (50, 250), got %v", room)
+	}
+
+	// A window between room centers: rooms 1 and 5 in opposite corners
+	cfg.Width, cfg.Height = 200, 200
+	result, err = r.RenderViewport(1, 0, MapRect{MinX: 0, MinY: 0, MaxX: 1, MaxY: 1})
+	if err != nil {
+		t.Fatalf("RenderViewport failed: %v", err)
+	}
+	if result.RoomSpacing != 200 || result.Offset != image.Pt(100, -100) {
+		t.Errorf("Unexpected spacing %d or offset %v", result.RoomSpacing, result.Offset)
+	}
+	for _, tc := range []struct {
+		x, y int
+		id   int32
+	}{{5, 195, 1}, {195, 5, 5}, {5, 5, 4}} {
+		if room := r.RoomAt(result, tc.x, tc.y); room == nil || room.ID != tc.id {
+			t.Errorf("Expected room %d at (%d, %d), got %v", tc.id, tc.x, tc.y, room)
+		}
+		if got, want := result.Image.RGBAAt(tc.x, tc.y), r.getEnvColor(1, nil); got != want {
+			t.Errorf("Expected the room drawn at (%d, %d), got %v", tc.x, tc.y, got)
+		}
+	}
+
+	if _, err := r.RenderViewport(1, 0, MapRect{MinX: 1, MaxX: 1, MaxY: 1}); err == nil {
+		t.Error("Expected an error for an empty viewport")
+	}
+}
@@ -0,0 +1,143 @@
+package maprenderer
+
+import (
+	"fmt"
+	"image/color"
+	"slices"
+
+	"github.com/szydell/mudlet-mapsnap/pkg/mapparser"
+)
+
+// WeightHeatmap is what [Config.WeightHeatmap] colors by pathfinding
+// weight.
+type WeightHeatmap int
+
+const (
+	// HeatmapOff draws exits and rooms in their usual colors (default).
+	HeatmapOff WeightHeatmap = iota
+	// HeatmapExits colors exit lines, special exits and custom lines by
+	// the weight of taking the exit; a line for exits both ways has the
+	// higher of their weights.
+	HeatmapExits
+	// HeatmapRooms fills rooms by their own weight instead of their
+	// environment color.
+	HeatmapRooms
+)
+
+var weightHeatmaps = []string{"off", "exits", "rooms"}
+
+// String returns the heatmap's name, as accepted by [ParseWeightHeatmap].
+func (h WeightHeatmap) String() string {
+	if h >= 0 && int(h) < len(weightHeatmaps) {
+		return weightHeatmaps[h]
+	}
+	return fmt.Sprintf("WeightHeatmap(%d)", int(h))
+}
+
+// MarshalText encodes the heatmap as its name.
+func (h WeightHeatmap) MarshalText() ([]byte, error) {
+	return []byte(h.String()), nil
+}
+
+// UnmarshalText decodes a heatmap name, as [ParseWeightHeatmap].
+func (h *WeightHeatmap) UnmarshalText(text []byte) error {
+	v, err := ParseWeightHeatmap(string(text))
+	if err != nil {
+		return err
+	}
+	*h = v
+	return nil
+}
+
+// ParseWeightHeatmap returns the heatmap named by s, as returned by
+// [WeightHeatmap.String].
+func ParseWeightHeatmap(s string) (WeightHeatmap, error) {
+	if i := slices.Index(weightHeatmaps, s); i >= 0 {
+		return WeightHeatmap(i), nil
+	}
+	return 0, fmt.Errorf("unknown weight heatmap %q (want off, exits or rooms)", s)
+}
+
+// defaultWeightGradient runs from green for weight 1 through yellow to red
+// for the highest weight, as the terrain cost outlines.
+var defaultWeightGradient = []color.RGBA{
+	{R: 0, G: 220, B: 0, A: 255},
+	{R: 255, G: 220, B: 0, A: 255},
+	{R: 255, G: 0, B: 0, A: 255},
+}
+
+// viewWeightMax returns the weight Config.WeightHeatmap colors with the
+// last gradient color: Config.WeightMax if set, otherwise the highest
+// weight among rooms and their exits.
+func (r *Renderer) viewWeightMax(rooms []*mapparser.MudletRoom) int32 {
+	if r.config.WeightMax > 0 {
+		return r.config.WeightMax
+	}
+	highest := int32(1)
+	for _, room := range rooms {
+		if r.config.WeightHeatmap == HeatmapRooms {
+			highest = max32(highest, room.Weight)
+			continue
+		}
+		for _, n := range room.Neighbors(r.mapData) {
+			highest = max32(highest, room.MoveWeight(n.Key(), n.Room))
+		}
+	}
+	return highest
+}
+
+// weightColor returns the gradient color of weight w, placing weight 1 at
+// the first color of Config.WeightGradient and the view's highest weight
+// at the last, blending in between.
+func (r *Renderer) weightColor(w int32) color.RGBA {
+	gradient := r.config.WeightGradient
+	if len(gradient) == 0 {
+		gradient = defaultWeightGradient
+	}
+	if len(gradient) == 1 || r.weightMax <= 1 {
+		return gradient[0]
+	}
+	t := float64(min(max32(w, 1), r.weightMax)-1) / float64(r.weightMax-1)
+	pos := t * float64(len(gradient)-1)
+	i := min(int(pos), len(gradient)-2)
+	return lerpColor(gradient[i], gradient[i+1], pos-float64(i))
+}
+
+// lerpColor blends from a (t = 0) to b (t = 1).
+func lerpColor(a, b color.RGBA, t float64) color.RGBA {
+	mix := func(x, y uint8) uint8 {
+		return uint8(float64(x) + (float64(y)-float64(x))*t + 0.5)
+	}
+	return color.RGBA{R: mix(a.R, b.R), G: mix(a.G, b.G), B: mix(a.B, b.B), A: mix(a.A, b.A)}
+}
+
+// exitWeightColor returns c, or under HeatmapExits the gradient color of
+// the exit of room stored under key leading to dest. With both set, a
+// line drawn once for the exits both ways between room and dest takes the
+// higher of the weights of the compass exits of dest leading back.
+func (r *Renderer) exitWeightColor(c color.RGBA, room, dest *mapparser.MudletRoom, key string, both bool) color.RGBA {
+	if r.config.WeightHeatmap != HeatmapExits || dest == nil {
+		return c
+	}
+	w := room.MoveWeight(key, dest)
+	if both {
+		for dir := mapparser.ExitNorth; dir <= mapparser.ExitNorthwest; dir++ {
+			if dest.Exits[dir] == room.ID {
+				w = max32(w, dest.MoveWeight(mapparser.ExitDirectionShortNames[dir], room))
+			}
+		}
+	}
+	return r.weightColor(w)
+}
+
+// exitDestination returns the room the exit of room stored under key, a
+// short direction name or special exit command, leads to, or nil.
+func (r *Renderer) exitDestination(room *mapparser.MudletRoom, key string) *mapparser.MudletRoom {
+	if dir := slices.Index(mapparser.ExitDirectionShortNames[:], key); dir >= 0 {
+		return r.mapData.GetRoom(room.Exits[dir])
+	}
+	if dest, ok := room.SpecialExits[key]; ok {
+		return r.mapData.GetRoom(dest)
+	}
+	return nil
+}
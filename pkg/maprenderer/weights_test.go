@@ -0,0 +1,60 @@
+package maprenderer
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestWeightHeatmap(t *testing.T) {
+	m := testGridMap(3)
+	// Rooms 2 and 3 are drawn at (100, 125) and (125, 125), room 1 left
+	// of room 2
+	m.Rooms[2].ExitWeights["e"] = 10
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 200, 200
+	cfg.WeightHeatmap = HeatmapExits
+	r := NewRenderer(cfg)
+	r.SetMap(m)
+	result, err := r.RenderAt(1, 1, 1, 0)
+	if err != nil {
+		t.Fatalf("RenderAt failed: %v", err)
+	}
+	green, red := defaultWeightGradient[0], defaultWeightGradient[2]
+	if got := result.Image.RGBAAt(112, 125); got != red {
+		t.Errorf("Expected the heaviest exit in %v, got %v", red, got)
+	}
+	if got := result.Image.RGBAAt(87, 125); got != green {
+		t.Errorf("Expected a weight 1 exit in %v, got %v", green, got)
+	}
+
+	cfg.WeightHeatmap = HeatmapRooms
+	cfg.WeightGradient = []color.RGBA{{B: 255, A: 255}, {R: 255, A: 255}}
+	m.Rooms[2].Weight = 3
+	m.Rooms[5].Weight = 5
+	if result, err = r.RenderAt(1, 1, 1, 0); err != nil {
+		t.Fatalf("RenderAt failed: %v", err)
+	}
+	for _, tc := range []struct {
+		x, y int
+		want color.RGBA
+	}{
+		{97, 97, color.RGBA{R: 255, A: 255}},
+		{97, 122, color.RGBA{R: 128, B: 128, A: 255}},
+		{72, 122, color.RGBA{B: 255, A: 255}},
+	} {
+		if got := result.Image.RGBAAt(tc.x, tc.y); got != tc.want {
+			t.Errorf("Expected %v at (%d, %d), got %v", tc.want, tc.x, tc.y, got)
+		}
+	}
+}
+
+func TestParseWeightHeatmap(t *testing.T) {
+	for _, h := range []WeightHeatmap{HeatmapOff, HeatmapExits, HeatmapRooms} {
+		if got, err := ParseWeightHeatmap(h.String()); err != nil || got != h {
+			t.Errorf("ParseWeightHeatmap(%q) = %v, %v", h, got, err)
+		}
+	}
+	if _, err := ParseWeightHeatmap("doors"); err == nil {
+		t.Error("Expected an error for an unknown heatmap")
+	}
+}